@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// ProtocolVersion matches what the Director currently sends
+const ProtocolVersion = 3
+
+// readOnlyActions can be run without explicitly allowing mutations;
+// server rejects other actions unless request is marked as mutating.
+var readOnlyActions = map[string]bool{
+	"get_state":  true,
+	"get_task":   true,
+	"info":       true,
+	"list_disk":  true,
+	"list_tasks": true,
+	"ping":       true,
+}
+
+func IsReadOnly(method string) bool {
+	return readOnlyActions[method]
+}
+
+type Response struct {
+	Value     json.RawMessage    `json:"value,omitempty"`
+	Exception *ResponseException `json:"exception,omitempty"`
+}
+
+type ResponseException struct {
+	Message string `json:"message"`
+}
+
+// TaskState returns state of asynchronous action, if response is one.
+func (r Response) TaskState() (boshtask.StateValue, bool) {
+	var state boshtask.StateValue
+
+	if r.Exception != nil || len(r.Value) == 0 {
+		return state, false
+	}
+
+	if json.Unmarshal(r.Value, &state) != nil || state.AgentTaskID == "" {
+		return state, false
+	}
+
+	return state, state.State == boshtask.StateRunning
+}
+
+type Client struct {
+	socketPath string
+}
+
+func NewClient(socketPath string) Client {
+	return Client{socketPath: socketPath}
+}
+
+// Call runs an action; mutating has to be set for actions which are not read-only.
+func (c Client) Call(method string, arguments []interface{}, mutating bool) (Response, error) {
+	var resp Response
+
+	if arguments == nil {
+		arguments = []interface{}{}
+	}
+
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return resp, bosherr.WrapErrorf(err, "Connecting to %s", c.socketPath)
+	}
+	defer conn.Close()
+
+	req := map[string]interface{}{
+		"protocol":  ProtocolVersion,
+		"method":    method,
+		"arguments": arguments,
+		"reply_to":  fmt.Sprintf("ctl.%d", os.Getpid()),
+		"mutating":  mutating,
+	}
+
+	err = json.NewEncoder(conn).Encode(req)
+	if err != nil {
+		return resp, bosherr.WrapError(err, "Sending request")
+	}
+
+	// Server reads request until EOF
+	err = conn.(*net.UnixConn).CloseWrite()
+	if err != nil {
+		return resp, bosherr.WrapError(err, "Finishing request")
+	}
+
+	err = json.NewDecoder(conn).Decode(&resp)
+	if err != nil {
+		return resp, bosherr.WrapError(err, "Reading response")
+	}
+
+	return resp, nil
+}
+
+// Wait polls get_task until task started by resp is no longer running.
+// Responses of synchronous actions are returned as they are.
+func (c Client) Wait(resp Response, interval time.Duration) (Response, error) {
+	for {
+		state, running := resp.TaskState()
+		if !running {
+			return resp, nil
+		}
+
+		time.Sleep(interval)
+
+		var err error
+		resp, err = c.Call("get_task", []interface{}{state.AgentTaskID}, false)
+		if err != nil {
+			return resp, bosherr.WrapErrorf(err, "Getting task %s", state.AgentTaskID)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package admin_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/admin"
+)
+
+var _ = Describe("IsReadOnly", func() {
+	It("allows actions which only report state", func() {
+		for _, method := range []string{"get_state", "get_task", "info", "list_disk", "list_tasks", "ping"} {
+			Expect(admin.IsReadOnly(method)).To(BeTrue(), method)
+		}
+	})
+
+	It("does not allow actions which change the VM", func() {
+		for _, method := range []string{"apply", "stop", "run_script", "update_settings", "unknown"} {
+			Expect(admin.IsReadOnly(method)).To(BeFalse(), method)
+		}
+	})
+})
+
+var _ = Describe("Response", func() {
+	Describe("TaskState", func() {
+		It("reports running tasks", func() {
+			resp := admin.Response{Value: []byte(`{"agent_task_id":"fake-task-id","state":"running"}`)}
+
+			state, running := resp.TaskState()
+			Expect(running).To(BeTrue())
+			Expect(state.AgentTaskID).To(Equal("fake-task-id"))
+		})
+
+		It("does not report values of finished actions", func() {
+			for _, value := range []string{`"fake-result"`, `{"agent_task_id":"fake-task-id","state":"done"}`, `{"job_state":"running"}`} {
+				resp := admin.Response{Value: []byte(value)}
+				_, running := resp.TaskState()
+				Expect(running).To(BeFalse(), value)
+			}
+		})
+
+		It("does not report exceptions", func() {
+			resp := admin.Response{Exception: &admin.ResponseException{Message: "fake-error"}}
+			_, running := resp.TaskState()
+			Expect(running).To(BeFalse())
+		})
+	})
+})
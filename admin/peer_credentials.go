@@ -0,0 +1,6 @@
+package admin
+
+type peerCredentials struct {
+	UID int
+	PID int
+}
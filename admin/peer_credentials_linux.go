@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+const peerCredentialsSupported = true
+
+func getPeerCredentials(conn *net.UnixConn) (peerCredentials, error) {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return peerCredentials{}, err
+	}
+
+	var ucred *unix.Ucred
+	var ucredErr error
+
+	err = rawConn.Control(func(fd uintptr) {
+		ucred, ucredErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return peerCredentials{}, err
+	}
+
+	if ucredErr != nil {
+		return peerCredentials{}, ucredErr
+	}
+
+	return peerCredentials{UID: int(ucred.Uid), PID: int(ucred.Pid)}, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package admin
+
+import (
+	"errors"
+	"net"
+)
+
+const peerCredentialsSupported = false
+
+func getPeerCredentials(_ *net.UnixConn) (peerCredentials, error) {
+	return peerCredentials{}, errors.New("peer credentials are not supported")
+}
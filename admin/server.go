@@ -0,0 +1,192 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	serverLogTag = "AdminServer"
+
+	// Requests are as small as the ones received over NATS
+	maxRequestBytes = 1024 * 1024
+
+	requestReadTimeout = 30 * time.Second
+)
+
+// mutationRequest holds fields checked before request is handled
+type mutationRequest struct {
+	Method   string `json:"method"`
+	Mutating bool   `json:"mutating"`
+}
+
+// Server accepts boshhandler.Request JSON over a Unix socket that only
+// root (or whoever runs the agent) can use. Each connection carries a
+// single request which is terminated by closing the write side.
+// Requests for actions which are not read-only have to be marked as
+// mutating by the client.
+type Server struct {
+	socketPath  string
+	handlerFunc boshhandler.Func
+	auditLogger boshplatform.AuditLogger
+	logger      boshlog.Logger
+
+	listener net.Listener
+}
+
+func NewServer(
+	socketPath string,
+	handlerFunc boshhandler.Func,
+	auditLogger boshplatform.AuditLogger,
+	logger boshlog.Logger,
+) *Server {
+	return &Server{
+		socketPath:  socketPath,
+		handlerFunc: handlerFunc,
+		auditLogger: auditLogger,
+		logger:      logger,
+	}
+}
+
+// Start begins accepting requests in the background.
+func (s *Server) Start() error {
+	if !peerCredentialsSupported {
+		s.logger.Info(serverLogTag, "Admin socket is not supported on this platform")
+		return nil
+	}
+
+	// Socket of a previous agent run is left behind after a crash
+	err := os.Remove(s.socketPath)
+	if err != nil && !os.IsNotExist(err) {
+		return bosherr.WrapErrorf(err, "Removing stale socket %s", s.socketPath)
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listening on %s", s.socketPath)
+	}
+
+	err = os.Chmod(s.socketPath, 0600)
+	if err != nil {
+		_ = listener.Close()
+		return bosherr.WrapErrorf(err, "Restricting access to %s", s.socketPath)
+	}
+
+	s.listener = listener
+
+	go func() {
+		defer s.logger.HandlePanic("Admin Server")
+
+		s.logger.Info(serverLogTag, "Serving admin requests on %s", s.socketPath)
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					s.logger.Error(serverLogTag, "Accepting connection: %s", err.Error())
+				}
+				return
+			}
+
+			go s.handleConn(conn.(*net.UnixConn))
+		}
+	}()
+
+	return nil
+}
+
+func (s *Server) Stop() {
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
+}
+
+func (s *Server) handleConn(conn *net.UnixConn) {
+	defer s.logger.HandlePanic("Admin Server Connection")
+	defer conn.Close()
+
+	creds, err := getPeerCredentials(conn)
+	if err != nil {
+		s.logger.Error(serverLogTag, "Getting peer credentials: %s", err.Error())
+		return
+	}
+
+	if creds.UID != 0 && creds.UID != os.Geteuid() {
+		s.respondWithError(conn, creds, mutationRequest{}, "Permission denied")
+		return
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(requestReadTimeout))
+
+	rawJSON, err := io.ReadAll(io.LimitReader(conn, maxRequestBytes))
+	if err != nil {
+		s.respondWithError(conn, creds, mutationRequest{}, bosherr.WrapError(err, "Reading request").Error())
+		return
+	}
+
+	_ = conn.SetReadDeadline(time.Time{})
+
+	// Malformed requests are reported by the handler below
+	var mutationReq mutationRequest
+	err = json.Unmarshal(rawJSON, &mutationReq)
+
+	if err == nil && !IsReadOnly(mutationReq.Method) && !mutationReq.Mutating {
+		s.respondWithError(conn, creds, mutationReq, fmt.Sprintf("Action '%s' may change the VM and was not sent as mutating", mutationReq.Method))
+		return
+	}
+
+	respBytes, req, err := boshhandler.PerformHandlerWithJSON(
+		rawJSON,
+		s.handlerFunc,
+		boshhandler.UnlimitedResponseLength,
+		s.logger,
+	)
+	if err != nil {
+		s.respondWithError(conn, creds, mutationRequest{Method: req.Method, Mutating: mutationReq.Mutating}, err.Error())
+		return
+	}
+
+	_, err = conn.Write(respBytes)
+	if err != nil {
+		s.logger.Error(serverLogTag, "Writing response: %s", err.Error())
+	}
+
+	s.audit(creds, mutationReq, 1, "")
+}
+
+func (s *Server) respondWithError(conn *net.UnixConn, creds peerCredentials, req mutationRequest, msg string) {
+	s.logger.Error(serverLogTag, "Handling request from uid %d: %s", creds.UID, msg)
+
+	respBytes, err := boshhandler.BuildErrorWithJSON(msg, s.logger)
+	if err == nil {
+		_, _ = conn.Write(respBytes)
+	}
+
+	s.audit(creds, req, 7, msg)
+}
+
+func (s *Server) audit(creds peerCredentials, req mutationRequest, severity int, statusReason string) {
+	cef := boshhandler.NewCommonEventFormat()
+
+	cefString, err := cef.ProduceLocalRequestEventLog(creds.UID, creds.PID, req.Method, req.Mutating, severity, statusReason)
+	if err != nil {
+		s.logger.Error(serverLogTag, err.Error())
+		return
+	}
+
+	if severity == 7 {
+		s.auditLogger.Err(cefString)
+	} else {
+		s.auditLogger.Debug(cefString)
+	}
+}
@@ -0,0 +1,184 @@
+package admin_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/admin"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		socketDir   string
+		socketPath  string
+		auditLogger *fakeplatform.FakeAuditLogger
+		requests    chan boshhandler.Request
+		handlerFunc boshhandler.Func
+		server      *admin.Server
+		client      admin.Client
+	)
+
+	BeforeEach(func() {
+		var err error
+		socketDir, err = os.MkdirTemp("", "admin-server")
+		Expect(err).ToNot(HaveOccurred())
+
+		socketPath = filepath.Join(socketDir, "admin.sock")
+		auditLogger = fakeplatform.NewFakeAuditLogger()
+		requests = make(chan boshhandler.Request, 10)
+
+		handlerFunc = func(req boshhandler.Request) boshhandler.Response {
+			requests <- req
+			return boshhandler.NewValueResponse(map[string]string{"method": req.Method})
+		}
+
+		client = admin.NewClient(socketPath)
+	})
+
+	JustBeforeEach(func() {
+		server = admin.NewServer(socketPath, handlerFunc, auditLogger, boshlog.NewLogger(boshlog.LevelNone))
+		Expect(server.Start()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Stop()
+		Expect(os.RemoveAll(socketDir)).To(Succeed())
+	})
+
+	It("passes requests to the handler and returns its response", func() {
+		resp, err := client.Call("get_state", []interface{}{"full"}, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.Exception).To(BeNil())
+		Expect(resp.Value).To(MatchJSON(`{"method":"get_state"}`))
+
+		var req boshhandler.Request
+		Eventually(requests).Should(Receive(&req))
+		Expect(req.Method).To(Equal("get_state"))
+		Expect(req.ProtocolVersion).To(Equal(boshhandler.ProtocolVersion(admin.ProtocolVersion)))
+		Expect(string(req.Payload)).To(ContainSubstring(`"arguments":["full"]`))
+	})
+
+	It("restricts access to the socket to its owner", func() {
+		info, err := os.Stat(socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("writes every request to the audit log", func() {
+		_, err := client.Call("info", nil, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(auditLogger.GetDebugMsgs).Should(HaveLen(1))
+		Expect(auditLogger.GetDebugMsgs()[0]).To(ContainSubstring("|agent_api|info|1|suid=0 spid="))
+		Expect(auditLogger.GetDebugMsgs()[0]).To(ContainSubstring("cs3=false cs3Label=mutating"))
+	})
+
+	It("rejects actions which are not read-only unless request is marked as mutating", func() {
+		resp, err := client.Call("stop", nil, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.Exception).ToNot(BeNil())
+		Expect(resp.Exception.Message).To(Equal("Action 'stop' may change the VM and was not sent as mutating"))
+		Expect(requests).ToNot(Receive())
+
+		Eventually(auditLogger.GetErrMsgs).Should(HaveLen(1))
+		Expect(auditLogger.GetErrMsgs()[0]).To(ContainSubstring("|agent_api|stop|7|"))
+		Expect(auditLogger.GetErrMsgs()[0]).To(ContainSubstring("cs3=false cs3Label=mutating"))
+	})
+
+	It("passes mutating requests to the handler and audits them as such", func() {
+		resp, err := client.Call("stop", nil, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.Exception).To(BeNil())
+
+		var req boshhandler.Request
+		Eventually(requests).Should(Receive(&req))
+		Expect(req.Method).To(Equal("stop"))
+
+		Eventually(auditLogger.GetDebugMsgs).Should(HaveLen(1))
+		Expect(auditLogger.GetDebugMsgs()[0]).To(ContainSubstring("|agent_api|stop|1|"))
+		Expect(auditLogger.GetDebugMsgs()[0]).To(ContainSubstring("cs3=true cs3Label=mutating"))
+	})
+
+	It("responds with an exception for malformed requests", func() {
+		conn, err := net.Dial("unix", socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("{"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conn.(*net.UnixConn).CloseWrite()).To(Succeed())
+
+		Expect(readAll(conn)).To(ContainSubstring(`"exception":{"message":"Unmarshalling JSON payload`))
+
+		Eventually(auditLogger.GetErrMsgs).Should(HaveLen(1))
+		Expect(auditLogger.GetErrMsgs()[0]).To(ContainSubstring("cs1Label=statusReason"))
+	})
+
+	Context("when a socket is left behind by a previous run", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(socketPath, []byte{}, 0600)).To(Succeed())
+		})
+
+		It("replaces it", func() {
+			_, err := client.Call("ping", nil, false)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("Client.Wait", func() {
+		BeforeEach(func() {
+			calls := 0
+			handlerFunc = func(req boshhandler.Request) boshhandler.Response {
+				calls++
+				if calls < 3 {
+					return boshhandler.NewValueResponse(boshtask.StateValue{AgentTaskID: "fake-task-id", State: boshtask.StateRunning})
+				}
+				return boshhandler.NewValueResponse("fake-result")
+			}
+		})
+
+		It("polls the task until it finishes", func() {
+			resp, err := client.Call("list_disk", nil, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			state, running := resp.TaskState()
+			Expect(running).To(BeTrue())
+			Expect(state.AgentTaskID).To(Equal("fake-task-id"))
+
+			resp, err = client.Wait(resp, time.Millisecond)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Value).To(MatchJSON(`"fake-result"`))
+		})
+
+		It("returns error if the agent cannot be reached", func() {
+			server.Stop()
+
+			resp := admin.Response{Value: []byte(`{"agent_task_id":"fake-task-id","state":"running"}`)}
+
+			_, err := client.Wait(resp, time.Millisecond)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Getting task fake-task-id"))
+		})
+	})
+})
+
+func readAll(conn net.Conn) string {
+	var buf []byte
+	chunk := make([]byte, 1024)
+	for {
+		n, err := conn.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if err != nil {
+			return string(buf)
+		}
+	}
+}
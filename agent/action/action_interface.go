@@ -1,5 +1,9 @@
 package action
 
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
 type ProtocolVersion int
 
 type Action interface {
@@ -22,3 +26,20 @@ type Action interface {
 	Resume() (interface{}, error)
 	Cancel() error
 }
+
+// LoggingAction is implemented by actions which log while running,
+// so that their lines carry correlation fields of the request.
+type LoggingAction interface {
+	WithLogger(logger boshlog.Logger) Action
+}
+
+// WithLogger returns action logging with logger if action supports it,
+// otherwise action is returned as it is.
+func WithLogger(action Action, logger boshlog.Logger) Action {
+	loggingAction, ok := action.(LoggingAction)
+	if !ok {
+		return action
+	}
+
+	return loggingAction.WithLogger(logger)
+}
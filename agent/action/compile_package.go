@@ -2,9 +2,11 @@ package action
 
 import (
 	"errors"
+	"fmt"
 
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
@@ -30,7 +32,7 @@ func (a CompilePackageAction) IsLoggable() bool {
 	return true
 }
 
-func (a CompilePackageAction) Run(blobID string, multiDigest boshcrypto.MultipleDigest, name, version string, deps boshcomp.Dependencies) (map[string]interface{}, error) {
+func (a CompilePackageAction) Run(progress boshtask.ProgressReporter, blobID string, multiDigest boshcrypto.MultipleDigest, name, version string, deps boshcomp.Dependencies) (map[string]interface{}, error) {
 	val := map[string]interface{}{}
 
 	pkg := boshcomp.Package{
@@ -53,11 +55,16 @@ func (a CompilePackageAction) Run(blobID string, multiDigest boshcrypto.Multiple
 		})
 	}
 
+	progress.SetStage(fmt.Sprintf("Compiling package %s/%s with %d dependencies", pkg.Name, pkg.Version, len(modelsDeps)), 0)
+
 	uploadedBlobID, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps)
 	if err != nil {
+		progress.AppendLog(err.Error())
 		return val, bosherr.WrapErrorf(err, "Compiling package %s", pkg.Name)
 	}
 
+	progress.SetStage(fmt.Sprintf("Compiled package %s/%s", pkg.Name, pkg.Version), 100)
+
 	result := map[string]string{
 		"blobstore_id": uploadedBlobID,
 		"sha1":         uploadedDigest.String(),
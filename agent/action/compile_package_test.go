@@ -11,10 +11,12 @@ import (
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	fakecomp "github.com/cloudfoundry/bosh-agent/agent/compiler/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 )
 
-func getCompileActionArguments() (blobID string, multiDigest boshcrypto.MultipleDigest, name, version string, deps boshcomp.Dependencies) {
+func getCompileActionArguments(progress boshtask.ProgressReporter) (reporter boshtask.ProgressReporter, blobID string, multiDigest boshcrypto.MultipleDigest, name, version string, deps boshcomp.Dependencies) {
+	reporter = progress
 	blobID = "fake-blobstore-id"
 	multiDigest = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"))
 	name = "fake-package-name"
@@ -39,11 +41,13 @@ func getCompileActionArguments() (blobID string, multiDigest boshcrypto.Multiple
 var _ = Describe("CompilePackageAction", func() {
 	var (
 		compiler *fakecomp.FakeCompiler
+		progress *boshtask.ProgressTracker
 		action   boshaction.CompilePackageAction
 	)
 
 	BeforeEach(func() {
 		compiler = fakecomp.NewFakeCompiler()
+		progress = boshtask.NewProgressTracker()
 		action = boshaction.NewCompilePackage(compiler)
 	})
 
@@ -111,10 +115,15 @@ var _ = Describe("CompilePackageAction", func() {
 				},
 			}
 
-			value, err := action.Run(getCompileActionArguments())
+			value, err := action.Run(getCompileActionArguments(progress))
 			Expect(err).ToNot(HaveOccurred())
 			Expect(value).To(Equal(expectedValue))
 
+			Expect(progress.Snapshot()).To(Equal(boshtask.Progress{
+				Stage:   "Compiled package fake-package-name/fake-package-version",
+				Percent: 100,
+			}))
+
 			Expect(compiler.CompilePkg).To(Equal(expectedPkg))
 
 			// Using ConsistOf since package dependencies are specified as a hash (no order)
@@ -124,9 +133,13 @@ var _ = Describe("CompilePackageAction", func() {
 		It("returns error when compile fails", func() {
 			compiler.CompileErr = errors.New("fake-compile-error")
 
-			_, err := action.Run(getCompileActionArguments())
+			_, err := action.Run(getCompileActionArguments(progress))
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
+
+			snapshot := progress.Snapshot()
+			Expect(snapshot.Stage).To(Equal("Compiling package fake-package-name/fake-package-version with 2 dependencies"))
+			Expect(snapshot.LogTail).To(Equal([]string{"fake-compile-error"}))
 		})
 	})
 })
@@ -6,10 +6,12 @@ import (
 	boshagentblob "github.com/cloudfoundry/bosh-agent/agent/blobstore"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	blobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator"
+	boshlogarchive "github.com/cloudfoundry/bosh-agent/agent/logarchive"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	"github.com/cloudfoundry/bosh-agent/agent/utils"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshprobe "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
 	boshnotif "github.com/cloudfoundry/bosh-agent/notification"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -27,20 +29,23 @@ func NewFactory(
 	// TODO(ctz, ja): refactor the usage of blobstore as its a duplicate to the
 	// last argument.
 	sensitiveBlobManager boshagentblob.BlobManagerInterface,
+	blobCache boshagentblob.BlobCache,
 	taskService boshtask.Service,
+	taskJournal boshtask.Journal,
 	notifier boshnotif.Notifier,
 	applier boshappl.Applier,
 	compiler boshcomp.Compiler,
 	jobSupervisor boshjobsuper.JobSupervisor,
+	probeMonitor boshprobe.Monitor,
 	specService boshas.V1Service,
 	jobScriptProvider boshscript.JobScriptProvider,
 	logger boshlog.Logger,
 	blobstoreDelegator blobdelegator.BlobstoreDelegator) Factory {
-	compressor := platform.GetCompressor()
-	copier := platform.GetCopier()
+	logArchiver := boshlogarchive.NewArchiver(platform.GetFs(), logger)
 	dirProvider := platform.GetDirProvider()
 	vitalsService := platform.GetVitalsService()
 	certManager := platform.GetCertManager()
+	diskSnapshotter := platform.GetPersistentDiskSnapshotter()
 
 	return concreteFactory{
 		availableActions: map[string]Action{
@@ -51,11 +56,12 @@ func NewFactory(
 			// Task management
 			"get_task":    NewGetTask(taskService),
 			"cancel_task": NewCancelTask(taskService),
+			"list_tasks":  NewListTasks(taskJournal),
 
 			// VM admin
 			"ssh":                        NewSSH(settingsService, platform, dirProvider, logger),
-			"fetch_logs":                 NewFetchLogs(compressor, copier, blobstoreDelegator, dirProvider),
-			"fetch_logs_with_signed_url": NewFetchLogsWithSignedURLAction(compressor, copier, dirProvider, blobstoreDelegator),
+			"fetch_logs":                 NewFetchLogs(logArchiver, blobstoreDelegator, dirProvider),
+			"fetch_logs_with_signed_url": NewFetchLogsWithSignedURLAction(logArchiver, dirProvider, blobstoreDelegator, logger),
 			"update_settings":            NewUpdateSettings(settingsService, platform, certManager, logger, utils.NewAgentKiller()),
 			"shutdown":                   NewShutdown(platform),
 
@@ -65,7 +71,7 @@ func NewFactory(
 			"start":      NewStart(jobSupervisor, applier, specService),
 			"stop":       NewStop(jobSupervisor),
 			"drain":      NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger),
-			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService),
+			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService, blobCache, probeMonitor),
 			"run_errand": NewRunErrand(specService, dirProvider.JobsDir(), platform.GetRunner(), logger),
 			"run_script": NewRunScript(jobScriptProvider, specService, logger),
 
@@ -81,8 +87,13 @@ func NewFactory(
 			"migrate_disk":           NewMigrateDisk(platform, dirProvider),
 			"mount_disk":             NewMountDisk(settingsService, platform, dirProvider, logger),
 			"unmount_disk":           NewUnmountDisk(settingsService, platform),
+			"resize_disk":            NewResizeDisk(settingsService, platform, dirProvider),
 			"add_persistent_disk":    NewAddPersistentDiskAction(settingsService),
 			"remove_persistent_disk": NewRemovePersistentDiskAction(settingsService),
+			"snapshot_disk":          NewSnapshotDisk(diskSnapshotter, dirProvider),
+			"list_disk_snapshots":    NewListDiskSnapshots(diskSnapshotter, dirProvider),
+			"restore_disk_snapshot":  NewRestoreDiskSnapshot(diskSnapshotter, jobSupervisor, dirProvider),
+			"delete_disk_snapshot":   NewDeleteDiskSnapshot(diskSnapshotter, dirProvider),
 
 			// ARP cache management
 			"delete_arp_entries": NewDeleteARPEntries(platform),
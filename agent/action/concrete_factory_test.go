@@ -5,9 +5,11 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry/bosh-agent/agent/script/scriptfakes"
+	"github.com/cloudfoundry/bosh-agent/platform/disk/diskfakes"
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshlogarchive "github.com/cloudfoundry/bosh-agent/agent/logarchive"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -19,6 +21,7 @@ import (
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
 	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	fakeprobe "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe/probefakes"
 	fakenotif "github.com/cloudfoundry/bosh-agent/notification/fakes"
 	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -31,11 +34,14 @@ var _ = Describe("concreteFactory", func() {
 		settingsService   *fakesettings.FakeSettingsService
 		platform          *platformfakes.FakePlatform
 		blobManager       *fakeagentblobstore.FakeBlobManagerInterface
+		blobCache         *fakeagentblobstore.FakeBlobCache
 		taskService       *faketask.FakeService
+		taskJournal       *faketask.FakeJournal
 		notifier          *fakenotif.FakeNotifier
 		applier           *fakeappl.FakeApplier
 		compiler          *fakecomp.FakeCompiler
 		jobSupervisor     *fakejobsuper.FakeJobSupervisor
+		probeMonitor      *fakeprobe.FakeMonitor
 		specService       *fakeas.FakeV1Service
 		jobScriptProvider boshscript.JobScriptProvider
 		factory           boshaction.Factory
@@ -51,9 +57,13 @@ var _ = Describe("concreteFactory", func() {
 		fileSystem = fakesys.NewFakeFileSystem()
 		platform.GetFsReturns(fileSystem)
 		platform.GetDirProviderReturns(boshdir.NewProvider("/var/vcap"))
+		platform.GetPersistentDiskSnapshotterReturns(&diskfakes.FakeSnapshotter{})
 
 		blobManager = &fakeagentblobstore.FakeBlobManagerInterface{}
+		blobCache = &fakeagentblobstore.FakeBlobCache{}
+		probeMonitor = &fakeprobe.FakeMonitor{}
 		taskService = &faketask.FakeService{}
+		taskJournal = faketask.NewFakeJournal()
 		notifier = fakenotif.NewFakeNotifier()
 		applier = fakeappl.NewFakeApplier()
 		compiler = fakecomp.NewFakeCompiler()
@@ -67,11 +77,14 @@ var _ = Describe("concreteFactory", func() {
 			settingsService,
 			platform,
 			blobManager,
+			blobCache,
 			taskService,
+			taskJournal,
 			notifier,
 			applier,
 			compiler,
 			jobSupervisor,
+			probeMonitor,
 			specService,
 			jobScriptProvider,
 			logger,
@@ -107,14 +120,14 @@ var _ = Describe("concreteFactory", func() {
 	It("fetch_logs", func() {
 		action, err := factory.Create("fetch_logs")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewFetchLogs(platform.GetCompressor(), platform.GetCopier(), blobDelegator, platform.GetDirProvider())))
+		Expect(action).To(Equal(boshaction.NewFetchLogs(boshlogarchive.NewArchiver(platform.GetFs(), logger), blobDelegator, platform.GetDirProvider())))
 	})
 
 	It("fetch_logs_with_signed_url", func() {
 		ac, err := factory.Create("fetch_logs_with_signed_url")
 		Expect(err).ToNot(HaveOccurred())
 
-		Expect(ac).To(Equal(boshaction.NewFetchLogsWithSignedURLAction(platform.GetCompressor(), platform.GetCopier(), platform.GetDirProvider(), blobDelegator)))
+		Expect(ac).To(Equal(boshaction.NewFetchLogsWithSignedURLAction(boshlogarchive.NewArchiver(platform.GetFs(), logger), platform.GetDirProvider(), blobDelegator, logger)))
 	})
 
 	It("get_task", func() {
@@ -123,6 +136,12 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(boshaction.NewGetTask(taskService)))
 	})
 
+	It("list_tasks", func() {
+		action, err := factory.Create("list_tasks")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewListTasks(taskJournal)))
+	})
+
 	It("cancel_task", func() {
 		action, err := factory.Create("cancel_task")
 		Expect(err).ToNot(HaveOccurred())
@@ -132,7 +151,7 @@ var _ = Describe("concreteFactory", func() {
 	It("get_state", func() {
 		action, err := factory.Create("get_state")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(boshaction.NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService())))
+		Expect(action).To(Equal(boshaction.NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService(), blobCache, probeMonitor)))
 	})
 
 	It("list_disk", func() {
@@ -195,6 +214,36 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(boshaction.NewUnmountDisk(settingsService, platform)))
 	})
 
+	It("resize_disk", func() {
+		action, err := factory.Create("resize_disk")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewResizeDisk(settingsService, platform, platform.GetDirProvider())))
+	})
+
+	It("snapshot_disk", func() {
+		action, err := factory.Create("snapshot_disk")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewSnapshotDisk(platform.GetPersistentDiskSnapshotter(), platform.GetDirProvider())))
+	})
+
+	It("list_disk_snapshots", func() {
+		action, err := factory.Create("list_disk_snapshots")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewListDiskSnapshots(platform.GetPersistentDiskSnapshotter(), platform.GetDirProvider())))
+	})
+
+	It("restore_disk_snapshot", func() {
+		action, err := factory.Create("restore_disk_snapshot")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewRestoreDiskSnapshot(platform.GetPersistentDiskSnapshotter(), jobSupervisor, platform.GetDirProvider())))
+	})
+
+	It("delete_disk_snapshot", func() {
+		action, err := factory.Create("delete_disk_snapshot")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(boshaction.NewDeleteDiskSnapshot(platform.GetPersistentDiskSnapshotter(), platform.GetDirProvider())))
+	})
+
 	It("compile_package", func() {
 		action, err := factory.Create("compile_package")
 		Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,52 @@
+package action
+
+import (
+	"errors"
+
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type DeleteDiskSnapshotAction struct {
+	snapshotter boshdisk.Snapshotter
+	dirProvider boshdirs.Provider
+}
+
+func NewDeleteDiskSnapshot(
+	snapshotter boshdisk.Snapshotter,
+	dirProvider boshdirs.Provider,
+) (action DeleteDiskSnapshotAction) {
+	action.snapshotter = snapshotter
+	action.dirProvider = dirProvider
+	return
+}
+
+func (a DeleteDiskSnapshotAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a DeleteDiskSnapshotAction) IsPersistent() bool {
+	return false
+}
+
+func (a DeleteDiskSnapshotAction) IsLoggable() bool {
+	return true
+}
+
+func (a DeleteDiskSnapshotAction) Run(name string) (map[string]string, error) {
+	err := a.snapshotter.DeleteSnapshot(a.dirProvider.StoreDir(), name)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Deleting persistent disk snapshot")
+	}
+
+	return map[string]string{}, nil
+}
+
+func (a DeleteDiskSnapshotAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a DeleteDiskSnapshotAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,39 @@
+package action_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	"github.com/cloudfoundry/bosh-agent/platform/disk/diskfakes"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+)
+
+var _ = Describe("DeleteDiskSnapshotAction", func() {
+	var (
+		snapshotter              *diskfakes.FakeSnapshotter
+		deleteDiskSnapshotAction action.DeleteDiskSnapshotAction
+	)
+
+	BeforeEach(func() {
+		snapshotter = &diskfakes.FakeSnapshotter{}
+		deleteDiskSnapshotAction = action.NewDeleteDiskSnapshot(snapshotter, boshdirs.NewProvider("/fake-base-dir"))
+	})
+
+	AssertActionIsAsynchronous(deleteDiskSnapshotAction)
+	AssertActionIsNotPersistent(deleteDiskSnapshotAction)
+	AssertActionIsLoggable(deleteDiskSnapshotAction)
+
+	AssertActionIsNotResumable(deleteDiskSnapshotAction)
+	AssertActionIsNotCancelable(deleteDiskSnapshotAction)
+
+	It("deletes snapshot of the store directory", func() {
+		_, err := deleteDiskSnapshotAction.Run("pre-deploy")
+		Expect(err).ToNot(HaveOccurred())
+
+		mountPoint, name := snapshotter.DeleteSnapshotArgsForCall(0)
+		Expect(mountPoint).To(boshassert.MatchPath("/fake-base-dir/store"))
+		Expect(name).To(Equal("pre-deploy"))
+	})
+})
@@ -62,6 +62,11 @@ func (a DrainAction) IsLoggable() bool {
 	return true
 }
 
+func (a DrainAction) WithLogger(logger boshlog.Logger) Action {
+	a.logger = logger
+	return a
+}
+
 func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V1ApplySpec) (int, error) {
 	currentSpec, err := a.specService.Get()
 	if err != nil {
@@ -2,6 +2,7 @@ package fakes
 
 import (
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 )
 
 type FakeRunner struct {
@@ -10,6 +11,7 @@ type FakeRunner struct {
 	RunProtocolVersion boshaction.ProtocolVersion
 	RunValue           interface{}
 	RunErr             error
+	RunProgress        boshtask.ProgressReporter
 
 	ResumeAction  boshaction.Action
 	ResumePayload []byte
@@ -24,6 +26,11 @@ func (runner *FakeRunner) Run(action boshaction.Action, payload []byte, version
 	return runner.RunValue, runner.RunErr
 }
 
+func (runner *FakeRunner) RunWithProgress(action boshaction.Action, payload []byte, version boshaction.ProtocolVersion, progress boshtask.ProgressReporter) (interface{}, error) {
+	runner.RunProgress = progress
+	return runner.Run(action, payload, version)
+}
+
 func (runner *FakeRunner) Resume(action boshaction.Action, payload []byte) (interface{}, error) {
 	runner.ResumeAction = action
 	runner.ResumePayload = payload
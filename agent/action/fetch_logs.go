@@ -2,28 +2,26 @@ package action
 
 import (
 	"errors"
+	"strconv"
 
 	"github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator"
+	boshlogarchive "github.com/cloudfoundry/bosh-agent/agent/logarchive"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
-	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
 )
 
 type FetchLogsAction struct {
-	compressor  boshcmd.Compressor
-	copier      boshcmd.Copier
+	archiver    boshlogarchive.Archiver
 	blobstore   blobstore_delegator.BlobstoreDelegator
 	settingsDir boshdirs.Provider
 }
 
 func NewFetchLogs(
-	compressor boshcmd.Compressor,
-	copier boshcmd.Copier,
+	archiver boshlogarchive.Archiver,
 	blobstore blobstore_delegator.BlobstoreDelegator,
 	settingsDir boshdirs.Provider,
 ) (action FetchLogsAction) {
-	action.compressor = compressor
-	action.copier = copier
+	action.archiver = archiver
 	action.blobstore = blobstore
 	action.settingsDir = settingsDir
 	return
@@ -41,7 +39,8 @@ func (a FetchLogsAction) IsLoggable() bool {
 	return true
 }
 
-func (a FetchLogsAction) Run(logType string, filters []string) (map[string]string, error) {
+// Run accepts optional time range and size limits as third argument
+func (a FetchLogsAction) Run(logType string, filters []string, options ...boshlogarchive.Options) (map[string]string, error) {
 	value := map[string]string{}
 	var logsDir string
 
@@ -60,20 +59,18 @@ func (a FetchLogsAction) Run(logType string, filters []string) (map[string]strin
 		return value, bosherr.Error("Invalid log type")
 	}
 
-	tmpDir, err := a.copier.FilteredCopyToTemp(logsDir, filters)
-	if err != nil {
-		return value, bosherr.WrapError(err, "Copying filtered files to temp directory")
+	var archiveOptions boshlogarchive.Options
+	if len(options) > 0 {
+		archiveOptions = options[0]
 	}
 
-	defer a.copier.CleanUp(tmpDir)
-
-	tarball, err := a.compressor.CompressFilesInDir(tmpDir)
+	tarball, manifest, err := a.archiver.Archive(logsDir, filters, archiveOptions)
 	if err != nil {
 		return value, bosherr.WrapError(err, "Making logs tarball")
 	}
 
 	defer func() {
-		_ = a.compressor.CleanUp(tarball)
+		_ = a.archiver.CleanUp(tarball)
 	}()
 
 	blobID, multidigestSha, err := a.blobstore.Write("", tarball, nil)
@@ -82,6 +79,15 @@ func (a FetchLogsAction) Run(logType string, filters []string) (map[string]strin
 	}
 
 	value = map[string]string{"blobstore_id": blobID, "sha1": multidigestSha.String()}
+
+	// Details of logs which were not archived as they are on disk are in the tarball
+	if len(manifest.Truncated) > 0 {
+		value["truncated_files"] = strconv.Itoa(len(manifest.Truncated))
+	}
+	if len(manifest.Skipped) > 0 {
+		value["skipped_files"] = strconv.Itoa(len(manifest.Skipped))
+	}
+
 	return value, nil
 }
 
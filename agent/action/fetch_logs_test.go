@@ -1,34 +1,35 @@
 package action_test
 
 import (
+	"errors"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry/bosh-agent/agent/action"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshlogarchive "github.com/cloudfoundry/bosh-agent/agent/logarchive"
+	"github.com/cloudfoundry/bosh-agent/agent/logarchive/logarchivefakes"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
-	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
 )
 
 var _ = Describe("FetchLogsAction", func() {
 	var (
-		compressor      *fakecmd.FakeCompressor
-		copier          *fakecmd.FakeCopier
+		archiver        *logarchivefakes.FakeArchiver
 		blobstore       *fakeblobdelegator.FakeBlobstoreDelegator
 		dirProvider     boshdirs.Provider
 		fetchLogsAction action.FetchLogsAction
 	)
 
 	BeforeEach(func() {
-		compressor = fakecmd.NewFakeCompressor()
+		archiver = &logarchivefakes.FakeArchiver{}
 		blobstore = &fakeblobdelegator.FakeBlobstoreDelegator{}
 		dirProvider = boshdirs.NewProvider("/fake/dir")
-		copier = fakecmd.NewFakeCopier()
-		fetchLogsAction = action.NewFetchLogs(compressor, copier, blobstore, dirProvider)
+		fetchLogsAction = action.NewFetchLogs(archiver, blobstore, dirProvider)
 	})
 
 	AssertActionIsAsynchronous(fetchLogsAction)
@@ -40,8 +41,7 @@ var _ = Describe("FetchLogsAction", func() {
 
 	Describe("Run", func() {
 		testLogs := func(logType string, filters []string, expectedFilters []string) {
-			copier.FilteredCopyToTempTempDir = "/fake-temp-dir"
-			compressor.CompressFilesInDirTarballPath = "logs_test.tar"
+			archiver.ArchiveReturns("logs_test.tgz", boshlogarchive.Manifest{}, nil)
 			multidigestSha := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "sec_dep_sha1"))
 			sha1 := multidigestSha.String()
 			blobstore.WriteStub = func(signedURL, fileName string, headers map[string]string) (blobID string, digest boshcrypto.MultipleDigest, err error) {
@@ -59,14 +59,14 @@ var _ = Describe("FetchLogsAction", func() {
 				expectedPath = filepath.Join("/fake", "dir", "bosh", "log")
 			}
 
-			Expect(copier.FilteredCopyToTempDir).To(boshassert.MatchPath(expectedPath))
-			Expect(copier.FilteredCopyToTempFilters).To(Equal(expectedFilters))
+			Expect(archiver.ArchiveCallCount()).To(Equal(1))
+			archivedDir, archivedFilters, options := archiver.ArchiveArgsForCall(0)
+			Expect(archivedDir).To(boshassert.MatchPath(expectedPath))
+			Expect(archivedFilters).To(Equal(expectedFilters))
+			Expect(options).To(Equal(boshlogarchive.Options{}))
 
-			Expect(copier.FilteredCopyToTempTempDir).To(Equal(compressor.CompressFilesInDirDir))
-			Expect(copier.CleanUpTempDir).To(Equal(compressor.CompressFilesInDirDir))
-
-			_, compressFilesInTarballPath, _ := blobstore.WriteArgsForCall(0)
-			Expect(compressFilesInTarballPath).To(Equal(compressor.CompressFilesInDirTarballPath))
+			_, tarballPath, _ := blobstore.WriteArgsForCall(0)
+			Expect(tarballPath).To(Equal("logs_test.tgz"))
 
 			boshassert.MatchesJSONString(GinkgoT(), logs, `{"blobstore_id":"my-blob-id","sha1":"`+sha1+`"}`)
 		}
@@ -100,13 +100,45 @@ var _ = Describe("FetchLogsAction", func() {
 			testLogs("job", filters, expectedFilters)
 		})
 
-		It("cleans up compressed package after uploading it to blobstore", func() {
-			var beforeCleanUpTarballPath, afterCleanUpTarballPath string
+		It("passes time range and size limits to archiver", func() {
+			since := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+			options := boshlogarchive.Options{Since: &since, MaxSizeBytes: 1024}
 
-			compressor.CompressFilesInDirTarballPath = "/fake-compressed-logs.tar"
+			_, err := fetchLogsAction.Run("job", []string{}, options)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, archivedOptions := archiver.ArchiveArgsForCall(0)
+			Expect(archivedOptions).To(Equal(options))
+		})
+
+		It("reports logs which were truncated or skipped", func() {
+			archiver.ArchiveReturns("/fake-compressed-logs.tgz", boshlogarchive.Manifest{
+				Truncated: []boshlogarchive.ManifestFile{{Path: "job/job.log"}},
+				Skipped:   []boshlogarchive.ManifestFile{{Path: "job/job.log.1"}, {Path: "job/job.log.2"}},
+			}, nil)
+			blobstore.WriteReturns("my-blob-id", boshcrypto.MultipleDigest{}, nil)
+
+			logs, err := fetchLogsAction.Run("job", []string{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(logs).To(HaveKeyWithValue("truncated_files", "1"))
+			Expect(logs).To(HaveKeyWithValue("skipped_files", "2"))
+		})
+
+		It("returns error if archiving logs fails", func() {
+			archiver.ArchiveReturns("", boshlogarchive.Manifest{}, errors.New("fake-archive-err"))
+
+			_, err := fetchLogsAction.Run("job", []string{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-archive-err"))
+			Expect(blobstore.WriteCallCount()).To(Equal(0))
+		})
+
+		It("cleans up compressed package after uploading it to blobstore", func() {
+			archiver.ArchiveReturns("/fake-compressed-logs.tgz", boshlogarchive.Manifest{}, nil)
 
 			blobstore.WriteStub = func(signedURL, fileName string, headers map[string]string) (blobID string, digest boshcrypto.MultipleDigest, err error) {
-				beforeCleanUpTarballPath = compressor.CleanUpTarballPath
+				// Logs are not cleaned up before blobstore upload
+				Expect(archiver.CleanUpCallCount()).To(Equal(0))
 
 				return "my-blob-id", boshcrypto.MultipleDigest{}, nil
 			}
@@ -114,12 +146,9 @@ var _ = Describe("FetchLogsAction", func() {
 			_, err := fetchLogsAction.Run("job", []string{})
 			Expect(err).ToNot(HaveOccurred())
 
-			// Logs are not cleaned up before blobstore upload
-			Expect(beforeCleanUpTarballPath).To(Equal(""))
-
 			// Deleted after it was uploaded
-			afterCleanUpTarballPath = compressor.CleanUpTarballPath
-			Expect(afterCleanUpTarballPath).To(Equal("/fake-compressed-logs.tar"))
+			Expect(archiver.CleanUpCallCount()).To(Equal(1))
+			Expect(archiver.CleanUpArgsForCall(0)).To(Equal("/fake-compressed-logs.tgz"))
 		})
 	})
 })
@@ -2,40 +2,52 @@ package action
 
 import (
 	"errors"
+	"io"
 
 	blobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator"
+	boshlogarchive "github.com/cloudfoundry/bosh-agent/agent/logarchive"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
-	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
+const fetchLogsWithSignedURLLogTag = "FetchLogsWithSignedURLAction"
+
 type FetchLogsWithSignedURLRequest struct {
 	SignedURL        string            `json:"signed_url"`
 	LogType          string            `json:"log_type"`
 	Filters          []string          `json:"filters"`
 	BlobstoreHeaders map[string]string `json:"blobstore_headers"`
+
+	// Optional time range and size limits
+	boshlogarchive.Options
 }
 
 type FetchLogsWithSignedURLResponse struct {
 	SHA1Digest string `json:"sha1"`
+
+	// Number of logs which were not archived as they are on disk,
+	// details are in the manifest inside the tarball
+	TruncatedFiles int `json:"truncated_files,omitempty"`
+	SkippedFiles   int `json:"skipped_files,omitempty"`
 }
 
 type FetchLogsWithSignedURLAction struct {
-	compressor    boshcmd.Compressor
-	copier        boshcmd.Copier
+	archiver      boshlogarchive.Archiver
 	settingsDir   boshdirs.Provider
 	blobDelegator blobdelegator.BlobstoreDelegator
+	logger        boshlog.Logger
 }
 
 func NewFetchLogsWithSignedURLAction(
-	compressor boshcmd.Compressor,
-	copier boshcmd.Copier,
+	archiver boshlogarchive.Archiver,
 	settingsDir boshdirs.Provider,
-	blobDelegator blobdelegator.BlobstoreDelegator) (action FetchLogsWithSignedURLAction) {
-	action.compressor = compressor
-	action.copier = copier
+	blobDelegator blobdelegator.BlobstoreDelegator,
+	logger boshlog.Logger) (action FetchLogsWithSignedURLAction) {
+	action.archiver = archiver
 	action.settingsDir = settingsDir
 	action.blobDelegator = blobDelegator
+	action.logger = logger
 	return
 }
 
@@ -51,6 +63,11 @@ func (a FetchLogsWithSignedURLAction) IsLoggable() bool {
 	return true
 }
 
+func (a FetchLogsWithSignedURLAction) WithLogger(logger boshlog.Logger) Action {
+	a.logger = logger
+	return a
+}
+
 func (a FetchLogsWithSignedURLAction) Run(request FetchLogsWithSignedURLRequest) (FetchLogsWithSignedURLResponse, error) {
 	var logsDir string
 	filters := request.Filters
@@ -70,20 +87,23 @@ func (a FetchLogsWithSignedURLAction) Run(request FetchLogsWithSignedURLRequest)
 		return FetchLogsWithSignedURLResponse{}, bosherr.Error("Invalid log type")
 	}
 
-	tmpDir, err := a.copier.FilteredCopyToTemp(logsDir, filters)
-	if err != nil {
-		return FetchLogsWithSignedURLResponse{}, bosherr.WrapError(err, "Copying filtered files to temp directory")
-	}
+	if request.SignedURL != "" {
+		response, err := a.streamLogs(logsDir, filters, request)
+		if err == nil {
+			return response, nil
+		}
 
-	defer a.copier.CleanUp(tmpDir)
+		// Signed URLs of some blobstores require uploads of known size
+		a.logger.Warn(fetchLogsWithSignedURLLogTag, "Streaming logs tarball failed, uploading it from temporary file: %s", err.Error())
+	}
 
-	tarball, err := a.compressor.CompressFilesInDir(tmpDir)
+	tarball, manifest, err := a.archiver.Archive(logsDir, filters, request.Options)
 	if err != nil {
 		return FetchLogsWithSignedURLResponse{}, bosherr.WrapError(err, "Making logs tarball")
 	}
 
 	defer func() {
-		_ = a.compressor.CleanUp(tarball)
+		_ = a.archiver.CleanUp(tarball)
 	}()
 
 	_, digest, err := a.blobDelegator.Write(request.SignedURL, tarball, request.BlobstoreHeaders)
@@ -92,7 +112,47 @@ func (a FetchLogsWithSignedURLAction) Run(request FetchLogsWithSignedURLRequest)
 	}
 
 	return FetchLogsWithSignedURLResponse{
-		SHA1Digest: digest.String(),
+		SHA1Digest:     digest.String(),
+		TruncatedFiles: len(manifest.Truncated),
+		SkippedFiles:   len(manifest.Skipped),
+	}, nil
+}
+
+// streamLogs pipes logs tarball into the upload so that it is not
+// written to ephemeral disk which may not have room for it
+func (a FetchLogsWithSignedURLAction) streamLogs(logsDir string, filters []string, request FetchLogsWithSignedURLRequest) (FetchLogsWithSignedURLResponse, error) {
+	type archiveResult struct {
+		manifest boshlogarchive.Manifest
+		err      error
+	}
+
+	reader, writer := io.Pipe()
+	archived := make(chan archiveResult, 1)
+
+	go func() {
+		manifest, err := a.archiver.ArchiveTo(logsDir, filters, request.Options, writer)
+		_ = writer.CloseWithError(err)
+		archived <- archiveResult{manifest: manifest, err: err}
+	}()
+
+	digest, err := a.blobDelegator.WriteStream(request.SignedURL, reader, request.BlobstoreHeaders)
+
+	// Unblocks archiver when upload stopped reading early
+	_ = reader.CloseWithError(errors.New("logs upload finished"))
+	result := <-archived
+
+	if err != nil {
+		return FetchLogsWithSignedURLResponse{}, bosherr.WrapError(err, "Streaming logs tarball to blobstore")
+	}
+
+	if result.err != nil {
+		return FetchLogsWithSignedURLResponse{}, bosherr.WrapError(result.err, "Making logs tarball")
+	}
+
+	return FetchLogsWithSignedURLResponse{
+		SHA1Digest:     digest.String(),
+		TruncatedFiles: len(result.manifest.Truncated),
+		SkippedFiles:   len(result.manifest.Skipped),
 	}, nil
 }
 
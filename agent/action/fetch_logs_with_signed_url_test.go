@@ -1,35 +1,52 @@
 package action_test
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry/bosh-agent/agent/action"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshlogarchive "github.com/cloudfoundry/bosh-agent/agent/logarchive"
+	"github.com/cloudfoundry/bosh-agent/agent/logarchive/logarchivefakes"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
-	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
 var _ = Describe("FetchLogsWithSignedURLAction", func() {
 	var (
-		compressor                   *fakecmd.FakeCompressor
-		copier                       *fakecmd.FakeCopier
+		archiver                     *logarchivefakes.FakeArchiver
 		dirProvider                  boshdirs.Provider
 		fetchLogsWithSignedURLAction action.FetchLogsWithSignedURLAction
 		blobDelegator                *fakeblobdelegator.FakeBlobstoreDelegator
+		uploadedContents             string
 	)
 
 	BeforeEach(func() {
-		compressor = fakecmd.NewFakeCompressor()
+		archiver = &logarchivefakes.FakeArchiver{}
 		dirProvider = boshdirs.NewProvider("/fake/dir")
-		copier = fakecmd.NewFakeCopier()
 		blobDelegator = &fakeblobdelegator.FakeBlobstoreDelegator{}
 
-		fetchLogsWithSignedURLAction = action.NewFetchLogsWithSignedURLAction(compressor, copier, dirProvider, blobDelegator)
+		fetchLogsWithSignedURLAction = action.NewFetchLogsWithSignedURLAction(archiver, dirProvider, blobDelegator, boshlog.NewLogger(boshlog.LevelNone))
+
+		archiver.ArchiveToStub = func(_ string, _ []string, _ boshlogarchive.Options, tarball io.Writer) (boshlogarchive.Manifest, error) {
+			_, err := tarball.Write([]byte("fake-tarball"))
+			return boshlogarchive.Manifest{}, err
+		}
+
+		uploadedContents = ""
+		blobDelegator.WriteStreamStub = func(_ string, contents io.Reader, _ map[string]string) (boshcrypto.MultipleDigest, error) {
+			uploaded, err := io.ReadAll(contents)
+			uploadedContents = string(uploaded)
+			return boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), err
+		}
 	})
 
 	AssertActionIsAsynchronous(fetchLogsWithSignedURLAction)
@@ -41,13 +58,6 @@ var _ = Describe("FetchLogsWithSignedURLAction", func() {
 
 	Describe("Run", func() {
 		testLogs := func(logType string, filters []string, expectedFilters []string) {
-			copier.FilteredCopyToTempTempDir = "/fake-temp-dir"
-			compressor.CompressFilesInDirTarballPath = "logs_test.tar"
-
-			multidigestSha := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "sec_dep_sha1"))
-			sha1 := multidigestSha.String()
-			blobDelegator.WriteReturns("", multidigestSha, nil)
-
 			logs, err := fetchLogsWithSignedURLAction.Run(action.FetchLogsWithSignedURLRequest{SignedURL: "foobar", LogType: logType, Filters: filters, BlobstoreHeaders: map[string]string{"key": "value"}})
 			Expect(err).ToNot(HaveOccurred())
 
@@ -59,20 +69,35 @@ var _ = Describe("FetchLogsWithSignedURLAction", func() {
 				expectedPath = filepath.Join("/fake", "dir", "bosh", "log")
 			}
 
-			Expect(copier.FilteredCopyToTempDir).To(boshassert.MatchPath(expectedPath))
-			Expect(copier.FilteredCopyToTempFilters).To(Equal(expectedFilters))
+			Expect(archiver.ArchiveToCallCount()).To(Equal(1))
+			archivedDir, archivedFilters, _, _ := archiver.ArchiveToArgsForCall(0)
+			Expect(archivedDir).To(boshassert.MatchPath(expectedPath))
+			Expect(archivedFilters).To(Equal(expectedFilters))
 
-			Expect(copier.FilteredCopyToTempTempDir).To(Equal(compressor.CompressFilesInDirDir))
-			Expect(copier.CleanUpTempDir).To(Equal(compressor.CompressFilesInDirDir))
-
-			actualSignedURL, actualTarballPath, headers := blobDelegator.WriteArgsForCall(0)
+			Expect(blobDelegator.WriteStreamCallCount()).To(Equal(1))
+			actualSignedURL, _, headers := blobDelegator.WriteStreamArgsForCall(0)
 			Expect(actualSignedURL).To(Equal("foobar"))
 			Expect(headers).To(Equal(map[string]string{"key": "value"}))
-			Expect(actualTarballPath).To(Equal(compressor.CompressFilesInDirTarballPath))
+			Expect(uploadedContents).To(Equal("fake-tarball"))
+
+			Expect(archiver.ArchiveCallCount()).To(Equal(0))
+			Expect(blobDelegator.WriteCallCount()).To(Equal(0))
 
-			boshassert.MatchesJSONString(GinkgoT(), logs, `{"sha1":"`+sha1+`"}`)
+			boshassert.MatchesJSONString(GinkgoT(), logs, `{"sha1":"fake-sha1"}`)
 		}
 
+		It("reports logs which were truncated or skipped", func() {
+			archiver.ArchiveToReturns(boshlogarchive.Manifest{
+				Truncated: []boshlogarchive.ManifestFile{{Path: "job/job.log"}},
+				Skipped:   []boshlogarchive.ManifestFile{{Path: "job/job.log.1"}, {Path: "job/job.log.2"}},
+			}, nil)
+
+			logs, err := fetchLogsWithSignedURLAction.Run(action.FetchLogsWithSignedURLRequest{SignedURL: "foobar", LogType: "job"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(logs.TruncatedFiles).To(Equal(1))
+			Expect(logs.SkippedFiles).To(Equal(2))
+		})
+
 		It("logs errs if given invalid log type", func() {
 			_, err := fetchLogsWithSignedURLAction.Run(action.FetchLogsWithSignedURLRequest{LogType: "other-logs", Filters: []string{}})
 			Expect(err).To(HaveOccurred())
@@ -102,20 +127,88 @@ var _ = Describe("FetchLogsWithSignedURLAction", func() {
 			testLogs("job", filters, expectedFilters)
 		})
 
-		It("cleans up compressed package after uploading it to blobstore", func() {
-			var beforeCleanUpTarballPath, afterCleanUpTarballPath string
+		It("passes time range and size limits from request to archiver", func() {
+			var request action.FetchLogsWithSignedURLRequest
+			err := json.Unmarshal([]byte(`{
+				"signed_url": "foobar",
+				"log_type": "job",
+				"since": "2026-10-17T00:00:00Z",
+				"until": "2026-10-18T00:00:00Z",
+				"max_file_size_bytes": 512,
+				"max_size_bytes": 1024
+			}`), &request)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = fetchLogsWithSignedURLAction.Run(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			since := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+			until := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
 
-			compressor.CompressFilesInDirTarballPath = "/fake-compressed-logs.tar"
+			_, _, options, _ := archiver.ArchiveToArgsForCall(0)
+			Expect(options).To(Equal(boshlogarchive.Options{
+				Since:            &since,
+				Until:            &until,
+				MaxFileSizeBytes: 512,
+				MaxSizeBytes:     1024,
+			}))
+		})
+
+		Context("when streaming logs tarball fails", func() {
+			BeforeEach(func() {
+				archiver.ArchiveReturns("/fake-compressed-logs.tgz", boshlogarchive.Manifest{
+					Skipped: []boshlogarchive.ManifestFile{{Path: "job/job.log.1"}},
+				}, nil)
+				blobDelegator.WriteReturns("", boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-file-sha1")), nil)
+			})
+
+			It("uploads logs tarball from temporary file when upload is rejected", func() {
+				blobDelegator.WriteStreamStub = nil
+				blobDelegator.WriteStreamReturns(boshcrypto.MultipleDigest{}, errors.New("fake-length-required-err"))
+
+				logs, err := fetchLogsWithSignedURLAction.Run(action.FetchLogsWithSignedURLRequest{SignedURL: "foobar", LogType: "job", BlobstoreHeaders: map[string]string{"key": "value"}})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(logs.SHA1Digest).To(Equal("fake-file-sha1"))
+				Expect(logs.SkippedFiles).To(Equal(1))
+
+				signedURL, tarballPath, headers := blobDelegator.WriteArgsForCall(0)
+				Expect(signedURL).To(Equal("foobar"))
+				Expect(tarballPath).To(Equal("/fake-compressed-logs.tgz"))
+				Expect(headers).To(Equal(map[string]string{"key": "value"}))
+
+				Expect(archiver.CleanUpArgsForCall(0)).To(Equal("/fake-compressed-logs.tgz"))
+			})
+
+			It("uploads logs tarball from temporary file when archiving fails", func() {
+				archiver.ArchiveToStub = nil
+				archiver.ArchiveToReturns(boshlogarchive.Manifest{}, errors.New("fake-archive-err"))
+
+				logs, err := fetchLogsWithSignedURLAction.Run(action.FetchLogsWithSignedURLRequest{SignedURL: "foobar", LogType: "job"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(logs.SHA1Digest).To(Equal("fake-file-sha1"))
+				Expect(blobDelegator.WriteCallCount()).To(Equal(1))
+			})
+
+			It("does not block archiving when upload stops reading", func() {
+				blobDelegator.WriteStreamStub = func(_ string, _ io.Reader, _ map[string]string) (boshcrypto.MultipleDigest, error) {
+					return boshcrypto.MultipleDigest{}, errors.New("fake-upload-err")
+				}
+
+				_, err := fetchLogsWithSignedURLAction.Run(action.FetchLogsWithSignedURLRequest{SignedURL: "foobar", LogType: "job"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(archiver.ArchiveToCallCount()).To(Equal(1))
+				Expect(blobDelegator.WriteCallCount()).To(Equal(1))
+			})
+		})
+
+		It("cleans up compressed package after uploading it to blobstore", func() {
+			archiver.ArchiveReturns("/fake-compressed-logs.tgz", boshlogarchive.Manifest{}, nil)
 
 			_, err := fetchLogsWithSignedURLAction.Run(action.FetchLogsWithSignedURLRequest{LogType: "job", Filters: []string{}})
 			Expect(err).ToNot(HaveOccurred())
 
-			// Logs are not cleaned up before blobstore upload
-			Expect(beforeCleanUpTarballPath).To(Equal(""))
-
-			// Deleted after it was uploaded
-			afterCleanUpTarballPath = compressor.CleanUpTarballPath
-			Expect(afterCleanUpTarballPath).To(Equal("/fake-compressed-logs.tar"))
+			Expect(archiver.CleanUpCallCount()).To(Equal(1))
+			Expect(archiver.CleanUpArgsForCall(0)).To(Equal("/fake-compressed-logs.tgz"))
 		})
 	})
 })
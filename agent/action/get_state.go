@@ -4,7 +4,9 @@ import (
 	"errors"
 
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshagentblob "github.com/cloudfoundry/bosh-agent/agent/blobstore"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshprobe "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -15,6 +17,8 @@ type GetStateAction struct {
 	specService     boshas.V1Service
 	jobSupervisor   boshjobsuper.JobSupervisor
 	vitalsService   boshvitals.Service
+	blobCache       boshagentblob.BlobCache
+	probeMonitor    boshprobe.Monitor
 }
 
 func NewGetState(
@@ -22,11 +26,15 @@ func NewGetState(
 	specService boshas.V1Service,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	vitalsService boshvitals.Service,
+	blobCache boshagentblob.BlobCache,
+	probeMonitor boshprobe.Monitor,
 ) (action GetStateAction) {
 	action.settingsService = settingsService
 	action.specService = specService
 	action.jobSupervisor = jobSupervisor
 	action.vitalsService = vitalsService
+	action.blobCache = blobCache
+	action.probeMonitor = probeMonitor
 	return
 }
 
@@ -50,6 +58,10 @@ type GetStateV1ApplySpec struct {
 	Vitals    *boshvitals.Vitals     `json:"vitals,omitempty"`
 	Processes []boshjobsuper.Process `json:"processes,omitempty"`
 	VM        boshsettings.VM        `json:"vm"`
+
+	BlobCache *boshagentblob.CacheStats `json:"blob_cache,omitempty"`
+
+	HealthProbes []boshprobe.Result `json:"health_probes,omitempty"`
 }
 
 func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
@@ -83,6 +95,19 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 		vitalsReference,
 		processes,
 		settings.VM,
+		nil,
+		nil,
+	}
+
+	// Blob cache is optional
+	if a.blobCache != nil {
+		blobCacheStats := a.blobCache.Stats()
+		value.BlobCache = &blobCacheStats
+	}
+
+	// Health probes are optional
+	if a.probeMonitor != nil {
+		value.HealthProbes = a.probeMonitor.Results()
 	}
 
 	if value.NetworkSpecs == nil {
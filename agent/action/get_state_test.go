@@ -9,8 +9,12 @@ import (
 	"github.com/cloudfoundry/bosh-agent/agent/action"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshagentblob "github.com/cloudfoundry/bosh-agent/agent/blobstore"
+	fakeagentblob "github.com/cloudfoundry/bosh-agent/agent/blobstore/blobstorefakes"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshprobe "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	fakeprobe "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe/probefakes"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	"github.com/cloudfoundry/bosh-agent/platform/vitals/vitalsfakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -32,7 +36,7 @@ var _ = Describe("GetState", func() {
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		specService = fakeas.NewFakeV1Service()
 		vitalsService = &vitalsfakes.FakeService{}
-		getStateAction = action.NewGetState(settingsService, specService, jobSupervisor, vitalsService)
+		getStateAction = action.NewGetState(settingsService, specService, jobSupervisor, vitalsService, nil, nil)
 	})
 
 	AssertActionIsNotAsynchronous(getStateAction)
@@ -127,6 +131,32 @@ var _ = Describe("GetState", func() {
 					boshassert.MatchesJSONMap(GinkgoT(), state.VM, expectedVM)
 				})
 
+				It("returns blob cache stats when blob cache is enabled", func() {
+					blobCache := &fakeagentblob.FakeBlobCache{}
+					blobCache.StatsReturns(boshagentblob.CacheStats{Hits: 3, Misses: 1, Entries: 1, SizeBytes: 10, MaxSizeBytes: 100})
+					getStateAction = action.NewGetState(settingsService, specService, jobSupervisor, vitalsService, blobCache, nil)
+
+					state, err := getStateAction.Run()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(state.BlobCache).To(Equal(&boshagentblob.CacheStats{Hits: 3, Misses: 1, Entries: 1, SizeBytes: 10, MaxSizeBytes: 100}))
+				})
+
+				It("does not return blob cache stats when blob cache is disabled", func() {
+					state, err := getStateAction.Run()
+					Expect(err).ToNot(HaveOccurred())
+					boshassert.LacksJSONKey(GinkgoT(), state, "blob_cache")
+				})
+
+				It("returns health probe results when probes are enabled", func() {
+					probeMonitor := &fakeprobe.FakeMonitor{}
+					probeMonitor.ResultsReturns([]boshprobe.Result{{Job: "nats", Name: "http", State: boshprobe.StateFailing, LastError: "fake-err"}})
+					getStateAction = action.NewGetState(settingsService, specService, jobSupervisor, vitalsService, nil, probeMonitor)
+
+					state, err := getStateAction.Run()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(state.HealthProbes).To(Equal([]boshprobe.Result{{Job: "nats", Name: "http", State: boshprobe.StateFailing, LastError: "fake-err"}}))
+				})
+
 				Describe("non-populated field formatting", func() {
 					It("returns network as empty hash if not set", func() {
 						specService.Spec = boshas.V1ApplySpec{NetworkSpecs: nil}
@@ -35,10 +35,17 @@ func (a GetTaskAction) Run(taskID string) (interface{}, error) {
 	}
 
 	if task.State == boshtask.StateRunning {
-		return boshtask.StateValue{
+		stateValue := boshtask.StateValue{
 			AgentTaskID: task.ID,
 			State:       task.State,
-		}, nil
+		}
+
+		if task.Progress != nil {
+			progress := task.Progress.Snapshot()
+			stateValue.Progress = &progress
+		}
+
+		return stateValue, nil
 	}
 
 	if task.Error != nil {
@@ -44,6 +44,24 @@ var _ = Describe("GetTask", func() {
 			`{"agent_task_id":"fake-task-id","state":"running"}`)
 	})
 
+	It("returns progress of a running task", func() {
+		progress := boshtask.NewProgressTracker()
+		progress.SetStage("fake-stage", 40)
+		progress.AppendLog("fake-log-line")
+
+		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+			ID:       "fake-task-id",
+			State:    boshtask.StateRunning,
+			Progress: progress,
+		}
+
+		taskValue, err := getTaskAction.Run("fake-task-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		boshassert.MatchesJSONString(GinkgoT(), taskValue,
+			`{"agent_task_id":"fake-task-id","state":"running","progress":{"stage":"fake-stage","percent":40,"log_tail":["fake-log-line"]}}`)
+	})
+
 	It("returns a failed task", func() {
 		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
 			ID:    "fake-task-id",
@@ -38,6 +38,11 @@ func (a ListDiskAction) IsLoggable() bool {
 	return true
 }
 
+func (a ListDiskAction) WithLogger(logger boshlog.Logger) Action {
+	a.logger = logger
+	return a
+}
+
 func (a ListDiskAction) Run() (interface{}, error) {
 	err := a.settingsService.LoadSettings()
 	if err != nil {
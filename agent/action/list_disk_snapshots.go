@@ -0,0 +1,52 @@
+package action
+
+import (
+	"errors"
+
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type ListDiskSnapshotsAction struct {
+	snapshotter boshdisk.Snapshotter
+	dirProvider boshdirs.Provider
+}
+
+func NewListDiskSnapshots(
+	snapshotter boshdisk.Snapshotter,
+	dirProvider boshdirs.Provider,
+) (action ListDiskSnapshotsAction) {
+	action.snapshotter = snapshotter
+	action.dirProvider = dirProvider
+	return
+}
+
+func (a ListDiskSnapshotsAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a ListDiskSnapshotsAction) IsPersistent() bool {
+	return false
+}
+
+func (a ListDiskSnapshotsAction) IsLoggable() bool {
+	return true
+}
+
+func (a ListDiskSnapshotsAction) Run() ([]boshdisk.Snapshot, error) {
+	snapshots, err := a.snapshotter.ListSnapshots(a.dirProvider.StoreDir())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing persistent disk snapshots")
+	}
+
+	return snapshots, nil
+}
+
+func (a ListDiskSnapshotsAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ListDiskSnapshotsAction) Cancel() error {
+	return errors.New("not supported")
+}
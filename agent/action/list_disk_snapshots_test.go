@@ -0,0 +1,40 @@
+package action_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	"github.com/cloudfoundry/bosh-agent/platform/disk/diskfakes"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+)
+
+var _ = Describe("ListDiskSnapshotsAction", func() {
+	var (
+		snapshotter             *diskfakes.FakeSnapshotter
+		listDiskSnapshotsAction action.ListDiskSnapshotsAction
+	)
+
+	BeforeEach(func() {
+		snapshotter = &diskfakes.FakeSnapshotter{}
+		listDiskSnapshotsAction = action.NewListDiskSnapshots(snapshotter, boshdirs.NewProvider("/fake-base-dir"))
+	})
+
+	AssertActionIsNotAsynchronous(listDiskSnapshotsAction)
+	AssertActionIsNotPersistent(listDiskSnapshotsAction)
+	AssertActionIsLoggable(listDiskSnapshotsAction)
+
+	AssertActionIsNotResumable(listDiskSnapshotsAction)
+	AssertActionIsNotCancelable(listDiskSnapshotsAction)
+
+	It("lists snapshots of the store directory", func() {
+		snapshotter.ListSnapshotsReturns([]boshdisk.Snapshot{{Name: "pre-deploy"}}, nil)
+
+		snapshots, err := listDiskSnapshotsAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(Equal([]boshdisk.Snapshot{{Name: "pre-deploy"}}))
+		Expect(snapshotter.ListSnapshotsArgsForCall(0)).To(boshassert.MatchPath("/fake-base-dir/store"))
+	})
+})
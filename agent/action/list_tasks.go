@@ -0,0 +1,53 @@
+package action
+
+import (
+	"errors"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type ListTasksAction struct {
+	taskJournal boshtask.Journal
+}
+
+func NewListTasks(taskJournal boshtask.Journal) (listTasks ListTasksAction) {
+	listTasks.taskJournal = taskJournal
+	return
+}
+
+func (a ListTasksAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a ListTasksAction) IsPersistent() bool {
+	return false
+}
+
+func (a ListTasksAction) IsLoggable() bool {
+	return true
+}
+
+// Run accepts an optional filter, e.g.
+// {"method": "compile_package", "state": "failed", "since": "2006-01-02T15:04:05Z"}
+func (a ListTasksAction) Run(filters ...boshtask.JournalFilter) ([]boshtask.JournalEntry, error) {
+	var filter boshtask.JournalFilter
+	if len(filters) > 0 {
+		filter = filters[0]
+	}
+
+	entries, err := a.taskJournal.List(filter)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing tasks")
+	}
+
+	return entries, nil
+}
+
+func (a ListTasksAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ListTasksAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,61 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
+)
+
+var _ = Describe("ListTasks", func() {
+	var (
+		taskJournal     *faketask.FakeJournal
+		listTasksAction action.ListTasksAction
+	)
+
+	BeforeEach(func() {
+		taskJournal = faketask.NewFakeJournal()
+		listTasksAction = action.NewListTasks(taskJournal)
+	})
+
+	AssertActionIsNotAsynchronous(listTasksAction)
+	AssertActionIsNotPersistent(listTasksAction)
+	AssertActionIsLoggable(listTasksAction)
+
+	AssertActionIsNotResumable(listTasksAction)
+	AssertActionIsNotCancelable(listTasksAction)
+
+	It("returns all journal entries when no filter is given", func() {
+		taskJournal.ListEntries = []boshtask.JournalEntry{{TaskID: "fake-task-id"}}
+
+		entries, err := listTasksAction.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(Equal(taskJournal.ListEntries))
+		Expect(taskJournal.ListFilter).To(Equal(boshtask.JournalFilter{}))
+	})
+
+	It("passes filter to the journal", func() {
+		filter := boshtask.JournalFilter{
+			Method: "fake-method",
+			State:  boshtask.StateFailed,
+			Since:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		}
+
+		_, err := listTasksAction.Run(filter)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(taskJournal.ListFilter).To(Equal(filter))
+	})
+
+	It("returns error when journal cannot be read", func() {
+		taskJournal.ListErr = errors.New("fake-list-err")
+
+		_, err := listTasksAction.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-list-err"))
+	})
+})
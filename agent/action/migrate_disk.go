@@ -3,7 +3,9 @@ package action
 import (
 	"errors"
 
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
@@ -34,8 +36,25 @@ func (a MigrateDiskAction) IsLoggable() bool {
 	return true
 }
 
-func (a MigrateDiskAction) Run() (value interface{}, err error) {
-	err = a.platform.MigratePersistentDisk(a.dirProvider.StoreDir(), a.dirProvider.StoreMigrationDir())
+func (a MigrateDiskAction) Run(progress boshtask.ProgressReporter) (value interface{}, err error) {
+	reportProgress := func(migration boshdisk.MigrationProgress) {
+		percent := 0
+		if migration.TotalBytes > 0 {
+			percent = int(migration.DoneBytes * 100 / migration.TotalBytes)
+		} else if migration.TotalFiles > 0 {
+			percent = int(migration.DoneFiles * 100 / migration.TotalFiles)
+		}
+
+		progress.SetStage(migration.Stage, percent)
+		progress.SetTransfer(boshtask.Transfer{
+			DoneFiles:  migration.DoneFiles,
+			TotalFiles: migration.TotalFiles,
+			DoneBytes:  migration.DoneBytes,
+			TotalBytes: migration.TotalBytes,
+		})
+	}
+
+	err = a.platform.MigratePersistentDisk(a.dirProvider.StoreDir(), a.dirProvider.StoreMigrationDir(), reportProgress)
 	if err != nil {
 		err = bosherr.WrapError(err, "Migrating persistent disk")
 		return
@@ -5,6 +5,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry/bosh-agent/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
@@ -30,13 +32,54 @@ var _ = Describe("Testing with Ginkgo", func() {
 	AssertActionIsNotCancelable(migrateDiskAction)
 
 	It("migrate disk migrateDiskAction run", func() {
-		value, err := migrateDiskAction.Run()
+		value, err := migrateDiskAction.Run(boshtask.NewProgressTracker())
 		Expect(err).ToNot(HaveOccurred())
 		boshassert.MatchesJSONString(GinkgoT(), value, "{}")
 
 		Expect(platform.MigratePersistentDiskCallCount()).To(Equal(1))
-		fromPath, toPath := platform.MigratePersistentDiskArgsForCall(0)
+		fromPath, toPath, _ := platform.MigratePersistentDiskArgsForCall(0)
 		Expect(fromPath).To(boshassert.MatchPath("/foo/store"))
 		Expect(toPath).To(boshassert.MatchPath("/foo/store_migration_target"))
 	})
+
+	It("reports migration progress of the platform", func() {
+		progress := boshtask.NewProgressTracker()
+
+		platform.MigratePersistentDiskStub = func(_, _ string, report boshdisk.MigrationProgressFunc) error {
+			report(boshdisk.MigrationProgress{
+				Stage:      boshdisk.MigrationStageCopying,
+				DoneFiles:  3,
+				TotalFiles: 10,
+				DoneBytes:  256,
+				TotalBytes: 1024,
+			})
+			return nil
+		}
+
+		_, err := migrateDiskAction.Run(progress)
+		Expect(err).ToNot(HaveOccurred())
+
+		snapshot := progress.Snapshot()
+		Expect(snapshot.Stage).To(Equal(boshdisk.MigrationStageCopying))
+		Expect(snapshot.Percent).To(Equal(25))
+		Expect(snapshot.Transfer).To(Equal(&boshtask.Transfer{
+			DoneFiles:  3,
+			TotalFiles: 10,
+			DoneBytes:  256,
+			TotalBytes: 1024,
+		}))
+	})
+
+	It("reports percentage of copied files when disk holds no data", func() {
+		progress := boshtask.NewProgressTracker()
+
+		platform.MigratePersistentDiskStub = func(_, _ string, report boshdisk.MigrationProgressFunc) error {
+			report(boshdisk.MigrationProgress{Stage: boshdisk.MigrationStageCopying, DoneFiles: 1, TotalFiles: 4})
+			return nil
+		}
+
+		_, err := migrateDiskAction.Run(progress)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(progress.Snapshot().Percent).To(Equal(25))
+	})
 })
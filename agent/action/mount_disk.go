@@ -3,15 +3,20 @@ package action
 import (
 	"errors"
 
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
+const mountDiskActionLogTag = "mountDiskAction"
+
 type diskMounter interface {
 	AdjustPersistentDiskPartitioning(diskSettings boshsettings.DiskSettings, mountPoint string) error
 	MountPersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) error
+	IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (bool, error)
+	ResizePersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) (boshplatform.DiskResize, error)
 }
 
 type MountDiskAction struct {
@@ -46,6 +51,11 @@ func (a MountDiskAction) IsLoggable() bool {
 	return true
 }
 
+func (a MountDiskAction) WithLogger(logger boshlog.Logger) Action {
+	a.logger = logger
+	return a
+}
+
 func (a MountDiskAction) Run(diskCid string) (interface{}, error) {
 	err := a.settingsService.LoadSettings()
 	if err != nil {
@@ -59,6 +69,24 @@ func (a MountDiskAction) Run(diskCid string) (interface{}, error) {
 
 	mountPoint := a.dirProvider.StoreDir()
 
+	mounted, err := a.diskMounter.IsPersistentDiskMounted(diskSettings)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Checking whether persistent disk is mounted")
+	}
+
+	// Volumes resized in place by the CPI stay mounted and are grown online.
+	// Mounting an already mounted disk used to do nothing hence resize failures do not fail it.
+	if mounted {
+		resize, err := a.diskMounter.ResizePersistentDisk(diskSettings, mountPoint)
+		if err != nil {
+			a.logger.Error(mountDiskActionLogTag, "Failed to resize already mounted persistent disk %s: %s", diskCid, err.Error())
+		} else {
+			a.logger.Debug(mountDiskActionLogTag, "Persistent disk %s is already mounted, resized: %+v", diskCid, resize)
+		}
+
+		return map[string]string{}, nil
+	}
+
 	err = a.diskMounter.AdjustPersistentDiskPartitioning(diskSettings, mountPoint)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Adjusting persistent disk partitioning")
@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry/bosh-agent/agent/action"
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -115,6 +116,38 @@ var _ = Describe("MountDiskAction", func() {
 					})
 				})
 
+				Context("when disk is already mounted", func() {
+					BeforeEach(func() {
+						settingsService.PersistentDiskSettings = map[string]boshsettings.DiskSettings{
+							"fake-disk-cid": {ID: "fake-disk-cid", Path: "fake-device-path"},
+						}
+						platform.IsPersistentDiskMountedReturns(true, nil)
+					})
+
+					It("grows the mounted disk instead of mounting it again", func() {
+						result, err := mountDiskAction.Run("fake-disk-cid")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(Equal(map[string]string{}))
+
+						Expect(platform.ResizePersistentDiskCallCount()).To(Equal(1))
+						diskSettings, mntPt := platform.ResizePersistentDiskArgsForCall(0)
+						Expect(diskSettings.ID).To(Equal("fake-disk-cid"))
+						Expect(mntPt).To(boshassert.MatchPath("/fake-base-dir/store"))
+
+						Expect(platform.AdjustPersistentDiskPartitioningCallCount()).To(Equal(0))
+						Expect(platform.MountPersistentDiskCallCount()).To(Equal(0))
+					})
+
+					It("succeeds when resizing fails", func() {
+						platform.ResizePersistentDiskReturns(boshplatform.DiskResize{}, errors.New("fake-resize-err"))
+
+						result, err := mountDiskAction.Run("fake-disk-cid")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(Equal(map[string]string{}))
+						Expect(platform.MountPersistentDiskCallCount()).To(Equal(0))
+					})
+				})
+
 				Context("when disk cid cannot be resolved to a device path from infrastructure settings", func() {
 					BeforeEach(func() {
 						settingsService.GetPersistentDiskSettingsError = errors.New("Persistent disk with volume id 'fake-unknown-disk-cid' could not be found")
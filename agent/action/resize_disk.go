@@ -0,0 +1,72 @@
+package action
+
+import (
+	"errors"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type ResizeDiskAction struct {
+	settingsService boshsettings.Service
+	platform        boshplatform.Platform
+	dirProvider     boshdirs.Provider
+}
+
+// NewResizeDisk grows a mounted persistent disk after its volume was
+// resized in place by the CPI, without remounting or migrating it.
+func NewResizeDisk(
+	settingsService boshsettings.Service,
+	platform boshplatform.Platform,
+	dirProvider boshdirs.Provider,
+) (action ResizeDiskAction) {
+	action.settingsService = settingsService
+	action.platform = platform
+	action.dirProvider = dirProvider
+	return
+}
+
+func (a ResizeDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a ResizeDiskAction) IsPersistent() bool {
+	return false
+}
+
+func (a ResizeDiskAction) IsLoggable() bool {
+	return true
+}
+
+func (a ResizeDiskAction) Run(diskCid string) (boshplatform.DiskResize, error) {
+	diskSettings, err := a.settingsService.GetPersistentDiskSettings(diskCid)
+	if err != nil {
+		return boshplatform.DiskResize{}, bosherr.WrapError(err, "Reading persistent disk settings")
+	}
+
+	mounted, err := a.platform.IsPersistentDiskMounted(diskSettings)
+	if err != nil {
+		return boshplatform.DiskResize{}, bosherr.WrapError(err, "Checking whether persistent disk is mounted")
+	}
+
+	if !mounted {
+		return boshplatform.DiskResize{}, bosherr.Errorf("Persistent disk %s is not mounted", diskCid)
+	}
+
+	resize, err := a.platform.ResizePersistentDisk(diskSettings, a.dirProvider.StoreDir())
+	if err != nil {
+		return boshplatform.DiskResize{}, bosherr.WrapError(err, "Resizing persistent disk")
+	}
+
+	return resize, nil
+}
+
+func (a ResizeDiskAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ResizeDiskAction) Cancel() error {
+	return errors.New("not supported")
+}
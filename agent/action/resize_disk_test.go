@@ -0,0 +1,83 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+)
+
+var _ = Describe("ResizeDiskAction", func() {
+	var (
+		settingsService  *fakesettings.FakeSettingsService
+		platform         *platformfakes.FakePlatform
+		resizeDiskAction action.ResizeDiskAction
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{
+			PersistentDiskSettings: map[string]boshsettings.DiskSettings{
+				"fake-disk-cid": {ID: "fake-disk-cid", Path: "fake-device-path"},
+			},
+		}
+		platform = &platformfakes.FakePlatform{}
+		resizeDiskAction = action.NewResizeDisk(settingsService, platform, boshdirs.NewProvider("/fake-base-dir"))
+	})
+
+	AssertActionIsAsynchronous(resizeDiskAction)
+	AssertActionIsNotPersistent(resizeDiskAction)
+	AssertActionIsLoggable(resizeDiskAction)
+
+	AssertActionIsNotResumable(resizeDiskAction)
+	AssertActionIsNotCancelable(resizeDiskAction)
+
+	It("grows mounted disk and reports sizes", func() {
+		platform.IsPersistentDiskMountedReturns(true, nil)
+		platform.ResizePersistentDiskReturns(boshplatform.DiskResize{
+			DeviceSizeBefore:     10,
+			DeviceSizeAfter:      20,
+			FilesystemSizeBefore: 9,
+			FilesystemSizeAfter:  19,
+		}, nil)
+
+		result, err := resizeDiskAction.Run("fake-disk-cid")
+		Expect(err).ToNot(HaveOccurred())
+		boshassert.MatchesJSONString(GinkgoT(), result, `{"device_size_before":10,"device_size_after":20,"filesystem_size_before":9,"filesystem_size_after":19}`)
+
+		diskSettings, mountPoint := platform.ResizePersistentDiskArgsForCall(0)
+		Expect(diskSettings.ID).To(Equal("fake-disk-cid"))
+		Expect(mountPoint).To(boshassert.MatchPath("/fake-base-dir/store"))
+	})
+
+	It("returns an error when disk is not mounted", func() {
+		_, err := resizeDiskAction.Run("fake-disk-cid")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Persistent disk fake-disk-cid is not mounted"))
+		Expect(platform.ResizePersistentDiskCallCount()).To(Equal(0))
+	})
+
+	It("returns an error when disk settings cannot be found", func() {
+		settingsService.GetPersistentDiskSettingsError = errors.New("fake-settings-err")
+
+		_, err := resizeDiskAction.Run("fake-disk-cid")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-settings-err"))
+	})
+
+	It("returns an error when resizing fails", func() {
+		platform.IsPersistentDiskMountedReturns(true, nil)
+		platform.ResizePersistentDiskReturns(boshplatform.DiskResize{}, errors.New("fake-resize-err"))
+
+		_, err := resizeDiskAction.Run("fake-disk-cid")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-resize-err"))
+	})
+})
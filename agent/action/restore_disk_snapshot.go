@@ -0,0 +1,63 @@
+package action
+
+import (
+	"errors"
+
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type RestoreDiskSnapshotAction struct {
+	snapshotter   boshdisk.Snapshotter
+	jobSupervisor boshjobsuper.JobSupervisor
+	dirProvider   boshdirs.Provider
+}
+
+// NewRestoreDiskSnapshot replaces contents of the persistent disk with
+// contents of a snapshot. Jobs must be stopped so that they do not
+// write to the disk while it is being restored.
+func NewRestoreDiskSnapshot(
+	snapshotter boshdisk.Snapshotter,
+	jobSupervisor boshjobsuper.JobSupervisor,
+	dirProvider boshdirs.Provider,
+) (action RestoreDiskSnapshotAction) {
+	action.snapshotter = snapshotter
+	action.jobSupervisor = jobSupervisor
+	action.dirProvider = dirProvider
+	return
+}
+
+func (a RestoreDiskSnapshotAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RestoreDiskSnapshotAction) IsPersistent() bool {
+	return false
+}
+
+func (a RestoreDiskSnapshotAction) IsLoggable() bool {
+	return true
+}
+
+func (a RestoreDiskSnapshotAction) Run(name string) (map[string]string, error) {
+	if status := a.jobSupervisor.Status(); status != "stopped" {
+		return nil, bosherr.Errorf("Jobs must be stopped before restoring persistent disk snapshot, current status is '%s'", status)
+	}
+
+	err := a.snapshotter.RestoreSnapshot(a.dirProvider.StoreDir(), name)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Restoring persistent disk snapshot")
+	}
+
+	return map[string]string{}, nil
+}
+
+func (a RestoreDiskSnapshotAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RestoreDiskSnapshotAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,54 @@
+package action_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	"github.com/cloudfoundry/bosh-agent/platform/disk/diskfakes"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+)
+
+var _ = Describe("RestoreDiskSnapshotAction", func() {
+	var (
+		snapshotter               *diskfakes.FakeSnapshotter
+		jobSupervisor             *fakejobsuper.FakeJobSupervisor
+		restoreDiskSnapshotAction action.RestoreDiskSnapshotAction
+	)
+
+	BeforeEach(func() {
+		snapshotter = &diskfakes.FakeSnapshotter{}
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		restoreDiskSnapshotAction = action.NewRestoreDiskSnapshot(snapshotter, jobSupervisor, boshdirs.NewProvider("/fake-base-dir"))
+	})
+
+	AssertActionIsAsynchronous(restoreDiskSnapshotAction)
+	AssertActionIsNotPersistent(restoreDiskSnapshotAction)
+	AssertActionIsLoggable(restoreDiskSnapshotAction)
+
+	AssertActionIsNotResumable(restoreDiskSnapshotAction)
+	AssertActionIsNotCancelable(restoreDiskSnapshotAction)
+
+	It("restores snapshot when jobs are stopped", func() {
+		jobSupervisor.StatusStatus = "stopped"
+
+		result, err := restoreDiskSnapshotAction.Run("pre-deploy")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(map[string]string{}))
+
+		mountPoint, name := snapshotter.RestoreSnapshotArgsForCall(0)
+		Expect(mountPoint).To(boshassert.MatchPath("/fake-base-dir/store"))
+		Expect(name).To(Equal("pre-deploy"))
+	})
+
+	It("refuses to restore while jobs are running", func() {
+		jobSupervisor.StatusStatus = "running"
+
+		_, err := restoreDiskSnapshotAction.Run("pre-deploy")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Jobs must be stopped"))
+		Expect(snapshotter.RestoreSnapshotCallCount()).To(Equal(0))
+	})
+})
@@ -1,12 +1,17 @@
 package action
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"path"
 	"time"
 
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	"github.com/cloudfoundry/bosh-agent/agent/script/cmd"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -53,13 +58,19 @@ func (a RunErrandAction) IsLoggable() bool {
 	return true
 }
 
+func (a RunErrandAction) WithLogger(logger boshlog.Logger) Action {
+	a.cmdRunner = agentlogger.CmdRunnerWithLogger(a.cmdRunner, logger)
+	a.logger = logger
+	return a
+}
+
 type ErrandResult struct {
 	Stdout     string `json:"stdout"`
 	Stderr     string `json:"stderr"`
 	ExitStatus int    `json:"exit_code"`
 }
 
-func (a RunErrandAction) Run(errandName ...string) (ErrandResult, error) {
+func (a RunErrandAction) Run(progress boshtask.ProgressReporter, errandName ...string) (ErrandResult, error) {
 	currentSpec, err := a.specService.Get()
 	if err != nil {
 		return ErrandResult{}, bosherr.WrapError(err, "Getting current spec")
@@ -90,6 +101,15 @@ func (a RunErrandAction) Run(errandName ...string) (ErrandResult, error) {
 
 	command := cmd.BuildCommand(path.Join(a.jobsDir, templateName, "bin", "run"))
 
+	// Custom writers replace the runner's own output capture,
+	// so keep full copies here in addition to the progress log tail.
+	var stdout, stderr bytes.Buffer
+	logWriter := boshtask.NewProgressLogWriter(progress)
+	command.Stdout = io.MultiWriter(&stdout, logWriter)
+	command.Stderr = io.MultiWriter(&stderr, logWriter)
+
+	progress.SetStage(fmt.Sprintf("Running errand %s", templateName), 0)
+
 	process, err := a.cmdRunner.RunComplexCommandAsync(command)
 	if err != nil {
 		return ErrandResult{}, bosherr.WrapError(err, "Running errand script")
@@ -115,11 +135,23 @@ func (a RunErrandAction) Run(errandName ...string) (ErrandResult, error) {
 		return ErrandResult{}, bosherr.WrapError(result.Error, "Running errand script")
 	}
 
-	return ErrandResult{
+	progress.SetStage(fmt.Sprintf("Errand %s exited with %d", templateName, result.ExitStatus), 100)
+
+	errandResult := ErrandResult{
 		Stdout:     result.Stdout,
 		Stderr:     result.Stderr,
 		ExitStatus: result.ExitStatus,
-	}, nil
+	}
+
+	if stdout.Len() > 0 {
+		errandResult.Stdout = stdout.String()
+	}
+
+	if stderr.Len() > 0 {
+		errandResult.Stderr = stderr.String()
+	}
+
+	return errandResult, nil
 }
 
 func (a RunErrandAction) Resume() (interface{}, error) {
@@ -12,6 +12,7 @@ import (
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	boshenv "github.com/cloudfoundry/bosh-agent/agent/script/pathenv"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -21,6 +22,7 @@ var _ = Describe("RunErrand", func() {
 	var (
 		specService     *fakeas.FakeV1Service
 		cmdRunner       *fakesys.FakeCmdRunner
+		progress        *boshtask.ProgressTracker
 		runErrandAction action.RunErrandAction
 		errandName      string
 		fullCommand     string
@@ -29,6 +31,7 @@ var _ = Describe("RunErrand", func() {
 	BeforeEach(func() {
 		specService = fakeas.NewFakeV1Service()
 		cmdRunner = fakesys.NewFakeCmdRunner()
+		progress = boshtask.NewProgressTracker()
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		runErrandAction = action.NewRunErrand(specService, "/fake-jobs-dir", cmdRunner, logger)
 		errandName = "fake-job-name"
@@ -62,7 +65,7 @@ var _ = Describe("RunErrand", func() {
 				})
 
 				It("returns errand result without error after running an errand", func() {
-					result, err := runErrandAction.Run()
+					result, err := runErrandAction.Run(progress)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(result).To(Equal(
 						action.ErrandResult{
@@ -72,6 +75,25 @@ var _ = Describe("RunErrand", func() {
 						},
 					))
 				})
+
+				It("reports errand progress", func() {
+					_, err := runErrandAction.Run(progress)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(progress.Snapshot()).To(Equal(boshtask.Progress{
+						Stage:   "Errand fake-job-name exited with 0",
+						Percent: 100,
+					}))
+				})
+
+				It("tees errand output into the progress log tail", func() {
+					_, err := runErrandAction.Run(progress)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(cmdRunner.RunComplexCommands).To(HaveLen(1))
+
+					_, err = cmdRunner.RunComplexCommands[0].Stdout.Write([]byte("line one\nline two\n"))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(progress.Snapshot().LogTail).To(Equal([]string{"line one", "line two"}))
+				})
 			})
 
 			Context("when current agent has a job spec template", func() {
@@ -100,7 +122,7 @@ var _ = Describe("RunErrand", func() {
 					})
 
 					It("returns errand result without error after running an errand", func() {
-						result, err := runErrandAction.Run(errandName)
+						result, err := runErrandAction.Run(progress, errandName)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(result).To(Equal(
 							action.ErrandResult{
@@ -112,7 +134,7 @@ var _ = Describe("RunErrand", func() {
 					})
 
 					It("runs errand script with properly configured environment", func() {
-						_, err := runErrandAction.Run(errandName)
+						_, err := runErrandAction.Run(progress, errandName)
 						Expect(err).ToNot(HaveOccurred())
 						cmd := cmdRunner.RunComplexCommands[0]
 						env := map[string]string{"PATH": boshenv.Path()}
@@ -133,7 +155,7 @@ var _ = Describe("RunErrand", func() {
 					})
 
 					It("returns errand result without an error", func() {
-						result, err := runErrandAction.Run(errandName)
+						result, err := runErrandAction.Run(progress, errandName)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(result).To(Equal(
 							action.ErrandResult{
@@ -156,7 +178,7 @@ var _ = Describe("RunErrand", func() {
 					})
 
 					It("returns error because script failed to execute", func() {
-						result, err := runErrandAction.Run(errandName)
+						result, err := runErrandAction.Run(progress, errandName)
 						Expect(err).To(HaveOccurred())
 						Expect(err.Error()).To(ContainSubstring("fake-bosh-error"))
 						Expect(result).To(Equal(action.ErrandResult{}))
@@ -170,13 +192,13 @@ var _ = Describe("RunErrand", func() {
 				})
 
 				It("returns error stating the errand cannot be found", func() {
-					_, err := runErrandAction.Run(errandName)
+					_, err := runErrandAction.Run(progress, errandName)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(Equal("Could not find errand fake-job-name"))
 				})
 
 				It("does not run errand script", func() {
-					_, err := runErrandAction.Run(errandName)
+					_, err := runErrandAction.Run(progress, errandName)
 					Expect(err).To(HaveOccurred())
 					Expect(len(cmdRunner.RunComplexCommands)).To(Equal(0))
 				})
@@ -189,13 +211,13 @@ var _ = Describe("RunErrand", func() {
 			})
 
 			It("returns error stating that job template is required", func() {
-				_, err := runErrandAction.Run(errandName)
+				_, err := runErrandAction.Run(progress, errandName)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-get-error"))
 			})
 
 			It("does not run errand script", func() {
-				_, err := runErrandAction.Run(errandName)
+				_, err := runErrandAction.Run(progress, errandName)
 				Expect(err).To(HaveOccurred())
 				Expect(len(cmdRunner.RunComplexCommands)).To(Equal(0))
 			})
@@ -236,7 +258,7 @@ var _ = Describe("RunErrand", func() {
 				err := runErrandAction.Cancel()
 				Expect(err).ToNot(HaveOccurred())
 
-				_, err = runErrandAction.Run(errandName)
+				_, err = runErrandAction.Run(progress, errandName)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
@@ -259,7 +281,7 @@ var _ = Describe("RunErrand", func() {
 					err := runErrandAction.Cancel()
 					Expect(err).ToNot(HaveOccurred())
 
-					result, err := runErrandAction.Run(errandName)
+					result, err := runErrandAction.Run(progress, errandName)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(result).To(Equal(
 						action.ErrandResult{
@@ -289,7 +311,7 @@ var _ = Describe("RunErrand", func() {
 					err := runErrandAction.Cancel()
 					Expect(err).ToNot(HaveOccurred())
 
-					result, err := runErrandAction.Run(errandName)
+					result, err := runErrandAction.Run(progress, errandName)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(result).To(Equal(
 						action.ErrandResult{
@@ -317,7 +339,7 @@ var _ = Describe("RunErrand", func() {
 					err := runErrandAction.Cancel()
 					Expect(err).ToNot(HaveOccurred())
 
-					result, err := runErrandAction.Run(errandName)
+					result, err := runErrandAction.Run(progress, errandName)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-bosh-error"))
 					Expect(result).To(Equal(action.ErrandResult{}))
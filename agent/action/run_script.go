@@ -47,6 +47,11 @@ func (a RunScriptAction) IsLoggable() bool {
 	return true
 }
 
+func (a RunScriptAction) WithLogger(logger boshlog.Logger) Action {
+	a.logger = logger
+	return a
+}
+
 func (a RunScriptAction) Run(scriptName string, options RunScriptOptions) (map[string]string, error) {
 	// May be used in future to return more information
 	emptyResults := map[string]string{}
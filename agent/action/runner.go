@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"reflect"
 
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
+var progressReporterType = reflect.TypeOf((*boshtask.ProgressReporter)(nil)).Elem()
+
 type Runner interface {
 	Run(action Action, payload []byte, protocolVersion ProtocolVersion) (value interface{}, err error)
+	RunWithProgress(action Action, payload []byte, protocolVersion ProtocolVersion, progress boshtask.ProgressReporter) (value interface{}, err error)
 	Resume(action Action, payload []byte) (value interface{}, err error)
 }
 
@@ -20,6 +24,12 @@ func NewRunner() Runner {
 type concreteRunner struct{}
 
 func (r concreteRunner) Run(action Action, payloadBytes []byte, protocolVersion ProtocolVersion) (value interface{}, err error) {
+	return r.RunWithProgress(action, payloadBytes, protocolVersion, boshtask.NewProgressTracker())
+}
+
+// RunWithProgress passes progress to actions whose Run method accepts
+// a boshtask.ProgressReporter right after the optional ProtocolVersion.
+func (r concreteRunner) RunWithProgress(action Action, payloadBytes []byte, protocolVersion ProtocolVersion, progress boshtask.ProgressReporter) (value interface{}, err error) {
 	payloadArgs, err := r.extractJSONArguments(payloadBytes)
 	if err != nil {
 		err = bosherr.WrapError(err, "Extracting json arguments")
@@ -39,7 +49,7 @@ func (r concreteRunner) Run(action Action, payloadBytes []byte, protocolVersion
 		return
 	}
 
-	methodArgs, err := r.extractMethodArgs(runMethodType, protocolVersion, payloadArgs)
+	methodArgs, err := r.extractMethodArgs(runMethodType, protocolVersion, progress, payloadArgs)
 	if err != nil {
 		err = bosherr.WrapError(err, "Extracting method arguments from payload")
 		return
@@ -88,7 +98,7 @@ func (r concreteRunner) invalidReturnTypes(methodType reflect.Type) (valid bool)
 	return
 }
 
-func (r concreteRunner) extractMethodArgs(runMethodType reflect.Type, protocolVersion ProtocolVersion, args []interface{}) ([]reflect.Value, error) {
+func (r concreteRunner) extractMethodArgs(runMethodType reflect.Type, protocolVersion ProtocolVersion, progress boshtask.ProgressReporter, args []interface{}) ([]reflect.Value, error) {
 	methodArgs := []reflect.Value{}
 	numberOfArgs := runMethodType.NumIn()
 	numberOfReqArgs := numberOfArgs
@@ -109,6 +119,12 @@ func (r concreteRunner) extractMethodArgs(runMethodType reflect.Type, protocolVe
 		}
 	}
 
+	if numberOfArgs > argsOffset && runMethodType.In(argsOffset) == progressReporterType {
+		methodArgs = append(methodArgs, reflect.ValueOf(&progress).Elem())
+		numberOfReqArgs--
+		argsOffset++
+	}
+
 	if len(args) < numberOfReqArgs {
 		return methodArgs, bosherr.Errorf("Not enough arguments, expected %d, got %d", numberOfReqArgs, len(args))
 	}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cloudfoundry/bosh-agent/agent/action"
 	fakeaction "github.com/cloudfoundry/bosh-agent/agent/action/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -278,6 +279,42 @@ func (a *actionWithProtocolVersion) Cancel() error {
 	return nil
 }
 
+type actionWithProgress struct {
+	ProtocolVersion action.ProtocolVersion
+	Progress        boshtask.ProgressReporter
+	SubAction       string
+}
+
+func (a *actionWithProgress) IsAsynchronous(_ action.ProtocolVersion) bool {
+	return true
+}
+
+func (a *actionWithProgress) IsPersistent() bool {
+	return false
+}
+
+func (a *actionWithProgress) IsLoggable() bool {
+	return true
+}
+
+func (a *actionWithProgress) Run(protocolVersion action.ProtocolVersion, progress boshtask.ProgressReporter, subAction string) (valueType, error) {
+	a.ProtocolVersion = protocolVersion
+	a.Progress = progress
+	a.SubAction = subAction
+
+	progress.SetStage("fake-stage", 50)
+
+	return valueType{}, nil
+}
+
+func (a *actionWithProgress) Resume() (interface{}, error) {
+	return nil, nil
+}
+
+func (a *actionWithProgress) Cancel() error {
+	return nil
+}
+
 var _ = Describe("concreteRunner", func() {
 	It("runner run parses the payload", func() {
 		runner := action.NewRunner()
@@ -467,4 +504,33 @@ var _ = Describe("concreteRunner", func() {
 		Expect(actionWithProtocolVersion.ProtocolVersion).To(Equal(action.ProtocolVersion(1)))
 		Expect(actionWithProtocolVersion.SubAction).To(Equal("setup"))
 	})
+
+	It("passes progress reporter to run method", func() {
+		runner := action.NewRunner()
+
+		actionWithProgress := &actionWithProgress{}
+		progress := boshtask.NewProgressTracker()
+		payload := `{"arguments":["setup"]}`
+
+		_, err := runner.RunWithProgress(actionWithProgress, []byte(payload), 1, progress)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(actionWithProgress.ProtocolVersion).To(Equal(action.ProtocolVersion(1)))
+		Expect(actionWithProgress.Progress).To(BeIdenticalTo(progress))
+		Expect(actionWithProgress.SubAction).To(Equal("setup"))
+		Expect(progress.Snapshot().Stage).To(Equal("fake-stage"))
+	})
+
+	It("passes a discarding progress reporter when run without progress", func() {
+		runner := action.NewRunner()
+
+		actionWithProgress := &actionWithProgress{}
+		payload := `{"arguments":["setup"]}`
+
+		_, err := runner.Run(actionWithProgress, []byte(payload), 1)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(actionWithProgress.Progress).ToNot(BeNil())
+		Expect(actionWithProgress.SubAction).To(Equal("setup"))
+	})
 })
@@ -0,0 +1,54 @@
+package action
+
+import (
+	"errors"
+
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type SnapshotDiskAction struct {
+	snapshotter boshdisk.Snapshotter
+	dirProvider boshdirs.Provider
+}
+
+// NewSnapshotDisk creates read-only snapshot of the persistent disk
+// so that its contents can be restored if a deploy goes wrong.
+func NewSnapshotDisk(
+	snapshotter boshdisk.Snapshotter,
+	dirProvider boshdirs.Provider,
+) (action SnapshotDiskAction) {
+	action.snapshotter = snapshotter
+	action.dirProvider = dirProvider
+	return
+}
+
+func (a SnapshotDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a SnapshotDiskAction) IsPersistent() bool {
+	return false
+}
+
+func (a SnapshotDiskAction) IsLoggable() bool {
+	return true
+}
+
+func (a SnapshotDiskAction) Run(name string) (boshdisk.Snapshot, error) {
+	snapshot, err := a.snapshotter.CreateSnapshot(a.dirProvider.StoreDir(), name)
+	if err != nil {
+		return boshdisk.Snapshot{}, bosherr.WrapError(err, "Snapshotting persistent disk")
+	}
+
+	return snapshot, nil
+}
+
+func (a SnapshotDiskAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a SnapshotDiskAction) Cancel() error {
+	return errors.New("not supported")
+}
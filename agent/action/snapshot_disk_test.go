@@ -0,0 +1,58 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/action"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	"github.com/cloudfoundry/bosh-agent/platform/disk/diskfakes"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+)
+
+var _ = Describe("SnapshotDiskAction", func() {
+	var (
+		snapshotter        *diskfakes.FakeSnapshotter
+		snapshotDiskAction action.SnapshotDiskAction
+	)
+
+	BeforeEach(func() {
+		snapshotter = &diskfakes.FakeSnapshotter{}
+		snapshotDiskAction = action.NewSnapshotDisk(snapshotter, boshdirs.NewProvider("/fake-base-dir"))
+	})
+
+	AssertActionIsAsynchronous(snapshotDiskAction)
+	AssertActionIsNotPersistent(snapshotDiskAction)
+	AssertActionIsLoggable(snapshotDiskAction)
+
+	AssertActionIsNotResumable(snapshotDiskAction)
+	AssertActionIsNotCancelable(snapshotDiskAction)
+
+	It("snapshots the store directory", func() {
+		snapshotter.CreateSnapshotReturns(boshdisk.Snapshot{
+			Name:      "pre-deploy",
+			Path:      "/fake-base-dir/store/.snapshots/pre-deploy",
+			CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		}, nil)
+
+		result, err := snapshotDiskAction.Run("pre-deploy")
+		Expect(err).ToNot(HaveOccurred())
+		boshassert.MatchesJSONString(GinkgoT(), result, `{"name":"pre-deploy","path":"/fake-base-dir/store/.snapshots/pre-deploy","created_at":"2020-01-02T03:04:05Z"}`)
+
+		mountPoint, name := snapshotter.CreateSnapshotArgsForCall(0)
+		Expect(mountPoint).To(boshassert.MatchPath("/fake-base-dir/store"))
+		Expect(name).To(Equal("pre-deploy"))
+	})
+
+	It("returns an error when snapshotting fails", func() {
+		snapshotter.CreateSnapshotReturns(boshdisk.Snapshot{}, errors.New("fake-err"))
+
+		_, err := snapshotDiskAction.Run("pre-deploy")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-err"))
+	})
+})
@@ -43,6 +43,11 @@ func (a SSHAction) IsLoggable() bool {
 	return true
 }
 
+func (a SSHAction) WithLogger(logger boshlog.Logger) Action {
+	a.logger = logger
+	return a
+}
+
 type SSHParams struct {
 	UserRegex string `json:"user_regex"`
 	User      string
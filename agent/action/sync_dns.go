@@ -52,6 +52,11 @@ func (a SyncDNS) IsLoggable() bool {
 	return true
 }
 
+func (a SyncDNS) WithLogger(logger boshlog.Logger) Action {
+	a.logger = logger
+	return a
+}
+
 func (a SyncDNS) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
@@ -59,6 +59,11 @@ func (a SyncDNSWithSignedURL) IsLoggable() bool {
 	return true
 }
 
+func (a SyncDNSWithSignedURL) WithLogger(logger boshlog.Logger) Action {
+	a.logger = logger
+	return a
+}
+
 func (a SyncDNSWithSignedURL) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
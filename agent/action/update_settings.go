@@ -41,6 +41,11 @@ func (a UpdateSettingsAction) IsLoggable() bool {
 	return true
 }
 
+func (a UpdateSettingsAction) WithLogger(logger logger.Logger) Action {
+	a.logger = logger
+	return a
+}
+
 func (a UpdateSettingsAction) Run(newUpdateSettings boshsettings.UpdateSettings) (string, error) {
 	var restartNeeded bool
 	err := a.settingsService.LoadSettings()
@@ -4,6 +4,7 @@ import (
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
@@ -19,6 +20,7 @@ type concreteActionDispatcher struct {
 	logger        boshlog.Logger
 	taskService   boshtask.Service
 	taskManager   boshtask.Manager
+	taskJournal   boshtask.Journal
 	actionFactory boshaction.Factory
 	actionRunner  boshaction.Runner
 }
@@ -27,6 +29,7 @@ func NewActionDispatcher(
 	logger boshlog.Logger,
 	taskService boshtask.Service,
 	taskManager boshtask.Manager,
+	taskJournal boshtask.Journal,
 	actionFactory boshaction.Factory,
 	actionRunner boshaction.Runner,
 ) (dispatcher ActionDispatcher) {
@@ -34,6 +37,7 @@ func NewActionDispatcher(
 		logger:        logger,
 		taskService:   taskService,
 		taskManager:   taskManager,
+		taskJournal:   taskJournal,
 		actionFactory: actionFactory,
 		actionRunner:  actionRunner,
 	}
@@ -62,30 +66,35 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 
 		taskID := taskInfo.TaskID
 		payload := taskInfo.Payload
+		method := taskInfo.Method
 
-		task := dispatcher.taskService.CreateTaskWithID(
+		taskDispatcher := dispatcher.withLogger(agentlogger.WithFields(dispatcher.logger, agentlogger.Fields{"method": method}))
+
+		var task boshtask.Task
+
+		task = dispatcher.taskService.CreateTaskWithID(
 			taskID,
-			func() (interface{}, error) { return dispatcher.actionRunner.Resume(action, payload) },
+			func() (interface{}, error) {
+				return dispatcher.actionRunner.Resume(boshaction.WithLogger(action, task.Logger(taskDispatcher.logger)), payload)
+			},
 			func(_ boshtask.Task) error { return action.Cancel() },
-			dispatcher.removeInfo,
+			taskDispatcher.endTask(action, true),
 		)
+		task = task.WithLogger(taskDispatcher.logger)
 
 		dispatcher.taskService.StartTask(task)
 	}
 }
 
 func (dispatcher concreteActionDispatcher) Dispatch(req boshhandler.Request) boshhandler.Response {
+	dispatcher = dispatcher.withLogger(req.Logger(dispatcher.logger))
+
 	action, err := dispatcher.actionFactory.Create(req.Method)
 	if err != nil {
 		dispatcher.logger.Error(actionDispatcherLogTag, "Unknown action %s", req.Method)
 		return boshhandler.NewExceptionResponse(bosherr.Errorf("unknown message %s", req.Method))
 	}
 
-	dispatcher.logger.Info(actionDispatcherLogTag, "Received request with action %s", req.Method)
-	if action.IsLoggable() {
-		dispatcher.logger.DebugWithDetails(actionDispatcherLogTag, "Payload", req.Payload)
-	}
-
 	if action.IsAsynchronous(boshaction.ProtocolVersion(req.ProtocolVersion)) {
 		return dispatcher.dispatchAsynchronousAction(action, req)
 	}
@@ -97,13 +106,14 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	action boshaction.Action,
 	req boshhandler.Request,
 ) boshhandler.Response {
-	dispatcher.logger.Info(actionDispatcherLogTag, "Running async action %s", req.Method)
-
 	var task boshtask.Task
 	var err error
 
+	// task is assigned below before it is started, so the closure
+	// sees the progress tracker and logger created along with it.
 	runTask := func() (interface{}, error) {
-		return dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
+		taskAction := boshaction.WithLogger(action, task.Logger(dispatcher.logger))
+		return dispatcher.actionRunner.RunWithProgress(taskAction, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion), task.Progress)
 	}
 
 	cancelTask := func(_ boshtask.Task) error { return action.Cancel() }
@@ -112,14 +122,18 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	// after agent restart so that API consumers do not need to know
 	// if agent is restarted midway through the task.
 	if action.IsPersistent() {
-		dispatcher.logger.Info(actionDispatcherLogTag, "Running persistent action %s", req.Method)
-		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, dispatcher.removeInfo)
+		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, dispatcher.endTask(action, true))
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Create Task Failed %s", req.Method)
 			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
 			return boshhandler.NewExceptionResponse(err)
 		}
 
+		task = task.WithLogger(dispatcher.logger)
+		taskDispatcher := dispatcher.withLogger(task.Logger(dispatcher.logger))
+		taskDispatcher.logRequest(action, req)
+		taskDispatcher.logger.Info(actionDispatcherLogTag, "Running persistent action %s", req.Method)
+
 		taskInfo := boshtask.Info{
 			TaskID:  task.ID,
 			Method:  req.Method,
@@ -129,16 +143,27 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 		err = dispatcher.taskManager.AddInfo(taskInfo)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Action Failed %s", req.Method)
-			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+			taskDispatcher.logger.Error(actionDispatcherLogTag, err.Error())
 			return boshhandler.NewExceptionResponse(err)
 		}
 	} else {
-		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, nil)
+		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, dispatcher.endTask(action, false))
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Create Task Failed %s", req.Method)
 			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
 			return boshhandler.NewExceptionResponse(err)
 		}
+
+		task = task.WithLogger(dispatcher.logger)
+		taskDispatcher := dispatcher.withLogger(task.Logger(dispatcher.logger))
+		taskDispatcher.logRequest(action, req)
+		taskDispatcher.logger.Info(actionDispatcherLogTag, "Running async action %s", req.Method)
+	}
+
+	err = dispatcher.taskJournal.RecordStart(task.ID, req.Method, req.GetPayload(), action.IsLoggable())
+	if err != nil {
+		// Journal is only used for troubleshooting so it must not fail the request
+		task.Logger(dispatcher.logger).Error(actionDispatcherLogTag, "Failed to record task start: %s", err.Error())
 	}
 
 	dispatcher.taskService.StartTask(task)
@@ -153,9 +178,10 @@ func (dispatcher concreteActionDispatcher) dispatchSynchronousAction(
 	action boshaction.Action,
 	req boshhandler.Request,
 ) boshhandler.Response {
+	dispatcher.logRequest(action, req)
 	dispatcher.logger.Info(actionDispatcherLogTag, "Running sync action %s", req.Method)
 
-	value, err := dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
+	value, err := dispatcher.actionRunner.Run(boshaction.WithLogger(action, dispatcher.logger), req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
 	if err != nil {
 		err = bosherr.WrapErrorf(err, "Action Failed %s", req.Method)
 		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
@@ -165,6 +191,37 @@ func (dispatcher concreteActionDispatcher) dispatchSynchronousAction(
 	return boshhandler.NewValueResponse(value)
 }
 
+func (dispatcher concreteActionDispatcher) endTask(action boshaction.Action, persistent bool) boshtask.EndFunc {
+	return func(task boshtask.Task) {
+		dispatcher := dispatcher.withLogger(task.Logger(dispatcher.logger))
+
+		err := dispatcher.taskJournal.RecordEnd(task, action.IsLoggable())
+		if err != nil {
+			dispatcher.logger.Error(actionDispatcherLogTag, "Failed to record task end: %s", err.Error())
+		}
+
+		if persistent {
+			dispatcher.removeInfo(task)
+		}
+	}
+}
+
+// logRequest is logged once task ID of async requests is known
+// so that request can be correlated with lines of its task
+func (dispatcher concreteActionDispatcher) logRequest(action boshaction.Action, req boshhandler.Request) {
+	dispatcher.logger.Info(actionDispatcherLogTag, "Received request with action %s", req.Method)
+	if action.IsLoggable() {
+		dispatcher.logger.DebugWithDetails(actionDispatcherLogTag, "Payload", req.Payload)
+	}
+}
+
+// withLogger returns a dispatcher logging with logger, e.g. one
+// correlating its lines with the request or task being handled
+func (dispatcher concreteActionDispatcher) withLogger(logger boshlog.Logger) concreteActionDispatcher {
+	dispatcher.logger = logger
+	return dispatcher
+}
+
 func (dispatcher concreteActionDispatcher) removeInfo(task boshtask.Task) {
 	err := dispatcher.taskManager.RemoveInfo(task.ID)
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 
 	"github.com/cloudfoundry/bosh-agent/agent"
 	"github.com/cloudfoundry/bosh-agent/agent/action"
@@ -14,7 +15,9 @@ import (
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakes "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
 )
 
@@ -24,6 +27,7 @@ func init() { //nolint:funlen,gochecknoinits
 			logger        *fakes.FakeLogger
 			taskService   *faketask.FakeService
 			taskManager   *faketask.FakeManager
+			taskJournal   *faketask.FakeJournal
 			actionFactory *fakeaction.FakeFactory
 			actionRunner  *fakeaction.FakeRunner
 			dispatcher    agent.ActionDispatcher
@@ -33,9 +37,10 @@ func init() { //nolint:funlen,gochecknoinits
 			logger = &fakes.FakeLogger{}
 			taskService = faketask.NewFakeService()
 			taskManager = faketask.NewFakeManager()
+			taskJournal = faketask.NewFakeJournal()
 			actionFactory = fakeaction.NewFakeFactory()
 			actionRunner = &fakeaction.FakeRunner{}
-			dispatcher = agent.NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner)
+			dispatcher = agent.NewActionDispatcher(logger, taskService, taskManager, taskJournal, actionFactory, actionRunner)
 		})
 
 		It("responds with exception when the method is unknown", func() {
@@ -46,6 +51,47 @@ func init() { //nolint:funlen,gochecknoinits
 			boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"unknown message fake-action"}}`)
 		})
 
+		It("correlates its log lines with request and task", func() {
+			outBuf := gbytes.NewBuffer()
+			jsonLogger := agentlogger.NewJSONLogger(boshlog.LevelDebug, outBuf)
+			dispatcher = agent.NewActionDispatcher(jsonLogger, taskService, taskManager, taskJournal, actionFactory, actionRunner)
+
+			action := &fakeaction.TestAction{Asynchronous: true}
+			actionFactory.RegisterAction("fake-action", action)
+			taskJournal.RecordEndErr = errors.New("fake-record-end-err")
+
+			dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0))
+			Expect(outBuf).To(gbytes.Say(`"message":"Received request with action fake-action","method":"fake-action","protocol_version":0,"request_id":"fake-reply","tag":"Action Dispatcher","task_id":"fake-generated-task-id"`))
+
+			task := taskService.StartedTasks["fake-generated-task-id"]
+			task.Logger(nil).Error("Task Service", "fake-task-failure")
+			Expect(outBuf).To(gbytes.Say(`"message":"fake-task-failure","method":"fake-action","protocol_version":0,"request_id":"fake-reply","tag":"Task Service","task_id":"fake-generated-task-id"`))
+
+			_, err := task.Func()
+			Expect(err).ToNot(HaveOccurred())
+			action.Logger.Info("fake-action", "fake-action-line")
+			Expect(outBuf).To(gbytes.Say(`"message":"fake-action-line","method":"fake-action","protocol_version":0,"request_id":"fake-reply","tag":"fake-action","task_id":"fake-generated-task-id"`))
+
+			task.EndFunc(task)
+			Expect(outBuf).To(gbytes.Say(`"message":"Failed to record task end: fake-record-end-err","method":"fake-action","protocol_version":0,"request_id":"fake-reply","tag":"Action Dispatcher","task_id":"fake-generated-task-id"`))
+		})
+
+		It("passes logger of request to synchronous actions", func() {
+			outBuf := gbytes.NewBuffer()
+			jsonLogger := agentlogger.NewJSONLogger(boshlog.LevelDebug, outBuf)
+			dispatcher = agent.NewActionDispatcher(jsonLogger, taskService, taskManager, taskJournal, actionFactory, actionRunner)
+
+			action := &fakeaction.TestAction{}
+			actionFactory.RegisterAction("fake-action", action)
+
+			req := boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0)
+			dispatcher.Dispatch(req.WithLogger(jsonLogger))
+			Expect(actionRunner.RunAction).To(Equal(action))
+
+			action.Logger.Info("fake-action", "fake-action-line")
+			Expect(outBuf).To(gbytes.Say(`"message":"fake-action-line","method":"fake-action","protocol_version":0,"request_id":"fake-reply","tag":"fake-action"`))
+		})
+
 		Context("Action Payload Logging", func() {
 			var (
 				action *fakeaction.TestAction
@@ -247,6 +293,17 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(string(actionRunner.RunPayload)).To(Equal("fake-payload"))
 				})
 
+				It("passes task progress to the action runner", func() {
+					dispatcher.Dispatch(req)
+
+					task := taskService.StartedTasks["fake-generated-task-id"]
+					_, err := task.Func()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(task.Progress).ToNot(BeNil())
+					Expect(actionRunner.RunProgress).To(BeIdenticalTo(task.Progress))
+				})
+
 				It("returns run error to the task", func() {
 					actionRunner.RunErr = errors.New("fake-run-error")
 					dispatcher.Dispatch(req)
@@ -268,9 +325,22 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(taskInfos).To(BeEmpty())
 				})
 
-				It("does not do anything after task finishes", func() {
+				It("records task in the journal when it starts and finishes", func() {
+					dispatcher.Dispatch(req)
+					Expect(taskJournal.StartedTaskIDs).To(Equal([]string{"fake-generated-task-id"}))
+					Expect(taskJournal.StartedMethods).To(Equal([]string{"fake-action"}))
+					Expect(string(taskJournal.StartedPayload)).To(Equal("fake-payload"))
+
+					finishedTask := boshtask.Task{ID: "fake-generated-task-id", State: boshtask.StateDone}
+					taskService.StartedTasks["fake-generated-task-id"].EndFunc(finishedTask)
+					Expect(taskJournal.EndedTasks).To(Equal([]boshtask.Task{finishedTask}))
+				})
+
+				It("still starts task if journal cannot record it", func() {
+					taskJournal.RecordStartErr = errors.New("fake-record-start-err")
+
 					dispatcher.Dispatch(req)
-					Expect(taskService.StartedTasks["fake-generated-task-id"].EndFunc).To(BeNil())
+					Expect(len(taskService.StartedTasks)).To(Equal(1))
 				})
 			})
 
@@ -420,6 +490,8 @@ func init() { //nolint:funlen,gochecknoinits
 				taskInfos, err := taskManager.GetInfos()
 				Expect(err).ToNot(HaveOccurred())
 				Expect(taskInfos).To(BeEmpty())
+
+				Expect(taskJournal.EndedTasks).To(HaveLen(2))
 			})
 
 			It("return resume error to each task", func() {
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"fmt"
 	"time"
 
 	"code.cloudfoundry.org/clock"
@@ -10,6 +11,7 @@ import (
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -46,6 +48,7 @@ type Agent struct {
 	uuidGenerator     boshuuid.Generator
 	timeService       clock.Clock
 	startManager      StartManager
+	alertProcessor    boshalert.Processor
 }
 
 func New(
@@ -60,6 +63,7 @@ func New(
 	uuidGenerator boshuuid.Generator,
 	timeService clock.Clock,
 	startManager StartManager,
+	alertProcessor boshalert.Processor,
 ) Agent {
 	return Agent{
 		logger:            logger,
@@ -73,6 +77,7 @@ func New(
 		uuidGenerator:     uuidGenerator,
 		timeService:       timeService,
 		startManager:      startManager,
+		alertProcessor:    alertProcessor,
 	}
 }
 
@@ -99,6 +104,13 @@ func (a Agent) Run() error {
 		}
 	}()
 
+	go func() {
+		err := a.platform.GetTimeSyncMonitor().MonitorDrift(a.handleClockDrift(errCh))
+		if err != nil {
+			errCh <- err
+		}
+	}()
+
 	return <-errCh
 }
 
@@ -207,14 +219,38 @@ func (a Agent) handleJobFailure(errCh chan error) boshjobsuper.JobFailureHandler
 			a.logger.Error(agentLogTag, "Unknown monit event name `%s', using default severity %d", monitAlert.Event, severity)
 		}
 
-		alert, err := alertAdapter.Alert()
+		err := a.alertProcessor.Process(alertAdapter)
+		if err != nil {
+			errCh <- bosherr.WrapError(err, "Processing monit alert")
+		}
+
+		return nil
+	}
+}
+
+func (a Agent) handleClockDrift(errCh chan error) boshntp.DriftHandler {
+	return func(status boshntp.Status, threshold time.Duration) error {
+		id, err := a.uuidGenerator.Generate()
 		if err != nil {
-			errCh <- bosherr.WrapError(err, "Adapting monit alert")
+			return bosherr.WrapError(err, "Generating clock drift alert id")
+		}
+
+		summary := fmt.Sprintf("Clock offset %s exceeds %s", status.Offset, threshold)
+		if status.Server != "" {
+			summary = fmt.Sprintf("%s (server %s, stratum %d)", summary, status.Server, status.Stratum)
 		}
 
-		err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+		alertAdapter := boshalert.NewEventAdapter(boshalert.Event{
+			ID:       id,
+			Service:  "ntp",
+			Event:    "clock drift",
+			Severity: "warning",
+			Summary:  summary,
+		}, a.settingsService, a.timeService)
+
+		err = a.alertProcessor.Process(alertAdapter)
 		if err != nil {
-			errCh <- bosherr.WrapError(err, "Sending monit alert")
+			errCh <- bosherr.WrapError(err, "Processing clock drift alert")
 		}
 
 		return nil
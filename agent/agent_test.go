@@ -17,6 +17,8 @@ import (
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
 	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	"github.com/cloudfoundry/bosh-agent/platform/ntp/ntpfakes"
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	"github.com/cloudfoundry/bosh-agent/platform/vitals/vitalsfakes"
@@ -38,7 +40,9 @@ func init() { //nolint:funlen,gochecknoinits
 			uuidGenerator    *fakeuuid.FakeGenerator
 			timeService      *fakeclock.FakeClock
 			vitalService     *vitalsfakes.FakeService
+			timeSyncMonitor  *ntpfakes.FakeMonitor
 			startManager     *agentfakes.FakeStartManager
+			alertProcessor   boshalert.Processor
 
 			boshAgent agent.Agent
 		)
@@ -63,6 +67,13 @@ func init() { //nolint:funlen,gochecknoinits
 
 			platform.GetVitalsServiceReturns(vitalService)
 
+			timeSyncMonitor = &ntpfakes.FakeMonitor{}
+			platform.GetTimeSyncMonitorReturns(timeSyncMonitor)
+
+			alertProcessor = boshalert.NewProcessor(func(alert boshalert.Alert) error {
+				return handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+			}, boshalert.Options{}, timeService, logger)
+
 			boshAgent = agent.New(
 				logger,
 				handler,
@@ -75,6 +86,7 @@ func init() { //nolint:funlen,gochecknoinits
 				uuidGenerator,
 				timeService,
 				startManager,
+				alertProcessor,
 			)
 		})
 
@@ -161,6 +173,7 @@ func init() { //nolint:funlen,gochecknoinits
 						uuidGenerator,
 						timeService,
 						startManager,
+						alertProcessor,
 					)
 
 					// Immediately exit after sending initial heartbeat
@@ -288,6 +301,42 @@ func init() { //nolint:funlen,gochecknoinits
 					Message: expectedAlert,
 				}))
 			})
+
+			It("sends clock drift alerts to health manager", func() {
+				handler.KeepOnRunning()
+				uuidGenerator.GeneratedUUID = "fake-uuid"
+
+				timeSyncMonitor.MonitorDriftStub = func(driftHandler boshntp.DriftHandler) error {
+					return driftHandler(boshntp.Status{
+						Synchronized: true,
+						Server:       "fake-ntp-server",
+						Stratum:      2,
+						Offset:       -1500 * time.Millisecond,
+					}, time.Second)
+				}
+
+				handler.SendCallback = func(input fakembus.SendInput) {
+					if input.Topic == boshhandler.Alert {
+						handler.SendErr = errors.New("stop")
+					}
+				}
+
+				err := boshAgent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				Expect(handler.SendInputs()).To(ContainElement(fakembus.SendInput{
+					Target: boshhandler.HealthMonitor,
+					Topic:  boshhandler.Alert,
+					Message: boshalert.Alert{
+						ID:        "fake-uuid",
+						Severity:  boshalert.SeverityWarning,
+						Title:     "ntp - clock drift",
+						Summary:   "Clock offset -1.5s exceeds 1s (server fake-ntp-server, stratum 2)",
+						CreatedAt: timeService.Now().Unix(),
+					},
+				}))
+			})
 		})
 	})
 }
@@ -0,0 +1,152 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/clock"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Event is a structured job failure report posted by job wrappers and
+// supervisors to the alert ingestion endpoint.
+type Event struct {
+	ID      string `json:"id"`
+	Service string `json:"service"`
+	Event   string `json:"event"`
+
+	// Source identifies the reporter, e.g. "bpm" or "systemd"
+	Source string `json:"source"`
+
+	// Severity overrides severity derived from the event name;
+	// one of alert, critical, error, warning or ignored
+	Severity string `json:"severity"`
+
+	ExitCode     *int   `json:"exit_code"`
+	RestartCount int    `json:"restart_count"`
+	Summary      string `json:"summary"`
+
+	// Unix timestamp; time of ingestion is used when unset
+	CreatedAt int64 `json:"created_at"`
+}
+
+type eventAdapter struct {
+	event           Event
+	settingsService boshsettings.Service
+	timeService     clock.Clock
+}
+
+func NewEventAdapter(event Event, settingsService boshsettings.Service, timeService clock.Clock) Adapter {
+	return &eventAdapter{
+		event:           event,
+		settingsService: settingsService,
+		timeService:     timeService,
+	}
+}
+
+func (e *eventAdapter) IsIgnorable() bool {
+	severity, _ := e.Severity()
+	return severity == SeverityIgnored
+}
+
+func (e *eventAdapter) Alert() (Alert, error) {
+	if e.event.Service == "" || e.event.Event == "" {
+		return Alert{}, bosherr.Error("Event must have a service and an event name")
+	}
+
+	if _, found := severityNames[strings.ToLower(e.event.Severity)]; e.event.Severity != "" && !found {
+		return Alert{}, bosherr.Errorf("Unknown severity '%s'", e.event.Severity)
+	}
+
+	severity, _ := e.Severity()
+
+	createdAt := e.event.CreatedAt
+	if createdAt == 0 {
+		createdAt = e.timeService.Now().Unix()
+	}
+
+	return Alert{
+		ID:        e.event.ID,
+		Severity:  severity,
+		Title:     fmt.Sprintf("%s - %s", serviceWithIPs(e.settingsService, e.event.Service), e.event.Event),
+		Summary:   e.summary(),
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// Severity is taken from event if given, otherwise derived from event name
+func (e *eventAdapter) Severity() (severity SeverityLevel, found bool) {
+	if e.event.Severity != "" {
+		severity, found = severityNames[strings.ToLower(e.event.Severity)]
+		if found {
+			return severity, true
+		}
+	}
+
+	var eventToSeverity = map[string]SeverityLevel{
+		"exited":                 SeverityAlert,
+		"oom killed":             SeverityAlert,
+		"restart limit reached":  SeverityAlert,
+		"restarted":              SeverityCritical,
+		"health check failed":    SeverityError,
+		"health check recovered": SeverityIgnored,
+		"started":                SeverityIgnored,
+	}
+
+	event := strings.ReplaceAll(strings.ToLower(e.event.Event), "_", " ")
+
+	severity, found = eventToSeverity[event]
+	if !found {
+		severity = SeverityDefault
+	}
+	return severity, found
+}
+
+func (e *eventAdapter) summary() string {
+	var details []string
+
+	if e.event.ExitCode != nil {
+		details = append(details, fmt.Sprintf("exit code %d", *e.event.ExitCode))
+	}
+
+	if e.event.RestartCount > 0 {
+		details = append(details, fmt.Sprintf("restarted %d times", e.event.RestartCount))
+	}
+
+	if e.event.Source != "" {
+		details = append(details, fmt.Sprintf("reported by %s", e.event.Source))
+	}
+
+	if len(details) == 0 {
+		return e.event.Summary
+	}
+
+	if e.event.Summary == "" {
+		return strings.Join(details, ", ")
+	}
+
+	return fmt.Sprintf("%s (%s)", e.event.Summary, strings.Join(details, ", "))
+}
+
+var severityNames = map[string]SeverityLevel{ //nolint:gochecknoglobals
+	"alert":    SeverityAlert,
+	"critical": SeverityCritical,
+	"error":    SeverityError,
+	"warning":  SeverityWarning,
+	"ignored":  SeverityIgnored,
+}
+
+func serviceWithIPs(settingsService boshsettings.Service, service string) string {
+	settings := settingsService.GetSettings()
+
+	ips := settings.Networks.IPs()
+	sort.Strings(ips)
+
+	if len(ips) > 0 {
+		return fmt.Sprintf("%s (%s)", service, strings.Join(ips, ", "))
+	}
+
+	return service
+}
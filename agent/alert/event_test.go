@@ -0,0 +1,92 @@
+package alert_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+var _ = Describe("eventAdapter", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		timeService     *fakeclock.FakeClock
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		timeService = fakeclock.NewFakeClock(time.Unix(1306076861, 0))
+	})
+
+	It("includes exit code, restart count and source in summary", func() {
+		exitCode := 137
+		settingsService.Settings.Networks = boshsettings.Networks{
+			"fake-net": boshsettings.Network{IP: "192.168.0.1"},
+		}
+
+		adapter := NewEventAdapter(Event{
+			ID:           "fake-id",
+			Service:      "nats",
+			Event:        "oom_killed",
+			Source:       "bpm",
+			ExitCode:     &exitCode,
+			RestartCount: 3,
+			Summary:      "process ran out of memory",
+		}, settingsService, timeService)
+
+		alert, err := adapter.Alert()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(alert).To(Equal(Alert{
+			ID:        "fake-id",
+			Severity:  SeverityAlert,
+			Title:     "nats (192.168.0.1) - oom_killed",
+			Summary:   "process ran out of memory (exit code 137, restarted 3 times, reported by bpm)",
+			CreatedAt: 1306076861,
+		}))
+	})
+
+	It("derives severity from event name", func() {
+		for event, severity := range map[string]SeverityLevel{
+			"exited":                SeverityAlert,
+			"restarted":             SeverityCritical,
+			"health_check_failed":   SeverityError,
+			"Restart Limit Reached": SeverityAlert,
+			"something-else":        SeverityDefault,
+		} {
+			adapter := NewEventAdapter(Event{Service: "nats", Event: event}, settingsService, timeService)
+
+			alert, err := adapter.Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert.Severity).To(Equal(severity), event)
+		}
+	})
+
+	It("lets event override severity", func() {
+		adapter := NewEventAdapter(Event{Service: "nats", Event: "exited", Severity: "warning"}, settingsService, timeService)
+
+		alert, err := adapter.Alert()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(alert.Severity).To(Equal(SeverityWarning))
+	})
+
+	It("is ignorable for uninteresting events", func() {
+		Expect(NewEventAdapter(Event{Service: "nats", Event: "started"}, settingsService, timeService).IsIgnorable()).To(BeTrue())
+		Expect(NewEventAdapter(Event{Service: "nats", Event: "exited", Severity: "ignored"}, settingsService, timeService).IsIgnorable()).To(BeTrue())
+		Expect(NewEventAdapter(Event{Service: "nats", Event: "exited"}, settingsService, timeService).IsIgnorable()).To(BeFalse())
+	})
+
+	It("returns an error for incomplete events", func() {
+		_, err := NewEventAdapter(Event{Event: "exited"}, settingsService, timeService).Alert()
+		Expect(err).To(HaveOccurred())
+
+		_, err = NewEventAdapter(Event{Service: "nats", Event: "exited", Severity: "fatal"}, settingsService, timeService).Alert()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unknown severity 'fatal'"))
+	})
+})
@@ -2,7 +2,6 @@ package alert
 
 import (
 	"fmt"
-	"sort"
 	"strings"
 	"time"
 
@@ -47,16 +46,7 @@ func (m *monitAdapter) Alert() (Alert, error) {
 }
 
 func (m *monitAdapter) title() string {
-	settings := m.settingsService.GetSettings()
-
-	ips := settings.Networks.IPs()
-	sort.Strings(ips)
-
-	service := m.monitAlert.Service
-
-	if len(ips) > 0 {
-		service = fmt.Sprintf("%s (%s)", service, strings.Join(ips, ", "))
-	}
+	service := serviceWithIPs(m.settingsService, m.monitAlert.Service)
 
 	return fmt.Sprintf("%s - %s - %s", service, m.monitAlert.Event, m.monitAlert.Action)
 }
@@ -0,0 +1,140 @@
+package alert
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const processorLogTag = "alertProcessor"
+
+type Options struct {
+	// Address to receive structured alerts on, e.g. "127.0.0.1:2826" or
+	// "unix:///var/vcap/data/sys/run/bosh-agent/alerts.sock".
+	// Endpoint is disabled when empty.
+	Address string
+
+	// Identical alerts are only sent once within this many seconds.
+	// Alerts are not deduplicated when zero.
+	DeduplicationWindowSeconds int
+
+	// Alerts exceeding this limit are dropped until the next minute.
+	// Alerts are not rate limited when zero.
+	MaxAlertsPerMinute int
+}
+
+func (o Options) Enabled() bool {
+	return o.Address != ""
+}
+
+type SendFunc func(Alert) error
+
+type Processor interface {
+	// Process sends alert unless it is ignorable, a duplicate or over
+	// the rate limit; only errors adapting or sending it are returned.
+	Process(adapter Adapter) error
+}
+
+type processor struct {
+	send        SendFunc
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	deduplicationWindow time.Duration
+	maxAlertsPerMinute  int
+
+	lock        sync.Mutex
+	lastSentAt  map[alertKey]time.Time
+	windowStart time.Time
+	windowSent  int
+	suppressed  int
+}
+
+// Alerts are duplicates when only their IDs and timestamps differ
+type alertKey struct {
+	severity SeverityLevel
+	title    string
+	summary  string
+}
+
+// NewProcessor only deduplicates and rate limits alerts when configured
+// so that monit alerts are delivered as they were unless opted in.
+func NewProcessor(send SendFunc, options Options, timeService clock.Clock, logger boshlog.Logger) Processor {
+	return &processor{
+		send:                send,
+		timeService:         timeService,
+		logger:              logger,
+		deduplicationWindow: time.Duration(options.DeduplicationWindowSeconds) * time.Second,
+		maxAlertsPerMinute:  options.MaxAlertsPerMinute,
+		lastSentAt:          map[alertKey]time.Time{},
+	}
+}
+
+func (p *processor) Process(adapter Adapter) error {
+	if adapter.IsIgnorable() {
+		return nil
+	}
+
+	alert, err := adapter.Alert()
+	if err != nil {
+		return bosherr.WrapError(err, "Adapting alert")
+	}
+
+	if !p.admit(alert) {
+		return nil
+	}
+
+	err = p.send(alert)
+	if err != nil {
+		return bosherr.WrapError(err, "Sending alert")
+	}
+
+	return nil
+}
+
+func (p *processor) admit(alert Alert) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.timeService.Now()
+
+	for key, sentAt := range p.lastSentAt {
+		if now.Sub(sentAt) >= p.deduplicationWindow {
+			delete(p.lastSentAt, key)
+		}
+	}
+
+	key := alertKey{severity: alert.Severity, title: alert.Title, summary: alert.Summary}
+
+	if _, found := p.lastSentAt[key]; found {
+		p.logger.Debug(processorLogTag, "Dropping duplicate alert '%s'", alert.Title)
+		return false
+	}
+
+	if now.Sub(p.windowStart) >= time.Minute {
+		if p.suppressed > 0 {
+			p.logger.Warn(processorLogTag, "Dropped %d alerts over the limit of %d per minute", p.suppressed, p.maxAlertsPerMinute)
+		}
+
+		p.windowStart = now
+		p.windowSent = 0
+		p.suppressed = 0
+	}
+
+	if p.maxAlertsPerMinute > 0 && p.windowSent >= p.maxAlertsPerMinute {
+		p.suppressed++
+		p.logger.Debug(processorLogTag, "Dropping alert '%s' over rate limit", alert.Title)
+		return false
+	}
+
+	p.windowSent++
+
+	if p.deduplicationWindow > 0 {
+		p.lastSentAt[key] = now
+	}
+
+	return true
+}
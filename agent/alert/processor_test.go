@@ -0,0 +1,101 @@
+package alert_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("processor", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		timeService     *fakeclock.FakeClock
+		sentAlerts      []Alert
+		sendErr         error
+		processor       Processor
+	)
+
+	eventAdapter := func(service, event string) Adapter {
+		return NewEventAdapter(Event{Service: service, Event: event}, settingsService, timeService)
+	}
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		sentAlerts = nil
+		sendErr = nil
+
+		processor = NewProcessor(func(alert Alert) error {
+			sentAlerts = append(sentAlerts, alert)
+			return sendErr
+		}, Options{DeduplicationWindowSeconds: 30, MaxAlertsPerMinute: 2}, timeService, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("sends alerts", func() {
+		Expect(processor.Process(eventAdapter("nats", "exited"))).To(Succeed())
+		Expect(sentAlerts).To(HaveLen(1))
+		Expect(sentAlerts[0].Title).To(Equal("nats - exited"))
+	})
+
+	It("does not send ignorable alerts", func() {
+		Expect(processor.Process(eventAdapter("nats", "started"))).To(Succeed())
+		Expect(sentAlerts).To(BeEmpty())
+	})
+
+	It("drops duplicates within deduplication window", func() {
+		Expect(processor.Process(eventAdapter("nats", "exited"))).To(Succeed())
+
+		timeService.Increment(29 * time.Second)
+		Expect(processor.Process(eventAdapter("nats", "exited"))).To(Succeed())
+		Expect(sentAlerts).To(HaveLen(1))
+
+		timeService.Increment(1 * time.Second)
+		Expect(processor.Process(eventAdapter("nats", "exited"))).To(Succeed())
+		Expect(sentAlerts).To(HaveLen(2))
+	})
+
+	It("drops alerts over the rate limit until the next minute", func() {
+		Expect(processor.Process(eventAdapter("nats", "exited"))).To(Succeed())
+		Expect(processor.Process(eventAdapter("redis", "exited"))).To(Succeed())
+		Expect(processor.Process(eventAdapter("postgres", "exited"))).To(Succeed())
+		Expect(sentAlerts).To(HaveLen(2))
+
+		timeService.Increment(1 * time.Minute)
+		Expect(processor.Process(eventAdapter("postgres", "exited"))).To(Succeed())
+		Expect(sentAlerts).To(HaveLen(3))
+	})
+
+	It("sends all alerts when deduplication and rate limiting are not configured", func() {
+		processor = NewProcessor(func(alert Alert) error {
+			sentAlerts = append(sentAlerts, alert)
+			return nil
+		}, Options{}, timeService, boshlog.NewLogger(boshlog.LevelNone))
+
+		for i := 0; i < 100; i++ {
+			Expect(processor.Process(eventAdapter("nats", "exited"))).To(Succeed())
+		}
+		Expect(sentAlerts).To(HaveLen(100))
+	})
+
+	It("returns an error when alert cannot be adapted", func() {
+		err := processor.Process(eventAdapter("", "exited"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Adapting alert"))
+	})
+
+	It("returns an error when sending fails", func() {
+		sendErr = errors.New("fake-send-err")
+
+		err := processor.Process(eventAdapter("nats", "exited"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-send-err"))
+	})
+})
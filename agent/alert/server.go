@@ -0,0 +1,179 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+const (
+	serverLogTag = "AlertServer"
+
+	unixAddressPrefix = "unix://"
+
+	maxEventBytes = 64 * 1024
+)
+
+// Server receives Events as JSON posted to /alerts over TCP or a Unix
+// socket. Like monit's SMTP alerts, any local process may post events;
+// processor's rate limit bounds what reaches the Director.
+type Server struct {
+	options         Options
+	processor       Processor
+	settingsService boshsettings.Service
+	uuidGenerator   boshuuid.Generator
+	timeService     clock.Clock
+	logger          boshlog.Logger
+
+	listener net.Listener
+	server   *http.Server
+}
+
+func NewServer(
+	options Options,
+	processor Processor,
+	settingsService boshsettings.Service,
+	uuidGenerator boshuuid.Generator,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) *Server {
+	return &Server{
+		options:         options,
+		processor:       processor,
+		settingsService: settingsService,
+		uuidGenerator:   uuidGenerator,
+		timeService:     timeService,
+		logger:          logger,
+	}
+}
+
+// Start begins accepting events in the background.
+func (s *Server) Start() error {
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/alerts", s.ServeHTTP)
+
+	s.listener = listener
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		defer s.logger.HandlePanic("Alert Server")
+
+		s.logger.Info(serverLogTag, "Receiving alerts on %s", s.options.Address)
+
+		err := s.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			s.logger.Error(serverLogTag, "Receiving alerts: %s", err.Error())
+		}
+	}()
+
+	return nil
+}
+
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+func (s *Server) Stop() {
+	if s.server != nil {
+		_ = s.server.Close()
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxEventBytes))
+	if err != nil {
+		http.Error(w, "Reading event", http.StatusBadRequest)
+		return
+	}
+
+	var event Event
+
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		http.Error(w, bosherr.WrapError(err, "Unmarshalling event").Error(), http.StatusBadRequest)
+		return
+	}
+
+	if event.ID == "" {
+		event.ID, err = s.uuidGenerator.Generate()
+		if err != nil {
+			http.Error(w, bosherr.WrapError(err, "Generating event id").Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	adapter := NewEventAdapter(event, s.settingsService, s.timeService)
+
+	// Validate before processing so that callers learn about bad events
+	if _, err := adapter.Alert(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.processor.Process(adapter)
+	if err != nil {
+		s.logger.Error(serverLogTag, "Processing alert from %s: %s", event.Service, err.Error())
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) listen() (net.Listener, error) {
+	if !strings.HasPrefix(s.options.Address, unixAddressPrefix) {
+		listener, err := net.Listen("tcp", s.options.Address)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Listening on %s", s.options.Address)
+		}
+		return listener, nil
+	}
+
+	socketPath := strings.TrimPrefix(s.options.Address, unixAddressPrefix)
+
+	// Socket of a previous agent run is left behind after a crash
+	err := os.Remove(socketPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, bosherr.WrapErrorf(err, "Removing stale socket %s", socketPath)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listening on %s", socketPath)
+	}
+
+	// Job processes run as vcap
+	err = os.Chmod(socketPath, 0666)
+	if err != nil {
+		_ = listener.Close()
+		return nil, bosherr.WrapErrorf(err, "Allowing access to %s", socketPath)
+	}
+
+	return listener, nil
+}
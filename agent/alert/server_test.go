@@ -0,0 +1,115 @@
+package alert_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		sentAlerts []Alert
+		server     *Server
+	)
+
+	newServer := func(address string) *Server {
+		settingsService := &fakesettings.FakeSettingsService{}
+		timeService := fakeclock.NewFakeClock(time.Unix(1306076861, 0))
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		options := Options{Address: address}
+		processor := NewProcessor(func(alert Alert) error {
+			sentAlerts = append(sentAlerts, alert)
+			return nil
+		}, options, timeService, logger)
+
+		return NewServer(options, processor, settingsService, &fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"}, timeService, logger)
+	}
+
+	BeforeEach(func() {
+		sentAlerts = nil
+		server = newServer("127.0.0.1:0")
+		Expect(server.Start()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Stop()
+	})
+
+	post := func(client *http.Client, url, body string) *http.Response {
+		resp, err := client.Post(url, "application/json", strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+		return resp
+	}
+
+	It("sends posted events as alerts", func() {
+		resp := post(http.DefaultClient, fmt.Sprintf("http://%s/alerts", server.Addr()), `{"service":"nats","event":"exited","exit_code":1}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+
+		Expect(sentAlerts).To(Equal([]Alert{{
+			ID:        "fake-uuid",
+			Severity:  SeverityAlert,
+			Title:     "nats - exited",
+			Summary:   "exit code 1",
+			CreatedAt: 1306076861,
+		}}))
+	})
+
+	It("rejects invalid events", func() {
+		url := fmt.Sprintf("http://%s/alerts", server.Addr())
+
+		Expect(post(http.DefaultClient, url, `not-json`).StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(post(http.DefaultClient, url, `{"event":"exited"}`).StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(sentAlerts).To(BeEmpty())
+	})
+
+	It("only accepts POST", func() {
+		resp, err := http.Get(fmt.Sprintf("http://%s/alerts", server.Addr()))
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("listens on unix sockets", func() {
+		dir, err := os.MkdirTemp("", "alert-server")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		socketPath := filepath.Join(dir, "alerts.sock")
+		Expect(os.WriteFile(socketPath, []byte("stale"), 0600)).To(Succeed())
+
+		unixServer := newServer("unix://" + socketPath)
+		Expect(unixServer.Start()).To(Succeed())
+		defer unixServer.Stop()
+
+		info, err := os.Stat(socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0666)))
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		}}
+
+		resp := post(client, "http://localhost/alerts", `{"service":"nats","event":"restarted"}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+		Expect(sentAlerts).To(HaveLen(1))
+	})
+})
@@ -2,16 +2,19 @@ package applyspec
 
 import (
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshlogrotate "github.com/cloudfoundry/bosh-agent/platform/logrotate"
 )
 
 type JobTemplateSpec struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
+	Name      string                `json:"name"`
+	Version   string                `json:"version"`
+	Logrotate *boshlogrotate.Policy `json:"logrotate,omitempty"`
 }
 
 func (s *JobTemplateSpec) AsJob() models.Job {
 	return models.Job{
-		Name:    s.Name,
-		Version: s.Version,
+		Name:      s.Name,
+		Version:   s.Version,
+		Logrotate: s.Logrotate,
 	}
 }
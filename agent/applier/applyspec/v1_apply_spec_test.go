@@ -271,6 +271,34 @@ var _ = Describe("V1ApplySpec", func() {
 			spec := V1ApplySpec{}
 			Expect(spec.Jobs()).To(Equal([]models.Job{}))
 		})
+
+		It("returns logrotate policies declared by jobs", func() {
+			specJSON := `{
+				"job": {
+					"templates": [
+						{"name": "fake-job1-name", "version": "fake-job1-version", "logrotate": {"size": "100M", "max_age_days": 3, "rotate": 0, "compress": false}},
+						{"name": "fake-job2-name", "version": "fake-job2-version"}
+					]
+				},
+				"rendered_templates_archive": {"sha1": "fakerenderedtemplatesarchivesha1", "blobstore_id": "fake-rendered-templates-archive-blobstore-id"}
+			}`
+
+			spec := V1ApplySpec{}
+			err := json.Unmarshal([]byte(specJSON), &spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			jobs := spec.Jobs()
+			Expect(jobs).To(HaveLen(2))
+
+			Expect(jobs[0].Logrotate).ToNot(BeNil())
+			Expect(jobs[0].Logrotate.Size).To(Equal("100M"))
+			Expect(jobs[0].Logrotate.MaxAgeDays).To(Equal(3))
+			Expect(jobs[0].Logrotate.RotateCount()).To(Equal(0))
+			Expect(jobs[0].Logrotate.IsCompressed()).To(BeFalse())
+			Expect(jobs[0].Logrotate.IsCopyTruncated()).To(BeTrue())
+
+			Expect(jobs[1].Logrotate).To(BeNil())
+		})
 	})
 
 	Describe("Packages", func() {
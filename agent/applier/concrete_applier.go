@@ -5,6 +5,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/agent/applier/jobs"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/packages"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshlogrotate "github.com/cloudfoundry/bosh-agent/platform/logrotate"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -143,11 +144,29 @@ func (a *concreteApplier) ConfigureJobs(desiredApplySpec as.ApplySpec) error {
 	return nil
 }
 
+// setUpLogrotate renders a rule for every job once any of them declares a
+// policy since job log directories may not exist yet, e.g. on a fresh VM
 func (a *concreteApplier) setUpLogrotate(applySpec as.ApplySpec) error {
+	jobPolicies := map[string]boshlogrotate.Policy{}
+	for _, job := range applySpec.Jobs() {
+		if job.Logrotate != nil {
+			jobPolicies[job.Name] = *job.Logrotate
+		}
+	}
+
+	if len(jobPolicies) > 0 {
+		for _, job := range applySpec.Jobs() {
+			if job.Logrotate == nil {
+				jobPolicies[job.Name] = boshlogrotate.Policy{}
+			}
+		}
+	}
+
 	err := a.logrotateDelegate.SetupLogrotate(
 		boshsettings.VCAPUsername,
 		a.dirProvider.BaseDir(),
 		applySpec.MaxLogFileSize(),
+		jobPolicies,
 	)
 	if err != nil {
 		return bosherr.WrapError(err, "Logrotate setup failed")
@@ -13,6 +13,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	fakepackages "github.com/cloudfoundry/bosh-agent/agent/applier/packages/fakes"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshlogrotate "github.com/cloudfoundry/bosh-agent/platform/logrotate"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
@@ -26,13 +27,14 @@ type FakeLogRotateDelegate struct {
 }
 
 type SetupLogrotateArgs struct {
-	GroupName string
-	BasePath  string
-	Size      string
+	GroupName   string
+	BasePath    string
+	Size        string
+	JobPolicies map[string]boshlogrotate.Policy
 }
 
-func (d *FakeLogRotateDelegate) SetupLogrotate(groupName, basePath, size string) error {
-	d.SetupLogrotateArgs = SetupLogrotateArgs{groupName, basePath, size}
+func (d *FakeLogRotateDelegate) SetupLogrotate(groupName, basePath, size string, jobPolicies map[string]boshlogrotate.Policy) error {
+	d.SetupLogrotateArgs = SetupLogrotateArgs{groupName, basePath, size, jobPolicies}
 	return d.SetupLogrotateErr
 }
 
@@ -306,12 +308,30 @@ var _ = Describe("concreteApplier", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			assert.Equal(GinkgoT(), logRotateDelegate.SetupLogrotateArgs, SetupLogrotateArgs{
-				GroupName: boshsettings.VCAPUsername,
-				BasePath:  filepath.Clean("/fake-base-dir"),
-				Size:      "fake-size",
+				GroupName:   boshsettings.VCAPUsername,
+				BasePath:    filepath.Clean("/fake-base-dir"),
+				Size:        "fake-size",
+				JobPolicies: map[string]boshlogrotate.Policy{},
 			})
 		})
 
+		It("apply sets up logrotation with default policy for jobs which do not declare one", func() {
+			policy := &boshlogrotate.Policy{Size: "50M", MaxAgeDays: 3}
+			jobWithPolicy := models.Job{Name: "fake-job-with-policy", Logrotate: policy}
+			jobWithoutPolicy := models.Job{Name: "fake-job-without-policy"}
+
+			err := agentApplier.Apply(&fakeas.FakeApplySpec{
+				JobResults:           []models.Job{jobWithPolicy, jobWithoutPolicy},
+				MaxLogFileSizeResult: "fake-size",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(logRotateDelegate.SetupLogrotateArgs.JobPolicies).To(Equal(map[string]boshlogrotate.Policy{
+				"fake-job-with-policy":    *policy,
+				"fake-job-without-policy": {},
+			}))
+		})
+
 		It("apply errs if setup logrotate fails", func() {
 			logRotateDelegate.SetupLogrotateErr = errors.New("fake-set-up-logrotate-error")
 
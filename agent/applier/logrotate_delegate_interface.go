@@ -1,5 +1,9 @@
 package applier
 
+import (
+	boshlogrotate "github.com/cloudfoundry/bosh-agent/platform/logrotate"
+)
+
 type LogrotateDelegate interface {
-	SetupLogrotate(groupName, basePath, size string) (err error)
+	SetupLogrotate(groupName, basePath, size string, jobPolicies map[string]boshlogrotate.Policy) (err error)
 }
@@ -3,6 +3,7 @@ package models
 import (
 	"os"
 
+	boshlogrotate "github.com/cloudfoundry/bosh-agent/platform/logrotate"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
@@ -11,6 +12,9 @@ type Job struct {
 	Version string
 	Source  Source
 
+	// Rotation policy declared by the job for its logs, if any
+	Logrotate *boshlogrotate.Policy
+
 	// Packages that this job depends on; however,
 	// currently it will contain packages from all jobs
 	Packages []Package
@@ -0,0 +1,322 @@
+package blobstore
+
+import (
+	"container/list"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const blobCacheLogTag = "blobCache"
+
+var blobCacheKeyPattern = regexp.MustCompile(`^[a-z0-9]+-[a-zA-Z0-9]+$`)
+
+type CacheOptions struct {
+	// Maximum total size of cached blobs in bytes. Cache is disabled when zero.
+	MaxSizeBytes int64
+}
+
+func (o CacheOptions) Enabled() bool {
+	return o.MaxSizeBytes > 0
+}
+
+type CacheStats struct {
+	Hits         uint64 `json:"hits"`
+	Misses       uint64 `json:"misses"`
+	Evictions    uint64 `json:"evictions"`
+	Entries      int    `json:"entries"`
+	SizeBytes    int64  `json:"size_bytes"`
+	MaxSizeBytes int64  `json:"max_size_bytes"`
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . BlobCache
+
+type BlobCache interface {
+	// Get returns path to a verified copy of the cached blob;
+	// caller is responsible for removing it.
+	Get(digest boshcrypto.Digest) (string, bool)
+
+	// Put copies blob at path into the cache if it matches digest.
+	Put(blobPath string, digest boshcrypto.Digest) error
+
+	Stats() CacheStats
+}
+
+type blobCacheEntry struct {
+	key  string
+	size int64
+}
+
+// LRUBlobCache keeps blobs keyed by their strongest digest and evicts
+// least recently used ones once MaxSizeBytes is exceeded. Access times
+// are kept as file modification times so ordering survives restarts.
+type LRUBlobCache struct {
+	workdir string
+	options CacheOptions
+	logger  boshlog.Logger
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // most recently used first
+	size    int64
+	stats   CacheStats
+}
+
+func NewLRUBlobCache(workdir string, options CacheOptions, logger boshlog.Logger) (*LRUBlobCache, error) {
+	c := &LRUBlobCache{
+		workdir: workdir,
+		options: options,
+		logger:  logger,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+
+	if err := mkdir(c.workdir); err != nil {
+		return nil, bosherr.WrapError(err, "Creating blob cache dir")
+	}
+
+	// Leftovers from interrupted copies are never referenced again
+	if err := os.RemoveAll(c.tmpPath()); err != nil {
+		return nil, bosherr.WrapError(err, "Cleaning blob cache tmp dir")
+	}
+
+	if err := mkdir(c.tmpPath()); err != nil {
+		return nil, bosherr.WrapError(err, "Creating blob cache tmp dir")
+	}
+
+	if err := c.loadEntries(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *LRUBlobCache) Get(digest boshcrypto.Digest) (string, bool) {
+	key, ok := blobCacheKey(digest)
+	if !ok {
+		return "", false
+	}
+
+	c.lock.Lock()
+	element, found := c.entries[key]
+	if !found {
+		c.stats.Misses++
+		c.lock.Unlock()
+		return "", false
+	}
+	c.lru.MoveToFront(element)
+	c.lock.Unlock()
+
+	tmpPath, err := c.copyVerified(c.entryPath(key), digest)
+	if err != nil {
+		c.logger.Warn(blobCacheLogTag, "Discarding cached blob %s: %s", key, err.Error())
+
+		c.lock.Lock()
+		// Entry could have been evicted and put again while it was being copied
+		if c.entries[key] == element {
+			c.removeEntry(key)
+		}
+		c.stats.Misses++
+		c.lock.Unlock()
+
+		return "", false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(c.entryPath(key), now, now)
+
+	c.lock.Lock()
+	c.stats.Hits++
+	c.lock.Unlock()
+
+	c.logger.Debug(blobCacheLogTag, "Found blob %s in cache", key)
+
+	return tmpPath, true
+}
+
+func (c *LRUBlobCache) Put(blobPath string, digest boshcrypto.Digest) error {
+	key, ok := blobCacheKey(digest)
+	if !ok {
+		return bosherr.Error("Digest cannot be used as cache key")
+	}
+
+	info, err := os.Stat(blobPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking blob to cache")
+	}
+
+	if info.Size() > c.options.MaxSizeBytes {
+		c.logger.Debug(blobCacheLogTag, "Not caching blob %s larger than cache", key)
+		return nil
+	}
+
+	c.lock.Lock()
+	_, found := c.entries[key]
+	c.lock.Unlock()
+
+	if found {
+		return nil
+	}
+
+	tmpPath, err := c.copyVerified(blobPath, digest)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, c.entryPath(key))
+	if err != nil {
+		os.Remove(tmpPath)
+		return bosherr.WrapError(err, "Moving blob into cache")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, found := c.entries[key]; !found {
+		c.addEntry(key, info.Size())
+	}
+	c.evict()
+
+	return nil
+}
+
+func (c *LRUBlobCache) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	stats.SizeBytes = c.size
+	stats.MaxSizeBytes = c.options.MaxSizeBytes
+
+	return stats
+}
+
+// copyVerified copies srcPath into the tmp dir verifying its digest on
+// the way so that every blob is only read once.
+func (c *LRUBlobCache) copyVerified(srcPath string, digest boshcrypto.Digest) (string, error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening source file")
+	}
+	defer src.Close()
+
+	dest, err := os.CreateTemp(c.tmpPath(), "blob-cache")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating destination file")
+	}
+	defer dest.Close()
+
+	err = digest.Verify(io.TeeReader(src, dest))
+	if err != nil {
+		os.Remove(dest.Name())
+		return "", bosherr.WrapError(err, "Verifying blob")
+	}
+
+	return dest.Name(), nil
+}
+
+func (c *LRUBlobCache) loadEntries() error {
+	dirEntries, err := os.ReadDir(c.workdir)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading blob cache dir")
+	}
+
+	var infos []os.FileInfo
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !blobCacheKeyPattern.MatchString(dirEntry.Name()) {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	// Oldest first so that most recently used ends up in front
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	for _, info := range infos {
+		c.addEntry(info.Name(), info.Size())
+	}
+
+	c.evict()
+
+	return nil
+}
+
+func (c *LRUBlobCache) addEntry(key string, size int64) {
+	c.entries[key] = c.lru.PushFront(blobCacheEntry{key: key, size: size})
+	c.size += size
+}
+
+func (c *LRUBlobCache) removeEntry(key string) {
+	element, found := c.entries[key]
+	if !found {
+		return
+	}
+
+	c.lru.Remove(element)
+	delete(c.entries, key)
+	c.size -= element.Value.(blobCacheEntry).size
+
+	err := os.Remove(c.entryPath(key))
+	if err != nil && !os.IsNotExist(err) {
+		c.logger.Warn(blobCacheLogTag, "Removing cached blob %s: %s", key, err.Error())
+	}
+}
+
+func (c *LRUBlobCache) evict() {
+	for c.size > c.options.MaxSizeBytes && c.lru.Len() > 0 {
+		entry := c.lru.Back().Value.(blobCacheEntry)
+		c.logger.Debug(blobCacheLogTag, "Evicting blob %s from cache", entry.key)
+		c.removeEntry(entry.key)
+		c.stats.Evictions++
+	}
+}
+
+func (c *LRUBlobCache) tmpPath() string {
+	return path.Join(c.workdir, "tmp")
+}
+
+func (c *LRUBlobCache) entryPath(key string) string {
+	return path.Join(c.workdir, key)
+}
+
+// blobCacheKey names cached blobs after the strongest digest only, so
+// lookups do not depend on which weaker digests accompany it.
+func blobCacheKey(digest boshcrypto.Digest) (string, bool) {
+	if digest == nil {
+		return "", false
+	}
+
+	if multipleDigest, ok := digest.(boshcrypto.MultipleDigest); ok {
+		if multipleDigest.String() == "" {
+			return "", false
+		}
+
+		strongest, err := multipleDigest.DigestFor(multipleDigest.Algorithm())
+		if err != nil {
+			return "", false
+		}
+		digest = strongest
+	}
+
+	algorithm := digest.Algorithm().Name()
+	key := algorithm + "-" + strings.TrimPrefix(digest.String(), algorithm+":")
+
+	return key, blobCacheKeyPattern.MatchString(key)
+}
@@ -0,0 +1,199 @@
+package blobstore_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/agent/blobstore"
+)
+
+var _ = Describe("LRUBlobCache", func() {
+	var (
+		basePath string
+		cacheDir string
+		logger   boshlog.Logger
+
+		blobCache *boshagentblobstore.LRUBlobCache
+	)
+
+	writeBlob := func(contents string) (string, boshcrypto.MultipleDigest) {
+		file, err := os.CreateTemp(basePath, "blob")
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(contents)
+		Expect(err).NotTo(HaveOccurred())
+
+		digest, err := boshcrypto.NewMultipleDigest(strings.NewReader(contents), []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1, boshcrypto.DigestAlgorithmSHA256})
+		Expect(err).NotTo(HaveOccurred())
+
+		return file.Name(), digest
+	}
+
+	readFile := func(path string) string {
+		contents, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	newCache := func(maxSizeBytes int64) *boshagentblobstore.LRUBlobCache {
+		cache, err := boshagentblobstore.NewLRUBlobCache(cacheDir, boshagentblobstore.CacheOptions{MaxSizeBytes: maxSizeBytes}, logger)
+		Expect(err).NotTo(HaveOccurred())
+		return cache
+	}
+
+	BeforeEach(func() {
+		var err error
+		basePath, err = os.MkdirTemp("", "blobcache")
+		Expect(err).NotTo(HaveOccurred())
+
+		cacheDir = filepath.Join(basePath, "cache")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+
+		blobCache = newCache(10)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(basePath)).To(Succeed())
+	})
+
+	It("returns a copy of cached blobs", func() {
+		blobPath, digest := writeBlob("smurf")
+		Expect(blobCache.Put(blobPath, digest)).To(Succeed())
+
+		cachedPath, found := blobCache.Get(digest)
+		Expect(found).To(BeTrue())
+		Expect(cachedPath).ToNot(Equal(blobPath))
+		Expect(readFile(cachedPath)).To(Equal("smurf"))
+
+		Expect(os.Remove(cachedPath)).To(Succeed())
+
+		_, found = blobCache.Get(digest)
+		Expect(found).To(BeTrue())
+
+		Expect(blobCache.Stats()).To(Equal(boshagentblobstore.CacheStats{
+			Hits:         2,
+			Entries:      1,
+			SizeBytes:    5,
+			MaxSizeBytes: 10,
+		}))
+	})
+
+	It("looks blobs up by their strongest digest", func() {
+		blobPath, digest := writeBlob("smurf")
+		Expect(blobCache.Put(blobPath, digest)).To(Succeed())
+
+		sha256, err := digest.DigestFor(boshcrypto.DigestAlgorithmSHA256)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, found := blobCache.Get(sha256)
+		Expect(found).To(BeTrue())
+	})
+
+	It("counts misses", func() {
+		_, digest := writeBlob("smurf")
+
+		_, found := blobCache.Get(digest)
+		Expect(found).To(BeFalse())
+		Expect(blobCache.Stats().Misses).To(Equal(uint64(1)))
+	})
+
+	It("does not cache blobs not matching their digest", func() {
+		blobPath, _ := writeBlob("smurf")
+		_, otherDigest := writeBlob("gargamel")
+
+		Expect(blobCache.Put(blobPath, otherDigest)).ToNot(Succeed())
+
+		_, found := blobCache.Get(otherDigest)
+		Expect(found).To(BeFalse())
+		Expect(blobCache.Stats().Entries).To(Equal(0))
+	})
+
+	It("does not cache blobs larger than the cache", func() {
+		blobPath, digest := writeBlob("smurf-village")
+		Expect(blobCache.Put(blobPath, digest)).To(Succeed())
+
+		_, found := blobCache.Get(digest)
+		Expect(found).To(BeFalse())
+	})
+
+	It("evicts least recently used blobs once full", func() {
+		blob1, digest1 := writeBlob("aaaa")
+		blob2, digest2 := writeBlob("bbbb")
+		blob3, digest3 := writeBlob("cccc")
+
+		Expect(blobCache.Put(blob1, digest1)).To(Succeed())
+		Expect(blobCache.Put(blob2, digest2)).To(Succeed())
+
+		_, found := blobCache.Get(digest1)
+		Expect(found).To(BeTrue())
+
+		Expect(blobCache.Put(blob3, digest3)).To(Succeed())
+
+		_, found = blobCache.Get(digest2)
+		Expect(found).To(BeFalse())
+
+		_, found = blobCache.Get(digest1)
+		Expect(found).To(BeTrue())
+
+		_, found = blobCache.Get(digest3)
+		Expect(found).To(BeTrue())
+
+		stats := blobCache.Stats()
+		Expect(stats.Evictions).To(Equal(uint64(1)))
+		Expect(stats.SizeBytes).To(Equal(int64(8)))
+	})
+
+	It("discards cached blobs which no longer match their digest", func() {
+		blobPath, digest := writeBlob("smurf")
+		Expect(blobCache.Put(blobPath, digest)).To(Succeed())
+
+		entries, err := filepath.Glob(filepath.Join(cacheDir, "sha256-*"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(os.WriteFile(entries[0], []byte("evil!"), 0640)).To(Succeed())
+
+		_, found := blobCache.Get(digest)
+		Expect(found).To(BeFalse())
+		Expect(entries[0]).ToNot(BeAnExistingFile())
+		Expect(blobCache.Stats().Entries).To(Equal(0))
+	})
+
+	It("keeps cached blobs and their recency across restarts", func() {
+		blob1, digest1 := writeBlob("aaaa")
+		blob2, digest2 := writeBlob("bbbb")
+		blob3, digest3 := writeBlob("cccc")
+
+		Expect(blobCache.Put(blob1, digest1)).To(Succeed())
+		Expect(blobCache.Put(blob2, digest2)).To(Succeed())
+
+		entries, err := filepath.Glob(filepath.Join(cacheDir, "sha256-*"))
+		Expect(err).NotTo(HaveOccurred())
+		for _, entry := range entries {
+			past := time.Now().Add(-time.Hour)
+			Expect(os.Chtimes(entry, past, past)).To(Succeed())
+		}
+
+		_, found := blobCache.Get(digest1)
+		Expect(found).To(BeTrue())
+
+		blobCache = newCache(10)
+		Expect(blobCache.Stats().Entries).To(Equal(2))
+
+		Expect(blobCache.Put(blob3, digest3)).To(Succeed())
+
+		_, found = blobCache.Get(digest2)
+		Expect(found).To(BeFalse())
+
+		_, found = blobCache.Get(digest1)
+		Expect(found).To(BeTrue())
+	})
+})
@@ -81,6 +81,71 @@ func (m BlobManager) BlobExists(blobID string) bool {
 	return !os.IsNotExist(err)
 }
 
+// UploadOffset returns how many bytes of a resumable upload were received.
+func (m BlobManager) UploadOffset(blobID string) (int64, error) {
+	info, err := os.Stat(m.uploadPath(blobID))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Checking upload")
+	}
+
+	return info.Size(), nil
+}
+
+// WriteChunk appends a chunk to a resumable upload. Chunks must be sent
+// in order, so offset has to match the number of bytes received so far.
+func (m BlobManager) WriteChunk(blobID string, offset int64, r io.Reader) (int64, error) {
+	currentOffset, err := m.UploadOffset(blobID)
+	if err != nil {
+		return 0, err
+	}
+
+	if offset != currentOffset {
+		return currentOffset, UploadOffsetMismatchError{Expected: currentOffset, Actual: offset}
+	}
+
+	file, err := os.OpenFile(m.uploadPath(blobID), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return currentOffset, bosherr.WrapError(err, "Opening upload file")
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, r)
+	if err != nil {
+		return currentOffset + written, bosherr.WrapError(err, "Writing upload chunk")
+	}
+
+	return currentOffset + written, nil
+}
+
+// CommitUpload verifies a completed resumable upload and makes it
+// available as a blob. Uploads failing verification are discarded.
+func (m BlobManager) CommitUpload(blobID string, digest boshcrypto.MultipleDigest) error {
+	uploadPath := m.uploadPath(blobID)
+
+	file, err := os.Open(uploadPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening upload file")
+	}
+
+	err = digest.Verify(file)
+	file.Close()
+
+	if err != nil {
+		os.Remove(uploadPath)
+		return bosherr.WrapErrorf(err, "Checking upload '%s'", blobID)
+	}
+
+	err = os.Rename(uploadPath, m.blobPath(blobID))
+	if err != nil {
+		return bosherr.WrapError(err, "Moving upload to blobs")
+	}
+
+	return nil
+}
+
 func (m BlobManager) copyToTmpFile(srcPath string) (string, error) {
 	dest, err := os.CreateTemp(m.tmpPath(), "blob-manager-copyToTmpFile")
 	if err != nil {
@@ -111,6 +176,10 @@ func (m BlobManager) createDirStructure() error {
 		return err
 	}
 
+	if err := mkdir(m.uploadsPath()); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -122,6 +191,14 @@ func (m BlobManager) tmpPath() string {
 	return path.Join(m.workdir, "tmp")
 }
 
+func (m BlobManager) uploadsPath() string {
+	return path.Join(m.workdir, "uploads")
+}
+
+func (m BlobManager) uploadPath(id string) string {
+	return path.Join(m.uploadsPath(), id)
+}
+
 func (m BlobManager) blobPath(id string) string {
 	return path.Join(m.blobsPath(), id)
 }
@@ -149,3 +226,12 @@ func mkdir(path string) error {
 
 	return nil
 }
+
+type UploadOffsetMismatchError struct {
+	Expected int64
+	Actual   int64
+}
+
+func (e UploadOffsetMismatchError) Error() string {
+	return fmt.Sprintf("Upload chunk starts at byte %d but %d bytes were received", e.Actual, e.Expected)
+}
@@ -15,4 +15,9 @@ type BlobManagerInterface interface {
 	GetPath(blobID string, digest boshcrypto.Digest) (string, error)
 	Delete(blobID string) error
 	BlobExists(blobID string) bool
+
+	// Resumable uploads are staged separately until committed
+	UploadOffset(blobID string) (int64, error)
+	WriteChunk(blobID string, offset int64, reader io.Reader) (int64, error)
+	CommitUpload(blobID string, digest boshcrypto.MultipleDigest) error
 }
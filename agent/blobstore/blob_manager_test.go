@@ -239,4 +239,62 @@ var _ = Describe("Blob Manager", func() {
 			})
 		})
 	})
+
+	Describe("chunked uploads", func() {
+		var digest boshcrypto.MultipleDigest
+
+		BeforeEach(func() {
+			var err error
+			digest, err = boshcrypto.NewMultipleDigest(strings.NewReader("super-smurf-content"), []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("starts uploads at offset zero", func() {
+			offset, err := blobManager.UploadOffset(blobID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(offset).To(Equal(int64(0)))
+		})
+
+		It("stores the blob once all chunks are committed", func() {
+			offset, err := blobManager.WriteChunk(blobID, 0, strings.NewReader("super-"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(offset).To(Equal(int64(6)))
+
+			offset, err = blobManager.WriteChunk(blobID, 6, strings.NewReader("smurf-content"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(offset).To(Equal(int64(19)))
+
+			Expect(blobManager.BlobExists(blobID)).To(BeFalse())
+
+			err = blobManager.CommitUpload(blobID, digest)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(getBlob(blobID)).To(Equal("super-smurf-content"))
+
+			offset, err = blobManager.UploadOffset(blobID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(offset).To(Equal(int64(0)))
+		})
+
+		It("rejects chunks that do not start at the current offset", func() {
+			_, err := blobManager.WriteChunk(blobID, 0, strings.NewReader("super-"))
+			Expect(err).NotTo(HaveOccurred())
+
+			offset, err := blobManager.WriteChunk(blobID, 4, strings.NewReader("er-smurf"))
+			Expect(err).To(Equal(boshagentblobstore.UploadOffsetMismatchError{Expected: 6, Actual: 4}))
+			Expect(offset).To(Equal(int64(6)))
+		})
+
+		It("discards the upload when digest does not match", func() {
+			_, err := blobManager.WriteChunk(blobID, 0, strings.NewReader("super-smurf"))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = blobManager.CommitUpload(blobID, digest)
+			Expect(err).To(HaveOccurred())
+			Expect(blobManager.BlobExists(blobID)).To(BeFalse())
+
+			offset, err := blobManager.UploadOffset(blobID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(offset).To(Equal(int64(0)))
+		})
+	})
 })
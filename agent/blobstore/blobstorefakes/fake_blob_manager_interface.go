@@ -22,6 +22,18 @@ type FakeBlobManagerInterface struct {
 	blobExistsReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	CommitUploadStub        func(string, crypto.MultipleDigest) error
+	commitUploadMutex       sync.RWMutex
+	commitUploadArgsForCall []struct {
+		arg1 string
+		arg2 crypto.MultipleDigest
+	}
+	commitUploadReturns struct {
+		result1 error
+	}
+	commitUploadReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteStub        func(string) error
 	deleteMutex       sync.RWMutex
 	deleteArgsForCall []struct {
@@ -62,6 +74,19 @@ type FakeBlobManagerInterface struct {
 		result1 string
 		result2 error
 	}
+	UploadOffsetStub        func(string) (int64, error)
+	uploadOffsetMutex       sync.RWMutex
+	uploadOffsetArgsForCall []struct {
+		arg1 string
+	}
+	uploadOffsetReturns struct {
+		result1 int64
+		result2 error
+	}
+	uploadOffsetReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
 	WriteStub        func(string, io.Reader) error
 	writeMutex       sync.RWMutex
 	writeArgsForCall []struct {
@@ -74,6 +99,21 @@ type FakeBlobManagerInterface struct {
 	writeReturnsOnCall map[int]struct {
 		result1 error
 	}
+	WriteChunkStub        func(string, int64, io.Reader) (int64, error)
+	writeChunkMutex       sync.RWMutex
+	writeChunkArgsForCall []struct {
+		arg1 string
+		arg2 int64
+		arg3 io.Reader
+	}
+	writeChunkReturns struct {
+		result1 int64
+		result2 error
+	}
+	writeChunkReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -139,6 +179,68 @@ func (fake *FakeBlobManagerInterface) BlobExistsReturnsOnCall(i int, result1 boo
 	}{result1}
 }
 
+func (fake *FakeBlobManagerInterface) CommitUpload(arg1 string, arg2 crypto.MultipleDigest) error {
+	fake.commitUploadMutex.Lock()
+	ret, specificReturn := fake.commitUploadReturnsOnCall[len(fake.commitUploadArgsForCall)]
+	fake.commitUploadArgsForCall = append(fake.commitUploadArgsForCall, struct {
+		arg1 string
+		arg2 crypto.MultipleDigest
+	}{arg1, arg2})
+	stub := fake.CommitUploadStub
+	fakeReturns := fake.commitUploadReturns
+	fake.recordInvocation("CommitUpload", []interface{}{arg1, arg2})
+	fake.commitUploadMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBlobManagerInterface) CommitUploadCallCount() int {
+	fake.commitUploadMutex.RLock()
+	defer fake.commitUploadMutex.RUnlock()
+	return len(fake.commitUploadArgsForCall)
+}
+
+func (fake *FakeBlobManagerInterface) CommitUploadCalls(stub func(string, crypto.MultipleDigest) error) {
+	fake.commitUploadMutex.Lock()
+	defer fake.commitUploadMutex.Unlock()
+	fake.CommitUploadStub = stub
+}
+
+func (fake *FakeBlobManagerInterface) CommitUploadArgsForCall(i int) (string, crypto.MultipleDigest) {
+	fake.commitUploadMutex.RLock()
+	defer fake.commitUploadMutex.RUnlock()
+	argsForCall := fake.commitUploadArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBlobManagerInterface) CommitUploadReturns(result1 error) {
+	fake.commitUploadMutex.Lock()
+	defer fake.commitUploadMutex.Unlock()
+	fake.CommitUploadStub = nil
+	fake.commitUploadReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBlobManagerInterface) CommitUploadReturnsOnCall(i int, result1 error) {
+	fake.commitUploadMutex.Lock()
+	defer fake.commitUploadMutex.Unlock()
+	fake.CommitUploadStub = nil
+	if fake.commitUploadReturnsOnCall == nil {
+		fake.commitUploadReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.commitUploadReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeBlobManagerInterface) Delete(arg1 string) error {
 	fake.deleteMutex.Lock()
 	ret, specificReturn := fake.deleteReturnsOnCall[len(fake.deleteArgsForCall)]
@@ -332,6 +434,70 @@ func (fake *FakeBlobManagerInterface) GetPathReturnsOnCall(i int, result1 string
 	}{result1, result2}
 }
 
+func (fake *FakeBlobManagerInterface) UploadOffset(arg1 string) (int64, error) {
+	fake.uploadOffsetMutex.Lock()
+	ret, specificReturn := fake.uploadOffsetReturnsOnCall[len(fake.uploadOffsetArgsForCall)]
+	fake.uploadOffsetArgsForCall = append(fake.uploadOffsetArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.UploadOffsetStub
+	fakeReturns := fake.uploadOffsetReturns
+	fake.recordInvocation("UploadOffset", []interface{}{arg1})
+	fake.uploadOffsetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBlobManagerInterface) UploadOffsetCallCount() int {
+	fake.uploadOffsetMutex.RLock()
+	defer fake.uploadOffsetMutex.RUnlock()
+	return len(fake.uploadOffsetArgsForCall)
+}
+
+func (fake *FakeBlobManagerInterface) UploadOffsetCalls(stub func(string) (int64, error)) {
+	fake.uploadOffsetMutex.Lock()
+	defer fake.uploadOffsetMutex.Unlock()
+	fake.UploadOffsetStub = stub
+}
+
+func (fake *FakeBlobManagerInterface) UploadOffsetArgsForCall(i int) string {
+	fake.uploadOffsetMutex.RLock()
+	defer fake.uploadOffsetMutex.RUnlock()
+	argsForCall := fake.uploadOffsetArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBlobManagerInterface) UploadOffsetReturns(result1 int64, result2 error) {
+	fake.uploadOffsetMutex.Lock()
+	defer fake.uploadOffsetMutex.Unlock()
+	fake.UploadOffsetStub = nil
+	fake.uploadOffsetReturns = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBlobManagerInterface) UploadOffsetReturnsOnCall(i int, result1 int64, result2 error) {
+	fake.uploadOffsetMutex.Lock()
+	defer fake.uploadOffsetMutex.Unlock()
+	fake.UploadOffsetStub = nil
+	if fake.uploadOffsetReturnsOnCall == nil {
+		fake.uploadOffsetReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.uploadOffsetReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBlobManagerInterface) Write(arg1 string, arg2 io.Reader) error {
 	fake.writeMutex.Lock()
 	ret, specificReturn := fake.writeReturnsOnCall[len(fake.writeArgsForCall)]
@@ -394,19 +560,91 @@ func (fake *FakeBlobManagerInterface) WriteReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeBlobManagerInterface) WriteChunk(arg1 string, arg2 int64, arg3 io.Reader) (int64, error) {
+	fake.writeChunkMutex.Lock()
+	ret, specificReturn := fake.writeChunkReturnsOnCall[len(fake.writeChunkArgsForCall)]
+	fake.writeChunkArgsForCall = append(fake.writeChunkArgsForCall, struct {
+		arg1 string
+		arg2 int64
+		arg3 io.Reader
+	}{arg1, arg2, arg3})
+	stub := fake.WriteChunkStub
+	fakeReturns := fake.writeChunkReturns
+	fake.recordInvocation("WriteChunk", []interface{}{arg1, arg2, arg3})
+	fake.writeChunkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBlobManagerInterface) WriteChunkCallCount() int {
+	fake.writeChunkMutex.RLock()
+	defer fake.writeChunkMutex.RUnlock()
+	return len(fake.writeChunkArgsForCall)
+}
+
+func (fake *FakeBlobManagerInterface) WriteChunkCalls(stub func(string, int64, io.Reader) (int64, error)) {
+	fake.writeChunkMutex.Lock()
+	defer fake.writeChunkMutex.Unlock()
+	fake.WriteChunkStub = stub
+}
+
+func (fake *FakeBlobManagerInterface) WriteChunkArgsForCall(i int) (string, int64, io.Reader) {
+	fake.writeChunkMutex.RLock()
+	defer fake.writeChunkMutex.RUnlock()
+	argsForCall := fake.writeChunkArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeBlobManagerInterface) WriteChunkReturns(result1 int64, result2 error) {
+	fake.writeChunkMutex.Lock()
+	defer fake.writeChunkMutex.Unlock()
+	fake.WriteChunkStub = nil
+	fake.writeChunkReturns = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBlobManagerInterface) WriteChunkReturnsOnCall(i int, result1 int64, result2 error) {
+	fake.writeChunkMutex.Lock()
+	defer fake.writeChunkMutex.Unlock()
+	fake.WriteChunkStub = nil
+	if fake.writeChunkReturnsOnCall == nil {
+		fake.writeChunkReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.writeChunkReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBlobManagerInterface) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.blobExistsMutex.RLock()
 	defer fake.blobExistsMutex.RUnlock()
+	fake.commitUploadMutex.RLock()
+	defer fake.commitUploadMutex.RUnlock()
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
 	fake.fetchMutex.RLock()
 	defer fake.fetchMutex.RUnlock()
 	fake.getPathMutex.RLock()
 	defer fake.getPathMutex.RUnlock()
+	fake.uploadOffsetMutex.RLock()
+	defer fake.uploadOffsetMutex.RUnlock()
 	fake.writeMutex.RLock()
 	defer fake.writeMutex.RUnlock()
+	fake.writeChunkMutex.RLock()
+	defer fake.writeChunkMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -11,17 +11,20 @@ const logTag = "cascadingBlobstore"
 type cascadingBlobstore struct {
 	innerBlobstore utilblobstore.DigestBlobstore
 	blobManagers   []BlobManagerInterface
+	blobCache      BlobCache
 	logger         boshlog.Logger
 }
 
 func NewCascadingBlobstore(
 	innerBlobstore utilblobstore.DigestBlobstore,
 	blobManagers []BlobManagerInterface,
+	blobCache BlobCache,
 	logger boshlog.Logger,
 ) utilblobstore.DigestBlobstore {
 	return cascadingBlobstore{
 		innerBlobstore: innerBlobstore,
 		blobManagers:   blobManagers,
+		blobCache:      blobCache,
 		logger:         logger,
 	}
 }
@@ -40,7 +43,26 @@ func (b cascadingBlobstore) Get(blobID string, digest boshcrypto.Digest) (string
 		}
 	}
 
-	return b.innerBlobstore.Get(blobID, digest)
+	if b.blobCache == nil {
+		return b.innerBlobstore.Get(blobID, digest)
+	}
+
+	if blobPath, found := b.blobCache.Get(digest); found {
+		b.logger.Debug(logTag, "Found blob in cache. BlobID: %s", blobID)
+		return blobPath, nil
+	}
+
+	blobPath, err := b.innerBlobstore.Get(blobID, digest)
+	if err != nil {
+		return "", err
+	}
+
+	// Caching is best effort; blob was already fetched successfully
+	if err := b.blobCache.Put(blobPath, digest); err != nil {
+		b.logger.Warn(logTag, "Caching blob %s: %s", blobID, err.Error())
+	}
+
+	return blobPath, nil
 }
 
 func (b cascadingBlobstore) CleanUp(fileName string) error {
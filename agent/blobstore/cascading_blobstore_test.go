@@ -36,7 +36,7 @@ var _ = Describe("cascadingBlobstore", func() {
 			blobManager = &fakeagentblob.FakeBlobManagerInterface{}
 			logger := boshlog.NewLogger(boshlog.LevelNone)
 
-			cascadingBlobstore = blobstore.NewCascadingBlobstore(innerBlobstore, []blobstore.BlobManagerInterface{blobManager}, logger)
+			cascadingBlobstore = blobstore.NewCascadingBlobstore(innerBlobstore, []blobstore.BlobManagerInterface{blobManager}, nil, logger)
 		})
 
 		Describe("Get", func() {
@@ -243,6 +243,79 @@ var _ = Describe("cascadingBlobstore", func() {
 		})
 	})
 
+	Context("when there is a blob cache", func() {
+		var (
+			blobManager *fakeagentblob.FakeBlobManagerInterface
+			blobCache   *fakeagentblob.FakeBlobCache
+			digest      boshcrypto.Digest
+		)
+
+		BeforeEach(func() {
+			blobManager = &fakeagentblob.FakeBlobManagerInterface{}
+			blobCache = &fakeagentblob.FakeBlobCache{}
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+
+			digest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-checksum")
+			cascadingBlobstore = blobstore.NewCascadingBlobstore(innerBlobstore, []blobstore.BlobManagerInterface{blobManager}, blobCache, logger)
+		})
+
+		Describe("Get", func() {
+			It("prefers blobs found with blobManager", func() {
+				blobManager.BlobExistsReturns(true)
+				blobManager.GetPathReturns("/path/to-copy/of-blob", nil)
+
+				filename, err := cascadingBlobstore.Get("blobID", digest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(filename).To(Equal("/path/to-copy/of-blob"))
+
+				Expect(blobCache.GetCallCount()).To(Equal(0))
+				Expect(blobCache.PutCallCount()).To(Equal(0))
+			})
+
+			It("returns cached blob without asking inner blobstore", func() {
+				blobCache.GetReturns("/path/to-copy/of-cached-blob", true)
+
+				filename, err := cascadingBlobstore.Get("blobID", digest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(filename).To(Equal("/path/to-copy/of-cached-blob"))
+
+				Expect(blobCache.GetArgsForCall(0)).To(Equal(digest))
+				Expect(innerBlobstore.GetCallCount()).To(Equal(0))
+			})
+
+			It("caches blobs fetched from inner blobstore", func() {
+				innerBlobstore.GetReturns("/path/to-blob/in-inner", nil)
+
+				filename, err := cascadingBlobstore.Get("blobID", digest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(filename).To(Equal("/path/to-blob/in-inner"))
+
+				Expect(blobCache.PutCallCount()).To(Equal(1))
+				cachedPath, cachedDigest := blobCache.PutArgsForCall(0)
+				Expect(cachedPath).To(Equal("/path/to-blob/in-inner"))
+				Expect(cachedDigest).To(Equal(digest))
+			})
+
+			It("returns blob fetched from inner blobstore even if caching fails", func() {
+				innerBlobstore.GetReturns("/path/to-blob/in-inner", nil)
+				blobCache.PutReturns(errors.New("fake-put-err"))
+
+				filename, err := cascadingBlobstore.Get("blobID", digest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(filename).To(Equal("/path/to-blob/in-inner"))
+			})
+
+			It("does not cache anything when inner blobstore fails", func() {
+				innerBlobstore.GetReturns("", errors.New("fake-get-err"))
+
+				_, err := cascadingBlobstore.Get("blobID", digest)
+				Expect(err).To(MatchError("fake-get-err"))
+
+				Expect(blobCache.PutCallCount()).To(Equal(0))
+			})
+		})
+	})
+
 	Context("when there are multiple blobManagers", func() {
 		var (
 			blobManagers []*fakeagentblob.FakeBlobManagerInterface
@@ -262,6 +335,7 @@ var _ = Describe("cascadingBlobstore", func() {
 			cascadingBlobstore = blobstore.NewCascadingBlobstore(
 				innerBlobstore,
 				bmis,
+				nil,
 				logger,
 			)
 		})
@@ -103,12 +103,13 @@ func (boot bootstrap) Run() (err error) { //nolint:gocyclo
 		return bosherr.WrapError(err, "Setting up raw ephemeral disk")
 	}
 
-	ephemeralDiskPath, err := boot.platform.GetEphemeralDiskPath(settings.EphemeralDiskSettings())
+	ephemeralDiskSettings := settings.EphemeralDiskSettings()
+	ephemeralDiskPath, err := boot.platform.GetEphemeralDiskPath(ephemeralDiskSettings)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting ephemeral disk path")
 	}
 	desiredSwapSizeInBytes := settings.Env.GetSwapSizeInBytes()
-	if err = boot.platform.SetupEphemeralDiskWithPath(ephemeralDiskPath, desiredSwapSizeInBytes, settings.AgentID); err != nil {
+	if err = boot.platform.SetupEphemeralDiskWithPath(ephemeralDiskPath, desiredSwapSizeInBytes, settings.AgentID, ephemeralDiskSettings.Encryption); err != nil {
 		return bosherr.WrapError(err, "Setting up ephemeral disk")
 	}
 
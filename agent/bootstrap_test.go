@@ -17,6 +17,8 @@ import (
 	"github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	fakedevicepathresolver "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver/fakes"
 	"github.com/cloudfoundry/bosh-agent/platform/disk/diskfakes"
+	"github.com/cloudfoundry/bosh-agent/platform/dnsresolver/dnsresolverfakes"
+	"github.com/cloudfoundry/bosh-agent/platform/ntp/ntpfakes"
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 
 	sigar "github.com/cloudfoundry/gosigar"
@@ -269,6 +271,7 @@ var _ = Describe("bootstrap", func() {
 		It("sets up ephemeral disk", func() {
 			var swapSize uint64 = 2048
 			settingsService.Settings.Env.Bosh.SwapSizeInMB = &swapSize
+			settingsService.Settings.Env.Bosh.DiskEncryption = boshsettings.DiskEncryptionEnv{Ephemeral: true, KeyPath: "/fake-key"}
 			settingsService.Settings.Disks = boshsettings.Disks{
 				Ephemeral: "fake-ephemeral-disk-setting",
 			}
@@ -277,15 +280,17 @@ var _ = Describe("bootstrap", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(platform.SetupEphemeralDiskWithPathCallCount()).To(Equal(1))
-			devicePath, desiredSwapSizeInBytes, labelPrefix := platform.SetupEphemeralDiskWithPathArgsForCall(0)
+			devicePath, desiredSwapSizeInBytes, labelPrefix, encryption := platform.SetupEphemeralDiskWithPathArgsForCall(0)
 			Expect(devicePath).To(Equal("/dev/sda"))
 			Expect(*desiredSwapSizeInBytes).To(Equal(uint64(2048 * 1024 * 1024)))
 			Expect(labelPrefix).To(Equal(settingsService.Settings.AgentID))
+			Expect(encryption).To(Equal(boshsettings.DiskEncryption{Enabled: true, KeyPath: "/fake-key"}))
 
 			Expect(platform.GetEphemeralDiskPathCallCount()).To(Equal(1))
 			Expect(platform.GetEphemeralDiskPathArgsForCall(0)).To(Equal(boshsettings.DiskSettings{
-				VolumeID: "fake-ephemeral-disk-setting",
-				Path:     "fake-ephemeral-disk-setting",
+				VolumeID:   "fake-ephemeral-disk-setting",
+				Path:       "fake-ephemeral-disk-setting",
+				Encryption: boshsettings.DiskEncryption{Enabled: true, KeyPath: "/fake-key"},
 			}))
 		})
 
@@ -1077,6 +1082,7 @@ var _ = Describe("bootstrap", func() {
 				diskManager.GetMountsSearcherReturns(mountSearcher)
 				diskManager.GetRootDevicePartitionerReturns(rootDevicePartitioner)
 				diskManager.GetFormatterReturns(formatter)
+				diskManager.GetEncryptorReturns(&diskfakes.FakeEncryptor{})
 				diskManager.GetMounterReturns(mounter)
 
 				// for the GrowRootFS call to findRootDevicePath
@@ -1098,9 +1104,9 @@ var _ = Describe("bootstrap", func() {
 				compressor := boshcmd.NewTarballCompressor(runner, fs)
 				copier := boshcmd.NewGenericCpCopier(fs, logger)
 
-				sigarCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{})
+				sigarCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{}, fs, runner)
 
-				vitalsService := boshvitals.NewService(sigarCollector, dirProvider, mounter)
+				vitalsService := boshvitals.NewService(sigarCollector, dirProvider, mounter, nil)
 
 				ipResolver := boship.NewResolver(boship.NetworkInterfaceToAddrsFunc)
 
@@ -1116,6 +1122,9 @@ var _ = Describe("bootstrap", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, fakeMACAddressDetector, interfaceConfigurationCreator, interfaceAddrsProvider, dnsValidator, arping, kernelIPv6, logger)
+				dnsResolver := &dnsresolverfakes.FakeResolver{}
+				timeSyncManager := &ntpfakes.FakeManager{}
+				timeSyncMonitor := &ntpfakes.FakeMonitor{}
 				ubuntuCertManager := boshcert.NewUbuntuCertManager(fs, runner, 1, logger)
 
 				monitRetryable := boshplatform.NewMonitRetryable(runner)
@@ -1140,6 +1149,9 @@ var _ = Describe("bootstrap", func() {
 					linuxCdutil,
 					diskManager,
 					ubuntuNetManager,
+					dnsResolver,
+					timeSyncManager,
+					timeSyncMonitor,
 					ubuntuCertManager,
 					monitRetryStrategy,
 					devicePathResolver,
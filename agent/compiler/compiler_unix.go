@@ -8,21 +8,31 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package) error {
+func (c concreteCompiler) runPackagingCommand(compilePath, installPath, enablePath string, pkg Package) error {
 	command := boshsys.Command{
-		Name: "bash",
-		Args: []string{"-x", PackagingScriptName},
-		Env: map[string]string{
-			"BOSH_COMPILE_TARGET":  compilePath,
-			"BOSH_INSTALL_TARGET":  enablePath,
-			"BOSH_PACKAGE_NAME":    pkg.Name,
-			"BOSH_PACKAGE_VERSION": pkg.Version,
-		},
+		Name:       "bash",
+		Args:       []string{"-x", PackagingScriptName},
+		Env:        c.packagingEnv(compilePath, enablePath, pkg),
 		WorkingDir: compilePath,
 	}
-	_, err := c.runner.RunCommand("compilation", PackagingScriptName, command)
+
+	// Enable path is a symlink so install path is used instead
+	command, err := c.sandbox.Confine(pkg.Name, command, []string{compilePath, installPath})
+	if err != nil {
+		return bosherr.WrapError(err, "Confining packaging script")
+	}
+
+	_, err = c.runner.RunCommand("compilation", PackagingScriptName, command)
+	releaseErr := c.sandbox.Release(pkg.Name)
+
 	if err != nil {
 		return bosherr.WrapError(err, "Running packaging script")
 	}
+
+	// Leftovers of the sandbox do not affect compiled package
+	if releaseErr != nil {
+		c.logger.Warn(compilerLogTag, "Releasing packaging script sandbox: %s", releaseErr.Error())
+	}
+
 	return nil
 }
@@ -7,16 +7,12 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package) error {
+// Packaging scripts are never sandboxed on Windows
+func (c concreteCompiler) runPackagingCommand(compilePath, _, enablePath string, pkg Package) error {
 	command := boshsys.Command{
-		Name: "powershell",
-		Args: []string{"-command", fmt.Sprintf("iex (get-content -raw %s)", PackagingScriptName)},
-		Env: map[string]string{
-			"BOSH_COMPILE_TARGET":  compilePath,
-			"BOSH_INSTALL_TARGET":  enablePath,
-			"BOSH_PACKAGE_NAME":    pkg.Name,
-			"BOSH_PACKAGE_VERSION": pkg.Version,
-		},
+		Name:       "powershell",
+		Args:       []string{"-command", fmt.Sprintf("iex (get-content -raw %s)", PackagingScriptName)},
+		Env:        c.packagingEnv(compilePath, enablePath, pkg),
 		WorkingDir: compilePath,
 	}
 
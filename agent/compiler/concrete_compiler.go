@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strconv"
+	"time"
 
 	"code.cloudfoundry.org/clock"
 
@@ -12,18 +14,32 @@ import (
 	"github.com/cloudfoundry/bosh-agent/agent/applier/packages"
 	boshcmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
 	"github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator"
+	boshsandbox "github.com/cloudfoundry/bosh-agent/agent/sandbox"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/cloudfoundry/bosh-utils/work"
 )
 
-const PackagingScriptName = "packaging"
+const (
+	PackagingScriptName = "packaging"
+
+	compilerLogTag = "concreteCompiler"
+)
 
 type CompileDirProvider interface {
 	CompileDir() string
 }
 
+type PackagingOptions struct {
+	// Reproducible packages are compressed with normalized metadata and
+	// their packaging scripts get SOURCE_DATE_EPOCH.
+	Reproducible    bool
+	SourceDateEpoch time.Time
+}
+
 type concreteCompiler struct {
 	compressor         boshcmd.Compressor
 	blobstore          blobstore_delegator.BlobstoreDelegator
@@ -33,6 +49,10 @@ type concreteCompiler struct {
 	packageApplier     packages.Applier
 	packagesBc         boshbc.BundleCollection
 	timeProvider       clock.Clock
+	parallel           int
+	packaging          PackagingOptions
+	sandbox            boshsandbox.Sandbox
+	logger             boshlog.Logger
 }
 
 func NewConcreteCompiler(
@@ -44,7 +64,19 @@ func NewConcreteCompiler(
 	packageApplier packages.Applier,
 	packagesBc boshbc.BundleCollection,
 	timeProvider clock.Clock,
+	parallel int,
+	packaging PackagingOptions,
+	sandbox boshsandbox.Sandbox,
+	logger boshlog.Logger,
 ) Compiler {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	if packaging.Reproducible {
+		compressor = NewReproducibleCompressor(compressor, fs, packaging.SourceDateEpoch)
+	}
+
 	return concreteCompiler{
 		compressor:         compressor,
 		blobstore:          blobstore,
@@ -54,6 +86,10 @@ func NewConcreteCompiler(
 		packageApplier:     packageApplier,
 		packagesBc:         packagesBc,
 		timeProvider:       timeProvider,
+		parallel:           parallel,
+		packaging:          packaging,
+		sandbox:            sandbox,
+		logger:             logger,
 	}
 }
 
@@ -63,11 +99,9 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 		return "", nil, bosherr.WrapError(err, "Removing packages")
 	}
 
-	for _, dep := range deps {
-		err := c.packageApplier.Apply(dep)
-		if err != nil {
-			return "", nil, bosherr.WrapErrorf(err, "Installing dependent package: '%s'", dep.Name)
-		}
+	err = c.installDependencies(deps)
+	if err != nil {
+		return "", nil, err
 	}
 
 	compilePath := path.Join(c.compileDirProvider.CompileDir(), pkg.Name)
@@ -107,7 +141,7 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 	scriptPath := path.Join(compilePath, PackagingScriptName)
 
 	if c.fs.FileExists(scriptPath) {
-		if err := c.runPackagingCommand(compilePath, enablePath, pkg); err != nil {
+		if err := c.runPackagingCommand(compilePath, installPath, enablePath, pkg); err != nil {
 			return "", nil, bosherr.WrapError(err, "Running packaging script")
 		}
 	}
@@ -144,6 +178,61 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 	return uploadedBlobID, digest, nil
 }
 
+// installDependencies fetches dependencies concurrently and only enables
+// them once all of them were fetched and verified. On failure all
+// dependencies are removed so that no partial set is left behind.
+func (c concreteCompiler) installDependencies(deps []boshmodels.Package) error {
+	tasks := make([]func() error, 0, len(deps))
+
+	for _, dep := range deps {
+		dep := dep
+		tasks = append(tasks, func() error {
+			err := c.packageApplier.Prepare(dep)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Fetching dependent package: '%s'", dep.Name)
+			}
+			return nil
+		})
+	}
+
+	err := work.Pool{Count: c.parallel}.ParallelDo(tasks...)
+
+	if err == nil {
+		for _, dep := range deps {
+			err = c.packageApplier.Apply(dep)
+			if err != nil {
+				err = bosherr.WrapErrorf(err, "Installing dependent package: '%s'", dep.Name)
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		cleanupErr := c.packageApplier.KeepOnly([]boshmodels.Package{})
+		if cleanupErr != nil {
+			return bosherr.NewMultiError(err, bosherr.WrapError(cleanupErr, "Removing dependent packages"))
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (c concreteCompiler) packagingEnv(compilePath, enablePath string, pkg Package) map[string]string {
+	env := map[string]string{
+		"BOSH_COMPILE_TARGET":  compilePath,
+		"BOSH_INSTALL_TARGET":  enablePath,
+		"BOSH_PACKAGE_NAME":    pkg.Name,
+		"BOSH_PACKAGE_VERSION": pkg.Version,
+	}
+
+	if c.packaging.Reproducible {
+		env["SOURCE_DATE_EPOCH"] = strconv.FormatInt(c.packaging.SourceDateEpoch.Unix(), 10)
+	}
+
+	return env
+}
+
 func (c concreteCompiler) fetchAndUncompress(pkg Package, targetDir string) error {
 	if pkg.BlobstoreID == "" && pkg.PackageGetSignedURL == "" {
 		return bosherr.Error(fmt.Sprintf("No blobstore reference for package '%s'", pkg.Name))
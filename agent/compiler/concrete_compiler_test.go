@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -16,8 +18,10 @@ import (
 	fakepackages "github.com/cloudfoundry/bosh-agent/agent/applier/packages/fakes"
 	fakecmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner/fakes"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	"github.com/cloudfoundry/bosh-agent/agent/sandbox/sandboxfakes"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
@@ -74,6 +78,7 @@ func init() { //nolint:funlen,gochecknoinits
 			runner         *fakecmdrunner.FakeFileLoggingCmdRunner
 			packageApplier *fakepackages.FakeApplier
 			packagesBc     *fakebc.FakeBundleCollection
+			sandbox        *sandboxfakes.FakeSandbox
 		)
 
 		BeforeEach(func() {
@@ -83,6 +88,10 @@ func init() { //nolint:funlen,gochecknoinits
 			runner = fakecmdrunner.NewFakeFileLoggingCmdRunner()
 			packageApplier = fakepackages.NewFakeApplier()
 			packagesBc = fakebc.NewFakeBundleCollection()
+			sandbox = &sandboxfakes.FakeSandbox{}
+			sandbox.ConfineStub = func(_ string, cmd boshsys.Command, _ []string) (boshsys.Command, error) {
+				return cmd, nil
+			}
 
 			compiler = NewConcreteCompiler(
 				compressor,
@@ -93,6 +102,10 @@ func init() { //nolint:funlen,gochecknoinits
 				packageApplier,
 				packagesBc,
 				new(fakebc.FakeClock),
+				2,
+				PackagingOptions{},
+				sandbox,
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
 
 			err := fs.MkdirAll("/real-compile-dir", os.ModePerm)
@@ -165,10 +178,64 @@ func init() { //nolint:funlen,gochecknoinits
 			It("cleans up all packages before and after applying dependent packages", func() {
 				_, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(packageApplier.ActionsCalled).To(Equal([]string{"KeepOnly", "Apply", "Apply", "KeepOnly"}))
+				Expect(packageApplier.ActionsCalled).To(Equal([]string{"KeepOnly", "Prepare", "Prepare", "Apply", "Apply", "KeepOnly"}))
 				Expect(packageApplier.KeptOnlyPackages).To(BeEmpty())
 			})
 
+			It("fetches dependent packages concurrently", func() {
+				fetching := &sync.WaitGroup{}
+				fetching.Add(len(pkgDeps))
+
+				packageApplier.PrepareStub = func(boshmodels.Package) error {
+					fetching.Done()
+
+					allFetching := make(chan struct{})
+					go func() {
+						fetching.Wait()
+						close(allFetching)
+					}()
+
+					select {
+					case <-allFetching:
+						return nil
+					case <-time.After(5 * time.Second):
+						return errors.New("dependent packages were fetched serially")
+					}
+				}
+
+				_, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(packageApplier.PreparedPackages).To(ConsistOf(pkgDeps))
+			})
+
+			Context("when fetching a dependent package fails", func() {
+				BeforeEach(func() {
+					packageApplier.PrepareStub = func(dep boshmodels.Package) error {
+						if dep.Name == pkgDeps[1].Name {
+							return errors.New("fake-prepare-error")
+						}
+						return nil
+					}
+				})
+
+				It("removes all dependent packages without enabling any of them", func() {
+					_, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Fetching dependent package: '%s'", pkgDeps[1].Name))
+					Expect(err.Error()).To(ContainSubstring("fake-prepare-error"))
+
+					Expect(packageApplier.AppliedPackages).To(BeEmpty())
+					Expect(packageApplier.ActionsCalled[len(packageApplier.ActionsCalled)-1]).To(Equal("KeepOnly"))
+					Expect(packageApplier.KeptOnlyPackages).To(BeEmpty())
+				})
+
+				It("does not fetch the package to compile", func() {
+					_, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).To(HaveOccurred())
+					Expect(blobstore.GetCallCount()).To(Equal(0))
+				})
+			})
+
 			It("returns an error if cleaning up packages fails", func() {
 				packageApplier.KeepOnlyErr = errors.New("fake-keep-only-error")
 
@@ -319,6 +386,105 @@ func init() { //nolint:funlen,gochecknoinits
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-packaging-error"))
 				})
+
+				if runtime.GOOS != "windows" {
+					It("runs packaging script in a sandbox writable only to compile and install paths", func() {
+						sandbox.ConfineReturns(boshsys.Command{Name: "fake-confined-cmd"}, nil)
+
+						_, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(sandbox.ConfineCallCount()).To(Equal(1))
+						id, cmd, writablePaths := sandbox.ConfineArgsForCall(0)
+						Expect(id).To(Equal("pkg_name"))
+						Expect(cmd.Args).To(Equal([]string{"-x", PackagingScriptName}))
+						Expect(writablePaths).To(Equal([]string{"/fake-compile-dir/pkg_name", "/fake-dir/data/packages/pkg_name/pkg_version"}))
+
+						Expect(runner.RunCommands[0].Name).To(Equal("fake-confined-cmd"))
+
+						Expect(sandbox.ReleaseCallCount()).To(Equal(1))
+						Expect(sandbox.ReleaseArgsForCall(0)).To(Equal("pkg_name"))
+					})
+
+					It("does not run packaging script if it cannot be confined", func() {
+						sandbox.ConfineReturns(boshsys.Command{}, errors.New("fake-confine-error"))
+
+						_, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-confine-error"))
+						Expect(runner.RunCommands).To(BeEmpty())
+					})
+
+					It("releases sandbox when packaging script fails", func() {
+						runner.RunCommandErr = errors.New("fake-packaging-error")
+
+						_, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).To(HaveOccurred())
+						Expect(sandbox.ReleaseCallCount()).To(Equal(1))
+					})
+
+					It("does not fail compilation if releasing sandbox fails", func() {
+						sandbox.ReleaseReturns(errors.New("fake-release-error"))
+
+						_, _, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).ToNot(HaveOccurred())
+					})
+				}
+			})
+
+			Context("when reproducible packaging is enabled", func() {
+				BeforeEach(func() {
+					compiler = NewConcreteCompiler(
+						compressor,
+						blobstore,
+						fs,
+						runner,
+						FakeCompileDirProvider{Dir: "/fake-compile-dir"},
+						packageApplier,
+						packagesBc,
+						new(fakebc.FakeClock),
+						2,
+						PackagingOptions{Reproducible: true, SourceDateEpoch: time.Unix(1577836800, 0)},
+						sandbox,
+						boshlog.NewLogger(boshlog.LevelNone),
+					)
+
+					compressor.DecompressFileToDirCallBack = func() {
+						err := fs.WriteFileString("/fake-compile-dir/pkg_name/"+PackagingScriptName, "hi")
+						Expect(err).NotTo(HaveOccurred())
+					}
+
+					fs.ReturnTempFile = fakesys.NewFakeFile("/tmp/reproducible-tarball", fs)
+				})
+
+				It("exports SOURCE_DATE_EPOCH to packaging script", func() {
+					_, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(runner.RunCommands[0].Env).To(HaveKeyWithValue("SOURCE_DATE_EPOCH", "1577836800"))
+				})
+
+				It("uploads reproducible tarball of compiled package", func() {
+					_, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(compressor.CompressFilesInDirDir).To(BeEmpty())
+
+					_, tarballPath, _ := blobstore.WriteArgsForCall(0)
+					Expect(tarballPath).To(Equal("/tmp/reproducible-tarball"))
+				})
+			})
+
+			It("does not export SOURCE_DATE_EPOCH by default", func() {
+				compressor.DecompressFileToDirCallBack = func() {
+					err := fs.WriteFileString("/fake-compile-dir/pkg_name/"+PackagingScriptName, "hi")
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				_, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(runner.RunCommands[0].Env).ToNot(HaveKey("SOURCE_DATE_EPOCH"))
 			})
 
 			It("does not run packaging script when script does not exist", func() {
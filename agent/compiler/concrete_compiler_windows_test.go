@@ -14,8 +14,10 @@ import (
 	fakecmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner/fakes"
 	. "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	fakeblobdelegator "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator/blobstore_delegatorfakes"
+	boshsandbox "github.com/cloudfoundry/bosh-agent/agent/sandbox"
 
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
@@ -50,6 +52,10 @@ func init() { //nolint:gochecknoinits
 				packageApplier,
 				packagesBc,
 				fakeClock,
+				2,
+				PackagingOptions{},
+				boshsandbox.NewUnconfinedSandbox(),
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
 
 			err := fs.MkdirAll("/fake-compile-dir", os.ModePerm)
@@ -0,0 +1,156 @@
+package compiler
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type reproducibleCompressor struct {
+	boshcmd.Compressor
+
+	fs              boshsys.FileSystem
+	sourceDateEpoch time.Time
+}
+
+// NewReproducibleCompressor creates tarballs whose bytes only depend on
+// names, contents, symlink targets and executable bits of packaged files.
+// Everything else is normalized: entries are sorted, timestamps are set
+// to sourceDateEpoch and files are owned by root with 0644/0755 modes.
+// Decompression is delegated to the wrapped compressor.
+func NewReproducibleCompressor(
+	compressor boshcmd.Compressor,
+	fs boshsys.FileSystem,
+	sourceDateEpoch time.Time,
+) boshcmd.Compressor {
+	return reproducibleCompressor{
+		Compressor:      compressor,
+		fs:              fs,
+		sourceDateEpoch: sourceDateEpoch.UTC(),
+	}
+}
+
+func (c reproducibleCompressor) CompressFilesInDir(dir string) (string, error) {
+	return c.CompressSpecificFilesInDir(dir, []string{"."})
+}
+
+func (c reproducibleCompressor) CompressSpecificFilesInDir(dir string, files []string) (string, error) {
+	tarball, err := c.fs.TempFile("bosh-agent-reproducible-tarball")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file for tarball")
+	}
+
+	defer tarball.Close()
+
+	err = c.writeTarball(tarball, dir, files)
+	if err != nil {
+		_ = c.fs.RemoveAll(tarball.Name())
+		return "", err
+	}
+
+	return tarball.Name(), nil
+}
+
+func (c reproducibleCompressor) writeTarball(w io.Writer, dir string, files []string) error {
+	// Zero gzip header keeps name and timestamp out of the output
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, file := range files {
+		// Walk visits entries in lexical order
+		err := c.fs.Walk(filepath.Join(dir, file), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+
+			return c.writeEntry(tarWriter, path, c.entryName(relPath), info)
+		})
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Archiving %s", dir)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return bosherr.WrapError(err, "Finishing tarball")
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		return bosherr.WrapError(err, "Finishing tarball compression")
+	}
+
+	return nil
+}
+
+func (c reproducibleCompressor) writeEntry(tarWriter *tar.Writer, path, name string, info os.FileInfo) error {
+	header := &tar.Header{
+		Name:    name,
+		ModTime: c.sourceDateEpoch,
+		Format:  tar.FormatPAX,
+	}
+
+	switch {
+	case info.IsDir():
+		header.Typeflag = tar.TypeDir
+		header.Name += "/"
+		header.Mode = 0755
+
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := c.fs.Readlink(path)
+		if err != nil {
+			return err
+		}
+
+		header.Typeflag = tar.TypeSymlink
+		header.Linkname = filepath.ToSlash(target)
+		header.Mode = 0777
+
+	case info.Mode().IsRegular():
+		header.Typeflag = tar.TypeReg
+		header.Size = info.Size()
+		header.Mode = 0644
+		if info.Mode()&0111 != 0 {
+			header.Mode = 0755
+		}
+
+	default:
+		return bosherr.Errorf("Unsupported file type of %s", path)
+	}
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if header.Typeflag != tar.TypeReg {
+		return nil
+	}
+
+	file, err := c.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(tarWriter, file)
+
+	return err
+}
+
+// entryName matches names produced by `tar -C dir .`
+func (c reproducibleCompressor) entryName(relPath string) string {
+	if relPath == "." {
+		return "."
+	}
+	return "./" + filepath.ToSlash(relPath)
+}
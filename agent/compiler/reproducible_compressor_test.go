@@ -0,0 +1,151 @@
+package compiler_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/compiler"
+
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("reproducibleCompressor", func() {
+	var (
+		fs              boshsys.FileSystem
+		dir             string
+		sourceDateEpoch time.Time
+		compressor      boshcmd.Compressor
+		innerCompressor *fakecmd.FakeCompressor
+	)
+
+	writeFile := func(name, contents string, mode os.FileMode, modTime time.Time) {
+		path := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(contents), mode)).To(Succeed())
+		Expect(os.Chmod(path, mode)).To(Succeed())
+		Expect(os.Chtimes(path, modTime, modTime)).To(Succeed())
+	}
+
+	readTarball := func(path string) []*tar.Header {
+		file, err := os.Open(path)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		gzipReader, err := gzip.NewReader(file)
+		Expect(err).ToNot(HaveOccurred())
+
+		var headers []*tar.Header
+		tarReader := tar.NewReader(gzipReader)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			headers = append(headers, header)
+		}
+
+		return headers
+	}
+
+	BeforeEach(func() {
+		var err error
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+
+		dir, err = os.MkdirTemp("", "reproducible-compressor")
+		Expect(err).ToNot(HaveOccurred())
+
+		sourceDateEpoch = time.Unix(1577836800, 0)
+		innerCompressor = fakecmd.NewFakeCompressor()
+		compressor = NewReproducibleCompressor(innerCompressor, fs, sourceDateEpoch)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("produces identical tarballs for identical contents", func() {
+		writeFile("bin/run", "#!/bin/bash", 0700, time.Now())
+		writeFile("lib/data", "data", 0600, time.Now())
+
+		first, err := compressor.CompressFilesInDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(first)
+
+		writeFile("bin/run", "#!/bin/bash", 0750, time.Now().Add(time.Hour))
+		writeFile("lib/data", "data", 0640, time.Now().Add(time.Hour))
+
+		second, err := compressor.CompressFilesInDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(second)
+
+		firstContents, err := os.ReadFile(first)
+		Expect(err).ToNot(HaveOccurred())
+		secondContents, err := os.ReadFile(second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(firstContents).To(Equal(secondContents))
+	})
+
+	It("sorts entries and normalizes their metadata", func() {
+		writeFile("b", "b", 0600, time.Now())
+		writeFile("a/run", "a", 0700, time.Now())
+
+		tarball, err := compressor.CompressFilesInDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(tarball)
+
+		headers := readTarball(tarball)
+
+		var names []string
+		for _, header := range headers {
+			names = append(names, header.Name)
+			Expect(header.ModTime.Unix()).To(Equal(sourceDateEpoch.Unix()))
+			Expect(header.Uid).To(Equal(0))
+			Expect(header.Gid).To(Equal(0))
+			Expect(header.Uname).To(BeEmpty())
+			Expect(header.Gname).To(BeEmpty())
+		}
+		Expect(names).To(Equal([]string{"./", "./a/", "./a/run", "./b"}))
+
+		Expect(headers[1].Mode).To(Equal(int64(0755)))
+		if runtime.GOOS != "windows" {
+			Expect(headers[2].Mode).To(Equal(int64(0755)))
+		}
+		Expect(headers[3].Mode).To(Equal(int64(0644)))
+	})
+
+	It("keeps symlinks", func() {
+		if runtime.GOOS == "windows" {
+			Skip("symlinks require elevated privileges on windows")
+		}
+
+		writeFile("target", "data", 0644, time.Now())
+		Expect(os.Symlink("target", filepath.Join(dir, "link"))).To(Succeed())
+
+		tarball, err := compressor.CompressFilesInDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(tarball)
+
+		headers := readTarball(tarball)
+		Expect(headers[1].Name).To(Equal("./link"))
+		Expect(headers[1].Typeflag).To(Equal(byte(tar.TypeSymlink)))
+		Expect(headers[1].Linkname).To(Equal("target"))
+	})
+
+	It("delegates decompression to wrapped compressor", func() {
+		err := compressor.DecompressFileToDir("/fake-tarball", "/fake-dir", boshcmd.CompressorOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(innerCompressor.DecompressFileToDirTarballPaths).To(Equal([]string{"/fake-tarball"}))
+	})
+})
@@ -31,8 +31,11 @@ type Heartbeat struct {
 //       "ephemeral": {"percent" => "5"},
 //       "persistent": {"percent" => "94"}
 //     },
-//   "ntp": {
-//       "offset": "-0.06423",
-//       "timestamp": "14 Oct 11:13:19"
+//     "ntp": {
+//       "offset": "-0.064230",
+//       "stratum": "2",
+//       "synced": "true",
+//       "server": "169.254.169.254"
+//     }
 //   }
 // }
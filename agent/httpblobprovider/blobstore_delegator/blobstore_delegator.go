@@ -2,6 +2,7 @@ package blobstore_delegator //nolint:revive
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -61,6 +62,15 @@ func (b *BlobstoreDelegatorImpl) Write(signedURL, path string, headers map[strin
 	return "", digest, err
 }
 
+// WriteStream is only supported for signed URLs as blobstore clients upload files
+func (b *BlobstoreDelegatorImpl) WriteStream(signedURL string, contents io.Reader, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	if signedURL == "" {
+		return boshcrypto.MultipleDigest{}, fmt.Errorf("WriteStream is only supported for signed URLs")
+	}
+
+	return b.h.UploadStream(signedURL, contents, headers)
+}
+
 func (b *BlobstoreDelegatorImpl) CleanUp(signedURL, fileName string) (err error) {
 	if signedURL != "" {
 		return fmt.Errorf("CleanUp is not supported for signed URLs")
@@ -1,6 +1,8 @@
 package blobstore_delegator //nolint:revive
 
 import (
+	"io"
+
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 )
 
@@ -9,6 +11,7 @@ import (
 type BlobstoreDelegator interface {
 	Get(digest boshcrypto.Digest, signedURL, blobID string, headers map[string]string) (fileName string, err error)
 	Write(signedURL, path string, headers map[string]string) (string, boshcrypto.MultipleDigest, error)
+	WriteStream(signedURL string, contents io.Reader, headers map[string]string) (boshcrypto.MultipleDigest, error)
 	CleanUp(signedURL, path string) error
 	Delete(signedURL, blobID string) error
 }
@@ -2,6 +2,7 @@ package blobstore_delegator_test
 
 import (
 	"errors"
+	"strings"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	. "github.com/onsi/ginkgo"
@@ -181,6 +182,32 @@ var _ = Describe("BlobstoreDelegator", func() {
 		})
 	})
 
+	Context("WriteStream", func() {
+		Context("when there is a signed URL provided", func() {
+			It("streams contents to the HTTP blobstore", func() {
+				fakeHTTPBlobProvider.UploadStreamReturns(digest, nil)
+				contents := strings.NewReader("some-contents")
+
+				actualDigest, err := blobstoreDelegator.WriteStream("some-signed-url", contents, map[string]string{"key": "value"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualDigest).To(Equal(digest))
+
+				signedURLArg, contentsArg, headersArg := fakeHTTPBlobProvider.UploadStreamArgsForCall(0)
+				Expect(signedURLArg).To(Equal("some-signed-url"))
+				Expect(contentsArg).To(Equal(contents))
+				Expect(headersArg).To(Equal(map[string]string{"key": "value"}))
+			})
+		})
+
+		Context("when there is no signed URL provided", func() {
+			It("errors", func() {
+				_, err := blobstoreDelegator.WriteStream("", strings.NewReader("some-contents"), nil)
+				Expect(err).To(HaveOccurred())
+				Expect(fakeHTTPBlobProvider.UploadStreamCallCount()).To(Equal(0))
+			})
+		})
+	})
+
 	Context("CleanUp", func() {
 		Context("when there is a signed URL provided", func() {
 			It("errors", func() {
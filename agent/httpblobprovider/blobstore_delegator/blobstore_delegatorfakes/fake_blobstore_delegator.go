@@ -2,6 +2,7 @@
 package blobstore_delegatorfakes
 
 import (
+	"io"
 	"sync"
 
 	"github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator"
@@ -66,6 +67,21 @@ type FakeBlobstoreDelegator struct {
 		result2 crypto.MultipleDigest
 		result3 error
 	}
+	WriteStreamStub        func(string, io.Reader, map[string]string) (crypto.MultipleDigest, error)
+	writeStreamMutex       sync.RWMutex
+	writeStreamArgsForCall []struct {
+		arg1 string
+		arg2 io.Reader
+		arg3 map[string]string
+	}
+	writeStreamReturns struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
+	writeStreamReturnsOnCall map[int]struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -330,6 +346,72 @@ func (fake *FakeBlobstoreDelegator) WriteReturnsOnCall(i int, result1 string, re
 	}{result1, result2, result3}
 }
 
+func (fake *FakeBlobstoreDelegator) WriteStream(arg1 string, arg2 io.Reader, arg3 map[string]string) (crypto.MultipleDigest, error) {
+	fake.writeStreamMutex.Lock()
+	ret, specificReturn := fake.writeStreamReturnsOnCall[len(fake.writeStreamArgsForCall)]
+	fake.writeStreamArgsForCall = append(fake.writeStreamArgsForCall, struct {
+		arg1 string
+		arg2 io.Reader
+		arg3 map[string]string
+	}{arg1, arg2, arg3})
+	stub := fake.WriteStreamStub
+	fakeReturns := fake.writeStreamReturns
+	fake.recordInvocation("WriteStream", []interface{}{arg1, arg2, arg3})
+	fake.writeStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStreamCallCount() int {
+	fake.writeStreamMutex.RLock()
+	defer fake.writeStreamMutex.RUnlock()
+	return len(fake.writeStreamArgsForCall)
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStreamCalls(stub func(string, io.Reader, map[string]string) (crypto.MultipleDigest, error)) {
+	fake.writeStreamMutex.Lock()
+	defer fake.writeStreamMutex.Unlock()
+	fake.WriteStreamStub = stub
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStreamArgsForCall(i int) (string, io.Reader, map[string]string) {
+	fake.writeStreamMutex.RLock()
+	defer fake.writeStreamMutex.RUnlock()
+	argsForCall := fake.writeStreamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStreamReturns(result1 crypto.MultipleDigest, result2 error) {
+	fake.writeStreamMutex.Lock()
+	defer fake.writeStreamMutex.Unlock()
+	fake.WriteStreamStub = nil
+	fake.writeStreamReturns = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBlobstoreDelegator) WriteStreamReturnsOnCall(i int, result1 crypto.MultipleDigest, result2 error) {
+	fake.writeStreamMutex.Lock()
+	defer fake.writeStreamMutex.Unlock()
+	fake.WriteStreamStub = nil
+	if fake.writeStreamReturnsOnCall == nil {
+		fake.writeStreamReturnsOnCall = make(map[int]struct {
+			result1 crypto.MultipleDigest
+			result2 error
+		})
+	}
+	fake.writeStreamReturnsOnCall[i] = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBlobstoreDelegator) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -341,6 +423,8 @@ func (fake *FakeBlobstoreDelegator) Invocations() map[string][][]interface{} {
 	defer fake.getMutex.RUnlock()
 	fake.writeMutex.RLock()
 	defer fake.writeMutex.RUnlock()
+	fake.writeStreamMutex.RLock()
+	defer fake.writeStreamMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -1,7 +1,11 @@
 package httpblobprovider
 
 import (
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"os"
@@ -76,6 +80,42 @@ func (h *HTTPBlobImpl) Upload(signedURL, filepath string, headers map[string]str
 	return digest, nil
 }
 
+// UploadStream uploads contents of unknown size using chunked transfer encoding
+// so that they do not have to be written to disk first. Digests are calculated
+// from uploaded bytes.
+func (h *HTTPBlobImpl) UploadStream(signedURL string, contents io.Reader, headers map[string]string) (boshcrypto.MultipleDigest, error) {
+	digester, err := newStreamDigester(h.createAlgorithms)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	req, err := http.NewRequest("PUT", signedURL, io.NopCloser(io.TeeReader(contents, digester))) //nolint:noctx
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("Expect", "100-continue")
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	req.ContentLength = -1
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+	defer resp.Body.Close()
+
+	if !isSuccess(resp) {
+		return boshcrypto.MultipleDigest{}, fmt.Errorf("Error executing streamed PUT, response was %d", resp.StatusCode)
+	}
+
+	return digester.Digest(), nil
+}
+
 func (h *HTTPBlobImpl) Get(signedURL string, digest boshcrypto.Digest, headers map[string]string) (string, error) {
 	file, err := h.fs.TempFile("bosh-http-blob-provider-GET")
 	if err != nil {
@@ -119,6 +159,46 @@ func (h *HTTPBlobImpl) Get(signedURL string, digest boshcrypto.Digest, headers m
 	return file.Name(), nil
 }
 
+// streamDigester calculates digests of everything written to it
+type streamDigester struct {
+	algorithms []boshcrypto.Algorithm
+	hashes     []hash.Hash
+}
+
+func newStreamDigester(algorithms []boshcrypto.Algorithm) (*streamDigester, error) {
+	digester := &streamDigester{algorithms: algorithms}
+
+	for _, algorithm := range algorithms {
+		switch algorithm.Name() {
+		case boshcrypto.DigestAlgorithmSHA1.Name():
+			digester.hashes = append(digester.hashes, sha1.New()) //nolint:gosec
+		case boshcrypto.DigestAlgorithmSHA256.Name():
+			digester.hashes = append(digester.hashes, sha256.New())
+		case boshcrypto.DigestAlgorithmSHA512.Name():
+			digester.hashes = append(digester.hashes, sha512.New())
+		default:
+			return nil, bosherr.Errorf("Unsupported digest algorithm '%s'", algorithm.Name())
+		}
+	}
+
+	return digester, nil
+}
+
+func (d *streamDigester) Write(p []byte) (int, error) {
+	for _, h := range d.hashes {
+		h.Write(p) //nolint:errcheck
+	}
+	return len(p), nil
+}
+
+func (d *streamDigester) Digest() boshcrypto.MultipleDigest {
+	digests := []boshcrypto.Digest{}
+	for i, h := range d.hashes {
+		digests = append(digests, boshcrypto.NewDigest(d.algorithms[i], fmt.Sprintf("%x", h.Sum(nil))))
+	}
+	return boshcrypto.MustNewMultipleDigest(digests...)
+}
+
 func isSuccess(resp *http.Response) bool {
 	return resp.StatusCode >= 200 && resp.StatusCode < 300
 }
@@ -1,6 +1,8 @@
 package httpblobprovider
 
 import (
+	"io"
+
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 )
 
@@ -8,5 +10,6 @@ import (
 
 type HTTPBlobProvider interface {
 	Upload(signedURL, filepath string, headers map[string]string) (boshcrypto.MultipleDigest, error)
+	UploadStream(signedURL string, contents io.Reader, headers map[string]string) (boshcrypto.MultipleDigest, error)
 	Get(signedURL string, digest boshcrypto.Digest, headers map[string]string) (string, error)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider"
 	. "github.com/onsi/ginkgo"
@@ -167,4 +168,39 @@ var _ = Describe("HTTPBlobImpl", func() {
 			Expect(err).To(HaveOccurred())
 		})
 	})
+
+	Describe("UploadStream", func() {
+		It("calculates the digest and uploads contents without content length", func() {
+			server.RouteToHandler("PUT", "/success-signed-url",
+				ghttp.CombineHandlers(
+					ghttp.VerifyBody([]byte("abc")),
+					ghttp.RespondWith(http.StatusCreated, ``),
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.Header.Get("key")).To(Equal("value"))
+						Expect(r.TransferEncoding).To(Equal([]string{"chunked"}))
+					}),
+				),
+			)
+
+			digest, err := blobProvider.UploadStream(fmt.Sprintf("%s/success-signed-url", server.URL()), strings.NewReader("abc"), map[string]string{"key": "value"})
+			Expect(err).NotTo(HaveOccurred())
+
+			// sha sums for "abc", the contents of our stream
+			sha1 := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "a9993e364706816aba3e25717850c26c9cd0d89d")
+			sha512 := boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA512, "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f")
+			Expect(digest.DigestFor(boshcrypto.DigestAlgorithmSHA1)).To(Equal(sha1))
+			Expect(digest.DigestFor(boshcrypto.DigestAlgorithmSHA512)).To(Equal(sha512))
+		})
+
+		It("returns an error when the server responds with a bad status code", func() {
+			server.RouteToHandler("PUT", "/length-required",
+				ghttp.RespondWith(http.StatusLengthRequired, ``),
+			)
+
+			_, err := blobProvider.UploadStream(fmt.Sprintf("%s/length-required", server.URL()), strings.NewReader("abc"), nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("response was 411"))
+			Expect(err.Error()).ToNot(ContainSubstring(fmt.Sprintf("%s/length-required", server.URL())))
+		})
+	})
 })
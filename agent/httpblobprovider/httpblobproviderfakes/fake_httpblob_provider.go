@@ -2,6 +2,7 @@
 package httpblobproviderfakes
 
 import (
+	"io"
 	"sync"
 
 	"github.com/cloudfoundry/bosh-agent/agent/httpblobprovider"
@@ -39,6 +40,21 @@ type FakeHTTPBlobProvider struct {
 		result1 crypto.MultipleDigest
 		result2 error
 	}
+	UploadStreamStub        func(string, io.Reader, map[string]string) (crypto.MultipleDigest, error)
+	uploadStreamMutex       sync.RWMutex
+	uploadStreamArgsForCall []struct {
+		arg1 string
+		arg2 io.Reader
+		arg3 map[string]string
+	}
+	uploadStreamReturns struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
+	uploadStreamReturnsOnCall map[int]struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -175,6 +191,72 @@ func (fake *FakeHTTPBlobProvider) UploadReturnsOnCall(i int, result1 crypto.Mult
 	}{result1, result2}
 }
 
+func (fake *FakeHTTPBlobProvider) UploadStream(arg1 string, arg2 io.Reader, arg3 map[string]string) (crypto.MultipleDigest, error) {
+	fake.uploadStreamMutex.Lock()
+	ret, specificReturn := fake.uploadStreamReturnsOnCall[len(fake.uploadStreamArgsForCall)]
+	fake.uploadStreamArgsForCall = append(fake.uploadStreamArgsForCall, struct {
+		arg1 string
+		arg2 io.Reader
+		arg3 map[string]string
+	}{arg1, arg2, arg3})
+	stub := fake.UploadStreamStub
+	fakeReturns := fake.uploadStreamReturns
+	fake.recordInvocation("UploadStream", []interface{}{arg1, arg2, arg3})
+	fake.uploadStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStreamCallCount() int {
+	fake.uploadStreamMutex.RLock()
+	defer fake.uploadStreamMutex.RUnlock()
+	return len(fake.uploadStreamArgsForCall)
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStreamCalls(stub func(string, io.Reader, map[string]string) (crypto.MultipleDigest, error)) {
+	fake.uploadStreamMutex.Lock()
+	defer fake.uploadStreamMutex.Unlock()
+	fake.UploadStreamStub = stub
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStreamArgsForCall(i int) (string, io.Reader, map[string]string) {
+	fake.uploadStreamMutex.RLock()
+	defer fake.uploadStreamMutex.RUnlock()
+	argsForCall := fake.uploadStreamArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStreamReturns(result1 crypto.MultipleDigest, result2 error) {
+	fake.uploadStreamMutex.Lock()
+	defer fake.uploadStreamMutex.Unlock()
+	fake.UploadStreamStub = nil
+	fake.uploadStreamReturns = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHTTPBlobProvider) UploadStreamReturnsOnCall(i int, result1 crypto.MultipleDigest, result2 error) {
+	fake.uploadStreamMutex.Lock()
+	defer fake.uploadStreamMutex.Unlock()
+	fake.UploadStreamStub = nil
+	if fake.uploadStreamReturnsOnCall == nil {
+		fake.uploadStreamReturnsOnCall = make(map[int]struct {
+			result1 crypto.MultipleDigest
+			result2 error
+		})
+	}
+	fake.uploadStreamReturnsOnCall[i] = struct {
+		result1 crypto.MultipleDigest
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeHTTPBlobProvider) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -182,6 +264,8 @@ func (fake *FakeHTTPBlobProvider) Invocations() map[string][][]interface{} {
 	defer fake.getMutex.RUnlock()
 	fake.uploadMutex.RLock()
 	defer fake.uploadMutex.RUnlock()
+	fake.uploadStreamMutex.RLock()
+	defer fake.uploadStreamMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,375 @@
+package logarchive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Archiver
+
+const (
+	archiverLogTag = "logArchiver"
+
+	// ManifestFileName is added to the root of every archive
+	ManifestFileName = "fetch_logs_manifest.json"
+
+	ReasonOlderThanSince      = "older than since"
+	ReasonNewerThanUntil      = "newer than until"
+	ReasonMaxFileSizeExceeded = "max file size exceeded"
+	ReasonMaxSizeReached      = "max size reached"
+	ReasonChangedWhileReading = "changed while archiving"
+)
+
+// Rotated logs are named like <log>.1, <log>.2.gz or <log>-20261018.gz
+var rotationSuffixPattern = regexp.MustCompile(`(\.\d+|-\d{8}(\d{2})?)$`)
+
+// Options narrow down archived logs; zero values do not limit anything
+type Options struct {
+	// Logs are selected by the time range they cover, i.e. from the time the
+	// previous rotated log was last written to until their own last write.
+	// Logs overlapping the range are archived entirely.
+	Since *time.Time `json:"since,omitempty"`
+	Until *time.Time `json:"until,omitempty"`
+
+	// Larger logs are truncated to their most recent bytes
+	MaxFileSizeBytes int64 `json:"max_file_size_bytes,omitempty"`
+
+	// Most recently written logs are archived first until their
+	// uncompressed sizes add up to this limit, other logs are skipped
+	MaxSizeBytes int64 `json:"max_size_bytes,omitempty"`
+}
+
+type ManifestFile struct {
+	Path          string    `json:"path"`
+	Size          int64     `json:"size"`
+	ModTime       time.Time `json:"mtime"`
+	ArchivedBytes int64     `json:"archived_bytes"`
+	Reason        string    `json:"reason"`
+}
+
+// Manifest lists logs which were not archived as they are on disk
+type Manifest struct {
+	Options       Options        `json:"options"`
+	ArchivedFiles int            `json:"archived_files"`
+	ArchivedBytes int64          `json:"archived_bytes"`
+	Truncated     []ManifestFile `json:"truncated"`
+	Skipped       []ManifestFile `json:"skipped"`
+}
+
+type Archiver interface {
+	// Archive writes logs in dir matching filters into a gzipped tarball;
+	// files are streamed into the tarball without copying them first.
+	// Tarball itself is written to a temporary file as blobstores upload files.
+	Archive(dir string, filters []string, options Options) (tarballPath string, manifest Manifest, err error)
+
+	// ArchiveTo writes the same gzipped tarball into tarball, e.g. an upload
+	ArchiveTo(dir string, filters []string, options Options, tarball io.Writer) (manifest Manifest, err error)
+
+	CleanUp(tarballPath string) error
+}
+
+type logFile struct {
+	relPath string
+	info    os.FileInfo
+
+	// Time the log started to be written, zero if unknown
+	start time.Time
+}
+
+type archiver struct {
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+}
+
+func NewArchiver(fs boshsys.FileSystem, logger boshlog.Logger) Archiver {
+	return archiver{fs: fs, logger: logger}
+}
+
+func (a archiver) Archive(dir string, filters []string, options Options) (string, Manifest, error) {
+	tarball, err := a.fs.TempFile("bosh-agent-fetch-logs")
+	if err != nil {
+		return "", Manifest{}, bosherr.WrapError(err, "Creating temporary file for logs tarball")
+	}
+
+	manifest, err := a.ArchiveTo(dir, filters, options, tarball)
+	if closeErr := tarball.Close(); err == nil && closeErr != nil {
+		err = bosherr.WrapError(closeErr, "Closing logs tarball")
+	}
+
+	if err != nil {
+		_ = a.fs.RemoveAll(tarball.Name())
+		return "", manifest, err
+	}
+
+	return tarball.Name(), manifest, nil
+}
+
+func (a archiver) ArchiveTo(dir string, filters []string, options Options, tarball io.Writer) (Manifest, error) {
+	manifest := Manifest{
+		Options:   options,
+		Truncated: []ManifestFile{},
+		Skipped:   []ManifestFile{},
+	}
+
+	files, err := a.matchFiles(dir, filters)
+	if err != nil {
+		return manifest, err
+	}
+
+	files = a.selectByTime(files, options, &manifest)
+
+	err = a.writeTarball(tarball, dir, files, options, &manifest)
+	if err != nil {
+		return manifest, err
+	}
+
+	return manifest, nil
+}
+
+func (a archiver) CleanUp(tarballPath string) error {
+	return a.fs.RemoveAll(tarballPath)
+}
+
+// matchFiles finds regular files matching filters the same way FilteredCopyToTemp did,
+// i.e. filters are globs relative to dir and directories match everything inside them
+func (a archiver) matchFiles(dir string, filters []string) ([]logFile, error) {
+	seen := map[string]bool{}
+	files := []logFile{}
+
+	for _, filter := range filters {
+		pattern := filepath.Join(dir, filter)
+
+		info, err := os.Stat(pattern)
+		if err == nil && info.IsDir() {
+			pattern = filepath.Join(pattern, "**", "*")
+		}
+
+		matches, err := doublestar.Glob(pattern)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Finding files matching filter '%s'", filter)
+		}
+
+		for _, match := range matches {
+			if seen[match] {
+				continue
+			}
+			seen[match] = true
+
+			info, err := os.Stat(match)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+
+			relPath, err := filepath.Rel(dir, match)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Making path of '%s' relative", match)
+			}
+
+			files = append(files, logFile{relPath: relPath, info: info})
+		}
+	}
+
+	assignStartTimes(files)
+
+	return files, nil
+}
+
+// assignStartTimes estimates when each log started to be written: a log
+// was created when the next older log of the same rotation chain was rotated
+func assignStartTimes(files []logFile) {
+	chains := map[string][]int{}
+
+	for i, file := range files {
+		name := strings.TrimSuffix(file.relPath, ".gz")
+		name = rotationSuffixPattern.ReplaceAllString(name, "")
+		chains[name] = append(chains[name], i)
+	}
+
+	for _, chain := range chains {
+		sort.Slice(chain, func(i, j int) bool {
+			return files[chain[i]].info.ModTime().Before(files[chain[j]].info.ModTime())
+		})
+
+		for i := 1; i < len(chain); i++ {
+			files[chain[i]].start = files[chain[i-1]].info.ModTime()
+		}
+	}
+}
+
+func (a archiver) selectByTime(files []logFile, options Options, manifest *Manifest) []logFile {
+	selected := []logFile{}
+
+	for _, file := range files {
+		switch {
+		case options.Since != nil && file.info.ModTime().Before(*options.Since):
+			manifest.Skipped = append(manifest.Skipped, manifestFile(file, 0, ReasonOlderThanSince))
+
+		case options.Until != nil && !file.start.IsZero() && file.start.After(*options.Until):
+			manifest.Skipped = append(manifest.Skipped, manifestFile(file, 0, ReasonNewerThanUntil))
+
+		default:
+			selected = append(selected, file)
+		}
+	}
+
+	return selected
+}
+
+func (a archiver) writeTarball(tarball io.Writer, dir string, files []logFile, options Options, manifest *Manifest) error {
+	gzipWriter := gzip.NewWriter(tarball)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	// Most recent logs are the most relevant ones when limits are reached
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].info.ModTime().After(files[j].info.ModTime())
+	})
+
+	for _, file := range files {
+		path := filepath.Join(dir, file.relPath)
+
+		// Logs may be removed by rotation in the meantime
+		source, err := os.Open(path)
+		if err != nil {
+			a.logger.Warn(archiverLogTag, "Skipping '%s': %s", path, err)
+			manifest.Skipped = append(manifest.Skipped, manifestFile(file, 0, ReasonChangedWhileReading))
+			continue
+		}
+
+		if info, err := source.Stat(); err == nil {
+			file.info = info
+		}
+
+		size := file.info.Size()
+		limit := size
+		reason := ""
+
+		if options.MaxFileSizeBytes > 0 && limit > options.MaxFileSizeBytes {
+			limit = options.MaxFileSizeBytes
+			reason = ReasonMaxFileSizeExceeded
+		}
+
+		if options.MaxSizeBytes > 0 && manifest.ArchivedBytes+limit > options.MaxSizeBytes {
+			limit = options.MaxSizeBytes - manifest.ArchivedBytes
+			reason = ReasonMaxSizeReached
+		}
+
+		// Tail of a compressed log cannot be read on its own
+		if (limit <= 0 && size > 0) || (limit < size && strings.HasSuffix(file.relPath, ".gz")) {
+			_ = source.Close()
+			manifest.Skipped = append(manifest.Skipped, manifestFile(file, 0, reason))
+			continue
+		}
+
+		complete, err := a.writeFile(tarWriter, source, file, limit)
+		_ = source.Close()
+		if err != nil {
+			return err
+		}
+
+		if !complete {
+			reason = ReasonChangedWhileReading
+		}
+
+		if reason != "" {
+			manifest.Truncated = append(manifest.Truncated, manifestFile(file, limit, reason))
+		}
+
+		manifest.ArchivedFiles++
+		manifest.ArchivedBytes += limit
+	}
+
+	manifestContents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling logs manifest")
+	}
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name:    "./" + ManifestFileName,
+		Mode:    0644,
+		Size:    int64(len(manifestContents)),
+		ModTime: time.Now(),
+	})
+	if err == nil {
+		_, err = tarWriter.Write(manifestContents)
+	}
+	if err != nil {
+		return bosherr.WrapError(err, "Adding logs manifest to tarball")
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Closing logs tarball")
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return bosherr.WrapError(err, "Compressing logs tarball")
+	}
+
+	return nil
+}
+
+// writeFile adds last limit bytes of file to the tarball; it returns false if file
+// shrank in the meantime, e.g. by being truncated during rotation, and was padded
+func (a archiver) writeFile(tarWriter *tar.Writer, source *os.File, file logFile, limit int64) (bool, error) {
+	path := source.Name()
+
+	err := tarWriter.WriteHeader(&tar.Header{
+		Name:    "./" + filepath.ToSlash(file.relPath),
+		Mode:    int64(file.info.Mode().Perm()),
+		Size:    limit,
+		ModTime: file.info.ModTime(),
+	})
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Adding '%s' to logs tarball", path)
+	}
+
+	copied, err := io.Copy(tarWriter, io.NewSectionReader(source, file.info.Size()-limit, limit))
+	if err != nil {
+		a.logger.Warn(archiverLogTag, "Reading '%s' for logs tarball: %s", path, err)
+	}
+
+	if copied == limit {
+		return true, nil
+	}
+
+	// Size in tar header was already written hence content has to be padded
+	_, err = io.CopyN(tarWriter, zeroReader{}, limit-copied)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Adding '%s' to logs tarball", path)
+	}
+
+	return false, nil
+}
+
+func manifestFile(file logFile, archivedBytes int64, reason string) ManifestFile {
+	return ManifestFile{
+		Path:          filepath.ToSlash(file.relPath),
+		Size:          file.info.Size(),
+		ModTime:       file.info.ModTime(),
+		ArchivedBytes: archivedBytes,
+		Reason:        reason,
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
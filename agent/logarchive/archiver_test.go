@@ -0,0 +1,272 @@
+package logarchive_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/logarchive"
+)
+
+var _ = Describe("Archiver", func() {
+	var (
+		logsDir  string
+		now      time.Time
+		archiver Archiver
+	)
+
+	writeLog := func(relPath, contents string, modTime time.Time) {
+		logPath := filepath.Join(logsDir, relPath)
+		Expect(os.MkdirAll(filepath.Dir(logPath), 0750)).To(Succeed())
+		Expect(os.WriteFile(logPath, []byte(contents), 0640)).To(Succeed())
+		Expect(os.Chtimes(logPath, modTime, modTime)).To(Succeed())
+	}
+
+	// readTarball returns contents of archived files keyed by their names
+	readTarball := func(tarballPath string) (map[string]string, Manifest) {
+		file, err := os.Open(tarballPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+
+		gzipReader, err := gzip.NewReader(file)
+		Expect(err).NotTo(HaveOccurred())
+
+		files := map[string]string{}
+		tarReader := tar.NewReader(gzipReader)
+
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := io.ReadAll(tarReader)
+			Expect(err).NotTo(HaveOccurred())
+
+			files[header.Name] = string(contents)
+		}
+
+		var manifest Manifest
+		Expect(json.Unmarshal([]byte(files["./"+ManifestFileName]), &manifest)).To(Succeed())
+		delete(files, "./"+ManifestFileName)
+
+		return files, manifest
+	}
+
+	archive := func(filters []string, options Options) (map[string]string, Manifest) {
+		tarballPath, manifest, err := archiver.Archive(logsDir, filters, options)
+		Expect(err).NotTo(HaveOccurred())
+		defer archiver.CleanUp(tarballPath) //nolint:errcheck
+
+		files, archivedManifest := readTarball(tarballPath)
+		Expect(archivedManifest.ArchivedFiles).To(Equal(manifest.ArchivedFiles))
+		Expect(archivedManifest.Skipped).To(HaveLen(len(manifest.Skipped)))
+		Expect(archivedManifest.Truncated).To(HaveLen(len(manifest.Truncated)))
+
+		return files, manifest
+	}
+
+	skippedPaths := func(manifest Manifest) map[string]string {
+		paths := map[string]string{}
+		for _, file := range manifest.Skipped {
+			paths[file.Path] = file.Reason
+		}
+		return paths
+	}
+
+	BeforeEach(func() {
+		var err error
+		logsDir, err = os.MkdirTemp("", "logarchive")
+		Expect(err).NotTo(HaveOccurred())
+
+		now = time.Now().Truncate(time.Second)
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		archiver = NewArchiver(boshsys.NewOsFileSystem(logger), logger)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(logsDir)).To(Succeed())
+	})
+
+	It("archives files matching filters", func() {
+		writeLog("job/job.stdout.log", "fake-stdout", now)
+		writeLog("job/job.stderr.log", "fake-stderr", now)
+		writeLog("job/nested/other.txt", "fake-other", now)
+		writeLog("top.log", "fake-top", now)
+
+		files, manifest := archive([]string{"**/*.stdout.log", "top.log"}, Options{})
+		Expect(files).To(Equal(map[string]string{
+			"./job/job.stdout.log": "fake-stdout",
+			"./top.log":            "fake-top",
+		}))
+
+		Expect(manifest.ArchivedFiles).To(Equal(2))
+		Expect(manifest.ArchivedBytes).To(Equal(int64(len("fake-stdout") + len("fake-top"))))
+		Expect(manifest.Skipped).To(BeEmpty())
+		Expect(manifest.Truncated).To(BeEmpty())
+	})
+
+	It("archives everything inside directories given as filters", func() {
+		writeLog("job/job.stdout.log", "fake-stdout", now)
+		writeLog("job/nested/other.txt", "fake-other", now)
+		writeLog("other-job/job.log", "fake-other-job", now)
+
+		files, _ := archive([]string{"job"}, Options{})
+		Expect(files).To(Equal(map[string]string{
+			"./job/job.stdout.log":   "fake-stdout",
+			"./job/nested/other.txt": "fake-other",
+		}))
+	})
+
+	It("writes tarball into given writer", func() {
+		writeLog("job/job.stdout.log", "fake-stdout", now)
+
+		tarball, err := os.CreateTemp("", "logarchive-tarball")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.Remove(tarball.Name())
+
+		manifest, err := archiver.ArchiveTo(logsDir, []string{"**/*"}, Options{}, tarball)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tarball.Close()).To(Succeed())
+		Expect(manifest.ArchivedFiles).To(Equal(1))
+
+		files, _ := readTarball(tarball.Name())
+		Expect(files).To(Equal(map[string]string{"./job/job.stdout.log": "fake-stdout"}))
+	})
+
+	Describe("time range", func() {
+		BeforeEach(func() {
+			// Rotation chain covering: .3.gz -> [?, now-72h], .2.gz -> [now-72h, now-48h],
+			// .1 -> [now-48h, now-24h], active -> [now-24h, now]
+			writeLog("job/job.log.3.gz", "fake-3", now.Add(-72*time.Hour))
+			writeLog("job/job.log.2.gz", "fake-2", now.Add(-48*time.Hour))
+			writeLog("job/job.log.1", "fake-1", now.Add(-24*time.Hour))
+			writeLog("job/job.log", "fake-0", now)
+		})
+
+		It("skips logs last written before since", func() {
+			since := now.Add(-36 * time.Hour)
+
+			files, manifest := archive([]string{"**/*"}, Options{Since: &since})
+			Expect(files).To(HaveKey("./job/job.log"))
+			Expect(files).To(HaveKey("./job/job.log.1"))
+			Expect(files).To(HaveLen(2))
+
+			Expect(skippedPaths(manifest)).To(Equal(map[string]string{
+				"job/job.log.3.gz": ReasonOlderThanSince,
+				"job/job.log.2.gz": ReasonOlderThanSince,
+			}))
+		})
+
+		It("skips logs started after until", func() {
+			until := now.Add(-60 * time.Hour)
+
+			files, manifest := archive([]string{"**/*"}, Options{Until: &until})
+			Expect(files).To(HaveKey("./job/job.log.3.gz"))
+			Expect(files).To(HaveKey("./job/job.log.2.gz"))
+			Expect(files).To(HaveLen(2))
+
+			Expect(skippedPaths(manifest)).To(Equal(map[string]string{
+				"job/job.log.1": ReasonNewerThanUntil,
+				"job/job.log":   ReasonNewerThanUntil,
+			}))
+		})
+
+		It("keeps logs overlapping the time range", func() {
+			since := now.Add(-50 * time.Hour)
+			until := now.Add(-30 * time.Hour)
+
+			files, _ := archive([]string{"**/*"}, Options{Since: &since, Until: &until})
+			Expect(files).To(Equal(map[string]string{
+				"./job/job.log.2.gz": "fake-2",
+				"./job/job.log.1":    "fake-1",
+			}))
+		})
+
+		It("groups logs rotated with date extension", func() {
+			writeLog("other/other.log-20261016.gz", "fake-old", now.Add(-48*time.Hour))
+			writeLog("other/other.log", "fake-new", now)
+
+			until := now.Add(-50 * time.Hour)
+
+			files, _ := archive([]string{"other/*"}, Options{Until: &until})
+			Expect(files).To(Equal(map[string]string{"./other/other.log-20261016.gz": "fake-old"}))
+		})
+	})
+
+	Describe("size limits", func() {
+		It("truncates files exceeding max file size to their most recent bytes", func() {
+			writeLog("job/job.log", "0123456789", now)
+
+			files, manifest := archive([]string{"**/*"}, Options{MaxFileSizeBytes: 4})
+			Expect(files).To(Equal(map[string]string{"./job/job.log": "6789"}))
+
+			Expect(manifest.Truncated).To(HaveLen(1))
+			Expect(manifest.Truncated[0].Path).To(Equal("job/job.log"))
+			Expect(manifest.Truncated[0].Size).To(Equal(int64(10)))
+			Expect(manifest.Truncated[0].ArchivedBytes).To(Equal(int64(4)))
+			Expect(manifest.Truncated[0].Reason).To(Equal(ReasonMaxFileSizeExceeded))
+		})
+
+		It("archives most recent files first until max size is reached", func() {
+			writeLog("job/newest.log", "aaaa", now)
+			writeLog("job/newer.log", "bbbb", now.Add(-time.Hour))
+			writeLog("job/older.log", "cccc", now.Add(-2*time.Hour))
+			writeLog("job/oldest.log", "dddd", now.Add(-3*time.Hour))
+
+			files, manifest := archive([]string{"**/*"}, Options{MaxSizeBytes: 10})
+			Expect(files).To(Equal(map[string]string{
+				"./job/newest.log": "aaaa",
+				"./job/newer.log":  "bbbb",
+				"./job/older.log":  "cc",
+			}))
+
+			Expect(manifest.ArchivedBytes).To(Equal(int64(10)))
+			Expect(manifest.Truncated).To(HaveLen(1))
+			Expect(manifest.Truncated[0].Reason).To(Equal(ReasonMaxSizeReached))
+			Expect(skippedPaths(manifest)).To(Equal(map[string]string{"job/oldest.log": ReasonMaxSizeReached}))
+		})
+
+		It("does not limit size of archived logs by default", func() {
+			writeLog("job/newer.log", "fake-log", now)
+			writeLog("job/older.log", "fake-log", now.Add(-time.Hour))
+
+			files, manifest := archive([]string{"**/*"}, Options{})
+			Expect(files).To(HaveLen(2))
+			Expect(manifest.Truncated).To(BeEmpty())
+			Expect(manifest.Skipped).To(BeEmpty())
+		})
+
+		It("skips compressed files which would need to be truncated", func() {
+			writeLog("job/job.log.1.gz", strings.Repeat("z", 10), now)
+
+			files, manifest := archive([]string{"**/*"}, Options{MaxFileSizeBytes: 4})
+			Expect(files).To(BeEmpty())
+			Expect(skippedPaths(manifest)).To(Equal(map[string]string{"job/job.log.1.gz": ReasonMaxFileSizeExceeded}))
+		})
+	})
+
+	It("returns error if tarball cannot be created", func() {
+		fs := fakesys.NewFakeFileSystem()
+		fs.TempFileError = errors.New("fake-temp-file-err")
+		archiver = NewArchiver(fs, boshlog.NewLogger(boshlog.LevelNone))
+
+		_, _, err := archiver.Archive(logsDir, []string{"**/*"}, Options{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-temp-file-err"))
+	})
+})
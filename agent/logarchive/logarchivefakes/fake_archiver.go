@@ -0,0 +1,295 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package logarchivefakes
+
+import (
+	"io"
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/agent/logarchive"
+)
+
+type FakeArchiver struct {
+	ArchiveStub        func(string, []string, logarchive.Options) (string, logarchive.Manifest, error)
+	archiveMutex       sync.RWMutex
+	archiveArgsForCall []struct {
+		arg1 string
+		arg2 []string
+		arg3 logarchive.Options
+	}
+	archiveReturns struct {
+		result1 string
+		result2 logarchive.Manifest
+		result3 error
+	}
+	archiveReturnsOnCall map[int]struct {
+		result1 string
+		result2 logarchive.Manifest
+		result3 error
+	}
+	ArchiveToStub        func(string, []string, logarchive.Options, io.Writer) (logarchive.Manifest, error)
+	archiveToMutex       sync.RWMutex
+	archiveToArgsForCall []struct {
+		arg1 string
+		arg2 []string
+		arg3 logarchive.Options
+		arg4 io.Writer
+	}
+	archiveToReturns struct {
+		result1 logarchive.Manifest
+		result2 error
+	}
+	archiveToReturnsOnCall map[int]struct {
+		result1 logarchive.Manifest
+		result2 error
+	}
+	CleanUpStub        func(string) error
+	cleanUpMutex       sync.RWMutex
+	cleanUpArgsForCall []struct {
+		arg1 string
+	}
+	cleanUpReturns struct {
+		result1 error
+	}
+	cleanUpReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeArchiver) Archive(arg1 string, arg2 []string, arg3 logarchive.Options) (string, logarchive.Manifest, error) {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.archiveMutex.Lock()
+	ret, specificReturn := fake.archiveReturnsOnCall[len(fake.archiveArgsForCall)]
+	fake.archiveArgsForCall = append(fake.archiveArgsForCall, struct {
+		arg1 string
+		arg2 []string
+		arg3 logarchive.Options
+	}{arg1, arg2Copy, arg3})
+	stub := fake.ArchiveStub
+	fakeReturns := fake.archiveReturns
+	fake.recordInvocation("Archive", []interface{}{arg1, arg2Copy, arg3})
+	fake.archiveMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeArchiver) ArchiveCallCount() int {
+	fake.archiveMutex.RLock()
+	defer fake.archiveMutex.RUnlock()
+	return len(fake.archiveArgsForCall)
+}
+
+func (fake *FakeArchiver) ArchiveCalls(stub func(string, []string, logarchive.Options) (string, logarchive.Manifest, error)) {
+	fake.archiveMutex.Lock()
+	defer fake.archiveMutex.Unlock()
+	fake.ArchiveStub = stub
+}
+
+func (fake *FakeArchiver) ArchiveArgsForCall(i int) (string, []string, logarchive.Options) {
+	fake.archiveMutex.RLock()
+	defer fake.archiveMutex.RUnlock()
+	argsForCall := fake.archiveArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeArchiver) ArchiveReturns(result1 string, result2 logarchive.Manifest, result3 error) {
+	fake.archiveMutex.Lock()
+	defer fake.archiveMutex.Unlock()
+	fake.ArchiveStub = nil
+	fake.archiveReturns = struct {
+		result1 string
+		result2 logarchive.Manifest
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeArchiver) ArchiveReturnsOnCall(i int, result1 string, result2 logarchive.Manifest, result3 error) {
+	fake.archiveMutex.Lock()
+	defer fake.archiveMutex.Unlock()
+	fake.ArchiveStub = nil
+	if fake.archiveReturnsOnCall == nil {
+		fake.archiveReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 logarchive.Manifest
+			result3 error
+		})
+	}
+	fake.archiveReturnsOnCall[i] = struct {
+		result1 string
+		result2 logarchive.Manifest
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeArchiver) ArchiveTo(arg1 string, arg2 []string, arg3 logarchive.Options, arg4 io.Writer) (logarchive.Manifest, error) {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.archiveToMutex.Lock()
+	ret, specificReturn := fake.archiveToReturnsOnCall[len(fake.archiveToArgsForCall)]
+	fake.archiveToArgsForCall = append(fake.archiveToArgsForCall, struct {
+		arg1 string
+		arg2 []string
+		arg3 logarchive.Options
+		arg4 io.Writer
+	}{arg1, arg2Copy, arg3, arg4})
+	stub := fake.ArchiveToStub
+	fakeReturns := fake.archiveToReturns
+	fake.recordInvocation("ArchiveTo", []interface{}{arg1, arg2Copy, arg3, arg4})
+	fake.archiveToMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeArchiver) ArchiveToCallCount() int {
+	fake.archiveToMutex.RLock()
+	defer fake.archiveToMutex.RUnlock()
+	return len(fake.archiveToArgsForCall)
+}
+
+func (fake *FakeArchiver) ArchiveToCalls(stub func(string, []string, logarchive.Options, io.Writer) (logarchive.Manifest, error)) {
+	fake.archiveToMutex.Lock()
+	defer fake.archiveToMutex.Unlock()
+	fake.ArchiveToStub = stub
+}
+
+func (fake *FakeArchiver) ArchiveToArgsForCall(i int) (string, []string, logarchive.Options, io.Writer) {
+	fake.archiveToMutex.RLock()
+	defer fake.archiveToMutex.RUnlock()
+	argsForCall := fake.archiveToArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeArchiver) ArchiveToReturns(result1 logarchive.Manifest, result2 error) {
+	fake.archiveToMutex.Lock()
+	defer fake.archiveToMutex.Unlock()
+	fake.ArchiveToStub = nil
+	fake.archiveToReturns = struct {
+		result1 logarchive.Manifest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeArchiver) ArchiveToReturnsOnCall(i int, result1 logarchive.Manifest, result2 error) {
+	fake.archiveToMutex.Lock()
+	defer fake.archiveToMutex.Unlock()
+	fake.ArchiveToStub = nil
+	if fake.archiveToReturnsOnCall == nil {
+		fake.archiveToReturnsOnCall = make(map[int]struct {
+			result1 logarchive.Manifest
+			result2 error
+		})
+	}
+	fake.archiveToReturnsOnCall[i] = struct {
+		result1 logarchive.Manifest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeArchiver) CleanUp(arg1 string) error {
+	fake.cleanUpMutex.Lock()
+	ret, specificReturn := fake.cleanUpReturnsOnCall[len(fake.cleanUpArgsForCall)]
+	fake.cleanUpArgsForCall = append(fake.cleanUpArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CleanUpStub
+	fakeReturns := fake.cleanUpReturns
+	fake.recordInvocation("CleanUp", []interface{}{arg1})
+	fake.cleanUpMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeArchiver) CleanUpCallCount() int {
+	fake.cleanUpMutex.RLock()
+	defer fake.cleanUpMutex.RUnlock()
+	return len(fake.cleanUpArgsForCall)
+}
+
+func (fake *FakeArchiver) CleanUpCalls(stub func(string) error) {
+	fake.cleanUpMutex.Lock()
+	defer fake.cleanUpMutex.Unlock()
+	fake.CleanUpStub = stub
+}
+
+func (fake *FakeArchiver) CleanUpArgsForCall(i int) string {
+	fake.cleanUpMutex.RLock()
+	defer fake.cleanUpMutex.RUnlock()
+	argsForCall := fake.cleanUpArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeArchiver) CleanUpReturns(result1 error) {
+	fake.cleanUpMutex.Lock()
+	defer fake.cleanUpMutex.Unlock()
+	fake.CleanUpStub = nil
+	fake.cleanUpReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeArchiver) CleanUpReturnsOnCall(i int, result1 error) {
+	fake.cleanUpMutex.Lock()
+	defer fake.cleanUpMutex.Unlock()
+	fake.CleanUpStub = nil
+	if fake.cleanUpReturnsOnCall == nil {
+		fake.cleanUpReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.cleanUpReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeArchiver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.archiveMutex.RLock()
+	defer fake.archiveMutex.RUnlock()
+	fake.archiveToMutex.RLock()
+	defer fake.archiveToMutex.RUnlock()
+	fake.cleanUpMutex.RLock()
+	defer fake.cleanUpMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeArchiver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ logarchive.Archiver = new(FakeArchiver)
@@ -0,0 +1,284 @@
+package logforwarder
+
+import (
+	"crypto/tls"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	forwarderLogTag = "logForwarder"
+
+	defaultBufferSizeMB = 100
+
+	pollInterval  = time.Second
+	retryInterval = 5 * time.Second
+	dialTimeout   = 10 * time.Second
+	writeTimeout  = 30 * time.Second
+)
+
+// Forwarder ships lines of job logs to a syslog endpoint over TCP or TLS.
+// Lines always go through the on-disk spool first so that nothing is lost
+// while the endpoint is unreachable, up to the configured buffer size.
+type Forwarder struct {
+	options     boshsettings.LogForwarding
+	hostname    string
+	logDir      string
+	stateDir    string
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	tlsConfig *tls.Config
+	tailer    *Tailer
+	spool     *Spool
+
+	appended chan struct{}
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewForwarder(
+	options boshsettings.LogForwarding,
+	hostname string,
+	logDir string,
+	stateDir string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) *Forwarder {
+	return &Forwarder{
+		options:     options,
+		hostname:    hostname,
+		logDir:      logDir,
+		stateDir:    stateDir,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+// Start begins tailing and sending logs in the background
+func (f *Forwarder) Start() error {
+	switch f.options.Transport {
+	case "", "tcp":
+	case "tls":
+		tlsConfig, err := f.buildTLSConfig()
+		if err != nil {
+			return err
+		}
+		f.tlsConfig = tlsConfig
+	default:
+		return bosherr.Errorf("Unsupported log forwarding transport '%s'", f.options.Transport)
+	}
+
+	bufferSizeMB := f.options.BufferSizeMB
+	if bufferSizeMB <= 0 {
+		bufferSizeMB = defaultBufferSizeMB
+	}
+
+	spool, err := NewSpool(filepath.Join(f.stateDir, "spool"), bufferSizeMB*1024*1024, f.logger)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening log forwarding spool")
+	}
+
+	f.spool = spool
+	f.tailer = NewTailer(f.logDir, filepath.Join(f.stateDir, "offsets.json"), f.logger)
+	f.appended = make(chan struct{}, 1)
+	f.stopCh = make(chan struct{})
+
+	f.logger.Info(forwarderLogTag, "Forwarding job logs to %s over %s", f.options.Address, f.transport())
+
+	f.wg.Add(2)
+	go f.tailLoop()
+	go f.sendLoop()
+
+	return nil
+}
+
+// Stop waits for logs read so far to be spooled; unsent ones are sent after the next start
+func (f *Forwarder) Stop() {
+	if f.stopCh == nil {
+		return
+	}
+
+	close(f.stopCh)
+	f.wg.Wait()
+
+	f.tailer.Close()
+
+	err := f.spool.Close()
+	if err != nil {
+		f.logger.Error(forwarderLogTag, "Closing log forwarding spool: %s", err)
+	}
+}
+
+func (f *Forwarder) tailLoop() {
+	defer f.wg.Done()
+	defer f.logger.HandlePanic("Log Forwarder Tailer")
+
+	for {
+		err := f.tailer.Poll(f.spoolLine)
+		if err != nil {
+			f.logger.Error(forwarderLogTag, "Reading job logs: %s", err)
+		}
+
+		select {
+		case f.appended <- struct{}{}:
+		default:
+		}
+
+		if !f.sleep(pollInterval) {
+			return
+		}
+	}
+}
+
+func (f *Forwarder) spoolLine(path string, line []byte) error {
+	severity := SeverityInfo
+	if strings.Contains(filepath.Base(path), "stderr") {
+		severity = SeverityError
+	}
+
+	message := Message{
+		Timestamp: f.timeService.Now(),
+		Hostname:  f.hostname,
+		AppName:   strings.SplitN(filepath.ToSlash(path), "/", 2)[0],
+		Severity:  severity,
+		Path:      filepath.ToSlash(path),
+		Text:      line,
+	}
+
+	return f.spool.Append(message.Frame())
+}
+
+func (f *Forwarder) sendLoop() {
+	defer f.wg.Done()
+	defer f.logger.HandlePanic("Log Forwarder Sender")
+
+	var conn net.Conn
+	connectFailed := false
+
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	for {
+		if conn == nil {
+			var err error
+
+			conn, err = f.dial()
+			if err != nil {
+				// Endpoint may be down for a while hence only the first failure is a warning
+				if connectFailed {
+					f.logger.Debug(forwarderLogTag, "Connecting to %s, %d bytes of logs buffered: %s", f.options.Address, f.spool.Size(), err)
+				} else {
+					f.logger.Warn(forwarderLogTag, "Connecting to %s, buffering logs until it is reachable: %s", f.options.Address, err)
+				}
+				connectFailed = true
+
+				if !f.sleep(retryInterval) {
+					return
+				}
+				continue
+			}
+
+			if connectFailed {
+				f.logger.Info(forwarderLogTag, "Connected to %s, sending %d bytes of buffered logs", f.options.Address, f.spool.Size())
+			}
+			connectFailed = false
+		}
+
+		frame, err := f.spool.Next()
+		if err != nil {
+			f.logger.Error(forwarderLogTag, "Reading log forwarding spool: %s", err)
+
+			if !f.sleep(retryInterval) {
+				return
+			}
+			continue
+		}
+
+		if frame == nil {
+			err = f.spool.Flush()
+			if err != nil {
+				f.logger.Error(forwarderLogTag, "Flushing log forwarding spool: %s", err)
+			}
+
+			select {
+			case <-f.stopCh:
+				return
+			case <-f.appended:
+			}
+			continue
+		}
+
+		_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+
+		_, err = conn.Write(frame)
+		if err != nil {
+			f.logger.Warn(forwarderLogTag, "Sending logs to %s: %s", f.options.Address, err)
+			_ = conn.Close()
+			conn = nil
+			continue
+		}
+
+		f.spool.Ack()
+	}
+}
+
+// sleep returns false once forwarder is stopped
+func (f *Forwarder) sleep(d time.Duration) bool {
+	select {
+	case <-f.stopCh:
+		return false
+	case <-f.timeService.After(d):
+		return true
+	}
+}
+
+func (f *Forwarder) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	if f.tlsConfig != nil {
+		return tls.DialWithDialer(dialer, "tcp", f.options.Address, f.tlsConfig)
+	}
+
+	return dialer.Dial("tcp", f.options.Address)
+}
+
+func (f *Forwarder) buildTLSConfig() (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(f.options.Address)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Parsing log forwarding address '%s'", f.options.Address)
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if f.options.CA != "" {
+		tlsConfig.RootCAs, err = boshcrypto.CertPoolFromPEM([]byte(f.options.CA))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing log forwarding CA")
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+func (f *Forwarder) transport() string {
+	if f.tlsConfig != nil {
+		return "tls"
+	}
+	return "tcp"
+}
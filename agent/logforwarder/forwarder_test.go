@@ -0,0 +1,248 @@
+package logforwarder_test
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/logforwarder"
+)
+
+type fakeSyslogServer struct {
+	listener net.Listener
+
+	lock     sync.Mutex
+	received []byte
+}
+
+func newFakeSyslogServer(listener net.Listener) *fakeSyslogServer {
+	s := &fakeSyslogServer{listener: listener}
+
+	go func() {
+		defer GinkgoRecover()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				buffer := make([]byte, 4096)
+				for {
+					n, err := conn.Read(buffer)
+
+					s.lock.Lock()
+					s.received = append(s.received, buffer[:n]...)
+					s.lock.Unlock()
+
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeSyslogServer) Received() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return string(s.received)
+}
+
+func (s *fakeSyslogServer) Close() {
+	_ = s.listener.Close()
+}
+
+var _ = Describe("Forwarder", func() {
+	var (
+		basePath  string
+		logDir    string
+		stateDir  string
+		options   boshsettings.LogForwarding
+		fakeClock *fakeclock.FakeClock
+		logger    boshlog.Logger
+
+		forwarder *Forwarder
+	)
+
+	appendLog := func(relPath, contents string) {
+		logPath := filepath.Join(logDir, relPath)
+		Expect(os.MkdirAll(filepath.Dir(logPath), 0750)).To(Succeed())
+
+		file, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(contents)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	// received advances time so that forwarder polls logs and retries connecting
+	received := func(server *fakeSyslogServer) func() string {
+		return func() string {
+			fakeClock.Increment(5 * time.Second)
+			return server.Received()
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		basePath, err = os.MkdirTemp("", "forwarder")
+		Expect(err).NotTo(HaveOccurred())
+
+		logDir = filepath.Join(basePath, "log")
+		Expect(os.MkdirAll(logDir, 0750)).To(Succeed())
+
+		stateDir = filepath.Join(basePath, "log_forwarder")
+		fakeClock = fakeclock.NewFakeClock(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+
+		forwarder = nil
+	})
+
+	AfterEach(func() {
+		if forwarder != nil {
+			forwarder.Stop()
+		}
+		Expect(os.RemoveAll(basePath)).To(Succeed())
+	})
+
+	// startForwarder waits for logs to be polled once since logs existing
+	// at that time are only followed from their end
+	startForwarder := func() {
+		forwarder = NewForwarder(options, "fake-agent-id", logDir, stateDir, fakeClock, logger)
+		Expect(forwarder.Start()).To(Succeed())
+
+		Eventually(func() error {
+			_, err := os.Stat(filepath.Join(stateDir, "offsets.json"))
+			return err
+		}).Should(Succeed())
+	}
+
+	Context("over tcp", func() {
+		var server *fakeSyslogServer
+
+		BeforeEach(func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+
+			server = newFakeSyslogServer(listener)
+			options = boshsettings.LogForwarding{Address: listener.Addr().String()}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("sends lines of job logs as syslog messages", func() {
+			startForwarder()
+
+			appendLog("fake-job/fake-job.stdout.log", "fake-stdout-line\n")
+			appendLog("fake-job/fake-job.stderr.log", "fake-stderr-line\n")
+
+			Eventually(received(server)).Should(MatchRegexp(
+				`126 <11>1 2026-10-18T\d\d:\d\d:\d\d\.000000Z fake-agent-id fake-job - - \[file@47450 path="fake-job/fake-job\.stderr\.log"\] fake-stderr-line`,
+			))
+			Eventually(received(server)).Should(MatchRegexp(
+				`126 <14>1 2026-10-18T\d\d:\d\d:\d\d\.000000Z fake-agent-id fake-job - - \[file@47450 path="fake-job/fake-job\.stdout\.log"\] fake-stdout-line`,
+			))
+		})
+
+		It("sends logs which were buffered while forwarder was stopped", func() {
+			startForwarder()
+
+			appendLog("fake-job/fake.log", "fake-line-1\n")
+			Eventually(received(server)).Should(ContainSubstring("fake-line-1"))
+
+			forwarder.Stop()
+
+			appendLog("fake-job/fake.log", "fake-line-2\n")
+
+			startForwarder()
+
+			Eventually(received(server)).Should(ContainSubstring("fake-line-2"))
+			Expect(server.Received()).To(ContainSubstring("fake-line-1"))
+		})
+	})
+
+	It("buffers logs while endpoint is unreachable", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		address := listener.Addr().String()
+		Expect(listener.Close()).To(Succeed())
+
+		options = boshsettings.LogForwarding{Address: address}
+		startForwarder()
+
+		appendLog("fake-job/fake.log", "fake-line-1\n")
+		fakeClock.WaitForWatcherAndIncrement(5 * time.Second)
+
+		listener, err = net.Listen("tcp", address)
+		Expect(err).NotTo(HaveOccurred())
+
+		server := newFakeSyslogServer(listener)
+		defer server.Close()
+
+		appendLog("fake-job/fake.log", "fake-line-2\n")
+
+		Eventually(received(server)).Should(ContainSubstring("fake-line-2"))
+		Expect(server.Received()).To(ContainSubstring("fake-line-1"))
+	})
+
+	It("sends logs over tls verifying endpoint with given CA", func() {
+		tlsServer := httptest.NewUnstartedServer(nil)
+		tlsServer.StartTLS()
+		certificate := tlsServer.Certificate()
+		tlsConfig := tlsServer.TLS
+		tlsServer.Close()
+
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+		Expect(err).NotTo(HaveOccurred())
+
+		server := newFakeSyslogServer(listener)
+		defer server.Close()
+
+		options = boshsettings.LogForwarding{
+			Address:   listener.Addr().String(),
+			Transport: "tls",
+			CA:        string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})),
+		}
+		startForwarder()
+
+		appendLog("fake-job/fake.log", "fake-line\n")
+
+		Eventually(received(server)).Should(ContainSubstring("fake-line"))
+	})
+
+	It("returns error if transport is not supported", func() {
+		err := NewForwarder(boshsettings.LogForwarding{Address: "fake-address:514", Transport: "udp"}, "fake-agent-id", logDir, stateDir, fakeClock, logger).Start()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unsupported log forwarding transport 'udp'"))
+	})
+
+	It("returns error if CA cannot be parsed", func() {
+		err := NewForwarder(boshsettings.LogForwarding{Address: "fake-address:6514", Transport: "tls", CA: "fake-ca"}, "fake-agent-id", logDir, stateDir, fakeClock, logger).Start()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing log forwarding CA"))
+	})
+})
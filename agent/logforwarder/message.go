@@ -0,0 +1,96 @@
+package logforwarder
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = 3
+	SeverityInfo  Severity = 6
+
+	facilityUser = 1
+
+	// Private enterprise number of Cloud Foundry Foundation
+	structuredDataID = "file@47450"
+
+	maxHostnameLength = 255
+	maxAppNameLength  = 48
+)
+
+// Message is a single log line formatted as RFC 5424 syslog message
+type Message struct {
+	Timestamp time.Time
+	Hostname  string
+	AppName   string
+	Severity  Severity
+
+	// Path of the log file relative to the logs directory
+	Path string
+
+	Text []byte
+}
+
+// Frame returns message using octet counting framing of RFC 6587,
+// i.e. "<length> <message>", which is what TCP syslog receivers expect
+func (m Message) Frame() []byte {
+	msg := m.format()
+	return append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+}
+
+func (m Message) format() []byte {
+	buffer := bytes.NewBuffer(nil)
+
+	buffer.WriteString("<")
+	buffer.WriteString(strconv.Itoa(facilityUser*8 + int(m.Severity)))
+	buffer.WriteString(">1 ")
+	buffer.WriteString(m.Timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00"))
+	buffer.WriteString(" ")
+	buffer.WriteString(headerField(m.Hostname, maxHostnameLength))
+	buffer.WriteString(" ")
+	buffer.WriteString(headerField(m.AppName, maxAppNameLength))
+	buffer.WriteString(" - - ") // PROCID and MSGID
+
+	if m.Path == "" {
+		buffer.WriteString("-")
+	} else {
+		buffer.WriteString("[" + structuredDataID + ` path="`)
+		buffer.WriteString(escapeParamValue(m.Path))
+		buffer.WriteString(`"]`)
+	}
+
+	if len(m.Text) > 0 {
+		buffer.WriteString(" ")
+		buffer.Write(m.Text)
+	}
+
+	return buffer.Bytes()
+}
+
+// headerField keeps printable US-ASCII characters only as required for header fields
+func headerField(value string, maxLength int) string {
+	field := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, value)
+
+	if len(field) > maxLength {
+		field = field[:maxLength]
+	}
+
+	if field == "" {
+		return "-"
+	}
+
+	return field
+}
+
+func escapeParamValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
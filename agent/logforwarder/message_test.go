@@ -0,0 +1,58 @@
+package logforwarder_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/logforwarder"
+)
+
+var _ = Describe("Message", func() {
+	var message Message
+
+	BeforeEach(func() {
+		message = Message{
+			Timestamp: time.Date(2026, 10, 18, 12, 30, 45, 123456789, time.FixedZone("fake-zone", 3600)),
+			Hostname:  "fake-agent-id",
+			AppName:   "fake-job",
+			Severity:  SeverityInfo,
+			Path:      "fake-job/fake-job.stdout.log",
+			Text:      []byte("fake log line"),
+		}
+	})
+
+	Describe("Frame", func() {
+		It("formats RFC 5424 message with octet counting", func() {
+			msg := `<14>1 2026-10-18T11:30:45.123456Z fake-agent-id fake-job - - [file@47450 path="fake-job/fake-job.stdout.log"] fake log line`
+			Expect(string(message.Frame())).To(Equal("123 " + msg))
+			Expect(len(msg)).To(Equal(123))
+		})
+
+		It("uses priority of user facility with given severity", func() {
+			message.Severity = SeverityError
+			Expect(string(message.Frame())).To(ContainSubstring(" <11>1 "))
+		})
+
+		It("uses nil values for empty header fields and structured data", func() {
+			message.Hostname = ""
+			message.AppName = " "
+			message.Path = ""
+
+			Expect(string(message.Frame())).To(HaveSuffix(" - - - - - fake log line"))
+		})
+
+		It("truncates app name and removes characters which are not allowed in header fields", func() {
+			message.AppName = "fake job-" + "0123456789012345678901234567890123456789012345678901234567890123456789"
+
+			Expect(string(message.Frame())).To(ContainSubstring(" fakejob-0123456789012345678901234567890123456789 - - "))
+		})
+
+		It("escapes path in structured data", func() {
+			message.Path = `fake-job/fake"]\.log`
+
+			Expect(string(message.Frame())).To(ContainSubstring(`[file@47450 path="fake-job/fake\"\]\\.log"]`))
+		})
+	})
+})
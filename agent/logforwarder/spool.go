@@ -0,0 +1,387 @@
+package logforwarder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	spoolLogTag = "logForwarderSpool"
+
+	spoolSegmentSuffix = ".seg"
+	spoolPositionFile  = "position.json"
+
+	// Oldest logs are dropped a segment at a time once spool is full
+	spoolSegmentCount = 8
+
+	// Length prefix of a frame is at most this long, see Message.Frame
+	maxFrameLengthDigits = 10
+)
+
+type spoolPosition struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+// Spool keeps frames on disk until they were sent so that logs survive
+// the syslog endpoint being unreachable as well as agent restarts.
+// Frames are appended to numbered segment files and read in order;
+// read position is persisted on Flush, hence frames read since the last
+// Flush may be sent again after a restart.
+type Spool struct {
+	dir              string
+	maxSizeBytes     int64
+	segmentSizeBytes int64
+	logger           boshlog.Logger
+
+	lock      sync.Mutex
+	segments  map[uint64]int64 // segment sizes
+	size      int64
+	writeID   uint64
+	writeFile *os.File
+	readPos   spoolPosition
+	readFile  *os.File
+	pending   int64 // length of frame returned by Next which was not acked yet
+	flushed   spoolPosition
+}
+
+func NewSpool(dir string, maxSizeBytes int64, logger boshlog.Logger) (*Spool, error) {
+	s := &Spool{
+		dir:              dir,
+		maxSizeBytes:     maxSizeBytes,
+		segmentSizeBytes: maxSizeBytes / spoolSegmentCount,
+		logger:           logger,
+		segments:         map[uint64]int64{},
+	}
+
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating spool directory %s", dir)
+	}
+
+	err = s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Append adds frame to the end of the spool, dropping oldest frames if spool gets full
+func (s *Spool) Append(frame []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.segments[s.writeID] > 0 && s.segments[s.writeID]+int64(len(frame)) > s.segmentSizeBytes {
+		err := s.openWriteSegment(s.writeID + 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := s.writeFile.Write(frame)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Appending to spool segment %s", s.writeFile.Name())
+	}
+
+	s.segments[s.writeID] += int64(len(frame))
+	s.size += int64(len(frame))
+
+	for s.size > s.maxSizeBytes && len(s.segments) > 1 {
+		s.dropOldestSegment()
+	}
+
+	return nil
+}
+
+// Next returns the oldest frame which was not acked yet, or nil if there is none
+func (s *Spool) Next() ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for {
+		frame, err := s.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		if frame != nil {
+			s.pending = int64(len(frame))
+			return frame, nil
+		}
+
+		if s.readPos.Segment == s.writeID {
+			return nil, nil
+		}
+
+		// Segment was consumed entirely hence it is no longer needed
+		s.removeSegment(s.readPos.Segment)
+		s.readPos = spoolPosition{Segment: s.nextSegment(s.readPos.Segment)}
+	}
+}
+
+// Ack marks frame returned by the last call to Next as sent
+func (s *Spool) Ack() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.readPos.Offset += s.pending
+	s.pending = 0
+}
+
+// Flush persists read position so that acked frames are not sent again after a restart
+func (s *Spool) Flush() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.readPos == s.flushed {
+		return nil
+	}
+
+	contents, err := json.Marshal(s.readPos)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling spool position")
+	}
+
+	positionPath := filepath.Join(s.dir, spoolPositionFile)
+
+	err = os.WriteFile(positionPath+".tmp", contents, 0640)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", positionPath)
+	}
+
+	err = os.Rename(positionPath+".tmp", positionPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Renaming %s", positionPath)
+	}
+
+	s.flushed = s.readPos
+
+	return nil
+}
+
+// Size returns total size of frames kept on disk including sent ones of the current segment
+func (s *Spool) Size() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.size
+}
+
+func (s *Spool) Close() error {
+	err := s.Flush()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closeReadFile()
+
+	if s.writeFile != nil {
+		_ = s.writeFile.Close()
+		s.writeFile = nil
+	}
+
+	return err
+}
+
+func (s *Spool) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listing spool directory %s", s.dir)
+	}
+
+	for _, entry := range entries {
+		id, err := strconv.ParseUint(strings.TrimSuffix(entry.Name(), spoolSegmentSuffix), 10, 64)
+		if err != nil || !strings.HasSuffix(entry.Name(), spoolSegmentSuffix) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Checking spool segment %s", entry.Name())
+		}
+
+		s.segments[id] = info.Size()
+		s.size += info.Size()
+
+		if id > s.writeID {
+			s.writeID = id
+		}
+	}
+
+	contents, err := os.ReadFile(filepath.Join(s.dir, spoolPositionFile))
+	if err == nil {
+		err = json.Unmarshal(contents, &s.readPos)
+		if err != nil {
+			s.logger.Warn(spoolLogTag, "Ignoring invalid spool position: %s", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return bosherr.WrapError(err, "Reading spool position")
+	}
+
+	// Last segment may end with a partially written frame
+	// hence frames are never appended to existing segments
+	err = s.openWriteSegment(s.writeID + 1)
+	if err != nil {
+		return err
+	}
+
+	if _, found := s.segments[s.readPos.Segment]; !found {
+		s.readPos = spoolPosition{Segment: s.oldestSegment()}
+	}
+	s.flushed = s.readPos
+
+	return nil
+}
+
+func (s *Spool) openWriteSegment(id uint64) error {
+	file, err := os.OpenFile(s.segmentPath(id), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating spool segment %d", id)
+	}
+
+	if s.writeFile != nil {
+		_ = s.writeFile.Close()
+	}
+
+	s.writeFile = file
+	s.writeID = id
+	s.segments[id] = 0
+
+	return nil
+}
+
+// readFrame returns nil when there are no more complete frames in current read segment
+func (s *Spool) readFrame() ([]byte, error) {
+	if s.readFile == nil {
+		file, err := os.Open(s.segmentPath(s.readPos.Segment))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Opening spool segment %d", s.readPos.Segment)
+		}
+		s.readFile = file
+	}
+
+	prefix := make([]byte, maxFrameLengthDigits+1)
+	n, err := s.readFile.ReadAt(prefix, s.readPos.Offset)
+	if err != nil && err != io.EOF {
+		return nil, bosherr.WrapErrorf(err, "Reading spool segment %d", s.readPos.Segment)
+	}
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	space := bytes.IndexByte(prefix[:n], ' ')
+	if space < 0 {
+		return nil, s.skipCorruptSegment("missing frame length")
+	}
+
+	length, err := strconv.Atoi(string(prefix[:space]))
+	if err != nil || length <= 0 {
+		return nil, s.skipCorruptSegment(fmt.Sprintf("invalid frame length '%s'", prefix[:space]))
+	}
+
+	frame := make([]byte, space+1+length)
+	n, err = s.readFile.ReadAt(frame, s.readPos.Offset)
+	if err == io.EOF || n < len(frame) {
+		if s.readPos.Segment != s.writeID {
+			s.logger.Warn(spoolLogTag, "Skipping partially written frame at end of spool segment %d", s.readPos.Segment)
+		}
+		return nil, nil
+	} else if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading spool segment %d", s.readPos.Segment)
+	}
+
+	return frame, nil
+}
+
+// skipCorruptSegment moves reading on to the next segment since frames
+// cannot be delimited any longer; this only happens when a file got damaged
+func (s *Spool) skipCorruptSegment(reason string) error {
+	if s.readPos.Segment == s.writeID {
+		return bosherr.Errorf("Reading spool segment %d: %s", s.readPos.Segment, reason)
+	}
+
+	s.logger.Error(spoolLogTag, "Skipping rest of spool segment %d: %s", s.readPos.Segment, reason)
+	s.readPos.Offset = s.segments[s.readPos.Segment]
+
+	return nil
+}
+
+func (s *Spool) dropOldestSegment() {
+	id := s.oldestSegment()
+
+	dropped := s.segments[id]
+	if id == s.readPos.Segment {
+		s.closeReadFile()
+		dropped -= s.readPos.Offset
+		s.readPos = spoolPosition{Segment: s.nextSegment(id)}
+		s.pending = 0
+	}
+
+	s.logger.Warn(spoolLogTag, "Spool exceeds %d bytes, dropped %d bytes of unsent logs", s.maxSizeBytes, dropped)
+
+	s.removeSegment(id)
+}
+
+func (s *Spool) removeSegment(id uint64) {
+	if id == s.readPos.Segment {
+		s.closeReadFile()
+	}
+
+	err := os.Remove(s.segmentPath(id))
+	if err != nil && !os.IsNotExist(err) {
+		s.logger.Warn(spoolLogTag, "Failed to remove spool segment %d: %s", id, err)
+	}
+
+	s.size -= s.segments[id]
+	delete(s.segments, id)
+}
+
+func (s *Spool) closeReadFile() {
+	if s.readFile != nil {
+		_ = s.readFile.Close()
+		s.readFile = nil
+	}
+}
+
+func (s *Spool) sortedSegments() []uint64 {
+	ids := make([]uint64, 0, len(s.segments))
+	for id := range s.segments {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (s *Spool) oldestSegment() uint64 {
+	ids := s.sortedSegments()
+	if len(ids) == 0 {
+		return 0
+	}
+	return ids[0]
+}
+
+func (s *Spool) nextSegment(id uint64) uint64 {
+	for _, next := range s.sortedSegments() {
+		if next > id {
+			return next
+		}
+	}
+	return s.writeID
+}
+
+func (s *Spool) segmentPath(id uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", id, spoolSegmentSuffix))
+}
@@ -0,0 +1,194 @@
+package logforwarder_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/logforwarder"
+)
+
+var _ = Describe("Spool", func() {
+	var (
+		basePath string
+		spoolDir string
+		logger   boshlog.Logger
+	)
+
+	frame := func(text string) []byte {
+		return []byte(fmt.Sprintf("%d %s", len(text), text))
+	}
+
+	newSpool := func(maxSizeBytes int64) *Spool {
+		spool, err := NewSpool(spoolDir, maxSizeBytes, logger)
+		Expect(err).NotTo(HaveOccurred())
+		return spool
+	}
+
+	// readAll returns texts of all remaining frames, acking each of them
+	readAll := func(spool *Spool) []string {
+		texts := []string{}
+		for {
+			f, err := spool.Next()
+			Expect(err).NotTo(HaveOccurred())
+			if f == nil {
+				return texts
+			}
+			texts = append(texts, string(f))
+			spool.Ack()
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		basePath, err = os.MkdirTemp("", "spool")
+		Expect(err).NotTo(HaveOccurred())
+
+		spoolDir = filepath.Join(basePath, "spool")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(basePath)).To(Succeed())
+	})
+
+	It("returns frames in order they were appended", func() {
+		spool := newSpool(1024 * 1024)
+		defer spool.Close()
+
+		Expect(spool.Append(frame("fake-msg-1"))).To(Succeed())
+		Expect(spool.Append(frame("fake-msg-2"))).To(Succeed())
+
+		Expect(readAll(spool)).To(Equal([]string{"10 fake-msg-1", "10 fake-msg-2"}))
+
+		Expect(spool.Append(frame("fake-msg-3"))).To(Succeed())
+		Expect(readAll(spool)).To(Equal([]string{"10 fake-msg-3"}))
+	})
+
+	It("returns the same frame again until it is acked", func() {
+		spool := newSpool(1024 * 1024)
+		defer spool.Close()
+
+		Expect(spool.Append(frame("fake-msg-1"))).To(Succeed())
+
+		f, err := spool.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(f)).To(Equal("10 fake-msg-1"))
+
+		f, err = spool.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(f)).To(Equal("10 fake-msg-1"))
+
+		spool.Ack()
+
+		f, err = spool.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f).To(BeNil())
+	})
+
+	It("reads frames across segments and removes consumed segments", func() {
+		spool := newSpool(8 * 30) // segments of 30 bytes
+		defer spool.Close()
+
+		for i := 0; i < 5; i++ {
+			Expect(spool.Append(frame(fmt.Sprintf("fake-msg-%d", i)))).To(Succeed())
+		}
+
+		segments, err := filepath.Glob(filepath.Join(spoolDir, "*.seg"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(segments).To(HaveLen(3))
+
+		Expect(readAll(spool)).To(Equal([]string{
+			"10 fake-msg-0", "10 fake-msg-1", "10 fake-msg-2", "10 fake-msg-3", "10 fake-msg-4",
+		}))
+
+		segments, err = filepath.Glob(filepath.Join(spoolDir, "*.seg"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(segments).To(HaveLen(1))
+	})
+
+	It("drops oldest frames once spool exceeds max size", func() {
+		spool := newSpool(8 * 28) // two frames per segment
+		defer spool.Close()
+
+		for i := 0; i < 20; i++ {
+			Expect(spool.Append(frame(fmt.Sprintf("fake-msg-%02d", i)))).To(Succeed())
+		}
+
+		Expect(spool.Size()).To(BeNumerically("<=", 8*28))
+
+		texts := readAll(spool)
+		Expect(texts).To(HaveLen(16))
+		Expect(texts[0]).To(Equal("11 fake-msg-04"))
+		Expect(texts[15]).To(Equal("11 fake-msg-19"))
+	})
+
+	It("drops unacked frame returned by Next when its segment is dropped", func() {
+		spool := newSpool(8 * 28)
+		defer spool.Close()
+
+		Expect(spool.Append(frame("fake-msg-00"))).To(Succeed())
+
+		f, err := spool.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(f)).To(Equal("11 fake-msg-00"))
+
+		for i := 1; i < 20; i++ {
+			Expect(spool.Append(frame(fmt.Sprintf("fake-msg-%02d", i)))).To(Succeed())
+		}
+
+		spool.Ack()
+
+		texts := readAll(spool)
+		Expect(texts[0]).To(Equal("11 fake-msg-04"))
+	})
+
+	It("resumes from flushed position after being reopened", func() {
+		spool := newSpool(1024 * 1024)
+
+		for i := 0; i < 3; i++ {
+			Expect(spool.Append(frame(fmt.Sprintf("fake-msg-%d", i)))).To(Succeed())
+		}
+
+		_, err := spool.Next()
+		Expect(err).NotTo(HaveOccurred())
+		spool.Ack()
+
+		Expect(spool.Close()).To(Succeed())
+
+		spool = newSpool(1024 * 1024)
+		defer spool.Close()
+
+		Expect(spool.Append(frame("fake-msg-3"))).To(Succeed())
+
+		Expect(readAll(spool)).To(Equal([]string{"10 fake-msg-1", "10 fake-msg-2", "10 fake-msg-3"}))
+	})
+
+	It("skips partially written frame at the end of a segment after being reopened", func() {
+		spool := newSpool(1024 * 1024)
+		Expect(spool.Append(frame("fake-msg-1"))).To(Succeed())
+		Expect(spool.Close()).To(Succeed())
+
+		segments, err := filepath.Glob(filepath.Join(spoolDir, "*.seg"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(segments).To(HaveLen(1))
+
+		file, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0640)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = file.WriteString("10 fake-")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		spool = newSpool(1024 * 1024)
+		defer spool.Close()
+
+		Expect(spool.Append(frame("fake-msg-2"))).To(Succeed())
+
+		Expect(readAll(spool)).To(Equal([]string{"10 fake-msg-1", "10 fake-msg-2"}))
+	})
+})
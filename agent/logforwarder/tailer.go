@@ -0,0 +1,286 @@
+package logforwarder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	tailerLogTag = "logForwarderTailer"
+
+	// Longer lines are split into multiple messages
+	maxLineBytes = 16 * 1024
+)
+
+// LineHandler receives a line read from log at path relative to the logs directory
+type LineHandler func(path string, line []byte) error
+
+type tailedFile struct {
+	file   *os.File
+	info   os.FileInfo
+	offset int64 // position after the last line handled
+}
+
+// Tailer follows job logs in <logs dir>/<job>/ and <logs dir>/<job>/<dir>/,
+// the same logs which are rotated by logrotate. Files are kept open so that
+// lines written right before rotation are still read after a log was moved;
+// a log which got smaller was truncated in place and is read from its start.
+type Tailer struct {
+	logDir    string
+	statePath string
+	logger    boshlog.Logger
+
+	files        map[string]*tailedFile
+	offsets      map[string]int64
+	savedOffsets []byte
+	started      bool
+}
+
+func NewTailer(logDir, statePath string, logger boshlog.Logger) *Tailer {
+	return &Tailer{
+		logDir:    logDir,
+		statePath: statePath,
+		logger:    logger,
+		files:     map[string]*tailedFile{},
+	}
+}
+
+// Poll reads lines appended to logs since the last poll. Logs which exist
+// when tailing starts for the first time are only followed from their end;
+// offsets are persisted after each poll so that restarts do not lose lines.
+func (t *Tailer) Poll(handler LineHandler) error {
+	firstStart := false
+
+	if !t.started {
+		var err error
+		firstStart, err = t.loadOffsets()
+		if err != nil {
+			return err
+		}
+		t.started = true
+	}
+
+	paths, err := t.logPaths()
+	if err != nil {
+		return err
+	}
+
+	current := map[string]bool{}
+
+	for _, path := range paths {
+		current[path] = true
+
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		tailed, found := t.files[path]
+		if found && !os.SameFile(tailed.info, info) {
+			// Log was moved away by rotation, read what was written before
+			err = t.readLines(path, tailed, handler)
+			if err != nil {
+				return err
+			}
+			t.closeFile(path)
+			found = false
+		}
+
+		if !found {
+			offset := t.offsets[path]
+			if firstStart {
+				offset = info.Size()
+			}
+
+			tailed, err = t.openFile(path, offset)
+			if err != nil {
+				t.logger.Warn(tailerLogTag, "Skipping log %s: %s", path, err)
+				continue
+			}
+		}
+
+		err = t.readLines(path, tailed, handler)
+		if err != nil {
+			return err
+		}
+	}
+
+	for path, tailed := range t.files {
+		if !current[path] {
+			// Log was removed or moved out of followed directories
+			err = t.readLines(path, tailed, handler)
+			if err != nil {
+				return err
+			}
+			t.closeFile(path)
+		}
+	}
+
+	return t.saveOffsets()
+}
+
+func (t *Tailer) Close() {
+	for path := range t.files {
+		t.closeFile(path)
+	}
+}
+
+func (t *Tailer) logPaths() ([]string, error) {
+	var paths []string
+
+	for _, pattern := range []string{"*/*.log", "*/.*.log", "*/*/*.log", "*/*/.*.log"} {
+		matches, err := filepath.Glob(filepath.Join(t.logDir, pattern))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Globbing logs in %s", t.logDir)
+		}
+		paths = append(paths, matches...)
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+func (t *Tailer) openFile(path string, offset int64) (*tailedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if offset > info.Size() {
+		offset = 0
+	}
+
+	tailed := &tailedFile{file: file, info: info, offset: offset}
+	t.files[path] = tailed
+
+	return tailed, nil
+}
+
+func (t *Tailer) closeFile(path string) {
+	_ = t.files[path].file.Close()
+	delete(t.files, path)
+	delete(t.offsets, path)
+}
+
+func (t *Tailer) readLines(path string, tailed *tailedFile, handler LineHandler) error {
+	info, err := tailed.file.Stat()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Checking log %s", path)
+	}
+
+	if info.Size() < tailed.offset {
+		t.logger.Debug(tailerLogTag, "Log %s was truncated, reading from its start", path)
+		tailed.offset = 0
+	}
+
+	relPath, err := filepath.Rel(t.logDir, path)
+	if err != nil {
+		relPath = path
+	}
+
+	buffer := make([]byte, maxLineBytes)
+
+	for {
+		n, err := tailed.file.ReadAt(buffer, tailed.offset)
+		if err != nil && err != io.EOF {
+			return bosherr.WrapErrorf(err, "Reading log %s", path)
+		}
+
+		chunk := buffer[:n]
+		consumed := 0
+
+		for {
+			newline := bytes.IndexByte(chunk[consumed:], '\n')
+			if newline < 0 {
+				break
+			}
+
+			line := bytes.TrimSuffix(chunk[consumed:consumed+newline], []byte("\r"))
+			if len(line) > 0 {
+				err = handler(relPath, line)
+				if err != nil {
+					return err
+				}
+			}
+			consumed += newline + 1
+		}
+
+		if consumed == 0 && n == len(buffer) {
+			// Line does not fit into the buffer
+			err = handler(relPath, chunk)
+			if err != nil {
+				return err
+			}
+			consumed = n
+		}
+
+		tailed.offset += int64(consumed)
+		t.offsets[path] = tailed.offset
+
+		// Partial line is read again once it was completed
+		if n < len(buffer) || consumed == 0 {
+			return nil
+		}
+	}
+}
+
+func (t *Tailer) loadOffsets() (bool, error) {
+	t.offsets = map[string]int64{}
+
+	contents, err := os.ReadFile(t.statePath)
+	if os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, bosherr.WrapErrorf(err, "Reading %s", t.statePath)
+	}
+
+	err = json.Unmarshal(contents, &t.offsets)
+	if err != nil {
+		t.logger.Warn(tailerLogTag, "Ignoring invalid log offsets: %s", err)
+		t.offsets = map[string]int64{}
+		return true, nil
+	}
+
+	t.savedOffsets = contents
+
+	return false, nil
+}
+
+func (t *Tailer) saveOffsets() error {
+	contents, err := json.Marshal(t.offsets)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling log offsets")
+	}
+
+	if bytes.Equal(t.savedOffsets, contents) {
+		return nil
+	}
+
+	err = os.WriteFile(t.statePath+".tmp", contents, 0640)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", t.statePath)
+	}
+
+	err = os.Rename(t.statePath+".tmp", t.statePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Renaming %s", t.statePath)
+	}
+
+	t.savedOffsets = contents
+
+	return nil
+}
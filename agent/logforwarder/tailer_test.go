@@ -0,0 +1,178 @@
+package logforwarder_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/logforwarder"
+)
+
+var _ = Describe("Tailer", func() {
+	var (
+		basePath  string
+		logDir    string
+		statePath string
+		logger    boshlog.Logger
+
+		tailer *Tailer
+		lines  []string
+	)
+
+	appendLog := func(relPath, contents string) {
+		logPath := filepath.Join(logDir, relPath)
+		Expect(os.MkdirAll(filepath.Dir(logPath), 0750)).To(Succeed())
+
+		file, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(contents)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	poll := func() []string {
+		lines = []string{}
+		err := tailer.Poll(func(path string, line []byte) error {
+			lines = append(lines, path+": "+string(line))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return lines
+	}
+
+	BeforeEach(func() {
+		var err error
+		basePath, err = os.MkdirTemp("", "tailer")
+		Expect(err).NotTo(HaveOccurred())
+
+		logDir = filepath.Join(basePath, "log")
+		Expect(os.MkdirAll(logDir, 0750)).To(Succeed())
+
+		statePath = filepath.Join(basePath, "offsets.json")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+
+		tailer = NewTailer(logDir, statePath, logger)
+	})
+
+	AfterEach(func() {
+		tailer.Close()
+		Expect(os.RemoveAll(basePath)).To(Succeed())
+	})
+
+	It("follows existing logs from their end when started for the first time", func() {
+		appendLog("fake-job/fake-job.stdout.log", "fake-old-line\n")
+
+		Expect(poll()).To(BeEmpty())
+
+		appendLog("fake-job/fake-job.stdout.log", "fake-new-line\n")
+
+		Expect(poll()).To(Equal([]string{"fake-job/fake-job.stdout.log: fake-new-line"}))
+	})
+
+	It("reads logs created after start from their beginning", func() {
+		Expect(poll()).To(BeEmpty())
+
+		appendLog("fake-job/fake-job.stdout.log", "fake-line-1\r\nfake-line-2\n")
+		appendLog("fake-job/nested/fake.log", "fake-nested-line\n")
+
+		Expect(poll()).To(Equal([]string{
+			"fake-job/fake-job.stdout.log: fake-line-1",
+			"fake-job/fake-job.stdout.log: fake-line-2",
+			"fake-job/nested/fake.log: fake-nested-line",
+		}))
+	})
+
+	It("ignores logs which are not in a job directory", func() {
+		Expect(poll()).To(BeEmpty())
+
+		appendLog("fake.log", "fake-line\n")
+		appendLog("fake-job/fake.txt", "fake-line\n")
+
+		Expect(poll()).To(BeEmpty())
+	})
+
+	It("reads partial lines once they were completed", func() {
+		Expect(poll()).To(BeEmpty())
+
+		appendLog("fake-job/fake.log", "fake-par")
+		Expect(poll()).To(BeEmpty())
+
+		appendLog("fake-job/fake.log", "tial-line\n")
+		Expect(poll()).To(Equal([]string{"fake-job/fake.log: fake-partial-line"}))
+	})
+
+	It("splits lines which are too long", func() {
+		Expect(poll()).To(BeEmpty())
+
+		appendLog("fake-job/fake.log", strings.Repeat("a", 16*1024+10)+"\n")
+
+		Expect(poll()).To(Equal([]string{
+			"fake-job/fake.log: " + strings.Repeat("a", 16*1024),
+			"fake-job/fake.log: " + strings.Repeat("a", 10),
+		}))
+	})
+
+	It("reads lines written before a log was moved away by rotation", func() {
+		Expect(poll()).To(BeEmpty())
+
+		appendLog("fake-job/fake.log", "fake-line-1\n")
+		Expect(poll()).To(HaveLen(1))
+
+		appendLog("fake-job/fake.log", "fake-line-2\n")
+		Expect(os.Rename(filepath.Join(logDir, "fake-job/fake.log"), filepath.Join(logDir, "fake-job/fake.log.1"))).To(Succeed())
+		appendLog("fake-job/fake.log", "fake-line-3\n")
+
+		Expect(poll()).To(Equal([]string{
+			"fake-job/fake.log: fake-line-2",
+			"fake-job/fake.log: fake-line-3",
+		}))
+	})
+
+	It("reads a log from its start after it was truncated", func() {
+		Expect(poll()).To(BeEmpty())
+
+		appendLog("fake-job/fake.log", "fake-line-1\nfake-line-2\n")
+		Expect(poll()).To(HaveLen(2))
+
+		Expect(os.Truncate(filepath.Join(logDir, "fake-job/fake.log"), 0)).To(Succeed())
+		appendLog("fake-job/fake.log", "fake-line-3\n")
+
+		Expect(poll()).To(Equal([]string{"fake-job/fake.log: fake-line-3"}))
+	})
+
+	It("resumes from persisted offsets after a restart", func() {
+		Expect(poll()).To(BeEmpty())
+
+		appendLog("fake-job/fake.log", "fake-line-1\n")
+		Expect(poll()).To(HaveLen(1))
+
+		tailer.Close()
+
+		appendLog("fake-job/fake.log", "fake-line-2\n")
+		appendLog("other-job/other.log", "fake-other-line\n")
+
+		tailer = NewTailer(logDir, statePath, logger)
+
+		Expect(poll()).To(Equal([]string{
+			"fake-job/fake.log: fake-line-2",
+			"other-job/other.log: fake-other-line",
+		}))
+	})
+
+	It("returns error from line handler", func() {
+		Expect(poll()).To(BeEmpty())
+
+		appendLog("fake-job/fake.log", "fake-line\n")
+
+		err := tailer.Poll(func(string, []byte) error {
+			return os.ErrClosed
+		})
+		Expect(err).To(Equal(os.ErrClosed))
+	})
+})
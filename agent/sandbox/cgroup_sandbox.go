@@ -0,0 +1,256 @@
+package sandbox
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	CgroupRoot = "/sys/fs/cgroup"
+
+	cgroupSandboxLogTag = "cgroupSandbox"
+
+	// Parent cgroup of all sandboxes, relative to cgroup root
+	sandboxesCgroup = "bosh-agent-sandboxes"
+
+	sandboxControllers = "+cpu +memory +pids"
+
+	cpuPeriodMicroseconds = 100000
+
+	// Killed processes are waited for up to 10s before cgroup is removed
+	releaseAttempts   = 100
+	releaseRetryDelay = 100 * time.Millisecond
+)
+
+var unsafeCgroupNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+type cgroupSandbox struct {
+	fs            boshsys.FileSystem
+	runner        boshsys.CmdRunner
+	cgroupRoot    string
+	readOnlyPaths []string
+	limits        Limits
+	logger        boshlog.Logger
+}
+
+// NewCgroupSandbox confines commands to a cgroup v2 group with given
+// limits and a private mount namespace in which readOnlyPaths (and
+// whatever is mounted below them) cannot be written. It relies on
+// unshare(1) and mount(8) and therefore only works on Linux.
+func NewCgroupSandbox(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	cgroupRoot string,
+	readOnlyPaths []string,
+	limits Limits,
+	logger boshlog.Logger,
+) Sandbox {
+	return cgroupSandbox{
+		fs:            fs,
+		runner:        runner,
+		cgroupRoot:    cgroupRoot,
+		readOnlyPaths: readOnlyPaths,
+		limits:        limits,
+		logger:        logger,
+	}
+}
+
+func (s cgroupSandbox) Confine(id string, cmd boshsys.Command, writablePaths []string) (boshsys.Command, error) {
+	cgroupPath, err := s.createCgroup(id)
+	if err != nil {
+		return boshsys.Command{}, err
+	}
+
+	env := map[string]string{}
+	for name, value := range cmd.Env {
+		env[name] = value
+	}
+
+	// Everything but /tmp is read-only, including home directory
+	env["TMPDIR"] = "/tmp"
+	env["HOME"] = "/tmp"
+
+	confinedCmd := cmd
+	confinedCmd.Name = "unshare"
+	confinedCmd.Args = append(
+		[]string{"--mount", "--propagation", "private", "--", "bash", "-e", "-c", s.setupScript(cgroupPath, writablePaths), "bosh-sandbox", cmd.Name},
+		cmd.Args...,
+	)
+	confinedCmd.Env = env
+
+	return confinedCmd, nil
+}
+
+func (s cgroupSandbox) Release(id string) error {
+	cgroupPath := s.cgroupPath(id)
+
+	if !s.fs.FileExists(cgroupPath) {
+		return nil
+	}
+
+	s.killProcesses(id, cgroupPath)
+
+	// Killing is asynchronous and non-empty cgroup cannot be removed
+	err := boshretry.NewAttemptRetryStrategy(releaseAttempts, releaseRetryDelay, s.emptyRetryable(cgroupPath), s.logger).Try()
+	if err != nil {
+		s.logger.Warn(cgroupSandboxLogTag, "Waiting for processes of sandbox %s to exit: %s", id, err.Error())
+	}
+
+	// Files in cgroupfs cannot be unlinked hence only directory itself is removed
+	_, _, _, err = s.runner.RunCommand("rmdir", cgroupPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing cgroup of sandbox %s", id)
+	}
+
+	return nil
+}
+
+// killProcesses kills processes left behind by the command; cgroup.kill
+// is not supported before Linux 5.14 hence processes are killed one by one
+func (s cgroupSandbox) killProcesses(id, cgroupPath string) {
+	err := s.fs.WriteFileString(path.Join(cgroupPath, "cgroup.kill"), "1")
+	if err == nil {
+		return
+	}
+
+	procs, err := s.fs.ReadFileString(path.Join(cgroupPath, "cgroup.procs"))
+	if err != nil {
+		s.logger.Warn(cgroupSandboxLogTag, "Listing processes of sandbox %s: %s", id, err.Error())
+		return
+	}
+
+	pids := strings.Fields(procs)
+	if len(pids) == 0 {
+		return
+	}
+
+	_, _, _, err = s.runner.RunCommand("kill", append([]string{"-9"}, pids...)...)
+	if err != nil {
+		s.logger.Warn(cgroupSandboxLogTag, "Killing processes of sandbox %s: %s", id, err.Error())
+	}
+}
+
+func (s cgroupSandbox) emptyRetryable(cgroupPath string) boshretry.Retryable {
+	return boshretry.NewRetryable(func() (bool, error) {
+		events, err := s.fs.ReadFileString(path.Join(cgroupPath, "cgroup.events"))
+		if err != nil {
+			return false, bosherr.WrapError(err, "Reading cgroup events")
+		}
+
+		for _, line := range strings.Split(events, "\n") {
+			if strings.TrimSpace(line) == "populated 0" {
+				return false, nil
+			}
+		}
+
+		return true, bosherr.Error("Cgroup is still populated")
+	})
+}
+
+func (s cgroupSandbox) createCgroup(id string) (string, error) {
+	if !s.fs.FileExists(path.Join(s.cgroupRoot, "cgroup.controllers")) {
+		return "", bosherr.Errorf("Cgroup v2 is not mounted at %s", s.cgroupRoot)
+	}
+
+	// Controllers have to be enabled on each level above the sandbox
+	parentPath := path.Join(s.cgroupRoot, sandboxesCgroup)
+
+	err := s.fs.WriteFileString(path.Join(s.cgroupRoot, "cgroup.subtree_control"), sandboxControllers)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Enabling cgroup controllers")
+	}
+
+	err = s.fs.MkdirAll(parentPath, 0755)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating sandboxes cgroup")
+	}
+
+	err = s.fs.WriteFileString(path.Join(parentPath, "cgroup.subtree_control"), sandboxControllers)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Enabling sandboxes cgroup controllers")
+	}
+
+	// Cgroup of a previous run may still be around if agent was restarted
+	err = s.Release(id)
+	if err != nil {
+		return "", err
+	}
+
+	cgroupPath := s.cgroupPath(id)
+
+	err = s.fs.MkdirAll(cgroupPath, 0755)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating cgroup of sandbox %s", id)
+	}
+
+	limits := map[string]string{}
+
+	if s.limits.CPUs > 0 {
+		quota := int64(s.limits.CPUs * cpuPeriodMicroseconds)
+		limits["cpu.max"] = fmt.Sprintf("%d %d", quota, cpuPeriodMicroseconds)
+	}
+
+	if s.limits.MemoryBytes > 0 {
+		limits["memory.max"] = fmt.Sprintf("%d", s.limits.MemoryBytes)
+	}
+
+	if s.limits.MaxPids > 0 {
+		limits["pids.max"] = fmt.Sprintf("%d", s.limits.MaxPids)
+	}
+
+	for name, value := range limits {
+		err = s.fs.WriteFileString(path.Join(cgroupPath, name), value)
+		if err != nil {
+			if releaseErr := s.Release(id); releaseErr != nil {
+				s.logger.Warn(cgroupSandboxLogTag, "Removing cgroup of sandbox %s: %s", id, releaseErr.Error())
+			}
+			return "", bosherr.WrapErrorf(err, "Setting %s of sandbox %s", name, id)
+		}
+	}
+
+	return cgroupPath, nil
+}
+
+// setupScript runs inside the new mount namespace before command is
+// exec'd, so that command starts already confined. Writable paths are
+// bind mounted after read-only ones as bind mounts inherit flags.
+func (s cgroupSandbox) setupScript(cgroupPath string, writablePaths []string) string {
+	lines := []string{
+		fmt.Sprintf("echo $$ > %s", shellQuote(path.Join(cgroupPath, "cgroup.procs"))),
+		"mount -t tmpfs -o mode=1777,nosuid,nodev tmpfs /tmp",
+	}
+
+	for _, p := range s.readOnlyPaths {
+		lines = append(lines,
+			fmt.Sprintf("mount --rbind %s %s", shellQuote(p), shellQuote(p)),
+			fmt.Sprintf("mount -o remount,bind,ro %s", shellQuote(p)),
+		)
+	}
+
+	for _, p := range writablePaths {
+		lines = append(lines,
+			fmt.Sprintf("mount --bind %s %s", shellQuote(p), shellQuote(p)),
+			fmt.Sprintf("mount -o remount,bind,rw %s", shellQuote(p)),
+		)
+	}
+
+	lines = append(lines, `exec "$@"`)
+
+	return strings.Join(lines, "\n")
+}
+
+func (s cgroupSandbox) cgroupPath(id string) string {
+	return path.Join(s.cgroupRoot, sandboxesCgroup, unsafeCgroupNameChars.ReplaceAllString(id, "_"))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,231 @@
+package sandbox_test
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/sandbox"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+// eventsFileSystem reports cgroup as populated for a number of reads of cgroup.events
+type eventsFileSystem struct {
+	*fakesys.FakeFileSystem
+	populatedReads int
+}
+
+func (fs *eventsFileSystem) ReadFileString(path string) (string, error) {
+	if strings.HasSuffix(path, "/cgroup.events") && fs.populatedReads > 0 {
+		fs.populatedReads--
+		return "populated 1\nfrozen 0\n", nil
+	}
+	return fs.FakeFileSystem.ReadFileString(path)
+}
+
+var _ = Describe("cgroupSandbox", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		runner  *fakesys.FakeCmdRunner
+		limits  Limits
+		sandbox Sandbox
+		cmd     boshsys.Command
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+		Expect(fs.WriteFileString("/fake-cgroup/cgroup.controllers", "cpu memory pids")).To(Succeed())
+
+		limits = Limits{CPUs: 1.5, MemoryBytes: 1024, MaxPids: 100}
+
+		cmd = boshsys.Command{
+			Name:       "bash",
+			Args:       []string{"-x", "packaging"},
+			Env:        map[string]string{"BOSH_COMPILE_TARGET": "/fake-compile-dir/pkg_name"},
+			WorkingDir: "/fake-compile-dir/pkg_name",
+		}
+	})
+
+	JustBeforeEach(func() {
+		sandbox = NewCgroupSandbox(fs, runner, "/fake-cgroup", []string{"/", "/var/vcap/data"}, limits, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("Confine", func() {
+		It("enables controllers for sandboxes", func() {
+			_, err := sandbox.Confine("pkg_name", cmd, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/fake-cgroup/cgroup.subtree_control")).To(Equal("+cpu +memory +pids"))
+			Expect(fs.ReadFileString("/fake-cgroup/bosh-agent-sandboxes/cgroup.subtree_control")).To(Equal("+cpu +memory +pids"))
+		})
+
+		It("creates cgroup with configured limits", func() {
+			_, err := sandbox.Confine("pkg_name", cmd, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/fake-cgroup/bosh-agent-sandboxes/pkg_name/cpu.max")).To(Equal("150000 100000"))
+			Expect(fs.ReadFileString("/fake-cgroup/bosh-agent-sandboxes/pkg_name/memory.max")).To(Equal("1024"))
+			Expect(fs.ReadFileString("/fake-cgroup/bosh-agent-sandboxes/pkg_name/pids.max")).To(Equal("100"))
+		})
+
+		Context("when limits are not set", func() {
+			BeforeEach(func() {
+				limits = Limits{}
+			})
+
+			It("does not restrict resources", func() {
+				_, err := sandbox.Confine("pkg_name", cmd, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.FileExists("/fake-cgroup/bosh-agent-sandboxes/pkg_name")).To(BeTrue())
+				Expect(fs.FileExists("/fake-cgroup/bosh-agent-sandboxes/pkg_name/cpu.max")).To(BeFalse())
+				Expect(fs.FileExists("/fake-cgroup/bosh-agent-sandboxes/pkg_name/memory.max")).To(BeFalse())
+				Expect(fs.FileExists("/fake-cgroup/bosh-agent-sandboxes/pkg_name/pids.max")).To(BeFalse())
+			})
+		})
+
+		It("sanitizes cgroup names", func() {
+			_, err := sandbox.Confine("../pkg name", cmd, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/fake-cgroup/bosh-agent-sandboxes/.._pkg_name")).To(BeTrue())
+		})
+
+		It("runs command in a private mount namespace joining the cgroup", func() {
+			confinedCmd, err := sandbox.Confine("pkg_name", cmd, []string{"/fake-compile-dir/pkg_name", "/fake-install-dir"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(confinedCmd.Name).To(Equal("unshare"))
+			Expect(confinedCmd.Args[:8]).To(Equal([]string{"--mount", "--propagation", "private", "--", "bash", "-e", "-c", confinedCmd.Args[7]}))
+			Expect(confinedCmd.Args[8:]).To(Equal([]string{"bosh-sandbox", "bash", "-x", "packaging"}))
+			Expect(confinedCmd.WorkingDir).To(Equal("/fake-compile-dir/pkg_name"))
+			Expect(confinedCmd.Env).To(Equal(map[string]string{
+				"BOSH_COMPILE_TARGET": "/fake-compile-dir/pkg_name",
+				"TMPDIR":              "/tmp",
+				"HOME":                "/tmp",
+			}))
+
+			Expect(confinedCmd.Args[7]).To(Equal(`echo $$ > '/fake-cgroup/bosh-agent-sandboxes/pkg_name/cgroup.procs'
+mount -t tmpfs -o mode=1777,nosuid,nodev tmpfs /tmp
+mount --rbind '/' '/'
+mount -o remount,bind,ro '/'
+mount --rbind '/var/vcap/data' '/var/vcap/data'
+mount -o remount,bind,ro '/var/vcap/data'
+mount --bind '/fake-compile-dir/pkg_name' '/fake-compile-dir/pkg_name'
+mount -o remount,bind,rw '/fake-compile-dir/pkg_name'
+mount --bind '/fake-install-dir' '/fake-install-dir'
+mount -o remount,bind,rw '/fake-install-dir'
+exec "$@"`))
+		})
+
+		It("does not modify original command", func() {
+			_, err := sandbox.Confine("pkg_name", cmd, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmd.Name).To(Equal("bash"))
+			Expect(cmd.Env).To(HaveLen(1))
+		})
+
+		It("replaces cgroup left behind by a previous run", func() {
+			Expect(fs.WriteFileString("/fake-cgroup/bosh-agent-sandboxes/pkg_name/cgroup.events", "populated 0\n")).To(Succeed())
+
+			_, err := sandbox.Confine("pkg_name", cmd, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/fake-cgroup/bosh-agent-sandboxes/pkg_name/cgroup.kill")).To(Equal("1"))
+			Expect(runner.RunCommands).To(Equal([][]string{{"rmdir", "/fake-cgroup/bosh-agent-sandboxes/pkg_name"}}))
+			Expect(fs.FileExists("/fake-cgroup/bosh-agent-sandboxes/pkg_name/cpu.max")).To(BeTrue())
+		})
+
+		It("returns error when cgroup v2 is not available", func() {
+			Expect(fs.RemoveAll("/fake-cgroup/cgroup.controllers")).To(Succeed())
+
+			_, err := sandbox.Confine("pkg_name", cmd, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Cgroup v2 is not mounted at /fake-cgroup"))
+		})
+
+		It("returns error when setting limits fails", func() {
+			fs.WriteFileErrors["/fake-cgroup/bosh-agent-sandboxes/pkg_name/memory.max"] = errors.New("fake-write-err")
+
+			_, err := sandbox.Confine("pkg_name", cmd, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Setting memory.max of sandbox pkg_name"))
+			Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+
+			Expect(runner.RunCommands).To(Equal([][]string{{"rmdir", "/fake-cgroup/bosh-agent-sandboxes/pkg_name"}}))
+		})
+	})
+
+	Describe("Release", func() {
+		const cgroupPath = "/fake-cgroup/bosh-agent-sandboxes/pkg_name"
+
+		BeforeEach(func() {
+			Expect(fs.WriteFileString(cgroupPath+"/cgroup.events", "populated 0\nfrozen 0\n")).To(Succeed())
+		})
+
+		It("kills remaining processes and removes cgroup directory", func() {
+			Expect(sandbox.Release("pkg_name")).To(Succeed())
+
+			Expect(fs.ReadFileString(cgroupPath + "/cgroup.kill")).To(Equal("1"))
+			Expect(runner.RunCommands).To(Equal([][]string{{"rmdir", cgroupPath}}))
+		})
+
+		It("kills processes one by one when cgroup.kill is not supported", func() {
+			fs.WriteFileErrors[cgroupPath+"/cgroup.kill"] = errors.New("fake-write-err")
+			Expect(fs.WriteFileString(cgroupPath+"/cgroup.procs", "123\n456\n")).To(Succeed())
+
+			Expect(sandbox.Release("pkg_name")).To(Succeed())
+
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"kill", "-9", "123", "456"},
+				{"rmdir", cgroupPath},
+			}))
+		})
+
+		It("waits for killed processes to exit before removing cgroup", func() {
+			eventsFs := &eventsFileSystem{FakeFileSystem: fs, populatedReads: 2}
+			sandbox = NewCgroupSandbox(eventsFs, runner, "/fake-cgroup", nil, limits, boshlog.NewLogger(boshlog.LevelNone))
+
+			Expect(sandbox.Release("pkg_name")).To(Succeed())
+
+			Expect(eventsFs.populatedReads).To(Equal(0))
+			Expect(runner.RunCommands).To(Equal([][]string{{"rmdir", cgroupPath}}))
+		})
+
+		It("does nothing when cgroup does not exist", func() {
+			Expect(fs.RemoveAll(cgroupPath)).To(Succeed())
+
+			Expect(sandbox.Release("pkg_name")).To(Succeed())
+			Expect(fs.FileExists(cgroupPath + "/cgroup.kill")).To(BeFalse())
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns error when removing cgroup fails", func() {
+			runner.AddCmdResult("rmdir "+cgroupPath, fakesys.FakeCmdResult{Error: errors.New("fake-rmdir-err")})
+
+			err := sandbox.Release("pkg_name")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-rmdir-err"))
+		})
+	})
+})
+
+var _ = Describe("unconfinedSandbox", func() {
+	It("runs commands as they are", func() {
+		cmd := boshsys.Command{Name: "bash", Args: []string{"-x", "packaging"}}
+
+		sandbox := NewUnconfinedSandbox()
+
+		confinedCmd, err := sandbox.Confine("pkg_name", cmd, []string{"/fake-dir"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(confinedCmd).To(Equal(cmd))
+		Expect(sandbox.Release("pkg_name")).To(Succeed())
+	})
+})
@@ -0,0 +1,25 @@
+package sandbox
+
+import (
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Sandbox
+
+type Sandbox interface {
+	// Confine returns cmd adjusted to run in a sandbox identified by id
+	// in which only writablePaths and a private /tmp can be written.
+	Confine(id string, cmd boshsys.Command, writablePaths []string) (boshsys.Command, error)
+
+	// Release cleans up sandbox once confined command exited
+	Release(id string) error
+}
+
+type Limits struct {
+	// Fractional number of CPUs, e.g. 1.5. Unlimited when zero.
+	CPUs float64
+
+	// Unlimited when zero
+	MemoryBytes uint64
+	MaxPids     uint64
+}
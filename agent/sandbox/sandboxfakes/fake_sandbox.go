@@ -0,0 +1,200 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package sandboxfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/agent/sandbox"
+	"github.com/cloudfoundry/bosh-utils/system"
+)
+
+type FakeSandbox struct {
+	ConfineStub        func(string, system.Command, []string) (system.Command, error)
+	confineMutex       sync.RWMutex
+	confineArgsForCall []struct {
+		arg1 string
+		arg2 system.Command
+		arg3 []string
+	}
+	confineReturns struct {
+		result1 system.Command
+		result2 error
+	}
+	confineReturnsOnCall map[int]struct {
+		result1 system.Command
+		result2 error
+	}
+	ReleaseStub        func(string) error
+	releaseMutex       sync.RWMutex
+	releaseArgsForCall []struct {
+		arg1 string
+	}
+	releaseReturns struct {
+		result1 error
+	}
+	releaseReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSandbox) Confine(arg1 string, arg2 system.Command, arg3 []string) (system.Command, error) {
+	var arg3Copy []string
+	if arg3 != nil {
+		arg3Copy = make([]string, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.confineMutex.Lock()
+	ret, specificReturn := fake.confineReturnsOnCall[len(fake.confineArgsForCall)]
+	fake.confineArgsForCall = append(fake.confineArgsForCall, struct {
+		arg1 string
+		arg2 system.Command
+		arg3 []string
+	}{arg1, arg2, arg3Copy})
+	stub := fake.ConfineStub
+	fakeReturns := fake.confineReturns
+	fake.recordInvocation("Confine", []interface{}{arg1, arg2, arg3Copy})
+	fake.confineMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSandbox) ConfineCallCount() int {
+	fake.confineMutex.RLock()
+	defer fake.confineMutex.RUnlock()
+	return len(fake.confineArgsForCall)
+}
+
+func (fake *FakeSandbox) ConfineCalls(stub func(string, system.Command, []string) (system.Command, error)) {
+	fake.confineMutex.Lock()
+	defer fake.confineMutex.Unlock()
+	fake.ConfineStub = stub
+}
+
+func (fake *FakeSandbox) ConfineArgsForCall(i int) (string, system.Command, []string) {
+	fake.confineMutex.RLock()
+	defer fake.confineMutex.RUnlock()
+	argsForCall := fake.confineArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeSandbox) ConfineReturns(result1 system.Command, result2 error) {
+	fake.confineMutex.Lock()
+	defer fake.confineMutex.Unlock()
+	fake.ConfineStub = nil
+	fake.confineReturns = struct {
+		result1 system.Command
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSandbox) ConfineReturnsOnCall(i int, result1 system.Command, result2 error) {
+	fake.confineMutex.Lock()
+	defer fake.confineMutex.Unlock()
+	fake.ConfineStub = nil
+	if fake.confineReturnsOnCall == nil {
+		fake.confineReturnsOnCall = make(map[int]struct {
+			result1 system.Command
+			result2 error
+		})
+	}
+	fake.confineReturnsOnCall[i] = struct {
+		result1 system.Command
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSandbox) Release(arg1 string) error {
+	fake.releaseMutex.Lock()
+	ret, specificReturn := fake.releaseReturnsOnCall[len(fake.releaseArgsForCall)]
+	fake.releaseArgsForCall = append(fake.releaseArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReleaseStub
+	fakeReturns := fake.releaseReturns
+	fake.recordInvocation("Release", []interface{}{arg1})
+	fake.releaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSandbox) ReleaseCallCount() int {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return len(fake.releaseArgsForCall)
+}
+
+func (fake *FakeSandbox) ReleaseCalls(stub func(string) error) {
+	fake.releaseMutex.Lock()
+	defer fake.releaseMutex.Unlock()
+	fake.ReleaseStub = stub
+}
+
+func (fake *FakeSandbox) ReleaseArgsForCall(i int) string {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	argsForCall := fake.releaseArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSandbox) ReleaseReturns(result1 error) {
+	fake.releaseMutex.Lock()
+	defer fake.releaseMutex.Unlock()
+	fake.ReleaseStub = nil
+	fake.releaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSandbox) ReleaseReturnsOnCall(i int, result1 error) {
+	fake.releaseMutex.Lock()
+	defer fake.releaseMutex.Unlock()
+	fake.ReleaseStub = nil
+	if fake.releaseReturnsOnCall == nil {
+		fake.releaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.releaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSandbox) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.confineMutex.RLock()
+	defer fake.confineMutex.RUnlock()
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeSandbox) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ sandbox.Sandbox = new(FakeSandbox)
@@ -57,6 +57,7 @@ func (service asyncTaskService) CreateTaskWithID(
 		Func:       taskFunc,
 		CancelFunc: cancelFunc,
 		EndFunc:    endFunc,
+		Progress:   NewProgressTracker(),
 	}
 }
 
@@ -104,7 +105,8 @@ func (service asyncTaskService) processTasks() {
 		if err != nil {
 			task.Error = err
 			task.State = StateFailed
-			service.logger.Error("Task Service", "Failed processing task #%s got: %s", task.ID, err.Error())
+
+			task.Logger(service.logger).Error("Task Service", "Failed processing task #%s got: %s", task.ID, err.Error())
 		} else {
 			task.Value = value
 			task.State = StateDone
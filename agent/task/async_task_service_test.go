@@ -7,8 +7,10 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/task"
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 )
@@ -88,6 +90,32 @@ func init() { //nolint:funlen,gochecknoinits
 				Expect(task.EndFunc).To(BeNil())
 			})
 
+			It("correlates failures of a task with its id", func() {
+				outBuf := gbytes.NewBuffer()
+				jsonLogger := agentlogger.NewJSONLogger(boshlog.LevelDebug, outBuf)
+				service = NewAsyncTaskService(uuidGen, jsonLogger)
+
+				runFunc := func() (interface{}, error) { return nil, errors.New("fake-error") }
+
+				task := service.CreateTaskWithID("fake-task-id", runFunc, nil, nil)
+				startAndWaitForTaskCompletion(task)
+
+				Expect(outBuf).To(gbytes.Say(`"message":"Failed processing task #fake-task-id got: fake-error","tag":"Task Service","task_id":"fake-task-id"`))
+			})
+
+			It("correlates failures of a task with request which started it", func() {
+				outBuf := gbytes.NewBuffer()
+				jsonLogger := agentlogger.NewJSONLogger(boshlog.LevelDebug, outBuf)
+				requestLogger := agentlogger.WithFields(jsonLogger, agentlogger.Fields{"request_id": "fake-request-id"})
+
+				runFunc := func() (interface{}, error) { return nil, errors.New("fake-error") }
+
+				task := service.CreateTaskWithID("fake-task-id", runFunc, nil, nil)
+				startAndWaitForTaskCompletion(task.WithLogger(requestLogger))
+
+				Expect(outBuf).To(gbytes.Say(`"message":"Failed processing task #fake-task-id got: fake-error","request_id":"fake-request-id","tag":"Task Service","task_id":"fake-task-id"`))
+			})
+
 			Describe("CreateTask", func() {
 				It("can run task created with CreateTask which does not have end func", func() {
 					ranFunc := false
@@ -0,0 +1,42 @@
+package fakes
+
+import boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+
+type FakeJournal struct {
+	StartedTaskIDs []string
+	StartedMethods []string
+	StartedPayload []byte
+	StartLoggable  bool
+	RecordStartErr error
+
+	EndedTasks   []boshtask.Task
+	EndLoggable  bool
+	RecordEndErr error
+
+	ListFilter  boshtask.JournalFilter
+	ListEntries []boshtask.JournalEntry
+	ListErr     error
+}
+
+func NewFakeJournal() *FakeJournal {
+	return &FakeJournal{}
+}
+
+func (j *FakeJournal) RecordStart(taskID, method string, payload []byte, loggable bool) error {
+	j.StartedTaskIDs = append(j.StartedTaskIDs, taskID)
+	j.StartedMethods = append(j.StartedMethods, method)
+	j.StartedPayload = payload
+	j.StartLoggable = loggable
+	return j.RecordStartErr
+}
+
+func (j *FakeJournal) RecordEnd(task boshtask.Task, loggable bool) error {
+	j.EndedTasks = append(j.EndedTasks, task)
+	j.EndLoggable = loggable
+	return j.RecordEndErr
+}
+
+func (j *FakeJournal) List(filter boshtask.JournalFilter) ([]boshtask.JournalEntry, error) {
+	j.ListFilter = filter
+	return j.ListEntries, j.ListErr
+}
@@ -0,0 +1,397 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	journalLogTag = "Task Journal"
+
+	// Results larger than this are not kept in the journal
+	maxJournalResultSize = 1024
+
+	// Arguments larger than this are replaced with truncatedValue
+	maxJournalArgumentsSize = 4096
+
+	redactedValue  = "<redacted>"
+	truncatedValue = "<truncated>"
+)
+
+var sensitiveArgumentKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|private_key|credential|cert|signed_url|blobstore_headers|key$)`)
+
+type JournalEntry struct {
+	TaskID    string          `json:"task_id"`
+	Method    string          `json:"method"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	State     State           `json:"state"`
+	StartedAt time.Time       `json:"started_at"`
+	EndedAt   *time.Time      `json:"ended_at,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+type JournalFilter struct {
+	Method string    `json:"method"`
+	State  State     `json:"state"`
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until"`
+}
+
+func (f JournalFilter) Matches(entry JournalEntry) bool {
+	if f.Method != "" && f.Method != entry.Method {
+		return false
+	}
+	if f.State != "" && f.State != entry.State {
+		return false
+	}
+	if !f.Since.IsZero() && entry.StartedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.StartedAt.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+type Journal interface {
+	// RecordStart records a dispatched task. Arguments of non-loggable
+	// actions are never written; sensitive fields of others are redacted.
+	RecordStart(taskID, method string, payload []byte, loggable bool) error
+	RecordEnd(task Task, loggable bool) error
+
+	// List returns matching entries, most recently started first
+	List(filter JournalFilter) ([]JournalEntry, error)
+}
+
+type concreteJournal struct {
+	fs          boshsys.FileSystem
+	path        string
+	rotatedPath string
+	maxEntries  int
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	// Current generation only; previous one lives in rotatedPath
+	entries []JournalEntry
+	lock    sync.Mutex
+}
+
+// NewJournal keeps up to maxEntries entries in path before rotating
+// them to path.1, so at most 2*maxEntries entries are kept on disk.
+// Each start and end of a task is appended to path as a separate line;
+// path is compacted when journal is loaded. Journal that cannot be
+// parsed is moved to path.corrupt and a new one is started.
+func NewJournal(
+	fs boshsys.FileSystem,
+	path string,
+	maxEntries int,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) (Journal, error) {
+	j := &concreteJournal{
+		fs:          fs,
+		path:        path,
+		rotatedPath: path + ".1",
+		maxEntries:  maxEntries,
+		timeService: timeService,
+		logger:      logger,
+	}
+
+	entries, compact, err := j.readEntries(j.path)
+	if err != nil {
+		j.logger.Error(journalLogTag, "Starting new task journal: %s", err.Error())
+
+		err = j.fs.Rename(j.path, j.path+".corrupt")
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Moving corrupt task journal")
+		}
+
+		entries, compact = nil, false
+	}
+
+	// Tasks that were running when agent stopped either get resumed
+	// (and recorded again when they finish) or are lost.
+	for i, entry := range entries {
+		if entry.State == StateRunning {
+			entries[i].State = StateFailed
+			entries[i].Error = "Agent restarted before task finished"
+			compact = true
+		}
+	}
+
+	j.entries = entries
+
+	if compact {
+		if err := j.writeEntries(); err != nil {
+			return nil, err
+		}
+	}
+
+	return j, nil
+}
+
+func (j *concreteJournal) RecordStart(taskID, method string, payload []byte, loggable bool) error {
+	entry := JournalEntry{
+		TaskID:    taskID,
+		Method:    method,
+		State:     StateRunning,
+		StartedAt: j.timeService.Now().UTC(),
+	}
+
+	if loggable {
+		entry.Arguments = RedactArguments(payload)
+
+		if len(entry.Arguments) > maxJournalArgumentsSize {
+			entry.Arguments = json.RawMessage(`"` + truncatedValue + `"`)
+		}
+	} else {
+		entry.Arguments = json.RawMessage(`"` + redactedValue + `"`)
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if len(j.entries) >= j.maxEntries {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+
+	j.entries = append(j.entries, entry)
+
+	return j.appendEntry(entry)
+}
+
+func (j *concreteJournal) RecordEnd(task Task, loggable bool) error {
+	endedAt := j.timeService.Now().UTC()
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	for i := range j.entries {
+		if j.entries[i].TaskID != task.ID {
+			continue
+		}
+
+		j.entries[i].State = task.State
+		j.entries[i].EndedAt = &endedAt
+		j.entries[i].Error = ""
+		j.entries[i].Result = nil
+
+		if task.Error != nil {
+			j.entries[i].Error = task.Error.Error()
+		} else if loggable {
+			j.entries[i].Result = j.marshalResult(task.Value)
+		}
+
+		return j.appendEntry(j.entries[i])
+	}
+
+	// Entry may have been rotated away by a burst of newer tasks
+	j.logger.Debug(journalLogTag, "Task %s not found in journal", task.ID)
+
+	return nil
+}
+
+func (j *concreteJournal) List(filter JournalFilter) ([]JournalEntry, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	// Previous generation is only informational so it should not
+	// prevent listing current one
+	rotated, _, err := j.readEntries(j.rotatedPath)
+	if err != nil {
+		j.logger.Warn(journalLogTag, "Skipping rotated task journal: %s", err.Error())
+	}
+
+	matching := []JournalEntry{}
+	for _, entry := range append(rotated, j.entries...) {
+		if filter.Matches(entry) {
+			matching = append(matching, entry)
+		}
+	}
+
+	sort.SliceStable(matching, func(a, b int) bool {
+		return matching[a].StartedAt.After(matching[b].StartedAt)
+	})
+
+	return matching, nil
+}
+
+func (j *concreteJournal) marshalResult(value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+
+	resultJSON, err := json.Marshal(value)
+	if err != nil || len(resultJSON) > maxJournalResultSize {
+		return nil
+	}
+
+	return resultJSON
+}
+
+func (j *concreteJournal) rotate() error {
+	if j.fs.FileExists(j.path) {
+		err := j.fs.Rename(j.path, j.rotatedPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Rotating task journal")
+		}
+	}
+
+	j.entries = nil
+
+	return nil
+}
+
+// readEntries merges lines recorded for the same task keeping the last one.
+// Journals written as a single JSON array by previous agent versions and
+// journals ending with a partially written line are reported to need compaction.
+func (j *concreteJournal) readEntries(path string) ([]JournalEntry, bool, error) {
+	var entries []JournalEntry
+
+	if !j.fs.FileExists(path) {
+		return entries, false, nil
+	}
+
+	entriesJSON, err := j.fs.ReadFile(path)
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Reading task journal")
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(entriesJSON), []byte("[")) {
+		err = json.Unmarshal(entriesJSON, &entries)
+		if err != nil {
+			return nil, false, bosherr.WrapError(err, "Unmarshalling task journal")
+		}
+
+		return entries, true, nil
+	}
+
+	lines := bytes.Split(entriesJSON, []byte("\n"))
+	compact := false
+
+	// Last line is either empty or was not completely written
+	if last := lines[len(lines)-1]; len(last) > 0 {
+		j.logger.Warn(journalLogTag, "Ignoring partially written task journal entry in %s", path)
+		compact = true
+	}
+
+	indexes := map[string]int{}
+
+	for _, line := range lines[:len(lines)-1] {
+		var entry JournalEntry
+
+		err = json.Unmarshal(line, &entry)
+		if err != nil {
+			return nil, false, bosherr.WrapError(err, "Unmarshalling task journal")
+		}
+
+		if i, found := indexes[entry.TaskID]; found {
+			entries[i] = entry
+			continue
+		}
+
+		indexes[entry.TaskID] = len(entries)
+		entries = append(entries, entry)
+	}
+
+	return entries, compact, nil
+}
+
+func (j *concreteJournal) appendEntry(entry JournalEntry) error {
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling task journal entry")
+	}
+
+	file, err := j.fs.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.FileMode(0640))
+	if err != nil {
+		return bosherr.WrapError(err, "Opening task journal")
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(append(entryJSON, '\n'))
+	if err != nil {
+		return bosherr.WrapError(err, "Writing task journal")
+	}
+
+	return nil
+}
+
+// writeEntries replaces journal with one line per entry
+func (j *concreteJournal) writeEntries() error {
+	var buf bytes.Buffer
+
+	for _, entry := range j.entries {
+		entryJSON, err := json.Marshal(entry)
+		if err != nil {
+			return bosherr.WrapError(err, "Marshalling task journal")
+		}
+
+		buf.Write(entryJSON)
+		buf.WriteByte('\n')
+	}
+
+	err := j.fs.WriteFile(j.path, buf.Bytes())
+	if err != nil {
+		return bosherr.WrapError(err, "Writing task journal")
+	}
+
+	return nil
+}
+
+// RedactArguments extracts arguments from a request payload
+// replacing values of sensitive looking keys.
+func RedactArguments(payload []byte) json.RawMessage {
+	var parsed struct {
+		Arguments []interface{} `json:"arguments"`
+	}
+
+	err := json.Unmarshal(payload, &parsed)
+	if err != nil {
+		return json.RawMessage(`"` + redactedValue + `"`)
+	}
+
+	redacted, err := json.Marshal(redactValue(parsed.Arguments))
+	if err != nil {
+		return json.RawMessage(`"` + redactedValue + `"`)
+	}
+
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for k, v := range typedValue {
+			if sensitiveArgumentKey.MatchString(k) {
+				typedValue[k] = redactedValue
+			} else {
+				typedValue[k] = redactValue(v)
+			}
+		}
+		return typedValue
+
+	case []interface{}:
+		for i, v := range typedValue {
+			typedValue[i] = redactValue(v)
+		}
+		return typedValue
+
+	default:
+		return value
+	}
+}
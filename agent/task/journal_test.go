@@ -0,0 +1,295 @@
+package task_test
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("Journal", func() {
+	var (
+		fs          boshsys.FileSystem
+		dir         string
+		journalPath string
+		timeService *fakeclock.FakeClock
+		logger      boshlog.Logger
+		startTime   time.Time
+		journal     boshtask.Journal
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		var err error
+		dir, err = fs.TempDir("task-journal")
+		Expect(err).ToNot(HaveOccurred())
+		journalPath = filepath.Join(dir, "task_journal.json")
+
+		startTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		timeService = fakeclock.NewFakeClock(startTime)
+	})
+
+	AfterEach(func() {
+		Expect(fs.RemoveAll(dir)).To(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		journal, err = boshtask.NewJournal(fs, journalPath, 2, timeService, logger)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("records task start and end", func() {
+		err := journal.RecordStart("fake-task-id", "fake-method", []byte(`{"arguments":["fake-arg"]}`), true)
+		Expect(err).ToNot(HaveOccurred())
+
+		timeService.Increment(time.Minute)
+
+		err = journal.RecordEnd(boshtask.Task{ID: "fake-task-id", State: boshtask.StateDone, Value: "fake-value"}, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		entries, err := journal.List(boshtask.JournalFilter{})
+		Expect(err).ToNot(HaveOccurred())
+
+		endedAt := startTime.Add(time.Minute)
+		Expect(entries).To(Equal([]boshtask.JournalEntry{
+			{
+				TaskID:    "fake-task-id",
+				Method:    "fake-method",
+				Arguments: json.RawMessage(`["fake-arg"]`),
+				State:     boshtask.StateDone,
+				StartedAt: startTime,
+				EndedAt:   &endedAt,
+				Result:    json.RawMessage(`"fake-value"`),
+			},
+		}))
+	})
+
+	It("records task errors", func() {
+		err := journal.RecordStart("fake-task-id", "fake-method", []byte(`{"arguments":[]}`), true)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = journal.RecordEnd(boshtask.Task{ID: "fake-task-id", State: boshtask.StateFailed, Error: errors.New("fake-task-err")}, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		entries, err := journal.List(boshtask.JournalFilter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries[0].State).To(Equal(boshtask.StateFailed))
+		Expect(entries[0].Error).To(Equal("fake-task-err"))
+		Expect(entries[0].Result).To(BeNil())
+	})
+
+	It("redacts sensitive arguments", func() {
+		payload := []byte(`{"arguments":[{"user":"fake-user","password":"fake-password","nested":{"private_key":"fake-key"}}]}`)
+		err := journal.RecordStart("fake-task-id", "fake-method", payload, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		entries, err := journal.List(boshtask.JournalFilter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(entries[0].Arguments)).To(MatchJSON(`[{"user":"fake-user","password":"<redacted>","nested":{"private_key":"<redacted>"}}]`))
+	})
+
+	It("redacts signed urls and blobstore headers", func() {
+		payload := []byte(`{"arguments":["job",[],"fake-blob-id",{"signed_url":"https://fake-url?sig=fake-sig","blobstore_headers":{"fake-header":"fake-value"}}]}`)
+		err := journal.RecordStart("fake-task-id", "fetch_logs_with_signed_url", payload, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		entries, err := journal.List(boshtask.JournalFilter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(entries[0].Arguments)).To(MatchJSON(`["job",[],"fake-blob-id",{"signed_url":"<redacted>","blobstore_headers":"<redacted>"}]`))
+
+		contents, err := fs.ReadFileString(journalPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).ToNot(ContainSubstring("fake-sig"))
+		Expect(contents).ToNot(ContainSubstring("fake-header"))
+	})
+
+	It("truncates large arguments", func() {
+		payload := []byte(`{"arguments":["` + strings.Repeat("a", 5000) + `"]}`)
+		err := journal.RecordStart("fake-task-id", "fake-method", payload, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		entries, err := journal.List(boshtask.JournalFilter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(entries[0].Arguments)).To(Equal(`"<truncated>"`))
+	})
+
+	It("appends a line for every task start and end", func() {
+		Expect(journal.RecordStart("fake-task-1", "fake-method", nil, true)).To(Succeed())
+		Expect(journal.RecordStart("fake-task-2", "fake-method", nil, true)).To(Succeed())
+		Expect(journal.RecordEnd(boshtask.Task{ID: "fake-task-1", State: boshtask.StateDone}, true)).To(Succeed())
+
+		contents, err := fs.ReadFileString(journalPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		lines := strings.Split(strings.TrimSuffix(contents, "\n"), "\n")
+		Expect(lines).To(HaveLen(3))
+		Expect(lines[0]).To(ContainSubstring(`"task_id":"fake-task-1"`))
+		Expect(lines[0]).To(ContainSubstring(`"state":"running"`))
+		Expect(lines[2]).To(ContainSubstring(`"task_id":"fake-task-1"`))
+		Expect(lines[2]).To(ContainSubstring(`"state":"done"`))
+	})
+
+	It("does not record arguments or results of non-loggable actions", func() {
+		err := journal.RecordStart("fake-task-id", "fake-method", []byte(`{"arguments":["fake-secret"]}`), false)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = journal.RecordEnd(boshtask.Task{ID: "fake-task-id", State: boshtask.StateDone, Value: "fake-secret"}, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		entries, err := journal.List(boshtask.JournalFilter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(entries[0].Arguments)).To(Equal(`"<redacted>"`))
+		Expect(entries[0].Result).To(BeNil())
+	})
+
+	It("rotates entries once journal is full and lists both generations newest first", func() {
+		for _, id := range []string{"fake-task-1", "fake-task-2", "fake-task-3", "fake-task-4", "fake-task-5"} {
+			timeService.Increment(time.Second)
+			err := journal.RecordStart(id, "fake-method", []byte(`{"arguments":[]}`), true)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(fs.FileExists(journalPath + ".1")).To(BeTrue())
+
+		entries, err := journal.List(boshtask.JournalFilter{})
+		Expect(err).ToNot(HaveOccurred())
+
+		ids := []string{}
+		for _, entry := range entries {
+			ids = append(ids, entry.TaskID)
+		}
+		Expect(ids).To(Equal([]string{"fake-task-5", "fake-task-4", "fake-task-3"}))
+	})
+
+	It("filters entries by method, state and time window", func() {
+		Expect(journal.RecordStart("fake-task-1", "fake-method-1", nil, true)).To(Succeed())
+		timeService.Increment(time.Hour)
+		Expect(journal.RecordStart("fake-task-2", "fake-method-2", nil, true)).To(Succeed())
+		Expect(journal.RecordEnd(boshtask.Task{ID: "fake-task-2", State: boshtask.StateFailed, Error: errors.New("fake-err")}, true)).To(Succeed())
+
+		entries, err := journal.List(boshtask.JournalFilter{Method: "fake-method-1"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].TaskID).To(Equal("fake-task-1"))
+
+		entries, err = journal.List(boshtask.JournalFilter{State: boshtask.StateFailed})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].TaskID).To(Equal("fake-task-2"))
+
+		entries, err = journal.List(boshtask.JournalFilter{Since: startTime.Add(time.Minute)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].TaskID).To(Equal("fake-task-2"))
+
+		entries, err = journal.List(boshtask.JournalFilter{Until: startTime.Add(time.Minute)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].TaskID).To(Equal("fake-task-1"))
+	})
+
+	Context("when journal contains tasks that were running when agent stopped", func() {
+		BeforeEach(func() {
+			err := fs.WriteFileString(journalPath, `{"task_id":"fake-task-id","method":"fake-method","state":"running"}`+"\n")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("marks them as failed", func() {
+			entries, err := journal.List(boshtask.JournalFilter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries[0].State).To(Equal(boshtask.StateFailed))
+			Expect(entries[0].Error).To(Equal("Agent restarted before task finished"))
+
+			contents, err := fs.ReadFileString(journalPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(ContainSubstring(`"state":"failed"`))
+		})
+	})
+
+	Context("when journal was written as a single list by previous agent", func() {
+		BeforeEach(func() {
+			err := fs.WriteFileString(journalPath, `[{"task_id":"fake-task-id","method":"fake-method","state":"done"}]`)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("loads its entries and keeps appending new ones", func() {
+			Expect(journal.RecordStart("fake-task-2", "fake-method", nil, true)).To(Succeed())
+
+			entries, err := journal.List(boshtask.JournalFilter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+
+			contents, err := fs.ReadFileString(journalPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(strings.Count(contents, "\n")).To(Equal(2))
+		})
+	})
+
+	Context("when last journal entry was only partially written", func() {
+		BeforeEach(func() {
+			err := fs.WriteFileString(journalPath, `{"task_id":"fake-task-1","method":"fake-method","state":"done"}`+"\n"+`{"task_id":"fake-ta`)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("ignores partially written entry", func() {
+			Expect(journal.RecordStart("fake-task-2", "fake-method", nil, true)).To(Succeed())
+
+			entries, err := journal.List(boshtask.JournalFilter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].TaskID).To(Equal("fake-task-2"))
+			Expect(entries[1].TaskID).To(Equal("fake-task-1"))
+		})
+	})
+
+	Context("when journal cannot be parsed", func() {
+		BeforeEach(func() {
+			err := fs.WriteFileString(journalPath, "fake-invalid-json\n")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("moves it aside and starts a new journal", func() {
+			entries, err := journal.List(boshtask.JournalFilter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+
+			contents, err := fs.ReadFileString(journalPath + ".corrupt")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("fake-invalid-json\n"))
+			Expect(fs.FileExists(journalPath)).To(BeFalse())
+
+			Expect(journal.RecordStart("fake-task-id", "fake-method", nil, true)).To(Succeed())
+
+			entries, err = journal.List(boshtask.JournalFilter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+	})
+
+	Context("when rotated journal cannot be parsed", func() {
+		BeforeEach(func() {
+			err := fs.WriteFileString(journalPath+".1", "fake-invalid-json\n")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("lists entries of current journal", func() {
+			Expect(journal.RecordStart("fake-task-id", "fake-method", nil, true)).To(Succeed())
+
+			entries, err := journal.List(boshtask.JournalFilter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,135 @@
+package task
+
+import (
+	"bytes"
+	"sync"
+)
+
+const progressLogTailSize = 20
+
+// Progress is a point-in-time view of what a running task is doing.
+type Progress struct {
+	Stage    string    `json:"stage,omitempty"`
+	Percent  int       `json:"percent"`
+	Transfer *Transfer `json:"transfer,omitempty"`
+	LogTail  []string  `json:"log_tail,omitempty"`
+}
+
+// Transfer counts files and bytes processed by tasks which copy data.
+type Transfer struct {
+	DoneFiles  uint64 `json:"done_files"`
+	TotalFiles uint64 `json:"total_files"`
+	DoneBytes  uint64 `json:"done_bytes"`
+	TotalBytes uint64 `json:"total_bytes"`
+}
+
+// ProgressReporter is handed to actions so that they can publish
+// progress while their task is running.
+type ProgressReporter interface {
+	SetStage(stage string, percent int)
+	SetTransfer(transfer Transfer)
+	AppendLog(line string)
+}
+
+// ProgressTracker keeps the latest progress of a single task.
+// It is safe to use from the task goroutine and get_task concurrently.
+type ProgressTracker struct {
+	progress Progress
+	lock     sync.RWMutex
+}
+
+func NewProgressTracker() *ProgressTracker {
+	return &ProgressTracker{}
+}
+
+func (t *ProgressTracker) SetStage(stage string, percent int) {
+	if t == nil {
+		return
+	}
+
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.progress.Stage = stage
+	t.progress.Percent = percent
+}
+
+func (t *ProgressTracker) SetTransfer(transfer Transfer) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.progress.Transfer = &transfer
+}
+
+func (t *ProgressTracker) AppendLog(line string) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.progress.LogTail = append(t.progress.LogTail, line)
+	if len(t.progress.LogTail) > progressLogTailSize {
+		t.progress.LogTail = t.progress.LogTail[len(t.progress.LogTail)-progressLogTailSize:]
+	}
+}
+
+func (t *ProgressTracker) Snapshot() Progress {
+	if t == nil {
+		return Progress{}
+	}
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	snapshot := t.progress
+	snapshot.LogTail = append([]string(nil), t.progress.LogTail...)
+	if t.progress.Transfer != nil {
+		transfer := *t.progress.Transfer
+		snapshot.Transfer = &transfer
+	}
+
+	return snapshot
+}
+
+// ProgressLogWriter splits written output into lines and appends
+// each complete line to the reporter's log tail.
+type ProgressLogWriter struct {
+	reporter ProgressReporter
+	partial  []byte
+	lock     sync.Mutex
+}
+
+func NewProgressLogWriter(reporter ProgressReporter) *ProgressLogWriter {
+	return &ProgressLogWriter{reporter: reporter}
+}
+
+func (w *ProgressLogWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.partial = append(w.partial, p...)
+
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.reporter.AppendLog(string(bytes.TrimRight(w.partial[:i], "\r")))
+		w.partial = w.partial[i+1:]
+	}
+
+	return len(p), nil
+}
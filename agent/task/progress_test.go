@@ -0,0 +1,78 @@
+package task_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/task"
+)
+
+var _ = Describe("ProgressTracker", func() {
+	var (
+		tracker *ProgressTracker
+	)
+
+	BeforeEach(func() {
+		tracker = NewProgressTracker()
+	})
+
+	It("records latest stage and clamps percent", func() {
+		tracker.SetStage("fake-stage-1", 10)
+		tracker.SetStage("fake-stage-2", 150)
+
+		Expect(tracker.Snapshot()).To(Equal(Progress{Stage: "fake-stage-2", Percent: 100}))
+
+		tracker.SetStage("fake-stage-3", -5)
+		Expect(tracker.Snapshot().Percent).To(Equal(0))
+	})
+
+	It("keeps only the last lines of the log tail", func() {
+		for i := 0; i < 25; i++ {
+			tracker.AppendLog(string(rune('a' + i)))
+		}
+
+		logTail := tracker.Snapshot().LogTail
+		Expect(logTail).To(HaveLen(20))
+		Expect(logTail[0]).To(Equal("f"))
+		Expect(logTail[19]).To(Equal("y"))
+	})
+
+	It("returns snapshots that are not affected by later updates", func() {
+		tracker.AppendLog("fake-line-1")
+		snapshot := tracker.Snapshot()
+
+		tracker.AppendLog("fake-line-2")
+		Expect(snapshot.LogTail).To(Equal([]string{"fake-line-1"}))
+	})
+
+	It("records transfer counters in snapshots that are not affected by later updates", func() {
+		tracker.SetTransfer(Transfer{DoneFiles: 1, TotalFiles: 2, DoneBytes: 3, TotalBytes: 4})
+		snapshot := tracker.Snapshot()
+
+		tracker.SetTransfer(Transfer{DoneFiles: 2, TotalFiles: 2, DoneBytes: 4, TotalBytes: 4})
+		Expect(snapshot.Transfer).To(Equal(&Transfer{DoneFiles: 1, TotalFiles: 2, DoneBytes: 3, TotalBytes: 4}))
+		Expect(tracker.Snapshot().Transfer).To(Equal(&Transfer{DoneFiles: 2, TotalFiles: 2, DoneBytes: 4, TotalBytes: 4}))
+	})
+
+	It("ignores updates on nil tracker", func() {
+		var nilTracker *ProgressTracker
+		nilTracker.SetStage("fake-stage", 10)
+		nilTracker.AppendLog("fake-line")
+		nilTracker.SetTransfer(Transfer{DoneFiles: 1})
+		Expect(nilTracker.Snapshot()).To(Equal(Progress{}))
+	})
+
+	Describe("ProgressLogWriter", func() {
+		It("appends complete lines to the log tail", func() {
+			writer := NewProgressLogWriter(tracker)
+
+			_, err := writer.Write([]byte("line-1\r\nline-"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tracker.Snapshot().LogTail).To(Equal([]string{"line-1"}))
+
+			_, err = writer.Write([]byte("2\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tracker.Snapshot().LogTail).To(Equal([]string{"line-1", "line-2"}))
+		})
+	})
+})
@@ -1,5 +1,10 @@
 package task
 
+import (
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
 type Func func() (value interface{}, err error)
 
 type CancelFunc func(task Task) error
@@ -20,9 +25,31 @@ type Task struct {
 	Value interface{}
 	Error error
 
+	// Progress is shared by all copies of the task so that the task
+	// function can publish updates while get_task reads them.
+	Progress *ProgressTracker
+
 	Func       Func
 	CancelFunc CancelFunc
 	EndFunc    EndFunc
+
+	logger boshlog.Logger
+}
+
+// WithLogger returns task carrying logger which attaches task ID, e.g. to
+// logger of request which started the task, so that task lines carry both.
+func (t Task) WithLogger(logger boshlog.Logger) Task {
+	t.logger = agentlogger.WithFields(logger, agentlogger.Fields{"task_id": t.ID})
+	return t
+}
+
+// Logger returns logger carried by task, or logger attaching task ID
+func (t Task) Logger(logger boshlog.Logger) boshlog.Logger {
+	if t.logger != nil {
+		return t.logger
+	}
+
+	return agentlogger.WithFields(logger, agentlogger.Fields{"task_id": t.ID})
 }
 
 func (t Task) Cancel() error {
@@ -33,6 +60,7 @@ func (t Task) Cancel() error {
 }
 
 type StateValue struct {
-	AgentTaskID string `json:"agent_task_id"`
-	State       State  `json:"state"`
+	AgentTaskID string    `json:"agent_task_id"`
+	State       State     `json:"state"`
+	Progress    *Progress `json:"progress,omitempty"`
 }
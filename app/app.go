@@ -9,8 +9,10 @@ import (
 
 	"os"
 
+	boshadmin "github.com/cloudfoundry/bosh-agent/admin"
 	boshagent "github.com/cloudfoundry/bosh-agent/agent"
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshapplier "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
@@ -22,12 +24,17 @@ import (
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	httpblobprovider "github.com/cloudfoundry/bosh-agent/agent/httpblobprovider"
 	"github.com/cloudfoundry/bosh-agent/agent/httpblobprovider/blobstore_delegator"
+	boshlogforwarder "github.com/cloudfoundry/bosh-agent/agent/logforwarder"
+	boshsandbox "github.com/cloudfoundry/bosh-agent/agent/sandbox"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
+	boshprobe "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
 	boshmbus "github.com/cloudfoundry/bosh-agent/mbus"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/metrics"
 	boshnotif "github.com/cloudfoundry/bosh-agent/notification"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -41,6 +48,9 @@ import (
 	sigar "github.com/cloudfoundry/gosigar"
 )
 
+// Bounds task journal to at most two generations of this many tasks
+const taskJournalMaxEntries = 500
+
 type App interface {
 	Setup(opts Options) error
 	Run() error
@@ -48,12 +58,16 @@ type App interface {
 }
 
 type app struct {
-	logger      boshlog.Logger
-	agent       boshagent.Agent
-	platform    boshplatform.Platform
-	fs          boshsys.FileSystem
-	logTag      string
-	dirProvider boshdirs.Provider
+	logger        boshlog.Logger
+	agent         boshagent.Agent
+	platform      boshplatform.Platform
+	fs            boshsys.FileSystem
+	logTag        string
+	dirProvider   boshdirs.Provider
+	metricsServer *boshmetrics.Server
+	adminServer   *boshadmin.Server
+	alertServer   *boshalert.Server
+	logForwarder  *boshlogforwarder.Forwarder
 }
 
 func New(logger boshlog.Logger, fs boshsys.FileSystem) App {
@@ -73,7 +87,7 @@ func (app *app) Setup(opts Options) error {
 	app.dirProvider = boshdirs.NewProvider(opts.BaseDirectory)
 	app.logStemcellInfo()
 
-	statsCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{})
+	statsCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{}, app.fs, boshsys.NewExecCmdRunner(app.logger))
 	auditLoggerProvider := boshplatform.NewAuditLoggerProvider()
 	auditLogger := boshplatform.NewDelayedAuditLogger(auditLoggerProvider, app.logger)
 
@@ -133,9 +147,19 @@ func (app *app) Setup(opts Options) error {
 		return bosherr.WrapError(err, "Getting blob manager")
 	}
 
+	// For reusing blobs downloaded from external blobstore
+	var blobCache boshagentblobstore.BlobCache
+	if config.BlobCache.Enabled() {
+		blobCache, err = boshagentblobstore.NewLRUBlobCache(app.dirProvider.BlobCacheDir(), config.BlobCache, app.logger)
+		if err != nil {
+			return bosherr.WrapError(err, "Getting blob cache")
+		}
+	}
+
 	blobstore, err := app.setupBlobstore(
 		settingsService.GetSettings().GetBlobstore(),
 		[]boshagentblobstore.BlobManagerInterface{sensitiveBlobManager, inconsiderateBlobManager},
+		blobCache,
 	)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting blobstore")
@@ -168,6 +192,13 @@ func (app *app) Setup(opts Options) error {
 		return bosherr.WrapError(err, "Getting job supervisor")
 	}
 
+	probeMonitor := boshprobe.NewMonitor(
+		boshprobe.NewChecker(app.platform.GetRunner()),
+		timeService,
+		app.logger,
+	)
+	jobSupervisor = boshjobsuper.NewProbingJobSupervisor(jobSupervisor, probeMonitor, app.dirProvider.JobsDir(), app.platform.GetFs(), app.logger)
+
 	notifier := boshnotif.NewNotifier(mbusHandler)
 
 	blobstoreHTTPClient, err := httpblobprovider.NewBlobstoreHTTPClient(settingsService.GetSettings().GetBlobstore())
@@ -198,6 +229,17 @@ func (app *app) Setup(opts Options) error {
 		app.dirProvider.BoshDir(),
 	)
 
+	taskJournal, err := boshtask.NewJournal(
+		app.platform.GetFs(),
+		filepath.Join(app.dirProvider.BoshDir(), "task_journal.json"),
+		taskJournalMaxEntries,
+		timeService,
+		app.logger,
+	)
+	if err != nil {
+		return bosherr.WrapError(err, "Loading task journal")
+	}
+
 	jobScriptProvider := boshscript.NewConcreteJobScriptProvider(
 		app.platform.GetRunner(),
 		app.platform.GetFs(),
@@ -210,11 +252,14 @@ func (app *app) Setup(opts Options) error {
 		settingsService,
 		app.platform,
 		sensitiveBlobManager,
+		blobCache,
 		taskService,
+		taskJournal,
 		notifier,
 		applier,
 		compiler,
 		jobSupervisor,
+		probeMonitor,
 		specService,
 		jobScriptProvider,
 		app.logger,
@@ -223,14 +268,69 @@ func (app *app) Setup(opts Options) error {
 
 	actionRunner := boshaction.NewRunner()
 
-	actionDispatcher := boshagent.NewActionDispatcher(
+	var actionDispatcher boshagent.ActionDispatcher = boshagent.NewActionDispatcher(
 		app.logger,
 		taskService,
 		taskManager,
+		taskJournal,
 		actionFactory,
 		actionRunner,
 	)
 
+	agentMbusHandler := mbusHandler
+
+	if config.Metrics.Enabled() {
+		metricsRegistry := boshmetrics.NewRegistry()
+		actionDispatcher = boshmetrics.NewActionDispatcher(actionDispatcher, metricsRegistry)
+		agentMbusHandler = boshmetrics.NewHandler(mbusHandler, metricsRegistry)
+
+		app.metricsServer = boshmetrics.NewServer(
+			config.Metrics,
+			app.platform.GetVitalsService(),
+			jobSupervisor,
+			metricsRegistry,
+			app.logger,
+		)
+	}
+
+	app.adminServer = boshadmin.NewServer(
+		app.dirProvider.AdminSocketPath(),
+		actionDispatcher.Dispatch,
+		auditLogger,
+		app.logger,
+	)
+
+	alertProcessor := boshalert.NewProcessor(
+		func(alert boshalert.Alert) error {
+			return agentMbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+		},
+		config.Alerts,
+		timeService,
+		app.logger,
+	)
+
+	if config.Alerts.Enabled() {
+		app.alertServer = boshalert.NewServer(
+			config.Alerts,
+			alertProcessor,
+			settingsService,
+			uuidGen,
+			timeService,
+			app.logger,
+		)
+	}
+
+	if logForwarding := settingsService.GetSettings().GetLogForwarding(); logForwarding.Enabled() {
+		app.logForwarder = boshlogforwarder.NewForwarder(
+			logForwarding,
+			settingsService.GetSettings().AgentID,
+			app.dirProvider.LogsDir(),
+			app.dirProvider.LogForwarderDir(),
+			timeService,
+			app.logger,
+		)
+	}
+
 	startManager := bootonce.NewStartManager(
 		settingsService,
 		app.platform.GetFs(),
@@ -239,7 +339,7 @@ func (app *app) Setup(opts Options) error {
 
 	app.agent = boshagent.New(
 		app.logger,
-		mbusHandler,
+		agentMbusHandler,
 		app.platform,
 		actionDispatcher,
 		jobSupervisor,
@@ -249,12 +349,42 @@ func (app *app) Setup(opts Options) error {
 		uuidGen,
 		timeService,
 		startManager,
+		alertProcessor,
 	)
 
 	return nil
 }
 
 func (app *app) Run() error {
+	if app.metricsServer != nil {
+		if err := app.metricsServer.Start(); err != nil {
+			return bosherr.WrapError(err, "Starting metrics server")
+		}
+		defer app.metricsServer.Stop()
+	}
+
+	if app.alertServer != nil {
+		if err := app.alertServer.Start(); err != nil {
+			return bosherr.WrapError(err, "Starting alert server")
+		}
+		defer app.alertServer.Stop()
+	}
+
+	// Jobs keep running and logs stay on disk without forwarding
+	if app.logForwarder != nil {
+		if err := app.logForwarder.Start(); err != nil {
+			app.logger.Error(app.logTag, "Starting log forwarder: %s", err.Error())
+		} else {
+			defer app.logForwarder.Stop()
+		}
+	}
+
+	// Agent can still be managed by the Director without admin socket
+	if err := app.adminServer.Start(); err != nil {
+		app.logger.Error(app.logTag, "Starting admin server: %s", err.Error())
+	}
+	defer app.adminServer.Stop()
+
 	if err := app.agent.Run(); err != nil {
 		return bosherr.WrapError(err, "Running agent")
 	}
@@ -324,6 +454,23 @@ func (app *app) buildApplierAndCompiler(
 		10*1024, // 10 Kb
 	)
 
+	var compileSandbox boshsandbox.Sandbox = boshsandbox.NewUnconfinedSandbox()
+
+	if sandboxSettings := settings.Env.Bosh.CompilationSandbox; sandboxSettings.Enabled {
+		compileSandbox = boshsandbox.NewCgroupSandbox(
+			fileSystem,
+			app.platform.GetRunner(),
+			boshsandbox.CgroupRoot,
+			[]string{"/", dirProvider.BaseDir(), dirProvider.DataDir()},
+			boshsandbox.Limits{
+				CPUs:        sandboxSettings.CPUs,
+				MemoryBytes: sandboxSettings.MemoryMB * 1024 * 1024,
+				MaxPids:     sandboxSettings.MaxPids,
+			},
+			app.logger,
+		)
+	}
+
 	compiler := boshcomp.NewConcreteCompiler(
 		app.platform.GetCompressor(),
 		blobstoreDelegator,
@@ -333,6 +480,13 @@ func (app *app) buildApplierAndCompiler(
 		packageApplierProvider.Root(),
 		packageApplierProvider.RootBundleCollection(),
 		clock.NewClock(),
+		*settings.Env.GetParallel(),
+		boshcomp.PackagingOptions{
+			Reproducible:    settings.Env.Bosh.ReproduciblePackages.Enabled,
+			SourceDateEpoch: time.Unix(settings.Env.Bosh.ReproduciblePackages.SourceDateEpoch, 0),
+		},
+		compileSandbox,
+		app.logger,
 	)
 
 	return applier, compiler
@@ -363,6 +517,7 @@ func (app *app) fileContents(path string) string {
 func (app *app) setupBlobstore(
 	blobstoreSettings boshsettings.Blobstore,
 	blobManagers []boshagentblobstore.BlobManagerInterface,
+	blobCache boshagentblobstore.BlobCache,
 ) (boshblob.DigestBlobstore, error) {
 	blobstoreProvider := boshblob.NewProvider(
 		app.platform.GetFs(),
@@ -378,7 +533,7 @@ func (app *app) setupBlobstore(
 		return nil, bosherr.WrapError(err, "Getting blobstore")
 	}
 
-	return boshagentblobstore.NewCascadingBlobstore(blobstore, blobManagers, app.logger), nil
+	return boshagentblobstore.NewCascadingBlobstore(blobstore, blobManagers, blobCache, app.logger), nil
 }
 
 func (app *app) patchBlobstoreOptions(blobstoreSettings boshsettings.Blobstore) boshsettings.Blobstore {
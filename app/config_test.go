@@ -4,7 +4,9 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/agent/blobstore"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
+	boshmetrics "github.com/cloudfoundry/bosh-agent/metrics"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
@@ -58,11 +60,23 @@ var _ = Describe("LoadConfigFromPath", func() {
 						"URI": "/fake-uri",
 						"Headers": {"fake": "headers"},
 						"SettingsPath": "/fake-settings-path"
+					  },
+					  {
+						"Type": "NoCloud",
+						"DiskPaths": ["/dev/disk/by-label/cidata"],
+						"NetworkConfigPath": "/fake-network-config-path",
+						"Attempts": 3
 					  }
 				  ],
 				  "UseServerName": true,
 				  "UseRegistry": true
 				}
+			},
+			"Metrics": {
+				"Address": "127.0.0.1:9100"
+			},
+			"BlobCache": {
+				"MaxSizeBytes": 1073741824
 			}
 		}`)
 		Expect(err).NotTo(HaveOccurred())
@@ -104,11 +118,22 @@ var _ = Describe("LoadConfigFromPath", func() {
 							Headers:      map[string]string{"fake": "headers"},
 							SettingsPath: "/fake-settings-path",
 						},
+						boshinf.NoCloudSourceOptions{
+							DiskPaths:         []string{"/dev/disk/by-label/cidata"},
+							NetworkConfigPath: "/fake-network-config-path",
+							Attempts:          3,
+						},
 					},
 					UseServerName: true,
 					UseRegistry:   true,
 				},
 			},
+			Metrics: boshmetrics.Options{
+				Address: "127.0.0.1:9100",
+			},
+			BlobCache: boshagentblobstore.CacheOptions{
+				MaxSizeBytes: 1073741824,
+			},
 		}))
 	})
 
@@ -3,6 +3,9 @@ package app
 import (
 	"flag"
 	"io"
+
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type Options struct {
@@ -11,6 +14,7 @@ type Options struct {
 	BaseDirectory      string
 	JobSupervisor      string
 	ConfigPath         string
+	LogFormat          string
 	VersionCheck       bool
 }
 
@@ -25,6 +29,7 @@ func ParseOptions(args []string) (Options, error) {
 	flagSet.StringVar(&opts.ConfigPath, "C", "", "Config path")
 	flagSet.StringVar(&opts.JobSupervisor, "M", "monit", "Set jobsupervisor")
 	flagSet.StringVar(&opts.BaseDirectory, "b", "/var/vcap", "Set Base Directory")
+	flagSet.StringVar(&opts.LogFormat, "log-format", agentlogger.FormatText, "Log format (text or json)")
 	flagSet.BoolVar(&opts.VersionCheck, "v", false, "version")
 
 	// The following two options are accepted but ignored for compatibility with the old agent
@@ -37,6 +42,13 @@ func ParseOptions(args []string) (Options, error) {
 	// cannot call flagSet.Parse in the return statement due to gccgo
 	// execution order issues: https://code.google.com/p/go/issues/detail?id=8698&thanks=8698&ts=1410376474
 	err := flagSet.Parse(args[1:])
+	if err != nil {
+		return opts, err
+	}
+
+	if opts.LogFormat != agentlogger.FormatText && opts.LogFormat != agentlogger.FormatJSON {
+		return opts, bosherr.Errorf("Unknown log format '%s'", opts.LogFormat)
+	}
 
-	return opts, err
+	return opts, nil
 }
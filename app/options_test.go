@@ -25,4 +25,20 @@ var _ = Describe("ParseOptions", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(opts.ConfigPath).To(Equal(""))
 	})
+
+	It("parses log format", func() {
+		opts, err := ParseOptions([]string{"bosh-agent", "-log-format", "json"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.LogFormat).To(Equal("json"))
+
+		opts, err = ParseOptions([]string{"bosh-agent"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.LogFormat).To(Equal("text"))
+	})
+
+	It("returns error for unknown log format", func() {
+		_, err := ParseOptions([]string{"bosh-agent", "-log-format", "xml"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unknown log format 'xml'"))
+	})
 })
@@ -19,6 +19,7 @@ const (
 type CommonEventFormat interface {
 	ProduceHTTPRequestEventLog(*http.Request, int, string) (string, error)
 	ProduceNATSRequestEventLog(string, string, string, string, int, string, string) (string, error)
+	ProduceLocalRequestEventLog(int, int, string, bool, int, string) (string, error)
 }
 
 func NewCommonEventFormat() CommonEventFormat {
@@ -79,3 +80,23 @@ func (cef concreteCommonEventFormat) ProduceNATSRequestEventLog(addr string, por
 
 	return fmt.Sprintf("CEF:%v|%s|%s|%s|%s|%s|%v|%s", cefVersion, deviceVendor, deviceProduct, deviceVersion, signatureID, msgMethod, severity, extension), nil
 }
+
+func (cef concreteCommonEventFormat) ProduceLocalRequestEventLog(uid int, pid int, msgMethod string, mutating bool, severity int, respBody string) (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+
+	extension := fmt.Sprintf(
+		`suid=%d spid=%d shost=%s cs2=peercred cs2Label=authType cs3=%t cs3Label=mutating `,
+		uid, pid, hostname, mutating)
+
+	if severity >= 7 {
+		var buffer bytes.Buffer
+		buffer.WriteString(extension)
+		buffer.WriteString(fmt.Sprintf("cs1=%s cs1Label=statusReason", respBody))
+		extension = buffer.String()
+	}
+
+	return fmt.Sprintf("CEF:%v|%s|%s|%s|%s|%s|%v|%s", cefVersion, deviceVendor, deviceProduct, deviceVersion, signatureID, msgMethod, severity, extension), nil
+}
@@ -73,4 +73,25 @@ var _ = Describe("CommonEventFormat", func() {
 			})
 		})
 	})
+
+	Context("when incoming request comes from the local admin socket", func() {
+		It("should produce CEF string", func() {
+			cefLog, err := cef.ProduceLocalRequestEventLog(0, 1234, "get_state", false, 1, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cefLog).To(ContainSubstring("CEF:0|CloudFoundry|BOSH|1|agent_api|get_state|1|suid=0 spid=1234 shost="))
+			Expect(cefLog).To(ContainSubstring("cs2=peercred cs2Label=authType"))
+			Expect(cefLog).To(ContainSubstring("cs3=false cs3Label=mutating"))
+			Expect(cefLog).NotTo(ContainSubstring("cs1Label=statusReason"))
+		})
+
+		Context("when responding with an error", func() {
+			It("should produce CEF string with severity=7 and statusReason", func() {
+				cefLog, err := cef.ProduceLocalRequestEventLog(1000, 1234, "apply", true, 7, "Permission denied")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cefLog).To(ContainSubstring("CEF:0|CloudFoundry|BOSH|1|agent_api|apply|7|suid=1000 spid=1234"))
+				Expect(cefLog).To(ContainSubstring("cs3=true cs3Label=mutating"))
+				Expect(cefLog).To(ContainSubstring("cs1=Permission denied cs1Label=statusReason"))
+			})
+		})
+	})
 })
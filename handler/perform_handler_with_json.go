@@ -18,26 +18,35 @@ func PerformHandlerWithJSON(rawJSON []byte, handler Func, maxResponseLength int,
 
 	err := json.Unmarshal(rawJSON, &request)
 	if err != nil {
+		// Invalid request cannot be correlated with anything
+		request.logger = logger
 		return []byte{}, request, bosherr.WrapError(err, "Unmarshalling JSON payload")
 	}
 
 	request.Payload = rawJSON
+	request = request.WithLogger(logger)
 
+	respJSON, err := handleRequest(request, handler, maxResponseLength, request.Logger(logger))
+
+	return respJSON, request, err
+}
+
+func handleRequest(request Request, handler Func, maxResponseLength int, logger boshlog.Logger) ([]byte, error) {
 	response := handler(request)
 	if response == nil {
 		logger.Info(mbusHandlerLogTag, "Nil response returned from handler")
-		return []byte{}, request, nil
+		return []byte{}, nil
 	}
 
 	respJSON, err := marshalResponse(response, maxResponseLength, logger)
 	if err != nil {
-		return respJSON, request, err
+		return respJSON, err
 	}
 
 	logger.Info(mbusHandlerLogTag, "Responding")
 	logger.DebugWithDetails(mbusHandlerLogTag, "Payload", respJSON)
 
-	return respJSON, request, nil
+	return respJSON, nil
 }
 
 func BuildErrorWithJSON(msg string, logger boshlog.Logger) ([]byte, error) {
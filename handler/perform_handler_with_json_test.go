@@ -0,0 +1,87 @@
+package handler_test
+
+import (
+	"github.com/onsi/gomega/gbytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("PerformHandlerWithJSON", func() {
+	var (
+		outBuf *gbytes.Buffer
+		logger boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		logger = agentlogger.NewJSONLogger(boshlog.LevelDebug, outBuf)
+	})
+
+	It("passes request to handler and returns its response", func() {
+		var receivedReq handler.Request
+		handlerFunc := func(req handler.Request) handler.Response {
+			receivedReq = req
+			return handler.NewValueResponse("fake-value")
+		}
+
+		respJSON, req, err := handler.PerformHandlerWithJSON(
+			[]byte(`{"method":"ping","reply_to":"fake-reply-to","protocol":3,"arguments":[]}`),
+			handlerFunc,
+			handler.UnlimitedResponseLength,
+			logger,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(respJSON)).To(Equal(`{"value":"fake-value"}`))
+
+		Expect(req.Method).To(Equal("ping"))
+		Expect(req.ReplyTo).To(Equal("fake-reply-to"))
+		Expect(req.ProtocolVersion).To(Equal(handler.ProtocolVersion(3)))
+		Expect(receivedReq).To(Equal(req))
+	})
+
+	It("correlates its lines with request", func() {
+		handlerFunc := func(req handler.Request) handler.Response {
+			return handler.NewValueResponse("fake-value")
+		}
+
+		_, _, err := handler.PerformHandlerWithJSON(
+			[]byte(`{"method":"ping","reply_to":"fake-reply-to","protocol":3,"arguments":[]}`),
+			handlerFunc,
+			handler.UnlimitedResponseLength,
+			logger,
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(outBuf).To(gbytes.Say(`"message":"Responding","method":"ping","protocol_version":3,"request_id":"fake-reply-to"`))
+	})
+
+	It("passes logger correlating lines with request to handler", func() {
+		handlerFunc := func(req handler.Request) handler.Response {
+			req.Logger(nil).Info("fake-handler", "Handling")
+			return handler.NewValueResponse("fake-value")
+		}
+
+		_, req, err := handler.PerformHandlerWithJSON(
+			[]byte(`{"method":"ping","reply_to":"fake-reply-to","protocol":3,"arguments":[]}`),
+			handlerFunc,
+			handler.UnlimitedResponseLength,
+			logger,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(outBuf).To(gbytes.Say(`"message":"Handling","method":"ping","protocol_version":3,"request_id":"fake-reply-to","tag":"fake-handler"`))
+
+		req.Logger(nil).Error("fake-handler", "Publishing")
+		Expect(outBuf).To(gbytes.Say(`"message":"Publishing","method":"ping","protocol_version":3,"request_id":"fake-reply-to"`))
+	})
+
+	It("returns error when payload is not valid JSON", func() {
+		_, _, err := handler.PerformHandlerWithJSON([]byte(`{`), nil, handler.UnlimitedResponseLength, logger)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling JSON payload"))
+	})
+})
@@ -1,5 +1,10 @@
 package handler
 
+import (
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
 type ProtocolVersion int
 
 func NewRequest(replyTo, method string, payload []byte, protocolVersion ProtocolVersion) Request {
@@ -16,8 +21,37 @@ type Request struct {
 	Method          string
 	Payload         []byte
 	ProtocolVersion ProtocolVersion `json:"protocol"`
+
+	logger boshlog.Logger
 }
 
 func (r Request) GetPayload() []byte {
 	return r.Payload
 }
+
+// WithLogger returns request carrying logger which attaches request's
+// log fields, so that everything handling it can log with them.
+func (r Request) WithLogger(logger boshlog.Logger) Request {
+	r.logger = agentlogger.WithFields(logger, r.LogFields())
+	return r
+}
+
+// Logger returns logger carried by request, or logger attaching
+// request's log fields if request was not received by a handler.
+func (r Request) Logger(logger boshlog.Logger) boshlog.Logger {
+	if r.logger != nil {
+		return r.logger
+	}
+
+	return agentlogger.WithFields(logger, r.LogFields())
+}
+
+// LogFields identify request in structured logs; payload is left out
+// since it may contain sensitive data.
+func (r Request) LogFields() agentlogger.Fields {
+	return agentlogger.Fields{
+		"request_id":       r.ReplyTo,
+		"method":           r.Method,
+		"protocol_version": r.ProtocolVersion,
+	}
+}
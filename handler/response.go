@@ -47,3 +47,9 @@ func (r exceptionResponse) Shorten() Response {
 
 	return r
 }
+
+// IsExceptionResponse reports whether resp was built with NewExceptionResponse.
+func IsExceptionResponse(resp Response) bool {
+	_, ok := resp.(exceptionResponse)
+	return ok
+}
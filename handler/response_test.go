@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/handler"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
@@ -92,3 +93,10 @@ var _ = Describe("NewExceptionResponse", func() {
 		})
 	})
 })
+
+var _ = Describe("IsExceptionResponse", func() {
+	It("returns true only for exception responses", func() {
+		Expect(IsExceptionResponse(NewExceptionResponse(errors.New("fake-err")))).To(BeTrue())
+		Expect(IsExceptionResponse(NewValueResponse("fake-value"))).To(BeFalse())
+	})
+})
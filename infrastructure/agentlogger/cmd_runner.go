@@ -0,0 +1,36 @@
+package agentlogger
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type LoggerCmdRunner interface {
+	// WithLogger returns a command runner logging commands it runs
+	// and their output with logger, e.g. one scoped to a request.
+	WithLogger(logger boshlog.Logger) boshsys.CmdRunner
+}
+
+type execCmdRunner struct {
+	boshsys.CmdRunner
+}
+
+// NewExecCmdRunner returns exec command runner which can be rescoped to other loggers
+func NewExecCmdRunner(logger boshlog.Logger) boshsys.CmdRunner {
+	return execCmdRunner{CmdRunner: boshsys.NewExecCmdRunner(logger)}
+}
+
+func (r execCmdRunner) WithLogger(logger boshlog.Logger) boshsys.CmdRunner {
+	return NewExecCmdRunner(logger)
+}
+
+// CmdRunnerWithLogger returns runner logging with logger if runner
+// supports it, otherwise runner is returned as it is.
+func CmdRunnerWithLogger(runner boshsys.CmdRunner, logger boshlog.Logger) boshsys.CmdRunner {
+	loggerRunner, ok := runner.(LoggerCmdRunner)
+	if !ok {
+		return runner
+	}
+
+	return loggerRunner.WithLogger(logger)
+}
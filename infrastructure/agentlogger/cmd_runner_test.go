@@ -0,0 +1,30 @@
+package agentlogger_test
+
+import (
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
+	"github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("CmdRunnerWithLogger", func() {
+	It("returns exec command runner logging with given logger", func() {
+		outBuf := gbytes.NewBuffer()
+		jsonLogger := agentlogger.NewJSONLogger(logger.LevelDebug, outBuf)
+		runner := agentlogger.NewExecCmdRunner(logger.NewLogger(logger.LevelNone))
+
+		requestLogger := agentlogger.WithFields(jsonLogger, agentlogger.Fields{"request_id": "fake-request-id"})
+		stdout, _, _, err := agentlogger.CmdRunnerWithLogger(runner, requestLogger).RunCommand("echo", "fake-output")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stdout).To(Equal("fake-output\n"))
+
+		Expect(outBuf).To(gbytes.Say(`"message":"Stdout: fake-output\\n","request_id":"fake-request-id"`))
+	})
+
+	It("returns runner as it is if it cannot log with other loggers", func() {
+		runner := fakesys.NewFakeCmdRunner()
+		Expect(agentlogger.CmdRunnerWithLogger(runner, logger.NewLogger(logger.LevelNone))).To(BeIdenticalTo(runner))
+	})
+})
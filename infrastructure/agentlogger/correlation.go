@@ -0,0 +1,37 @@
+package agentlogger
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// Fields are attached to every line logged by a logger returned by WithFields
+type Fields map[string]interface{}
+
+// Merge returns new fields with other taking precedence
+func (f Fields) Merge(other Fields) Fields {
+	merged := Fields{}
+	for name, value := range f {
+		merged[name] = value
+	}
+	for name, value := range other {
+		merged[name] = value
+	}
+	return merged
+}
+
+type FieldLogger interface {
+	// WithFields returns a logger sharing output with this one which
+	// attaches fields to all lines it logs in addition to existing ones.
+	WithFields(fields Fields) boshlog.Logger
+}
+
+// WithFields returns logger attaching fields to log lines if logger
+// supports it, e.g. in text mode logger is returned as it is.
+func WithFields(logger boshlog.Logger, fields Fields) boshlog.Logger {
+	fieldLogger, ok := logger.(FieldLogger)
+	if !ok {
+		return logger
+	}
+
+	return fieldLogger.WithFields(fields)
+}
@@ -0,0 +1,135 @@
+package agentlogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type jsonLogger struct {
+	output *jsonOutput
+
+	// Correlation fields attached to every line
+	fields Fields
+}
+
+// jsonOutput is shared by loggers derived with WithFields
+type jsonOutput struct {
+	level boshlog.LogLevel
+
+	// Toggled from signal handler while other goroutines log
+	forcedDebug atomic.Bool
+
+	writer   io.Writer
+	writerMu sync.Mutex
+}
+
+// NewJSONLogger writes each log line as a JSON object including
+// correlation fields of loggers derived from it with WithFields.
+func NewJSONLogger(level boshlog.LogLevel, writer io.Writer) boshlog.Logger {
+	return &jsonLogger{
+		output: &jsonOutput{
+			level:  level,
+			writer: writer,
+		},
+		fields: Fields{},
+	}
+}
+
+func (l *jsonLogger) Debug(tag, msg string, args ...interface{}) {
+	l.log(boshlog.LevelDebug, tag, msg, args...)
+}
+
+func (l *jsonLogger) DebugWithDetails(tag, msg string, args ...interface{}) {
+	l.log(boshlog.LevelDebug, tag, msg+"\n%s", args...)
+}
+
+func (l *jsonLogger) Info(tag, msg string, args ...interface{}) {
+	l.log(boshlog.LevelInfo, tag, msg, args...)
+}
+
+func (l *jsonLogger) Warn(tag, msg string, args ...interface{}) {
+	l.log(boshlog.LevelWarn, tag, msg, args...)
+}
+
+func (l *jsonLogger) Error(tag, msg string, args ...interface{}) {
+	l.log(boshlog.LevelError, tag, msg, args...)
+}
+
+func (l *jsonLogger) ErrorWithDetails(tag, msg string, args ...interface{}) {
+	l.log(boshlog.LevelError, tag, msg+"\n%s", args...)
+}
+
+func (l *jsonLogger) HandlePanic(tag string) {
+	if e := recover(); e != nil {
+		l.ErrorWithDetails(tag, "Panic: %v", e, debug.Stack())
+		os.Exit(2)
+	}
+}
+
+func (l *jsonLogger) ToggleForcedDebug() {
+	for {
+		forcedDebug := l.output.forcedDebug.Load()
+		if l.output.forcedDebug.CompareAndSwap(forcedDebug, !forcedDebug) {
+			return
+		}
+	}
+}
+
+// UseRFC3339Timestamps does nothing as timestamps are always RFC 3339
+func (l *jsonLogger) UseRFC3339Timestamps() {}
+
+func (l *jsonLogger) Flush() error                       { return nil }
+func (l *jsonLogger) FlushTimeout(_ time.Duration) error { return nil }
+
+func (l *jsonLogger) WithFields(fields Fields) boshlog.Logger {
+	return &jsonLogger{
+		output: l.output,
+		fields: l.fields.Merge(fields),
+	}
+}
+
+func (l *jsonLogger) log(level boshlog.LogLevel, tag, msg string, args ...interface{}) {
+	if l.output.level > level && !l.output.forcedDebug.Load() {
+		return
+	}
+
+	line := map[string]interface{}{}
+
+	for name, value := range l.fields {
+		line[name] = value
+	}
+
+	line["timestamp"] = time.Now().UTC().Format(time.RFC3339Nano)
+	line["level"] = boshlog.AsString(level)
+	line["tag"] = tag
+	line["message"] = fmt.Sprintf(msg, args...)
+
+	bytes, err := json.Marshal(line)
+	if err != nil {
+		// Only happens with unsupported field values; keep the message
+		bytes, _ = json.Marshal(map[string]string{
+			"timestamp": line["timestamp"].(string),
+			"level":     line["level"].(string),
+			"tag":       tag,
+			"message":   line["message"].(string),
+		})
+	}
+
+	l.output.writerMu.Lock()
+	defer l.output.writerMu.Unlock()
+
+	_, _ = l.output.writer.Write(append(bytes, '\n'))
+}
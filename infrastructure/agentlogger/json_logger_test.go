@@ -0,0 +1,122 @@
+package agentlogger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
+	"github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSON logger", func() {
+	var (
+		outBuf     *bytes.Buffer
+		jsonLogger logger.Logger
+	)
+
+	readLines := func() []map[string]interface{} {
+		var lines []map[string]interface{}
+		for _, rawLine := range strings.Split(strings.TrimSpace(outBuf.String()), "\n") {
+			line := map[string]interface{}{}
+			Expect(json.Unmarshal([]byte(rawLine), &line)).To(Succeed())
+			lines = append(lines, line)
+		}
+		return lines
+	}
+
+	BeforeEach(func() {
+		outBuf = new(bytes.Buffer)
+		jsonLogger = agentlogger.NewJSONLogger(logger.LevelInfo, outBuf)
+	})
+
+	It("writes a JSON object per line", func() {
+		jsonLogger.Info("fake-tag", "fake-%s", "message")
+		jsonLogger.ErrorWithDetails("fake-tag", "fake-error", "fake-details")
+
+		lines := readLines()
+		Expect(lines).To(HaveLen(2))
+
+		Expect(lines[0]).To(HaveKeyWithValue("level", "INFO"))
+		Expect(lines[0]).To(HaveKeyWithValue("tag", "fake-tag"))
+		Expect(lines[0]).To(HaveKeyWithValue("message", "fake-message"))
+		Expect(lines[0]).To(HaveKey("timestamp"))
+
+		Expect(lines[1]).To(HaveKeyWithValue("level", "ERROR"))
+		Expect(lines[1]).To(HaveKeyWithValue("message", "fake-error\nfake-details"))
+	})
+
+	It("skips lines below log level unless debug is forced", func() {
+		jsonLogger.Debug("fake-tag", "fake-debug")
+		Expect(outBuf.Len()).To(Equal(0))
+
+		jsonLogger.ToggleForcedDebug()
+		jsonLogger.Debug("fake-tag", "fake-debug")
+		Expect(readLines()).To(HaveLen(1))
+	})
+
+	It("adds fields of loggers derived with WithFields to their lines", func() {
+		requestLogger := agentlogger.WithFields(jsonLogger, agentlogger.Fields{"request_id": "fake-request-id", "method": "fake-method"})
+		taskLogger := agentlogger.WithFields(requestLogger, agentlogger.Fields{"task_id": "fake-task-id"})
+
+		requestLogger.Info("fake-tag", "request")
+		taskLogger.Info("fake-tag", "task")
+		jsonLogger.Info("fake-tag", "uncorrelated")
+
+		lines := readLines()
+		Expect(lines).To(HaveLen(3))
+
+		Expect(lines[0]).To(HaveKeyWithValue("request_id", "fake-request-id"))
+		Expect(lines[0]).To(HaveKeyWithValue("method", "fake-method"))
+		Expect(lines[0]).ToNot(HaveKey("task_id"))
+
+		Expect(lines[1]).To(HaveKeyWithValue("request_id", "fake-request-id"))
+		Expect(lines[1]).To(HaveKeyWithValue("task_id", "fake-task-id"))
+
+		Expect(lines[2]).ToNot(HaveKey("request_id"))
+	})
+
+	It("does not let correlation fields override standard fields", func() {
+		agentlogger.WithFields(jsonLogger, agentlogger.Fields{"message": "fake-field"}).Info("fake-tag", "fake-message")
+
+		Expect(readLines()[0]).To(HaveKeyWithValue("message", "fake-message"))
+	})
+
+	It("shares forced debug with derived loggers", func() {
+		derivedLogger := agentlogger.WithFields(jsonLogger, agentlogger.Fields{"request_id": "fake-request-id"})
+
+		jsonLogger.ToggleForcedDebug()
+		derivedLogger.Debug("fake-tag", "fake-debug")
+
+		Expect(readLines()).To(HaveLen(1))
+	})
+
+	It("toggles forced debug while derived loggers are logging", func() {
+		derivedLogger := agentlogger.WithFields(jsonLogger, agentlogger.Fields{"request_id": "fake-request-id"})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				derivedLogger.Debug("fake-tag", "fake-debug")
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			jsonLogger.ToggleForcedDebug()
+		}
+		<-done
+
+		outBuf.Reset()
+		jsonLogger.Debug("fake-tag", "fake-debug")
+		Expect(outBuf.Len()).To(Equal(0))
+	})
+
+	It("returns loggers without field support as they are", func() {
+		textLogger := logger.NewWriterLogger(logger.LevelInfo, outBuf)
+
+		Expect(agentlogger.WithFields(textLogger, agentlogger.Fields{"request_id": "fake-request-id"})).To(BeIdenticalTo(textLogger))
+	})
+})
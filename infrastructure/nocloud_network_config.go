@@ -0,0 +1,270 @@
+package infrastructure
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// noCloudStrings accepts either a single string or a list of strings
+type noCloudStrings []string
+
+func (s *noCloudStrings) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = []string{node.Value}
+		return nil
+	}
+
+	var values []string
+	err := node.Decode(&values)
+	*s = values
+
+	return err
+}
+
+type noCloudNetworkConfig struct {
+	Version int `yaml:"version"`
+
+	// Version 1
+	Config []noCloudNetworkConfigV1Entry `yaml:"config"`
+
+	// Version 2
+	Ethernets map[string]noCloudNetworkConfigV2Ethernet `yaml:"ethernets"`
+}
+
+type noCloudNetworkConfigV1Entry struct {
+	Type       string                         `yaml:"type"`
+	Name       string                         `yaml:"name"`
+	MacAddress string                         `yaml:"mac_address"`
+	Subnets    []noCloudNetworkConfigV1Subnet `yaml:"subnets"`
+
+	// Addresses of global nameservers
+	Address noCloudStrings `yaml:"address"`
+}
+
+type noCloudNetworkConfigV1Subnet struct {
+	Type           string         `yaml:"type"`
+	Address        string         `yaml:"address"`
+	Netmask        string         `yaml:"netmask"`
+	Gateway        string         `yaml:"gateway"`
+	DNSNameservers noCloudStrings `yaml:"dns_nameservers"`
+	Routes         []struct {
+		Network string `yaml:"network"`
+		Netmask string `yaml:"netmask"`
+		Gateway string `yaml:"gateway"`
+	} `yaml:"routes"`
+}
+
+type noCloudNetworkConfigV2Ethernet struct {
+	Match struct {
+		MacAddress string `yaml:"macaddress"`
+	} `yaml:"match"`
+	SetName     string   `yaml:"set-name"`
+	DHCP4       bool     `yaml:"dhcp4"`
+	Addresses   []string `yaml:"addresses"`
+	Gateway4    string   `yaml:"gateway4"`
+	Nameservers struct {
+		Addresses []string `yaml:"addresses"`
+	} `yaml:"nameservers"`
+	Routes []struct {
+		To  string `yaml:"to"`
+		Via string `yaml:"via"`
+	} `yaml:"routes"`
+}
+
+// ParseNoCloudNetworkConfig converts cloud-init network-config version 1 or 2
+// into networks named after their interfaces; first network (in order of names)
+// with a gateway or using DHCP is made default for DNS and gateway
+func ParseNoCloudNetworkConfig(contents []byte) (boshsettings.Networks, error) {
+	var wrapper struct {
+		Network *noCloudNetworkConfig `yaml:"network"`
+	}
+
+	err := yaml.Unmarshal(contents, &wrapper)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling network config")
+	}
+
+	config := wrapper.Network
+	if config == nil {
+		config = &noCloudNetworkConfig{}
+
+		err = yaml.Unmarshal(contents, config)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Unmarshalling network config")
+		}
+	}
+
+	var networks boshsettings.Networks
+
+	switch config.Version {
+	case 1:
+		networks, err = noCloudNetworksV1(config.Config)
+	case 2:
+		networks, err = noCloudNetworksV2(config.Ethernets)
+	default:
+		err = bosherr.Errorf("Unsupported network config version '%d'", config.Version)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	setNoCloudDefaultNetwork(networks)
+
+	return networks, nil
+}
+
+func noCloudNetworksV1(entries []noCloudNetworkConfigV1Entry) (boshsettings.Networks, error) {
+	networks := boshsettings.Networks{}
+	nameservers := []string{}
+
+	for _, entry := range entries {
+		switch entry.Type {
+		case "nameserver":
+			nameservers = append(nameservers, entry.Address...)
+
+		case "physical":
+			network := boshsettings.Network{Mac: strings.ToLower(entry.MacAddress)}
+
+			for _, subnet := range entry.Subnets {
+				if subnet.Type == "dhcp" || subnet.Type == "dhcp4" {
+					network.Type = boshsettings.NetworkTypeDynamic
+					break
+				}
+
+				if subnet.Type != "static" {
+					continue
+				}
+
+				ip, netmask, err := noCloudAddress(subnet.Address, subnet.Netmask)
+				if err != nil {
+					return nil, bosherr.WrapErrorf(err, "Parsing address of interface '%s'", entry.Name)
+				}
+
+				network.IP = ip
+				network.Netmask = netmask
+				network.Gateway = subnet.Gateway
+				network.DNS = subnet.DNSNameservers
+
+				for _, route := range subnet.Routes {
+					destination, netmask, err := noCloudAddress(route.Network, route.Netmask)
+					if err != nil {
+						return nil, bosherr.WrapErrorf(err, "Parsing route of interface '%s'", entry.Name)
+					}
+
+					network.Routes = append(network.Routes, boshsettings.Route{
+						Destination: destination,
+						Netmask:     netmask,
+						Gateway:     route.Gateway,
+					})
+				}
+
+				break
+			}
+
+			networks[entry.Name] = network
+		}
+	}
+
+	for name, network := range networks {
+		if len(network.DNS) == 0 && len(nameservers) > 0 {
+			network.DNS = nameservers
+			networks[name] = network
+		}
+	}
+
+	return networks, nil
+}
+
+func noCloudNetworksV2(ethernets map[string]noCloudNetworkConfigV2Ethernet) (boshsettings.Networks, error) {
+	networks := boshsettings.Networks{}
+
+	for id, ethernet := range ethernets {
+		name := id
+		if ethernet.SetName != "" {
+			name = ethernet.SetName
+		}
+
+		network := boshsettings.Network{
+			Mac:     strings.ToLower(ethernet.Match.MacAddress),
+			Gateway: ethernet.Gateway4,
+			DNS:     ethernet.Nameservers.Addresses,
+		}
+
+		if ethernet.DHCP4 {
+			network.Type = boshsettings.NetworkTypeDynamic
+		} else if len(ethernet.Addresses) > 0 {
+			ip, netmask, err := noCloudAddress(ethernet.Addresses[0], "")
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Parsing address of interface '%s'", name)
+			}
+
+			network.IP = ip
+			network.Netmask = netmask
+		}
+
+		for _, route := range ethernet.Routes {
+			if route.To == "default" || route.To == "0.0.0.0/0" {
+				if network.Gateway == "" {
+					network.Gateway = route.Via
+				}
+				continue
+			}
+
+			destination, netmask, err := noCloudAddress(route.To, "")
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Parsing route of interface '%s'", name)
+			}
+
+			network.Routes = append(network.Routes, boshsettings.Route{
+				Destination: destination,
+				Netmask:     netmask,
+				Gateway:     route.Via,
+			})
+		}
+
+		networks[name] = network
+	}
+
+	return networks, nil
+}
+
+// noCloudAddress splits address in CIDR notation unless netmask is given separately
+func noCloudAddress(address, netmask string) (string, string, error) {
+	if !strings.Contains(address, "/") {
+		if net.ParseIP(address) == nil {
+			return "", "", bosherr.Errorf("Invalid IP address '%s'", address)
+		}
+		return address, netmask, nil
+	}
+
+	ip, ipNet, err := net.ParseCIDR(address)
+	if err != nil {
+		return "", "", err
+	}
+
+	return ip.String(), net.IP(ipNet.Mask).String(), nil
+}
+
+func setNoCloudDefaultNetwork(networks boshsettings.Networks) {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		network := networks[name]
+		if network.Gateway != "" || network.Type == boshsettings.NetworkTypeDynamic {
+			network.Default = []string{"dns", "gateway"}
+			networks[name] = network
+			return
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package infrastructure_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+var _ = Describe("ParseNoCloudNetworkConfig", func() {
+	It("parses version 1 network config", func() {
+		networks, err := ParseNoCloudNetworkConfig([]byte(`
+network:
+  version: 1
+  config:
+  - type: physical
+    name: eth0
+    mac_address: "AA:BB:CC:DD:EE:01"
+    subnets:
+    - type: static
+      address: 10.0.0.5
+      netmask: 255.255.255.0
+      gateway: 10.0.0.1
+      routes:
+      - network: 192.168.0.0
+        netmask: 255.255.0.0
+        gateway: 10.0.0.254
+  - type: physical
+    name: eth1
+    mac_address: "aa:bb:cc:dd:ee:02"
+    subnets:
+    - type: static
+      address: 10.1.0.5/16
+      dns_nameservers: 10.1.0.2
+  - type: physical
+    name: eth2
+    subnets:
+    - type: dhcp
+  - type: nameserver
+    address: [8.8.8.8, 8.8.4.4]
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(networks).To(Equal(boshsettings.Networks{
+			"eth0": boshsettings.Network{
+				IP:      "10.0.0.5",
+				Netmask: "255.255.255.0",
+				Gateway: "10.0.0.1",
+				Mac:     "aa:bb:cc:dd:ee:01",
+				DNS:     []string{"8.8.8.8", "8.8.4.4"},
+				Default: []string{"dns", "gateway"},
+				Routes: boshsettings.Routes{
+					{Destination: "192.168.0.0", Netmask: "255.255.0.0", Gateway: "10.0.0.254"},
+				},
+			},
+			"eth1": boshsettings.Network{
+				IP:      "10.1.0.5",
+				Netmask: "255.255.0.0",
+				Mac:     "aa:bb:cc:dd:ee:02",
+				DNS:     []string{"10.1.0.2"},
+			},
+			"eth2": boshsettings.Network{
+				Type: boshsettings.NetworkTypeDynamic,
+				DNS:  []string{"8.8.8.8", "8.8.4.4"},
+			},
+		}))
+	})
+
+	It("parses version 2 network config", func() {
+		networks, err := ParseNoCloudNetworkConfig([]byte(`
+version: 2
+ethernets:
+  id0:
+    match:
+      macaddress: "AA:BB:CC:DD:EE:01"
+    set-name: eth0
+    addresses: [10.0.0.5/24]
+    nameservers:
+      addresses: [10.0.0.2]
+    routes:
+    - to: default
+      via: 10.0.0.1
+    - to: 192.168.0.0/16
+      via: 10.0.0.254
+  eth1:
+    dhcp4: true
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(networks).To(Equal(boshsettings.Networks{
+			"eth0": boshsettings.Network{
+				IP:      "10.0.0.5",
+				Netmask: "255.255.255.0",
+				Gateway: "10.0.0.1",
+				Mac:     "aa:bb:cc:dd:ee:01",
+				DNS:     []string{"10.0.0.2"},
+				Default: []string{"dns", "gateway"},
+				Routes: boshsettings.Routes{
+					{Destination: "192.168.0.0", Netmask: "255.255.0.0", Gateway: "10.0.0.254"},
+				},
+			},
+			"eth1": boshsettings.Network{
+				Type: boshsettings.NetworkTypeDynamic,
+			},
+		}))
+	})
+
+	It("returns an error for unsupported versions", func() {
+		_, err := ParseNoCloudNetworkConfig([]byte("version: 3\n"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unsupported network config version '3'"))
+	})
+
+	It("returns an error for invalid addresses", func() {
+		_, err := ParseNoCloudNetworkConfig([]byte(`
+version: 2
+ethernets:
+  eth0:
+    addresses: [fake-address]
+`))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing address of interface 'eth0'"))
+	})
+})
@@ -0,0 +1,216 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
+)
+
+const (
+	NoCloudDefaultMetaDataPath      = "meta-data"
+	NoCloudDefaultUserDataPath      = "user-data"
+	NoCloudDefaultNetworkConfigPath = "network-config"
+
+	noCloudDefaultAttempts = 1
+	noCloudRetryDelay      = 5 * time.Second
+)
+
+// NoCloudDefaultDiskPaths are volumes labelled as required by cloud-init NoCloud datasource
+var NoCloudDefaultDiskPaths = []string{"/dev/disk/by-label/cidata", "/dev/disk/by-label/CIDATA"}
+
+// NoCloudMetadataContentsType is the YAML meta-data of a NoCloud seed;
+// public keys are either a list of keys or OpenStack-style map
+type NoCloudMetadataContentsType struct {
+	InstanceID    string    `yaml:"instance-id"`
+	LocalHostname string    `yaml:"local-hostname"`
+	PublicKeys    yaml.Node `yaml:"public-keys"`
+}
+
+type noCloudSeed struct {
+	metadata      []byte
+	userData      []byte
+	networkConfig []byte
+}
+
+// NoCloudSettingsSource reads settings from cloud-init NoCloud seed volume.
+// User data contains agent settings; networks described by network-config
+// are only used if settings do not specify any networks.
+type NoCloudSettingsSource struct {
+	diskPaths         []string
+	metadataPath      string
+	userDataPath      string
+	networkConfigPath string
+
+	attempts   int
+	retryDelay time.Duration
+
+	platform boshplatform.Platform
+
+	seed *noCloudSeed
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewNoCloudSettingsSource(
+	diskPaths []string,
+	metadataPath string,
+	userDataPath string,
+	networkConfigPath string,
+	attempts int,
+	retryDelay time.Duration,
+	platform boshplatform.Platform,
+	logger boshlog.Logger,
+) *NoCloudSettingsSource {
+	if len(diskPaths) == 0 {
+		diskPaths = NoCloudDefaultDiskPaths
+	}
+	if metadataPath == "" {
+		metadataPath = NoCloudDefaultMetaDataPath
+	}
+	if userDataPath == "" {
+		userDataPath = NoCloudDefaultUserDataPath
+	}
+	if networkConfigPath == "" {
+		networkConfigPath = NoCloudDefaultNetworkConfigPath
+	}
+	if attempts < 1 {
+		attempts = noCloudDefaultAttempts
+	}
+
+	return &NoCloudSettingsSource{
+		diskPaths:         diskPaths,
+		metadataPath:      metadataPath,
+		userDataPath:      userDataPath,
+		networkConfigPath: networkConfigPath,
+
+		attempts:   attempts,
+		retryDelay: retryDelay,
+
+		platform: platform,
+
+		logTag: "NoCloudSettingsSource",
+		logger: logger,
+	}
+}
+
+func (s *NoCloudSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
+	seed, err := s.loadSeed()
+	if err != nil {
+		return "", err
+	}
+
+	var metadata NoCloudMetadataContentsType
+	err = yaml.Unmarshal(seed.metadata, &metadata)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing NoCloud metadata from '%s'", s.metadataPath)
+	}
+
+	return firstNoCloudPublicKey(metadata.PublicKeys), nil
+}
+
+func (s *NoCloudSettingsSource) Settings() (boshsettings.Settings, error) {
+	seed, err := s.loadSeed()
+	if err != nil {
+		return boshsettings.Settings{}, err
+	}
+
+	var settings boshsettings.Settings
+	err = json.Unmarshal(seed.userData, &settings)
+	if err != nil {
+		return boshsettings.Settings{}, bosherr.WrapErrorf(
+			err, "Parsing NoCloud settings from '%s'", s.userDataPath)
+	}
+
+	if len(settings.Networks) == 0 && len(seed.networkConfig) > 0 {
+		settings.Networks, err = ParseNoCloudNetworkConfig(seed.networkConfig)
+		if err != nil {
+			return boshsettings.Settings{}, bosherr.WrapErrorf(
+				err, "Parsing NoCloud network config from '%s'", s.networkConfigPath)
+		}
+	}
+
+	return settings, nil
+}
+
+// loadSeed retries reading all disk paths since seed volume may be attached after boot
+func (s *NoCloudSettingsSource) loadSeed() (*noCloudSeed, error) {
+	if s.seed != nil {
+		return s.seed, nil
+	}
+
+	var seed *noCloudSeed
+
+	retryable := boshretry.NewRetryable(func() (bool, error) {
+		var err error
+		seed, err = s.loadSeedFromDisks()
+		return err != nil, err
+	})
+
+	err := boshretry.NewAttemptRetryStrategy(s.attempts, s.retryDelay, retryable, s.logger).Try()
+	if err != nil {
+		return nil, err
+	}
+
+	s.seed = seed
+
+	return seed, nil
+}
+
+func (s *NoCloudSettingsSource) loadSeedFromDisks() (*noCloudSeed, error) {
+	var err error
+	var contents [][]byte
+
+	for _, diskPath := range s.diskPaths {
+		contents, err = s.platform.GetFilesContentsFromDisk(diskPath, []string{s.metadataPath, s.userDataPath})
+		if err != nil {
+			s.logger.Warn(s.logTag, "Failed to load NoCloud seed from %s - %s", diskPath, err.Error())
+			continue
+		}
+
+		s.logger.Debug(s.logTag, "Successfully loaded NoCloud seed from '%s'", diskPath)
+
+		seed := &noCloudSeed{metadata: contents[0], userData: contents[1]}
+
+		// Network config is optional in NoCloud seed
+		contents, err = s.platform.GetFilesContentsFromDisk(diskPath, []string{s.networkConfigPath})
+		if err == nil {
+			seed.networkConfig = contents[0]
+		} else {
+			s.logger.Debug(s.logTag, "Not using NoCloud network config from '%s': %s", diskPath, err.Error())
+		}
+
+		return seed, nil
+	}
+
+	return nil, bosherr.WrapError(err, "Loading NoCloud seed")
+}
+
+func firstNoCloudPublicKey(node yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value
+
+	case yaml.SequenceNode:
+		if len(node.Content) > 0 {
+			return node.Content[0].Value
+		}
+
+	case yaml.MappingNode:
+		var keys map[string]PublicKeyType
+		if node.Decode(&keys) == nil {
+			if firstPublicKey, ok := keys["0"]; ok {
+				return firstPublicKey["openssh-key"]
+			}
+		}
+	}
+
+	return ""
+}
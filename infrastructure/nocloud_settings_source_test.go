@@ -0,0 +1,226 @@
+package infrastructure_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("NoCloudSettingsSource", func() {
+	var (
+		platform *platformfakes.FakePlatform
+		attempts int
+		source   *NoCloudSettingsSource
+	)
+
+	const networkConfig = `
+version: 2
+ethernets:
+  eth0:
+    match:
+      macaddress: "AA:BB:CC:DD:EE:FF"
+    addresses: [10.0.0.5/24]
+    gateway4: 10.0.0.1
+`
+
+	// seed returns contents of requested files like GetFilesContentsFromDisk would
+	seed := func(files map[string]string) func(string, []string) ([][]byte, error) {
+		return func(_ string, fileNames []string) ([][]byte, error) {
+			contents := [][]byte{}
+			for _, fileName := range fileNames {
+				content, ok := files[fileName]
+				if !ok {
+					return nil, errors.New("fake-missing-file-err")
+				}
+				contents = append(contents, []byte(content))
+			}
+			return contents, nil
+		}
+	}
+
+	BeforeEach(func() {
+		platform = &platformfakes.FakePlatform{}
+		attempts = 1
+	})
+
+	JustBeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		source = NewNoCloudSettingsSource([]string{"/fake-disk-path-1", "/fake-disk-path-2"}, "", "", "", attempts, 0, platform, logger)
+	})
+
+	Describe("PublicSSHKeyForUsername", func() {
+		It("returns first public key listed in meta-data", func() {
+			platform.GetFilesContentsFromDiskStub = seed(map[string]string{
+				"meta-data": "instance-id: fake-id\npublic-keys:\n- fake-openssh-key-1\n- fake-openssh-key-2\n",
+				"user-data": "{}",
+			})
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("fake-openssh-key-1"))
+		})
+
+		It("returns public key from OpenStack-style meta-data", func() {
+			platform.GetFilesContentsFromDiskStub = seed(map[string]string{
+				"meta-data": `{"public-keys": {"0": {"openssh-key": "fake-openssh-key"}}}`,
+				"user-data": "{}",
+			})
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("fake-openssh-key"))
+		})
+
+		It("returns an empty string when meta-data does not contain public keys", func() {
+			platform.GetFilesContentsFromDiskStub = seed(map[string]string{
+				"meta-data": "instance-id: fake-id\n",
+				"user-data": "{}",
+			})
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal(""))
+		})
+	})
+
+	Describe("Settings", func() {
+		It("returns settings read from user-data on the seed volume", func() {
+			platform.GetFilesContentsFromDiskStub = seed(map[string]string{
+				"meta-data": "instance-id: fake-id\n",
+				"user-data": `{"agent_id": "123"}`,
+			})
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.AgentID).To(Equal("123"))
+			Expect(settings.Networks).To(BeEmpty())
+
+			diskPath, fileNames := platform.GetFilesContentsFromDiskArgsForCall(0)
+			Expect(diskPath).To(Equal("/fake-disk-path-1"))
+			Expect(fileNames).To(Equal([]string{"meta-data", "user-data"}))
+		})
+
+		It("uses networks from network-config when settings do not specify networks", func() {
+			platform.GetFilesContentsFromDiskStub = seed(map[string]string{
+				"meta-data":      "instance-id: fake-id\n",
+				"user-data":      `{"agent_id": "123"}`,
+				"network-config": networkConfig,
+			})
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.Networks).To(Equal(boshsettings.Networks{
+				"eth0": boshsettings.Network{
+					IP:      "10.0.0.5",
+					Netmask: "255.255.255.0",
+					Gateway: "10.0.0.1",
+					Mac:     "aa:bb:cc:dd:ee:ff",
+					Default: []string{"dns", "gateway"},
+				},
+			}))
+		})
+
+		It("prefers networks specified in settings over network-config", func() {
+			platform.GetFilesContentsFromDiskStub = seed(map[string]string{
+				"meta-data":      "instance-id: fake-id\n",
+				"user-data":      `{"networks": {"fake-net": {"type": "dynamic"}}}`,
+				"network-config": networkConfig,
+			})
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.Networks).To(HaveLen(1))
+			Expect(settings.Networks).To(HaveKey("fake-net"))
+		})
+
+		It("returns an error if network-config cannot be parsed", func() {
+			platform.GetFilesContentsFromDiskStub = seed(map[string]string{
+				"meta-data":      "instance-id: fake-id\n",
+				"user-data":      "{}",
+				"network-config": "version: 3\n",
+			})
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unsupported network config version '3'"))
+		})
+
+		It("returns an error if user-data does not contain settings", func() {
+			platform.GetFilesContentsFromDiskStub = seed(map[string]string{
+				"meta-data": "instance-id: fake-id\n",
+				"user-data": "#cloud-config\n",
+			})
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing NoCloud settings from 'user-data'"))
+		})
+
+		It("tries to load seed from multiple potential disk locations", func() {
+			platform.GetFilesContentsFromDiskReturnsOnCall(0, nil, errors.New("fake-read-disk-error"))
+			platform.GetFilesContentsFromDiskReturnsOnCall(1, [][]byte{[]byte("{}"), []byte(`{"agent_id": "123"}`)}, nil)
+			platform.GetFilesContentsFromDiskReturnsOnCall(2, nil, errors.New("fake-missing-file-err"))
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.AgentID).To(Equal("123"))
+
+			diskPath, _ := platform.GetFilesContentsFromDiskArgsForCall(1)
+			Expect(diskPath).To(Equal("/fake-disk-path-2"))
+		})
+
+		It("reads seed volume only once", func() {
+			platform.GetFilesContentsFromDiskStub = seed(map[string]string{
+				"meta-data": "instance-id: fake-id\n",
+				"user-data": "{}",
+			})
+
+			_, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(platform.GetFilesContentsFromDiskCallCount()).To(Equal(2))
+		})
+
+		It("returns an error if seed cannot be loaded from any disk", func() {
+			platform.GetFilesContentsFromDiskReturnsOnCall(0, nil, errors.New("fake-read-disk-error-1"))
+			platform.GetFilesContentsFromDiskReturnsOnCall(1, nil, errors.New("fake-read-disk-error-2"))
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-read-disk-error-2"))
+			Expect(platform.GetFilesContentsFromDiskCallCount()).To(Equal(2))
+		})
+
+		Context("when multiple attempts are allowed", func() {
+			BeforeEach(func() {
+				attempts = 3
+			})
+
+			It("retries until seed volume becomes available", func() {
+				platform.GetFilesContentsFromDiskReturns(nil, errors.New("fake-read-disk-error"))
+				platform.GetFilesContentsFromDiskReturnsOnCall(4, [][]byte{[]byte("{}"), []byte(`{"agent_id": "123"}`)}, nil)
+
+				settings, err := source.Settings()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(settings.AgentID).To(Equal("123"))
+			})
+
+			It("returns an error once all attempts failed", func() {
+				platform.GetFilesContentsFromDiskReturns(nil, errors.New("fake-read-disk-error"))
+
+				_, err := source.Settings()
+				Expect(err).To(HaveOccurred())
+				Expect(platform.GetFilesContentsFromDiskCallCount()).To(Equal(6))
+			})
+		})
+	})
+})
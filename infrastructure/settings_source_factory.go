@@ -73,6 +73,19 @@ type InstanceMetadataSourceOptions struct {
 
 func (o InstanceMetadataSourceOptions) sourceOptionsInterface() {}
 
+type NoCloudSourceOptions struct {
+	DiskPaths []string
+
+	MetaDataPath      string
+	UserDataPath      string
+	NetworkConfigPath string
+
+	// Number of times seed volume is looked for before falling back to next source
+	Attempts int
+}
+
+func (o NoCloudSourceOptions) sourceOptionsInterface() {}
+
 type SettingsSourceFactory struct {
 	options  SettingsOptions
 	platform boshplat.Platform
@@ -145,6 +158,9 @@ func (f SettingsSourceFactory) buildWithRegistry() (boshsettings.Source, error)
 
 		case InstanceMetadataSourceOptions:
 			return nil, bosherr.Error("Instance Metadata source is not supported when registry is used")
+
+		case NoCloudSourceOptions:
+			return nil, bosherr.Error("NoCloud source is not supported when registry is used")
 		}
 		metadataServices = append(metadataServices, metadataService)
 	}
@@ -196,6 +212,18 @@ func (f SettingsSourceFactory) buildWithoutRegistry() (boshsettings.Source, erro
 				f.platform,
 				f.logger,
 			)
+
+		case NoCloudSourceOptions:
+			settingsSource = NewNoCloudSettingsSource(
+				typedOpts.DiskPaths,
+				typedOpts.MetaDataPath,
+				typedOpts.UserDataPath,
+				typedOpts.NetworkConfigPath,
+				typedOpts.Attempts,
+				noCloudRetryDelay,
+				f.platform,
+				f.logger,
+			)
 		}
 
 		settingsSources = append(settingsSources, settingsSource)
@@ -238,6 +266,10 @@ func (s *SourceOptionsSlice) UnmarshalJSON(data []byte) error {
 				var o CDROMSourceOptions
 				err, opts = mapstruc.Decode(m, &o), o
 
+			case optType == "NoCloud":
+				var o NoCloudSourceOptions
+				err, opts = mapstruc.Decode(m, &o), o
+
 			default:
 				err = bosherr.Errorf("Unknown source type '%s'", optType)
 			}
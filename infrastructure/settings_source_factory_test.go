@@ -2,6 +2,7 @@ package infrastructure_test
 
 import (
 	"reflect"
+	"time"
 
 	. "github.com/cloudfoundry/bosh-agent/infrastructure"
 	. "github.com/onsi/ginkgo"
@@ -134,6 +135,20 @@ var _ = Describe("SettingsSourceFactory", func() {
 						Expect(err.Error()).To(ContainSubstring("CDROM source is not supported when registry is used"))
 					})
 				})
+
+				Context("when using NoCloud source", func() {
+					BeforeEach(func() {
+						options.Sources = []SourceOptions{
+							NoCloudSourceOptions{},
+						}
+					})
+
+					It("returns error because it is not supported", func() {
+						_, err := factory.New()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("NoCloud source is not supported when registry is used"))
+					})
+				})
 			}
 
 			Context("when UseServerName is set to true", func() {
@@ -251,6 +266,39 @@ var _ = Describe("SettingsSourceFactory", func() {
 					Expect(settingsSource).To(Equal(multiSettingsSource))
 				})
 			})
+
+			Context("when using NoCloud source", func() {
+				BeforeEach(func() {
+					options = SettingsOptions{
+						Sources: []SourceOptions{
+							NoCloudSourceOptions{
+								DiskPaths: []string{"/fake-disk-path"},
+								Attempts:  3,
+							},
+						},
+					}
+				})
+
+				It("returns a settings source that uses NoCloud seed to fetch settings", func() {
+					noCloudSettingsSource := NewNoCloudSettingsSource(
+						[]string{"/fake-disk-path"},
+						"meta-data",
+						"user-data",
+						"network-config",
+						3,
+						5*time.Second,
+						platform,
+						logger,
+					)
+
+					multiSettingsSource, err := NewMultiSettingsSource(noCloudSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := factory.New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
+				})
+			})
 		})
 	})
 })
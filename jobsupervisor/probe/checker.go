@@ -0,0 +1,100 @@
+package probe
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const scriptKillGracePeriod = 5 * time.Second
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Checker
+
+type Checker interface {
+	// Check returns an error when probe does not pass within its timeout
+	Check(spec Spec) error
+}
+
+type checker struct {
+	runner boshsys.CmdRunner
+}
+
+func NewChecker(runner boshsys.CmdRunner) Checker {
+	return checker{runner: runner}
+}
+
+func (c checker) Check(spec Spec) error {
+	switch spec.Type {
+	case TypeHTTP:
+		return c.checkHTTP(spec)
+	case TypeTCP:
+		return c.checkTCP(spec)
+	case TypeScript:
+		return c.checkScript(spec)
+	default:
+		return bosherr.Errorf("Unknown probe type '%s'", spec.Type)
+	}
+}
+
+func (c checker) checkHTTP(spec Spec) error {
+	client := &http.Client{
+		Timeout: spec.Timeout(),
+
+		// Redirects count as passing, just like monit's http checks
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(spec.URL)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Requesting %s", spec.URL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return bosherr.Errorf("Requesting %s returned status %d", spec.URL, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (c checker) checkTCP(spec Spec) error {
+	conn, err := net.DialTimeout("tcp", spec.Address, spec.Timeout())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Connecting to %s", spec.Address)
+	}
+
+	return conn.Close()
+}
+
+func (c checker) checkScript(spec Spec) error {
+	process, err := c.runner.RunComplexCommandAsync(boshsys.Command{
+		Name:  spec.Path,
+		Args:  spec.Args,
+		Quiet: true,
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running %s", spec.Path)
+	}
+
+	resultCh := process.Wait()
+	timer := time.NewTimer(spec.Timeout())
+	defer timer.Stop()
+
+	select {
+	case result := <-resultCh:
+		if result.Error != nil {
+			return bosherr.WrapErrorf(result.Error, "Running %s", spec.Path)
+		}
+		return nil
+
+	case <-timer.C:
+		_ = process.TerminateNicely(scriptKillGracePeriod)
+		go func() { <-resultCh }()
+		return bosherr.Errorf("Running %s timed out after %s", spec.Path, spec.Timeout())
+	}
+}
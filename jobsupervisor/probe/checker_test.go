@@ -0,0 +1,73 @@
+package probe_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("checker", func() {
+	var checker Checker
+
+	BeforeEach(func() {
+		checker = NewChecker(boshsys.NewExecCmdRunner(boshlog.NewLogger(boshlog.LevelNone)))
+	})
+
+	Describe("http", func() {
+		It("passes on successful responses and fails otherwise", func() {
+			status := http.StatusOK
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			spec := Spec{Type: TypeHTTP, URL: server.URL, TimeoutSeconds: 1}
+			Expect(checker.Check(spec)).To(Succeed())
+
+			status = http.StatusInternalServerError
+			err := checker.Check(spec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("returned status 500"))
+		})
+	})
+
+	Describe("tcp", func() {
+		It("passes when connection can be made", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+
+			spec := Spec{Type: TypeTCP, Address: listener.Addr().String(), TimeoutSeconds: 1}
+			Expect(checker.Check(spec)).To(Succeed())
+
+			Expect(listener.Close()).To(Succeed())
+			Expect(checker.Check(spec)).ToNot(Succeed())
+		})
+	})
+
+	Describe("script", func() {
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("uses unix commands")
+			}
+		})
+
+		It("passes when script exits with 0", func() {
+			Expect(checker.Check(Spec{Type: TypeScript, Path: "true", TimeoutSeconds: 1})).To(Succeed())
+			Expect(checker.Check(Spec{Type: TypeScript, Path: "false", TimeoutSeconds: 1})).ToNot(Succeed())
+		})
+
+		It("fails when script times out", func() {
+			err := checker.Check(Spec{Type: TypeScript, Path: "sleep", Args: []string{"10"}, TimeoutSeconds: 1})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("timed out"))
+		})
+	})
+})
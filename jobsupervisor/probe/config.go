@@ -0,0 +1,144 @@
+package probe
+
+import (
+	"encoding/json"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	// ConfigFileName is looked up in each job's directory
+	ConfigFileName = "probes.json"
+
+	KindLiveness  = "liveness"
+	KindReadiness = "readiness"
+
+	TypeHTTP   = "http"
+	TypeTCP    = "tcp"
+	TypeScript = "script"
+
+	defaultIntervalSeconds     = 10
+	defaultTimeoutSeconds      = 5
+	defaultFailureThreshold    = 3
+	defaultStartTimeoutSeconds = 60
+)
+
+// Config is read from probes.json rendered into a job's directory
+type Config struct {
+	// GateStart makes start wait until readiness probes pass
+	GateStart           bool `json:"gate_start"`
+	StartTimeoutSeconds int  `json:"start_timeout_seconds"`
+
+	Probes []Spec `json:"probes"`
+}
+
+type Spec struct {
+	Name string `json:"name"`
+
+	// Readiness probes are pending until they pass for the first time;
+	// liveness probes only matter once they fail. Defaults to liveness.
+	Kind string `json:"kind"`
+
+	// One of http, tcp or script
+	Type string `json:"type"`
+
+	// HTTP probes pass on 2xx and 3xx responses to a GET of URL
+	URL string `json:"url"`
+
+	// TCP probes pass when a connection to Address can be made
+	Address string `json:"address"`
+
+	// Script probes pass when Path exits with 0
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+
+	IntervalSeconds  int `json:"interval_seconds"`
+	TimeoutSeconds   int `json:"timeout_seconds"`
+	FailureThreshold int `json:"failure_threshold"`
+}
+
+func LoadConfig(fs boshsys.FileSystem, path string) (Config, error) {
+	var config Config
+
+	bytes, err := fs.ReadFile(path)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Reading probes config")
+	}
+
+	err = json.Unmarshal(bytes, &config)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Unmarshalling probes config")
+	}
+
+	if config.StartTimeoutSeconds == 0 {
+		config.StartTimeoutSeconds = defaultStartTimeoutSeconds
+	}
+
+	for i := range config.Probes {
+		err = config.Probes[i].validateAndDefault()
+		if err != nil {
+			return config, bosherr.WrapErrorf(err, "Validating probe %d", i)
+		}
+	}
+
+	return config, nil
+}
+
+func (c Config) StartTimeout() time.Duration {
+	return time.Duration(c.StartTimeoutSeconds) * time.Second
+}
+
+func (s Spec) Interval() time.Duration {
+	return time.Duration(s.IntervalSeconds) * time.Second
+}
+
+func (s Spec) Timeout() time.Duration {
+	return time.Duration(s.TimeoutSeconds) * time.Second
+}
+
+func (s *Spec) validateAndDefault() error {
+	if s.Name == "" {
+		return bosherr.Error("Missing name")
+	}
+
+	switch s.Kind {
+	case "":
+		s.Kind = KindLiveness
+	case KindLiveness, KindReadiness:
+	default:
+		return bosherr.Errorf("Unknown kind '%s'", s.Kind)
+	}
+
+	switch s.Type {
+	case TypeHTTP:
+		if s.URL == "" {
+			return bosherr.Error("Missing url")
+		}
+	case TypeTCP:
+		if s.Address == "" {
+			return bosherr.Error("Missing address")
+		}
+	case TypeScript:
+		if s.Path == "" {
+			return bosherr.Error("Missing path")
+		}
+	default:
+		return bosherr.Errorf("Unknown type '%s'", s.Type)
+	}
+
+	if s.IntervalSeconds == 0 {
+		s.IntervalSeconds = defaultIntervalSeconds
+	}
+
+	if s.TimeoutSeconds == 0 {
+		s.TimeoutSeconds = defaultTimeoutSeconds
+	}
+
+	if s.FailureThreshold == 0 {
+		s.FailureThreshold = defaultFailureThreshold
+	}
+
+	return nil
+}
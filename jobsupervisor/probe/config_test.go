@@ -0,0 +1,54 @@
+package probe_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("LoadConfig", func() {
+	var fs *fakesys.FakeFileSystem
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+	})
+
+	It("fills in defaults", func() {
+		Expect(fs.WriteFileString("/probes.json", `{
+			"gate_start": true,
+			"probes": [
+				{"name": "http", "type": "http", "url": "http://127.0.0.1:8080/health"},
+				{"name": "ready", "kind": "readiness", "type": "script", "path": "/bin/ready", "interval_seconds": 1}
+			]
+		}`)).To(Succeed())
+
+		config, err := LoadConfig(fs, "/probes.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config).To(Equal(Config{
+			GateStart:           true,
+			StartTimeoutSeconds: 60,
+			Probes: []Spec{
+				{Name: "http", Kind: KindLiveness, Type: TypeHTTP, URL: "http://127.0.0.1:8080/health", IntervalSeconds: 10, TimeoutSeconds: 5, FailureThreshold: 3},
+				{Name: "ready", Kind: KindReadiness, Type: TypeScript, Path: "/bin/ready", IntervalSeconds: 1, TimeoutSeconds: 5, FailureThreshold: 3},
+			},
+		}))
+	})
+
+	It("returns an error for invalid probes", func() {
+		for _, probe := range []string{
+			`{"type": "tcp", "address": "127.0.0.1:80"}`,
+			`{"name": "p", "type": "udp"}`,
+			`{"name": "p", "type": "tcp"}`,
+			`{"name": "p", "type": "http"}`,
+			`{"name": "p", "type": "script"}`,
+			`{"name": "p", "kind": "startup", "type": "tcp", "address": "127.0.0.1:80"}`,
+		} {
+			Expect(fs.WriteFileString("/probes.json", `{"probes": [`+probe+`]}`)).To(Succeed())
+
+			_, err := LoadConfig(fs, "/probes.json")
+			Expect(err).To(HaveOccurred(), probe)
+		}
+	})
+})
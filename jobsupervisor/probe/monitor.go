@@ -0,0 +1,335 @@
+package probe
+
+import (
+	"sort"
+	"sync"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	monitorLogTag = "probeMonitor"
+
+	StatePending = "pending"
+	StatePassing = "passing"
+	StateFailing = "failing"
+)
+
+// Result is reported per probe in get_state
+type Result struct {
+	Job   string `json:"job"`
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	LastError           string `json:"last_error,omitempty"`
+	LastCheckedAt       int64  `json:"last_checked_at,omitempty"`
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Monitor
+
+type Monitor interface {
+	AddJob(jobName string, config Config)
+	RemoveAllJobs()
+
+	// Start (re)starts probing from pending state; Stop stops it
+	Start()
+	Stop()
+
+	// State is failing if any probe is failing, pending if any readiness
+	// probe has not passed yet and passing otherwise.
+	State() string
+	Results() []Result
+
+	// WaitForReadiness blocks until probes of jobs with gate_start pass
+	WaitForReadiness() error
+}
+
+type monitor struct {
+	checker     Checker
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	lock    sync.Mutex
+	jobs    []job
+	results map[probeKey]*Result
+	stopCh  chan struct{}
+
+	// Closed and replaced whenever a result changes
+	changedCh chan struct{}
+}
+
+type job struct {
+	name   string
+	config Config
+}
+
+type probeKey struct {
+	job  string
+	name string
+}
+
+func NewMonitor(checker Checker, timeService clock.Clock, logger boshlog.Logger) Monitor {
+	return &monitor{
+		checker:     checker,
+		timeService: timeService,
+		logger:      logger,
+		results:     map[probeKey]*Result{},
+		changedCh:   make(chan struct{}),
+	}
+}
+
+func (m *monitor) AddJob(jobName string, config Config) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.jobs = append(m.jobs, job{name: jobName, config: config})
+
+	for _, spec := range config.Probes {
+		m.results[probeKey{jobName, spec.Name}] = m.pendingResult(jobName, spec)
+	}
+}
+
+func (m *monitor) RemoveAllJobs() {
+	m.stop()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.jobs = nil
+	m.results = map[probeKey]*Result{}
+	m.notifyChanged()
+}
+
+func (m *monitor) Start() {
+	m.stop()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stopCh := make(chan struct{})
+	m.stopCh = stopCh
+
+	for _, j := range m.jobs {
+		for _, spec := range j.config.Probes {
+			m.results[probeKey{j.name, spec.Name}] = m.pendingResult(j.name, spec)
+			go m.run(j.name, spec, stopCh)
+		}
+	}
+
+	m.notifyChanged()
+}
+
+func (m *monitor) Stop() {
+	m.stop()
+}
+
+func (m *monitor) State() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.state(nil)
+}
+
+func (m *monitor) Results() []Result {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	results := []Result{}
+	for _, result := range m.results {
+		results = append(results, *result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Job != results[j].Job {
+			return results[i].Job < results[j].Job
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	return results
+}
+
+func (m *monitor) WaitForReadiness() error {
+	m.lock.Lock()
+	jobs := m.jobs
+	m.lock.Unlock()
+
+	for _, j := range jobs {
+		if !j.config.GateStart {
+			continue
+		}
+
+		err := m.waitForJob(j)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *monitor) waitForJob(j job) error {
+	m.logger.Debug(monitorLogTag, "Waiting for probes of job %s to pass", j.name)
+
+	timer := m.timeService.NewTimer(j.config.StartTimeout())
+	defer timer.Stop()
+
+	for {
+		m.lock.Lock()
+		state := m.state(&j.name)
+		changedCh := m.changedCh
+		m.lock.Unlock()
+
+		switch state {
+		case StatePassing:
+			return nil
+		case StateFailing:
+			return bosherr.Errorf("Probes of job %s are failing: %s", j.name, m.failures(j.name))
+		}
+
+		select {
+		case <-changedCh:
+		case <-timer.C():
+			return bosherr.Errorf("Timed out waiting for probes of job %s to pass after %s", j.name, j.config.StartTimeout())
+		}
+	}
+}
+
+func (m *monitor) run(jobName string, spec Spec, stopCh chan struct{}) {
+	defer m.logger.HandlePanic("Probe Monitor")
+
+	for {
+		err := m.checker.Check(spec)
+
+		m.record(jobName, spec, err, stopCh)
+
+		timer := m.timeService.NewTimer(spec.Interval())
+
+		select {
+		case <-stopCh:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+	}
+}
+
+func (m *monitor) record(jobName string, spec Spec, err error, stopCh chan struct{}) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Results of a stopped run must not leak into the next one
+	select {
+	case <-stopCh:
+		return
+	default:
+	}
+
+	result, found := m.results[probeKey{jobName, spec.Name}]
+	if !found {
+		return
+	}
+
+	result.LastCheckedAt = m.timeService.Now().Unix()
+
+	if err == nil {
+		result.State = StatePassing
+		result.ConsecutiveFailures = 0
+		result.LastError = ""
+	} else {
+		m.logger.Debug(monitorLogTag, "Probe %s of job %s failed: %s", spec.Name, jobName, err.Error())
+
+		result.ConsecutiveFailures++
+		result.LastError = err.Error()
+
+		// Readiness probes stay pending until they pass for the first time
+		// so that slowly starting jobs are only limited by start timeout
+		pendingReadiness := result.State == StatePending && spec.Kind == KindReadiness
+
+		if result.ConsecutiveFailures >= spec.FailureThreshold && !pendingReadiness {
+			if result.State != StateFailing {
+				m.logger.Warn(monitorLogTag, "Probe %s of job %s is failing: %s", spec.Name, jobName, err.Error())
+			}
+			result.State = StateFailing
+		}
+	}
+
+	m.notifyChanged()
+}
+
+// state must be called with lock held; job limits it to a single job
+func (m *monitor) state(jobName *string) string {
+	state := StatePassing
+
+	for _, result := range m.results {
+		if jobName != nil && result.Job != *jobName {
+			continue
+		}
+
+		switch {
+		case result.State == StateFailing:
+			return StateFailing
+		case result.State == StatePending && result.Kind == KindReadiness:
+			state = StatePending
+		case result.State == StatePending && jobName != nil && !m.hasReadinessProbes(*jobName):
+			// Jobs without readiness probes wait for liveness probes to pass
+			state = StatePending
+		}
+	}
+
+	return state
+}
+
+func (m *monitor) hasReadinessProbes(jobName string) bool {
+	for _, result := range m.results {
+		if result.Job == jobName && result.Kind == KindReadiness {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *monitor) failures(jobName string) string {
+	var failures string
+
+	for _, result := range m.Results() {
+		if result.Job == jobName && result.State == StateFailing {
+			if failures != "" {
+				failures += ", "
+			}
+			failures += result.Name + ": " + result.LastError
+		}
+	}
+
+	return failures
+}
+
+func (m *monitor) stop() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.stopCh != nil {
+		close(m.stopCh)
+		m.stopCh = nil
+	}
+}
+
+func (m *monitor) notifyChanged() {
+	close(m.changedCh)
+	m.changedCh = make(chan struct{})
+}
+
+func (m *monitor) pendingResult(jobName string, spec Spec) *Result {
+	return &Result{
+		Job:   jobName,
+		Name:  spec.Name,
+		Kind:  spec.Kind,
+		Type:  spec.Type,
+		State: StatePending,
+	}
+}
@@ -0,0 +1,208 @@
+package probe_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/probe/probefakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("monitor", func() {
+	var (
+		checker     *probefakes.FakeChecker
+		timeService *fakeclock.FakeClock
+		monitor     Monitor
+
+		setErr func(name string, err error)
+	)
+
+	liveness := Spec{Name: "live", Kind: KindLiveness, Type: TypeTCP, IntervalSeconds: 10, FailureThreshold: 2}
+	readiness := Spec{Name: "ready", Kind: KindReadiness, Type: TypeTCP, IntervalSeconds: 10, FailureThreshold: 2}
+
+	BeforeEach(func() {
+		// Probes of previous specs may still be running
+		var errsLock sync.Mutex
+		errs := map[string]error{}
+
+		setErr = func(name string, err error) {
+			errsLock.Lock()
+			defer errsLock.Unlock()
+			errs[name] = err
+		}
+
+		checker = &probefakes.FakeChecker{}
+		checker.CheckStub = func(spec Spec) error {
+			errsLock.Lock()
+			defer errsLock.Unlock()
+			return errs[spec.Name]
+		}
+
+		timeService = fakeclock.NewFakeClock(time.Now())
+		monitor = NewMonitor(checker, timeService, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		monitor.Stop()
+	})
+
+	It("is pending until readiness probes pass", func() {
+		setErr("ready", errors.New("not yet"))
+		monitor.AddJob("nats", Config{Probes: []Spec{liveness, readiness}})
+		Expect(monitor.State()).To(Equal(StatePending))
+
+		monitor.Start()
+		Eventually(checker.CheckCallCount).Should(Equal(2))
+		Expect(monitor.State()).To(Equal(StatePending))
+
+		setErr("ready", nil)
+		Eventually(func() string {
+			timeService.WaitForWatcherAndIncrement(10 * time.Second)
+			return monitor.State()
+		}).Should(Equal(StatePassing))
+	})
+
+	It("is failing after failure threshold is reached", func() {
+		monitor.AddJob("nats", Config{Probes: []Spec{liveness}})
+		monitor.Start()
+		Eventually(func() string { return monitor.Results()[0].State }).Should(Equal(StatePassing))
+
+		setErr("live", errors.New("fake-err"))
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		Eventually(checker.CheckCallCount).Should(Equal(2))
+		Expect(monitor.State()).To(Equal(StatePassing))
+
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		Eventually(monitor.State).Should(Equal(StateFailing))
+
+		Expect(monitor.Results()).To(Equal([]Result{{
+			Job:                 "nats",
+			Name:                "live",
+			Kind:                KindLiveness,
+			Type:                TypeTCP,
+			State:               StateFailing,
+			ConsecutiveFailures: 2,
+			LastError:           "fake-err",
+			LastCheckedAt:       timeService.Now().Unix(),
+		}}))
+	})
+
+	It("keeps readiness probes pending until they pass for the first time", func() {
+		setErr("ready", errors.New("not yet"))
+		monitor.AddJob("nats", Config{Probes: []Spec{readiness}})
+		monitor.Start()
+
+		for i := 1; i <= 3; i++ {
+			Eventually(checker.CheckCallCount).Should(Equal(i))
+			timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		}
+		Eventually(checker.CheckCallCount).Should(Equal(4))
+		Expect(monitor.State()).To(Equal(StatePending))
+		Expect(monitor.Results()[0].ConsecutiveFailures).To(Equal(4))
+
+		setErr("ready", nil)
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		Eventually(monitor.State).Should(Equal(StatePassing))
+
+		setErr("ready", errors.New("fake-err"))
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		Eventually(checker.CheckCallCount).Should(Equal(6))
+		timeService.WaitForWatcherAndIncrement(10 * time.Second)
+		Eventually(monitor.State).Should(Equal(StateFailing))
+	})
+
+	It("forgets probes of removed jobs", func() {
+		monitor.AddJob("nats", Config{Probes: []Spec{readiness}})
+		monitor.RemoveAllJobs()
+
+		Expect(monitor.Results()).To(BeEmpty())
+		Expect(monitor.State()).To(Equal(StatePassing))
+	})
+
+	Describe("WaitForReadiness", func() {
+		It("does not wait for jobs without gate_start", func() {
+			setErr("ready", errors.New("not yet"))
+			monitor.AddJob("nats", Config{Probes: []Spec{readiness}})
+			monitor.Start()
+
+			Expect(monitor.WaitForReadiness()).To(Succeed())
+		})
+
+		It("waits until probes of gated jobs pass", func() {
+			setErr("ready", errors.New("not yet"))
+			monitor.AddJob("nats", Config{GateStart: true, StartTimeoutSeconds: 60, Probes: []Spec{readiness}})
+			monitor.Start()
+
+			doneCh := make(chan error)
+			go func() { doneCh <- monitor.WaitForReadiness() }()
+
+			Eventually(checker.CheckCallCount).Should(Equal(1))
+			Consistently(doneCh).ShouldNot(Receive())
+
+			setErr("ready", nil)
+			timeService.WaitForNWatchersAndIncrement(10*time.Second, 2)
+
+			Eventually(doneCh).Should(Receive(BeNil()))
+		})
+
+		It("waits for slowly starting jobs whose readiness probes fail more often than failure threshold", func() {
+			setErr("ready", errors.New("not yet"))
+			monitor.AddJob("nats", Config{GateStart: true, StartTimeoutSeconds: 60, Probes: []Spec{readiness}})
+			monitor.Start()
+
+			doneCh := make(chan error)
+			go func() { doneCh <- monitor.WaitForReadiness() }()
+
+			for i := 1; i <= 3; i++ {
+				Eventually(checker.CheckCallCount).Should(Equal(i))
+				timeService.WaitForNWatchersAndIncrement(10*time.Second, 2)
+			}
+			Eventually(checker.CheckCallCount).Should(Equal(4))
+			Consistently(doneCh).ShouldNot(Receive())
+
+			setErr("ready", nil)
+			timeService.WaitForNWatchersAndIncrement(10*time.Second, 2)
+
+			Eventually(doneCh).Should(Receive(BeNil()))
+		})
+
+		It("returns an error when liveness probes fail", func() {
+			setErr("live", errors.New("fake-err"))
+			setErr("ready", errors.New("not yet"))
+			monitor.AddJob("nats", Config{GateStart: true, StartTimeoutSeconds: 60, Probes: []Spec{liveness, readiness}})
+			monitor.Start()
+
+			doneCh := make(chan error)
+			go func() { doneCh <- monitor.WaitForReadiness() }()
+
+			Eventually(checker.CheckCallCount).Should(Equal(2))
+			timeService.WaitForNWatchersAndIncrement(10*time.Second, 3)
+
+			var err error
+			Eventually(doneCh).Should(Receive(&err))
+			Expect(err).To(MatchError(ContainSubstring("Probes of job nats are failing: live: fake-err")))
+		})
+
+		It("returns an error when probes do not pass in time", func() {
+			setErr("ready", errors.New("not yet"))
+			monitor.AddJob("nats", Config{GateStart: true, StartTimeoutSeconds: 5, Probes: []Spec{readiness}})
+			monitor.Start()
+
+			doneCh := make(chan error)
+			go func() { doneCh <- monitor.WaitForReadiness() }()
+
+			timeService.WaitForNWatchersAndIncrement(5*time.Second, 2)
+
+			var err error
+			Eventually(doneCh).Should(Receive(&err))
+			Expect(err).To(MatchError(ContainSubstring("Timed out waiting for probes of job nats")))
+		})
+	})
+})
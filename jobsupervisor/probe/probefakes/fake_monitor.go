@@ -0,0 +1,363 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package probefakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+)
+
+type FakeMonitor struct {
+	AddJobStub        func(string, probe.Config)
+	addJobMutex       sync.RWMutex
+	addJobArgsForCall []struct {
+		arg1 string
+		arg2 probe.Config
+	}
+	RemoveAllJobsStub        func()
+	removeAllJobsMutex       sync.RWMutex
+	removeAllJobsArgsForCall []struct {
+	}
+	ResultsStub        func() []probe.Result
+	resultsMutex       sync.RWMutex
+	resultsArgsForCall []struct {
+	}
+	resultsReturns struct {
+		result1 []probe.Result
+	}
+	resultsReturnsOnCall map[int]struct {
+		result1 []probe.Result
+	}
+	StartStub        func()
+	startMutex       sync.RWMutex
+	startArgsForCall []struct {
+	}
+	StateStub        func() string
+	stateMutex       sync.RWMutex
+	stateArgsForCall []struct {
+	}
+	stateReturns struct {
+		result1 string
+	}
+	stateReturnsOnCall map[int]struct {
+		result1 string
+	}
+	StopStub        func()
+	stopMutex       sync.RWMutex
+	stopArgsForCall []struct {
+	}
+	WaitForReadinessStub        func() error
+	waitForReadinessMutex       sync.RWMutex
+	waitForReadinessArgsForCall []struct {
+	}
+	waitForReadinessReturns struct {
+		result1 error
+	}
+	waitForReadinessReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMonitor) AddJob(arg1 string, arg2 probe.Config) {
+	fake.addJobMutex.Lock()
+	fake.addJobArgsForCall = append(fake.addJobArgsForCall, struct {
+		arg1 string
+		arg2 probe.Config
+	}{arg1, arg2})
+	stub := fake.AddJobStub
+	fake.recordInvocation("AddJob", []interface{}{arg1, arg2})
+	fake.addJobMutex.Unlock()
+	if stub != nil {
+		fake.AddJobStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeMonitor) AddJobCallCount() int {
+	fake.addJobMutex.RLock()
+	defer fake.addJobMutex.RUnlock()
+	return len(fake.addJobArgsForCall)
+}
+
+func (fake *FakeMonitor) AddJobCalls(stub func(string, probe.Config)) {
+	fake.addJobMutex.Lock()
+	defer fake.addJobMutex.Unlock()
+	fake.AddJobStub = stub
+}
+
+func (fake *FakeMonitor) AddJobArgsForCall(i int) (string, probe.Config) {
+	fake.addJobMutex.RLock()
+	defer fake.addJobMutex.RUnlock()
+	argsForCall := fake.addJobArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMonitor) RemoveAllJobs() {
+	fake.removeAllJobsMutex.Lock()
+	fake.removeAllJobsArgsForCall = append(fake.removeAllJobsArgsForCall, struct {
+	}{})
+	stub := fake.RemoveAllJobsStub
+	fake.recordInvocation("RemoveAllJobs", []interface{}{})
+	fake.removeAllJobsMutex.Unlock()
+	if stub != nil {
+		fake.RemoveAllJobsStub()
+	}
+}
+
+func (fake *FakeMonitor) RemoveAllJobsCallCount() int {
+	fake.removeAllJobsMutex.RLock()
+	defer fake.removeAllJobsMutex.RUnlock()
+	return len(fake.removeAllJobsArgsForCall)
+}
+
+func (fake *FakeMonitor) RemoveAllJobsCalls(stub func()) {
+	fake.removeAllJobsMutex.Lock()
+	defer fake.removeAllJobsMutex.Unlock()
+	fake.RemoveAllJobsStub = stub
+}
+
+func (fake *FakeMonitor) Results() []probe.Result {
+	fake.resultsMutex.Lock()
+	ret, specificReturn := fake.resultsReturnsOnCall[len(fake.resultsArgsForCall)]
+	fake.resultsArgsForCall = append(fake.resultsArgsForCall, struct {
+	}{})
+	stub := fake.ResultsStub
+	fakeReturns := fake.resultsReturns
+	fake.recordInvocation("Results", []interface{}{})
+	fake.resultsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMonitor) ResultsCallCount() int {
+	fake.resultsMutex.RLock()
+	defer fake.resultsMutex.RUnlock()
+	return len(fake.resultsArgsForCall)
+}
+
+func (fake *FakeMonitor) ResultsCalls(stub func() []probe.Result) {
+	fake.resultsMutex.Lock()
+	defer fake.resultsMutex.Unlock()
+	fake.ResultsStub = stub
+}
+
+func (fake *FakeMonitor) ResultsReturns(result1 []probe.Result) {
+	fake.resultsMutex.Lock()
+	defer fake.resultsMutex.Unlock()
+	fake.ResultsStub = nil
+	fake.resultsReturns = struct {
+		result1 []probe.Result
+	}{result1}
+}
+
+func (fake *FakeMonitor) ResultsReturnsOnCall(i int, result1 []probe.Result) {
+	fake.resultsMutex.Lock()
+	defer fake.resultsMutex.Unlock()
+	fake.ResultsStub = nil
+	if fake.resultsReturnsOnCall == nil {
+		fake.resultsReturnsOnCall = make(map[int]struct {
+			result1 []probe.Result
+		})
+	}
+	fake.resultsReturnsOnCall[i] = struct {
+		result1 []probe.Result
+	}{result1}
+}
+
+func (fake *FakeMonitor) Start() {
+	fake.startMutex.Lock()
+	fake.startArgsForCall = append(fake.startArgsForCall, struct {
+	}{})
+	stub := fake.StartStub
+	fake.recordInvocation("Start", []interface{}{})
+	fake.startMutex.Unlock()
+	if stub != nil {
+		fake.StartStub()
+	}
+}
+
+func (fake *FakeMonitor) StartCallCount() int {
+	fake.startMutex.RLock()
+	defer fake.startMutex.RUnlock()
+	return len(fake.startArgsForCall)
+}
+
+func (fake *FakeMonitor) StartCalls(stub func()) {
+	fake.startMutex.Lock()
+	defer fake.startMutex.Unlock()
+	fake.StartStub = stub
+}
+
+func (fake *FakeMonitor) State() string {
+	fake.stateMutex.Lock()
+	ret, specificReturn := fake.stateReturnsOnCall[len(fake.stateArgsForCall)]
+	fake.stateArgsForCall = append(fake.stateArgsForCall, struct {
+	}{})
+	stub := fake.StateStub
+	fakeReturns := fake.stateReturns
+	fake.recordInvocation("State", []interface{}{})
+	fake.stateMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMonitor) StateCallCount() int {
+	fake.stateMutex.RLock()
+	defer fake.stateMutex.RUnlock()
+	return len(fake.stateArgsForCall)
+}
+
+func (fake *FakeMonitor) StateCalls(stub func() string) {
+	fake.stateMutex.Lock()
+	defer fake.stateMutex.Unlock()
+	fake.StateStub = stub
+}
+
+func (fake *FakeMonitor) StateReturns(result1 string) {
+	fake.stateMutex.Lock()
+	defer fake.stateMutex.Unlock()
+	fake.StateStub = nil
+	fake.stateReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeMonitor) StateReturnsOnCall(i int, result1 string) {
+	fake.stateMutex.Lock()
+	defer fake.stateMutex.Unlock()
+	fake.StateStub = nil
+	if fake.stateReturnsOnCall == nil {
+		fake.stateReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.stateReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeMonitor) Stop() {
+	fake.stopMutex.Lock()
+	fake.stopArgsForCall = append(fake.stopArgsForCall, struct {
+	}{})
+	stub := fake.StopStub
+	fake.recordInvocation("Stop", []interface{}{})
+	fake.stopMutex.Unlock()
+	if stub != nil {
+		fake.StopStub()
+	}
+}
+
+func (fake *FakeMonitor) StopCallCount() int {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return len(fake.stopArgsForCall)
+}
+
+func (fake *FakeMonitor) StopCalls(stub func()) {
+	fake.stopMutex.Lock()
+	defer fake.stopMutex.Unlock()
+	fake.StopStub = stub
+}
+
+func (fake *FakeMonitor) WaitForReadiness() error {
+	fake.waitForReadinessMutex.Lock()
+	ret, specificReturn := fake.waitForReadinessReturnsOnCall[len(fake.waitForReadinessArgsForCall)]
+	fake.waitForReadinessArgsForCall = append(fake.waitForReadinessArgsForCall, struct {
+	}{})
+	stub := fake.WaitForReadinessStub
+	fakeReturns := fake.waitForReadinessReturns
+	fake.recordInvocation("WaitForReadiness", []interface{}{})
+	fake.waitForReadinessMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMonitor) WaitForReadinessCallCount() int {
+	fake.waitForReadinessMutex.RLock()
+	defer fake.waitForReadinessMutex.RUnlock()
+	return len(fake.waitForReadinessArgsForCall)
+}
+
+func (fake *FakeMonitor) WaitForReadinessCalls(stub func() error) {
+	fake.waitForReadinessMutex.Lock()
+	defer fake.waitForReadinessMutex.Unlock()
+	fake.WaitForReadinessStub = stub
+}
+
+func (fake *FakeMonitor) WaitForReadinessReturns(result1 error) {
+	fake.waitForReadinessMutex.Lock()
+	defer fake.waitForReadinessMutex.Unlock()
+	fake.WaitForReadinessStub = nil
+	fake.waitForReadinessReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMonitor) WaitForReadinessReturnsOnCall(i int, result1 error) {
+	fake.waitForReadinessMutex.Lock()
+	defer fake.waitForReadinessMutex.Unlock()
+	fake.WaitForReadinessStub = nil
+	if fake.waitForReadinessReturnsOnCall == nil {
+		fake.waitForReadinessReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.waitForReadinessReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMonitor) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.addJobMutex.RLock()
+	defer fake.addJobMutex.RUnlock()
+	fake.removeAllJobsMutex.RLock()
+	defer fake.removeAllJobsMutex.RUnlock()
+	fake.resultsMutex.RLock()
+	defer fake.resultsMutex.RUnlock()
+	fake.startMutex.RLock()
+	defer fake.startMutex.RUnlock()
+	fake.stateMutex.RLock()
+	defer fake.stateMutex.RUnlock()
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	fake.waitForReadinessMutex.RLock()
+	defer fake.waitForReadinessMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMonitor) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ probe.Monitor = new(FakeMonitor)
@@ -0,0 +1,167 @@
+package jobsupervisor
+
+import (
+	"path"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const probingJobSupervisorLogTag = "probingJobSupervisor"
+
+type probingJobSupervisor struct {
+	JobSupervisor
+
+	monitor probe.Monitor
+	fs      boshsys.FileSystem
+	logger  boshlog.Logger
+
+	// probedJobDirs avoids adding probes of a job once per its monit file
+	probedJobDirs map[string]bool
+}
+
+// NewProbingJobSupervisor runs health probes declared in probes.json of
+// each job while jobs are started. Failing probes make a running job
+// failing and readiness probes that have not passed yet make it starting.
+// Probes of jobs found in jobsDir are loaded right away since jobs applied
+// before agent was restarted are not added again.
+func NewProbingJobSupervisor(
+	delegate JobSupervisor,
+	monitor probe.Monitor,
+	jobsDir string,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) JobSupervisor {
+	p := &probingJobSupervisor{
+		JobSupervisor: delegate,
+		monitor:       monitor,
+		fs:            fs,
+		logger:        logger,
+		probedJobDirs: map[string]bool{},
+	}
+
+	p.loadJobs(jobsDir)
+
+	return p
+}
+
+// loadJobs resumes probing of already applied jobs which monit keeps running
+// across agent restarts and reboots without Start being called
+func (p *probingJobSupervisor) loadJobs(jobsDir string) {
+	probesPaths, err := p.fs.Glob(path.Join(jobsDir, "*", probe.ConfigFileName))
+	if err != nil {
+		p.logger.Error(probingJobSupervisorLogTag, "Finding probes of applied jobs: %s", err.Error())
+		return
+	}
+
+	if len(probesPaths) == 0 {
+		return
+	}
+
+	for _, probesPath := range probesPaths {
+		jobName := path.Base(path.Dir(probesPath))
+
+		err = p.addProbes(jobName, probesPath)
+		if err != nil {
+			p.logger.Error(probingJobSupervisorLogTag, "Restoring probes: %s", err.Error())
+		}
+	}
+
+	p.monitor.Start()
+}
+
+func (p *probingJobSupervisor) Start() error {
+	err := p.JobSupervisor.Start()
+	if err != nil {
+		return err
+	}
+
+	p.monitor.Start()
+
+	err = p.monitor.WaitForReadiness()
+	if err != nil {
+		return bosherr.WrapError(err, "Waiting for jobs to become ready")
+	}
+
+	return nil
+}
+
+func (p *probingJobSupervisor) Stop() error {
+	p.monitor.Stop()
+	return p.JobSupervisor.Stop()
+}
+
+func (p *probingJobSupervisor) StopAndWait() error {
+	p.monitor.Stop()
+	return p.JobSupervisor.StopAndWait()
+}
+
+func (p *probingJobSupervisor) Unmonitor() error {
+	p.monitor.Stop()
+	return p.JobSupervisor.Unmonitor()
+}
+
+func (p *probingJobSupervisor) Status() string {
+	status := p.JobSupervisor.Status()
+	if status != "running" {
+		return status
+	}
+
+	switch p.monitor.State() {
+	case probe.StateFailing:
+		return "failing"
+	case probe.StatePending:
+		return "starting"
+	default:
+		return status
+	}
+}
+
+// AddJob adds probes of job directory once no matter how many monit files
+// it has. Additional monit files are added as <job>_<label> so probes are
+// added under job name as they are when loaded from jobs dir.
+func (p *probingJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	err := p.JobSupervisor.AddJob(jobName, jobIndex, configPath)
+	if err != nil {
+		return err
+	}
+
+	jobDir := path.Dir(configPath)
+	if p.probedJobDirs[jobDir] {
+		return nil
+	}
+	p.probedJobDirs[jobDir] = true
+
+	probesPath := path.Join(jobDir, probe.ConfigFileName)
+	if !p.fs.FileExists(probesPath) {
+		return nil
+	}
+
+	if fileName := path.Base(configPath); fileName != "monit" {
+		jobName = strings.TrimSuffix(jobName, "_"+strings.TrimSuffix(fileName, ".monit"))
+	}
+
+	return p.addProbes(jobName, probesPath)
+}
+
+func (p *probingJobSupervisor) addProbes(jobName string, probesPath string) error {
+	config, err := probe.LoadConfig(p.fs, probesPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Loading probes of job %s", jobName)
+	}
+
+	p.logger.Debug(probingJobSupervisorLogTag, "Adding %d probes of job %s", len(config.Probes), jobName)
+
+	p.monitor.AddJob(jobName, config)
+
+	return nil
+}
+
+func (p *probingJobSupervisor) RemoveAllJobs() error {
+	p.probedJobDirs = map[string]bool{}
+	p.monitor.RemoveAllJobs()
+	return p.JobSupervisor.RemoveAllJobs()
+}
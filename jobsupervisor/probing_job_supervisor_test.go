@@ -0,0 +1,154 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/probe/probefakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("probingJobSupervisor", func() {
+	var (
+		delegate   *fakejobsuper.FakeJobSupervisor
+		monitor    *probefakes.FakeMonitor
+		fs         *fakesys.FakeFileSystem
+		supervisor JobSupervisor
+	)
+
+	BeforeEach(func() {
+		delegate = fakejobsuper.NewFakeJobSupervisor()
+		monitor = &probefakes.FakeMonitor{}
+		fs = fakesys.NewFakeFileSystem()
+
+		supervisor = NewProbingJobSupervisor(delegate, monitor, "/var/vcap/jobs", fs, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("NewProbingJobSupervisor", func() {
+		It("restores probes of already applied jobs and starts probing", func() {
+			monitor = &probefakes.FakeMonitor{}
+			Expect(fs.WriteFileString("/var/vcap/jobs/nats/probes.json", `{"probes": [{"name": "port", "type": "tcp", "address": "127.0.0.1:4222"}]}`)).To(Succeed())
+			Expect(fs.WriteFileString("/var/vcap/jobs/redis/probes.json", `{"probes": [{"name": "port", "type": "udp"}]}`)).To(Succeed())
+			fs.SetGlob("/var/vcap/jobs/*/probes.json", []string{"/var/vcap/jobs/nats/probes.json", "/var/vcap/jobs/redis/probes.json"})
+
+			NewProbingJobSupervisor(delegate, monitor, "/var/vcap/jobs", fs, boshlog.NewLogger(boshlog.LevelNone))
+
+			Expect(monitor.AddJobCallCount()).To(Equal(1))
+			jobName, config := monitor.AddJobArgsForCall(0)
+			Expect(jobName).To(Equal("nats"))
+			Expect(config.Probes[0].Address).To(Equal("127.0.0.1:4222"))
+
+			Expect(monitor.StartCallCount()).To(Equal(1))
+		})
+
+		It("does not start probing when no jobs have probes", func() {
+			Expect(monitor.StartCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("AddJob", func() {
+		It("adds probes found next to job's monit file", func() {
+			Expect(fs.WriteFileString("/var/vcap/jobs/nats/probes.json", `{"probes": [{"name": "port", "type": "tcp", "address": "127.0.0.1:4222"}]}`)).To(Succeed())
+
+			Expect(supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit")).To(Succeed())
+			Expect(delegate.AddJobArgs).To(HaveLen(1))
+
+			Expect(monitor.AddJobCallCount()).To(Equal(1))
+			jobName, config := monitor.AddJobArgsForCall(0)
+			Expect(jobName).To(Equal("nats"))
+			Expect(config.Probes).To(HaveLen(1))
+			Expect(config.Probes[0].Address).To(Equal("127.0.0.1:4222"))
+		})
+
+		It("adds probes of job directory once when job has additional monit files", func() {
+			Expect(fs.WriteFileString("/var/vcap/jobs/nats/probes.json", `{"probes": []}`)).To(Succeed())
+
+			Expect(supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit")).To(Succeed())
+			Expect(supervisor.AddJob("nats_sub", 0, "/var/vcap/jobs/nats/sub.monit")).To(Succeed())
+			Expect(delegate.AddJobArgs).To(HaveLen(2))
+			Expect(monitor.AddJobCallCount()).To(Equal(1))
+		})
+
+		It("adds probes under job name when job only has additional monit files", func() {
+			Expect(fs.WriteFileString("/var/vcap/jobs/nats/probes.json", `{"probes": []}`)).To(Succeed())
+
+			Expect(supervisor.AddJob("nats_sub", 0, "/var/vcap/jobs/nats/sub.monit")).To(Succeed())
+			Expect(monitor.AddJobCallCount()).To(Equal(1))
+			jobName, _ := monitor.AddJobArgsForCall(0)
+			Expect(jobName).To(Equal("nats"))
+		})
+
+		It("adds probes again after all jobs are removed", func() {
+			Expect(fs.WriteFileString("/var/vcap/jobs/nats/probes.json", `{"probes": []}`)).To(Succeed())
+
+			Expect(supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit")).To(Succeed())
+			Expect(supervisor.RemoveAllJobs()).To(Succeed())
+			Expect(supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit")).To(Succeed())
+			Expect(monitor.AddJobCallCount()).To(Equal(2))
+		})
+
+		It("does not add probes for jobs without probes", func() {
+			Expect(supervisor.AddJob("redis", 1, "/var/vcap/jobs/redis/monit")).To(Succeed())
+			Expect(monitor.AddJobCallCount()).To(Equal(0))
+		})
+
+		It("returns an error when probes are invalid", func() {
+			Expect(fs.WriteFileString("/var/vcap/jobs/nats/probes.json", `{"probes": [{"name": "port", "type": "udp"}]}`)).To(Succeed())
+
+			err := supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Loading probes of job nats"))
+		})
+	})
+
+	Describe("Start", func() {
+		It("starts probing and waits for readiness", func() {
+			Expect(supervisor.Start()).To(Succeed())
+			Expect(delegate.Started).To(BeTrue())
+			Expect(monitor.StartCallCount()).To(Equal(1))
+			Expect(monitor.WaitForReadinessCallCount()).To(Equal(1))
+		})
+
+		It("returns an error when jobs do not become ready", func() {
+			monitor.WaitForReadinessReturns(errors.New("fake-err"))
+
+			err := supervisor.Start()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	It("stops probing when jobs are stopped or unmonitored", func() {
+		Expect(supervisor.Stop()).To(Succeed())
+		Expect(supervisor.StopAndWait()).To(Succeed())
+		Expect(supervisor.Unmonitor()).To(Succeed())
+		Expect(monitor.StopCallCount()).To(Equal(3))
+	})
+
+	Describe("Status", func() {
+		It("reflects probe state of running jobs", func() {
+			delegate.StatusStatus = "running"
+
+			monitor.StateReturns(probe.StatePassing)
+			Expect(supervisor.Status()).To(Equal("running"))
+
+			monitor.StateReturns(probe.StatePending)
+			Expect(supervisor.Status()).To(Equal("starting"))
+
+			monitor.StateReturns(probe.StateFailing)
+			Expect(supervisor.Status()).To(Equal("failing"))
+		})
+
+		It("keeps status of jobs which are not running", func() {
+			delegate.StatusStatus = "stopped"
+			monitor.StateReturns(probe.StateFailing)
+			Expect(supervisor.Status()).To(Equal("stopped"))
+		})
+	})
+})
@@ -10,6 +10,7 @@ import (
 
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -47,9 +48,18 @@ func NewProvider(
 		timeService,
 	)
 
+	systemdJobSupervisor := NewSystemdJobSupervisor(
+		systemd.NewDBusManager(logger),
+		fs,
+		dirProvider,
+		logger,
+		timeService,
+	)
+
 	return Provider{
 		supervisors: map[string]JobSupervisor{
 			"monit":      NewWrapperJobSupervisor(monitJobSupervisor, fs, dirProvider, logger),
+			"systemd":    NewWrapperJobSupervisor(systemdJobSupervisor, fs, dirProvider, logger),
 			"dummy":      NewDummyJobSupervisor(),
 			"dummy-nats": NewDummyNatsJobSupervisor(handler),
 		},
@@ -95,6 +95,16 @@ func init() { //nolint:gochecknoinits
 			}
 		})
 
+		It("provides a systemd job supervisor", func() {
+			if runtime.GOOS == "windows" {
+				Skip("systemd is not available on windows")
+			}
+
+			actualSupervisor, err := provider.Get("systemd")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualSupervisor).ToNot(BeNil())
+		})
+
 		It("provides a dummy job supervisor", func() {
 			actualSupervisor, err := provider.Get("dummy")
 			Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,241 @@
+package systemd
+
+import (
+	"context"
+	"math"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	godbus "github.com/godbus/dbus/v5"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const dbusManagerLogTag = "systemdDBusManager"
+
+type dbusManager struct {
+	logger boshlog.Logger
+
+	// Connects to the bus on which systemd is reachable
+	connect func() (*dbus.Conn, error)
+
+	connLock sync.Mutex
+	conn     *dbus.Conn
+}
+
+// NewDBusManager talks to systemd over the system bus. Connection is
+// only made on first use so that it can be constructed on any system.
+func NewDBusManager(logger boshlog.Logger) Manager {
+	return &dbusManager{
+		logger: logger,
+		connect: func() (*dbus.Conn, error) {
+			return dbus.NewSystemConnectionContext(context.Background())
+		},
+	}
+}
+
+func (m *dbusManager) StartTransientUnit(spec UnitSpec) error {
+	conn, err := m.connection()
+	if err != nil {
+		return err
+	}
+
+	properties := []dbus.Property{
+		dbus.PropDescription(spec.Description),
+		dbus.PropSlice(spec.Slice),
+		dbus.PropType(spec.Type),
+		dbus.PropExecStart(spec.ExecStart, true),
+		{Name: "Restart", Value: godbus.MakeVariant("on-failure")},
+		{Name: "CPUAccounting", Value: godbus.MakeVariant(true)},
+		{Name: "MemoryAccounting", Value: godbus.MakeVariant(true)},
+	}
+
+	if len(spec.ExecStop) > 0 {
+		execStop := dbus.PropExecStart(spec.ExecStop, false)
+		execStop.Name = "ExecStop"
+
+		properties = append(properties, execStop)
+	}
+
+	if spec.PIDFile != "" {
+		properties = append(properties, dbus.Property{Name: "PIDFile", Value: godbus.MakeVariant(spec.PIDFile)})
+	}
+
+	if len(spec.Environment) > 0 {
+		properties = append(properties, dbus.Property{Name: "Environment", Value: godbus.MakeVariant(spec.Environment)})
+	}
+
+	if spec.User != "" {
+		properties = append(properties, dbus.Property{Name: "User", Value: godbus.MakeVariant(spec.User)})
+	}
+
+	resultCh := make(chan string, 1)
+
+	_, err = conn.StartTransientUnitContext(context.Background(), spec.Name, "replace", properties, resultCh)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Starting unit %s", spec.Name)
+	}
+
+	if result := <-resultCh; result != "done" {
+		return bosherr.Errorf("Starting unit %s finished with result '%s'", spec.Name, result)
+	}
+
+	return nil
+}
+
+func (m *dbusManager) StopUnit(name string) error {
+	conn, err := m.connection()
+	if err != nil {
+		return err
+	}
+
+	resultCh := make(chan string, 1)
+
+	_, err = conn.StopUnitContext(context.Background(), name, "replace", resultCh)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Stopping unit %s", name)
+	}
+
+	if result := <-resultCh; result != "done" {
+		return bosherr.Errorf("Stopping unit %s finished with result '%s'", name, result)
+	}
+
+	return nil
+}
+
+func (m *dbusManager) ResetFailedUnit(name string) error {
+	conn, err := m.connection()
+	if err != nil {
+		return err
+	}
+
+	err = conn.ResetFailedUnitContext(context.Background(), name)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Resetting failed unit %s", name)
+	}
+
+	return nil
+}
+
+func (m *dbusManager) ListUnits(pattern string) ([]UnitStatus, error) {
+	conn, err := m.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	units, err := conn.ListUnitsByPatternsContext(context.Background(), nil, []string{pattern})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing units")
+	}
+
+	var statuses []UnitStatus
+
+	for _, unit := range units {
+		status := UnitStatus{
+			Name:        unit.Name,
+			ActiveState: unit.ActiveState,
+			SubState:    unit.SubState,
+		}
+
+		unitProps, err := conn.GetUnitPropertiesContext(context.Background(), unit.Name)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Getting properties of unit %s", unit.Name)
+		}
+
+		if usec, ok := unitProps["ActiveEnterTimestamp"].(uint64); ok && usec > 0 {
+			status.ActiveEnterTimestamp = time.UnixMicro(int64(usec))
+		}
+
+		serviceProps, err := conn.GetUnitTypePropertiesContext(context.Background(), unit.Name, "Service")
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Getting service properties of unit %s", unit.Name)
+		}
+
+		status.MainPID, _ = serviceProps["MainPID"].(uint32)
+		status.CPUUsageNSec = accountingValue(serviceProps["CPUUsageNSec"])
+		status.MemoryCurrentBytes = accountingValue(serviceProps["MemoryCurrent"])
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (m *dbusManager) SubscribeUnits(pattern string, interval time.Duration) (<-chan UnitStatus, <-chan error) {
+	statusCh := make(chan UnitStatus)
+	errCh := make(chan error, 1)
+
+	conn, err := m.connection()
+	if err != nil {
+		errCh <- err
+		return statusCh, errCh
+	}
+
+	filterUnit := func(name string) bool {
+		matched, _ := path.Match(pattern, name)
+		return !matched
+	}
+
+	isChanged := func(u1, u2 *dbus.UnitStatus) bool {
+		return *u1 != *u2
+	}
+
+	changesCh, changesErrCh := conn.SubscribeUnitsCustom(interval, 0, isChanged, filterUnit)
+
+	go func() {
+		defer m.logger.HandlePanic("Systemd Unit Subscription")
+
+		for {
+			select {
+			case changes := <-changesCh:
+				for name, unit := range changes {
+					// Unloaded units are reported without status
+					status := UnitStatus{Name: name, ActiveState: StateInactive}
+					if unit != nil {
+						status.ActiveState = unit.ActiveState
+						status.SubState = unit.SubState
+					}
+					statusCh <- status
+				}
+
+			case err := <-changesErrCh:
+				errCh <- bosherr.WrapError(err, "Watching units")
+				return
+			}
+		}
+	}()
+
+	return statusCh, errCh
+}
+
+func (m *dbusManager) connection() (*dbus.Conn, error) {
+	m.connLock.Lock()
+	defer m.connLock.Unlock()
+
+	if m.conn != nil && m.conn.Connected() {
+		return m.conn, nil
+	}
+
+	m.logger.Debug(dbusManagerLogTag, "Connecting to systemd")
+
+	conn, err := m.connect()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Connecting to systemd")
+	}
+
+	m.conn = conn
+
+	return conn, nil
+}
+
+// accountingValue treats "[not set]" (max uint64) as zero
+func accountingValue(value interface{}) uint64 {
+	v, ok := value.(uint64)
+	if !ok || v == math.MaxUint64 {
+		return 0
+	}
+	return v
+}
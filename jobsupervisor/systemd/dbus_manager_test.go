@@ -0,0 +1,179 @@
+package systemd_test
+
+import (
+	"time"
+
+	godbus "github.com/godbus/dbus/v5"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("DBusManager", func() {
+	var (
+		bus         *privateBus
+		fakeSystemd *fakeSystemd
+		manager     systemd.Manager
+	)
+
+	BeforeEach(func() {
+		bus = startPrivateBus()
+		fakeSystemd = startFakeSystemd(bus.Address)
+		manager = systemd.NewDBusManagerOnBus(bus.Address, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		fakeSystemd.Stop()
+		bus.Stop()
+	})
+
+	Describe("StartTransientUnit", func() {
+		It("starts unit with properties of spec", func() {
+			err := manager.StartTransientUnit(systemd.UnitSpec{
+				Name:        "bosh-job-fake-process.service",
+				Description: "fake-description",
+				Slice:       "bosh.slice",
+				Type:        "simple",
+				ExecStart:   []string{"/fake-exec", "fake-arg"},
+				ExecStop:    []string{"/fake-stop"},
+				Environment: []string{"FAKE_ENV=fake-value"},
+				User:        "vcap",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			unit := fakeSystemd.Unit("bosh-job-fake-process.service")
+			Expect(unit).ToNot(BeNil())
+			Expect(unit.ActiveState).To(Equal("active"))
+
+			Expect(unit.Properties["Description"].Value()).To(Equal("fake-description"))
+			Expect(unit.Properties["Slice"].Value()).To(Equal("bosh.slice"))
+			Expect(unit.Properties["Type"].Value()).To(Equal("simple"))
+			Expect(unit.Properties["Restart"].Value()).To(Equal("on-failure"))
+			Expect(unit.Properties["Environment"].Value()).To(Equal([]string{"FAKE_ENV=fake-value"}))
+			Expect(unit.Properties["User"].Value()).To(Equal("vcap"))
+			Expect(unit.Properties["ExecStart"].String()).To(ContainSubstring(`"/fake-exec", ["/fake-exec", "fake-arg"]`))
+			Expect(unit.Properties["ExecStop"].String()).To(ContainSubstring(`"/fake-stop"`))
+			Expect(unit.Properties).ToNot(HaveKey("PIDFile"))
+		})
+
+		It("returns an error when start job does not succeed", func() {
+			fakeSystemd.JobResult = "failed"
+
+			err := manager.StartTransientUnit(systemd.UnitSpec{Name: "bosh-job-fake-process.service", ExecStart: []string{"/fake-exec"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Starting unit bosh-job-fake-process.service finished with result 'failed'"))
+		})
+
+		It("returns an error when unit cannot be started", func() {
+			fakeSystemd.SetUnit("bosh-job-fake-process.service", &fakeUnit{ActiveState: "active"})
+
+			err := manager.StartTransientUnit(systemd.UnitSpec{Name: "bosh-job-fake-process.service", ExecStart: []string{"/fake-exec"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("already exists"))
+		})
+	})
+
+	Describe("StopUnit", func() {
+		It("stops unit and waits for stop job to finish", func() {
+			fakeSystemd.SetUnit("bosh-job-fake-process.service", &fakeUnit{ActiveState: "active"})
+
+			err := manager.StopUnit("bosh-job-fake-process.service")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeSystemd.Unit("bosh-job-fake-process.service")).To(BeNil())
+		})
+
+		It("returns an error when unit is not loaded", func() {
+			err := manager.StopUnit("bosh-job-fake-process.service")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Stopping unit bosh-job-fake-process.service"))
+		})
+	})
+
+	Describe("ResetFailedUnit", func() {
+		It("unloads failed unit", func() {
+			fakeSystemd.SetUnit("bosh-job-fake-process.service", &fakeUnit{ActiveState: "failed"})
+
+			err := manager.ResetFailedUnit("bosh-job-fake-process.service")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeSystemd.Unit("bosh-job-fake-process.service")).To(BeNil())
+		})
+	})
+
+	Describe("ListUnits", func() {
+		It("returns status and accounting of units matching pattern", func() {
+			activeEnterTimestamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+			fakeSystemd.SetUnit("bosh-job-fake-process.service", &fakeUnit{
+				ActiveState: "active",
+				SubState:    "running",
+				UnitProperties: map[string]godbus.Variant{
+					"ActiveEnterTimestamp": godbus.MakeVariant(uint64(activeEnterTimestamp.UnixMicro())),
+				},
+				ServiceProperties: map[string]godbus.Variant{
+					"MainPID":       godbus.MakeVariant(uint32(123)),
+					"CPUUsageNSec":  godbus.MakeVariant(uint64(456)),
+					"MemoryCurrent": godbus.MakeVariant(uint64(789)),
+				},
+			})
+			fakeSystemd.SetUnit("other.service", &fakeUnit{ActiveState: "active"})
+
+			statuses, err := manager.ListUnits("bosh-job-*.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses).To(HaveLen(1))
+
+			Expect(statuses[0].Name).To(Equal("bosh-job-fake-process.service"))
+			Expect(statuses[0].ActiveState).To(Equal("active"))
+			Expect(statuses[0].SubState).To(Equal("running"))
+			Expect(statuses[0].MainPID).To(Equal(uint32(123)))
+			Expect(statuses[0].ActiveEnterTimestamp.Equal(activeEnterTimestamp)).To(BeTrue())
+			Expect(statuses[0].CPUUsageNSec).To(Equal(uint64(456)))
+			Expect(statuses[0].MemoryCurrentBytes).To(Equal(uint64(789)))
+		})
+
+		It("treats accounting values which are not set as zero", func() {
+			fakeSystemd.SetUnit("bosh-job-fake-process.service", &fakeUnit{
+				ActiveState:    "active",
+				UnitProperties: map[string]godbus.Variant{},
+				ServiceProperties: map[string]godbus.Variant{
+					"CPUUsageNSec":  godbus.MakeVariant(uint64(18446744073709551615)),
+					"MemoryCurrent": godbus.MakeVariant(uint64(18446744073709551615)),
+				},
+			})
+
+			statuses, err := manager.ListUnits("bosh-job-*.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses[0].CPUUsageNSec).To(BeZero())
+			Expect(statuses[0].MemoryCurrentBytes).To(BeZero())
+			Expect(statuses[0].ActiveEnterTimestamp.IsZero()).To(BeTrue())
+		})
+
+		It("returns an error when unit properties cannot be read", func() {
+			fakeSystemd.SetUnit("bosh-job-fake-process.service", &fakeUnit{ActiveState: "active"})
+			fakeSystemd.Stop()
+
+			_, err := manager.ListUnits("bosh-job-*.service")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("SubscribeUnits", func() {
+		It("reports state changes of units matching pattern", func() {
+			statusCh, _ := manager.SubscribeUnits("bosh-job-*.service", 10*time.Millisecond)
+
+			fakeSystemd.SetUnit("other.service", &fakeUnit{ActiveState: "failed"})
+			fakeSystemd.SetUnit("bosh-job-fake-process.service", &fakeUnit{ActiveState: "failed", SubState: "failed"})
+
+			var status systemd.UnitStatus
+			Eventually(statusCh).Should(Receive(&status))
+			Expect(status).To(Equal(systemd.UnitStatus{
+				Name:        "bosh-job-fake-process.service",
+				ActiveState: "failed",
+				SubState:    "failed",
+			}))
+		})
+	})
+})
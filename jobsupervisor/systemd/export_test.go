@@ -0,0 +1,35 @@
+package systemd
+
+import (
+	"github.com/coreos/go-systemd/v22/dbus"
+	godbus "github.com/godbus/dbus/v5"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// NewDBusManagerOnBus talks to systemd reachable on a private bus
+// so that tests do not depend on the system bus.
+func NewDBusManagerOnBus(address string, logger boshlog.Logger) Manager {
+	return &dbusManager{
+		logger: logger,
+		connect: func() (*dbus.Conn, error) {
+			return dbus.NewConnection(func() (*godbus.Conn, error) {
+				conn, err := godbus.Dial(address)
+				if err != nil {
+					return nil, err
+				}
+
+				err = conn.Auth(nil)
+				if err == nil {
+					err = conn.Hello()
+				}
+				if err != nil {
+					conn.Close()
+					return nil, err
+				}
+
+				return conn, nil
+			})
+		},
+	}
+}
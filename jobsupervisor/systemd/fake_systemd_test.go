@@ -0,0 +1,271 @@
+package systemd_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	godbus "github.com/godbus/dbus/v5"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+const (
+	systemdBusName     = "org.freedesktop.systemd1"
+	systemdObjectPath  = godbus.ObjectPath("/org/freedesktop/systemd1")
+	systemdManagerName = "org.freedesktop.systemd1.Manager"
+)
+
+const privateBusConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:path=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// privateBus is a dbus-daemon only reachable by the test
+type privateBus struct {
+	Address string
+
+	dir     string
+	session *gexec.Session
+}
+
+func startPrivateBus() *privateBus {
+	daemonPath, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		Skip("dbus-daemon is not available")
+	}
+
+	dir, err := os.MkdirTemp("", "private-bus")
+	Expect(err).ToNot(HaveOccurred())
+
+	socketPath := filepath.Join(dir, "bus")
+	configPath := filepath.Join(dir, "bus.conf")
+
+	err = os.WriteFile(configPath, []byte(fmt.Sprintf(privateBusConfig, socketPath)), 0600)
+	Expect(err).ToNot(HaveOccurred())
+
+	cmd := exec.Command(daemonPath, "--config-file="+configPath, "--nofork", "--nopidfile", "--print-address")
+
+	session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+	Expect(err).ToNot(HaveOccurred())
+
+	// Address is printed once daemon accepts connections
+	Eventually(session.Out).Should(gbytes.Say("unix:"))
+
+	return &privateBus{Address: "unix:path=" + socketPath, dir: dir, session: session}
+}
+
+func (b *privateBus) Stop() {
+	b.session.Kill().Wait()
+	Expect(os.RemoveAll(b.dir)).To(Succeed())
+}
+
+type fakeUnit struct {
+	ActiveState string
+	SubState    string
+	Properties  map[string]godbus.Variant
+
+	// Properties of org.freedesktop.systemd1.Unit and Service interfaces
+	UnitProperties    map[string]godbus.Variant
+	ServiceProperties map[string]godbus.Variant
+}
+
+// fakeSystemd implements the part of systemd manager API used by agent
+// on a private bus; started units become active immediately.
+type fakeSystemd struct {
+	conn *godbus.Conn
+
+	lock  sync.Mutex
+	units map[string]*fakeUnit
+	jobID uint32
+
+	// Result reported for jobs, "done" unless set
+	JobResult string
+}
+
+func startFakeSystemd(address string) *fakeSystemd {
+	conn, err := godbus.Dial(address)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(conn.Auth(nil)).To(Succeed())
+	Expect(conn.Hello()).To(Succeed())
+
+	s := &fakeSystemd{conn: conn, units: map[string]*fakeUnit{}, JobResult: "done"}
+
+	Expect(conn.Export(s, systemdObjectPath, systemdManagerName)).To(Succeed())
+
+	reply, err := conn.RequestName(systemdBusName, godbus.NameFlagDoNotQueue)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(reply).To(Equal(godbus.RequestNameReplyPrimaryOwner))
+
+	return s
+}
+
+func (s *fakeSystemd) Stop() {
+	s.conn.Close()
+}
+
+func (s *fakeSystemd) Unit(name string) *fakeUnit {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.units[name]
+}
+
+// SetUnit loads unit as if systemd already knew about it
+func (s *fakeSystemd) SetUnit(name string, unit *fakeUnit) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.loadUnit(name, unit)
+}
+
+func (s *fakeSystemd) StartTransientUnit(name, mode string, properties []dbus.Property, aux []dbus.PropertyCollection) (godbus.ObjectPath, *godbus.Error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, found := s.units[name]; found {
+		return "", godbus.NewError("org.freedesktop.systemd1.UnitExists", []interface{}{"Unit " + name + " already exists."})
+	}
+
+	unit := &fakeUnit{ActiveState: "active", SubState: "running", Properties: map[string]godbus.Variant{}}
+	for _, property := range properties {
+		unit.Properties[property.Name] = property.Value
+	}
+
+	s.loadUnit(name, unit)
+
+	return s.finishJob(name), nil
+}
+
+func (s *fakeSystemd) StopUnit(name, mode string) (godbus.ObjectPath, *godbus.Error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, found := s.units[name]; !found {
+		return "", godbus.NewError("org.freedesktop.systemd1.NoSuchUnit", []interface{}{"Unit " + name + " not loaded."})
+	}
+
+	// Transient units are unloaded once stopped
+	s.unloadUnit(name)
+
+	return s.finishJob(name), nil
+}
+
+func (s *fakeSystemd) ResetFailedUnit(name string) *godbus.Error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if unit, found := s.units[name]; found && unit.ActiveState == "failed" {
+		s.unloadUnit(name)
+	}
+
+	return nil
+}
+
+func (s *fakeSystemd) ListUnits() ([]dbusUnitStatus, *godbus.Error) {
+	return s.ListUnitsByPatterns(nil, []string{"*"})
+}
+
+func (s *fakeSystemd) ListUnitsByPatterns(states, patterns []string) ([]dbusUnitStatus, *godbus.Error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	statuses := []dbusUnitStatus{}
+
+	for name, unit := range s.units {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, name); matched {
+				statuses = append(statuses, dbusUnitStatus{
+					Name:        name,
+					LoadState:   "loaded",
+					ActiveState: unit.ActiveState,
+					SubState:    unit.SubState,
+					Path:        unitObjectPath(name),
+					JobPath:     "/",
+				})
+				break
+			}
+		}
+	}
+
+	return statuses, nil
+}
+
+// loadUnit exports unit properties on the path where clients look for them
+func (s *fakeSystemd) loadUnit(name string, unit *fakeUnit) {
+	s.units[name] = unit
+
+	err := s.conn.Export(fakeUnitProperties{unit: unit, lock: &s.lock}, unitObjectPath(name), "org.freedesktop.DBus.Properties")
+	Expect(err).ToNot(HaveOccurred())
+}
+
+func (s *fakeSystemd) unloadUnit(name string) {
+	delete(s.units, name)
+
+	err := s.conn.Export(nil, unitObjectPath(name), "org.freedesktop.DBus.Properties")
+	Expect(err).ToNot(HaveOccurred())
+}
+
+// finishJob reports job as finished like systemd does once it is done
+func (s *fakeSystemd) finishJob(unitName string) godbus.ObjectPath {
+	s.jobID++
+
+	jobPath := godbus.ObjectPath(fmt.Sprintf("/org/freedesktop/systemd1/job/%d", s.jobID))
+
+	err := s.conn.Emit(systemdObjectPath, systemdManagerName+".JobRemoved", s.jobID, jobPath, unitName, s.JobResult)
+	Expect(err).ToNot(HaveOccurred())
+
+	return jobPath
+}
+
+type fakeUnitProperties struct {
+	unit *fakeUnit
+	lock *sync.Mutex
+}
+
+func (p fakeUnitProperties) GetAll(iface string) (map[string]godbus.Variant, *godbus.Error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	switch iface {
+	case "org.freedesktop.systemd1.Unit":
+		return p.unit.UnitProperties, nil
+	case "org.freedesktop.systemd1.Service":
+		return p.unit.ServiceProperties, nil
+	}
+
+	return nil, godbus.NewError("org.freedesktop.DBus.Error.UnknownInterface", []interface{}{"Unknown interface " + iface})
+}
+
+// dbusUnitStatus is marshalled as systemd's (ssssssouso) unit status
+type dbusUnitStatus struct {
+	Name        string
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	Followed    string
+	Path        godbus.ObjectPath
+	JobID       uint32
+	JobType     string
+	JobPath     godbus.ObjectPath
+}
+
+func unitObjectPath(name string) godbus.ObjectPath {
+	return godbus.ObjectPath("/org/freedesktop/systemd1/unit/" + dbus.PathBusEscape(name))
+}
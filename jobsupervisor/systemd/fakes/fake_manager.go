@@ -0,0 +1,198 @@
+package fakes
+
+import (
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
+)
+
+// FakeManager is an in-memory stand-in for systemd which keeps track of
+// transient units and lets tests change their state as systemd would.
+type FakeManager struct {
+	lock sync.Mutex
+
+	Units map[string]systemd.UnitStatus
+	Specs map[string]systemd.UnitSpec
+
+	StartedUnits []string
+	StoppedUnits []string
+
+	StartErr  error
+	StopErr   error
+	ListErr   error
+	Connected bool
+
+	subscribers []fakeSubscriber
+}
+
+type fakeSubscriber struct {
+	pattern  string
+	statusCh chan systemd.UnitStatus
+	errCh    chan error
+}
+
+func NewFakeManager() *FakeManager {
+	return &FakeManager{
+		Units:     map[string]systemd.UnitStatus{},
+		Specs:     map[string]systemd.UnitSpec{},
+		Connected: true,
+	}
+}
+
+func (m *FakeManager) StartTransientUnit(spec systemd.UnitSpec) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.StartErr != nil {
+		return m.StartErr
+	}
+
+	if _, found := m.Units[spec.Name]; found {
+		return bosherr.Errorf("Unit %s already exists", spec.Name)
+	}
+
+	m.Specs[spec.Name] = spec
+	m.StartedUnits = append(m.StartedUnits, spec.Name)
+	m.setState(spec.Name, systemd.StateActive, "running")
+
+	return nil
+}
+
+func (m *FakeManager) StopUnit(name string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.StopErr != nil {
+		return m.StopErr
+	}
+
+	if _, found := m.Units[name]; !found {
+		return bosherr.Errorf("Unit %s not loaded", name)
+	}
+
+	m.StoppedUnits = append(m.StoppedUnits, name)
+
+	// Like systemd, stopped transient units are garbage collected
+	m.setState(name, systemd.StateInactive, "dead")
+	delete(m.Units, name)
+
+	return nil
+}
+
+func (m *FakeManager) ResetFailedUnit(name string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	unit, found := m.Units[name]
+	if !found || unit.ActiveState != systemd.StateFailed {
+		return bosherr.Errorf("Unit %s is not failed", name)
+	}
+
+	delete(m.Units, name)
+
+	return nil
+}
+
+func (m *FakeManager) ListUnits(pattern string) ([]systemd.UnitStatus, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.ListErr != nil {
+		return nil, m.ListErr
+	}
+
+	var units []systemd.UnitStatus
+	for name, unit := range m.Units {
+		if matched, _ := path.Match(pattern, name); matched {
+			units = append(units, unit)
+		}
+	}
+
+	sort.Slice(units, func(i, j int) bool { return units[i].Name < units[j].Name })
+
+	return units, nil
+}
+
+func (m *FakeManager) SubscribeUnits(pattern string, _ time.Duration) (<-chan systemd.UnitStatus, <-chan error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	subscriber := fakeSubscriber{
+		pattern:  pattern,
+		statusCh: make(chan systemd.UnitStatus, 100),
+		errCh:    make(chan error, 1),
+	}
+
+	if !m.Connected {
+		subscriber.errCh <- bosherr.Error("Connecting to systemd")
+	}
+
+	m.subscribers = append(m.subscribers, subscriber)
+
+	return subscriber.statusCh, subscriber.errCh
+}
+
+// SetState changes state of a unit, e.g. to simulate a crash
+func (m *FakeManager) SetState(name, activeState, subState string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.setState(name, activeState, subState)
+}
+
+// SetAccounting sets resource usage reported for a unit
+func (m *FakeManager) SetAccounting(name string, cpuUsageNSec, memoryCurrentBytes uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	unit := m.Units[name]
+	unit.CPUUsageNSec = cpuUsageNSec
+	unit.MemoryCurrentBytes = memoryCurrentBytes
+	m.Units[name] = unit
+}
+
+// Disconnect makes subscribers fail as if systemd went away
+func (m *FakeManager) Disconnect() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.Connected = false
+	for _, subscriber := range m.subscribers {
+		select {
+		case subscriber.errCh <- bosherr.Error("Connection closed"):
+		default:
+		}
+	}
+}
+
+func (m *FakeManager) SubscriberCount() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return len(m.subscribers)
+}
+
+func (m *FakeManager) setState(name, activeState, subState string) {
+	unit := m.Units[name]
+	unit.Name = name
+	unit.ActiveState = activeState
+	unit.SubState = subState
+
+	if activeState == systemd.StateActive {
+		unit.ActiveEnterTimestamp = time.Now()
+		unit.MainPID = uint32(1000 + len(m.StartedUnits))
+	}
+
+	m.Units[name] = unit
+
+	for _, subscriber := range m.subscribers {
+		if matched, _ := path.Match(subscriber.pattern, name); matched {
+			subscriber.statusCh <- systemd.UnitStatus{Name: name, ActiveState: activeState, SubState: subState}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package systemd
+
+import (
+	"time"
+)
+
+const (
+	StateActive       = "active"
+	StateActivating   = "activating"
+	StateDeactivating = "deactivating"
+	StateInactive     = "inactive"
+	StateFailed       = "failed"
+
+	// Sub state of services waiting to be restarted after a failure
+	SubStateAutoRestart = "auto-restart"
+)
+
+// UnitSpec describes a transient service unit
+type UnitSpec struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Slice       string   `json:"slice"`
+	Type        string   `json:"type"`
+	ExecStart   []string `json:"exec_start"`
+	ExecStop    []string `json:"exec_stop,omitempty"`
+	PIDFile     string   `json:"pid_file,omitempty"`
+	Environment []string `json:"environment,omitempty"`
+	User        string   `json:"user,omitempty"`
+}
+
+type UnitStatus struct {
+	Name        string
+	ActiveState string
+	SubState    string
+
+	// Only set by ListUnits
+	MainPID              uint32
+	ActiveEnterTimestamp time.Time
+
+	// Cgroup accounting; zero when accounting is disabled
+	CPUUsageNSec       uint64
+	MemoryCurrentBytes uint64
+}
+
+type Manager interface {
+	// StartTransientUnit starts unit and waits for the start job to finish.
+	// Services are restarted on failure and have CPU and memory accounting.
+	StartTransientUnit(spec UnitSpec) error
+
+	// StopUnit stops unit and waits for the stop job to finish
+	StopUnit(name string) error
+
+	ResetFailedUnit(name string) error
+
+	// ListUnits returns loaded units matching a glob pattern
+	ListUnits(pattern string) ([]UnitStatus, error)
+
+	// SubscribeUnits reports state changes of units matching a glob
+	// pattern until an error occurs.
+	SubscribeUnits(pattern string, interval time.Duration) (<-chan UnitStatus, <-chan error)
+}
@@ -0,0 +1,597 @@
+package jobsupervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	systemdJobSupervisorLogTag = "systemdJobSupervisor"
+
+	systemdSubscribeInterval = 1 * time.Second
+	systemdMemInfoPath       = "/proc/meminfo"
+
+	// Specs units were last started with, to restart units whose spec changed
+	systemdStartedUnitsFileName = "started_units.json"
+)
+
+type systemdJobSupervisor struct {
+	manager     systemd.Manager
+	fs          boshsys.FileSystem
+	dirProvider boshdir.Provider
+	logger      boshlog.Logger
+	timeService clock.Clock
+
+	// Failures are reported only while jobs are monitored
+	monitoredLock sync.Mutex
+	monitored     bool
+
+	// Previous CPU usage of each unit to calculate CPU percentage
+	cpuSamplesLock sync.Mutex
+	cpuSamples     map[string]systemdCPUSample
+}
+
+type systemdCPUSample struct {
+	usageNSec uint64
+	takenAt   time.Time
+}
+
+// NewSystemdJobSupervisor runs each job process as a transient systemd
+// service in the bosh slice. Processes are taken either from a
+// processes.json next to the job's monit file or from the monit file
+// itself. Transient units do not survive reboots, just like monit jobs
+// are not started until agent starts them.
+func NewSystemdJobSupervisor(
+	manager systemd.Manager,
+	fs boshsys.FileSystem,
+	dirProvider boshdir.Provider,
+	logger boshlog.Logger,
+	timeService clock.Clock,
+) JobSupervisor {
+	return &systemdJobSupervisor{
+		manager:     manager,
+		fs:          fs,
+		dirProvider: dirProvider,
+		logger:      logger,
+		timeService: timeService,
+		cpuSamples:  map[string]systemdCPUSample{},
+	}
+}
+
+// Reload stops units of processes which are no longer configured;
+// configured ones are started by Start.
+func (s *systemdJobSupervisor) Reload() error {
+	specs, err := s.unitSpecs()
+	if err != nil {
+		return err
+	}
+
+	configured := map[string]bool{}
+	for _, spec := range specs {
+		configured[spec.Name] = true
+	}
+
+	units, err := s.manager.ListUnits(systemdUnitPattern)
+	if err != nil {
+		return bosherr.WrapError(err, "Listing units")
+	}
+
+	for _, unit := range units {
+		if configured[unit.Name] {
+			continue
+		}
+
+		s.logger.Debug(systemdJobSupervisorLogTag, "Removing unit %s", unit.Name)
+
+		err = s.removeUnit(unit)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) Start() error {
+	specs, err := s.unitSpecs()
+	if err != nil {
+		return err
+	}
+
+	units, err := s.unitsByName()
+	if err != nil {
+		return err
+	}
+
+	startedSpecs := s.startedSpecs()
+	configured := map[string]bool{}
+
+	for _, spec := range specs {
+		configured[spec.Name] = true
+
+		unit, found := units[spec.Name]
+
+		if found && (unit.ActiveState == systemd.StateActive || unit.ActiveState == systemd.StateActivating) {
+			// Units started before specs were recorded are kept running
+			startedSpec, known := startedSpecs[spec.Name]
+			if !known || reflect.DeepEqual(startedSpec, spec) {
+				startedSpecs[spec.Name] = spec
+				continue
+			}
+
+			s.logger.Debug(systemdJobSupervisorLogTag, "Restarting unit %s since its spec changed", spec.Name)
+
+			err = s.manager.StopUnit(spec.Name)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Stopping unit %s", spec.Name)
+			}
+		}
+
+		if found && unit.ActiveState == systemd.StateFailed {
+			err = s.manager.ResetFailedUnit(spec.Name)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Resetting failed unit %s", spec.Name)
+			}
+		}
+
+		s.logger.Debug(systemdJobSupervisorLogTag, "Starting unit %s", spec.Name)
+
+		err = s.manager.StartTransientUnit(spec)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Starting unit %s", spec.Name)
+		}
+
+		startedSpecs[spec.Name] = spec
+
+		err = s.writeStartedSpecs(startedSpecs)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name := range startedSpecs {
+		if !configured[name] {
+			delete(startedSpecs, name)
+		}
+	}
+
+	err = s.writeStartedSpecs(startedSpecs)
+	if err != nil {
+		return err
+	}
+
+	err = s.fs.RemoveAll(s.stoppedFilePath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing stopped File")
+	}
+
+	s.setMonitored(true)
+
+	return nil
+}
+
+// Stop also unmonitors jobs so that failures of stopping
+// processes are not reported.
+func (s *systemdJobSupervisor) Stop() error {
+	s.setMonitored(false)
+
+	units, err := s.manager.ListUnits(systemdUnitPattern)
+	if err != nil {
+		return bosherr.WrapError(err, "Listing units")
+	}
+
+	for _, unit := range units {
+		s.logger.Debug(systemdJobSupervisorLogTag, "Stopping unit %s", unit.Name)
+
+		err = s.removeUnit(unit)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.fs.WriteFileString(s.stoppedFilePath(), "")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating stopped File")
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) StopAndWait() error {
+	timer := s.timeService.NewTimer(5 * time.Minute)
+
+	err := s.Stop()
+	if err != nil {
+		return err
+	}
+
+	s.logger.Debug(systemdJobSupervisorLogTag, "Waiting for units to stop")
+
+	for {
+		units, err := s.manager.ListUnits(systemdUnitPattern)
+		if err != nil {
+			return bosherr.WrapError(err, "Listing units")
+		}
+
+		var unitsToStop []string
+		for _, unit := range units {
+			if unit.ActiveState != systemd.StateInactive && unit.ActiveState != systemd.StateFailed {
+				unitsToStop = append(unitsToStop, unit.Name)
+			}
+		}
+
+		if len(unitsToStop) == 0 {
+			s.logger.Debug(systemdJobSupervisorLogTag, "Successfully stopped all units")
+			return nil
+		}
+
+		select {
+		case <-timer.C():
+			return bosherr.Errorf("Timed out waiting for units '%s' to stop after 5 minutes", strings.Join(unitsToStop, ", "))
+		default:
+		}
+
+		s.timeService.Sleep(500 * time.Millisecond)
+	}
+}
+
+// Unmonitor stops reporting failures. Systemd keeps restarting failed
+// processes as it has no equivalent of unmonitored services.
+func (s *systemdJobSupervisor) Unmonitor() error {
+	s.setMonitored(false)
+	return nil
+}
+
+func (s *systemdJobSupervisor) Status() string {
+	if s.fs.FileExists(s.stoppedFilePath()) {
+		return "stopped"
+	}
+
+	specs, err := s.unitSpecs()
+	if err != nil {
+		return "unknown"
+	}
+
+	units, err := s.unitsByName()
+	if err != nil {
+		return "unknown"
+	}
+
+	status := "running"
+
+	for _, spec := range specs {
+		switch units[spec.Name].ActiveState {
+		case systemd.StateActivating:
+			return "starting"
+		case systemd.StateActive:
+		default:
+			status = "failing"
+		}
+	}
+
+	return status
+}
+
+func (s *systemdJobSupervisor) Processes() ([]Process, error) {
+	processes := []Process{}
+
+	specs, err := s.unitSpecs()
+	if err != nil {
+		return processes, err
+	}
+
+	units, err := s.unitsByName()
+	if err != nil {
+		return processes, bosherr.WrapError(err, "Getting unit status")
+	}
+
+	memTotalKb, err := s.memTotalKb()
+	if err != nil {
+		s.logger.Warn(systemdJobSupervisorLogTag, "Getting total memory: %s", err.Error())
+	}
+
+	now := s.timeService.Now()
+
+	for _, spec := range specs {
+		unit := units[spec.Name]
+
+		process := Process{
+			Name:  systemdProcessName(spec.Name),
+			State: s.processState(unit),
+		}
+
+		if unit.ActiveState == systemd.StateActive && !unit.ActiveEnterTimestamp.IsZero() {
+			process.Uptime.Secs = int(now.Sub(unit.ActiveEnterTimestamp).Seconds())
+		}
+
+		process.Memory.Kb = int(unit.MemoryCurrentBytes / 1024)
+		if memTotalKb > 0 {
+			process.Memory.Percent = float64(process.Memory.Kb) / float64(memTotalKb) * 100
+		}
+
+		process.CPU.Total = s.cpuPercent(spec.Name, unit.CPUUsageNSec, now)
+
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+func (s *systemdJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	var specs []systemd.UnitSpec
+
+	processesConfigPath := path.Join(path.Dir(configPath), systemdProcessesFileName)
+
+	if path.Base(configPath) == "monit" && s.fs.FileExists(processesConfigPath) {
+		content, err := s.fs.ReadFile(processesConfigPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Reading job processes config from file")
+		}
+
+		specs, err = unitSpecsFromProcessesConfig(jobName, content)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing processes config of job %s", jobName)
+		}
+	} else {
+		content, err := s.fs.ReadFileString(configPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Reading job config from file")
+		}
+
+		specs, err = unitSpecsFromMonitConfig(jobName, content)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing monit config of job %s", jobName)
+		}
+	}
+
+	if len(specs) == 0 {
+		return nil
+	}
+
+	specsJSON, err := json.Marshal(specs)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling unit specs")
+	}
+
+	targetFilename := fmt.Sprintf("%04d_%s.json", jobIndex, jobName)
+
+	err = s.fs.WriteFile(path.Join(s.dirProvider.SystemdJobsDir(), targetFilename), specsJSON)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing to job unit specs file")
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) RemoveAllJobs() error {
+	return s.fs.RemoveAll(s.dirProvider.SystemdJobsDir())
+}
+
+// MonitorJobFailures blocks while reporting failed processes of
+// monitored jobs in the same way monit alerts are reported.
+func (s *systemdJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	statusCh, errCh := s.manager.SubscribeUnits(systemdUnitPattern, systemdSubscribeInterval)
+
+	for {
+		select {
+		case unit := <-statusCh:
+			if unit.ActiveState != systemd.StateFailed && unit.SubState != systemd.SubStateAutoRestart {
+				continue
+			}
+
+			if !s.isMonitored() {
+				s.logger.Debug(systemdJobSupervisorLogTag, "Ignoring failure of unmonitored unit %s", unit.Name)
+				continue
+			}
+
+			now := s.timeService.Now()
+
+			err := handler(boshalert.MonitAlert{
+				ID:          fmt.Sprintf("%d.%s@localhost", now.UnixNano(), unit.Name),
+				Service:     systemdProcessName(unit.Name),
+				Event:       "does not exist",
+				Action:      "restart",
+				Date:        now.Format(time.RFC1123Z),
+				Description: fmt.Sprintf("process is not running (unit %s is %s/%s)", unit.Name, unit.ActiveState, unit.SubState),
+			})
+			if err != nil {
+				s.logger.Error(systemdJobSupervisorLogTag, "Handling failure of unit %s: %s", unit.Name, err.Error())
+			}
+
+		case err := <-errCh:
+			return bosherr.WrapError(err, "Subscribing to unit changes")
+		}
+	}
+}
+
+func (s *systemdJobSupervisor) HealthRecorder(status string) {
+}
+
+func (s *systemdJobSupervisor) unitSpecs() ([]systemd.UnitSpec, error) {
+	paths, err := s.fs.Glob(path.Join(s.dirProvider.SystemdJobsDir(), "*.json"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding job unit specs")
+	}
+
+	sort.Strings(paths)
+
+	var specs []systemd.UnitSpec
+
+	for _, p := range paths {
+		content, err := s.fs.ReadFile(p)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading job unit specs %s", p)
+		}
+
+		var jobSpecs []systemd.UnitSpec
+
+		err = json.Unmarshal(content, &jobSpecs)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Unmarshalling job unit specs %s", p)
+		}
+
+		specs = append(specs, jobSpecs...)
+	}
+
+	return specs, nil
+}
+
+// startedSpecs returns specs recorded when units were started; a missing
+// or unreadable record only means units are not restarted on changes.
+func (s *systemdJobSupervisor) startedSpecs() map[string]systemd.UnitSpec {
+	specs := map[string]systemd.UnitSpec{}
+
+	startedUnitsPath := s.startedUnitsFilePath()
+	if !s.fs.FileExists(startedUnitsPath) {
+		return specs
+	}
+
+	content, err := s.fs.ReadFile(startedUnitsPath)
+	if err == nil {
+		err = json.Unmarshal(content, &specs)
+	}
+
+	if err != nil {
+		s.logger.Warn(systemdJobSupervisorLogTag, "Ignoring specs of started units: %s", err.Error())
+		return map[string]systemd.UnitSpec{}
+	}
+
+	return specs
+}
+
+func (s *systemdJobSupervisor) writeStartedSpecs(specs map[string]systemd.UnitSpec) error {
+	specsJSON, err := json.Marshal(specs)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling specs of started units")
+	}
+
+	err = s.fs.WriteFile(s.startedUnitsFilePath(), specsJSON)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing specs of started units")
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) unitsByName() (map[string]systemd.UnitStatus, error) {
+	units, err := s.manager.ListUnits(systemdUnitPattern)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing units")
+	}
+
+	unitsByName := map[string]systemd.UnitStatus{}
+	for _, unit := range units {
+		unitsByName[unit.Name] = unit
+	}
+
+	return unitsByName, nil
+}
+
+// removeUnit stops unit which makes systemd unload it; failed units
+// are only unloaded once reset.
+func (s *systemdJobSupervisor) removeUnit(unit systemd.UnitStatus) error {
+	if unit.ActiveState == systemd.StateFailed {
+		err := s.manager.ResetFailedUnit(unit.Name)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Resetting failed unit %s", unit.Name)
+		}
+		return nil
+	}
+
+	err := s.manager.StopUnit(unit.Name)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Stopping unit %s", unit.Name)
+	}
+
+	return nil
+}
+
+func (s *systemdJobSupervisor) processState(unit systemd.UnitStatus) string {
+	switch unit.ActiveState {
+	case systemd.StateActive:
+		return "running"
+	case systemd.StateActivating:
+		return "starting"
+	case systemd.StateFailed:
+		return "failing"
+	default:
+		if unit.SubState == systemd.SubStateAutoRestart {
+			return "failing"
+		}
+		return "stopped"
+	}
+}
+
+func (s *systemdJobSupervisor) cpuPercent(unitName string, usageNSec uint64, now time.Time) float64 {
+	s.cpuSamplesLock.Lock()
+	defer s.cpuSamplesLock.Unlock()
+
+	previous, found := s.cpuSamples[unitName]
+	s.cpuSamples[unitName] = systemdCPUSample{usageNSec: usageNSec, takenAt: now}
+
+	// Usage restarts from zero when unit is restarted
+	if !found || usageNSec < previous.usageNSec {
+		return 0
+	}
+
+	elapsed := now.Sub(previous.takenAt)
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(usageNSec-previous.usageNSec) / float64(elapsed.Nanoseconds()) * 100
+}
+
+func (s *systemdJobSupervisor) memTotalKb() (int, error) {
+	content, err := s.fs.ReadFileString(systemdMemInfoPath)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			return strconv.Atoi(fields[1])
+		}
+	}
+
+	return 0, bosherr.Errorf("MemTotal not found in %s", systemdMemInfoPath)
+}
+
+func (s *systemdJobSupervisor) setMonitored(monitored bool) {
+	s.monitoredLock.Lock()
+	defer s.monitoredLock.Unlock()
+
+	s.monitored = monitored
+}
+
+func (s *systemdJobSupervisor) isMonitored() bool {
+	s.monitoredLock.Lock()
+	defer s.monitoredLock.Unlock()
+
+	return s.monitored
+}
+
+func (s *systemdJobSupervisor) stoppedFilePath() string {
+	return path.Join(s.dirProvider.SystemdDir(), "stopped")
+}
+
+func (s *systemdJobSupervisor) startedUnitsFilePath() string {
+	return path.Join(s.dirProvider.SystemdDir(), systemdStartedUnitsFileName)
+}
@@ -0,0 +1,335 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
+	fakesystemd "github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd/fakes"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("systemdJobSupervisor", func() {
+	const monitConfig = `
+check process nats
+  with pidfile /var/vcap/sys/run/nats/nats.pid
+  start program "/var/vcap/jobs/nats/bin/nats_ctl start 'with space'"
+    with timeout 60 seconds
+  stop program "/var/vcap/jobs/nats/bin/nats_ctl stop" # no quotes
+  as uid vcap
+  group vcap
+
+check process other-group
+  with pidfile /var/vcap/sys/run/other.pid
+  start program "/bin/other"
+  group other
+
+check file some-file with path /var/vcap/some-file
+  group vcap
+`
+
+	var (
+		manager     *fakesystemd.FakeManager
+		fs          *fakesys.FakeFileSystem
+		dirProvider boshdir.Provider
+		timeService *fakeclock.FakeClock
+		supervisor  JobSupervisor
+	)
+
+	BeforeEach(func() {
+		manager = fakesystemd.NewFakeManager()
+		fs = fakesys.NewFakeFileSystem()
+		dirProvider = boshdir.NewProvider("/var/vcap")
+		timeService = fakeclock.NewFakeClock(time.Now())
+
+		fs.GlobStub = func(pattern string) ([]string, error) {
+			paths, err := fs.Ls(filepath.Dir(pattern))
+			if err != nil {
+				return nil, err
+			}
+
+			var matches []string
+			for _, p := range paths {
+				if matched, _ := filepath.Match(pattern, p); matched {
+					matches = append(matches, p)
+				}
+			}
+			return matches, nil
+		}
+
+		supervisor = NewSystemdJobSupervisor(manager, fs, dirProvider, boshlog.NewLogger(boshlog.LevelNone), timeService)
+	})
+
+	addNatsJob := func() {
+		Expect(fs.WriteFileString("/var/vcap/jobs/nats/monit", monitConfig)).To(Succeed())
+		Expect(supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit")).To(Succeed())
+	}
+
+	Describe("AddJob", func() {
+		It("translates vcap processes of monit config into forking units", func() {
+			addNatsJob()
+			Expect(supervisor.Start()).To(Succeed())
+
+			Expect(manager.StartedUnits).To(Equal([]string{"bosh-job-nats.service"}))
+			Expect(manager.Specs["bosh-job-nats.service"]).To(Equal(systemd.UnitSpec{
+				Name:        "bosh-job-nats.service",
+				Description: "BOSH job nats process nats",
+				Slice:       "bosh.slice",
+				Type:        "forking",
+				ExecStart:   []string{"/var/vcap/jobs/nats/bin/nats_ctl", "start", "with space"},
+				ExecStop:    []string{"/var/vcap/jobs/nats/bin/nats_ctl", "stop"},
+				PIDFile:     "/var/vcap/sys/run/nats/nats.pid",
+				User:        "vcap",
+			}))
+		})
+
+		It("prefers per-process config next to monit file", func() {
+			Expect(fs.WriteFileString("/var/vcap/jobs/nats/monit", monitConfig)).To(Succeed())
+			Expect(fs.WriteFileString("/var/vcap/jobs/nats/processes.json", `{
+				"processes": [{
+					"name": "gnatsd",
+					"executable": "/var/vcap/packages/gnatsd/bin/gnatsd",
+					"args": ["-c", "/var/vcap/jobs/nats/config/gnatsd.conf"],
+					"env": {"B": "2", "A": "1"}
+				}]
+			}`)).To(Succeed())
+
+			Expect(supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit")).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Expect(manager.StartedUnits).To(Equal([]string{"bosh-job-gnatsd.service"}))
+			Expect(manager.Specs["bosh-job-gnatsd.service"]).To(Equal(systemd.UnitSpec{
+				Name:        "bosh-job-gnatsd.service",
+				Description: "BOSH job nats process gnatsd",
+				Slice:       "bosh.slice",
+				Type:        "simple",
+				ExecStart:   []string{"/var/vcap/packages/gnatsd/bin/gnatsd", "-c", "/var/vcap/jobs/nats/config/gnatsd.conf"},
+				Environment: []string{"A=1", "B=2"},
+			}))
+		})
+
+		It("does not write unit specs for jobs without processes", func() {
+			Expect(fs.WriteFileString("/var/vcap/jobs/errand/monit", "")).To(Succeed())
+			Expect(supervisor.AddJob("errand", 0, "/var/vcap/jobs/errand/monit")).To(Succeed())
+			Expect(fs.FileExists("/var/vcap/systemd/job/0000_errand.json")).To(BeFalse())
+		})
+
+		It("returns an error when monit config cannot be parsed", func() {
+			Expect(fs.WriteFileString("/var/vcap/jobs/nats/monit", "check process nats\n start program \"/bin/nats\n group vcap")).To(Succeed())
+			err := supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unterminated quoted string"))
+		})
+	})
+
+	Describe("RemoveAllJobs", func() {
+		It("removes unit specs so that Reload stops their units", func() {
+			addNatsJob()
+			Expect(supervisor.Start()).To(Succeed())
+
+			Expect(supervisor.RemoveAllJobs()).To(Succeed())
+			Expect(fs.FileExists("/var/vcap/systemd/job")).To(BeFalse())
+
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(manager.StoppedUnits).To(Equal([]string{"bosh-job-nats.service"}))
+		})
+	})
+
+	Describe("Start", func() {
+		It("restarts failed units and removes stopped file", func() {
+			addNatsJob()
+			Expect(fs.WriteFileString("/var/vcap/systemd/stopped", "")).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			manager.SetState("bosh-job-nats.service", systemd.StateFailed, "failed")
+			Expect(supervisor.Start()).To(Succeed())
+
+			Expect(manager.StartedUnits).To(Equal([]string{"bosh-job-nats.service", "bosh-job-nats.service"}))
+			Expect(fs.FileExists("/var/vcap/systemd/stopped")).To(BeFalse())
+		})
+
+		It("restarts active units whose spec changed", func() {
+			addNatsJob()
+			Expect(supervisor.Start()).To(Succeed())
+
+			changedConfig := strings.Replace(monitConfig, "'with space'", "'changed'", 1)
+			Expect(fs.WriteFileString("/var/vcap/jobs/nats/monit", changedConfig)).To(Succeed())
+			Expect(supervisor.AddJob("nats", 0, "/var/vcap/jobs/nats/monit")).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Expect(manager.StoppedUnits).To(Equal([]string{"bosh-job-nats.service"}))
+			Expect(manager.StartedUnits).To(Equal([]string{"bosh-job-nats.service", "bosh-job-nats.service"}))
+			Expect(manager.Specs["bosh-job-nats.service"].ExecStart).To(ContainElement("changed"))
+		})
+
+		It("keeps active units running when their spec did not change", func() {
+			addNatsJob()
+			Expect(supervisor.Start()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Expect(manager.StoppedUnits).To(BeEmpty())
+			Expect(manager.StartedUnits).To(Equal([]string{"bosh-job-nats.service"}))
+		})
+
+		It("keeps active units running when specs they were started with are unknown", func() {
+			addNatsJob()
+			Expect(supervisor.Start()).To(Succeed())
+			Expect(fs.RemoveAll("/var/vcap/systemd/started_units.json")).To(Succeed())
+
+			Expect(supervisor.Start()).To(Succeed())
+
+			Expect(manager.StoppedUnits).To(BeEmpty())
+			Expect(fs.FileExists("/var/vcap/systemd/started_units.json")).To(BeTrue())
+		})
+
+		It("returns an error when starting unit fails", func() {
+			addNatsJob()
+			manager.StartErr = errors.New("fake-start-err")
+
+			err := supervisor.Start()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-start-err"))
+		})
+	})
+
+	Describe("StopAndWait", func() {
+		It("stops all units and writes stopped file", func() {
+			addNatsJob()
+			Expect(supervisor.Start()).To(Succeed())
+
+			Expect(supervisor.StopAndWait()).To(Succeed())
+			Expect(manager.StoppedUnits).To(Equal([]string{"bosh-job-nats.service"}))
+			Expect(fs.FileExists("/var/vcap/systemd/stopped")).To(BeTrue())
+			Expect(supervisor.Status()).To(Equal("stopped"))
+		})
+	})
+
+	Describe("Status", func() {
+		BeforeEach(addNatsJob)
+
+		It("reports running when all units are active", func() {
+			Expect(supervisor.Start()).To(Succeed())
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+
+		It("reports starting when a unit is activating", func() {
+			Expect(supervisor.Start()).To(Succeed())
+			manager.SetState("bosh-job-nats.service", systemd.StateActivating, "start")
+			Expect(supervisor.Status()).To(Equal("starting"))
+		})
+
+		It("reports failing when a unit is not running", func() {
+			Expect(supervisor.Status()).To(Equal("failing"))
+		})
+
+		It("reports unknown when units cannot be listed", func() {
+			manager.ListErr = errors.New("fake-list-err")
+			Expect(supervisor.Status()).To(Equal("unknown"))
+		})
+	})
+
+	Describe("Processes", func() {
+		BeforeEach(func() {
+			addNatsJob()
+			Expect(fs.WriteFileString("/proc/meminfo", "MemTotal:        4096 kB\nMemFree:  1024 kB\n")).To(Succeed())
+		})
+
+		It("reports vitals from cgroup accounting", func() {
+			Expect(supervisor.Start()).To(Succeed())
+
+			manager.SetAccounting("bosh-job-nats.service", 1000000000, 1024*1024)
+			_, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+
+			timeService.Increment(2 * time.Second)
+			manager.SetAccounting("bosh-job-nats.service", 1500000000, 2048*1024)
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(HaveLen(1))
+			Expect(processes[0].Name).To(Equal("nats"))
+			Expect(processes[0].State).To(Equal("running"))
+			Expect(processes[0].Memory).To(Equal(MemoryVitals{Kb: 2048, Percent: 50}))
+			Expect(processes[0].CPU.Total).To(BeNumerically("~", 25, 0.001))
+		})
+
+		It("reports processes without units as stopped", func() {
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(Equal([]Process{{Name: "nats", State: "stopped"}}))
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		var alerts chan boshalert.MonitAlert
+
+		BeforeEach(func() {
+			addNatsJob()
+			alerts = make(chan boshalert.MonitAlert, 10)
+
+			go func() {
+				defer GinkgoRecover()
+				_ = supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+					alerts <- alert
+					return nil
+				})
+			}()
+
+			Eventually(manager.SubscriberCount).Should(Equal(1))
+		})
+
+		It("reports failed units of monitored jobs", func() {
+			Expect(supervisor.Start()).To(Succeed())
+
+			manager.SetState("bosh-job-nats.service", systemd.StateActivating, systemd.SubStateAutoRestart)
+
+			var alert boshalert.MonitAlert
+			Eventually(alerts).Should(Receive(&alert))
+			Expect(alert.Service).To(Equal("nats"))
+			Expect(alert.Event).To(Equal("does not exist"))
+			Expect(alert.Action).To(Equal("restart"))
+			Expect(alert.Date).To(Equal(timeService.Now().Format(time.RFC1123Z)))
+		})
+
+		It("does not report failures of stopped jobs", func() {
+			Expect(supervisor.Start()).To(Succeed())
+			Expect(supervisor.Stop()).To(Succeed())
+
+			manager.SetState("bosh-job-nats.service", systemd.StateFailed, "failed")
+			Consistently(alerts).ShouldNot(Receive())
+		})
+
+		It("does not report failures of unmonitored jobs", func() {
+			Expect(supervisor.Start()).To(Succeed())
+			Expect(supervisor.Unmonitor()).To(Succeed())
+
+			manager.SetState("bosh-job-nats.service", systemd.StateFailed, "failed")
+			Consistently(alerts).ShouldNot(Receive())
+		})
+	})
+
+	It("returns an error from MonitorJobFailures when systemd connection is lost", func() {
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- supervisor.MonitorJobFailures(func(boshalert.MonitAlert) error { return nil })
+		}()
+
+		Eventually(manager.SubscriberCount).Should(Equal(1))
+		manager.Disconnect()
+
+		var err error
+		Eventually(errCh).Should(Receive(&err))
+		Expect(err.Error()).To(ContainSubstring("Subscribing to unit changes"))
+	})
+})
@@ -0,0 +1,317 @@
+package jobsupervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
+)
+
+const (
+	systemdUnitPrefix  = "bosh-job-"
+	systemdUnitSuffix  = ".service"
+	systemdUnitPattern = systemdUnitPrefix + "*" + systemdUnitSuffix
+	systemdSlice       = "bosh.slice"
+
+	// Per-process config shipped by a job next to its monit file
+	systemdProcessesFileName = "processes.json"
+)
+
+var unsafeUnitNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+type systemdProcessesConfig struct {
+	Processes []systemdProcessConfig `json:"processes"`
+}
+
+type systemdProcessConfig struct {
+	Name       string            `json:"name"`
+	Executable string            `json:"executable"`
+	Args       []string          `json:"args"`
+	Env        map[string]string `json:"env"`
+	User       string            `json:"user"`
+}
+
+type monitProcess struct {
+	name         string
+	pidFile      string
+	startProgram string
+	stopProgram  string
+	user         string
+	groups       []string
+}
+
+func systemdUnitName(processName string) string {
+	return systemdUnitPrefix + unsafeUnitNameChars.ReplaceAllString(processName, "_") + systemdUnitSuffix
+}
+
+func systemdProcessName(unitName string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(unitName, systemdUnitPrefix), systemdUnitSuffix)
+}
+
+// unitSpecsFromProcessesConfig translates per-process config into
+// simple services whose main process is the configured executable.
+func unitSpecsFromProcessesConfig(jobName string, content []byte) ([]systemd.UnitSpec, error) {
+	var config systemdProcessesConfig
+
+	err := json.Unmarshal(content, &config)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling processes config")
+	}
+
+	var specs []systemd.UnitSpec
+
+	for _, process := range config.Processes {
+		if process.Name == "" || process.Executable == "" {
+			return nil, bosherr.Error("Process must have a name and an executable")
+		}
+
+		var env []string
+		for name, value := range process.Env {
+			env = append(env, name+"="+value)
+		}
+		sort.Strings(env)
+
+		specs = append(specs, systemd.UnitSpec{
+			Name:        systemdUnitName(process.Name),
+			Description: fmt.Sprintf("BOSH job %s process %s", jobName, process.Name),
+			Slice:       systemdSlice,
+			Type:        "simple",
+			ExecStart:   append([]string{process.Executable}, process.Args...),
+			Environment: env,
+			User:        process.User,
+		})
+	}
+
+	return specs, nil
+}
+
+// unitSpecsFromMonitConfig translates processes of the vcap group into
+// forking services, which track main process via pidfile like monit does.
+func unitSpecsFromMonitConfig(jobName string, content string) ([]systemd.UnitSpec, error) {
+	processes, err := parseMonitConfig(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []systemd.UnitSpec
+
+	for _, process := range processes {
+		if !process.inGroup("vcap") {
+			continue
+		}
+
+		if process.startProgram == "" {
+			return nil, bosherr.Errorf("Process %s does not have a start program", process.name)
+		}
+
+		execStart, err := splitMonitProgram(process.startProgram)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing start program of process %s", process.name)
+		}
+
+		var execStop []string
+		if process.stopProgram != "" {
+			execStop, err = splitMonitProgram(process.stopProgram)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Parsing stop program of process %s", process.name)
+			}
+		}
+
+		specs = append(specs, systemd.UnitSpec{
+			Name:        systemdUnitName(process.name),
+			Description: fmt.Sprintf("BOSH job %s process %s", jobName, process.name),
+			Slice:       systemdSlice,
+			Type:        "forking",
+			ExecStart:   execStart,
+			ExecStop:    execStop,
+			PIDFile:     process.pidFile,
+			User:        process.user,
+		})
+	}
+
+	return specs, nil
+}
+
+// parseMonitConfig understands the subset of monit control file syntax
+// used by BOSH jobs; other statements and services are ignored.
+func parseMonitConfig(content string) ([]monitProcess, error) {
+	tokens, err := tokenizeMonitConfig(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []monitProcess
+	var current *monitProcess
+
+	next := func(i int) string {
+		if i+1 < len(tokens) {
+			return tokens[i+1]
+		}
+		return ""
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "check":
+			if current != nil {
+				processes = append(processes, *current)
+				current = nil
+			}
+
+			if next(i) == "process" && next(i+1) != "" {
+				current = &monitProcess{name: next(i + 1)}
+				i += 2
+			}
+
+		case "pidfile":
+			if current != nil {
+				current.pidFile = next(i)
+				i++
+			}
+
+		case "program":
+			if current == nil || i == 0 {
+				continue
+			}
+
+			switch tokens[i-1] {
+			case "start":
+				current.startProgram = next(i)
+				i++
+			case "stop":
+				current.stopProgram = next(i)
+				i++
+			}
+
+		case "uid":
+			if current != nil && i > 0 && tokens[i-1] == "as" {
+				current.user = next(i)
+				i++
+			}
+
+		case "group":
+			if current != nil {
+				current.groups = append(current.groups, next(i))
+				i++
+			}
+		}
+	}
+
+	if current != nil {
+		processes = append(processes, *current)
+	}
+
+	return processes, nil
+}
+
+// tokenizeMonitConfig splits on whitespace, keeps double quoted strings
+// together and drops comments.
+func tokenizeMonitConfig(content string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+
+	inToken := false
+	inQuotes := false
+	inComment := false
+
+	for _, r := range content {
+		switch {
+		case inComment:
+			if r == '\n' {
+				inComment = false
+			}
+
+		case inQuotes:
+			if r == '"' {
+				inQuotes = false
+			} else {
+				token.WriteRune(r)
+			}
+
+		case r == '"':
+			inQuotes = true
+			inToken = true
+
+		case r == '#' && !inToken:
+			inComment = true
+
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+
+		default:
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if inQuotes {
+		return nil, bosherr.Error("Unterminated quoted string in monit config")
+	}
+
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens, nil
+}
+
+// splitMonitProgram splits program into arguments like monit does, which
+// only groups words enclosed in single quotes.
+func splitMonitProgram(program string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+
+	inArg := false
+	inQuotes := false
+
+	for _, r := range program {
+		switch {
+		case r == '\'':
+			inQuotes = !inQuotes
+			inArg = true
+
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if inQuotes {
+		return nil, bosherr.Errorf("Unterminated quoted string in '%s'", program)
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	if len(args) == 0 {
+		return nil, bosherr.Error("Program is empty")
+	}
+
+	return args, nil
+}
+
+func (p monitProcess) inGroup(group string) bool {
+	for _, g := range p.groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
@@ -48,13 +48,7 @@ func runAgent(opts boshapp.Options, logger logger.Logger) chan error {
 	return errCh
 }
 
-func startAgent(logger logger.Logger) error {
-	opts, err := boshapp.ParseOptions(os.Args)
-	if err != nil {
-		logger.Error(mainLogTag, "Parsing options %s", err.Error())
-		return err
-	}
-
+func startAgent(opts boshapp.Options, logger logger.Logger) error {
 	if opts.VersionCheck {
 		fmt.Println(VersionLabel)
 		os.Exit(0)
@@ -75,11 +69,19 @@ func startAgent(logger logger.Logger) error {
 }
 
 func main() {
-	asyncLog := logger.NewAsyncWriterLogger(logger.LevelDebug, os.Stderr)
-	logger := newSignalableLogger(asyncLog)
+	if len(os.Args) > 1 && os.Args[1] == "ctl" {
+		os.Exit(runCtl(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	opts, optsErr := boshapp.ParseOptions(os.Args)
+
+	logger := newSignalableLogger(newLogger(opts.LogFormat))
 
 	exitCode := 0
-	if err := startAgent(logger); err != nil {
+	if optsErr != nil {
+		logger.Error(mainLogTag, "Parsing options %s", optsErr.Error())
+		exitCode = 1
+	} else if err := startAgent(opts, logger); err != nil {
 		logger.Error(mainLogTag, "Agent exited with error: %s", err)
 		exitCode = 1
 	}
@@ -89,6 +91,13 @@ func main() {
 	os.Exit(exitCode)
 }
 
+func newLogger(format string) logger.Logger {
+	if format == agentlogger.FormatJSON {
+		return agentlogger.NewJSONLogger(logger.LevelDebug, os.Stderr)
+	}
+	return logger.NewAsyncWriterLogger(logger.LevelDebug, os.Stderr)
+}
+
 func newSignalableLogger(logger logger.Logger) logger.Logger {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGSEGV)
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cloudfoundry/bosh-agent/admin"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+)
+
+const ctlUsage = `Usage: bosh-agent ctl [options] <action> [argument...]
+
+Runs an agent action over the local admin socket, e.g.
+
+  bosh-agent ctl get_state full
+  bosh-agent ctl get_task <task-id>
+
+Arguments are passed as JSON when they parse as such and as strings
+otherwise. Only read-only actions (get_state, get_task, info, list_disk,
+list_tasks, ping) are allowed unless -allow-mutating is given.
+
+Options:
+`
+
+func runCtl(args []string, stdout, stderr io.Writer) int {
+	flagSet := flag.NewFlagSet("bosh-agent ctl", flag.ContinueOnError)
+	flagSet.SetOutput(stderr)
+	flagSet.Usage = func() {
+		fmt.Fprint(stderr, ctlUsage)
+		flagSet.PrintDefaults()
+	}
+
+	baseDirectory := flagSet.String("b", "/var/vcap", "Base directory of the agent")
+	socketPath := flagSet.String("socket", "", "Admin socket path (defaults to one under base directory)")
+	allowMutating := flagSet.Bool("allow-mutating", false, "Allow actions which change the VM")
+	noWait := flagSet.Bool("no-wait", false, "Do not wait for asynchronous actions to finish")
+
+	if err := flagSet.Parse(args); err != nil {
+		return 2
+	}
+
+	if flagSet.NArg() < 1 {
+		flagSet.Usage()
+		return 2
+	}
+
+	method := flagSet.Arg(0)
+
+	if !admin.IsReadOnly(method) && !*allowMutating {
+		fmt.Fprintf(stderr, "Action '%s' may change the VM, pass -allow-mutating to run it\n", method)
+		return 2
+	}
+
+	arguments := []interface{}{}
+	for _, arg := range flagSet.Args()[1:] {
+		var value interface{}
+		if json.Unmarshal([]byte(arg), &value) != nil {
+			value = arg
+		}
+		arguments = append(arguments, value)
+	}
+
+	if *socketPath == "" {
+		*socketPath = boshdirs.NewProvider(*baseDirectory).AdminSocketPath()
+	}
+
+	client := admin.NewClient(*socketPath)
+
+	resp, err := client.Call(method, arguments, *allowMutating)
+	if err == nil && !*noWait && method != "get_task" {
+		resp, err = client.Wait(resp, time.Second)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	if resp.Exception != nil {
+		fmt.Fprintln(stderr, resp.Exception.Message)
+		return 1
+	}
+
+	output, err := json.MarshalIndent(resp.Value, "", "  ")
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	fmt.Fprintln(stdout, string(output))
+
+	return 0
+}
@@ -0,0 +1,91 @@
+package mbus
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const unknownTotal = -1
+
+type contentRange struct {
+	first int64
+	last  int64
+	total int64
+
+	// "bytes */<total>" asks for upload status without sending data
+	statusOnly bool
+}
+
+func (c contentRange) length() int64 {
+	return c.last - c.first + 1
+}
+
+func (c contentRange) isLast() bool {
+	return c.total != unknownTotal && c.last+1 == c.total
+}
+
+func parseContentRange(header string) (contentRange, error) {
+	var parsed contentRange
+
+	if !strings.HasPrefix(header, "bytes ") {
+		return parsed, bosherr.Errorf("Unsupported Content-Range '%s'", header)
+	}
+
+	spec := strings.TrimPrefix(header, "bytes ")
+
+	rangeSpec, totalSpec, found := strings.Cut(spec, "/")
+	if !found {
+		return parsed, bosherr.Errorf("Missing total length in Content-Range '%s'", header)
+	}
+
+	parsed.total = unknownTotal
+	if totalSpec != "*" {
+		total, err := strconv.ParseInt(totalSpec, 10, 64)
+		if err != nil || total < 0 {
+			return parsed, bosherr.Errorf("Invalid total length in Content-Range '%s'", header)
+		}
+		parsed.total = total
+	}
+
+	if rangeSpec == "*" {
+		parsed.statusOnly = true
+		return parsed, nil
+	}
+
+	firstSpec, lastSpec, found := strings.Cut(rangeSpec, "-")
+	if !found {
+		return parsed, bosherr.Errorf("Invalid range in Content-Range '%s'", header)
+	}
+
+	first, err := strconv.ParseInt(firstSpec, 10, 64)
+	if err != nil {
+		return parsed, bosherr.Errorf("Invalid range start in Content-Range '%s'", header)
+	}
+
+	last, err := strconv.ParseInt(lastSpec, 10, 64)
+	if err != nil {
+		return parsed, bosherr.Errorf("Invalid range end in Content-Range '%s'", header)
+	}
+
+	if first < 0 || last < first || (parsed.total != unknownTotal && last >= parsed.total) {
+		return parsed, bosherr.Errorf("Invalid range in Content-Range '%s'", header)
+	}
+
+	parsed.first = first
+	parsed.last = last
+
+	return parsed, nil
+}
+
+type statusRecordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusRecordingResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
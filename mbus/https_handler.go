@@ -1,22 +1,30 @@
 package mbus
 
 import (
-	"bufio"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"time"
 
 	"github.com/cloudfoundry/bosh-agent/platform"
 	"github.com/cloudfoundry/bosh-agent/settings"
 
 	boshagentblobstore "github.com/cloudfoundry/bosh-agent/agent/blobstore"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
-const httpsHandlerLogTag = "https_handler"
+const (
+	httpsHandlerLogTag = "https_handler"
+
+	// Value is a multiple digest, e.g. "sha1:abc;sha256:def"
+	blobDigestHeader   = "X-Blob-Digest"
+	uploadOffsetHeader = "X-Upload-Offset"
+)
 
 type HTTPSHandler struct {
 	parsedURL   *url.URL
@@ -87,16 +95,18 @@ func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.Func) func(http.Respo
 			return
 		}
 
-		respBytes, _, err := boshhandler.PerformHandlerWithJSON(
+		respBytes, req, err := boshhandler.PerformHandlerWithJSON(
 			rawJSONPayload,
 			handlerFunc,
 			boshhandler.UnlimitedResponseLength,
 			h.logger,
 		)
 
+		requestLogger := req.Logger(h.logger)
+
 		if err != nil {
 			err = bosherr.WrapError(err, "Running handler in a nice JSON sandwich")
-			h.logger.Error(httpsHandlerLogTag, err.Error())
+			requestLogger.Error(httpsHandlerLogTag, err.Error())
 			w.WriteHeader(500)
 			h.generateCEFLog(r, 500, "")
 
@@ -106,7 +116,7 @@ func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.Func) func(http.Respo
 		_, err = w.Write(respBytes)
 		if err != nil {
 			err = bosherr.WrapError(err, "Writing response")
-			h.logger.Error(httpsHandlerLogTag, err.Error())
+			requestLogger.Error(httpsHandlerLogTag, err.Error())
 		}
 		h.generateCEFLog(r, 200, "")
 	}
@@ -129,13 +139,82 @@ func (h HTTPSHandler) blobsHandler() func(http.ResponseWriter, *http.Request) {
 func (h HTTPSHandler) putBlob(w http.ResponseWriter, r *http.Request) {
 	_, blobID := path.Split(r.URL.Path)
 
+	if r.Header.Get("Content-Range") != "" {
+		h.putBlobChunk(w, r, blobID)
+		return
+	}
+
 	err := h.blobManager.Write(blobID, r.Body)
 	if err != nil {
-		w.WriteHeader(500)
-		h.generateCEFLog(r, 500, "")
-		if _, wErr := w.Write([]byte(err.Error())); wErr != nil {
-			h.logger.Error(httpsHandlerLogTag, "Failed to write response body: %s", wErr.Error())
+		h.writeBlobError(w, r, 500, err)
+		return
+	}
+
+	w.WriteHeader(201)
+	h.generateCEFLog(r, 201, "")
+}
+
+// putBlobChunk implements resumable uploads. Each request carries
+// "Content-Range: bytes <first>-<last>/<total>" for the next chunk; "*" may be
+// used as total until the last chunk. "Content-Range: bytes */<total>" with
+// no body only reports how many bytes were received so far. The last chunk
+// must carry a blob digest header which is verified before the blob is stored.
+func (h HTTPSHandler) putBlobChunk(w http.ResponseWriter, r *http.Request, blobID string) {
+	chunkRange, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		h.writeBlobError(w, r, 400, err)
+		return
+	}
+
+	if chunkRange.statusOnly {
+		offset, err := h.blobManager.UploadOffset(blobID)
+		if err != nil {
+			h.writeBlobError(w, r, 500, err)
+			return
+		}
+
+		h.writeUploadOffset(w, r, 200, offset)
+		return
+	}
+
+	var digest boshcrypto.MultipleDigest
+
+	if chunkRange.isLast() {
+		digest, err = boshcrypto.ParseMultipleDigest(r.Header.Get(blobDigestHeader))
+		if err != nil {
+			h.writeBlobError(w, r, 400, bosherr.WrapErrorf(err, "Parsing %s header", blobDigestHeader))
+			return
+		}
+	}
+
+	chunk := io.LimitReader(r.Body, chunkRange.length())
+
+	offset, err := h.blobManager.WriteChunk(blobID, chunkRange.first, chunk)
+	if err != nil {
+		if _, ok := err.(boshagentblobstore.UploadOffsetMismatchError); ok {
+			w.Header().Set(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+			h.writeBlobError(w, r, 409, err)
+			return
 		}
+
+		h.writeBlobError(w, r, 500, err)
+		return
+	}
+
+	if offset != chunkRange.last+1 {
+		// Connection dropped mid chunk; client resumes from reported offset
+		h.writeUploadOffset(w, r, 400, offset)
+		return
+	}
+
+	if !chunkRange.isLast() {
+		h.writeUploadOffset(w, r, 202, offset)
+		return
+	}
+
+	err = h.blobManager.CommitUpload(blobID, digest)
+	if err != nil {
+		h.writeBlobError(w, r, 422, err)
 		return
 	}
 
@@ -143,6 +222,21 @@ func (h HTTPSHandler) putBlob(w http.ResponseWriter, r *http.Request) {
 	h.generateCEFLog(r, 201, "")
 }
 
+func (h HTTPSHandler) writeUploadOffset(w http.ResponseWriter, r *http.Request, statusCode int, offset int64) {
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+	w.WriteHeader(statusCode)
+	h.generateCEFLog(r, statusCode, "")
+}
+
+func (h HTTPSHandler) writeBlobError(w http.ResponseWriter, r *http.Request, statusCode int, err error) {
+	w.WriteHeader(statusCode)
+	h.generateCEFLog(r, statusCode, "")
+	if _, wErr := w.Write([]byte(err.Error())); wErr != nil {
+		h.logger.Error(httpsHandlerLogTag, "Failed to write response body: %s", wErr.Error())
+	}
+}
+
+// getBlob supports Range requests so that interrupted downloads can be resumed.
 func (h HTTPSHandler) getBlob(w http.ResponseWriter, r *http.Request) {
 	_, blobID := path.Split(r.URL.Path)
 
@@ -150,17 +244,23 @@ func (h HTTPSHandler) getBlob(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		h.logger.Error(httpsHandlerLogTag, "Failed to fetch blob: %s", err.Error())
 		w.WriteHeader(statusCode)
-	} else {
-		defer func() {
-			_ = file.Close()
-		}()
-		reader := bufio.NewReader(file)
-		if _, wErr := io.Copy(w, reader); wErr != nil {
-			h.logger.Error(httpsHandlerLogTag, "Failed to write response body: %s", wErr.Error())
-		}
+		h.generateCEFLog(r, statusCode, "")
+		return
 	}
 
-	h.generateCEFLog(r, statusCode, "")
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var modTime time.Time
+	if info, err := file.Stat(); err == nil {
+		modTime = info.ModTime()
+	}
+
+	recorder := &statusRecordingResponseWriter{ResponseWriter: w, statusCode: 200}
+	http.ServeContent(recorder, r, "", modTime, file)
+
+	h.generateCEFLog(r, recorder.statusCode, "")
 }
 
 func (h HTTPSHandler) generateCEFLog(r *http.Request, respStatusCode int, respJSON string) {
@@ -18,6 +18,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/mbus"
 	"github.com/cloudfoundry/bosh-agent/platform/fakes"
 	"github.com/cloudfoundry/bosh-agent/settings"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
@@ -163,6 +164,25 @@ var _ = Describe("HTTPSHandler", func() {
 					})
 				})
 
+				It("returns the requested byte range", func() {
+					err := blobManager.Write("123-456-789", strings.NewReader("Some data"))
+					Expect(err).NotTo(HaveOccurred())
+
+					request, err := http.NewRequest("GET", serverURL+"/blobs/123-456-789", nil)
+					Expect(err).ToNot(HaveOccurred())
+					request.Header.Set("Range", "bytes=5-")
+
+					httpResponse, err := httpClient.Do(request)
+					Expect(err).ToNot(HaveOccurred())
+					defer httpResponse.Body.Close()
+
+					httpBody, readErr := io.ReadAll(httpResponse.Body)
+					Expect(readErr).ToNot(HaveOccurred())
+					Expect(httpResponse.StatusCode).To(Equal(206))
+					Expect(httpResponse.Header.Get("Content-Range")).To(Equal("bytes 5-8/9"))
+					Expect(httpBody).To(Equal([]byte("data")))
+				})
+
 				Context("when file does not exist", func() {
 					It("returns a 404", func() {
 						httpResponse, err := httpClient.Get(serverURL + "/blobs/a-file-that-does-not-exist")
@@ -200,6 +220,76 @@ var _ = Describe("HTTPSHandler", func() {
 					Expect(string(contents)).To(Equal("Updated data"))
 				})
 
+				Context("when uploading in chunks", func() {
+					putChunk := func(chunk, contentRange, digest string) *http.Response {
+						request, err := http.NewRequest("PUT", serverURL+"/blobs/a5/123-456-789", strings.NewReader(chunk))
+						Expect(err).ToNot(HaveOccurred())
+						request.Header.Set("Content-Range", contentRange)
+						if digest != "" {
+							request.Header.Set("X-Blob-Digest", digest)
+						}
+
+						httpResponse, err := httpClient.Do(request)
+						Expect(err).ToNot(HaveOccurred())
+						httpResponse.Body.Close()
+
+						return httpResponse
+					}
+
+					var digest string
+
+					BeforeEach(func() {
+						multipleDigest, err := boshcrypto.NewMultipleDigest(strings.NewReader("Updated data"), []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1})
+						Expect(err).ToNot(HaveOccurred())
+						digest = multipleDigest.String()
+					})
+
+					It("stores the blob once the last chunk is verified", func() {
+						httpResponse := putChunk("Updated ", "bytes 0-7/*", "")
+						Expect(httpResponse.StatusCode).To(Equal(202))
+						Expect(httpResponse.Header.Get("X-Upload-Offset")).To(Equal("8"))
+						Expect(blobManager.BlobExists("123-456-789")).To(BeFalse())
+
+						httpResponse = putChunk("", "bytes */12", "")
+						Expect(httpResponse.StatusCode).To(Equal(200))
+						Expect(httpResponse.Header.Get("X-Upload-Offset")).To(Equal("8"))
+
+						httpResponse = putChunk("data", "bytes 8-11/12", digest)
+						Expect(httpResponse.StatusCode).To(Equal(201))
+
+						file, _, err := blobManager.Fetch("123-456-789")
+						Expect(err).NotTo(HaveOccurred())
+						defer file.Close()
+
+						contents, err := io.ReadAll(file)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(string(contents)).To(Equal("Updated data"))
+					})
+
+					It("rejects chunks that do not continue the upload", func() {
+						httpResponse := putChunk("Updated ", "bytes 0-7/*", "")
+						Expect(httpResponse.StatusCode).To(Equal(202))
+
+						httpResponse = putChunk("ata", "bytes 9-11/12", digest)
+						Expect(httpResponse.StatusCode).To(Equal(409))
+						Expect(httpResponse.Header.Get("X-Upload-Offset")).To(Equal("8"))
+					})
+
+					It("rejects the last chunk without a digest", func() {
+						httpResponse := putChunk("Updated data", "bytes 0-11/12", "")
+						Expect(httpResponse.StatusCode).To(Equal(400))
+					})
+
+					It("discards the upload when digest does not match", func() {
+						httpResponse := putChunk("Updated data", "bytes 0-11/12", "sha1:0000000000000000000000000000000000000000")
+						Expect(httpResponse.StatusCode).To(Equal(422))
+						Expect(blobManager.BlobExists("123-456-789")).To(BeFalse())
+
+						httpResponse = putChunk("", "bytes */12", "")
+						Expect(httpResponse.Header.Get("X-Upload-Offset")).To(Equal("0"))
+					})
+				})
+
 				Context("when an incorrect username and password is provided", func() {
 					It("returns a 401", func() {
 						err := blobManager.Write("123-456-789", strings.NewReader("Some data"))
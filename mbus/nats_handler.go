@@ -248,8 +248,10 @@ func (h *natsHandler) handleNatsMsg(natsMsg *nats.Msg, handlerFunc boshhandler.F
 		h.logger,
 	)
 
+	requestLogger := req.Logger(h.logger)
+
 	if err != nil {
-		h.logger.Error(h.logTag, "Running handler: %s", err)
+		requestLogger.Error(h.logTag, "Running handler: %s", err)
 		h.generateCEFLog(natsMsg, 7, err.Error())
 		return
 	}
@@ -258,7 +260,7 @@ func (h *natsHandler) handleNatsMsg(natsMsg *nats.Msg, handlerFunc boshhandler.F
 		err = h.connection.Publish(req.ReplyTo, respBytes)
 		if err != nil {
 			h.generateCEFLog(natsMsg, 7, err.Error())
-			h.logger.Error(h.logTag, "Publishing to the client: %s", err.Error())
+			requestLogger.Error(h.logTag, "Publishing to the client: %s", err.Error())
 			return
 		}
 	}
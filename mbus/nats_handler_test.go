@@ -102,7 +102,7 @@ func init() { //nolint:funlen,gochecknoinits
 					ReplyTo: "reply to me!",
 					Method:  "ping",
 					Payload: expectedPayload,
-				}))
+				}.WithLogger(logger)))
 
 				Expect(connection.PublishCallCount()).To(Equal(1))
 				subj, message := connection.PublishArgsForCall(0)
@@ -211,13 +211,13 @@ func init() { //nolint:funlen,gochecknoinits
 					ReplyTo: "fake-reply-to",
 					Method:  "ping",
 					Payload: expectedPayload,
-				}))
+				}.WithLogger(logger)))
 
 				Expect(secondHandlerRequest).To(Equal(boshhandler.Request{
 					ReplyTo: "fake-reply-to",
 					Method:  "ping",
 					Payload: expectedPayload,
-				}))
+				}.WithLogger(logger)))
 
 				// Bosh handler responses were sent
 				Expect(connection.PublishCallCount()).To(Equal(2))
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	boshagent "github.com/cloudfoundry/bosh-agent/agent"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+)
+
+type actionDispatcher struct {
+	delegate boshagent.ActionDispatcher
+	registry *Registry
+}
+
+// NewActionDispatcher counts every dispatched request by method and
+// whether the dispatcher responded with an exception.
+func NewActionDispatcher(delegate boshagent.ActionDispatcher, registry *Registry) boshagent.ActionDispatcher {
+	return actionDispatcher{delegate: delegate, registry: registry}
+}
+
+func (d actionDispatcher) ResumePreviouslyDispatchedTasks() {
+	d.delegate.ResumePreviouslyDispatchedTasks()
+}
+
+func (d actionDispatcher) Dispatch(req boshhandler.Request) boshhandler.Response {
+	resp := d.delegate.Dispatch(req)
+	d.registry.RecordAction(req.Method, boshhandler.IsExceptionResponse(resp))
+	return resp
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+)
+
+type handler struct {
+	boshhandler.Handler
+	registry *Registry
+}
+
+// NewHandler counts heartbeats sent through the wrapped handler.
+// Every send attempt is counted, including retries.
+func NewHandler(delegate boshhandler.Handler, registry *Registry) boshhandler.Handler {
+	return handler{Handler: delegate, registry: registry}
+}
+
+func (h handler) Send(target boshhandler.Target, topic boshhandler.Topic, message interface{}) error {
+	err := h.Handler.Send(target, topic, message)
+
+	if topic == boshhandler.Heartbeat {
+		h.registry.RecordHeartbeat(err != nil)
+	}
+
+	return err
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+)
+
+const metricPrefix = "bosh_agent_"
+
+type label struct {
+	name  string
+	value string
+}
+
+type openMetricsWriter struct {
+	w io.Writer
+}
+
+func newOpenMetricsWriter(w io.Writer) openMetricsWriter {
+	return openMetricsWriter{w: w}
+}
+
+func (o openMetricsWriter) Family(name, metricType, help string) {
+	fmt.Fprintf(o.w, "# TYPE %s%s %s\n", metricPrefix, name, metricType)
+	fmt.Fprintf(o.w, "# HELP %s%s %s\n", metricPrefix, name, help)
+}
+
+func (o openMetricsWriter) Sample(name string, value float64, labels ...label) {
+	fmt.Fprintf(o.w, "%s%s", metricPrefix, name)
+
+	if len(labels) > 0 {
+		pairs := make([]string, len(labels))
+		for i, l := range labels {
+			pairs[i] = fmt.Sprintf("%s=\"%s\"", l.name, escapeLabelValue(l.value))
+		}
+		fmt.Fprintf(o.w, "{%s}", strings.Join(pairs, ","))
+	}
+
+	fmt.Fprintf(o.w, " %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (o openMetricsWriter) Finish() {
+	fmt.Fprint(o.w, "# EOF\n")
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// sampleString writes vitals which are reported as strings in heartbeats.
+// Missing or unparsable values are skipped rather than reported as zero.
+func (o openMetricsWriter) sampleString(name, value string, labels ...label) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	o.Sample(name, f, labels...)
+}
+
+func writeVitals(o openMetricsWriter, vitals boshvitals.Vitals) {
+	o.Family("cpu_percent", "gauge", "CPU usage percent by mode.")
+	o.sampleString("cpu_percent", vitals.CPU.User, label{"mode", "user"})
+	o.sampleString("cpu_percent", vitals.CPU.Sys, label{"mode", "sys"})
+	o.sampleString("cpu_percent", vitals.CPU.Wait, label{"mode", "wait"})
+
+	loadPeriods := []string{"1m", "5m", "15m"}
+	o.Family("load_average", "gauge", "System load average.")
+	for i, load := range vitals.Load {
+		if i < len(loadPeriods) {
+			o.sampleString("load_average", load, label{"period", loadPeriods[i]})
+		}
+	}
+
+	o.Family("memory_used_kilobytes", "gauge", "Used memory in kilobytes.")
+	o.sampleString("memory_used_kilobytes", vitals.Mem.Kb)
+	o.Family("memory_used_percent", "gauge", "Used memory percent.")
+	o.sampleString("memory_used_percent", vitals.Mem.Percent)
+
+	o.Family("swap_used_kilobytes", "gauge", "Used swap in kilobytes.")
+	o.sampleString("swap_used_kilobytes", vitals.Swap.Kb)
+	o.Family("swap_used_percent", "gauge", "Used swap percent.")
+	o.sampleString("swap_used_percent", vitals.Swap.Percent)
+
+	diskNames := sortedDiskNames(vitals.Disk)
+
+	o.Family("disk_used_percent", "gauge", "Used disk space percent.")
+	for _, name := range diskNames {
+		o.sampleString("disk_used_percent", vitals.Disk[name].Percent, label{"disk", name})
+	}
+
+	o.Family("disk_inodes_used_percent", "gauge", "Used disk inodes percent.")
+	for _, name := range diskNames {
+		o.sampleString("disk_inodes_used_percent", vitals.Disk[name].InodePercent, label{"disk", name})
+	}
+
+	o.Family("uptime_seconds", "gauge", "System uptime in seconds.")
+	o.Sample("uptime_seconds", float64(vitals.Uptime.Secs))
+}
+
+func writeProcesses(o openMetricsWriter, processes []boshjobsuper.Process) {
+	o.Family("process_running", "gauge", "Whether job process is running.")
+	for _, p := range processes {
+		running := 0.0
+		if p.State == "running" {
+			running = 1
+		}
+		o.Sample("process_running", running, label{"process", p.Name}, label{"state", p.State})
+	}
+
+	o.Family("process_uptime_seconds", "gauge", "Job process uptime in seconds.")
+	for _, p := range processes {
+		o.Sample("process_uptime_seconds", float64(p.Uptime.Secs), label{"process", p.Name})
+	}
+
+	o.Family("process_memory_kilobytes", "gauge", "Job process memory usage in kilobytes.")
+	for _, p := range processes {
+		o.Sample("process_memory_kilobytes", float64(p.Memory.Kb), label{"process", p.Name})
+	}
+
+	o.Family("process_memory_percent", "gauge", "Job process memory usage percent.")
+	for _, p := range processes {
+		o.Sample("process_memory_percent", p.Memory.Percent, label{"process", p.Name})
+	}
+
+	o.Family("process_cpu_percent", "gauge", "Job process CPU usage percent.")
+	for _, p := range processes {
+		o.Sample("process_cpu_percent", p.CPU.Total, label{"process", p.Name})
+	}
+}
+
+func writeRegistry(o openMetricsWriter, registry *Registry) {
+	o.Family("actions_dispatched", "counter", "Dispatched agent actions by method and result.")
+	for _, c := range registry.ActionCounts() {
+		o.Sample("actions_dispatched_total", float64(c.Count), label{"method", c.Method}, label{"result", c.Result})
+	}
+
+	successes, failures := registry.HeartbeatCounts()
+	o.Family("heartbeats", "counter", "Heartbeat send attempts by result.")
+	o.Sample("heartbeats_total", float64(successes), label{"result", ResultSucceeded})
+	o.Sample("heartbeats_total", float64(failures), label{"result", ResultFailed})
+}
+
+func sortedDiskNames(disks boshvitals.DiskVitals) []string {
+	names := make([]string, 0, len(disks))
+	for name := range disks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+)
+
+type ActionCount struct {
+	Method string
+	Result string
+	Count  uint64
+}
+
+// Registry keeps agent counters which are not part of vitals.
+// It is safe for concurrent use.
+type Registry struct {
+	actions            map[actionKey]uint64
+	heartbeatSuccesses uint64
+	heartbeatFailures  uint64
+	lock               sync.Mutex
+}
+
+type actionKey struct {
+	method string
+	result string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{actions: map[actionKey]uint64{}}
+}
+
+func (r *Registry) RecordAction(method string, failed bool) {
+	result := ResultSucceeded
+	if failed {
+		result = ResultFailed
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.actions[actionKey{method: method, result: result}]++
+}
+
+func (r *Registry) RecordHeartbeat(failed bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if failed {
+		r.heartbeatFailures++
+	} else {
+		r.heartbeatSuccesses++
+	}
+}
+
+// ActionCounts returns dispatch counts ordered by method and result.
+func (r *Registry) ActionCounts() []ActionCount {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	counts := make([]ActionCount, 0, len(r.actions))
+	for key, count := range r.actions {
+		counts = append(counts, ActionCount{Method: key.method, Result: key.result, Count: count})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Method != counts[j].Method {
+			return counts[i].Method < counts[j].Method
+		}
+		return counts[i].Result < counts[j].Result
+	})
+
+	return counts
+}
+
+func (r *Registry) HeartbeatCounts() (successes, failures uint64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.heartbeatSuccesses, r.heartbeatFailures
+}
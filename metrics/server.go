@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"time"
+
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	serverLogTag = "MetricsServer"
+
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+type Options struct {
+	// Address to listen on, e.g. "127.0.0.1:9100". Exporter is disabled when empty.
+	Address string
+}
+
+func (o Options) Enabled() bool {
+	return o.Address != ""
+}
+
+type Server struct {
+	options       Options
+	vitalsService boshvitals.Service
+	jobSupervisor boshjobsuper.JobSupervisor
+	registry      *Registry
+	logger        boshlog.Logger
+
+	listener net.Listener
+	server   *http.Server
+}
+
+func NewServer(
+	options Options,
+	vitalsService boshvitals.Service,
+	jobSupervisor boshjobsuper.JobSupervisor,
+	registry *Registry,
+	logger boshlog.Logger,
+) *Server {
+	return &Server{
+		options:       options,
+		vitalsService: vitalsService,
+		jobSupervisor: jobSupervisor,
+		registry:      registry,
+		logger:        logger,
+	}
+}
+
+// Start begins serving /metrics in the background.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.options.Address)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listening on %s", s.options.Address)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.ServeHTTP)
+
+	s.listener = listener
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		defer s.logger.HandlePanic("Metrics Server")
+
+		s.logger.Info(serverLogTag, "Serving metrics on %s", listener.Addr())
+
+		err := s.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			s.logger.Error(serverLogTag, "Serving metrics: %s", err.Error())
+		}
+	}()
+
+	return nil
+}
+
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+func (s *Server) Stop() {
+	if s.server != nil {
+		_ = s.server.Close()
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := newOpenMetricsWriter(&buf)
+
+	vitals, err := s.vitalsService.Get()
+	if err != nil {
+		s.logger.Error(serverLogTag, "Getting vitals: %s", err.Error())
+	} else {
+		writeVitals(writer, vitals)
+	}
+
+	processes, err := s.jobSupervisor.Processes()
+	if err != nil {
+		s.logger.Error(serverLogTag, "Getting processes: %s", err.Error())
+	} else {
+		writeProcesses(writer, processes)
+	}
+
+	writeRegistry(writer, s.registry)
+	writer.Finish()
+
+	w.Header().Set("Content-Type", openMetricsContentType)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,160 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakeagent "github.com/cloudfoundry/bosh-agent/agent/fakes"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
+	. "github.com/cloudfoundry/bosh-agent/metrics"
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+	"github.com/cloudfoundry/bosh-agent/platform/vitals/vitalsfakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		vitalsService *vitalsfakes.FakeService
+		jobSupervisor *fakejobsuper.FakeJobSupervisor
+		registry      *Registry
+		server        *Server
+	)
+
+	BeforeEach(func() {
+		vitalsService = &vitalsfakes.FakeService{}
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		registry = NewRegistry()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		server = NewServer(Options{Address: "127.0.0.1:0"}, vitalsService, jobSupervisor, registry, logger)
+	})
+
+	scrape := func() (*httptest.ResponseRecorder, string) {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		return recorder, recorder.Body.String()
+	}
+
+	It("exposes vitals in OpenMetrics text format", func() {
+		vitalsService.GetReturns(boshvitals.Vitals{
+			CPU:  boshvitals.CPUVitals{User: "1.5", Sys: "0.5", Wait: "0.1"},
+			Load: []string{"0.09", "0.04", "0.01"},
+			Mem:  boshvitals.MemoryVitals{Kb: "145996", Percent: "3"},
+			Swap: boshvitals.MemoryVitals{Kb: "0", Percent: "0"},
+			Disk: boshvitals.DiskVitals{
+				"system":    boshvitals.SpecificDiskVitals{Percent: "82", InodePercent: "10"},
+				"ephemeral": boshvitals.SpecificDiskVitals{Percent: "5", InodePercent: "1"},
+			},
+			Uptime: boshvitals.UptimeVitals{Secs: 1234},
+		}, nil)
+
+		recorder, body := scrape()
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(HavePrefix("application/openmetrics-text"))
+
+		Expect(body).To(ContainSubstring("# TYPE bosh_agent_cpu_percent gauge\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_cpu_percent{mode="user"} 1.5` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_load_average{period="15m"} 0.01` + "\n"))
+		Expect(body).To(ContainSubstring("bosh_agent_memory_used_kilobytes 145996\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_disk_used_percent{disk="ephemeral"} 5` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_disk_inodes_used_percent{disk="system"} 10` + "\n"))
+		Expect(body).To(ContainSubstring("bosh_agent_uptime_seconds 1234\n"))
+		Expect(body).To(HaveSuffix("# EOF\n"))
+	})
+
+	It("skips vitals that are not reported", func() {
+		vitalsService.GetReturns(boshvitals.Vitals{}, nil)
+
+		_, body := scrape()
+		Expect(body).To(ContainSubstring("# TYPE bosh_agent_cpu_percent gauge\n"))
+		Expect(body).ToNot(ContainSubstring("bosh_agent_cpu_percent{"))
+	})
+
+	It("exposes job process stats", func() {
+		jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+			{
+				Name:   "fake-process",
+				State:  "running",
+				Uptime: boshjobsuper.UptimeVitals{Secs: 10},
+				Memory: boshjobsuper.MemoryVitals{Kb: 100, Percent: 0.5},
+				CPU:    boshjobsuper.CPUVitals{Total: 1.25},
+			},
+			{Name: "fake-\"quoted\"-process", State: "failing"},
+		}
+
+		_, body := scrape()
+		Expect(body).To(ContainSubstring(`bosh_agent_process_running{process="fake-process",state="running"} 1` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_process_running{process="fake-\"quoted\"-process",state="failing"} 0` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_process_uptime_seconds{process="fake-process"} 10` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_process_memory_kilobytes{process="fake-process"} 100` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_process_memory_percent{process="fake-process"} 0.5` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_process_cpu_percent{process="fake-process"} 1.25` + "\n"))
+	})
+
+	It("still serves counters when vitals and processes fail", func() {
+		vitalsService.GetReturns(boshvitals.Vitals{}, errors.New("fake-vitals-err"))
+		jobSupervisor.ProcessesError = errors.New("fake-processes-err")
+
+		recorder, body := scrape()
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(body).ToNot(ContainSubstring("bosh_agent_cpu_percent"))
+		Expect(body).To(ContainSubstring(`bosh_agent_heartbeats_total{result="succeeded"} 0` + "\n"))
+	})
+
+	It("exposes action dispatch counters", func() {
+		delegate := &fakeagent.FakeActionDispatcher{DispatchResp: boshhandler.NewValueResponse("fake-value")}
+		dispatcher := NewActionDispatcher(delegate, registry)
+
+		dispatcher.Dispatch(boshhandler.Request{Method: "ping"})
+		dispatcher.Dispatch(boshhandler.Request{Method: "ping"})
+
+		delegate.DispatchResp = boshhandler.NewExceptionResponse(errors.New("fake-err"))
+		dispatcher.Dispatch(boshhandler.Request{Method: "apply"})
+
+		_, body := scrape()
+		Expect(body).To(ContainSubstring("# TYPE bosh_agent_actions_dispatched counter\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_actions_dispatched_total{method="apply",result="failed"} 1` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_actions_dispatched_total{method="ping",result="succeeded"} 2` + "\n"))
+	})
+
+	It("exposes heartbeat counters", func() {
+		delegate := fakembus.NewFakeHandler()
+		handler := NewHandler(delegate, registry)
+
+		Expect(handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, "fake-heartbeat")).To(Succeed())
+		Expect(handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, "fake-alert")).To(Succeed())
+
+		delegate.SendErr = errors.New("fake-send-err")
+		Expect(handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, "fake-heartbeat")).ToNot(Succeed())
+
+		_, body := scrape()
+		Expect(body).To(ContainSubstring(`bosh_agent_heartbeats_total{result="succeeded"} 1` + "\n"))
+		Expect(body).To(ContainSubstring(`bosh_agent_heartbeats_total{result="failed"} 1` + "\n"))
+	})
+
+	It("serves metrics over HTTP once started", func() {
+		Expect(server.Start()).To(Succeed())
+		defer server.Stop()
+
+		resp, err := http.Get("http://" + server.Addr().String() + "/metrics")
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(HaveSuffix("# EOF\n"))
+	})
+
+	It("rejects methods other than GET", func() {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest("POST", "/metrics", nil))
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
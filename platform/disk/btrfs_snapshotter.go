@@ -0,0 +1,263 @@
+package disk
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	btrfsSnapshotterLogTag = "btrfsSnapshotter"
+
+	// SnapshotsDirName is kept at the root of the snapshotted filesystem
+	SnapshotsDirName = ".snapshots"
+
+	btrfsCreationTimeLayout = "2006-01-02 15:04:05 -0700"
+
+	// Snapshot is copied next to live data before swapping them
+	restoreStagingDirName = ".snapshot-restore"
+	restoreTrashDirName   = ".snapshot-restore-trash"
+)
+
+var snapshotNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9._-]*$`)
+
+type btrfsSnapshotter struct {
+	runner boshsys.CmdRunner
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+}
+
+// NewBtrfsSnapshotter keeps read-only btrfs subvolume snapshots
+// in a hidden directory at the root of the snapshotted filesystem.
+func NewBtrfsSnapshotter(runner boshsys.CmdRunner, fs boshsys.FileSystem, logger boshlog.Logger) Snapshotter {
+	return btrfsSnapshotter{
+		runner: runner,
+		fs:     fs,
+		logger: logger,
+	}
+}
+
+func (s btrfsSnapshotter) CreateSnapshot(mountPoint, name string) (Snapshot, error) {
+	snapshotPath, err := s.snapshotPath(mountPoint, name)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	if s.fs.FileExists(snapshotPath) {
+		return Snapshot{}, bosherr.Errorf("Snapshot '%s' already exists", name)
+	}
+
+	err = s.fs.MkdirAll(path.Join(mountPoint, SnapshotsDirName), 0700)
+	if err != nil {
+		return Snapshot{}, bosherr.WrapError(err, "Creating snapshots directory")
+	}
+
+	s.logger.Info(btrfsSnapshotterLogTag, "Creating snapshot of %s at %s", mountPoint, snapshotPath)
+
+	_, _, _, err = s.runner.RunCommand("btrfs", "subvolume", "snapshot", "-r", mountPoint, snapshotPath)
+	if err != nil {
+		return Snapshot{}, bosherr.WrapErrorf(err, "Creating snapshot '%s'", name)
+	}
+
+	return s.snapshot(snapshotPath)
+}
+
+func (s btrfsSnapshotter) ListSnapshots(mountPoint string) ([]Snapshot, error) {
+	err := s.ensureBtrfs(mountPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotPaths, err := s.fs.Glob(path.Join(mountPoint, SnapshotsDirName, "*"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing snapshots directory")
+	}
+
+	snapshots := []Snapshot{}
+
+	for _, snapshotPath := range snapshotPaths {
+		snapshot, err := s.snapshot(snapshotPath)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+func (s btrfsSnapshotter) RestoreSnapshot(mountPoint, name string) error {
+	snapshotPath, err := s.snapshotPath(mountPoint, name)
+	if err != nil {
+		return err
+	}
+
+	if !s.fs.FileExists(snapshotPath) {
+		return bosherr.Errorf("Snapshot '%s' does not exist", name)
+	}
+
+	s.logger.Info(btrfsSnapshotterLogTag, "Restoring %s from %s", mountPoint, snapshotPath)
+
+	stagingDir := path.Join(mountPoint, restoreStagingDirName)
+	trashDir := path.Join(mountPoint, restoreTrashDirName)
+
+	// Leftovers of an interrupted restore
+	for _, dir := range []string{stagingDir, trashDir} {
+		err = s.fs.RemoveAll(dir)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing '%s'", dir)
+		}
+	}
+
+	err = s.fs.MkdirAll(stagingDir, 0700)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating restore staging directory")
+	}
+
+	// Live data is only touched once snapshot was copied; reflinks share
+	// extents with the snapshot so copying does not need extra space.
+	// Files are renamed afterwards which is not possible across subvolumes.
+	_, _, _, err = s.runner.RunCommand("cp", "-a", "--reflink=always", snapshotPath+"/.", stagingDir+"/")
+	if err != nil {
+		_ = s.fs.RemoveAll(stagingDir)
+		return bosherr.WrapErrorf(err, "Restoring snapshot '%s'", name)
+	}
+
+	err = s.fs.MkdirAll(trashDir, 0700)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating restore trash directory")
+	}
+
+	err = s.moveEntries(mountPoint, trashDir)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Moving current contents of %s to %s", mountPoint, trashDir)
+	}
+
+	err = s.moveEntries(stagingDir, mountPoint)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Moving restored contents to %s, previous contents are kept in %s", mountPoint, trashDir)
+	}
+
+	for _, dir := range []string{stagingDir, trashDir} {
+		err = s.fs.RemoveAll(dir)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing '%s'", dir)
+		}
+	}
+
+	return nil
+}
+
+// moveEntries renames all entries of srcDir, including hidden ones, into dstDir
+// except for snapshots and directories used while restoring
+func (s btrfsSnapshotter) moveEntries(srcDir, dstDir string) error {
+	var entries []string
+
+	// Glob does not list . and .. entries
+	for _, pattern := range []string{"*", ".*"} {
+		matches, err := s.fs.Glob(path.Join(srcDir, pattern))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Listing contents of '%s'", srcDir)
+		}
+		entries = append(entries, matches...)
+	}
+
+	for _, entry := range entries {
+		switch path.Base(entry) {
+		case SnapshotsDirName, restoreStagingDirName, restoreTrashDirName:
+			continue
+		}
+
+		err := s.fs.Rename(entry, path.Join(dstDir, path.Base(entry)))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Moving '%s'", entry)
+		}
+	}
+
+	return nil
+}
+
+func (s btrfsSnapshotter) DeleteSnapshot(mountPoint, name string) error {
+	snapshotPath, err := s.snapshotPath(mountPoint, name)
+	if err != nil {
+		return err
+	}
+
+	if !s.fs.FileExists(snapshotPath) {
+		return bosherr.Errorf("Snapshot '%s' does not exist", name)
+	}
+
+	s.logger.Info(btrfsSnapshotterLogTag, "Deleting snapshot %s", snapshotPath)
+
+	_, _, _, err = s.runner.RunCommand("btrfs", "subvolume", "delete", snapshotPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Deleting snapshot '%s'", name)
+	}
+
+	return nil
+}
+
+func (s btrfsSnapshotter) snapshotPath(mountPoint, name string) (string, error) {
+	if !snapshotNameRegexp.MatchString(name) {
+		return "", bosherr.Errorf("Invalid snapshot name '%s'", name)
+	}
+
+	err := s.ensureBtrfs(mountPoint)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(mountPoint, SnapshotsDirName, name), nil
+}
+
+func (s btrfsSnapshotter) ensureBtrfs(mountPoint string) error {
+	stdout, _, _, err := s.runner.RunCommand("stat", "-f", "-c", "%T", mountPoint)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Determining filesystem type of '%s'", mountPoint)
+	}
+
+	if strings.TrimSpace(stdout) != string(FileSystemBtrfs) {
+		return bosherr.Errorf("Snapshots are only supported on btrfs, '%s' is %s", mountPoint, strings.TrimSpace(stdout))
+	}
+
+	return nil
+}
+
+func (s btrfsSnapshotter) snapshot(snapshotPath string) (Snapshot, error) {
+	stdout, _, _, err := s.runner.RunCommand("btrfs", "subvolume", "show", snapshotPath)
+	if err != nil {
+		return Snapshot{}, bosherr.WrapErrorf(err, "Showing snapshot '%s'", snapshotPath)
+	}
+
+	snapshot := Snapshot{
+		Name: path.Base(snapshotPath),
+		Path: snapshotPath,
+	}
+
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(fields) != 2 || fields[0] != "Creation time" {
+			continue
+		}
+
+		createdAt, err := time.Parse(btrfsCreationTimeLayout, strings.TrimSpace(fields[1]))
+		if err != nil {
+			return Snapshot{}, bosherr.WrapErrorf(err, "Parsing creation time of snapshot '%s'", snapshotPath)
+		}
+
+		snapshot.CreatedAt = createdAt.UTC()
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,195 @@
+package disk_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("btrfsSnapshotter", func() {
+	var (
+		runner      *fakesys.FakeCmdRunner
+		fs          *fakesys.FakeFileSystem
+		snapshotter Snapshotter
+		fsType      string
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		fs = fakesys.NewFakeFileSystem()
+		snapshotter = NewBtrfsSnapshotter(runner, fs, boshlog.NewLogger(boshlog.LevelNone))
+		fsType = "btrfs"
+	})
+
+	JustBeforeEach(func() {
+		runner.AddCmdResult("stat -f -c %T /var/vcap/store", fakesys.FakeCmdResult{Stdout: fsType + "\n"})
+	})
+
+	showOutput := func(createdAt string) fakesys.FakeCmdResult {
+		return fakesys.FakeCmdResult{Stdout: `pre-deploy
+	Name: 			pre-deploy
+	Flags: 			readonly
+	Creation time: 		` + createdAt + `
+	Generation: 		12
+`}
+	}
+
+	Describe("CreateSnapshot", func() {
+		It("creates read-only snapshot in snapshots directory", func() {
+			runner.AddCmdResult("btrfs subvolume show /var/vcap/store/.snapshots/pre-deploy", showOutput("2020-01-02 03:04:05 +0100"))
+
+			snapshot, err := snapshotter.CreateSnapshot("/var/vcap/store", "pre-deploy")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot).To(Equal(Snapshot{
+				Name:      "pre-deploy",
+				Path:      "/var/vcap/store/.snapshots/pre-deploy",
+				CreatedAt: time.Date(2020, 1, 2, 2, 4, 5, 0, time.UTC),
+			}))
+
+			Expect(fs.FileExists("/var/vcap/store/.snapshots")).To(BeTrue())
+			Expect(runner.RunCommands).To(ContainElement(
+				[]string{"btrfs", "subvolume", "snapshot", "-r", "/var/vcap/store", "/var/vcap/store/.snapshots/pre-deploy"},
+			))
+		})
+
+		It("rejects invalid snapshot names", func() {
+			for _, name := range []string{"", "..", ".hidden", "a/b", "a b"} {
+				_, err := snapshotter.CreateSnapshot("/var/vcap/store", name)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Invalid snapshot name"))
+			}
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+
+		Context("when filesystem is not btrfs", func() {
+			BeforeEach(func() {
+				fsType = "ext2/ext3"
+			})
+
+			It("returns an error", func() {
+				_, err := snapshotter.CreateSnapshot("/var/vcap/store", "pre-deploy")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("only supported on btrfs"))
+			})
+		})
+
+		It("returns an error when snapshot already exists", func() {
+			Expect(fs.MkdirAll("/var/vcap/store/.snapshots/pre-deploy", 0700)).To(Succeed())
+
+			_, err := snapshotter.CreateSnapshot("/var/vcap/store", "pre-deploy")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("already exists"))
+		})
+
+		It("returns an error when snapshotting fails", func() {
+			runner.AddCmdResult("btrfs subvolume snapshot -r /var/vcap/store /var/vcap/store/.snapshots/pre-deploy", fakesys.FakeCmdResult{Error: errors.New("fake-err")})
+
+			_, err := snapshotter.CreateSnapshot("/var/vcap/store", "pre-deploy")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("ListSnapshots", func() {
+		It("lists snapshots ordered by creation time", func() {
+			fs.SetGlob("/var/vcap/store/.snapshots/*", []string{
+				"/var/vcap/store/.snapshots/newer",
+				"/var/vcap/store/.snapshots/older",
+			})
+			runner.AddCmdResult("btrfs subvolume show /var/vcap/store/.snapshots/newer", showOutput("2020-01-03 00:00:00 +0000"))
+			runner.AddCmdResult("btrfs subvolume show /var/vcap/store/.snapshots/older", showOutput("2020-01-02 00:00:00 +0000"))
+
+			snapshots, err := snapshotter.ListSnapshots("/var/vcap/store")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(2))
+			Expect(snapshots[0].Name).To(Equal("older"))
+			Expect(snapshots[1].Name).To(Equal("newer"))
+		})
+
+		It("returns empty list when there are no snapshots", func() {
+			snapshots, err := snapshotter.ListSnapshots("/var/vcap/store")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(BeEmpty())
+		})
+	})
+
+	Describe("RestoreSnapshot", func() {
+		BeforeEach(func() {
+			Expect(fs.MkdirAll("/var/vcap/store/.snapshots/pre-deploy", 0700)).To(Succeed())
+			Expect(fs.WriteFileString("/var/vcap/store/db/data", "new")).To(Succeed())
+			Expect(fs.WriteFileString("/var/vcap/store/.config", "new")).To(Succeed())
+		})
+
+		It("replaces contents of the mount point with contents of the snapshot", func() {
+			runner.AddCmdResult("cp -a --reflink=always /var/vcap/store/.snapshots/pre-deploy/. /var/vcap/store/.snapshot-restore/", fakesys.FakeCmdResult{})
+			fs.GlobStub = func(pattern string) ([]string, error) {
+				// Snapshot contains empty directory in place of nested snapshots subvolume
+				switch pattern {
+				case "/var/vcap/store/*":
+					return []string{"/var/vcap/store/db"}, nil
+				case "/var/vcap/store/.*":
+					return []string{"/var/vcap/store/.config", "/var/vcap/store/.snapshot-restore", "/var/vcap/store/.snapshot-restore-trash", "/var/vcap/store/.snapshots"}, nil
+				case "/var/vcap/store/.snapshot-restore/*":
+					Expect(fs.WriteFileString("/var/vcap/store/.snapshot-restore/db/data", "old")).To(Succeed())
+					return []string{"/var/vcap/store/.snapshot-restore/db"}, nil
+				case "/var/vcap/store/.snapshot-restore/.*":
+					Expect(fs.MkdirAll("/var/vcap/store/.snapshot-restore/.snapshots", 0700)).To(Succeed())
+					return []string{"/var/vcap/store/.snapshot-restore/.snapshots"}, nil
+				}
+				return nil, nil
+			}
+
+			Expect(snapshotter.RestoreSnapshot("/var/vcap/store", "pre-deploy")).To(Succeed())
+
+			Expect(fs.ReadFileString("/var/vcap/store/db/data")).To(Equal("old"))
+			Expect(fs.FileExists("/var/vcap/store/.config")).To(BeFalse())
+			Expect(fs.FileExists("/var/vcap/store/.snapshots/pre-deploy")).To(BeTrue())
+			Expect(fs.FileExists("/var/vcap/store/.snapshot-restore")).To(BeFalse())
+			Expect(fs.FileExists("/var/vcap/store/.snapshot-restore-trash")).To(BeFalse())
+		})
+
+		It("keeps live data when copying snapshot fails", func() {
+			runner.AddCmdResult("cp -a --reflink=always /var/vcap/store/.snapshots/pre-deploy/. /var/vcap/store/.snapshot-restore/", fakesys.FakeCmdResult{Error: errors.New("fake-cp-err")})
+			fs.SetGlob("/var/vcap/store/*", []string{"/var/vcap/store/db"})
+			fs.SetGlob("/var/vcap/store/.*", []string{"/var/vcap/store/.config", "/var/vcap/store/.snapshots"})
+
+			err := snapshotter.RestoreSnapshot("/var/vcap/store", "pre-deploy")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-cp-err"))
+
+			Expect(fs.ReadFileString("/var/vcap/store/db/data")).To(Equal("new"))
+			Expect(fs.ReadFileString("/var/vcap/store/.config")).To(Equal("new"))
+			Expect(fs.FileExists("/var/vcap/store/.snapshot-restore")).To(BeFalse())
+		})
+
+		It("returns an error when snapshot does not exist", func() {
+			err := snapshotter.RestoreSnapshot("/var/vcap/store", "missing")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not exist"))
+			Expect(fs.FileExists("/var/vcap/store/db/data")).To(BeTrue())
+		})
+	})
+
+	Describe("DeleteSnapshot", func() {
+		It("deletes snapshot subvolume", func() {
+			Expect(fs.MkdirAll("/var/vcap/store/.snapshots/pre-deploy", 0700)).To(Succeed())
+
+			Expect(snapshotter.DeleteSnapshot("/var/vcap/store", "pre-deploy")).To(Succeed())
+			Expect(runner.RunCommands).To(ContainElement(
+				[]string{"btrfs", "subvolume", "delete", "/var/vcap/store/.snapshots/pre-deploy"},
+			))
+		})
+
+		It("returns an error when snapshot does not exist", func() {
+			err := snapshotter.DeleteSnapshot("/var/vcap/store", "missing")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not exist"))
+		})
+	})
+})
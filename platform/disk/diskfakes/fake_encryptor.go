@@ -0,0 +1,440 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package diskfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeEncryptor struct {
+	CloseStub        func(string) error
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct {
+		arg1 string
+	}
+	closeReturns struct {
+		result1 error
+	}
+	closeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	EncryptStub        func(string, []byte) error
+	encryptMutex       sync.RWMutex
+	encryptArgsForCall []struct {
+		arg1 string
+		arg2 []byte
+	}
+	encryptReturns struct {
+		result1 error
+	}
+	encryptReturnsOnCall map[int]struct {
+		result1 error
+	}
+	IsEncryptedStub        func(string) (bool, error)
+	isEncryptedMutex       sync.RWMutex
+	isEncryptedArgsForCall []struct {
+		arg1 string
+	}
+	isEncryptedReturns struct {
+		result1 bool
+		result2 error
+	}
+	isEncryptedReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	OpenStub        func(string, string, []byte) (string, error)
+	openMutex       sync.RWMutex
+	openArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}
+	openReturns struct {
+		result1 string
+		result2 error
+	}
+	openReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	ResizeStub        func(string, []byte) error
+	resizeMutex       sync.RWMutex
+	resizeArgsForCall []struct {
+		arg1 string
+		arg2 []byte
+	}
+	resizeReturns struct {
+		result1 error
+	}
+	resizeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeEncryptor) Close(arg1 string) error {
+	fake.closeMutex.Lock()
+	ret, specificReturn := fake.closeReturnsOnCall[len(fake.closeArgsForCall)]
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CloseStub
+	fakeReturns := fake.closeReturns
+	fake.recordInvocation("Close", []interface{}{arg1})
+	fake.closeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeEncryptor) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeEncryptor) CloseCalls(stub func(string) error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = stub
+}
+
+func (fake *FakeEncryptor) CloseArgsForCall(i int) string {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	argsForCall := fake.closeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEncryptor) CloseReturns(result1 error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = nil
+	fake.closeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEncryptor) CloseReturnsOnCall(i int, result1 error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = nil
+	if fake.closeReturnsOnCall == nil {
+		fake.closeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.closeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEncryptor) Encrypt(arg1 string, arg2 []byte) error {
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.encryptMutex.Lock()
+	ret, specificReturn := fake.encryptReturnsOnCall[len(fake.encryptArgsForCall)]
+	fake.encryptArgsForCall = append(fake.encryptArgsForCall, struct {
+		arg1 string
+		arg2 []byte
+	}{arg1, arg2Copy})
+	stub := fake.EncryptStub
+	fakeReturns := fake.encryptReturns
+	fake.recordInvocation("Encrypt", []interface{}{arg1, arg2Copy})
+	fake.encryptMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeEncryptor) EncryptCallCount() int {
+	fake.encryptMutex.RLock()
+	defer fake.encryptMutex.RUnlock()
+	return len(fake.encryptArgsForCall)
+}
+
+func (fake *FakeEncryptor) EncryptCalls(stub func(string, []byte) error) {
+	fake.encryptMutex.Lock()
+	defer fake.encryptMutex.Unlock()
+	fake.EncryptStub = stub
+}
+
+func (fake *FakeEncryptor) EncryptArgsForCall(i int) (string, []byte) {
+	fake.encryptMutex.RLock()
+	defer fake.encryptMutex.RUnlock()
+	argsForCall := fake.encryptArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeEncryptor) EncryptReturns(result1 error) {
+	fake.encryptMutex.Lock()
+	defer fake.encryptMutex.Unlock()
+	fake.EncryptStub = nil
+	fake.encryptReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEncryptor) EncryptReturnsOnCall(i int, result1 error) {
+	fake.encryptMutex.Lock()
+	defer fake.encryptMutex.Unlock()
+	fake.EncryptStub = nil
+	if fake.encryptReturnsOnCall == nil {
+		fake.encryptReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.encryptReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEncryptor) IsEncrypted(arg1 string) (bool, error) {
+	fake.isEncryptedMutex.Lock()
+	ret, specificReturn := fake.isEncryptedReturnsOnCall[len(fake.isEncryptedArgsForCall)]
+	fake.isEncryptedArgsForCall = append(fake.isEncryptedArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.IsEncryptedStub
+	fakeReturns := fake.isEncryptedReturns
+	fake.recordInvocation("IsEncrypted", []interface{}{arg1})
+	fake.isEncryptedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEncryptor) IsEncryptedCallCount() int {
+	fake.isEncryptedMutex.RLock()
+	defer fake.isEncryptedMutex.RUnlock()
+	return len(fake.isEncryptedArgsForCall)
+}
+
+func (fake *FakeEncryptor) IsEncryptedCalls(stub func(string) (bool, error)) {
+	fake.isEncryptedMutex.Lock()
+	defer fake.isEncryptedMutex.Unlock()
+	fake.IsEncryptedStub = stub
+}
+
+func (fake *FakeEncryptor) IsEncryptedArgsForCall(i int) string {
+	fake.isEncryptedMutex.RLock()
+	defer fake.isEncryptedMutex.RUnlock()
+	argsForCall := fake.isEncryptedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEncryptor) IsEncryptedReturns(result1 bool, result2 error) {
+	fake.isEncryptedMutex.Lock()
+	defer fake.isEncryptedMutex.Unlock()
+	fake.IsEncryptedStub = nil
+	fake.isEncryptedReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEncryptor) IsEncryptedReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.isEncryptedMutex.Lock()
+	defer fake.isEncryptedMutex.Unlock()
+	fake.IsEncryptedStub = nil
+	if fake.isEncryptedReturnsOnCall == nil {
+		fake.isEncryptedReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.isEncryptedReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEncryptor) Open(arg1 string, arg2 string, arg3 []byte) (string, error) {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.openMutex.Lock()
+	ret, specificReturn := fake.openReturnsOnCall[len(fake.openArgsForCall)]
+	fake.openArgsForCall = append(fake.openArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}{arg1, arg2, arg3Copy})
+	stub := fake.OpenStub
+	fakeReturns := fake.openReturns
+	fake.recordInvocation("Open", []interface{}{arg1, arg2, arg3Copy})
+	fake.openMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEncryptor) OpenCallCount() int {
+	fake.openMutex.RLock()
+	defer fake.openMutex.RUnlock()
+	return len(fake.openArgsForCall)
+}
+
+func (fake *FakeEncryptor) OpenCalls(stub func(string, string, []byte) (string, error)) {
+	fake.openMutex.Lock()
+	defer fake.openMutex.Unlock()
+	fake.OpenStub = stub
+}
+
+func (fake *FakeEncryptor) OpenArgsForCall(i int) (string, string, []byte) {
+	fake.openMutex.RLock()
+	defer fake.openMutex.RUnlock()
+	argsForCall := fake.openArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeEncryptor) OpenReturns(result1 string, result2 error) {
+	fake.openMutex.Lock()
+	defer fake.openMutex.Unlock()
+	fake.OpenStub = nil
+	fake.openReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEncryptor) OpenReturnsOnCall(i int, result1 string, result2 error) {
+	fake.openMutex.Lock()
+	defer fake.openMutex.Unlock()
+	fake.OpenStub = nil
+	if fake.openReturnsOnCall == nil {
+		fake.openReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.openReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEncryptor) Resize(arg1 string, arg2 []byte) error {
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.resizeMutex.Lock()
+	ret, specificReturn := fake.resizeReturnsOnCall[len(fake.resizeArgsForCall)]
+	fake.resizeArgsForCall = append(fake.resizeArgsForCall, struct {
+		arg1 string
+		arg2 []byte
+	}{arg1, arg2Copy})
+	stub := fake.ResizeStub
+	fakeReturns := fake.resizeReturns
+	fake.recordInvocation("Resize", []interface{}{arg1, arg2Copy})
+	fake.resizeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeEncryptor) ResizeCallCount() int {
+	fake.resizeMutex.RLock()
+	defer fake.resizeMutex.RUnlock()
+	return len(fake.resizeArgsForCall)
+}
+
+func (fake *FakeEncryptor) ResizeCalls(stub func(string, []byte) error) {
+	fake.resizeMutex.Lock()
+	defer fake.resizeMutex.Unlock()
+	fake.ResizeStub = stub
+}
+
+func (fake *FakeEncryptor) ResizeArgsForCall(i int) (string, []byte) {
+	fake.resizeMutex.RLock()
+	defer fake.resizeMutex.RUnlock()
+	argsForCall := fake.resizeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeEncryptor) ResizeReturns(result1 error) {
+	fake.resizeMutex.Lock()
+	defer fake.resizeMutex.Unlock()
+	fake.ResizeStub = nil
+	fake.resizeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEncryptor) ResizeReturnsOnCall(i int, result1 error) {
+	fake.resizeMutex.Lock()
+	defer fake.resizeMutex.Unlock()
+	fake.ResizeStub = nil
+	if fake.resizeReturnsOnCall == nil {
+		fake.resizeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.resizeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEncryptor) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	fake.encryptMutex.RLock()
+	defer fake.encryptMutex.RUnlock()
+	fake.isEncryptedMutex.RLock()
+	defer fake.isEncryptedMutex.RUnlock()
+	fake.openMutex.RLock()
+	defer fake.openMutex.RUnlock()
+	fake.resizeMutex.RLock()
+	defer fake.resizeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeEncryptor) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ disk.Encryptor = new(FakeEncryptor)
@@ -8,6 +8,16 @@ import (
 )
 
 type FakeManager struct {
+	GetEncryptorStub        func() disk.Encryptor
+	getEncryptorMutex       sync.RWMutex
+	getEncryptorArgsForCall []struct {
+	}
+	getEncryptorReturns struct {
+		result1 disk.Encryptor
+	}
+	getEncryptorReturnsOnCall map[int]struct {
+		result1 disk.Encryptor
+	}
 	GetEphemeralDevicePartitionerStub        func() disk.Partitioner
 	getEphemeralDevicePartitionerMutex       sync.RWMutex
 	getEphemeralDevicePartitionerArgsForCall []struct {
@@ -28,6 +38,16 @@ type FakeManager struct {
 	getFormatterReturnsOnCall map[int]struct {
 		result1 disk.Formatter
 	}
+	GetMigratorStub        func() disk.Migrator
+	getMigratorMutex       sync.RWMutex
+	getMigratorArgsForCall []struct {
+	}
+	getMigratorReturns struct {
+		result1 disk.Migrator
+	}
+	getMigratorReturnsOnCall map[int]struct {
+		result1 disk.Migrator
+	}
 	GetMounterStub        func() disk.Mounter
 	getMounterMutex       sync.RWMutex
 	getMounterArgsForCall []struct {
@@ -71,6 +91,16 @@ type FakeManager struct {
 	getRootDevicePartitionerReturnsOnCall map[int]struct {
 		result1 disk.Partitioner
 	}
+	GetSnapshotterStub        func() disk.Snapshotter
+	getSnapshotterMutex       sync.RWMutex
+	getSnapshotterArgsForCall []struct {
+	}
+	getSnapshotterReturns struct {
+		result1 disk.Snapshotter
+	}
+	getSnapshotterReturnsOnCall map[int]struct {
+		result1 disk.Snapshotter
+	}
 	GetUtilStub        func() disk.Util
 	getUtilMutex       sync.RWMutex
 	getUtilArgsForCall []struct {
@@ -85,6 +115,59 @@ type FakeManager struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeManager) GetEncryptor() disk.Encryptor {
+	fake.getEncryptorMutex.Lock()
+	ret, specificReturn := fake.getEncryptorReturnsOnCall[len(fake.getEncryptorArgsForCall)]
+	fake.getEncryptorArgsForCall = append(fake.getEncryptorArgsForCall, struct {
+	}{})
+	stub := fake.GetEncryptorStub
+	fakeReturns := fake.getEncryptorReturns
+	fake.recordInvocation("GetEncryptor", []interface{}{})
+	fake.getEncryptorMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) GetEncryptorCallCount() int {
+	fake.getEncryptorMutex.RLock()
+	defer fake.getEncryptorMutex.RUnlock()
+	return len(fake.getEncryptorArgsForCall)
+}
+
+func (fake *FakeManager) GetEncryptorCalls(stub func() disk.Encryptor) {
+	fake.getEncryptorMutex.Lock()
+	defer fake.getEncryptorMutex.Unlock()
+	fake.GetEncryptorStub = stub
+}
+
+func (fake *FakeManager) GetEncryptorReturns(result1 disk.Encryptor) {
+	fake.getEncryptorMutex.Lock()
+	defer fake.getEncryptorMutex.Unlock()
+	fake.GetEncryptorStub = nil
+	fake.getEncryptorReturns = struct {
+		result1 disk.Encryptor
+	}{result1}
+}
+
+func (fake *FakeManager) GetEncryptorReturnsOnCall(i int, result1 disk.Encryptor) {
+	fake.getEncryptorMutex.Lock()
+	defer fake.getEncryptorMutex.Unlock()
+	fake.GetEncryptorStub = nil
+	if fake.getEncryptorReturnsOnCall == nil {
+		fake.getEncryptorReturnsOnCall = make(map[int]struct {
+			result1 disk.Encryptor
+		})
+	}
+	fake.getEncryptorReturnsOnCall[i] = struct {
+		result1 disk.Encryptor
+	}{result1}
+}
+
 func (fake *FakeManager) GetEphemeralDevicePartitioner() disk.Partitioner {
 	fake.getEphemeralDevicePartitionerMutex.Lock()
 	ret, specificReturn := fake.getEphemeralDevicePartitionerReturnsOnCall[len(fake.getEphemeralDevicePartitionerArgsForCall)]
@@ -191,6 +274,59 @@ func (fake *FakeManager) GetFormatterReturnsOnCall(i int, result1 disk.Formatter
 	}{result1}
 }
 
+func (fake *FakeManager) GetMigrator() disk.Migrator {
+	fake.getMigratorMutex.Lock()
+	ret, specificReturn := fake.getMigratorReturnsOnCall[len(fake.getMigratorArgsForCall)]
+	fake.getMigratorArgsForCall = append(fake.getMigratorArgsForCall, struct {
+	}{})
+	stub := fake.GetMigratorStub
+	fakeReturns := fake.getMigratorReturns
+	fake.recordInvocation("GetMigrator", []interface{}{})
+	fake.getMigratorMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) GetMigratorCallCount() int {
+	fake.getMigratorMutex.RLock()
+	defer fake.getMigratorMutex.RUnlock()
+	return len(fake.getMigratorArgsForCall)
+}
+
+func (fake *FakeManager) GetMigratorCalls(stub func() disk.Migrator) {
+	fake.getMigratorMutex.Lock()
+	defer fake.getMigratorMutex.Unlock()
+	fake.GetMigratorStub = stub
+}
+
+func (fake *FakeManager) GetMigratorReturns(result1 disk.Migrator) {
+	fake.getMigratorMutex.Lock()
+	defer fake.getMigratorMutex.Unlock()
+	fake.GetMigratorStub = nil
+	fake.getMigratorReturns = struct {
+		result1 disk.Migrator
+	}{result1}
+}
+
+func (fake *FakeManager) GetMigratorReturnsOnCall(i int, result1 disk.Migrator) {
+	fake.getMigratorMutex.Lock()
+	defer fake.getMigratorMutex.Unlock()
+	fake.GetMigratorStub = nil
+	if fake.getMigratorReturnsOnCall == nil {
+		fake.getMigratorReturnsOnCall = make(map[int]struct {
+			result1 disk.Migrator
+		})
+	}
+	fake.getMigratorReturnsOnCall[i] = struct {
+		result1 disk.Migrator
+	}{result1}
+}
+
 func (fake *FakeManager) GetMounter() disk.Mounter {
 	fake.getMounterMutex.Lock()
 	ret, specificReturn := fake.getMounterReturnsOnCall[len(fake.getMounterArgsForCall)]
@@ -414,6 +550,59 @@ func (fake *FakeManager) GetRootDevicePartitionerReturnsOnCall(i int, result1 di
 	}{result1}
 }
 
+func (fake *FakeManager) GetSnapshotter() disk.Snapshotter {
+	fake.getSnapshotterMutex.Lock()
+	ret, specificReturn := fake.getSnapshotterReturnsOnCall[len(fake.getSnapshotterArgsForCall)]
+	fake.getSnapshotterArgsForCall = append(fake.getSnapshotterArgsForCall, struct {
+	}{})
+	stub := fake.GetSnapshotterStub
+	fakeReturns := fake.getSnapshotterReturns
+	fake.recordInvocation("GetSnapshotter", []interface{}{})
+	fake.getSnapshotterMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) GetSnapshotterCallCount() int {
+	fake.getSnapshotterMutex.RLock()
+	defer fake.getSnapshotterMutex.RUnlock()
+	return len(fake.getSnapshotterArgsForCall)
+}
+
+func (fake *FakeManager) GetSnapshotterCalls(stub func() disk.Snapshotter) {
+	fake.getSnapshotterMutex.Lock()
+	defer fake.getSnapshotterMutex.Unlock()
+	fake.GetSnapshotterStub = stub
+}
+
+func (fake *FakeManager) GetSnapshotterReturns(result1 disk.Snapshotter) {
+	fake.getSnapshotterMutex.Lock()
+	defer fake.getSnapshotterMutex.Unlock()
+	fake.GetSnapshotterStub = nil
+	fake.getSnapshotterReturns = struct {
+		result1 disk.Snapshotter
+	}{result1}
+}
+
+func (fake *FakeManager) GetSnapshotterReturnsOnCall(i int, result1 disk.Snapshotter) {
+	fake.getSnapshotterMutex.Lock()
+	defer fake.getSnapshotterMutex.Unlock()
+	fake.GetSnapshotterStub = nil
+	if fake.getSnapshotterReturnsOnCall == nil {
+		fake.getSnapshotterReturnsOnCall = make(map[int]struct {
+			result1 disk.Snapshotter
+		})
+	}
+	fake.getSnapshotterReturnsOnCall[i] = struct {
+		result1 disk.Snapshotter
+	}{result1}
+}
+
 func (fake *FakeManager) GetUtil() disk.Util {
 	fake.getUtilMutex.Lock()
 	ret, specificReturn := fake.getUtilReturnsOnCall[len(fake.getUtilArgsForCall)]
@@ -470,10 +659,14 @@ func (fake *FakeManager) GetUtilReturnsOnCall(i int, result1 disk.Util) {
 func (fake *FakeManager) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.getEncryptorMutex.RLock()
+	defer fake.getEncryptorMutex.RUnlock()
 	fake.getEphemeralDevicePartitionerMutex.RLock()
 	defer fake.getEphemeralDevicePartitionerMutex.RUnlock()
 	fake.getFormatterMutex.RLock()
 	defer fake.getFormatterMutex.RUnlock()
+	fake.getMigratorMutex.RLock()
+	defer fake.getMigratorMutex.RUnlock()
 	fake.getMounterMutex.RLock()
 	defer fake.getMounterMutex.RUnlock()
 	fake.getMountsSearcherMutex.RLock()
@@ -482,6 +675,8 @@ func (fake *FakeManager) Invocations() map[string][][]interface{} {
 	defer fake.getPersistentDevicePartitionerMutex.RUnlock()
 	fake.getRootDevicePartitionerMutex.RLock()
 	defer fake.getRootDevicePartitionerMutex.RUnlock()
+	fake.getSnapshotterMutex.RLock()
+	defer fake.getSnapshotterMutex.RUnlock()
 	fake.getUtilMutex.RLock()
 	defer fake.getUtilMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
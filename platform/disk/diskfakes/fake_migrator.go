@@ -0,0 +1,115 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package diskfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeMigrator struct {
+	MigrateStub        func(string, string, disk.MigrationProgressFunc) error
+	migrateMutex       sync.RWMutex
+	migrateArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 disk.MigrationProgressFunc
+	}
+	migrateReturns struct {
+		result1 error
+	}
+	migrateReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMigrator) Migrate(arg1 string, arg2 string, arg3 disk.MigrationProgressFunc) error {
+	fake.migrateMutex.Lock()
+	ret, specificReturn := fake.migrateReturnsOnCall[len(fake.migrateArgsForCall)]
+	fake.migrateArgsForCall = append(fake.migrateArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 disk.MigrationProgressFunc
+	}{arg1, arg2, arg3})
+	stub := fake.MigrateStub
+	fakeReturns := fake.migrateReturns
+	fake.recordInvocation("Migrate", []interface{}{arg1, arg2, arg3})
+	fake.migrateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMigrator) MigrateCallCount() int {
+	fake.migrateMutex.RLock()
+	defer fake.migrateMutex.RUnlock()
+	return len(fake.migrateArgsForCall)
+}
+
+func (fake *FakeMigrator) MigrateCalls(stub func(string, string, disk.MigrationProgressFunc) error) {
+	fake.migrateMutex.Lock()
+	defer fake.migrateMutex.Unlock()
+	fake.MigrateStub = stub
+}
+
+func (fake *FakeMigrator) MigrateArgsForCall(i int) (string, string, disk.MigrationProgressFunc) {
+	fake.migrateMutex.RLock()
+	defer fake.migrateMutex.RUnlock()
+	argsForCall := fake.migrateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeMigrator) MigrateReturns(result1 error) {
+	fake.migrateMutex.Lock()
+	defer fake.migrateMutex.Unlock()
+	fake.MigrateStub = nil
+	fake.migrateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMigrator) MigrateReturnsOnCall(i int, result1 error) {
+	fake.migrateMutex.Lock()
+	defer fake.migrateMutex.Unlock()
+	fake.MigrateStub = nil
+	if fake.migrateReturnsOnCall == nil {
+		fake.migrateReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.migrateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMigrator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.migrateMutex.RLock()
+	defer fake.migrateMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMigrator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ disk.Migrator = new(FakeMigrator)
@@ -0,0 +1,349 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package diskfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeSnapshotter struct {
+	CreateSnapshotStub        func(string, string) (disk.Snapshot, error)
+	createSnapshotMutex       sync.RWMutex
+	createSnapshotArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	createSnapshotReturns struct {
+		result1 disk.Snapshot
+		result2 error
+	}
+	createSnapshotReturnsOnCall map[int]struct {
+		result1 disk.Snapshot
+		result2 error
+	}
+	DeleteSnapshotStub        func(string, string) error
+	deleteSnapshotMutex       sync.RWMutex
+	deleteSnapshotArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	deleteSnapshotReturns struct {
+		result1 error
+	}
+	deleteSnapshotReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ListSnapshotsStub        func(string) ([]disk.Snapshot, error)
+	listSnapshotsMutex       sync.RWMutex
+	listSnapshotsArgsForCall []struct {
+		arg1 string
+	}
+	listSnapshotsReturns struct {
+		result1 []disk.Snapshot
+		result2 error
+	}
+	listSnapshotsReturnsOnCall map[int]struct {
+		result1 []disk.Snapshot
+		result2 error
+	}
+	RestoreSnapshotStub        func(string, string) error
+	restoreSnapshotMutex       sync.RWMutex
+	restoreSnapshotArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	restoreSnapshotReturns struct {
+		result1 error
+	}
+	restoreSnapshotReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSnapshotter) CreateSnapshot(arg1 string, arg2 string) (disk.Snapshot, error) {
+	fake.createSnapshotMutex.Lock()
+	ret, specificReturn := fake.createSnapshotReturnsOnCall[len(fake.createSnapshotArgsForCall)]
+	fake.createSnapshotArgsForCall = append(fake.createSnapshotArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.CreateSnapshotStub
+	fakeReturns := fake.createSnapshotReturns
+	fake.recordInvocation("CreateSnapshot", []interface{}{arg1, arg2})
+	fake.createSnapshotMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSnapshotter) CreateSnapshotCallCount() int {
+	fake.createSnapshotMutex.RLock()
+	defer fake.createSnapshotMutex.RUnlock()
+	return len(fake.createSnapshotArgsForCall)
+}
+
+func (fake *FakeSnapshotter) CreateSnapshotCalls(stub func(string, string) (disk.Snapshot, error)) {
+	fake.createSnapshotMutex.Lock()
+	defer fake.createSnapshotMutex.Unlock()
+	fake.CreateSnapshotStub = stub
+}
+
+func (fake *FakeSnapshotter) CreateSnapshotArgsForCall(i int) (string, string) {
+	fake.createSnapshotMutex.RLock()
+	defer fake.createSnapshotMutex.RUnlock()
+	argsForCall := fake.createSnapshotArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeSnapshotter) CreateSnapshotReturns(result1 disk.Snapshot, result2 error) {
+	fake.createSnapshotMutex.Lock()
+	defer fake.createSnapshotMutex.Unlock()
+	fake.CreateSnapshotStub = nil
+	fake.createSnapshotReturns = struct {
+		result1 disk.Snapshot
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSnapshotter) CreateSnapshotReturnsOnCall(i int, result1 disk.Snapshot, result2 error) {
+	fake.createSnapshotMutex.Lock()
+	defer fake.createSnapshotMutex.Unlock()
+	fake.CreateSnapshotStub = nil
+	if fake.createSnapshotReturnsOnCall == nil {
+		fake.createSnapshotReturnsOnCall = make(map[int]struct {
+			result1 disk.Snapshot
+			result2 error
+		})
+	}
+	fake.createSnapshotReturnsOnCall[i] = struct {
+		result1 disk.Snapshot
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSnapshotter) DeleteSnapshot(arg1 string, arg2 string) error {
+	fake.deleteSnapshotMutex.Lock()
+	ret, specificReturn := fake.deleteSnapshotReturnsOnCall[len(fake.deleteSnapshotArgsForCall)]
+	fake.deleteSnapshotArgsForCall = append(fake.deleteSnapshotArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteSnapshotStub
+	fakeReturns := fake.deleteSnapshotReturns
+	fake.recordInvocation("DeleteSnapshot", []interface{}{arg1, arg2})
+	fake.deleteSnapshotMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSnapshotter) DeleteSnapshotCallCount() int {
+	fake.deleteSnapshotMutex.RLock()
+	defer fake.deleteSnapshotMutex.RUnlock()
+	return len(fake.deleteSnapshotArgsForCall)
+}
+
+func (fake *FakeSnapshotter) DeleteSnapshotCalls(stub func(string, string) error) {
+	fake.deleteSnapshotMutex.Lock()
+	defer fake.deleteSnapshotMutex.Unlock()
+	fake.DeleteSnapshotStub = stub
+}
+
+func (fake *FakeSnapshotter) DeleteSnapshotArgsForCall(i int) (string, string) {
+	fake.deleteSnapshotMutex.RLock()
+	defer fake.deleteSnapshotMutex.RUnlock()
+	argsForCall := fake.deleteSnapshotArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeSnapshotter) DeleteSnapshotReturns(result1 error) {
+	fake.deleteSnapshotMutex.Lock()
+	defer fake.deleteSnapshotMutex.Unlock()
+	fake.DeleteSnapshotStub = nil
+	fake.deleteSnapshotReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSnapshotter) DeleteSnapshotReturnsOnCall(i int, result1 error) {
+	fake.deleteSnapshotMutex.Lock()
+	defer fake.deleteSnapshotMutex.Unlock()
+	fake.DeleteSnapshotStub = nil
+	if fake.deleteSnapshotReturnsOnCall == nil {
+		fake.deleteSnapshotReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteSnapshotReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSnapshotter) ListSnapshots(arg1 string) ([]disk.Snapshot, error) {
+	fake.listSnapshotsMutex.Lock()
+	ret, specificReturn := fake.listSnapshotsReturnsOnCall[len(fake.listSnapshotsArgsForCall)]
+	fake.listSnapshotsArgsForCall = append(fake.listSnapshotsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ListSnapshotsStub
+	fakeReturns := fake.listSnapshotsReturns
+	fake.recordInvocation("ListSnapshots", []interface{}{arg1})
+	fake.listSnapshotsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSnapshotter) ListSnapshotsCallCount() int {
+	fake.listSnapshotsMutex.RLock()
+	defer fake.listSnapshotsMutex.RUnlock()
+	return len(fake.listSnapshotsArgsForCall)
+}
+
+func (fake *FakeSnapshotter) ListSnapshotsCalls(stub func(string) ([]disk.Snapshot, error)) {
+	fake.listSnapshotsMutex.Lock()
+	defer fake.listSnapshotsMutex.Unlock()
+	fake.ListSnapshotsStub = stub
+}
+
+func (fake *FakeSnapshotter) ListSnapshotsArgsForCall(i int) string {
+	fake.listSnapshotsMutex.RLock()
+	defer fake.listSnapshotsMutex.RUnlock()
+	argsForCall := fake.listSnapshotsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSnapshotter) ListSnapshotsReturns(result1 []disk.Snapshot, result2 error) {
+	fake.listSnapshotsMutex.Lock()
+	defer fake.listSnapshotsMutex.Unlock()
+	fake.ListSnapshotsStub = nil
+	fake.listSnapshotsReturns = struct {
+		result1 []disk.Snapshot
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSnapshotter) ListSnapshotsReturnsOnCall(i int, result1 []disk.Snapshot, result2 error) {
+	fake.listSnapshotsMutex.Lock()
+	defer fake.listSnapshotsMutex.Unlock()
+	fake.ListSnapshotsStub = nil
+	if fake.listSnapshotsReturnsOnCall == nil {
+		fake.listSnapshotsReturnsOnCall = make(map[int]struct {
+			result1 []disk.Snapshot
+			result2 error
+		})
+	}
+	fake.listSnapshotsReturnsOnCall[i] = struct {
+		result1 []disk.Snapshot
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSnapshotter) RestoreSnapshot(arg1 string, arg2 string) error {
+	fake.restoreSnapshotMutex.Lock()
+	ret, specificReturn := fake.restoreSnapshotReturnsOnCall[len(fake.restoreSnapshotArgsForCall)]
+	fake.restoreSnapshotArgsForCall = append(fake.restoreSnapshotArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RestoreSnapshotStub
+	fakeReturns := fake.restoreSnapshotReturns
+	fake.recordInvocation("RestoreSnapshot", []interface{}{arg1, arg2})
+	fake.restoreSnapshotMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSnapshotter) RestoreSnapshotCallCount() int {
+	fake.restoreSnapshotMutex.RLock()
+	defer fake.restoreSnapshotMutex.RUnlock()
+	return len(fake.restoreSnapshotArgsForCall)
+}
+
+func (fake *FakeSnapshotter) RestoreSnapshotCalls(stub func(string, string) error) {
+	fake.restoreSnapshotMutex.Lock()
+	defer fake.restoreSnapshotMutex.Unlock()
+	fake.RestoreSnapshotStub = stub
+}
+
+func (fake *FakeSnapshotter) RestoreSnapshotArgsForCall(i int) (string, string) {
+	fake.restoreSnapshotMutex.RLock()
+	defer fake.restoreSnapshotMutex.RUnlock()
+	argsForCall := fake.restoreSnapshotArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeSnapshotter) RestoreSnapshotReturns(result1 error) {
+	fake.restoreSnapshotMutex.Lock()
+	defer fake.restoreSnapshotMutex.Unlock()
+	fake.RestoreSnapshotStub = nil
+	fake.restoreSnapshotReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSnapshotter) RestoreSnapshotReturnsOnCall(i int, result1 error) {
+	fake.restoreSnapshotMutex.Lock()
+	defer fake.restoreSnapshotMutex.Unlock()
+	fake.RestoreSnapshotStub = nil
+	if fake.restoreSnapshotReturnsOnCall == nil {
+		fake.restoreSnapshotReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.restoreSnapshotReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSnapshotter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createSnapshotMutex.RLock()
+	defer fake.createSnapshotMutex.RUnlock()
+	fake.deleteSnapshotMutex.RLock()
+	defer fake.deleteSnapshotMutex.RUnlock()
+	fake.listSnapshotsMutex.RLock()
+	defer fake.listSnapshotsMutex.RUnlock()
+	fake.restoreSnapshotMutex.RLock()
+	defer fake.restoreSnapshotMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeSnapshotter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ disk.Snapshotter = new(FakeSnapshotter)
@@ -0,0 +1,13 @@
+package disk
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Encryptor
+
+type Encryptor interface {
+	IsEncrypted(partitionPath string) (bool, error)
+	Encrypt(partitionPath string, key []byte) error
+
+	// Open returns path of the mapped device and succeeds if it is already open
+	Open(partitionPath, mapperName string, key []byte) (mappedPath string, err error)
+	Resize(mapperName string, key []byte) error
+	Close(mapperName string) error
+}
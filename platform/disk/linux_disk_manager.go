@@ -17,7 +17,10 @@ type linuxDiskManager struct {
 	rootDevicePartitioner Partitioner
 	diskUtil              Util
 
-	formatter Formatter
+	formatter   Formatter
+	encryptor   Encryptor
+	migrator    Migrator
+	snapshotter Snapshotter
 
 	mounter        Mounter
 	mountsSearcher MountsSearcher
@@ -83,8 +86,10 @@ func NewLinuxDiskManager(
 		ephemeralPartitioner:  ephemeralPartitioner,
 		diskUtil:              diskUtil,
 		formatter:             NewLinuxFormatter(runner, fs),
+		encryptor:             NewLuksEncryptor(runner, fs, logger),
 		fs:                    fs,
 		logger:                logger,
+		migrator:              NewNativeMigrator(logger),
 		mounter:               mounter,
 		mountsSearcher:        mountsSearcher,
 		partedPartitioner:     partedPartitioner,
@@ -92,6 +97,7 @@ func NewLinuxDiskManager(
 		persistentPartitioner: persistentPartitioner,
 		rootDevicePartitioner: NewRootDevicePartitioner(logger, runner, uint64(20*1024*1024)),
 		runner:                runner,
+		snapshotter:           NewBtrfsSnapshotter(runner, fs, logger),
 	}
 }
 
@@ -111,8 +117,11 @@ func (m linuxDiskManager) GetPersistentDevicePartitioner(partitionerType string)
 	}
 }
 
+func (m linuxDiskManager) GetEncryptor() Encryptor           { return m.encryptor }
 func (m linuxDiskManager) GetFormatter() Formatter           { return m.formatter }
+func (m linuxDiskManager) GetMigrator() Migrator             { return m.migrator }
 func (m linuxDiskManager) GetMounter() Mounter               { return m.mounter }
 func (m linuxDiskManager) GetMountsSearcher() MountsSearcher { return m.mountsSearcher }
+func (m linuxDiskManager) GetSnapshotter() Snapshotter       { return m.snapshotter }
 
 func (m linuxDiskManager) GetUtil() Util { return m.diskUtil }
@@ -31,7 +31,7 @@ func (f linuxFormatter) Format(partitionPath string, fsType FileSystemType) erro
 			return err
 		}
 		// swap is not user-configured, so we're not concerned about reformatting
-	} else if existingFsType == FileSystemExt4 || existingFsType == FileSystemXFS {
+	} else if existingFsType == FileSystemExt4 || existingFsType == FileSystemXFS || existingFsType == FileSystemBtrfs {
 		// never reformat if it is already formatted in a supported format
 		return err
 	}
@@ -59,6 +59,13 @@ func (f linuxFormatter) Format(partitionPath string, fsType FileSystemType) erro
 		if err != nil {
 			return bosherr.WrapError(err, "Shelling out to mkfs.xfs")
 		}
+
+	case FileSystemBtrfs:
+		_, _, _, err = f.runner.RunCommand("mkfs.btrfs", partitionPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Shelling out to mkfs.btrfs")
+		}
+
 	case FileSystemDefault:
 		return nil
 	}
@@ -91,6 +98,19 @@ func (f linuxFormatter) GrowFilesystem(partitionPath string) error {
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to grow XFS filesystem")
 		}
+
+	case FileSystemBtrfs:
+		// btrfs is resized through a path within the mounted filesystem
+		stdout, _, _, err := f.runner.RunCommand("findmnt", "-n", "-f", "-o", "TARGET", "--source", partitionPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Finding mount point of %s", partitionPath)
+		}
+
+		_, _, _, err = f.runner.RunCommand("btrfs", "filesystem", "resize", "max", strings.TrimSpace(stdout))
+		if err != nil {
+			return bosherr.WrapError(err, "Failed to grow Btrfs filesystem")
+		}
+
 	case FileSystemDefault, FileSystemSwap:
 		return nil
 	}
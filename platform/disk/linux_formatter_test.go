@@ -221,6 +221,33 @@ var _ = Describe("Linux Formatter", func() {
 				Expect(err.Error()).To(Equal("Shelling out to mkfs.xfs: Sadness"))
 			})
 		})
+
+		Context("when using btrfs", func() {
+			It("formats a blank disk with type btrfs", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda2", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("Exit code 2")})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda2", FileSystemBtrfs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeRunner.RunCommands).To(HaveLen(2))
+				Expect(fakeRunner.RunCommands[1]).To(Equal([]string{"mkfs.btrfs", "/dev/xvda2"}))
+			})
+
+			It("does not re-format if fs is already btrfs", func() {
+				fakeRunner := fakesys.NewFakeCmdRunner()
+				fakeFs := fakesys.NewFakeFileSystem()
+				fakeRunner.AddCmdResult("blkid -p /dev/xvda1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="btrfs" yyyy zzzz`})
+
+				formatter := NewLinuxFormatter(fakeRunner, fakeFs)
+				err := formatter.Format("/dev/xvda1", FileSystemBtrfs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeRunner.RunCommands).To(Equal([][]string{{"blkid", "-p", "/dev/xvda1"}}))
+			})
+		})
 	})
 
 	Describe("GrowFilesystem", func() {
@@ -277,6 +304,21 @@ var _ = Describe("Linux Formatter", func() {
 			})
 		})
 
+		Context("when using Btrfs", func() {
+			BeforeEach(func() {
+				fakeRunner.AddCmdResult("blkid -p /dev/nvme2n1p1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="btrfs" yyyy zzzz`})
+				fakeRunner.AddCmdResult("findmnt -n -f -o TARGET --source /dev/nvme2n1p1", fakesys.FakeCmdResult{Stdout: "/var/vcap/store\n"})
+				formatter = NewLinuxFormatter(fakeRunner, fakeFs)
+			})
+
+			It("grows the Btrfs filesystem through its mount point", func() {
+				err := formatter.GrowFilesystem("/dev/nvme2n1p1")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeRunner.RunCommands[2]).To(Equal([]string{"btrfs", "filesystem", "resize", "max", "/var/vcap/store"}))
+			})
+		})
+
 		Context("when using XFS", func() {
 			BeforeEach(func() {
 				fakeRunner.AddCmdResult("blkid -p /dev/nvme2n1p1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="xfs" yyyy zzzz`})
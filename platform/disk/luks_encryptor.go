@@ -0,0 +1,118 @@
+package disk
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	luksEncryptorLogTag = "luksEncryptor"
+	deviceMapperDir     = "/dev/mapper"
+	luksFileSystemType  = "crypto_LUKS"
+)
+
+type luksEncryptor struct {
+	runner boshsys.CmdRunner
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+}
+
+// NewLuksEncryptor encrypts partitions with LUKS2 using cryptsetup.
+// Keys are passed on stdin so that they never show up in process lists.
+func NewLuksEncryptor(runner boshsys.CmdRunner, fs boshsys.FileSystem, logger boshlog.Logger) Encryptor {
+	return luksEncryptor{
+		runner: runner,
+		fs:     fs,
+		logger: logger,
+	}
+}
+
+// IsEncrypted looks for LUKS header with blkid the same way formatter detects
+// filesystems, so that cryptsetup is only needed once encryption is used
+func (e luksEncryptor) IsEncrypted(partitionPath string) (bool, error) {
+	stdout, stderr, exitStatus, err := e.runner.RunCommand("blkid", "-p", "-s", "TYPE", "-o", "value", partitionPath)
+	if err != nil {
+		switch {
+		case exitStatus == 2 && stderr == "":
+			// Nothing was detected on the device
+			return false, nil
+		case exitStatus == -1:
+			e.logger.Warn(luksEncryptorLogTag, "Assuming %s is not encrypted: %s", partitionPath, err.Error())
+			return false, nil
+		}
+		return false, bosherr.WrapErrorf(err, "Checking whether %s is encrypted", partitionPath)
+	}
+
+	return strings.TrimSpace(stdout) == luksFileSystemType, nil
+}
+
+func (e luksEncryptor) Encrypt(partitionPath string, key []byte) error {
+	e.logger.Info(luksEncryptorLogTag, "Encrypting %s", partitionPath)
+
+	_, _, _, err := e.runCryptsetup(key, "luksFormat", "--type", "luks2", "--batch-mode", "--key-file", "-", partitionPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Encrypting %s", partitionPath)
+	}
+
+	return nil
+}
+
+func (e luksEncryptor) Open(partitionPath, mapperName string, key []byte) (string, error) {
+	mappedPath := MappedDevicePath(mapperName)
+
+	if e.fs.FileExists(mappedPath) {
+		e.logger.Debug(luksEncryptorLogTag, "%s is already open as %s", partitionPath, mappedPath)
+		return mappedPath, nil
+	}
+
+	e.logger.Info(luksEncryptorLogTag, "Opening %s as %s", partitionPath, mappedPath)
+
+	_, _, _, err := e.runCryptsetup(key, "open", "--type", "luks", "--key-file", "-", partitionPath, mapperName)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Opening %s", partitionPath)
+	}
+
+	return mappedPath, nil
+}
+
+func (e luksEncryptor) Resize(mapperName string, key []byte) error {
+	_, _, _, err := e.runCryptsetup(key, "resize", "--key-file", "-", mapperName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Resizing %s", mapperName)
+	}
+
+	return nil
+}
+
+func (e luksEncryptor) Close(mapperName string) error {
+	if !e.fs.FileExists(MappedDevicePath(mapperName)) {
+		return nil
+	}
+
+	e.logger.Info(luksEncryptorLogTag, "Closing %s", mapperName)
+
+	_, _, _, err := e.runner.RunCommand("cryptsetup", "close", mapperName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Closing %s", mapperName)
+	}
+
+	return nil
+}
+
+func (e luksEncryptor) runCryptsetup(key []byte, args ...string) (string, string, int, error) {
+	return e.runner.RunComplexCommand(boshsys.Command{
+		Name:  "cryptsetup",
+		Args:  args,
+		Stdin: bytes.NewReader(key),
+	})
+}
+
+// MappedDevicePath returns path of the device an encrypted partition is opened as
+func MappedDevicePath(mapperName string) string {
+	return path.Join(deviceMapperDir, mapperName)
+}
@@ -0,0 +1,118 @@
+package disk_test
+
+import (
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("luksEncryptor", func() {
+	var (
+		runner    *fakesys.FakeCmdRunner
+		fs        *fakesys.FakeFileSystem
+		encryptor Encryptor
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		fs = fakesys.NewFakeFileSystem()
+		encryptor = NewLuksEncryptor(runner, fs, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	stdinOf := func(cmd boshsys.Command) string {
+		stdin, err := io.ReadAll(cmd.Stdin)
+		Expect(err).ToNot(HaveOccurred())
+		return string(stdin)
+	}
+
+	Describe("IsEncrypted", func() {
+		It("returns true for partitions with LUKS header", func() {
+			runner.AddCmdResult("blkid -p -s TYPE -o value /dev/sdb1", fakesys.FakeCmdResult{Stdout: "crypto_LUKS\n"})
+
+			Expect(encryptor.IsEncrypted("/dev/sdb1")).To(BeTrue())
+			Expect(runner.RunCommands).To(Equal([][]string{{"blkid", "-p", "-s", "TYPE", "-o", "value", "/dev/sdb1"}}))
+		})
+
+		It("returns false for partitions with a filesystem", func() {
+			runner.AddCmdResult("blkid -p -s TYPE -o value /dev/sdb1", fakesys.FakeCmdResult{Stdout: "ext4\n"})
+			Expect(encryptor.IsEncrypted("/dev/sdb1")).To(BeFalse())
+		})
+
+		It("returns false for blank partitions", func() {
+			runner.AddCmdResult("blkid -p -s TYPE -o value /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("exit 2")})
+			Expect(encryptor.IsEncrypted("/dev/sdb1")).To(BeFalse())
+		})
+
+		It("returns false when blkid is not available", func() {
+			runner.AddCmdResult("blkid -p -s TYPE -o value /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: -1, Error: errors.New("executable file not found")})
+			Expect(encryptor.IsEncrypted("/dev/sdb1")).To(BeFalse())
+		})
+
+		It("returns an error when the partition cannot be checked", func() {
+			runner.AddCmdResult("blkid -p -s TYPE -o value /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 4, Stderr: "error: /dev/sdb1: No such file or directory", Error: errors.New("exit 4")})
+
+			_, err := encryptor.IsEncrypted("/dev/sdb1")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Encrypt", func() {
+		It("formats partition with LUKS2 passing key on stdin", func() {
+			Expect(encryptor.Encrypt("/dev/sdb1", []byte("fake-key"))).To(Succeed())
+
+			Expect(runner.RunComplexCommands).To(HaveLen(1))
+			cmd := runner.RunComplexCommands[0]
+			Expect(cmd.Name).To(Equal("cryptsetup"))
+			Expect(cmd.Args).To(Equal([]string{"luksFormat", "--type", "luks2", "--batch-mode", "--key-file", "-", "/dev/sdb1"}))
+			Expect(stdinOf(cmd)).To(Equal("fake-key"))
+		})
+	})
+
+	Describe("Open", func() {
+		It("opens partition as mapped device", func() {
+			mappedPath, err := encryptor.Open("/dev/sdb1", "fake-name", []byte("fake-key"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mappedPath).To(Equal("/dev/mapper/fake-name"))
+
+			Expect(runner.RunComplexCommands).To(HaveLen(1))
+			cmd := runner.RunComplexCommands[0]
+			Expect(cmd.Args).To(Equal([]string{"open", "--type", "luks", "--key-file", "-", "/dev/sdb1", "fake-name"}))
+			Expect(stdinOf(cmd)).To(Equal("fake-key"))
+		})
+
+		It("does nothing when partition is already open", func() {
+			Expect(fs.WriteFileString("/dev/mapper/fake-name", "")).To(Succeed())
+
+			mappedPath, err := encryptor.Open("/dev/sdb1", "fake-name", []byte("fake-key"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mappedPath).To(Equal("/dev/mapper/fake-name"))
+			Expect(runner.RunComplexCommands).To(BeEmpty())
+		})
+
+		It("returns an error when opening fails", func() {
+			runner.AddCmdResult("cryptsetup open --type luks --key-file - /dev/sdb1 fake-name", fakesys.FakeCmdResult{Error: errors.New("fake-err")})
+
+			_, err := encryptor.Open("/dev/sdb1", "fake-name", []byte("fake-key"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("Close", func() {
+		It("closes open mapped devices only", func() {
+			Expect(encryptor.Close("fake-name")).To(Succeed())
+			Expect(runner.RunCommands).To(BeEmpty())
+
+			Expect(fs.WriteFileString("/dev/mapper/fake-name", "")).To(Succeed())
+			Expect(encryptor.Close("fake-name")).To(Succeed())
+			Expect(runner.RunCommands).To(Equal([][]string{{"cryptsetup", "close", "fake-name"}}))
+		})
+	})
+})
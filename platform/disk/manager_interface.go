@@ -4,10 +4,13 @@ package disk
 
 type Manager interface {
 	GetEphemeralDevicePartitioner() Partitioner
+	GetEncryptor() Encryptor
 	GetFormatter() Formatter
+	GetMigrator() Migrator
 	GetMounter() Mounter
 	GetMountsSearcher() MountsSearcher
 	GetPersistentDevicePartitioner(partitionerType string) (Partitioner, error)
 	GetRootDevicePartitioner() Partitioner
+	GetSnapshotter() Snapshotter
 	GetUtil() Util
 }
@@ -0,0 +1,207 @@
+package disk
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	// MigrationJournalDirName is kept on the target disk while migration
+	// is in progress; its presence means that the disk is not fully copied
+	MigrationJournalDirName = ".bosh-migration"
+
+	migrationJournalFileName = "journal"
+
+	migrationCheckpointEntries = 1000
+	migrationCheckpointBytes   = 256 * 1024 * 1024
+)
+
+// migrationRecord describes a source entry which was copied to the target disk
+type migrationRecord struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size,omitempty"`
+	ModTime int64  `json:"mtime"`
+	SHA256  string `json:"sha256,omitempty"`
+}
+
+// migrationJournal keeps records of copied entries. Records are buffered
+// and only written after data of the target filesystem is synced so that
+// every journaled entry is known to be persisted.
+type migrationJournal struct {
+	targetDir string
+	path      string
+	syncFS    func(dir string) error
+
+	records      map[string]migrationRecord
+	pending      []migrationRecord
+	pendingBytes int64
+}
+
+func openMigrationJournal(targetDir string, syncFS func(dir string) error) (*migrationJournal, error) {
+	dir := filepath.Join(targetDir, MigrationJournalDirName)
+
+	err := os.MkdirAll(dir, os.FileMode(0700))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating migration journal directory '%s'", dir)
+	}
+
+	journal := &migrationJournal{
+		targetDir: targetDir,
+		path:      filepath.Join(dir, migrationJournalFileName),
+		syncFS:    syncFS,
+		records:   map[string]migrationRecord{},
+	}
+
+	file, err := os.Open(journal.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return journal, nil
+		}
+		return nil, bosherr.WrapErrorf(err, "Opening migration journal '%s'", journal.path)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var record migrationRecord
+
+		// Last line may be partially written when machine crashed
+		if json.Unmarshal(scanner.Bytes(), &record) != nil {
+			continue
+		}
+
+		journal.records[record.Path] = record
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading migration journal '%s'", journal.path)
+	}
+
+	return journal, nil
+}
+
+func (j *migrationJournal) Len() int {
+	return len(j.records)
+}
+
+func (j *migrationJournal) Get(path string) (migrationRecord, bool) {
+	record, found := j.records[path]
+	return record, found
+}
+
+func (j *migrationJournal) Add(record migrationRecord) error {
+	j.records[record.Path] = record
+	j.pending = append(j.pending, record)
+	j.pendingBytes += record.Size
+
+	if len(j.pending) >= migrationCheckpointEntries || j.pendingBytes >= migrationCheckpointBytes {
+		return j.Checkpoint()
+	}
+
+	return nil
+}
+
+// Checkpoint persists records added since previous checkpoint
+func (j *migrationJournal) Checkpoint() error {
+	if len(j.pending) == 0 {
+		return nil
+	}
+
+	err := j.syncFS(j.targetDir)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Syncing filesystem of '%s'", j.targetDir)
+	}
+
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, os.FileMode(0600))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening migration journal '%s'", j.path)
+	}
+	defer file.Close()
+
+	err = j.write(file, j.pending)
+	if err != nil {
+		return err
+	}
+
+	j.pending = nil
+	j.pendingBytes = 0
+
+	return nil
+}
+
+// Forget removes records so that their entries are copied again by the next migration attempt
+func (j *migrationJournal) Forget(paths []string) error {
+	for _, path := range paths {
+		delete(j.records, path)
+	}
+
+	j.pending = nil
+	j.pendingBytes = 0
+
+	records := make([]migrationRecord, 0, len(j.records))
+	for _, record := range j.records {
+		records = append(records, record)
+	}
+
+	tmpPath := j.path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, os.FileMode(0600))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening migration journal '%s'", tmpPath)
+	}
+
+	err = j.write(file, records)
+	_ = file.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, j.path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Replacing migration journal '%s'", j.path)
+	}
+
+	return nil
+}
+
+// Remove deletes the journal marking target disk as fully migrated
+func (j *migrationJournal) Remove() error {
+	dir := filepath.Dir(j.path)
+
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing migration journal directory '%s'", dir)
+	}
+
+	return j.syncFS(j.targetDir)
+}
+
+func (j *migrationJournal) write(file *os.File, records []migrationRecord) error {
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+
+	for _, record := range records {
+		err := encoder.Encode(record)
+		if err != nil {
+			return bosherr.WrapError(err, "Encoding migration journal record")
+		}
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing migration journal '%s'", file.Name())
+	}
+
+	err = file.Sync()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Syncing migration journal '%s'", file.Name())
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package disk
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Migrator
+
+const (
+	MigrationStageScanning  = "Scanning source disk"
+	MigrationStageCopying   = "Copying files"
+	MigrationStageVerifying = "Verifying copied files"
+)
+
+// MigrationProgress describes how much work of the current migration stage is done
+type MigrationProgress struct {
+	Stage      string
+	DoneFiles  uint64
+	TotalFiles uint64
+	DoneBytes  uint64
+	TotalBytes uint64
+}
+
+type MigrationProgressFunc func(MigrationProgress)
+
+// Migrator copies contents of one mounted disk to another one. Interrupted
+// migrations are resumed from the last checkpoint when run again.
+type Migrator interface {
+	Migrate(fromDir, toDir string, progress MigrationProgressFunc) error
+}
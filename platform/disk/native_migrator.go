@@ -0,0 +1,498 @@
+package disk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const migrationProgressInterval = time.Second
+
+type migrationEntry struct {
+	path string
+	info os.FileInfo
+}
+
+// nativeMigrator copies files together with their ownership, permissions,
+// timestamps and extended attributes (which include POSIX ACLs),
+// preserves hard links and holes of sparse files, and keeps a checkpoint
+// journal on the target disk so that interrupted migrations can be resumed.
+type nativeMigrator struct {
+	logger boshlog.Logger
+	logTag string
+}
+
+func NewNativeMigrator(logger boshlog.Logger) Migrator {
+	return nativeMigrator{
+		logger: logger,
+		logTag: "NativeMigrator",
+	}
+}
+
+func (m nativeMigrator) Migrate(fromDir, toDir string, progress MigrationProgressFunc) error {
+	reporter := newMigrationReporter(progress)
+
+	reporter.Start(MigrationStageScanning, 0, 0)
+
+	entries, totalBytes, err := m.scan(fromDir)
+	if err != nil {
+		return err
+	}
+
+	journal, err := openMigrationJournal(toDir, syncMigratedFilesystem)
+	if err != nil {
+		return err
+	}
+
+	if journal.Len() > 0 {
+		m.logger.Info(m.logTag, "Resuming migration of '%s' to '%s' with %d entries already copied", fromDir, toDir, journal.Len())
+	}
+
+	err = m.copyEntries(fromDir, toDir, entries, totalBytes, journal, reporter)
+	if err != nil {
+		return err
+	}
+
+	err = m.verify(toDir, entries, journal, reporter)
+	if err != nil {
+		return err
+	}
+
+	return journal.Remove()
+}
+
+func (m nativeMigrator) scan(fromDir string) ([]migrationEntry, uint64, error) {
+	entries := []migrationEntry{}
+	totalBytes := uint64(0)
+
+	err := filepath.Walk(fromDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Scanning '%s'", path)
+		}
+
+		relPath, err := filepath.Rel(fromDir, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath == "." {
+			return nil
+		}
+
+		if isExcludedFromMigration(relPath) {
+			if relPath == SnapshotsDirName {
+				m.logger.Warn(m.logTag, "Not migrating snapshots in '%s' since they are bound to the source disk", path)
+			}
+
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.Mode()&os.ModeSocket != 0 {
+			m.logger.Warn(m.logTag, "Skipping socket '%s'", path)
+			return nil
+		}
+
+		entries = append(entries, migrationEntry{path: relPath, info: info})
+
+		if info.Mode().IsRegular() {
+			totalBytes += uint64(info.Size())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, totalBytes, nil
+}
+
+func (m nativeMigrator) copyEntries(
+	fromDir, toDir string,
+	entries []migrationEntry,
+	totalBytes uint64,
+	journal *migrationJournal,
+	reporter *migrationReporter,
+) error {
+	reporter.Start(MigrationStageCopying, uint64(len(entries)), totalBytes)
+
+	// First copied path of every hard linked inode
+	hardLinks := map[migrationInodeKey]string{}
+
+	for _, entry := range entries {
+		srcPath := filepath.Join(fromDir, entry.path)
+		dstPath := filepath.Join(toDir, entry.path)
+		inodeKey, linked := migrationInode(entry.info)
+
+		if isMigrated(journal, entry, dstPath) {
+			if _, found := hardLinks[inodeKey]; linked && !found {
+				hardLinks[inodeKey] = entry.path
+			}
+			if entry.info.Mode().IsRegular() {
+				reporter.AddBytes(uint64(entry.info.Size()))
+			}
+			reporter.AddFile()
+			continue
+		}
+
+		exists, err := prepareMigrationTarget(dstPath, entry.info.IsDir())
+		if err != nil {
+			return err
+		}
+
+		record := migrationRecord{Path: entry.path, ModTime: entry.info.ModTime().UnixNano()}
+		mode := entry.info.Mode()
+
+		switch {
+		case mode.IsDir():
+			if !exists {
+				err = os.Mkdir(dstPath, os.FileMode(0700))
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Creating directory '%s'", dstPath)
+				}
+			}
+
+			// Directories are journaled once their metadata is copied after their contents
+			reporter.AddFile()
+			continue
+
+		case mode.IsRegular():
+			record.Size = entry.info.Size()
+
+			firstPath, found := hardLinks[inodeKey]
+			if linked && found {
+				err = os.Link(filepath.Join(toDir, firstPath), dstPath)
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Linking '%s'", dstPath)
+				}
+
+				firstRecord, _ := journal.Get(firstPath)
+				record.SHA256 = firstRecord.SHA256
+				reporter.AddBytes(uint64(record.Size))
+			} else {
+				record.SHA256, err = copyMigratedFile(srcPath, dstPath, entry.info, reporter)
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Copying file '%s'", srcPath)
+				}
+
+				if linked {
+					hardLinks[inodeKey] = entry.path
+				}
+			}
+
+		case mode&os.ModeSymlink != 0:
+			err = copyMigratedSymlink(srcPath, dstPath, entry.info)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Copying symlink '%s'", srcPath)
+			}
+
+		default:
+			err = copyMigratedSpecialFile(srcPath, dstPath, entry.info)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Copying special file '%s'", srcPath)
+			}
+		}
+
+		err = journal.Add(record)
+		if err != nil {
+			return err
+		}
+
+		reporter.AddFile()
+	}
+
+	// Pruning modifies directories hence it has to happen before their metadata is copied
+	err := m.pruneStaleEntries(toDir, entries)
+	if err != nil {
+		return err
+	}
+
+	// Going backwards copies directory metadata after all of their contents
+	// so that modification times are not changed afterwards
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		dstPath := filepath.Join(toDir, entry.path)
+
+		if !entry.info.IsDir() || isMigrated(journal, entry, dstPath) {
+			continue
+		}
+
+		err := copyMigratedMetadata(filepath.Join(fromDir, entry.path), dstPath, entry.info)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Copying metadata of directory '%s'", entry.path)
+		}
+
+		err = journal.Add(migrationRecord{Path: entry.path, ModTime: entry.info.ModTime().UnixNano()})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = journal.Checkpoint()
+	if err != nil {
+		return err
+	}
+
+	reporter.Flush()
+
+	return nil
+}
+
+// pruneStaleEntries removes entries which were left on the target disk by earlier copies
+// but are not present on the source disk
+func (m nativeMigrator) pruneStaleEntries(toDir string, entries []migrationEntry) error {
+	expected := map[string]bool{}
+	for _, entry := range entries {
+		expected[entry.path] = true
+	}
+
+	stalePaths := []string{}
+
+	err := walkMigratedEntries(toDir, func(relPath string, info os.FileInfo) error {
+		if !expected[relPath] {
+			stalePaths = append(stalePaths, filepath.Join(toDir, relPath))
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, path := range stalePaths {
+		m.logger.Debug(m.logTag, "Removing '%s' which is not present on source disk", path)
+
+		err := os.RemoveAll(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing stale entry '%s'", path)
+		}
+	}
+
+	return nil
+}
+
+func (m nativeMigrator) verify(toDir string, entries []migrationEntry, journal *migrationJournal, reporter *migrationReporter) error {
+	files := []migrationEntry{}
+	filesBytes := uint64(0)
+
+	for _, entry := range entries {
+		if entry.info.Mode().IsRegular() {
+			files = append(files, entry)
+			filesBytes += uint64(entry.info.Size())
+		}
+	}
+
+	reporter.Start(MigrationStageVerifying, uint64(len(files)), filesBytes)
+
+	found := map[string]bool{}
+
+	err := walkMigratedEntries(toDir, func(relPath string, info os.FileInfo) error {
+		found[relPath] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(found) != len(entries) {
+		missingPaths := []string{}
+		for _, entry := range entries {
+			if !found[entry.path] {
+				missingPaths = append(missingPaths, entry.path)
+			}
+		}
+
+		err = journal.Forget(missingPaths)
+		if err != nil {
+			return err
+		}
+
+		return bosherr.Errorf("Expected %d files on target disk but found %d", len(entries), len(found))
+	}
+
+	mismatchedPaths := []string{}
+
+	for _, entry := range files {
+		record, _ := journal.Get(entry.path)
+
+		checksum, err := migratedFileChecksum(filepath.Join(toDir, entry.path), reporter)
+		if err != nil {
+			return err
+		}
+
+		if checksum != record.SHA256 {
+			m.logger.Error(m.logTag, "Checksum of '%s' does not match source disk", entry.path)
+			mismatchedPaths = append(mismatchedPaths, entry.path)
+		}
+
+		reporter.AddFile()
+	}
+
+	if len(mismatchedPaths) > 0 {
+		err = journal.Forget(mismatchedPaths)
+		if err != nil {
+			return err
+		}
+
+		return bosherr.Errorf("Checksums of %d copied files do not match source disk, e.g. '%s'", len(mismatchedPaths), mismatchedPaths[0])
+	}
+
+	reporter.Flush()
+
+	return nil
+}
+
+// isMigrated checks whether entry was copied by previous migration attempt
+// and neither its source nor its target has changed since
+func isMigrated(journal *migrationJournal, entry migrationEntry, dstPath string) bool {
+	record, found := journal.Get(entry.path)
+	if !found || record.ModTime != entry.info.ModTime().UnixNano() {
+		return false
+	}
+
+	dstInfo, err := os.Lstat(dstPath)
+	if err != nil || dstInfo.Mode().Type() != entry.info.Mode().Type() {
+		return false
+	}
+
+	if entry.info.Mode().IsRegular() {
+		return record.Size == entry.info.Size() && dstInfo.Size() == entry.info.Size()
+	}
+
+	return true
+}
+
+// isExcludedFromMigration skips snapshots (and directories used while
+// restoring them) since btrfs snapshots are only usable on their source disk
+func isExcludedFromMigration(relPath string) bool {
+	switch relPath {
+	case "lost+found", MigrationJournalDirName, SnapshotsDirName, restoreStagingDirName, restoreTrashDirName:
+		return true
+	}
+	return false
+}
+
+// prepareMigrationTarget removes whatever is in the way of the entry
+// and returns whether a directory can be reused
+func prepareMigrationTarget(dstPath string, isDir bool) (bool, error) {
+	info, err := os.Lstat(dstPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, bosherr.WrapErrorf(err, "Checking '%s'", dstPath)
+	}
+
+	if isDir && info.IsDir() {
+		return true, nil
+	}
+
+	err = os.RemoveAll(dstPath)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Removing '%s'", dstPath)
+	}
+
+	return false, nil
+}
+
+func walkMigratedEntries(dir string, walkFunc func(relPath string, info os.FileInfo) error) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Walking '%s'", path)
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath == "." {
+			return nil
+		}
+
+		if isExcludedFromMigration(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return walkFunc(relPath, info)
+	})
+}
+
+func migratedFileChecksum(path string, reporter *migrationReporter) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Opening '%s'", path)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	_, err = io.Copy(io.MultiWriter(hash, reporter), file)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Reading '%s'", path)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// migrationReporter accumulates progress of the current stage
+// and publishes it at most once per migrationProgressInterval
+type migrationReporter struct {
+	progress   MigrationProgressFunc
+	current    MigrationProgress
+	reportedAt time.Time
+}
+
+func newMigrationReporter(progress MigrationProgressFunc) *migrationReporter {
+	if progress == nil {
+		progress = func(MigrationProgress) {}
+	}
+
+	return &migrationReporter{progress: progress}
+}
+
+func (r *migrationReporter) Start(stage string, totalFiles, totalBytes uint64) {
+	r.current = MigrationProgress{Stage: stage, TotalFiles: totalFiles, TotalBytes: totalBytes}
+	r.Flush()
+}
+
+func (r *migrationReporter) AddFile() {
+	r.current.DoneFiles++
+	r.reportPeriodically()
+}
+
+func (r *migrationReporter) AddBytes(n uint64) {
+	r.current.DoneBytes += n
+	r.reportPeriodically()
+}
+
+// Write counts bytes of copied or verified file contents
+func (r *migrationReporter) Write(p []byte) (int, error) {
+	r.AddBytes(uint64(len(p)))
+	return len(p), nil
+}
+
+func (r *migrationReporter) Flush() {
+	r.reportedAt = time.Now()
+	r.progress(r.current)
+}
+
+func (r *migrationReporter) reportPeriodically() {
+	if time.Since(r.reportedAt) >= migrationProgressInterval {
+		r.Flush()
+	}
+}
@@ -0,0 +1,299 @@
+package disk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type migrationInodeKey struct {
+	dev uint64
+	ino uint64
+}
+
+// migrationInode returns inode of a regular file and whether it has multiple hard links
+func migrationInode(info os.FileInfo) (migrationInodeKey, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || !info.Mode().IsRegular() {
+		return migrationInodeKey{}, false
+	}
+
+	return migrationInodeKey{dev: uint64(stat.Dev), ino: stat.Ino}, stat.Nlink > 1
+}
+
+// copyMigratedFile copies contents and metadata of a regular file skipping holes
+// of sparse files and returns checksum of its contents
+func copyMigratedFile(srcPath, dstPath string, info os.FileInfo, counter io.Writer) (string, error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.FileMode(0600))
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+
+	err = copySparseContents(src, dst, info.Size(), io.MultiWriter(hash, counter))
+	if err != nil {
+		_ = dst.Close()
+		return "", err
+	}
+
+	err = dst.Close()
+	if err != nil {
+		return "", err
+	}
+
+	err = copyMigratedMetadata(srcPath, dstPath, info)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// copySparseContents copies only data regions of src; holes are recreated
+// by extending dst to full size and are hashed as zeros
+func copySparseContents(src, dst *os.File, size int64, hash io.Writer) error {
+	srcFd := int(src.Fd())
+	offset := int64(0)
+
+	for offset < size {
+		dataStart, err := unix.Seek(srcFd, offset, unix.SEEK_DATA)
+		if err != nil {
+			if errors.Is(err, unix.ENXIO) {
+				// No more data till the end of file
+				dataStart = size
+			} else {
+				// Filesystem does not support finding holes
+				dataStart = offset
+			}
+		}
+
+		if dataStart > size {
+			dataStart = size
+		}
+
+		err = writeZeros(hash, dataStart-offset)
+		if err != nil {
+			return err
+		}
+
+		if dataStart >= size {
+			break
+		}
+
+		dataEnd, err := unix.Seek(srcFd, dataStart, unix.SEEK_HOLE)
+		if err != nil || dataEnd > size {
+			dataEnd = size
+		}
+
+		_, err = src.Seek(dataStart, io.SeekStart)
+		if err != nil {
+			return err
+		}
+
+		_, err = dst.Seek(dataStart, io.SeekStart)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.CopyN(io.MultiWriter(dst, hash), src, dataEnd-dataStart)
+		if err != nil {
+			return err
+		}
+
+		offset = dataEnd
+	}
+
+	return dst.Truncate(size)
+}
+
+func writeZeros(writer io.Writer, n int64) error {
+	zeros := make([]byte, 64*1024)
+
+	for n > 0 {
+		chunk := int64(len(zeros))
+		if n < chunk {
+			chunk = n
+		}
+
+		_, err := writer.Write(zeros[:chunk])
+		if err != nil {
+			return err
+		}
+
+		n -= chunk
+	}
+
+	return nil
+}
+
+func copyMigratedSymlink(srcPath, dstPath string, info os.FileInfo) error {
+	target, err := os.Readlink(srcPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.Symlink(target, dstPath)
+	if err != nil {
+		return err
+	}
+
+	return copyMigratedMetadata(srcPath, dstPath, info)
+}
+
+// copyMigratedSpecialFile recreates device nodes and named pipes
+func copyMigratedSpecialFile(srcPath, dstPath string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return bosherr.Errorf("Unable to stat '%s'", srcPath)
+	}
+
+	err := unix.Mknod(dstPath, stat.Mode, int(stat.Rdev))
+	if err != nil {
+		return err
+	}
+
+	return copyMigratedMetadata(srcPath, dstPath, info)
+}
+
+// copyMigratedMetadata copies ownership, permissions, extended attributes and timestamps;
+// ownership goes first since changing it clears setuid bits and file capabilities
+func copyMigratedMetadata(srcPath, dstPath string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return bosherr.Errorf("Unable to stat '%s'", srcPath)
+	}
+
+	isSymlink := info.Mode()&os.ModeSymlink != 0
+
+	err := os.Lchown(dstPath, int(stat.Uid), int(stat.Gid))
+	if err != nil {
+		return err
+	}
+
+	if !isSymlink {
+		err = unix.Chmod(dstPath, stat.Mode&07777)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = copyXattrs(srcPath, dstPath, isSymlink)
+	if err != nil {
+		return err
+	}
+
+	times := []unix.Timespec{
+		unix.NsecToTimespec(syscall.TimespecToNsec(stat.Atim)),
+		unix.NsecToTimespec(syscall.TimespecToNsec(stat.Mtim)),
+	}
+
+	return unix.UtimesNanoAt(unix.AT_FDCWD, dstPath, times, unix.AT_SYMLINK_NOFOLLOW)
+}
+
+// copyXattrs copies extended attributes in all namespaces
+// including system.posix_acl_* attributes holding ACLs
+func copyXattrs(srcPath, dstPath string, isSymlink bool) error {
+	names, err := listXattrs(srcPath)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil
+		}
+		return bosherr.WrapErrorf(err, "Listing extended attributes of '%s'", srcPath)
+	}
+
+	for _, name := range names {
+		value, err := getXattr(srcPath, name)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Getting extended attribute '%s' of '%s'", name, srcPath)
+		}
+
+		err = unix.Lsetxattr(dstPath, name, value, 0)
+		if err != nil {
+			// Symlinks cannot have attributes in user namespace
+			if isSymlink && (errors.Is(err, unix.EPERM) || errors.Is(err, unix.ENOTSUP)) {
+				continue
+			}
+			return bosherr.WrapErrorf(err, "Setting extended attribute '%s' of '%s'", name, dstPath)
+		}
+	}
+
+	return nil
+}
+
+func listXattrs(path string) ([]string, error) {
+	for {
+		size, err := unix.Llistxattr(path, nil)
+		if err != nil || size == 0 {
+			return nil, err
+		}
+
+		buffer := make([]byte, size)
+
+		size, err = unix.Llistxattr(path, buffer)
+		if errors.Is(err, unix.ERANGE) {
+			// Attributes were added in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		names := []string{}
+		start := 0
+		for i, b := range buffer[:size] {
+			if b == 0 {
+				if i > start {
+					names = append(names, string(buffer[start:i]))
+				}
+				start = i + 1
+			}
+		}
+
+		return names, nil
+	}
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	for {
+		size, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		buffer := make([]byte, size)
+
+		size, err = unix.Lgetxattr(path, name, buffer)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return buffer[:size], nil
+	}
+}
+
+func syncMigratedFilesystem(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return unix.Syncfs(int(file.Fd()))
+}
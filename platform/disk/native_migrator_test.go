@@ -0,0 +1,293 @@
+//go:build linux
+// +build linux
+
+package disk_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("nativeMigrator", func() {
+	var (
+		fromDir  string
+		toDir    string
+		migrator Migrator
+		mtime    time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		fromDir, err = os.MkdirTemp("", "native-migrator-from")
+		Expect(err).ToNot(HaveOccurred())
+
+		toDir, err = os.MkdirTemp("", "native-migrator-to")
+		Expect(err).ToNot(HaveOccurred())
+
+		migrator = NewNativeMigrator(boshlog.NewLogger(boshlog.LevelNone))
+		mtime = time.Date(2020, 1, 2, 3, 4, 5, 600, time.UTC)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(fromDir)).To(Succeed())
+		Expect(os.RemoveAll(toDir)).To(Succeed())
+	})
+
+	writeFile := func(relPath, contents string) string {
+		path := filepath.Join(fromDir, relPath)
+		Expect(os.MkdirAll(filepath.Dir(path), os.FileMode(0755))).To(Succeed())
+		Expect(os.WriteFile(path, []byte(contents), os.FileMode(0644))).To(Succeed())
+		Expect(os.Chtimes(path, mtime, mtime)).To(Succeed())
+		return path
+	}
+
+	writeJournal := func(records ...map[string]interface{}) {
+		dir := filepath.Join(toDir, MigrationJournalDirName)
+		Expect(os.MkdirAll(dir, os.FileMode(0700))).To(Succeed())
+
+		file, err := os.Create(filepath.Join(dir, "journal"))
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		for _, record := range records {
+			Expect(json.NewEncoder(file).Encode(record)).To(Succeed())
+		}
+	}
+
+	checksum := func(contents string) string {
+		sum := sha256.Sum256([]byte(contents))
+		return hex.EncodeToString(sum[:])
+	}
+
+	inode := func(path string) uint64 {
+		info, err := os.Lstat(path)
+		Expect(err).ToNot(HaveOccurred())
+		return info.Sys().(*syscall.Stat_t).Ino
+	}
+
+	It("copies files with their ownership, permissions, timestamps and extended attributes", func() {
+		path := writeFile("dir/file", "fake-contents")
+		Expect(os.Chmod(path, os.FileMode(0640)|os.ModeSetgid)).To(Succeed())
+		Expect(os.Lchown(path, 1000, 2000)).To(Succeed())
+		Expect(unix.Setxattr(path, "user.fake-attr", []byte("fake-value"), 0)).To(Succeed())
+
+		dirPath := filepath.Join(fromDir, "dir")
+		Expect(os.Chmod(dirPath, os.FileMode(0750))).To(Succeed())
+		Expect(os.Chtimes(dirPath, mtime, mtime)).To(Succeed())
+
+		err := migrator.Migrate(fromDir, toDir, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := os.ReadFile(filepath.Join(toDir, "dir/file"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("fake-contents"))
+
+		info, err := os.Lstat(filepath.Join(toDir, "dir/file"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode()).To(Equal(os.FileMode(0640) | os.ModeSetgid))
+		Expect(info.ModTime().Equal(mtime)).To(BeTrue())
+		Expect(info.Sys().(*syscall.Stat_t).Uid).To(Equal(uint32(1000)))
+		Expect(info.Sys().(*syscall.Stat_t).Gid).To(Equal(uint32(2000)))
+
+		value := make([]byte, 64)
+		size, err := unix.Getxattr(filepath.Join(toDir, "dir/file"), "user.fake-attr", value)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(value[:size])).To(Equal("fake-value"))
+
+		dirInfo, err := os.Lstat(filepath.Join(toDir, "dir"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dirInfo.Mode()).To(Equal(os.ModeDir | os.FileMode(0750)))
+		Expect(dirInfo.ModTime().Equal(mtime)).To(BeTrue())
+	})
+
+	It("copies symlinks and named pipes", func() {
+		writeFile("file", "fake-contents")
+		Expect(os.Symlink("file", filepath.Join(fromDir, "link"))).To(Succeed())
+		Expect(unix.Mkfifo(filepath.Join(fromDir, "pipe"), 0600)).To(Succeed())
+
+		err := migrator.Migrate(fromDir, toDir, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		target, err := os.Readlink(filepath.Join(toDir, "link"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target).To(Equal("file"))
+
+		info, err := os.Lstat(filepath.Join(toDir, "pipe"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode()).To(Equal(os.ModeNamedPipe | os.FileMode(0600)))
+	})
+
+	It("preserves hard links", func() {
+		writeFile("file", "fake-contents")
+		Expect(os.Link(filepath.Join(fromDir, "file"), filepath.Join(fromDir, "other-file"))).To(Succeed())
+
+		err := migrator.Migrate(fromDir, toDir, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(inode(filepath.Join(toDir, "file"))).To(Equal(inode(filepath.Join(toDir, "other-file"))))
+	})
+
+	It("keeps holes of sparse files", func() {
+		path := writeFile("sparse", "")
+		file, err := os.OpenFile(path, os.O_WRONLY, 0)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = file.WriteAt([]byte("fake-tail"), 64*1024*1024)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		err = migrator.Migrate(fromDir, toDir, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		info, err := os.Lstat(filepath.Join(toDir, "sparse"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Size()).To(Equal(int64(64*1024*1024 + len("fake-tail"))))
+		Expect(info.Sys().(*syscall.Stat_t).Blocks * 512).To(BeNumerically("<", 1024*1024))
+	})
+
+	It("skips lost+found and removes migration journal once done", func() {
+		writeFile("lost+found/fake-file", "fake-contents")
+		writeFile("file", "fake-contents")
+
+		err := migrator.Migrate(fromDir, toDir, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(filepath.Join(toDir, "lost+found")).ToNot(BeAnExistingFile())
+		Expect(filepath.Join(toDir, MigrationJournalDirName)).ToNot(BeAnExistingFile())
+	})
+
+	It("skips snapshots since they are bound to the source disk", func() {
+		writeFile(".snapshots/fake-snapshot/file", "fake-contents")
+		writeFile(".snapshot-restore/file", "fake-contents")
+		writeFile("file", "fake-contents")
+
+		err := migrator.Migrate(fromDir, toDir, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(filepath.Join(toDir, "file")).To(BeAnExistingFile())
+		Expect(filepath.Join(toDir, SnapshotsDirName)).ToNot(BeAnExistingFile())
+		Expect(filepath.Join(toDir, ".snapshot-restore")).ToNot(BeAnExistingFile())
+	})
+
+	It("removes entries left on target disk which are not on source disk", func() {
+		writeFile("file", "fake-contents")
+		Expect(os.MkdirAll(filepath.Join(toDir, "stale-dir"), os.FileMode(0755))).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(toDir, "stale-file"), []byte("stale"), os.FileMode(0644))).To(Succeed())
+
+		err := migrator.Migrate(fromDir, toDir, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(filepath.Join(toDir, "file")).To(BeAnExistingFile())
+		Expect(filepath.Join(toDir, "stale-dir")).ToNot(BeAnExistingFile())
+		Expect(filepath.Join(toDir, "stale-file")).ToNot(BeAnExistingFile())
+	})
+
+	It("reports progress of every stage", func() {
+		writeFile("dir/file-1", "1234")
+		writeFile("dir/file-2", "123456")
+
+		reported := []MigrationProgress{}
+
+		err := migrator.Migrate(fromDir, toDir, func(progress MigrationProgress) {
+			reported = append(reported, progress)
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(reported[0].Stage).To(Equal(MigrationStageScanning))
+		Expect(reported).To(ContainElement(MigrationProgress{
+			Stage:      MigrationStageCopying,
+			DoneFiles:  3,
+			TotalFiles: 3,
+			DoneBytes:  10,
+			TotalBytes: 10,
+		}))
+		Expect(reported[len(reported)-1]).To(Equal(MigrationProgress{
+			Stage:      MigrationStageVerifying,
+			DoneFiles:  2,
+			TotalFiles: 2,
+			DoneBytes:  10,
+			TotalBytes: 10,
+		}))
+	})
+
+	Context("when target disk holds a journal of interrupted migration", func() {
+		It("does not copy journaled entries again", func() {
+			writeFile("copied", "fake-contents")
+			writeFile("not-copied", "fake-contents")
+
+			copiedPath := filepath.Join(toDir, "copied")
+			Expect(os.WriteFile(copiedPath, []byte("fake-contents"), os.FileMode(0644))).To(Succeed())
+			copiedInode := inode(copiedPath)
+
+			writeJournal(map[string]interface{}{
+				"path":   "copied",
+				"size":   len("fake-contents"),
+				"mtime":  mtime.UnixNano(),
+				"sha256": checksum("fake-contents"),
+			})
+
+			err := migrator.Migrate(fromDir, toDir, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(inode(copiedPath)).To(Equal(copiedInode))
+			Expect(filepath.Join(toDir, "not-copied")).To(BeAnExistingFile())
+		})
+
+		It("copies journaled entries again when they changed on source disk", func() {
+			writeFile("file", "new-contents")
+
+			Expect(os.WriteFile(filepath.Join(toDir, "file"), []byte("old-contents"), os.FileMode(0644))).To(Succeed())
+
+			writeJournal(map[string]interface{}{
+				"path":   "file",
+				"size":   len("old-contents"),
+				"mtime":  mtime.Add(-time.Hour).UnixNano(),
+				"sha256": checksum("old-contents"),
+			})
+
+			err := migrator.Migrate(fromDir, toDir, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := os.ReadFile(filepath.Join(toDir, "file"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("new-contents"))
+		})
+
+		It("fails verification of corrupted files and copies them again on the next attempt", func() {
+			writeFile("file", "fake-contents")
+
+			Expect(os.WriteFile(filepath.Join(toDir, "file"), []byte("bad-contents!"), os.FileMode(0644))).To(Succeed())
+
+			writeJournal(map[string]interface{}{
+				"path":   "file",
+				"size":   len("fake-contents"),
+				"mtime":  mtime.UnixNano(),
+				"sha256": checksum("fake-contents"),
+			})
+
+			err := migrator.Migrate(fromDir, toDir, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Checksums of 1 copied files do not match source disk, e.g. 'file'"))
+			Expect(filepath.Join(toDir, MigrationJournalDirName)).To(BeADirectory())
+
+			err = migrator.Migrate(fromDir, toDir, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := os.ReadFile(filepath.Join(toDir, "file"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("fake-contents"))
+		})
+	})
+})
@@ -0,0 +1,38 @@
+//go:build !linux
+// +build !linux
+
+package disk
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var errMigrationUnsupported = errors.New("Native disk migration is only supported on Linux")
+
+type migrationInodeKey struct{}
+
+func migrationInode(info os.FileInfo) (migrationInodeKey, bool) {
+	return migrationInodeKey{}, false
+}
+
+func copyMigratedFile(srcPath, dstPath string, info os.FileInfo, counter io.Writer) (string, error) {
+	return "", errMigrationUnsupported
+}
+
+func copyMigratedSymlink(srcPath, dstPath string, info os.FileInfo) error {
+	return errMigrationUnsupported
+}
+
+func copyMigratedSpecialFile(srcPath, dstPath string, info os.FileInfo) error {
+	return errMigrationUnsupported
+}
+
+func copyMigratedMetadata(srcPath, dstPath string, info os.FileInfo) error {
+	return errMigrationUnsupported
+}
+
+func syncMigratedFilesystem(dir string) error {
+	return errMigrationUnsupported
+}
@@ -0,0 +1,23 @@
+package disk
+
+import (
+	"time"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Snapshotter
+
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Snapshotter interface {
+	// CreateSnapshot creates read-only snapshot of the filesystem mounted at mountPoint
+	CreateSnapshot(mountPoint, name string) (Snapshot, error)
+	ListSnapshots(mountPoint string) ([]Snapshot, error)
+
+	// RestoreSnapshot replaces contents of mountPoint with contents of the snapshot
+	RestoreSnapshot(mountPoint, name string) error
+	DeleteSnapshot(mountPoint, name string) error
+}
@@ -0,0 +1,28 @@
+package disk
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type unsupportedSnapshotter struct{}
+
+// NewUnsupportedSnapshotter is used on platforms without snapshot capable filesystems
+func NewUnsupportedSnapshotter() Snapshotter {
+	return unsupportedSnapshotter{}
+}
+
+func (s unsupportedSnapshotter) CreateSnapshot(mountPoint, name string) (Snapshot, error) {
+	return Snapshot{}, bosherr.Error("Disk snapshots are not supported on this platform")
+}
+
+func (s unsupportedSnapshotter) ListSnapshots(mountPoint string) ([]Snapshot, error) {
+	return nil, bosherr.Error("Disk snapshots are not supported on this platform")
+}
+
+func (s unsupportedSnapshotter) RestoreSnapshot(mountPoint, name string) error {
+	return bosherr.Error("Disk snapshots are not supported on this platform")
+}
+
+func (s unsupportedSnapshotter) DeleteSnapshot(mountPoint, name string) error {
+	return bosherr.Error("Disk snapshots are not supported on this platform")
+}
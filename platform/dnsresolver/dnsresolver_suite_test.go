@@ -0,0 +1,13 @@
+package dnsresolver_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDNSResolver(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DNS Resolver Suite")
+}
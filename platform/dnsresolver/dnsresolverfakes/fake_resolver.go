@@ -0,0 +1,316 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package dnsresolverfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/platform/dnsresolver"
+	"github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type FakeResolver struct {
+	IsRunningStub        func() bool
+	isRunningMutex       sync.RWMutex
+	isRunningArgsForCall []struct {
+	}
+	isRunningReturns struct {
+		result1 bool
+	}
+	isRunningReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	SetUpstreamsStub        func([]string)
+	setUpstreamsMutex       sync.RWMutex
+	setUpstreamsArgsForCall []struct {
+		arg1 []string
+	}
+	StartStub        func() error
+	startMutex       sync.RWMutex
+	startArgsForCall []struct {
+	}
+	startReturns struct {
+		result1 error
+	}
+	startReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StopStub        func() error
+	stopMutex       sync.RWMutex
+	stopArgsForCall []struct {
+	}
+	stopReturns struct {
+		result1 error
+	}
+	stopReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateRecordsStub        func(settings.DNSRecords)
+	updateRecordsMutex       sync.RWMutex
+	updateRecordsArgsForCall []struct {
+		arg1 settings.DNSRecords
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeResolver) IsRunning() bool {
+	fake.isRunningMutex.Lock()
+	ret, specificReturn := fake.isRunningReturnsOnCall[len(fake.isRunningArgsForCall)]
+	fake.isRunningArgsForCall = append(fake.isRunningArgsForCall, struct {
+	}{})
+	stub := fake.IsRunningStub
+	fakeReturns := fake.isRunningReturns
+	fake.recordInvocation("IsRunning", []interface{}{})
+	fake.isRunningMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeResolver) IsRunningCallCount() int {
+	fake.isRunningMutex.RLock()
+	defer fake.isRunningMutex.RUnlock()
+	return len(fake.isRunningArgsForCall)
+}
+
+func (fake *FakeResolver) IsRunningCalls(stub func() bool) {
+	fake.isRunningMutex.Lock()
+	defer fake.isRunningMutex.Unlock()
+	fake.IsRunningStub = stub
+}
+
+func (fake *FakeResolver) IsRunningReturns(result1 bool) {
+	fake.isRunningMutex.Lock()
+	defer fake.isRunningMutex.Unlock()
+	fake.IsRunningStub = nil
+	fake.isRunningReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeResolver) IsRunningReturnsOnCall(i int, result1 bool) {
+	fake.isRunningMutex.Lock()
+	defer fake.isRunningMutex.Unlock()
+	fake.IsRunningStub = nil
+	if fake.isRunningReturnsOnCall == nil {
+		fake.isRunningReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isRunningReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeResolver) SetUpstreams(arg1 []string) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.setUpstreamsMutex.Lock()
+	fake.setUpstreamsArgsForCall = append(fake.setUpstreamsArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	stub := fake.SetUpstreamsStub
+	fake.recordInvocation("SetUpstreams", []interface{}{arg1Copy})
+	fake.setUpstreamsMutex.Unlock()
+	if stub != nil {
+		fake.SetUpstreamsStub(arg1)
+	}
+}
+
+func (fake *FakeResolver) SetUpstreamsCallCount() int {
+	fake.setUpstreamsMutex.RLock()
+	defer fake.setUpstreamsMutex.RUnlock()
+	return len(fake.setUpstreamsArgsForCall)
+}
+
+func (fake *FakeResolver) SetUpstreamsCalls(stub func([]string)) {
+	fake.setUpstreamsMutex.Lock()
+	defer fake.setUpstreamsMutex.Unlock()
+	fake.SetUpstreamsStub = stub
+}
+
+func (fake *FakeResolver) SetUpstreamsArgsForCall(i int) []string {
+	fake.setUpstreamsMutex.RLock()
+	defer fake.setUpstreamsMutex.RUnlock()
+	argsForCall := fake.setUpstreamsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeResolver) Start() error {
+	fake.startMutex.Lock()
+	ret, specificReturn := fake.startReturnsOnCall[len(fake.startArgsForCall)]
+	fake.startArgsForCall = append(fake.startArgsForCall, struct {
+	}{})
+	stub := fake.StartStub
+	fakeReturns := fake.startReturns
+	fake.recordInvocation("Start", []interface{}{})
+	fake.startMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeResolver) StartCallCount() int {
+	fake.startMutex.RLock()
+	defer fake.startMutex.RUnlock()
+	return len(fake.startArgsForCall)
+}
+
+func (fake *FakeResolver) StartCalls(stub func() error) {
+	fake.startMutex.Lock()
+	defer fake.startMutex.Unlock()
+	fake.StartStub = stub
+}
+
+func (fake *FakeResolver) StartReturns(result1 error) {
+	fake.startMutex.Lock()
+	defer fake.startMutex.Unlock()
+	fake.StartStub = nil
+	fake.startReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeResolver) StartReturnsOnCall(i int, result1 error) {
+	fake.startMutex.Lock()
+	defer fake.startMutex.Unlock()
+	fake.StartStub = nil
+	if fake.startReturnsOnCall == nil {
+		fake.startReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.startReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeResolver) Stop() error {
+	fake.stopMutex.Lock()
+	ret, specificReturn := fake.stopReturnsOnCall[len(fake.stopArgsForCall)]
+	fake.stopArgsForCall = append(fake.stopArgsForCall, struct {
+	}{})
+	stub := fake.StopStub
+	fakeReturns := fake.stopReturns
+	fake.recordInvocation("Stop", []interface{}{})
+	fake.stopMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeResolver) StopCallCount() int {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return len(fake.stopArgsForCall)
+}
+
+func (fake *FakeResolver) StopCalls(stub func() error) {
+	fake.stopMutex.Lock()
+	defer fake.stopMutex.Unlock()
+	fake.StopStub = stub
+}
+
+func (fake *FakeResolver) StopReturns(result1 error) {
+	fake.stopMutex.Lock()
+	defer fake.stopMutex.Unlock()
+	fake.StopStub = nil
+	fake.stopReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeResolver) StopReturnsOnCall(i int, result1 error) {
+	fake.stopMutex.Lock()
+	defer fake.stopMutex.Unlock()
+	fake.StopStub = nil
+	if fake.stopReturnsOnCall == nil {
+		fake.stopReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.stopReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeResolver) UpdateRecords(arg1 settings.DNSRecords) {
+	fake.updateRecordsMutex.Lock()
+	fake.updateRecordsArgsForCall = append(fake.updateRecordsArgsForCall, struct {
+		arg1 settings.DNSRecords
+	}{arg1})
+	stub := fake.UpdateRecordsStub
+	fake.recordInvocation("UpdateRecords", []interface{}{arg1})
+	fake.updateRecordsMutex.Unlock()
+	if stub != nil {
+		fake.UpdateRecordsStub(arg1)
+	}
+}
+
+func (fake *FakeResolver) UpdateRecordsCallCount() int {
+	fake.updateRecordsMutex.RLock()
+	defer fake.updateRecordsMutex.RUnlock()
+	return len(fake.updateRecordsArgsForCall)
+}
+
+func (fake *FakeResolver) UpdateRecordsCalls(stub func(settings.DNSRecords)) {
+	fake.updateRecordsMutex.Lock()
+	defer fake.updateRecordsMutex.Unlock()
+	fake.UpdateRecordsStub = stub
+}
+
+func (fake *FakeResolver) UpdateRecordsArgsForCall(i int) settings.DNSRecords {
+	fake.updateRecordsMutex.RLock()
+	defer fake.updateRecordsMutex.RUnlock()
+	argsForCall := fake.updateRecordsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeResolver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.isRunningMutex.RLock()
+	defer fake.isRunningMutex.RUnlock()
+	fake.setUpstreamsMutex.RLock()
+	defer fake.setUpstreamsMutex.RUnlock()
+	fake.startMutex.RLock()
+	defer fake.startMutex.RUnlock()
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	fake.updateRecordsMutex.RLock()
+	defer fake.updateRecordsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeResolver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ dnsresolver.Resolver = new(FakeResolver)
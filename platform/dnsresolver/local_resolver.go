@@ -0,0 +1,629 @@
+package dnsresolver
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"golang.org/x/net/dns/dnsmessage"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	ListenIP       = "127.0.0.1"
+	DefaultAddress = ListenIP + ":53"
+
+	localResolverLogTag = "LocalDNSResolver"
+
+	// Synced records may change with every sync_dns hence short TTL
+	localRecordTTL = 30
+
+	upstreamTimeout = 2 * time.Second
+	upstreamBackoff = 30 * time.Second
+	tcpIdleTimeout  = 10 * time.Second
+
+	minUDPSize        = 512
+	advertisedUDPSize = 4096
+	maxMessageSize    = 65535
+)
+
+type upstream struct {
+	address     string
+	failedUntil time.Time
+}
+
+type localResolver struct {
+	address string
+	clock   clock.Clock
+	logger  boshlog.Logger
+	cache   *responseCache
+
+	// records holds *recordSet and is swapped as a whole on every update
+	records atomic.Value
+
+	lock        sync.Mutex
+	upstreams   []*upstream
+	udpConn     net.PacketConn
+	tcpListener net.Listener
+}
+
+func NewLocalResolver(address string, clock clock.Clock, logger boshlog.Logger) Resolver {
+	resolver := &localResolver{
+		address: address,
+		clock:   clock,
+		logger:  logger,
+		cache:   newResponseCache(clock),
+	}
+	resolver.records.Store(newRecordSet(boshsettings.DNSRecords{}))
+	return resolver
+}
+
+func (r *localResolver) Start() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.udpConn != nil {
+		return nil
+	}
+
+	udpConn, err := net.ListenPacket("udp", r.address)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listening on udp address '%s'", r.address)
+	}
+
+	tcpListener, err := net.Listen("tcp", r.address)
+	if err != nil {
+		_ = udpConn.Close()
+		return bosherr.WrapErrorf(err, "Listening on tcp address '%s'", r.address)
+	}
+
+	r.udpConn = udpConn
+	r.tcpListener = tcpListener
+
+	go r.serveUDP(udpConn)
+	go r.serveTCP(tcpListener)
+
+	r.logger.Info(localResolverLogTag, "Listening on '%s'", r.address)
+
+	return nil
+}
+
+func (r *localResolver) Stop() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.udpConn == nil {
+		return nil
+	}
+
+	udpErr := r.udpConn.Close()
+	tcpErr := r.tcpListener.Close()
+
+	r.udpConn = nil
+	r.tcpListener = nil
+
+	if udpErr != nil {
+		return bosherr.WrapError(udpErr, "Closing udp listener")
+	}
+	if tcpErr != nil {
+		return bosherr.WrapError(tcpErr, "Closing tcp listener")
+	}
+
+	return nil
+}
+
+func (r *localResolver) IsRunning() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.udpConn != nil
+}
+
+func (r *localResolver) UpdateRecords(records boshsettings.DNSRecords) {
+	r.records.Store(newRecordSet(records))
+	r.logger.Info(localResolverLogTag, "Serving %d DNS records of version %d", len(records.Records), records.Version)
+}
+
+func (r *localResolver) SetUpstreams(servers []string) {
+	upstreams := []*upstream{}
+
+	for _, server := range servers {
+		address := server
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			address = net.JoinHostPort(server, "53")
+		}
+		upstreams = append(upstreams, &upstream{address: address})
+	}
+
+	r.lock.Lock()
+	r.upstreams = upstreams
+	r.lock.Unlock()
+
+	r.cache.Purge()
+}
+
+func (r *localResolver) serveUDP(conn net.PacketConn) {
+	for {
+		buffer := make([]byte, maxMessageSize)
+
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			r.logger.Error(localResolverLogTag, "Reading udp query: %s", err)
+			continue
+		}
+
+		go func() {
+			response := r.handle(buffer[:n], true)
+			if response == nil {
+				return
+			}
+
+			_, err := conn.WriteTo(response, addr)
+			if err != nil {
+				r.logger.Debug(localResolverLogTag, "Writing udp response to '%s': %s", addr, err)
+			}
+		}()
+	}
+}
+
+func (r *localResolver) serveTCP(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			r.logger.Error(localResolverLogTag, "Accepting tcp connection: %s", err)
+			continue
+		}
+
+		go r.serveTCPConn(conn)
+	}
+}
+
+func (r *localResolver) serveTCPConn(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		_ = conn.SetDeadline(time.Now().Add(tcpIdleTimeout))
+
+		request, err := readTCPMessage(conn)
+		if err != nil {
+			return
+		}
+
+		response := r.handle(request, false)
+		if response == nil {
+			return
+		}
+
+		if err = writeTCPMessage(conn, response); err != nil {
+			return
+		}
+	}
+}
+
+// handle returns packed response to packed query or nil if query should be dropped
+func (r *localResolver) handle(request []byte, udp bool) []byte {
+	var query dnsmessage.Message
+
+	err := query.Unpack(request)
+	if err != nil {
+		r.logger.Debug(localResolverLogTag, "Dropping malformed query: %s", err)
+		return nil
+	}
+
+	if query.Response {
+		return nil
+	}
+
+	response := r.resolve(query)
+
+	_, edns := findOPT(query.Additionals)
+	if edns {
+		response.Additionals = append(response.Additionals, newOPT())
+	}
+
+	maxSize := maxMessageSize
+	if udp {
+		maxSize = udpSize(query)
+	}
+
+	packed, err := response.Pack()
+	if err != nil {
+		r.logger.Error(localResolverLogTag, "Packing response: %s", err)
+		failure := r.reply(query, dnsmessage.RCodeServerFailure)
+		packed, err = failure.Pack()
+		if err != nil {
+			return nil
+		}
+	}
+
+	if len(packed) > maxSize {
+		// Client is expected to retry over tcp
+		response.Truncated = true
+		response.Answers = nil
+		response.Authorities = nil
+		response.Additionals = nil
+		if edns {
+			response.Additionals = []dnsmessage.Resource{newOPT()}
+		}
+
+		packed, err = response.Pack()
+		if err != nil {
+			return nil
+		}
+	}
+
+	return packed
+}
+
+func (r *localResolver) resolve(query dnsmessage.Message) dnsmessage.Message {
+	if query.OpCode != 0 {
+		return r.reply(query, dnsmessage.RCodeNotImplemented)
+	}
+
+	if len(query.Questions) != 1 {
+		return r.reply(query, dnsmessage.RCodeFormatError)
+	}
+
+	question := query.Questions[0]
+
+	if response, found := r.answerLocally(query, question); found {
+		return response
+	}
+
+	if response, found := r.cache.Get(question); found {
+		response.ID = query.ID
+		response.RecursionDesired = query.RecursionDesired
+		return response
+	}
+
+	response, err := r.forward(question)
+	if err != nil {
+		r.logger.Error(localResolverLogTag, "Forwarding query for '%s': %s", question.Name, err)
+		return r.reply(query, dnsmessage.RCodeServerFailure)
+	}
+
+	r.cache.Put(question, response)
+
+	response.ID = query.ID
+	response.RecursionDesired = query.RecursionDesired
+
+	return response
+}
+
+func (r *localResolver) answerLocally(query dnsmessage.Message, question dnsmessage.Question) (dnsmessage.Message, bool) {
+	if question.Class != dnsmessage.ClassINET {
+		return dnsmessage.Message{}, false
+	}
+
+	records := r.records.Load().(*recordSet)
+	name := question.Name.String()
+
+	if question.Type == dnsmessage.TypeSRV {
+		hosts, found := records.lookupService(name)
+		if !found {
+			return dnsmessage.Message{}, false
+		}
+
+		response := r.reply(query, dnsmessage.RCodeSuccess)
+		response.Authoritative = true
+
+		for _, host := range hosts {
+			target, err := dnsmessage.NewName(host)
+			if err != nil {
+				continue
+			}
+
+			// Records do not carry ports hence clients are expected
+			// to use well known port of the service
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET, TTL: localRecordTTL},
+				Body:   &dnsmessage.SRVResource{Priority: 0, Weight: 1, Port: 0, Target: target},
+			})
+
+			ips, _ := records.lookup(host)
+			response.Additionals = append(response.Additionals, addressResources(target, ips, dnsmessage.TypeA)...)
+			response.Additionals = append(response.Additionals, addressResources(target, ips, dnsmessage.TypeAAAA)...)
+		}
+
+		return response, true
+	}
+
+	ips, found := records.lookup(name)
+	if !found {
+		return dnsmessage.Message{}, false
+	}
+
+	// Known names without records of requested type are answered with NODATA
+	response := r.reply(query, dnsmessage.RCodeSuccess)
+	response.Authoritative = true
+	response.Answers = addressResources(question.Name, ips, question.Type)
+
+	return response, true
+}
+
+func (r *localResolver) forward(question dnsmessage.Question) (dnsmessage.Message, error) {
+	id, err := randomID()
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	query := dnsmessage.Message{
+		Header:      dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions:   []dnsmessage.Question{question},
+		Additionals: []dnsmessage.Resource{newOPT()},
+	}
+
+	packed, err := query.Pack()
+	if err != nil {
+		return dnsmessage.Message{}, bosherr.WrapError(err, "Packing upstream query")
+	}
+
+	upstreams := r.upstreamsToTry()
+	if len(upstreams) == 0 {
+		return dnsmessage.Message{}, bosherr.Error("No upstream DNS servers configured")
+	}
+
+	var lastErr error
+
+	for _, upstream := range upstreams {
+		response, err := exchange(upstream.address, packed, id)
+		if err != nil {
+			r.logger.Debug(localResolverLogTag, "Upstream '%s' failed: %s", upstream.address, err)
+			r.markUpstream(upstream, false)
+			lastErr = err
+			continue
+		}
+
+		r.markUpstream(upstream, true)
+
+		response.Additionals = withoutOPT(response.Additionals)
+
+		return response, nil
+	}
+
+	return dnsmessage.Message{}, bosherr.WrapError(lastErr, "Querying upstream DNS servers")
+}
+
+// upstreamsToTry returns healthy upstreams first so that failing
+// upstreams are only queried when nothing else responds
+func (r *localResolver) upstreamsToTry() []*upstream {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.clock.Now()
+	healthy := []*upstream{}
+	failed := []*upstream{}
+
+	for _, upstream := range r.upstreams {
+		if now.Before(upstream.failedUntil) {
+			failed = append(failed, upstream)
+		} else {
+			healthy = append(healthy, upstream)
+		}
+	}
+
+	return append(healthy, failed...)
+}
+
+func (r *localResolver) markUpstream(upstream *upstream, healthy bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if healthy {
+		upstream.failedUntil = time.Time{}
+	} else {
+		upstream.failedUntil = r.clock.Now().Add(upstreamBackoff)
+	}
+}
+
+func (r *localResolver) reply(query dnsmessage.Message, rcode dnsmessage.RCode) dnsmessage.Message {
+	return dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 query.ID,
+			Response:           true,
+			OpCode:             query.OpCode,
+			RecursionDesired:   query.RecursionDesired,
+			RecursionAvailable: true,
+			RCode:              rcode,
+		},
+		Questions: query.Questions,
+	}
+}
+
+func exchange(address string, query []byte, id uint16) (dnsmessage.Message, error) {
+	response, err := exchangeUDP(address, query, id)
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	if response.Truncated {
+		return exchangeTCP(address, query, id)
+	}
+
+	return response, nil
+}
+
+func exchangeUDP(address string, query []byte, id uint16) (dnsmessage.Message, error) {
+	conn, err := net.DialTimeout("udp", address, upstreamTimeout)
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(upstreamTimeout))
+
+	if _, err = conn.Write(query); err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	buffer := make([]byte, maxMessageSize)
+
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return dnsmessage.Message{}, err
+		}
+
+		var response dnsmessage.Message
+		if err := response.Unpack(buffer[:n]); err != nil {
+			continue
+		}
+
+		// Ignore stray or spoofed responses
+		if !response.Response || response.ID != id {
+			continue
+		}
+
+		return response, nil
+	}
+}
+
+func exchangeTCP(address string, query []byte, id uint16) (dnsmessage.Message, error) {
+	conn, err := net.DialTimeout("tcp", address, upstreamTimeout)
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(upstreamTimeout))
+
+	if err = writeTCPMessage(conn, query); err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	packed, err := readTCPMessage(conn)
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	var response dnsmessage.Message
+	if err := response.Unpack(packed); err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	if !response.Response || response.ID != id {
+		return dnsmessage.Message{}, errors.New("Mismatched upstream response")
+	}
+
+	return response, nil
+}
+
+func readTCPMessage(reader io.Reader) ([]byte, error) {
+	var length uint16
+
+	if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+
+	message := make([]byte, length)
+	if _, err := io.ReadFull(reader, message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func writeTCPMessage(writer io.Writer, message []byte) error {
+	buffer := make([]byte, 2+len(message))
+	binary.BigEndian.PutUint16(buffer, uint16(len(message)))
+	copy(buffer[2:], message)
+
+	_, err := writer.Write(buffer)
+	return err
+}
+
+func addressResources(name dnsmessage.Name, ips []net.IP, qtype dnsmessage.Type) []dnsmessage.Resource {
+	resources := []dnsmessage.Resource{}
+
+	for _, ip := range ips {
+		header := dnsmessage.ResourceHeader{Name: name, Type: qtype, Class: dnsmessage.ClassINET, TTL: localRecordTTL}
+
+		if ip4 := ip.To4(); ip4 != nil {
+			if qtype == dnsmessage.TypeA {
+				resource := &dnsmessage.AResource{}
+				copy(resource.A[:], ip4)
+				resources = append(resources, dnsmessage.Resource{Header: header, Body: resource})
+			}
+		} else if qtype == dnsmessage.TypeAAAA {
+			resource := &dnsmessage.AAAAResource{}
+			copy(resource.AAAA[:], ip.To16())
+			resources = append(resources, dnsmessage.Resource{Header: header, Body: resource})
+		}
+	}
+
+	return resources
+}
+
+func newOPT() dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  dnsmessage.MustNewName("."),
+			Type:  dnsmessage.TypeOPT,
+			Class: dnsmessage.Class(advertisedUDPSize),
+		},
+		Body: &dnsmessage.OPTResource{},
+	}
+}
+
+// udpSize returns response size limit advertised by client with EDNS
+func udpSize(query dnsmessage.Message) int {
+	opt, found := findOPT(query.Additionals)
+	if !found {
+		return minUDPSize
+	}
+
+	size := int(opt.Header.Class)
+	if size < minUDPSize {
+		return minUDPSize
+	}
+	if size > advertisedUDPSize {
+		return advertisedUDPSize
+	}
+
+	return size
+}
+
+func findOPT(resources []dnsmessage.Resource) (dnsmessage.Resource, bool) {
+	for _, resource := range resources {
+		if resource.Header.Type == dnsmessage.TypeOPT {
+			return resource, true
+		}
+	}
+	return dnsmessage.Resource{}, false
+}
+
+func withoutOPT(resources []dnsmessage.Resource) []dnsmessage.Resource {
+	filtered := []dnsmessage.Resource{}
+	for _, resource := range resources {
+		if resource.Header.Type != dnsmessage.TypeOPT {
+			filtered = append(filtered, resource)
+		}
+	}
+	return filtered
+}
+
+func randomID() (uint16, error) {
+	var id uint16
+
+	err := binary.Read(rand.Reader, binary.BigEndian, &id)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Generating query ID")
+	}
+
+	return id, nil
+}
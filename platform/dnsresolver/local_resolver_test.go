@@ -0,0 +1,362 @@
+package dnsresolver_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/dns/dnsmessage"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/dnsresolver"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type fakeUpstream struct {
+	conn    net.PacketConn
+	lock    sync.Mutex
+	queries []dnsmessage.Question
+	answer  func(query dnsmessage.Message) dnsmessage.Message
+}
+
+func newFakeUpstream(answer func(query dnsmessage.Message) dnsmessage.Message) *fakeUpstream {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	upstream := &fakeUpstream{conn: conn, answer: answer}
+
+	go func() {
+		buffer := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+
+			var query dnsmessage.Message
+			if err := query.Unpack(buffer[:n]); err != nil {
+				continue
+			}
+
+			upstream.lock.Lock()
+			upstream.queries = append(upstream.queries, query.Questions[0])
+			answer := upstream.answer
+			upstream.lock.Unlock()
+
+			response := answer(query)
+			response.ID = query.ID
+			response.Response = true
+			response.Questions = query.Questions
+
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+
+	return upstream
+}
+
+func (u *fakeUpstream) Address() string { return u.conn.LocalAddr().String() }
+
+func (u *fakeUpstream) Queries() []dnsmessage.Question {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return append([]dnsmessage.Question{}, u.queries...)
+}
+
+func (u *fakeUpstream) SetAnswer(answer func(query dnsmessage.Message) dnsmessage.Message) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.answer = answer
+}
+
+func (u *fakeUpstream) Close() { _ = u.conn.Close() }
+
+func freeAddress() string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func newQuery(name string, qtype dnsmessage.Type) dnsmessage.Message {
+	return dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 1234, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+}
+
+func exchangeUDP(address string, query dnsmessage.Message) dnsmessage.Message {
+	conn, err := net.Dial("udp", address)
+	Expect(err).ToNot(HaveOccurred())
+	defer conn.Close()
+
+	packed, err := query.Pack()
+	Expect(err).ToNot(HaveOccurred())
+
+	Expect(conn.SetDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+	_, err = conn.Write(packed)
+	Expect(err).ToNot(HaveOccurred())
+
+	buffer := make([]byte, 65535)
+	n, err := conn.Read(buffer)
+	Expect(err).ToNot(HaveOccurred())
+
+	var response dnsmessage.Message
+	Expect(response.Unpack(buffer[:n])).To(Succeed())
+	Expect(response.ID).To(Equal(query.ID))
+
+	return response
+}
+
+func exchangeTCP(address string, query dnsmessage.Message) dnsmessage.Message {
+	conn, err := net.Dial("tcp", address)
+	Expect(err).ToNot(HaveOccurred())
+	defer conn.Close()
+
+	packed, err := query.Pack()
+	Expect(err).ToNot(HaveOccurred())
+
+	Expect(conn.SetDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+	Expect(binary.Write(conn, binary.BigEndian, uint16(len(packed)))).To(Succeed())
+	_, err = conn.Write(packed)
+	Expect(err).ToNot(HaveOccurred())
+
+	var length uint16
+	Expect(binary.Read(conn, binary.BigEndian, &length)).To(Succeed())
+	buffer := make([]byte, length)
+	_, err = io.ReadFull(conn, buffer)
+	Expect(err).ToNot(HaveOccurred())
+
+	var response dnsmessage.Message
+	Expect(response.Unpack(buffer)).To(Succeed())
+
+	return response
+}
+
+func aAnswer(name string, ip string, ttl uint32) dnsmessage.Resource {
+	resource := &dnsmessage.AResource{}
+	copy(resource.A[:], net.ParseIP(ip).To4())
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: ttl},
+		Body:   resource,
+	}
+}
+
+func answeredIPs(resources []dnsmessage.Resource) []string {
+	ips := []string{}
+	for _, resource := range resources {
+		switch body := resource.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IP(body.AAAA[:]).String())
+		}
+	}
+	return ips
+}
+
+var _ = Describe("LocalResolver", func() {
+	var (
+		clock    *fakeclock.FakeClock
+		address  string
+		upstream *fakeUpstream
+		resolver Resolver
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		upstream = newFakeUpstream(func(query dnsmessage.Message) dnsmessage.Message {
+			return dnsmessage.Message{
+				Header:  dnsmessage.Header{RecursionAvailable: true},
+				Answers: []dnsmessage.Resource{aAnswer(query.Questions[0].Name.String(), "93.184.216.34", 60)},
+			}
+		})
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		Eventually(func() error {
+			address = freeAddress()
+			resolver = NewLocalResolver(address, clock, logger)
+			return resolver.Start()
+		}).Should(Succeed())
+
+		resolver.SetUpstreams([]string{upstream.Address()})
+		resolver.UpdateRecords(boshsettings.DNSRecords{
+			Version: 2,
+			Records: [][2]string{
+				{"10.0.0.5", "uuid-1.web.default.dep.bosh"},
+				{"10.0.0.6", "uuid-2.web.default.dep.bosh"},
+				{"fd00::6", "uuid-2.web.default.dep.bosh"},
+				{"10.0.0.7", "uuid-3.db.default.dep.bosh"},
+			},
+		})
+	})
+
+	AfterEach(func() {
+		Expect(resolver.Stop()).To(Succeed())
+		upstream.Close()
+	})
+
+	It("reports whether it is running", func() {
+		Expect(resolver.IsRunning()).To(BeTrue())
+		Expect(resolver.Start()).To(Succeed())
+
+		Expect(resolver.Stop()).To(Succeed())
+		Expect(resolver.IsRunning()).To(BeFalse())
+	})
+
+	Describe("synced records", func() {
+		It("answers A and AAAA queries", func() {
+			response := exchangeUDP(address, newQuery("UUID-2.web.default.dep.bosh.", dnsmessage.TypeA))
+			Expect(response.RCode).To(Equal(dnsmessage.RCodeSuccess))
+			Expect(response.Authoritative).To(BeTrue())
+			Expect(answeredIPs(response.Answers)).To(Equal([]string{"10.0.0.6"}))
+			Expect(response.Answers[0].Header.TTL).To(Equal(uint32(30)))
+
+			response = exchangeUDP(address, newQuery("uuid-2.web.default.dep.bosh.", dnsmessage.TypeAAAA))
+			Expect(answeredIPs(response.Answers)).To(Equal([]string{"fd00::6"}))
+
+			Expect(upstream.Queries()).To(BeEmpty())
+		})
+
+		It("answers with no data when synced name has no records of requested type", func() {
+			response := exchangeUDP(address, newQuery("uuid-1.web.default.dep.bosh.", dnsmessage.TypeAAAA))
+			Expect(response.RCode).To(Equal(dnsmessage.RCodeSuccess))
+			Expect(response.Answers).To(BeEmpty())
+			Expect(upstream.Queries()).To(BeEmpty())
+		})
+
+		It("answers SRV-style service lookups with hosts of the domain", func() {
+			response := exchangeUDP(address, newQuery("_http._tcp.web.default.dep.bosh.", dnsmessage.TypeSRV))
+			Expect(response.RCode).To(Equal(dnsmessage.RCodeSuccess))
+
+			targets := []string{}
+			for _, answer := range response.Answers {
+				targets = append(targets, answer.Body.(*dnsmessage.SRVResource).Target.String())
+			}
+			Expect(targets).To(Equal([]string{"uuid-1.web.default.dep.bosh.", "uuid-2.web.default.dep.bosh."}))
+			Expect(answeredIPs(response.Additionals)).To(Equal([]string{"10.0.0.5", "10.0.0.6", "fd00::6"}))
+		})
+
+		It("serves new records after update", func() {
+			resolver.UpdateRecords(boshsettings.DNSRecords{
+				Version: 3,
+				Records: [][2]string{{"10.0.0.8", "uuid-4.web.default.dep.bosh"}},
+			})
+
+			response := exchangeUDP(address, newQuery("uuid-4.web.default.dep.bosh.", dnsmessage.TypeA))
+			Expect(answeredIPs(response.Answers)).To(Equal([]string{"10.0.0.8"}))
+
+			exchangeUDP(address, newQuery("uuid-1.web.default.dep.bosh.", dnsmessage.TypeA))
+			Expect(upstream.Queries()).To(HaveLen(1))
+		})
+
+		It("truncates udp responses which do not fit and serves them over tcp", func() {
+			records := [][2]string{}
+			for i := 0; i < 100; i++ {
+				records = append(records, [2]string{fmt.Sprintf("10.0.1.%d", i), "many.default.dep.bosh"})
+			}
+			resolver.UpdateRecords(boshsettings.DNSRecords{Version: 4, Records: records})
+
+			response := exchangeUDP(address, newQuery("many.default.dep.bosh.", dnsmessage.TypeA))
+			Expect(response.Truncated).To(BeTrue())
+			Expect(response.Answers).To(BeEmpty())
+
+			response = exchangeTCP(address, newQuery("many.default.dep.bosh.", dnsmessage.TypeA))
+			Expect(response.Truncated).To(BeFalse())
+			Expect(response.Answers).To(HaveLen(100))
+		})
+	})
+
+	Describe("forwarding", func() {
+		It("forwards other names to upstream and caches responses for their TTL", func() {
+			response := exchangeUDP(address, newQuery("example.com.", dnsmessage.TypeA))
+			Expect(response.RCode).To(Equal(dnsmessage.RCodeSuccess))
+			Expect(answeredIPs(response.Answers)).To(Equal([]string{"93.184.216.34"}))
+
+			clock.Increment(40 * time.Second)
+
+			response = exchangeUDP(address, newQuery("EXAMPLE.com.", dnsmessage.TypeA))
+			Expect(answeredIPs(response.Answers)).To(Equal([]string{"93.184.216.34"}))
+			Expect(response.Answers[0].Header.TTL).To(Equal(uint32(20)))
+			Expect(upstream.Queries()).To(HaveLen(1))
+
+			clock.Increment(20 * time.Second)
+
+			exchangeUDP(address, newQuery("example.com.", dnsmessage.TypeA))
+			Expect(upstream.Queries()).To(HaveLen(2))
+		})
+
+		It("caches negative responses using SOA minimum TTL", func() {
+			upstream.SetAnswer(func(query dnsmessage.Message) dnsmessage.Message {
+				return dnsmessage.Message{
+					Header: dnsmessage.Header{RCode: dnsmessage.RCodeNameError},
+					Authorities: []dnsmessage.Resource{{
+						Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("com."), Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET, TTL: 900},
+						Body: &dnsmessage.SOAResource{
+							NS:     dnsmessage.MustNewName("ns.com."),
+							MBox:   dnsmessage.MustNewName("admin.com."),
+							MinTTL: 10,
+						},
+					}},
+				}
+			})
+
+			response := exchangeUDP(address, newQuery("missing.com.", dnsmessage.TypeA))
+			Expect(response.RCode).To(Equal(dnsmessage.RCodeNameError))
+
+			response = exchangeUDP(address, newQuery("missing.com.", dnsmessage.TypeA))
+			Expect(response.RCode).To(Equal(dnsmessage.RCodeNameError))
+			Expect(upstream.Queries()).To(HaveLen(1))
+
+			clock.Increment(10 * time.Second)
+
+			exchangeUDP(address, newQuery("missing.com.", dnsmessage.TypeA))
+			Expect(upstream.Queries()).To(HaveLen(2))
+		})
+
+		It("does not cache server failures", func() {
+			upstream.SetAnswer(func(query dnsmessage.Message) dnsmessage.Message {
+				return dnsmessage.Message{Header: dnsmessage.Header{RCode: dnsmessage.RCodeServerFailure}}
+			})
+
+			exchangeUDP(address, newQuery("example.com.", dnsmessage.TypeA))
+			exchangeUDP(address, newQuery("example.com.", dnsmessage.TypeA))
+			Expect(upstream.Queries()).To(HaveLen(2))
+		})
+
+		It("uses next upstream when one is not reachable", func() {
+			unreachable := newFakeUpstream(nil)
+			unreachableAddress := unreachable.Address()
+			unreachable.Close()
+
+			resolver.SetUpstreams([]string{unreachableAddress, upstream.Address()})
+
+			response := exchangeUDP(address, newQuery("example.com.", dnsmessage.TypeA))
+			Expect(answeredIPs(response.Answers)).To(Equal([]string{"93.184.216.34"}))
+
+			response = exchangeUDP(address, newQuery("example.org.", dnsmessage.TypeA))
+			Expect(answeredIPs(response.Answers)).To(Equal([]string{"93.184.216.34"}))
+			Expect(upstream.Queries()).To(HaveLen(2))
+		})
+
+		It("responds with server failure when there are no upstreams", func() {
+			resolver.SetUpstreams(nil)
+
+			response := exchangeUDP(address, newQuery("example.com.", dnsmessage.TypeA))
+			Expect(response.RCode).To(Equal(dnsmessage.RCodeServerFailure))
+		})
+	})
+})
@@ -0,0 +1,73 @@
+package dnsresolver
+
+import (
+	"net"
+	"strings"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+// recordSet indexes synced DNS records by name; it is never modified
+// once built so that it can be shared between concurrent queries
+type recordSet struct {
+	version   uint64
+	addresses map[string][]net.IP
+
+	// hostsByDomain maps parent domain to hosts directly under it;
+	// it is used to answer SRV-style service lookups
+	hostsByDomain map[string][]string
+}
+
+func newRecordSet(records boshsettings.DNSRecords) *recordSet {
+	set := &recordSet{
+		version:       records.Version,
+		addresses:     map[string][]net.IP{},
+		hostsByDomain: map[string][]string{},
+	}
+
+	for _, record := range records.Records {
+		ip := net.ParseIP(record[0])
+		if ip == nil {
+			continue
+		}
+
+		name := canonicalName(record[1])
+
+		if _, found := set.addresses[name]; !found {
+			labels := strings.SplitN(name, ".", 2)
+			if len(labels) == 2 && labels[1] != "" {
+				set.hostsByDomain[labels[1]] = append(set.hostsByDomain[labels[1]], name)
+			}
+		}
+
+		set.addresses[name] = append(set.addresses[name], ip)
+	}
+
+	return set
+}
+
+// lookup returns addresses of a synced name and whether name is known
+func (s *recordSet) lookup(name string) ([]net.IP, bool) {
+	ips, found := s.addresses[canonicalName(name)]
+	return ips, found
+}
+
+// lookupService returns hosts for names in the form of _service._proto.domain
+func (s *recordSet) lookupService(name string) ([]string, bool) {
+	labels := strings.SplitN(canonicalName(name), ".", 3)
+	if len(labels) != 3 || !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") {
+		return nil, false
+	}
+
+	hosts, found := s.hostsByDomain[labels[2]]
+	return hosts, found
+}
+
+// canonicalName lowercases name and makes it fully qualified
+func canonicalName(name string) string {
+	name = strings.ToLower(name)
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	return name
+}
@@ -0,0 +1,23 @@
+package dnsresolver
+
+import (
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Resolver
+
+// Resolver is a DNS responder running inside the agent which answers
+// queries for records synced by the director and forwards the rest
+// to upstream DNS servers
+type Resolver interface {
+	Start() error
+	Stop() error
+	IsRunning() bool
+
+	// UpdateRecords atomically replaces records served by the resolver
+	UpdateRecords(records boshsettings.DNSRecords)
+
+	// SetUpstreams replaces DNS servers used for names that are not synced records;
+	// servers may include port, otherwise port 53 is used
+	SetUpstreams(servers []string)
+}
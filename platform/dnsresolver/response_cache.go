@@ -0,0 +1,200 @@
+package dnsresolver
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// Used for negative answers which do not carry SOA record
+	defaultNegativeTTL = 30 * time.Second
+	maxNegativeTTL     = 5 * time.Minute
+	maxPositiveTTL     = 24 * time.Hour
+
+	maxCacheEntries = 10000
+)
+
+type cacheKey struct {
+	name  string
+	qtype dnsmessage.Type
+	class dnsmessage.Class
+}
+
+type cacheEntry struct {
+	response  dnsmessage.Message
+	storedAt  time.Time
+	expiresAt time.Time
+}
+
+// responseCache keeps upstream responses, including negative ones (NXDOMAIN and NODATA),
+// for as long as their TTL allows
+type responseCache struct {
+	clock   clock.Clock
+	lock    sync.Mutex
+	entries map[cacheKey]cacheEntry
+}
+
+func newResponseCache(clock clock.Clock) *responseCache {
+	return &responseCache{
+		clock:   clock,
+		entries: map[cacheKey]cacheEntry{},
+	}
+}
+
+func newCacheKey(question dnsmessage.Question) cacheKey {
+	return cacheKey{
+		name:  strings.ToLower(question.Name.String()),
+		qtype: question.Type,
+		class: question.Class,
+	}
+}
+
+// Get returns cached response with TTLs decreased by the time spent in cache
+func (c *responseCache) Get(question dnsmessage.Question) (dnsmessage.Message, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := newCacheKey(question)
+	now := c.clock.Now()
+
+	entry, found := c.entries[key]
+	if !found {
+		return dnsmessage.Message{}, false
+	}
+
+	if !now.Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return dnsmessage.Message{}, false
+	}
+
+	elapsed := uint32(now.Sub(entry.storedAt) / time.Second)
+
+	response := entry.response
+	response.Answers = agedResources(response.Answers, elapsed)
+	response.Authorities = agedResources(response.Authorities, elapsed)
+	response.Additionals = agedResources(response.Additionals, elapsed)
+
+	return response, true
+}
+
+// Put stores response if it is cacheable
+func (c *responseCache) Put(question dnsmessage.Question, response dnsmessage.Message) {
+	ttl, cacheable := responseTTL(response)
+	if !cacheable {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.clock.Now()
+
+	if len(c.entries) >= maxCacheEntries {
+		c.evict(now)
+	}
+
+	c.entries[newCacheKey(question)] = cacheEntry{
+		response:  response,
+		storedAt:  now,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+func (c *responseCache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries = map[cacheKey]cacheEntry{}
+}
+
+// evict removes expired entries and, if cache is still full, arbitrary ones
+func (c *responseCache) evict(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+
+	for key := range c.entries {
+		if len(c.entries) < maxCacheEntries {
+			break
+		}
+		delete(c.entries, key)
+	}
+}
+
+func responseTTL(response dnsmessage.Message) (time.Duration, bool) {
+	if response.Truncated {
+		return 0, false
+	}
+
+	switch response.RCode {
+	case dnsmessage.RCodeSuccess:
+		if len(response.Answers) == 0 {
+			return negativeTTL(response), true
+		}
+	case dnsmessage.RCodeNameError:
+		return negativeTTL(response), true
+	default:
+		return 0, false
+	}
+
+	ttl := maxPositiveTTL
+	for _, answer := range response.Answers {
+		answerTTL := time.Duration(answer.Header.TTL) * time.Second
+		if answerTTL < ttl {
+			ttl = answerTTL
+		}
+	}
+
+	return ttl, ttl > 0
+}
+
+// negativeTTL follows RFC 2308: minimum of SOA record TTL and its MINIMUM field
+func negativeTTL(response dnsmessage.Message) time.Duration {
+	for _, authority := range response.Authorities {
+		soa, ok := authority.Body.(*dnsmessage.SOAResource)
+		if !ok {
+			continue
+		}
+
+		ttl := authority.Header.TTL
+		if soa.MinTTL < ttl {
+			ttl = soa.MinTTL
+		}
+
+		duration := time.Duration(ttl) * time.Second
+		if duration > maxNegativeTTL {
+			return maxNegativeTTL
+		}
+		return duration
+	}
+
+	return defaultNegativeTTL
+}
+
+func agedResources(resources []dnsmessage.Resource, elapsed uint32) []dnsmessage.Resource {
+	if len(resources) == 0 {
+		return resources
+	}
+
+	aged := make([]dnsmessage.Resource, len(resources))
+	for i, resource := range resources {
+		aged[i] = resource
+		// OPT pseudo records use TTL field for extended flags
+		if resource.Header.Type == dnsmessage.TypeOPT {
+			continue
+		}
+		if resource.Header.TTL > elapsed {
+			aged[i].Header.TTL -= elapsed
+		} else {
+			aged[i].Header.TTL = 0
+		}
+	}
+
+	return aged
+}
@@ -9,6 +9,9 @@ import (
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlogrotate "github.com/cloudfoundry/bosh-agent/platform/logrotate"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -68,7 +71,7 @@ func NewDummyPlatform(
 		copier:             boshcmd.NewGenericCpCopier(fs, logger),
 		dirProvider:        dirProvider,
 		devicePathResolver: devicePathResolver,
-		vitalsService:      boshvitals.NewService(collector, dirProvider, nil),
+		vitalsService:      boshvitals.NewService(collector, dirProvider, nil, nil),
 		certManager:        boshcert.NewDummyCertManager(fs, cmdRunner, 0, logger),
 		logger:             logger,
 		auditLogger:        auditLogger,
@@ -187,11 +190,19 @@ func (p dummyPlatform) GetConfiguredNetworkInterfaces() (interfaces []string, er
 	return
 }
 
+func (p dummyPlatform) GetPersistentDiskSnapshotter() boshdisk.Snapshotter {
+	return boshdisk.NewUnsupportedSnapshotter()
+}
+
+func (p dummyPlatform) GetTimeSyncMonitor() boshntp.Monitor {
+	return boshntp.NewUnsupportedMonitor()
+}
+
 func (p dummyPlatform) GetCertManager() (certManager boshcert.Manager) {
 	return p.certManager
 }
 
-func (p dummyPlatform) SetupLogrotate(groupName, basePath, size string) (err error) {
+func (p dummyPlatform) SetupLogrotate(groupName, basePath, size string, jobPolicies map[string]boshlogrotate.Policy) (err error) {
 	return
 }
 
@@ -199,7 +210,7 @@ func (p dummyPlatform) SetTimeWithNtpServers(servers []string) (err error) {
 	return
 }
 
-func (p dummyPlatform) SetupEphemeralDiskWithPath(devicePath string, desiredSwapSizeInBytes *uint64, labelPrefix string) (err error) {
+func (p dummyPlatform) SetupEphemeralDiskWithPath(devicePath string, desiredSwapSizeInBytes *uint64, labelPrefix string, encryption boshsettings.DiskEncryption) (err error) {
 	return
 }
 
@@ -323,6 +334,10 @@ func (p dummyPlatform) MountPersistentDisk(diskSettings boshsettings.DiskSetting
 	return p.fs.WriteFile(p.mountsPath(), mountsJSON)
 }
 
+func (p dummyPlatform) ResizePersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) (DiskResize, error) {
+	return DiskResize{}, nil
+}
+
 func (p dummyPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error) {
 	mounts, err := p.existingMounts()
 	if err != nil {
@@ -361,7 +376,7 @@ func (p dummyPlatform) GetFilesContentsFromDisk(diskPath string, fileNames []str
 	return
 }
 
-func (p dummyPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error) {
+func (p dummyPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string, progress boshdisk.MigrationProgressFunc) (err error) {
 	diskMigrationsPath := filepath.Join(p.dirProvider.BoshDir(), "disk_migrations.json")
 	var diskMigrations []diskMigration
 	if p.fs.FileExists(diskMigrationsPath) {
@@ -2,11 +2,14 @@ package platform
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -15,7 +18,10 @@ import (
 	"github.com/cloudfoundry/bosh-agent/platform/cdrom"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshdnsresolver "github.com/cloudfoundry/bosh-agent/platform/dnsresolver"
+	boshlogrotate "github.com/cloudfoundry/bosh-agent/platform/logrotate"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -76,6 +82,23 @@ type LinuxOptions struct {
 	// Strategy for resolving ephemeral & persistent disk partitioners;
 	// possible values: parted, "" (default is sfdisk if disk < 2TB, parted otherwise)
 	PartitionerType string
+
+	// Strategy for configuring networking on ubuntu;
+	// possible values: netplan, "" (default is systemd-networkd)
+	NetworkManagerType string
+
+	// When set to true agent serves DNS records synced by the director
+	// on 127.0.0.1 and forwards other queries to DNS servers of the networks
+	// instead of writing records to /etc/hosts
+	UseLocalDNSResolver bool
+
+	// Daemon keeping system clock in sync with NTP servers;
+	// possible values: chrony, timesyncd, "" (default writes servers for sync-time script)
+	TimeSyncManagerType string
+
+	// Alert is raised when clock offset exceeds this many milliseconds;
+	// default is 500ms. Offset is only known with chrony and timesyncd.
+	ClockDriftThresholdMs int
 }
 
 type linux struct {
@@ -89,6 +112,9 @@ type linux struct {
 	cdutil                 cdrom.CDUtil
 	diskManager            boshdisk.Manager
 	netManager             boshnet.Manager
+	dnsResolver            boshdnsresolver.Resolver
+	timeSyncManager        boshntp.Manager
+	timeSyncMonitor        boshntp.Monitor
 	certManager            boshcert.Manager
 	monitRetryStrategy     boshretry.RetryStrategy
 	devicePathResolver     boshdpresolv.DevicePathResolver
@@ -111,6 +137,9 @@ func NewLinuxPlatform(
 	cdutil cdrom.CDUtil,
 	diskManager boshdisk.Manager,
 	netManager boshnet.Manager,
+	dnsResolver boshdnsresolver.Resolver,
+	timeSyncManager boshntp.Manager,
+	timeSyncMonitor boshntp.Monitor,
 	certManager boshcert.Manager,
 	monitRetryStrategy boshretry.RetryStrategy,
 	devicePathResolver boshdpresolv.DevicePathResolver,
@@ -132,6 +161,9 @@ func NewLinuxPlatform(
 		cdutil:                 cdutil,
 		diskManager:            diskManager,
 		netManager:             netManager,
+		dnsResolver:            dnsResolver,
+		timeSyncManager:        timeSyncManager,
+		timeSyncMonitor:        timeSyncMonitor,
 		certManager:            certManager,
 		monitRetryStrategy:     monitRetryStrategy,
 		devicePathResolver:     devicePathResolver,
@@ -146,6 +178,12 @@ func NewLinuxPlatform(
 
 const logTag = "linuxPlatform"
 
+const (
+	persistentDiskMapperPrefix = "bosh-persistent-"
+	ephemeralDataMapperName    = "bosh-ephemeral-data"
+	ephemeralSwapMapperName    = "bosh-ephemeral-swap"
+)
+
 func (p linux) AssociateDisk(name string, settings boshsettings.DiskSettings) error {
 	disksDir := p.dirProvider.DisksDir()
 	err := p.fs.MkdirAll(disksDir, disksDirPermissions)
@@ -204,14 +242,73 @@ func (p linux) GetDevicePathResolver() (devicePathResolver boshdpresolv.DevicePa
 	return p.devicePathResolver
 }
 
+func (p linux) GetPersistentDiskSnapshotter() boshdisk.Snapshotter {
+	return p.diskManager.GetSnapshotter()
+}
+
+func (p linux) GetTimeSyncMonitor() boshntp.Monitor {
+	return p.timeSyncMonitor
+}
+
 func (p linux) GetAuditLogger() AuditLogger {
 	return p.auditLogger
 }
 
 func (p linux) SetupNetworking(networks boshsettings.Networks, mbus string) (err error) {
+	if p.options.UseLocalDNSResolver {
+		networks, err = p.setupLocalDNSResolver(networks)
+		if err != nil {
+			return err
+		}
+	}
+
 	return p.netManager.SetupNetworking(networks, mbus, nil)
 }
 
+// setupLocalDNSResolver starts resolver forwarding to DNS servers of the networks
+// and returns networks which use the resolver as their only DNS server
+func (p linux) setupLocalDNSResolver(networks boshsettings.Networks) (boshsettings.Networks, error) {
+	dnsNetwork, _ := networks.DefaultNetworkFor("dns")
+	if len(dnsNetwork.DNS) == 0 {
+		p.logger.Warn(logTag, "Not starting local DNS resolver since networks do not specify DNS servers")
+		return networks, nil
+	}
+
+	p.dnsResolver.SetUpstreams(dnsNetwork.DNS)
+
+	// Records synced before agent restart are served until next sync_dns
+	recordsPath := filepath.Join(p.dirProvider.InstanceDNSDir(), "records.json")
+	if p.fs.FileExists(recordsPath) {
+		contents, err := p.fs.ReadFile(recordsPath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading DNS records from '%s'", recordsPath)
+		}
+
+		var dnsRecords boshsettings.DNSRecords
+		err = json.Unmarshal(contents, &dnsRecords)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Unmarshalling DNS records from '%s'", recordsPath)
+		}
+
+		p.dnsResolver.UpdateRecords(dnsRecords)
+	}
+
+	err := p.dnsResolver.Start()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Starting local DNS resolver")
+	}
+
+	resolvedNetworks := boshsettings.Networks{}
+	for name, network := range networks {
+		if len(network.DNS) > 0 {
+			network.DNS = []string{boshdnsresolver.ListenIP}
+		}
+		resolvedNetworks[name] = network
+	}
+
+	return resolvedNetworks, nil
+}
+
 func (p linux) GetConfiguredNetworkInterfaces() ([]string, error) {
 	return p.netManager.GetConfiguredNetworkInterfaces()
 }
@@ -483,6 +580,13 @@ ff02::3 ip6-allhosts
 `
 
 func (p linux) SaveDNSRecords(dnsRecords boshsettings.DNSRecords, hostname string) error {
+	if p.dnsResolver.IsRunning() {
+		p.dnsResolver.UpdateRecords(dnsRecords)
+
+		// Records are served by local DNS resolver hence /etc/hosts only keeps defaults
+		dnsRecords = boshsettings.DNSRecords{Version: dnsRecords.Version}
+	}
+
 	dnsRecordsContents, err := p.generateDefaultEtcHosts(hostname)
 	if err != nil {
 		return bosherr.WrapError(err, "Generating default /etc/hosts")
@@ -548,16 +652,66 @@ func (p linux) SetupHostname(hostname string) error {
 	return nil
 }
 
-func (p linux) SetupLogrotate(groupName, basePath, size string) (err error) {
+func (p linux) SetupLogrotate(groupName, basePath, size string, jobPolicies map[string]boshlogrotate.Policy) (err error) {
 	buffer := bytes.NewBuffer([]byte{})
-	t := template.Must(template.New("logrotate-d-config").Parse(etcLogrotateDTemplate))
+	t := template.Must(template.New("logrotate-d-config").Funcs(template.FuncMap{"join": strings.Join}).Parse(etcLogrotateDTemplate))
+
+	type jobLogrotateArgs struct {
+		Paths        []string
+		Size         string
+		Rotate       int
+		MaxAgeDays   int
+		Compress     bool
+		CopyTruncate bool
+	}
 
 	type logrotateArgs struct {
-		BasePath string
-		Size     string
+		Jobs         []jobLogrotateArgs
+		DefaultPaths []string
+		Size         string
 	}
 
-	err = t.Execute(buffer, logrotateArgs{basePath, size})
+	logDir := path.Join(basePath, "data/sys/log")
+	args := logrotateArgs{
+		DefaultPaths: logrotatePaths(logDir, 3),
+		Size:         size,
+	}
+
+	if len(jobPolicies) > 0 {
+		// A log matched by multiple rules is an error for logrotate hence
+		// default rule only covers top level logs and directories which
+		// do not belong to jobs once jobs have own rules
+		args.DefaultPaths, err = p.defaultLogrotatePaths(logDir, jobPolicies)
+		if err != nil {
+			return
+		}
+
+		jobNames := make([]string, 0, len(jobPolicies))
+		for jobName := range jobPolicies {
+			jobNames = append(jobNames, jobName)
+		}
+		sort.Strings(jobNames)
+
+		for _, jobName := range jobNames {
+			policy := jobPolicies[jobName]
+
+			jobSize := policy.Size
+			if jobSize == "" {
+				jobSize = size
+			}
+
+			args.Jobs = append(args.Jobs, jobLogrotateArgs{
+				Paths:        logrotatePaths(path.Join(logDir, jobName), 2),
+				Size:         jobSize,
+				Rotate:       policy.RotateCount(),
+				MaxAgeDays:   policy.MaxAgeDays,
+				Compress:     policy.IsCompressed(),
+				CopyTruncate: policy.IsCopyTruncated(),
+			})
+		}
+	}
+
+	err = t.Execute(buffer, args)
 	if err != nil {
 		err = bosherr.WrapError(err, "Generating logrotate config")
 		return
@@ -574,11 +728,70 @@ func (p linux) SetupLogrotate(groupName, basePath, size string) (err error) {
 	return
 }
 
+// defaultLogrotatePaths lists directories present in logDir since logrotate
+// globs cannot exclude job directories. Directories created later are
+// covered once logrotate is set up again on next apply.
+func (p linux) defaultLogrotatePaths(logDir string, jobPolicies map[string]boshlogrotate.Policy) ([]string, error) {
+	paths := logrotatePaths(logDir, 1)
+
+	dirPaths, err := p.fs.Glob(path.Join(logDir, "*"))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing log directories in %s", logDir)
+	}
+	sort.Strings(dirPaths)
+
+	for _, dirPath := range dirPaths {
+		if _, isJobDir := jobPolicies[path.Base(dirPath)]; isJobDir {
+			continue
+		}
+
+		info, err := p.fs.Stat(dirPath)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+
+		paths = append(paths, logrotatePaths(dirPath, 2)...)
+	}
+
+	return paths, nil
+}
+
+// logrotatePaths returns globs for logs in dir and its subdirectories, depth levels deep
+func logrotatePaths(dir string, depth int) []string {
+	paths := []string{}
+
+	for i := 0; i < depth; i++ {
+		paths = append(paths, path.Join(dir, "*.log"), path.Join(dir, ".*.log"))
+		dir = path.Join(dir, "*")
+	}
+
+	return paths
+}
+
 // Logrotate config file - /etc/logrotate.d/<group-name>
 // Stemcell stage logrotate_config configures logrotate to run every hour
 const etcLogrotateDTemplate = `# Generated by bosh-agent
-
-{{ .BasePath }}/data/sys/log/*.log {{ .BasePath }}/data/sys/log/.*.log {{ .BasePath }}/data/sys/log/*/*.log {{ .BasePath }}/data/sys/log/*/.*.log {{ .BasePath }}/data/sys/log/*/*/*.log {{ .BasePath }}/data/sys/log/*/*/.*.log {
+{{ range .Jobs }}
+{{ join .Paths " " }} {
+  missingok
+  rotate {{ .Rotate }}
+{{- if .MaxAgeDays }}
+  maxage {{ .MaxAgeDays }}
+{{- end }}
+{{- if .Compress }}
+  compress
+{{- else }}
+  nocompress
+{{- end }}
+{{- if .CopyTruncate }}
+  copytruncate
+{{- else }}
+  nocopytruncate
+{{- end }}
+  size={{ .Size }}
+}
+{{ end }}
+{{ join .DefaultPaths " " }} {
   missingok
   rotate 7
   compress
@@ -588,23 +801,15 @@ const etcLogrotateDTemplate = `# Generated by bosh-agent
 `
 
 func (p linux) SetTimeWithNtpServers(servers []string) (err error) {
-	serversFilePath := path.Join(p.dirProvider.BaseDir(), "/bosh/etc/ntpserver")
-	if len(servers) == 0 {
-		return
-	}
-
-	err = p.fs.WriteFileString(serversFilePath, strings.Join(servers, " "))
+	err = p.timeSyncManager.Configure(servers)
 	if err != nil {
-		err = bosherr.WrapErrorf(err, "Writing to %s", serversFilePath)
-		return
+		return bosherr.WrapError(err, "Configuring time synchronization")
 	}
 
-	// Make a best effort to sync time now but don't error
-	_, _, _, _ = p.cmdRunner.RunCommand("sync-time")
-	return
+	return nil
 }
 
-func (p linux) SetupEphemeralDiskWithPath(realPath string, desiredSwapSizeInBytes *uint64, labelPrefix string) error {
+func (p linux) SetupEphemeralDiskWithPath(realPath string, desiredSwapSizeInBytes *uint64, labelPrefix string, encryption boshsettings.DiskEncryption) error {
 	p.logger.Info(logTag, "Setting up ephemeral disk...")
 	mountPoint := p.dirProvider.DataDir()
 
@@ -661,6 +866,11 @@ func (p linux) SetupEphemeralDiskWithPath(realPath string, desiredSwapSizeInByte
 			return err
 		}
 
+		canonicalSwapPartitionPath, err = p.unlockPartition(canonicalSwapPartitionPath, ephemeralSwapMapperName, encryption, true)
+		if err != nil {
+			return bosherr.WrapError(err, "Unlocking swap partition")
+		}
+
 		p.logger.Info(logTag, "Formatting `%s' (canonical path: %s) as swap", swapPartitionPath, canonicalSwapPartitionPath)
 		err = p.diskManager.GetFormatter().Format(canonicalSwapPartitionPath, boshdisk.FileSystemSwap)
 		if err != nil {
@@ -679,6 +889,11 @@ func (p linux) SetupEphemeralDiskWithPath(realPath string, desiredSwapSizeInByte
 		return err
 	}
 
+	canonicalDataPartitionPath, err = p.unlockPartition(canonicalDataPartitionPath, ephemeralDataMapperName, encryption, true)
+	if err != nil {
+		return bosherr.WrapError(err, "Unlocking data partition")
+	}
+
 	p.logger.Info(logTag, "Formatting `%s' (canonical path: %s) as ext4", dataPartitionPath, canonicalDataPartitionPath)
 	err = p.diskManager.GetFormatter().Format(canonicalDataPartitionPath, boshdisk.FileSystemExt4)
 	if err != nil {
@@ -1209,6 +1424,7 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 	}
 
 	firstPartitionPath := p.partitionPath(devicePath, 1)
+	mapperName := persistentDiskMapperName(diskSetting.ID)
 
 	partitioner, err := p.diskManager.GetPersistentDevicePartitioner(diskSetting.Partitioner)
 	if err != nil {
@@ -1227,17 +1443,29 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 			return bosherr.WrapError(err, "Resizing disk partition")
 		}
 
-		err := p.diskManager.GetMounter().Mount(firstPartitionPath, mountPoint, diskSetting.MountOptions...)
+		partitionPathToGrow, err := p.unlockPartition(firstPartitionPath, mapperName, diskSetting.Encryption, false)
+		if err != nil {
+			return bosherr.WrapError(err, "Unlocking persistent disk partition")
+		}
+
+		if partitionPathToGrow != firstPartitionPath {
+			err = p.resizeEncryptedPartition(mapperName, diskSetting.Encryption)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = p.diskManager.GetMounter().Mount(partitionPathToGrow, mountPoint, diskSetting.MountOptions...)
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to mount partition for filesystem growing")
 		}
 
-		err = p.diskManager.GetFormatter().GrowFilesystem(firstPartitionPath)
+		err = p.diskManager.GetFormatter().GrowFilesystem(partitionPathToGrow)
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to grow filesystem")
 		}
 
-		_, err = p.diskManager.GetMounter().Unmount(firstPartitionPath)
+		_, err = p.diskManager.GetMounter().Unmount(partitionPathToGrow)
 		if err != nil {
 			return bosherr.WrapError(err, "Failed to unmount partition after filesystem growing")
 		}
@@ -1252,7 +1480,7 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 
 		persistentDiskFS := diskSetting.FileSystemType
 		switch persistentDiskFS {
-		case boshdisk.FileSystemExt4, boshdisk.FileSystemXFS:
+		case boshdisk.FileSystemExt4, boshdisk.FileSystemXFS, boshdisk.FileSystemBtrfs:
 		case boshdisk.FileSystemDefault:
 			persistentDiskFS = boshdisk.FileSystemExt4
 		case boshdisk.FileSystemSwap:
@@ -1261,7 +1489,12 @@ func (p linux) AdjustPersistentDiskPartitioning(diskSetting boshsettings.DiskSet
 			return bosherr.Error(fmt.Sprintf(`The filesystem type "%s" is not supported`, diskSetting.FileSystemType))
 		}
 
-		err = p.diskManager.GetFormatter().Format(firstPartitionPath, persistentDiskFS)
+		partitionPathToFormat, err := p.unlockPartition(firstPartitionPath, mapperName, diskSetting.Encryption, true)
+		if err != nil {
+			return bosherr.WrapError(err, "Unlocking persistent disk partition")
+		}
+
+		err = p.diskManager.GetFormatter().Format(partitionPathToFormat, persistentDiskFS)
 		if err != nil {
 			return bosherr.WrapError(err, fmt.Sprintf("Formatting partition with %s", diskSetting.FileSystemType))
 		}
@@ -1284,8 +1517,19 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 	p.logger.Info(logTag, "devicePath = %s, alreadyMountedPartPath = %s, hasMountedDevice = %t", devicePath, alreadyMountedPartPath, hasMountedDevice)
 
 	firstPartitionPath := p.partitionPath(devicePath, 1)
+
+	partitionPathToMount := firstPartitionPath
+	if p.options.UsePreformattedPersistentDisk {
+		partitionPathToMount = devicePath
+	}
+
+	partitionPathToMount, err = p.unlockPartition(partitionPathToMount, persistentDiskMapperName(diskSetting.ID), diskSetting.Encryption, false)
+	if err != nil {
+		return bosherr.WrapError(err, "Unlocking persistent disk partition")
+	}
+
 	if hasMountedDevice {
-		if alreadyMountedPartPath == firstPartitionPath {
+		if alreadyMountedPartPath == firstPartitionPath || alreadyMountedPartPath == partitionPathToMount {
 			p.logger.Info(logTag, "device: %s is already mounted on %s, skipping mounting", alreadyMountedPartPath, mountPoint)
 			return nil
 		}
@@ -1298,13 +1542,6 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 		return bosherr.WrapErrorf(err, "Creating directory %s", mountPoint)
 	}
 
-	var partitionPathToMount string
-	if p.options.UsePreformattedPersistentDisk {
-		partitionPathToMount = devicePath
-	} else {
-		partitionPathToMount = firstPartitionPath
-	}
-
 	err = p.diskManager.GetMounter().Mount(partitionPathToMount, mountPoint, diskSetting.MountOptions...)
 	if err != nil {
 		return bosherr.WrapError(err, "Mounting partition")
@@ -1320,6 +1557,158 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 	return nil
 }
 
+// ResizePersistentDisk grows partition and filesystem of a mounted persistent
+// disk after its volume was resized in place. ext4 and xfs are grown online.
+// Disk mounted at migration dir next to the disk at mountPoint is grown there.
+func (p linux) ResizePersistentDisk(diskSetting boshsettings.DiskSettings, mountPoint string) (DiskResize, error) {
+	p.logger.Debug(logTag, "Resizing persistent disk %+v mounted at %s", diskSetting, mountPoint)
+
+	var resize DiskResize
+
+	devicePath, _, err := p.devicePathResolver.GetRealDevicePath(diskSetting)
+	if err != nil {
+		return resize, bosherr.WrapError(err, "Getting real device path")
+	}
+
+	mountPoint, err = p.persistentDiskMountPoint(diskSetting, devicePath, mountPoint)
+	if err != nil {
+		return resize, err
+	}
+
+	partitioner, err := p.diskManager.GetPersistentDevicePartitioner(diskSetting.Partitioner)
+	if err != nil {
+		return resize, bosherr.WrapError(err, "Selecting partitioner")
+	}
+
+	resize.DeviceSizeBefore, err = partitioner.GetDeviceSizeInBytes(devicePath)
+	if err != nil {
+		return resize, bosherr.WrapError(err, "Getting device size")
+	}
+
+	resize.FilesystemSizeBefore, err = p.filesystemSize(mountPoint)
+	if err != nil {
+		return resize, err
+	}
+
+	err = p.rescanBlockDevice(devicePath)
+	if err != nil {
+		return resize, bosherr.WrapError(err, "Rescanning block device")
+	}
+
+	resize.DeviceSizeAfter, err = partitioner.GetDeviceSizeInBytes(devicePath)
+	if err != nil {
+		return resize, bosherr.WrapError(err, "Getting device size")
+	}
+
+	if resize.DeviceSizeAfter == resize.DeviceSizeBefore && filesystemFillsDevice(resize.FilesystemSizeBefore, resize.DeviceSizeAfter) {
+		p.logger.Debug(logTag, "Filesystem of persistent disk %s already spans the device, not resizing", diskSetting.ID)
+		resize.FilesystemSizeAfter = resize.FilesystemSizeBefore
+		return resize, nil
+	}
+
+	partitionPathToGrow := devicePath
+
+	if !p.options.UsePreformattedPersistentDisk {
+		partitionPathToGrow = p.partitionPath(devicePath, 1)
+
+		singlePartNeedsResize, err := partitioner.SinglePartitionNeedsResize(devicePath, boshdisk.PartitionTypeLinux)
+		if err != nil {
+			return resize, bosherr.WrapError(err, "Failed to determine whether partitions need rezising")
+		}
+
+		if singlePartNeedsResize {
+			err = partitioner.ResizeSinglePartition(devicePath)
+			if err != nil {
+				return resize, bosherr.WrapError(err, "Resizing disk partition")
+			}
+		}
+	}
+
+	mapperName := persistentDiskMapperName(diskSetting.ID)
+	if p.fs.FileExists(boshdisk.MappedDevicePath(mapperName)) {
+		err = p.resizeEncryptedPartition(mapperName, diskSetting.Encryption)
+		if err != nil {
+			return resize, err
+		}
+
+		partitionPathToGrow = boshdisk.MappedDevicePath(mapperName)
+	}
+
+	err = p.diskManager.GetFormatter().GrowFilesystem(partitionPathToGrow)
+	if err != nil {
+		return resize, bosherr.WrapError(err, "Failed to grow filesystem")
+	}
+
+	resize.FilesystemSizeAfter, err = p.filesystemSize(mountPoint)
+	if err != nil {
+		return resize, err
+	}
+
+	p.logger.Info(logTag, "Resized persistent disk %s from %d to %d bytes", diskSetting.ID, resize.FilesystemSizeBefore, resize.FilesystemSizeAfter)
+
+	return resize, nil
+}
+
+// persistentDiskMountPoint returns where partition of persistent disk is mounted
+// since a new disk is mounted at migration dir until data is migrated onto it
+func (p linux) persistentDiskMountPoint(diskSetting boshsettings.DiskSettings, devicePath, mountPoint string) (string, error) {
+	partitionPath := devicePath
+	if !p.options.UsePreformattedPersistentDisk {
+		partitionPath = p.partitionPath(devicePath, 1)
+	}
+
+	mappedPath := boshdisk.MappedDevicePath(persistentDiskMapperName(diskSetting.ID))
+	if p.fs.FileExists(mappedPath) {
+		partitionPath = mappedPath
+	}
+
+	for _, candidate := range []string{mountPoint, p.dirProvider.StoreMigrationDir()} {
+		mountedPartitionPath, isMountPoint, err := p.IsMountPoint(candidate)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Checking whether %s is a mount point", candidate)
+		}
+
+		if isMountPoint && mountedPartitionPath == partitionPath {
+			return candidate, nil
+		}
+	}
+
+	return "", bosherr.Errorf("Persistent disk partition %s is not mounted at %s or %s", partitionPath, mountPoint, p.dirProvider.StoreMigrationDir())
+}
+
+// filesystemFillsDevice allows for partition table and filesystem metadata
+// which are not part of filesystem size reported by df
+func filesystemFillsDevice(filesystemSize, deviceSize uint64) bool {
+	const filesystemOverheadPercent = 5
+	return filesystemSize*100 >= deviceSize*(100-filesystemOverheadPercent)
+}
+
+// rescanBlockDevice makes the kernel pick up size changes of SCSI disks;
+// NVMe and virtio disks are rescanned automatically
+func (p linux) rescanBlockDevice(devicePath string) error {
+	canonicalDevicePath, err := resolveCanonicalLink(p.cmdRunner, devicePath)
+	if err != nil {
+		return err
+	}
+
+	rescanPath := path.Join("/sys/class/block", path.Base(canonicalDevicePath), "device", "rescan")
+	if !p.fs.FileExists(rescanPath) {
+		return nil
+	}
+
+	return p.fs.WriteFileString(rescanPath, "1")
+}
+
+func (p linux) filesystemSize(mountPoint string) (uint64, error) {
+	stats, err := p.collector.GetDiskStats(mountPoint)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Getting filesystem size of %s", mountPoint)
+	}
+
+	// Disk usage is reported in KB
+	return boshdisk.ConvertFromKbToBytes(stats.DiskUsage.Total), nil
+}
+
 func (p linux) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (bool, error) {
 	p.logger.Debug(logTag, "Unmounting persistent disk %+v", diskSettings)
 
@@ -1331,6 +1720,21 @@ func (p linux) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (bo
 		return false, bosherr.WrapError(err, "Getting real device path")
 	}
 
+	mapperName := persistentDiskMapperName(diskSettings.ID)
+	if p.fs.FileExists(boshdisk.MappedDevicePath(mapperName)) {
+		didUnmount, err := p.diskManager.GetMounter().Unmount(boshdisk.MappedDevicePath(mapperName))
+		if err != nil {
+			return false, err
+		}
+
+		err = p.diskManager.GetEncryptor().Close(mapperName)
+		if err != nil {
+			return false, bosherr.WrapError(err, "Closing encrypted persistent disk")
+		}
+
+		return didUnmount, nil
+	}
+
 	if !p.options.UsePreformattedPersistentDisk {
 		realPath = p.partitionPath(realPath, 1)
 	}
@@ -1367,7 +1771,7 @@ func (p linux) IsMountPoint(path string) (string, bool, error) {
 	return p.diskManager.GetMounter().IsMountPoint(path)
 }
 
-func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error {
+func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string, progress boshdisk.MigrationProgressFunc) error {
 	p.logger.Debug(logTag, "Migrating persistent disk %v to %v", fromMountPoint, toMountPoint)
 
 	err := p.diskManager.GetMounter().RemountAsReadonly(fromMountPoint)
@@ -1375,14 +1779,18 @@ func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error
 		return bosherr.WrapError(err, "Remounting persistent disk as readonly")
 	}
 
-	// Golang does not implement a file copy that would allow us to preserve dates...
-	// So we have to shell out to tar to perform the copy instead of delegating to the FileSystem
-	tarCopy := fmt.Sprintf("(tar -C %s --xattrs -cf - .) | (tar -C %s --xattrs -xpf -)", fromMountPoint, toMountPoint)
-	_, _, _, err = p.cmdRunner.RunCommand("sh", "-c", tarCopy)
+	// Migrator verifies copied files and resumes from its checkpoint on the target disk
+	// so mounts are only switched once the target disk holds a complete copy
+	err = p.diskManager.GetMigrator().Migrate(fromMountPoint, toMountPoint, progress)
 	if err != nil {
 		return bosherr.WrapError(err, "Copying files from old disk to new disk")
 	}
 
+	fromPartitionPath, _, err := p.diskManager.GetMounter().IsMountPoint(fromMountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Finding old persistent disk partition")
+	}
+
 	// Find iSCSI device id of fromMountPoint
 	var iscsiID string
 	if p.options.DevicePathResolutionType == "iscsi" {
@@ -1407,6 +1815,13 @@ func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) error
 		return bosherr.WrapError(err, "Unmounting old persistent disk")
 	}
 
+	if strings.HasPrefix(fromPartitionPath, boshdisk.MappedDevicePath(persistentDiskMapperPrefix)) {
+		err = p.diskManager.GetEncryptor().Close(path.Base(fromPartitionPath))
+		if err != nil {
+			return bosherr.WrapError(err, "Closing old encrypted persistent disk")
+		}
+	}
+
 	err = p.diskManager.GetMounter().Remount(toMountPoint, fromMountPoint)
 	if err != nil {
 		err = bosherr.WrapError(err, "Remounting new disk on original mountpoint")
@@ -1433,6 +1848,11 @@ func (p linux) IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (
 		return false, bosherr.WrapError(err, "Getting real device path")
 	}
 
+	mappedPath := boshdisk.MappedDevicePath(persistentDiskMapperName(diskSettings.ID))
+	if p.fs.FileExists(mappedPath) {
+		return p.diskManager.GetMounter().IsMounted(mappedPath)
+	}
+
 	if !p.options.UsePreformattedPersistentDisk {
 		realPath = p.partitionPath(realPath, 1)
 	}
@@ -1686,6 +2106,80 @@ func (p linux) RemoveStaticLibraries(staticLibrariesListFilePath string) error {
 	return nil
 }
 
+// unlockPartition opens encrypted partitions and returns path of the mapped
+// device to use instead. Unencrypted partitions are only encrypted when
+// encryption is enabled, encryptBlank is set and they are not formatted yet.
+func (p linux) unlockPartition(partitionPath, mapperName string, encryption boshsettings.DiskEncryption, encryptBlank bool) (string, error) {
+	encryptor := p.diskManager.GetEncryptor()
+
+	encrypted, err := encryptor.IsEncrypted(partitionPath)
+	if err != nil {
+		return "", err
+	}
+
+	if !encrypted {
+		if !encryption.Enabled || !encryptBlank {
+			return partitionPath, nil
+		}
+
+		fsType, err := p.diskManager.GetFormatter().GetPartitionFormatType(partitionPath)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Checking filesystem format of partition")
+		}
+
+		if fsType != boshdisk.FileSystemDefault {
+			p.logger.Warn(logTag, "Not encrypting `%s' as it is already formatted with %s", partitionPath, fsType)
+			return partitionPath, nil
+		}
+	}
+
+	key, err := p.diskEncryptionKey(encryption)
+	if err != nil {
+		return "", err
+	}
+
+	if !encrypted {
+		err = encryptor.Encrypt(partitionPath, key)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return encryptor.Open(partitionPath, mapperName, key)
+}
+
+func (p linux) resizeEncryptedPartition(mapperName string, encryption boshsettings.DiskEncryption) error {
+	key, err := p.diskEncryptionKey(encryption)
+	if err != nil {
+		return err
+	}
+
+	return p.diskManager.GetEncryptor().Resize(mapperName, key)
+}
+
+func (p linux) diskEncryptionKey(encryption boshsettings.DiskEncryption) ([]byte, error) {
+	if encryption.Key != "" {
+		return []byte(encryption.Key), nil
+	}
+
+	if encryption.KeyPath == "" {
+		return nil, bosherr.Error("No disk encryption key or key path configured")
+	}
+
+	key, err := p.fs.ReadFile(encryption.KeyPath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading disk encryption key file")
+	}
+
+	return key, nil
+}
+
+// persistentDiskMapperName hashes disk ID as some IaaSes use
+// long disk IDs containing characters not allowed in device names
+func persistentDiskMapperName(diskID string) string {
+	return fmt.Sprintf("%s%x", persistentDiskMapperPrefix, sha256.Sum256([]byte(diskID)))[:len(persistentDiskMapperPrefix)+16]
+}
+
 func (p linux) partitionPath(devicePath string, partitionNumber int) string {
 	switch {
 	case strings.HasPrefix(devicePath, "/dev/nvme"):
@@ -22,8 +22,12 @@ import (
 	"github.com/cloudfoundry/bosh-agent/platform/cert/certfakes"
 	"github.com/cloudfoundry/bosh-agent/platform/disk/diskfakes"
 	fakedisk "github.com/cloudfoundry/bosh-agent/platform/disk/fakes"
+	"github.com/cloudfoundry/bosh-agent/platform/dnsresolver/dnsresolverfakes"
 	fakeplat "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshlogrotate "github.com/cloudfoundry/bosh-agent/platform/logrotate"
 	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
+	"github.com/cloudfoundry/bosh-agent/platform/ntp/ntpfakes"
+	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	fakestats "github.com/cloudfoundry/bosh-agent/platform/stats/fakes"
 	fakeretry "github.com/cloudfoundry/bosh-utils/retrystrategy/fakes"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -44,6 +48,7 @@ var _ = Describe("LinuxPlatform", func() {
 		fs                         *fakesys.FakeFileSystem
 		cmdRunner                  *fakesys.FakeCmdRunner
 		diskManager                *diskfakes.FakeManager
+		encryptor                  *diskfakes.FakeEncryptor
 		dirProvider                boshdirs.Provider
 		devicePathResolver         *fakedpresolv.FakeDevicePathResolver
 		platform                   Platform
@@ -52,6 +57,9 @@ var _ = Describe("LinuxPlatform", func() {
 		copier                     boshcmd.Copier
 		vitalsService              boshvitals.Service
 		netManager                 *fakenet.FakeManager
+		dnsResolver                *dnsresolverfakes.FakeResolver
+		timeSyncManager            *ntpfakes.FakeManager
+		timeSyncMonitor            *ntpfakes.FakeMonitor
 		certManager                *certfakes.FakeManager
 		monitRetryStrategy         *fakeretry.FakeRetryStrategy
 		fakeDefaultNetworkResolver *fakenet.FakeDefaultNetworkResolver
@@ -70,6 +78,7 @@ var _ = Describe("LinuxPlatform", func() {
 		mounter        *diskfakes.FakeMounter
 		mountsSearcher *fakedisk.FakeMountsSearcher
 		diskUtil       *fakedisk.FakeDiskUtil
+		migrator       *diskfakes.FakeMigrator
 	)
 
 	BeforeEach(func() {
@@ -83,6 +92,9 @@ var _ = Describe("LinuxPlatform", func() {
 		compressor = boshcmd.NewTarballCompressor(cmdRunner, fs)
 		copier = boshcmd.NewGenericCpCopier(fs, logger)
 		netManager = &fakenet.FakeManager{}
+		dnsResolver = &dnsresolverfakes.FakeResolver{}
+		timeSyncManager = &ntpfakes.FakeManager{}
+		timeSyncMonitor = &ntpfakes.FakeMonitor{}
 		certManager = new(certfakes.FakeManager)
 		monitRetryStrategy = fakeretry.NewFakeRetryStrategy()
 		devicePathResolver = fakedpresolv.NewFakeDevicePathResolver()
@@ -115,6 +127,9 @@ var _ = Describe("LinuxPlatform", func() {
 		formatter = fakedisk.NewFakeFormatter()
 		diskManager.GetFormatterReturns(formatter)
 
+		encryptor = &diskfakes.FakeEncryptor{}
+		diskManager.GetEncryptorReturns(encryptor)
+
 		mounter = &diskfakes.FakeMounter{}
 		diskManager.GetMounterReturns(mounter)
 
@@ -124,7 +139,10 @@ var _ = Describe("LinuxPlatform", func() {
 		diskUtil = fakedisk.NewFakeDiskUtil()
 		diskManager.GetUtilReturns(diskUtil)
 
-		vitalsService = boshvitals.NewService(collector, dirProvider, mounter)
+		migrator = &diskfakes.FakeMigrator{}
+		diskManager.GetMigratorReturns(migrator)
+
+		vitalsService = boshvitals.NewService(collector, dirProvider, mounter, nil)
 	})
 
 	JustBeforeEach(func() {
@@ -139,6 +157,9 @@ var _ = Describe("LinuxPlatform", func() {
 			cdutil,
 			diskManager,
 			netManager,
+			dnsResolver,
+			timeSyncManager,
+			timeSyncMonitor,
 			certManager,
 			monitRetryStrategy,
 			devicePathResolver,
@@ -318,7 +339,7 @@ bosh_foobar:...`
 			})
 
 			It("runs growpart and resize2fs for the right root device number", func() {
-				err := platform.SetupEphemeralDiskWithPath("/dev/sda", nil, labelPrefix)
+				err := platform.SetupEphemeralDiskWithPath("/dev/sda", nil, labelPrefix, boshsettings.DiskEncryption{})
 				Expect(err).NotTo(HaveOccurred())
 
 				mountsSearcher.SearchMountsMounts = []boshdisk.Mount{{
@@ -343,7 +364,7 @@ bosh_foobar:...`
 			})
 
 			It("runs growpart and xfs_growfs for the right root device number", func() {
-				err := platform.SetupEphemeralDiskWithPath("/dev/sda", nil, labelPrefix)
+				err := platform.SetupEphemeralDiskWithPath("/dev/sda", nil, labelPrefix, boshsettings.DiskEncryption{})
 				Expect(err).NotTo(HaveOccurred())
 
 				mountsSearcher.SearchMountsMounts = []boshdisk.Mount{{
@@ -455,6 +476,9 @@ bosh_foobar:...`
 					cdutil,
 					diskManager,
 					netManager,
+					dnsResolver,
+					timeSyncManager,
+					timeSyncMonitor,
 					certManager,
 					monitRetryStrategy,
 					devicePathResolver,
@@ -553,7 +577,7 @@ bosh_foobar:...`
 				})
 
 				It("runs growpart and resize2fs for the right root device number", func() {
-					err := platform.SetupEphemeralDiskWithPath("/dev/nvme0n1", nil, labelPrefix)
+					err := platform.SetupEphemeralDiskWithPath("/dev/nvme0n1", nil, labelPrefix, boshsettings.DiskEncryption{})
 					Expect(err).NotTo(HaveOccurred())
 
 					mountsSearcher.SearchMountsMounts = []boshdisk.Mount{{
@@ -578,7 +602,7 @@ bosh_foobar:...`
 				})
 
 				It("runs growpart and xfs_growfs for the right root device number", func() {
-					err := platform.SetupEphemeralDiskWithPath("/dev/nvme0n1", nil, labelPrefix)
+					err := platform.SetupEphemeralDiskWithPath("/dev/nvme0n1", nil, labelPrefix, boshsettings.DiskEncryption{})
 					Expect(err).NotTo(HaveOccurred())
 
 					mountsSearcher.SearchMountsMounts = []boshdisk.Mount{{
@@ -690,6 +714,9 @@ bosh_foobar:...`
 						cdutil,
 						diskManager,
 						netManager,
+						dnsResolver,
+						timeSyncManager,
+						timeSyncMonitor,
 						certManager,
 						monitRetryStrategy,
 						devicePathResolver,
@@ -911,7 +938,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 `
 
 		It("sets up logrotate", func() {
-			err := platform.SetupLogrotate("fake-group-name", "fake-base-path", "fake-size")
+			err := platform.SetupLogrotate("fake-group-name", "fake-base-path", "fake-size", nil)
 			Expect(err).NotTo(HaveOccurred())
 
 			logrotateFileContent, err := fs.ReadFileString("/etc/logrotate.d/fake-group-name")
@@ -921,28 +948,114 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 			Expect(len(cmdRunner.RunCommands)).To(Equal(1))
 			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"/var/vcap/bosh/bin/setup-logrotate.sh"}))
 		})
+
+		Context("when jobs declare logrotate policies", func() {
+			const expectedEtcLogrotateWithPolicies = `# Generated by bosh-agent
+
+fake-base-path/data/sys/log/job-a/*.log fake-base-path/data/sys/log/job-a/.*.log fake-base-path/data/sys/log/job-a/*/*.log fake-base-path/data/sys/log/job-a/*/.*.log {
+  missingok
+  rotate 7
+  compress
+  copytruncate
+  size=fake-size
+}
+
+fake-base-path/data/sys/log/job-c/*.log fake-base-path/data/sys/log/job-c/.*.log fake-base-path/data/sys/log/job-c/*/*.log fake-base-path/data/sys/log/job-c/*/.*.log {
+  missingok
+  rotate 2
+  maxage 3
+  nocompress
+  nocopytruncate
+  size=100M
+}
+
+fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log {
+  missingok
+  rotate 7
+  compress
+  copytruncate
+  size=fake-size
+}
+`
+
+			It("writes a rule per job and excludes their logs from the default rule", func() {
+				rotate := 2
+				compress := false
+				copyTruncate := false
+
+				err := platform.SetupLogrotate("fake-group-name", "fake-base-path", "fake-size", map[string]boshlogrotate.Policy{
+					"job-a": {},
+					"job-c": {Size: "100M", MaxAgeDays: 3, Rotate: &rotate, Compress: &compress, CopyTruncate: &copyTruncate},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				logrotateFileContent, err := fs.ReadFileString("/etc/logrotate.d/fake-group-name")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logrotateFileContent).To(Equal(expectedEtcLogrotateWithPolicies))
+			})
+
+			It("keeps rotating logs in directories which do not belong to jobs", func() {
+				Expect(fs.MkdirAll("fake-base-path/data/sys/log/job-a", 0750)).To(Succeed())
+				Expect(fs.MkdirAll("fake-base-path/data/sys/log/other", 0750)).To(Succeed())
+				Expect(fs.WriteFileString("fake-base-path/data/sys/log/top.log", "")).To(Succeed())
+				fs.SetGlob("fake-base-path/data/sys/log/*", []string{
+					"fake-base-path/data/sys/log/top.log",
+					"fake-base-path/data/sys/log/other",
+					"fake-base-path/data/sys/log/job-a",
+				})
+
+				err := platform.SetupLogrotate("fake-group-name", "fake-base-path", "fake-size", map[string]boshlogrotate.Policy{
+					"job-a": {},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				logrotateFileContent, err := fs.ReadFileString("/etc/logrotate.d/fake-group-name")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logrotateFileContent).To(HaveSuffix(`
+fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-path/data/sys/log/other/*.log fake-base-path/data/sys/log/other/.*.log fake-base-path/data/sys/log/other/*/*.log fake-base-path/data/sys/log/other/*/.*.log {
+  missingok
+  rotate 7
+  compress
+  copytruncate
+  size=fake-size
+}
+`))
+			})
+
+			It("returns an error when log directories cannot be listed", func() {
+				fs.GlobErr = errors.New("fake-glob-err")
+
+				err := platform.SetupLogrotate("fake-group-name", "fake-base-path", "fake-size", map[string]boshlogrotate.Policy{
+					"job-a": {},
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-glob-err"))
+			})
+		})
 	})
 
 	Describe("SetTimeWithNtpServers", func() {
-		It("sets time with ntp servers", func() {
+		It("configures time synchronization with ntp servers", func() {
 			err := platform.SetTimeWithNtpServers([]string{"0.north-america.pool.ntp.org", "1.north-america.pool.ntp.org"})
 			Expect(err).NotTo(HaveOccurred())
 
-			ntpConfig := fs.GetFileTestStat("/fake-dir/bosh/etc/ntpserver")
-			Expect(ntpConfig.StringContents()).To(Equal("0.north-america.pool.ntp.org 1.north-america.pool.ntp.org"))
-			Expect(ntpConfig.FileType).To(Equal(fakesys.FakeFileTypeFile))
-
-			Expect(len(cmdRunner.RunCommands)).To(Equal(1))
-			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"sync-time"}))
+			Expect(timeSyncManager.ConfigureCallCount()).To(Equal(1))
+			Expect(timeSyncManager.ConfigureArgsForCall(0)).To(Equal([]string{"0.north-america.pool.ntp.org", "1.north-america.pool.ntp.org"}))
 		})
 
-		It("sets time with ntp servers is noop when no ntp server provided", func() {
-			err := platform.SetTimeWithNtpServers([]string{})
-			Expect(err).NotTo(HaveOccurred())
-			Expect(len(cmdRunner.RunCommands)).To(Equal(0))
+		It("returns error if configuring time synchronization fails", func() {
+			timeSyncManager.ConfigureReturns(errors.New("fake-configure-err"))
+
+			err := platform.SetTimeWithNtpServers([]string{"0.north-america.pool.ntp.org"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Configuring time synchronization"))
+			Expect(err.Error()).To(ContainSubstring("fake-configure-err"))
+		})
+	})
 
-			ntpConfig := fs.GetFileTestStat("/fake-dir/bosh/etc/ntpserver")
-			Expect(ntpConfig).To(BeNil())
+	Describe("GetTimeSyncMonitor", func() {
+		It("returns time synchronization monitor", func() {
+			Expect(platform.GetTimeSyncMonitor()).To(Equal(timeSyncMonitor))
 		})
 	})
 
@@ -980,7 +1093,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 
 		Context("when ephemeral disk path is provided", func() {
 			act := func() error {
-				return platform.SetupEphemeralDiskWithPath("/dev/xvda", nil, labelPrefix)
+				return platform.SetupEphemeralDiskWithPath("/dev/xvda", nil, labelPrefix, boshsettings.DiskEncryption{})
 			}
 
 			itSetsUpEphemeralDisk(act)
@@ -1132,7 +1245,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 						It("creates swap equal to specified amount", func() {
 							var desiredSwapSize uint64 = 2048
 							act = func() error {
-								return platform.SetupEphemeralDiskWithPath(devicePath, &desiredSwapSize, labelPrefix)
+								return platform.SetupEphemeralDiskWithPath(devicePath, &desiredSwapSize, labelPrefix, boshsettings.DiskEncryption{})
 							}
 							partitioner.GetDeviceSizeInBytesSizes[devicePath] = diskSizeInBytes
 
@@ -1160,7 +1273,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 
 							var desiredSwapSize uint64
 							act = func() error {
-								return platform.SetupEphemeralDiskWithPath(devicePath, &desiredSwapSize, labelPrefix)
+								return platform.SetupEphemeralDiskWithPath(devicePath, &desiredSwapSize, labelPrefix, boshsettings.DiskEncryption{})
 							}
 							partitioner.GetDeviceSizeInBytesSizes[devicePath] = diskSizeInBytes
 
@@ -1181,7 +1294,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 
 					It("uses the default swap size options", func() {
 						act = func() error {
-							return platform.SetupEphemeralDiskWithPath(devicePath, nil, labelPrefix)
+							return platform.SetupEphemeralDiskWithPath(devicePath, nil, labelPrefix, boshsettings.DiskEncryption{})
 						}
 						partitioner.GetDeviceSizeInBytesSizes[devicePath] = diskSizeInBytes
 						collector.MemStats.Total = 2048
@@ -1198,7 +1311,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 						labelPrefix = "12345678-1234-abcd-1234-1234abcd5678"
 						expectedLabelPrefix = ("bosh-partition-" + labelPrefix)[0:32]
 						act = func() error {
-							return platform.SetupEphemeralDiskWithPath(devicePath, nil, labelPrefix)
+							return platform.SetupEphemeralDiskWithPath(devicePath, nil, labelPrefix, boshsettings.DiskEncryption{})
 						}
 						partitioner.GetDeviceSizeInBytesSizes[devicePath] = diskSizeInBytes
 						collector.MemStats.Total = 2048
@@ -1218,7 +1331,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 
 			Context("and is NVMe", func() {
 				act = func() error {
-					return platform.SetupEphemeralDiskWithPath("/dev/nvme1n1", nil, labelPrefix)
+					return platform.SetupEphemeralDiskWithPath("/dev/nvme1n1", nil, labelPrefix, boshsettings.DiskEncryption{})
 				}
 
 				itSetsUpEphemeralDisk(act)
@@ -1229,7 +1342,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 
 		Context("when ephemeral disk path is not provided", func() {
 			act := func() error {
-				return platform.SetupEphemeralDiskWithPath("", nil, labelPrefix)
+				return platform.SetupEphemeralDiskWithPath("", nil, labelPrefix, boshsettings.DiskEncryption{})
 			}
 
 			Context("when agent should partition ephemeral disk on root disk", func() {
@@ -1426,7 +1539,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 									It("creates swap equal to specified amount", func() {
 										var desiredSwapSize uint64 = 2048
 										act := func() error {
-											return platform.SetupEphemeralDiskWithPath("", &desiredSwapSize, labelPrefix)
+											return platform.SetupEphemeralDiskWithPath("", &desiredSwapSize, labelPrefix, boshsettings.DiskEncryption{})
 										}
 										partitioner.GetDeviceSizeInBytesSizes["/dev/vda"] = diskSizeInBytes
 
@@ -1449,7 +1562,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 
 										var desiredSwapSize uint64
 										act := func() error {
-											return platform.SetupEphemeralDiskWithPath("", &desiredSwapSize, labelPrefix)
+											return platform.SetupEphemeralDiskWithPath("", &desiredSwapSize, labelPrefix, boshsettings.DiskEncryption{})
 										}
 										partitioner.GetDeviceSizeInBytesSizes["/dev/vda"] = diskSizeInBytes
 
@@ -1663,7 +1776,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 
 			It("makes sure ephemeral directory is there but does nothing else", func() {
 				swapSize := uint64(0)
-				err := platform.SetupEphemeralDiskWithPath("/dev/xvda", &swapSize, labelPrefix)
+				err := platform.SetupEphemeralDiskWithPath("/dev/xvda", &swapSize, labelPrefix, boshsettings.DiskEncryption{})
 				Expect(err).ToNot(HaveOccurred())
 
 				dataDir := fs.GetFileTestStat("/fake-dir/data")
@@ -1675,6 +1788,76 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 				Expect(mounter.MountCallCount()).To(Equal(0))
 			})
 		})
+
+		Context("when encryption is enabled", func() {
+			var encryption boshsettings.DiskEncryption
+
+			BeforeEach(func() {
+				encryption = boshsettings.DiskEncryption{Enabled: true, Key: "fake-key"}
+
+				cmdRunner.AddCmdResult("readlink -f /dev/xvda1", fakesys.FakeCmdResult{Stdout: "/dev/xvda1"})
+				cmdRunner.AddCmdResult("readlink -f /dev/xvda2", fakesys.FakeCmdResult{Stdout: "/dev/xvda2"})
+				partitioner.GetDeviceSizeInBytesSizes["/dev/xvda"] = 4096
+				collector.MemStats.Total = 2048
+
+				encryptor.OpenStub = func(partitionPath, mapperName string, key []byte) (string, error) {
+					return "/dev/mapper/" + mapperName, nil
+				}
+			})
+
+			It("encrypts blank partitions and uses mapped devices", func() {
+				err := platform.SetupEphemeralDiskWithPath("/dev/xvda", nil, labelPrefix, encryption)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(encryptor.EncryptCallCount()).To(Equal(2))
+				partitionPath, key := encryptor.EncryptArgsForCall(0)
+				Expect(partitionPath).To(Equal("/dev/xvda1"))
+				Expect(key).To(Equal([]byte("fake-key")))
+
+				Expect(formatter.FormatPartitionPaths).To(Equal([]string{"/dev/mapper/bosh-ephemeral-swap", "/dev/mapper/bosh-ephemeral-data"}))
+				Expect(mounter.SwapOnArgsForCall(0)).To(Equal("/dev/mapper/bosh-ephemeral-swap"))
+
+				partition, mntPoint, _ := mounter.MountArgsForCall(0)
+				Expect(partition).To(Equal("/dev/mapper/bosh-ephemeral-data"))
+				Expect(mntPoint).To(Equal("/fake-dir/data"))
+			})
+
+			It("opens encrypted partitions with key from key file after reboot", func() {
+				encryption = boshsettings.DiskEncryption{Enabled: true, KeyPath: "/fake-key-path"}
+				Expect(fs.WriteFileString("/fake-key-path", "fake-file-key")).To(Succeed())
+				encryptor.IsEncryptedReturns(true, nil)
+
+				err := platform.SetupEphemeralDiskWithPath("/dev/xvda", nil, labelPrefix, encryption)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(encryptor.EncryptCallCount()).To(Equal(0))
+				Expect(encryptor.OpenCallCount()).To(Equal(2))
+				partitionPath, mapperName, key := encryptor.OpenArgsForCall(1)
+				Expect(partitionPath).To(Equal("/dev/xvda2"))
+				Expect(mapperName).To(Equal("bosh-ephemeral-data"))
+				Expect(key).To(Equal([]byte("fake-file-key")))
+			})
+
+			It("does not encrypt partitions which are already formatted", func() {
+				formatter.GetFileSystemType["/dev/xvda2"] = boshdisk.FileSystemExt4
+
+				err := platform.SetupEphemeralDiskWithPath("/dev/xvda", nil, labelPrefix, encryption)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(encryptor.EncryptCallCount()).To(Equal(1))
+				partition, _, _ := mounter.MountArgsForCall(0)
+				Expect(partition).To(Equal("/dev/xvda2"))
+			})
+
+			It("returns an error when no key is configured", func() {
+				encryption = boshsettings.DiskEncryption{Enabled: true}
+
+				err := platform.SetupEphemeralDiskWithPath("/dev/xvda", nil, labelPrefix, encryption)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("No disk encryption key or key path configured"))
+				Expect(formatter.FormatCalled).To(BeFalse())
+			})
+		})
 	})
 
 	Describe("SetupRawEphemeralDisks", func() {
@@ -3114,6 +3297,19 @@ sam:fakeanotheruser`)
 				})
 			})
 
+			Context("when settings specify btrfs filesystem", func() {
+				BeforeEach(func() {
+					diskSettings.FileSystemType = boshdisk.FileSystemBtrfs
+				})
+
+				It("formats with a btrfs filesystem", func() {
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+
+					Expect(err).ToNot(HaveOccurred())
+					Expect(formatter.FormatFsTypes).To(Equal([]boshdisk.FileSystemType{boshdisk.FileSystemBtrfs}))
+				})
+			})
+
 			Context("when settings specify an unsupported filesystem", func() {
 				BeforeEach(func() {
 					diskSettings.FileSystemType = boshdisk.FileSystemType("blahblah")
@@ -3155,6 +3351,55 @@ sam:fakeanotheruser`)
 					Expect(err.Error()).To(Equal("Formatting partition with xfs: oh noes"))
 				})
 			})
+
+			Context("when encryption is enabled", func() {
+				BeforeEach(func() {
+					diskSettings.Encryption = boshsettings.DiskEncryption{Enabled: true, Key: "fake-key"}
+					encryptor.OpenReturns("/dev/mapper/bosh-persistent-2eafc86bfd856e56", nil)
+				})
+
+				It("encrypts the first partition and formats the mapped device", func() {
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(encryptor.EncryptCallCount()).To(Equal(1))
+					partitionPath, key := encryptor.EncryptArgsForCall(0)
+					Expect(partitionPath).To(Equal("fake-real-device-path1"))
+					Expect(key).To(Equal([]byte("fake-key")))
+
+					_, mapperName, _ := encryptor.OpenArgsForCall(0)
+					Expect(mapperName).To(Equal("bosh-persistent-2eafc86bfd856e56"))
+
+					Expect(formatter.FormatPartitionPaths).To(Equal([]string{"/dev/mapper/bosh-persistent-2eafc86bfd856e56"}))
+				})
+
+				It("does not encrypt partitions of existing unencrypted disks", func() {
+					formatter.GetFileSystemType["fake-real-device-path1"] = boshdisk.FileSystemExt4
+
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(encryptor.EncryptCallCount()).To(Equal(0))
+					Expect(formatter.FormatPartitionPaths).To(Equal([]string{"fake-real-device-path1"}))
+				})
+
+				It("resizes encrypted partitions before growing their filesystem", func() {
+					partitioner.SinglePartitionNeedsResizeReturns.NeedResize = true
+					encryptor.IsEncryptedReturns(true, nil)
+
+					err := platform.AdjustPersistentDiskPartitioning(diskSettings, mntPoint)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(encryptor.ResizeCallCount()).To(Equal(1))
+					mapperName, key := encryptor.ResizeArgsForCall(0)
+					Expect(mapperName).To(Equal("bosh-persistent-2eafc86bfd856e56"))
+					Expect(key).To(Equal([]byte("fake-key")))
+
+					partition, _, _ := mounter.MountArgsForCall(0)
+					Expect(partition).To(Equal("/dev/mapper/bosh-persistent-2eafc86bfd856e56"))
+					Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/mapper/bosh-persistent-2eafc86bfd856e56"))
+				})
+			})
 		})
 	})
 
@@ -3462,6 +3707,167 @@ sam:fakeanotheruser`)
 				Expect(err.Error()).To(ContainSubstring("fake-get-real-device-path-err"))
 			})
 		})
+
+		Context("when persistent disk is encrypted", func() {
+			BeforeEach(func() {
+				devicePathResolver.RealDevicePath = "/dev/sdb"
+				encryptor.IsEncryptedReturns(true, nil)
+				encryptor.OpenReturns("/dev/mapper/bosh-persistent-2eafc86bfd856e56", nil)
+				Expect(fs.WriteFileString("/fake-key-path", "fake-file-key")).To(Succeed())
+				diskSettings.Encryption = boshsettings.DiskEncryption{KeyPath: "/fake-key-path"}
+			})
+
+			It("opens the partition with key from key file and mounts the mapped device", func() {
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).ToNot(HaveOccurred())
+
+				partitionPath, mapperName, key := encryptor.OpenArgsForCall(0)
+				Expect(partitionPath).To(Equal("/dev/sdb1"))
+				Expect(mapperName).To(Equal("bosh-persistent-2eafc86bfd856e56"))
+				Expect(key).To(Equal([]byte("fake-file-key")))
+
+				partition, mntPt, _ := mounter.MountArgsForCall(0)
+				Expect(partition).To(Equal("/dev/mapper/bosh-persistent-2eafc86bfd856e56"))
+				Expect(mntPt).To(Equal("/mnt/point"))
+			})
+
+			It("skips mounting when the mapped device is already mounted", func() {
+				mounter.IsMountPointReturns("/dev/mapper/bosh-persistent-2eafc86bfd856e56", true, nil)
+
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mounter.MountCallCount()).To(Equal(0))
+			})
+
+			It("returns an error when opening fails", func() {
+				encryptor.OpenReturns("", errors.New("fake-open-err"))
+
+				err := platform.MountPersistentDisk(diskSettings, mntPoint)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-open-err"))
+				Expect(mounter.MountCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("ResizePersistentDisk", func() {
+		var diskSettings boshsettings.DiskSettings
+
+		BeforeEach(func() {
+			diskSettings = boshsettings.DiskSettings{ID: "fake-unique-id", Path: "fake-volume-id"}
+			devicePathResolver.RealDevicePath = "/dev/disk/by-id/fake-disk"
+
+			cmdRunner.AddCmdResult("readlink -f /dev/disk/by-id/fake-disk", fakesys.FakeCmdResult{Stdout: "/dev/sdb\n"})
+			Expect(fs.WriteFileString("/sys/class/block/sdb/device/rescan", "")).To(Succeed())
+
+			partitioner.GetDeviceSizeInBytesSizes["/dev/disk/by-id/fake-disk"] = 10 * 1024 * 1024
+			cmdRunner.SetCmdCallback("readlink -f /dev/disk/by-id/fake-disk", func() {
+				partitioner.GetDeviceSizeInBytesSizes["/dev/disk/by-id/fake-disk"] = 20 * 1024 * 1024
+			})
+
+			collector.DiskStats = map[string]boshstats.DiskStats{
+				"/mnt/point": {DiskUsage: boshstats.Usage{Total: 9 * 1024}},
+			}
+			partitioner.SinglePartitionNeedsResizeReturns.NeedResize = true
+
+			mounter.IsMountPointStub = func(mountPoint string) (string, bool, error) {
+				if mountPoint == "/mnt/point" {
+					if fs.FileExists("/dev/mapper/bosh-persistent-2eafc86bfd856e56") {
+						return "/dev/mapper/bosh-persistent-2eafc86bfd856e56", true, nil
+					}
+					return "/dev/disk/by-id/fake-disk1", true, nil
+				}
+				return "", false, nil
+			}
+		})
+
+		It("rescans the device and grows partition and filesystem while mounted", func() {
+			resize, err := platform.ResizePersistentDisk(diskSettings, "/mnt/point")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/sys/class/block/sdb/device/rescan")).To(Equal("1"))
+			Expect(partitioner.ResizeSinglePartitionDevicePath).To(Equal("/dev/disk/by-id/fake-disk"))
+			Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/disk/by-id/fake-disk1"))
+			Expect(mounter.MountCallCount()).To(Equal(0))
+			Expect(mounter.UnmountCallCount()).To(Equal(0))
+
+			Expect(resize).To(Equal(DiskResize{
+				DeviceSizeBefore:     10 * 1024 * 1024,
+				DeviceSizeAfter:      20 * 1024 * 1024,
+				FilesystemSizeBefore: 9 * 1024 * 1024,
+				FilesystemSizeAfter:  9 * 1024 * 1024,
+			}))
+		})
+
+		It("does not resize partition when it already spans the disk", func() {
+			partitioner.SinglePartitionNeedsResizeReturns.NeedResize = false
+
+			_, err := platform.ResizePersistentDisk(diskSettings, "/mnt/point")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(partitioner.ResizeSinglePartitionCalled).To(BeFalse())
+			Expect(formatter.GrowFilesystemCalled).To(BeTrue())
+		})
+
+		It("resizes encrypted partitions and grows filesystem of the mapped device", func() {
+			diskSettings.Encryption = boshsettings.DiskEncryption{Key: "fake-key"}
+			Expect(fs.WriteFileString("/dev/mapper/bosh-persistent-2eafc86bfd856e56", "")).To(Succeed())
+
+			_, err := platform.ResizePersistentDisk(diskSettings, "/mnt/point")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(encryptor.ResizeCallCount()).To(Equal(1))
+			Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/mapper/bosh-persistent-2eafc86bfd856e56"))
+		})
+
+		It("returns an error when growing filesystem fails", func() {
+			formatter.GrowFilesystemError = errors.New("fake-grow-err")
+
+			_, err := platform.ResizePersistentDisk(diskSettings, "/mnt/point")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-grow-err"))
+		})
+
+		It("does not resize when filesystem already spans the device", func() {
+			cmdRunner.SetCmdCallback("readlink -f /dev/disk/by-id/fake-disk", func() {})
+			collector.DiskStats["/mnt/point"] = boshstats.DiskStats{DiskUsage: boshstats.Usage{Total: 10 * 1000}}
+
+			resize, err := platform.ResizePersistentDisk(diskSettings, "/mnt/point")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(partitioner.ResizeSinglePartitionCalled).To(BeFalse())
+			Expect(formatter.GrowFilesystemCalled).To(BeFalse())
+			Expect(resize.FilesystemSizeAfter).To(Equal(resize.FilesystemSizeBefore))
+		})
+
+		It("grows filesystem of the disk mounted at migration dir", func() {
+			mounter.IsMountPointStub = func(mountPoint string) (string, bool, error) {
+				switch mountPoint {
+				case "/mnt/point":
+					return "/dev/sdc1", true, nil
+				case dirProvider.StoreMigrationDir():
+					return "/dev/disk/by-id/fake-disk1", true, nil
+				}
+				return "", false, nil
+			}
+			collector.DiskStats[dirProvider.StoreMigrationDir()] = boshstats.DiskStats{DiskUsage: boshstats.Usage{Total: 8 * 1024}}
+
+			resize, err := platform.ResizePersistentDisk(diskSettings, "/mnt/point")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(formatter.GrowFilesystemPartitionPath).To(Equal("/dev/disk/by-id/fake-disk1"))
+			Expect(resize.FilesystemSizeBefore).To(Equal(uint64(8 * 1024 * 1024)))
+		})
+
+		It("returns an error when disk is not mounted", func() {
+			mounter.IsMountPointReturns("", false, nil)
+			mounter.IsMountPointStub = nil
+
+			_, err := platform.ResizePersistentDisk(diskSettings, "/mnt/point")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is not mounted at /mnt/point"))
+			Expect(formatter.GrowFilesystemCalled).To(BeFalse())
+		})
 	})
 
 	Describe("UnmountPersistentDisk", func() {
@@ -3498,6 +3904,25 @@ sam:fakeanotheruser`)
 			})
 		}
 
+		Context("when persistent disk is encrypted", func() {
+			BeforeEach(func() {
+				devicePathResolver.RealDevicePath = "/dev/sdb"
+				Expect(fs.WriteFileString("/dev/mapper/bosh-persistent-2eafc86bfd856e56", "")).To(Succeed())
+			})
+
+			It("unmounts the mapped device and closes it", func() {
+				mounter.UnmountReturns(true, nil)
+
+				didUnmount, err := platform.UnmountPersistentDisk(boshsettings.DiskSettings{ID: "fake-unique-id"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(didUnmount).To(BeTrue())
+				Expect(mounter.UnmountArgsForCall(0)).To(Equal("/dev/mapper/bosh-persistent-2eafc86bfd856e56"))
+
+				Expect(encryptor.CloseCallCount()).To(Equal(1))
+				Expect(encryptor.CloseArgsForCall(0)).To(Equal("bosh-persistent-2eafc86bfd856e56"))
+			})
+		})
+
 		Context("when device real path contains /dev/mapper/ and can be resolved", func() {
 			BeforeEach(func() {
 				devicePathResolver.RealDevicePath = "/dev/mapper/fake-real-device-path"
@@ -3667,14 +4092,22 @@ sam:fakeanotheruser`)
 
 	Describe("MigratePersistentDisk", func() {
 		It("migrate persistent disk", func() {
-			err := platform.MigratePersistentDisk("/from/path", "/to/path")
+			var reported []boshdisk.MigrationProgress
+			progress := func(p boshdisk.MigrationProgress) { reported = append(reported, p) }
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", progress)
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(mounter.RemountAsReadonlyCallCount()).To(Equal(1))
 			Expect(mounter.RemountAsReadonlyArgsForCall(0)).To(Equal("/from/path"))
 
-			Expect(len(cmdRunner.RunCommands)).To(Equal(1))
-			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"sh", "-c", "(tar -C /from/path --xattrs -cf - .) | (tar -C /to/path --xattrs -xpf -)"}))
+			Expect(migrator.MigrateCallCount()).To(Equal(1))
+			fromDir, toDir, migrationProgress := migrator.MigrateArgsForCall(0)
+			Expect(fromDir).To(Equal("/from/path"))
+			Expect(toDir).To(Equal("/to/path"))
+
+			migrationProgress(boshdisk.MigrationProgress{Stage: boshdisk.MigrationStageCopying, DoneFiles: 1})
+			Expect(reported).To(Equal([]boshdisk.MigrationProgress{{Stage: boshdisk.MigrationStageCopying, DoneFiles: 1}}))
 
 			Expect(mounter.UnmountCallCount()).To(Equal(1))
 			Expect(mounter.UnmountArgsForCall(0)).To(Equal("/from/path"))
@@ -3686,6 +4119,36 @@ sam:fakeanotheruser`)
 			Expect(options).To(BeEmpty())
 		})
 
+		It("does not switch mounts when copying files fails", func() {
+			migrator.MigrateReturns(errors.New("fake-migrate-err"))
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Copying files from old disk to new disk"))
+			Expect(err.Error()).To(ContainSubstring("fake-migrate-err"))
+
+			Expect(mounter.UnmountCallCount()).To(Equal(0))
+			Expect(mounter.RemountCallCount()).To(Equal(0))
+		})
+
+		It("closes old persistent disk if it is encrypted", func() {
+			mounter.IsMountPointReturns("/dev/mapper/bosh-persistent-2eafc86bfd856e56", true, nil)
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(encryptor.CloseCallCount()).To(Equal(1))
+			Expect(encryptor.CloseArgsForCall(0)).To(Equal("bosh-persistent-2eafc86bfd856e56"))
+		})
+
+		It("does not close unencrypted old persistent disk", func() {
+			mounter.IsMountPointReturns("/dev/sdb1", true, nil)
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(encryptor.CloseCallCount()).To(Equal(0))
+		})
+
 		Context("when device path resolution type is iscsi", func() {
 			BeforeEach(func() {
 				mountsSearcher.SearchMountsMounts = []boshdisk.Mount{
@@ -3716,6 +4179,9 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 					cdutil,
 					diskManager,
 					netManager,
+					dnsResolver,
+					timeSyncManager,
+					timeSyncMonitor,
 					certManager,
 					monitRetryStrategy,
 					devicePathResolver,
@@ -3727,14 +4193,15 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 					fakeAuditLogger,
 				)
 
-				err := platformWithISCSIType.MigratePersistentDisk("/from/path", "/to/path")
+				err := platformWithISCSIType.MigratePersistentDisk("/from/path", "/to/path", nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(mounter.RemountAsReadonlyCallCount()).To(Equal(1))
 				Expect(mounter.RemountAsReadonlyArgsForCall(0)).To(Equal("/from/path"))
 
-				Expect(len(cmdRunner.RunCommands)).To(Equal(3))
-				Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"sh", "-c", "(tar -C /from/path --xattrs -cf - .) | (tar -C /to/path --xattrs -xpf -)"}))
+				Expect(migrator.MigrateCallCount()).To(Equal(1))
+
+				Expect(len(cmdRunner.RunCommands)).To(Equal(2))
 
 				Expect(mounter.UnmountCallCount()).To(Equal(1))
 				Expect(mounter.UnmountArgsForCall(0)).To(Equal("/from/path"))
@@ -3745,8 +4212,8 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 				Expect(toPath).To(Equal("/from/path"))
 				Expect(options).To(BeEmpty())
 
-				Expect(cmdRunner.RunCommands[1]).To(Equal([]string{"multipath", "-ll"}))
-				Expect(cmdRunner.RunCommands[2]).To(Equal([]string{"multipath", "-f", "from-device-path"}))
+				Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"multipath", "-ll"}))
+				Expect(cmdRunner.RunCommands[1]).To(Equal([]string{"multipath", "-f", "from-device-path"}))
 			})
 		})
 	})
@@ -4111,6 +4578,68 @@ unit: sectors
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(netManager.SetupNetworkingNetworks).To(Equal(networks))
+			Expect(dnsResolver.StartCallCount()).To(Equal(0))
+		})
+
+		Context("when local DNS resolver is enabled", func() {
+			var networks boshsettings.Networks
+
+			BeforeEach(func() {
+				options.UseLocalDNSResolver = true
+
+				networks = boshsettings.Networks{
+					"first": boshsettings.Network{
+						IP:      "10.0.0.5",
+						DNS:     []string{"8.8.8.8", "9.9.9.9"},
+						Default: []string{"dns", "gateway"},
+					},
+					"second": boshsettings.Network{IP: "10.1.0.5"},
+				}
+			})
+
+			It("starts resolver forwarding to DNS servers and configures networks to use it", func() {
+				err := platform.SetupNetworking(networks, "")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(dnsResolver.SetUpstreamsArgsForCall(0)).To(Equal([]string{"8.8.8.8", "9.9.9.9"}))
+				Expect(dnsResolver.StartCallCount()).To(Equal(1))
+
+				Expect(netManager.SetupNetworkingNetworks["first"].DNS).To(Equal([]string{"127.0.0.1"}))
+				Expect(netManager.SetupNetworkingNetworks["second"].DNS).To(BeEmpty())
+				Expect(networks["first"].DNS).To(Equal([]string{"8.8.8.8", "9.9.9.9"}))
+			})
+
+			It("serves previously synced DNS records", func() {
+				err := fs.WriteFileString("/fake-dir/instance/dns/records.json", `{"Version":3,"records":[["10.0.0.6","fake-name"]]}`)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = platform.SetupNetworking(networks, "")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(dnsResolver.UpdateRecordsArgsForCall(0)).To(Equal(boshsettings.DNSRecords{
+					Version: 3,
+					Records: [][2]string{{"10.0.0.6", "fake-name"}},
+				}))
+			})
+
+			It("returns an error when resolver fails to start", func() {
+				dnsResolver.StartReturns(errors.New("fake-start-err"))
+
+				err := platform.SetupNetworking(networks, "")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-start-err"))
+				Expect(netManager.SetupNetworkingNetworks).To(BeNil())
+			})
+
+			It("does not start resolver when networks do not specify DNS servers", func() {
+				networks = boshsettings.Networks{"first": boshsettings.Network{Type: "dynamic"}}
+
+				err := platform.SetupNetworking(networks, "")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(dnsResolver.StartCallCount()).To(Equal(0))
+				Expect(netManager.SetupNetworkingNetworks).To(Equal(networks))
+			})
 		})
 	})
 
@@ -4325,6 +4854,23 @@ unit: sectors
 			Expect(fs.WriteFileCallCount).To(Equal(0))
 			Expect(fs.WriteFileQuietlyCallCount).To(Equal(1))
 		})
+
+		Context("when local DNS resolver is running", func() {
+			BeforeEach(func() {
+				dnsResolver.IsRunningReturns(true)
+			})
+
+			It("updates resolver records and only writes default records to '/etc/hosts'", func() {
+				err := platform.SaveDNSRecords(dnsRecords, "fake-hostname")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(dnsResolver.UpdateRecordsArgsForCall(0)).To(Equal(dnsRecords))
+
+				hostsFileContents, err := fs.ReadFileString("/etc/hosts")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hostsFileContents).To(Equal(defaultEtcHosts))
+			})
+		})
 	})
 
 	Describe("SetupDNSRecordFile", func() {
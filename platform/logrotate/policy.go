@@ -0,0 +1,38 @@
+package logrotate
+
+const defaultRotate = 7
+
+// Policy is declared by a job for rotation of its logs in sys/log/<job>;
+// unset fields fall back to defaults used for logs of all other jobs
+type Policy struct {
+	// Size at which logs are rotated, e.g. "100M"; defaults to max log file size of the instance
+	Size string `json:"size,omitempty"`
+
+	// Rotated logs older than this many days are removed regardless of their count
+	MaxAgeDays int `json:"max_age_days,omitempty"`
+
+	// Number of rotated logs to keep; defaults to 7
+	Rotate *int `json:"rotate,omitempty"`
+
+	// Whether rotated logs are gzipped; defaults to true
+	Compress *bool `json:"compress,omitempty"`
+
+	// Whether logs are truncated in place after being copied; defaults to true.
+	// Otherwise logs are moved and the job has to reopen them.
+	CopyTruncate *bool `json:"copytruncate,omitempty"`
+}
+
+func (p Policy) RotateCount() int {
+	if p.Rotate == nil {
+		return defaultRotate
+	}
+	return *p.Rotate
+}
+
+func (p Policy) IsCompressed() bool {
+	return p.Compress == nil || *p.Compress
+}
+
+func (p Policy) IsCopyTruncated() bool {
+	return p.CopyTruncate == nil || *p.CopyTruncate
+}
@@ -96,6 +96,10 @@ func (net centosNetManager) SetupNetworking(networks boshsettings.Networks, mbus
 		return err
 	}
 
+	// Policy routing is only configured for static interfaces;
+	// DHCP interfaces keep using the main routing table.
+	StaticInterfaceConfigurations(staticConfigs).AssignRoutingTables()
+
 	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
 	dnsServers := dnsNetwork.DNS
 
@@ -186,6 +190,28 @@ PEERDNS=no{{ range .DNSServers }}
 DNS{{ .Index }}={{ .Address }}{{ end }}
 `
 
+// Policy routing files use iproute2 syntax and are applied by ifup-routes
+const centosRouteTemplate = `{{ .Subnet }} dev {{ .Name }} scope link table {{ .RoutingTable }}
+default via {{ .Gateway }} dev {{ .Name }} table {{ .RoutingTable }}
+{{ range .Routes }}{{ .Destination }} via {{ .Gateway }} dev {{ $.Name }} table {{ $.RoutingTable }}
+{{ end }}`
+
+const centosRuleTemplate = `from {{ .HostCIDR }} table {{ .RoutingTable }}
+`
+
+type centosRouteConfig struct {
+	StaticInterfaceConfiguration
+	Subnet string
+
+	// Routes of the network with destinations in CIDR notation
+	Routes []centosRoute
+}
+
+type centosRoute struct {
+	Destination string
+	Gateway     string
+}
+
 type centosStaticIfcfg struct {
 	*StaticInterfaceConfiguration
 	DNSServers []dnsConfig
@@ -208,7 +234,19 @@ func interfaceConfigurationFileCentos(name string) string {
 	return path.Join("/etc/sysconfig/network-scripts", "ifcfg-"+name)
 }
 
+func routeConfigurationFileCentos(config StaticInterfaceConfiguration) string {
+	return path.Join("/etc/sysconfig/network-scripts", "route"+config.Version6()+"-"+config.Name)
+}
+
+func ruleConfigurationFileCentos(config StaticInterfaceConfiguration) string {
+	return path.Join("/etc/sysconfig/network-scripts", "rule"+config.Version6()+"-"+config.Name)
+}
+
 func (net centosNetManager) writeIfcfgFile(name string, t *template.Template, config interface{}) (bool, error) {
+	return net.writeNetworkScript(interfaceConfigurationFileCentos(name), name, t, config)
+}
+
+func (net centosNetManager) writeNetworkScript(filePath, name string, t *template.Template, config interface{}) (bool, error) {
 	buffer := bytes.NewBuffer([]byte{})
 
 	err := t.Execute(buffer, config)
@@ -216,7 +254,6 @@ func (net centosNetManager) writeIfcfgFile(name string, t *template.Template, co
 		return false, bosherr.WrapErrorf(err, "Generating '%s' config from template", name)
 	}
 
-	filePath := interfaceConfigurationFileCentos(name)
 	changed, err := net.fs.ConvergeFileContents(filePath, buffer.Bytes())
 	if err != nil {
 		return false, bosherr.WrapErrorf(err, "Writing config to '%s'", filePath)
@@ -241,6 +278,13 @@ func (net centosNetManager) writeNetworkInterfaces(dhcpConfigs []DHCPInterfaceCo
 		}
 
 		anyInterfaceChanged = anyInterfaceChanged || changed
+
+		changed, err = net.writePolicyRouting(staticConfigs[i])
+		if err != nil {
+			return false, bosherr.WrapError(err, "Writing policy routing config")
+		}
+
+		anyInterfaceChanged = anyInterfaceChanged || changed
 	}
 
 	dhcpTemplate := template.Must(template.New("ifcfg").Parse(centosDHCPIfcfgTemplate))
@@ -259,6 +303,62 @@ func (net centosNetManager) writeNetworkInterfaces(dhcpConfigs []DHCPInterfaceCo
 	return anyInterfaceChanged, nil
 }
 
+// writePolicyRouting writes route-<iface> and rule-<iface> files so that traffic
+// originating from interface address uses interface's own routing table.
+// Files left over from a previous multi-homed configuration are removed.
+func (net centosNetManager) writePolicyRouting(config StaticInterfaceConfiguration) (bool, error) {
+	routePath := routeConfigurationFileCentos(config)
+	rulePath := ruleConfigurationFileCentos(config)
+
+	if config.RoutingTable == 0 {
+		changed := false
+		for _, filePath := range []string{routePath, rulePath} {
+			if !net.fs.FileExists(filePath) {
+				continue
+			}
+			err := net.fs.RemoveAll(filePath)
+			if err != nil {
+				return false, bosherr.WrapErrorf(err, "Removing '%s'", filePath)
+			}
+			changed = true
+		}
+		return changed, nil
+	}
+
+	subnet, err := config.SubnetCIDR()
+	if err != nil {
+		return false, err
+	}
+
+	routeConfig := centosRouteConfig{StaticInterfaceConfiguration: config, Subnet: subnet}
+
+	for _, postUpRoute := range config.PostUpRoutes {
+		cidr, err := boshsettings.NetmaskToCIDR(postUpRoute.Netmask, config.IsVersion6())
+		if err != nil {
+			return false, err
+		}
+
+		routeConfig.Routes = append(routeConfig.Routes, centosRoute{
+			Destination: postUpRoute.Destination + "/" + cidr,
+			Gateway:     postUpRoute.Gateway,
+		})
+	}
+
+	routeTemplate := template.Must(template.New("route").Parse(centosRouteTemplate))
+	routeChanged, err := net.writeNetworkScript(routePath, config.Name, routeTemplate, routeConfig)
+	if err != nil {
+		return false, err
+	}
+
+	ruleTemplate := template.Must(template.New("rule").Parse(centosRuleTemplate))
+	ruleChanged, err := net.writeNetworkScript(rulePath, config.Name, ruleTemplate, config)
+	if err != nil {
+		return false, err
+	}
+
+	return routeChanged || ruleChanged, nil
+}
+
 func (net centosNetManager) buildInterfaces(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
 	interfacesByMacAddress, err := net.macAddressDetector.DetectMacAddresses()
 	if err != nil {
@@ -719,6 +719,100 @@ DNS1=8.8.8.8
 
 		})
 
+		Context("when VM is attached to multiple networks with gateways", func() {
+			var secondStaticNetwork boshsettings.Network
+
+			BeforeEach(func() {
+				secondStaticNetwork = boshsettings.Network{
+					Type:    "manual",
+					IP:      "5.6.7.8",
+					Netmask: "255.255.255.0",
+					Gateway: "5.6.7.1",
+					Mac:     "second-fake-static-mac-address",
+					Default: []string{"gateway", "dns"},
+					Routes: boshsettings.Routes{
+						{Destination: "10.0.0.0", Netmask: "255.0.0.0", Gateway: "5.6.7.254"},
+					},
+				}
+
+				stubInterfaces(map[string]boshsettings.Network{
+					"eth0":    staticNetwork,
+					"eth1":    secondStaticNetwork,
+					"ethdhcp": dhcpNetwork,
+				})
+
+				interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+					boship.NewSimpleInterfaceAddress("eth0", "1.2.3.4"),
+					boship.NewSimpleInterfaceAddress("eth1", "5.6.7.8"),
+				}
+			})
+
+			It("writes route and rule files for each static interface", func() {
+				err := netManager.SetupNetworking(boshsettings.Networks{
+					"static-1": staticNetwork,
+					"static-2": secondStaticNetwork,
+					"dhcp":     dhcpNetwork,
+				}, "", nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				routeConfig0 := fs.GetFileTestStat("/etc/sysconfig/network-scripts/route-eth0")
+				Expect(routeConfig0).ToNot(BeNil())
+				Expect(routeConfig0.StringContents()).To(Equal(`1.2.3.0/24 dev eth0 scope link table 101
+default via 3.4.5.6 dev eth0 table 101
+`))
+				ruleConfig0 := fs.GetFileTestStat("/etc/sysconfig/network-scripts/rule-eth0")
+				Expect(ruleConfig0).ToNot(BeNil())
+				Expect(ruleConfig0.StringContents()).To(Equal("from 1.2.3.4/32 table 101\n"))
+
+				routeConfig1 := fs.GetFileTestStat("/etc/sysconfig/network-scripts/route-eth1")
+				Expect(routeConfig1).ToNot(BeNil())
+				Expect(routeConfig1.StringContents()).To(Equal(`5.6.7.0/24 dev eth1 scope link table 102
+default via 5.6.7.1 dev eth1 table 102
+10.0.0.0/8 via 5.6.7.254 dev eth1 table 102
+`))
+				ruleConfig1 := fs.GetFileTestStat("/etc/sysconfig/network-scripts/rule-eth1")
+				Expect(ruleConfig1).ToNot(BeNil())
+				Expect(ruleConfig1.StringContents()).To(Equal("from 5.6.7.8/32 table 102\n"))
+
+				Expect(fs.FileExists("/etc/sysconfig/network-scripts/route-ethdhcp")).To(BeFalse())
+				Expect(fs.FileExists("/etc/sysconfig/network-scripts/rule-ethdhcp")).To(BeFalse())
+			})
+
+			It("removes route and rule files when VM is no longer multi-homed", func() {
+				err := netManager.SetupNetworking(boshsettings.Networks{
+					"static-1": staticNetwork,
+					"static-2": secondStaticNetwork,
+				}, "", nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fs.FileExists("/etc/sysconfig/network-scripts/route-eth0")).To(BeTrue())
+
+				cmdRunner = fakesys.NewFakeCmdRunner()
+				netManager = NewCentosNetManager(
+					fs,
+					cmdRunner,
+					ipResolver,
+					fakeMACAddressDetector,
+					interfaceConfigurationCreator,
+					interfaceAddrsProvider,
+					NewDNSValidator(fs),
+					addressBroadcaster,
+					boshlog.NewLogger(boshlog.LevelNone),
+				)
+				interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+					boship.NewSimpleInterfaceAddress("eth0", "1.2.3.4"),
+				}
+
+				err = netManager.SetupNetworking(boshsettings.Networks{
+					"static-1": staticNetwork,
+				}, "", nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.FileExists("/etc/sysconfig/network-scripts/route-eth0")).To(BeFalse())
+				Expect(fs.FileExists("/etc/sysconfig/network-scripts/rule-eth0")).To(BeFalse())
+				Expect(cmdRunner.RunCommands).To(Equal([][]string{{"service", "network", "restart"}}))
+			})
+		})
+
 	})
 
 	Describe("GetConfiguredNetworkInterfaces", func() {
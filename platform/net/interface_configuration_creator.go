@@ -2,6 +2,8 @@ package net
 
 import (
 	"net"
+	"sort"
+	"strings"
 
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -25,8 +27,17 @@ type StaticInterfaceConfiguration struct {
 	Gateway             string
 	PostUpRoutes        boshsettings.Routes
 	VirtualInterfaces   []VirtualInterface
+	Bond                *boshsettings.NetworkBond
+	VLAN                *boshsettings.NetworkVLAN
+	Bridge              *boshsettings.NetworkBridge
+
+	// RoutingTable is set when traffic from Address should be routed
+	// through Gateway using its own routing table and source based rule
+	RoutingTable int
 }
 
+const policyRoutingTableOffset = 100
+
 func (c StaticInterfaceConfiguration) Version6() string {
 	if c.IsVersion6() {
 		return "6"
@@ -42,6 +53,29 @@ func (c StaticInterfaceConfiguration) CIDR() (string, error) {
 	return boshsettings.NetmaskToCIDR(c.Netmask, c.IsVersion6())
 }
 
+// SubnetCIDR returns the subnet of the interface address, e.g. 10.0.0.0/24
+func (c StaticInterfaceConfiguration) SubnetCIDR() (string, error) {
+	cidr, err := c.CIDR()
+	if err != nil {
+		return "", err
+	}
+
+	_, subnet, err := net.ParseCIDR(c.Address + "/" + cidr)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing address '%s'", c.Address)
+	}
+
+	return subnet.String(), nil
+}
+
+// HostCIDR returns the interface address as a single host prefix, e.g. 10.0.0.5/32
+func (c StaticInterfaceConfiguration) HostCIDR() string {
+	if c.IsVersion6() {
+		return c.Address + "/128"
+	}
+	return c.Address + "/32"
+}
+
 type StaticInterfaceConfigurations []StaticInterfaceConfiguration
 
 func (configs StaticInterfaceConfigurations) Len() int {
@@ -56,6 +90,37 @@ func (configs StaticInterfaceConfigurations) Swap(i, j int) {
 	configs[i], configs[j] = configs[j], configs[i]
 }
 
+// AssignRoutingTables gives every interface with a gateway its own routing table
+// when VM is attached to more than one such network of the same IP version
+// (multi-homed), so that replies leave through the interface on which requests arrived.
+// Virtual interfaces share routing with their device and are skipped.
+// Only static interfaces are considered: DHCP interfaces get their routes
+// from the DHCP server and keep using the main routing table.
+func (configs StaticInterfaceConfigurations) AssignRoutingTables() {
+	indexes := []int{}
+	gatewaysByVersion := map[bool]int{}
+
+	for i, config := range configs {
+		if config.Gateway != "" && !strings.Contains(config.Name, ":") {
+			indexes = append(indexes, i)
+			gatewaysByVersion[config.IsVersion6()]++
+		}
+	}
+
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return configs[indexes[i]].Name < configs[indexes[j]].Name
+	})
+
+	table := policyRoutingTableOffset
+	for _, i := range indexes {
+		if gatewaysByVersion[configs[i].IsVersion6()] < 2 {
+			continue
+		}
+		table++
+		configs[i].RoutingTable = table
+	}
+}
+
 func (configs StaticInterfaceConfigurations) HasVersion6() bool {
 	for _, config := range configs {
 		if config.IsVersion6() {
@@ -69,6 +134,9 @@ type DHCPInterfaceConfiguration struct {
 	Name         string
 	PostUpRoutes boshsettings.Routes
 	Address      string
+	Bond         *boshsettings.NetworkBond
+	VLAN         *boshsettings.NetworkVLAN
+	Bridge       *boshsettings.NetworkBridge
 }
 
 func (c DHCPInterfaceConfiguration) Version6() string {
@@ -194,6 +262,9 @@ func (creator interfaceConfigurationCreator) createInterfaceConfiguration(static
 			Name:         ifaceName,
 			PostUpRoutes: networkSettings.Routes,
 			Address:      networkSettings.IP,
+			Bond:         networkSettings.Bond,
+			VLAN:         networkSettings.VLAN,
+			Bridge:       networkSettings.Bridge,
 		})
 	} else {
 		creator.logger.Debug(creator.logTag, "Using static networking")
@@ -212,6 +283,9 @@ func (creator interfaceConfigurationCreator) createInterfaceConfiguration(static
 			Mac:                 networkSettings.Mac,
 			Gateway:             networkSettings.Gateway,
 			PostUpRoutes:        networkSettings.Routes,
+			Bond:                networkSettings.Bond,
+			VLAN:                networkSettings.VLAN,
+			Bridge:              networkSettings.Bridge,
 		})
 	}
 	return staticConfigs, dhcpConfigs, nil
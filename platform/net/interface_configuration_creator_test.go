@@ -513,9 +513,62 @@ var _ = Describe("StaticInterfaceConfiguration", func() {
 			Expect(StaticInterfaceConfiguration{Netmask: "255.0.0.0", Broadcast: "broadcast"}.CIDR()).To(Equal("8"))
 		})
 	})
+
+	Describe("SubnetCIDR", func() {
+		It("returns subnet of IPv4 address", func() {
+			config := StaticInterfaceConfiguration{Address: "10.0.1.5", Netmask: "255.255.254.0", Network: "10.0.0.0"}
+			Expect(config.SubnetCIDR()).To(Equal("10.0.0.0/23"))
+		})
+
+		It("returns subnet of IPv6 address", func() {
+			config := StaticInterfaceConfiguration{Address: "2601:646:100:e8e8::103", Netmask: "ffff:ffff:ffff:ffff::"}
+			Expect(config.SubnetCIDR()).To(Equal("2601:646:100:e8e8::/64"))
+		})
+
+		It("returns an error when address is invalid", func() {
+			_, err := StaticInterfaceConfiguration{Address: "invalid", Netmask: "255.255.255.0", Network: "network"}.SubnetCIDR()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing address 'invalid'"))
+		})
+	})
+
+	Describe("HostCIDR", func() {
+		It("returns address with single host prefix", func() {
+			Expect(StaticInterfaceConfiguration{Address: "10.0.0.5", Network: "10.0.0.0"}.HostCIDR()).To(Equal("10.0.0.5/32"))
+			Expect(StaticInterfaceConfiguration{Address: "ff00::1"}.HostCIDR()).To(Equal("ff00::1/128"))
+		})
+	})
 })
 
 var _ = Describe("StaticInterfaceConfigurations", func() {
+	Describe("AssignRoutingTables", func() {
+		It("assigns routing table to every interface with gateway when there are multiple gateways", func() {
+			configs := StaticInterfaceConfigurations{
+				{Name: "eth1", Gateway: "10.1.0.1", Network: "10.1.0.0"},
+				{Name: "eth0", Gateway: "10.0.0.1", Network: "10.0.0.0"},
+				{Name: "eth0:1", Gateway: "10.0.0.1", Network: "10.0.0.0"},
+				{Name: "eth2", Network: "10.2.0.0"},
+			}
+			configs.AssignRoutingTables()
+
+			Expect(configs[0].RoutingTable).To(Equal(102))
+			Expect(configs[1].RoutingTable).To(Equal(101))
+			Expect(configs[2].RoutingTable).To(Equal(0))
+			Expect(configs[3].RoutingTable).To(Equal(0))
+		})
+
+		It("does not assign routing tables when there is a single gateway per IP version", func() {
+			configs := StaticInterfaceConfigurations{
+				{Name: "eth0", Gateway: "10.0.0.1", Network: "10.0.0.0"},
+				{Name: "eth1", Gateway: "ff00::1"},
+			}
+			configs.AssignRoutingTables()
+
+			Expect(configs[0].RoutingTable).To(Equal(0))
+			Expect(configs[1].RoutingTable).To(Equal(0))
+		})
+	})
+
 	Describe("HasVersion6", func() {
 		It("returns true if there is at least one IPv6 static config", func() {
 			Expect(StaticInterfaceConfigurations{}.HasVersion6()).To(BeFalse())
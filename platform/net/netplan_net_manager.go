@@ -0,0 +1,592 @@
+package net
+
+import (
+	"bytes"
+	"fmt"
+	gonet "net"
+	gonetURL "net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	NetplanNetManagerLogTag = "NetplanNetManager"
+
+	netplanConfigPath = "/etc/netplan/50-bosh.yaml"
+
+	mbusConnectivityAttempts = 5
+	mbusConnectivityTimeout  = 5 * time.Second
+)
+
+// Dialer is used to verify that mbus is still reachable after networking is reconfigured
+type Dialer func(network, address string, timeout time.Duration) (gonet.Conn, error)
+
+type NetplanNetManager struct {
+	cmdRunner                     boshsys.CmdRunner
+	fs                            boshsys.FileSystem
+	ipResolver                    boship.Resolver
+	macAddressDetector            MACAddressDetector
+	interfaceConfigurationCreator InterfaceConfigurationCreator
+	interfaceAddrsProvider        boship.InterfaceAddressesProvider
+	addressBroadcaster            bosharp.AddressBroadcaster
+	kernelIPv6                    KernelIPv6
+	dialer                        Dialer
+	retryDelay                    time.Duration
+	logger                        boshlog.Logger
+}
+
+type netplanConfig struct {
+	Network netplanNetwork `yaml:"network"`
+}
+
+type netplanNetwork struct {
+	Version   int                       `yaml:"version"`
+	Renderer  string                    `yaml:"renderer"`
+	Ethernets map[string]*netplanDevice `yaml:"ethernets,omitempty"`
+	Bonds     map[string]*netplanDevice `yaml:"bonds,omitempty"`
+	VLANs     map[string]*netplanDevice `yaml:"vlans,omitempty"`
+	Bridges   map[string]*netplanDevice `yaml:"bridges,omitempty"`
+}
+
+type netplanDevice struct {
+	DHCP4       bool                   `yaml:"dhcp4,omitempty"`
+	DHCP6       bool                   `yaml:"dhcp6,omitempty"`
+	AcceptRA    bool                   `yaml:"accept-ra,omitempty"`
+	Addresses   []string               `yaml:"addresses,omitempty"`
+	Routes      []netplanRoute         `yaml:"routes,omitempty"`
+	Policy      []netplanRoutingPolicy `yaml:"routing-policy,omitempty"`
+	Nameservers *netplanNameservers    `yaml:"nameservers,omitempty"`
+	Interfaces  []string               `yaml:"interfaces,omitempty"`
+	ID          int                    `yaml:"id,omitempty"`
+	Link        string                 `yaml:"link,omitempty"`
+	Parameters  *netplanBondParameters `yaml:"parameters,omitempty"`
+}
+
+type netplanRoute struct {
+	To    string `yaml:"to"`
+	Via   string `yaml:"via,omitempty"`
+	Scope string `yaml:"scope,omitempty"`
+	Table int    `yaml:"table,omitempty"`
+}
+
+type netplanRoutingPolicy struct {
+	From  string `yaml:"from"`
+	Table int    `yaml:"table"`
+}
+
+type netplanNameservers struct {
+	Addresses []string `yaml:"addresses"`
+}
+
+type netplanBondParameters struct {
+	Mode string `yaml:"mode"`
+}
+
+// NewNetplanNetManager configures networking by rendering netplan configuration.
+// Unlike other managers it supports bonds, VLANs and bridges.
+func NewNetplanNetManager(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	ipResolver boship.Resolver,
+	macAddressDetector MACAddressDetector,
+	interfaceConfigurationCreator InterfaceConfigurationCreator,
+	interfaceAddrsProvider boship.InterfaceAddressesProvider,
+	addressBroadcaster bosharp.AddressBroadcaster,
+	kernelIPv6 KernelIPv6,
+	dialer Dialer,
+	retryDelay time.Duration,
+	logger boshlog.Logger,
+) Manager {
+	return NetplanNetManager{
+		cmdRunner:                     cmdRunner,
+		fs:                            fs,
+		ipResolver:                    ipResolver,
+		macAddressDetector:            macAddressDetector,
+		interfaceConfigurationCreator: interfaceConfigurationCreator,
+		interfaceAddrsProvider:        interfaceAddrsProvider,
+		addressBroadcaster:            addressBroadcaster,
+		kernelIPv6:                    kernelIPv6,
+		dialer:                        dialer,
+		retryDelay:                    retryDelay,
+		logger:                        logger,
+	}
+}
+
+func (net NetplanNetManager) GetConfiguredNetworkInterfaces() ([]string, error) {
+	interfacesByMacAddress, err := net.macAddressDetector.DetectMacAddresses()
+	if err != nil {
+		return []string{}, bosherr.WrapError(err, "Getting network interfaces")
+	}
+
+	interfaces := []string{}
+
+	if !net.fs.FileExists(netplanConfigPath) {
+		return interfaces, nil
+	}
+
+	contents, err := net.fs.ReadFile(netplanConfigPath)
+	if err != nil {
+		return []string{}, bosherr.WrapErrorf(err, "Reading %s", netplanConfigPath)
+	}
+
+	var config netplanConfig
+
+	err = yaml.Unmarshal(contents, &config)
+	if err != nil {
+		return []string{}, bosherr.WrapErrorf(err, "Unmarshalling %s", netplanConfigPath)
+	}
+
+	for _, iface := range interfacesByMacAddress {
+		if _, found := config.Network.Ethernets[iface]; found {
+			interfaces = append(interfaces, iface)
+		}
+	}
+
+	return interfaces, nil
+}
+
+func (net NetplanNetManager) SetupIPv6(config boshsettings.IPv6, stopCh <-chan struct{}) error {
+	if config.Enable {
+		return net.kernelIPv6.Enable(stopCh)
+	}
+	return nil
+}
+
+func (net NetplanNetManager) SetupNetworking(networks boshsettings.Networks, mbus string, errCh chan error) error {
+	if networks.IsPreconfigured() {
+		err := SetupNatsFirewall(mbus)
+		if err != nil {
+			return bosherr.WrapError(err, "Setting up Nats Firewall")
+		}
+		net.logger.Info(NetplanNetManagerLogTag, "Successfully set up outgoing nats api firewall")
+		return nil
+	}
+
+	interfacesByMacAddress, err := net.macAddressDetector.DetectMacAddresses()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting network interfaces")
+	}
+
+	staticConfigs, dhcpConfigs, dnsServers, err := net.computeNetworkConfig(networks, interfacesByMacAddress)
+	if err != nil {
+		return bosherr.WrapError(err, "Computing network configuration")
+	}
+
+	if StaticInterfaceConfigurations(staticConfigs).HasVersion6() {
+		err := net.kernelIPv6.Enable(make(chan struct{}))
+		if err != nil {
+			return bosherr.WrapError(err, "Enabling IPv6 in kernel")
+		}
+	}
+
+	config, err := net.buildNetplanConfig(staticConfigs, dhcpConfigs, dnsServers, interfacesByMacAddress)
+	if err != nil {
+		return bosherr.WrapError(err, "Building netplan configuration")
+	}
+
+	buffer := bytes.NewBufferString("# Generated by bosh-agent\n")
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+
+	err = encoder.Encode(config)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling netplan configuration")
+	}
+
+	previousContents, err := net.readPreviousConfig()
+	if err != nil {
+		return err
+	}
+
+	changed, err := net.fs.ConvergeFileContents(netplanConfigPath, buffer.Bytes())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", netplanConfigPath)
+	}
+
+	staticAddresses, dynamicAddresses := net.ifaceAddresses(config)
+
+	if changed {
+		err = net.applyConfig(staticAddresses, mbus)
+		if err != nil {
+			rollbackErr := net.rollback(previousContents)
+			if rollbackErr != nil {
+				net.logger.Error(NetplanNetManagerLogTag, "Failed to roll back network configuration: %s", rollbackErr)
+			}
+			return err
+		}
+	}
+
+	err = SetupNatsFirewall(mbus)
+	if err != nil {
+		return bosherr.WrapError(err, "Setting up nats firewall")
+	}
+	net.logger.Info(NetplanNetManagerLogTag, "Successfully set up outgoing nats api firewall")
+
+	go net.addressBroadcaster.BroadcastMACAddresses(append(staticAddresses, dynamicAddresses...))
+
+	return nil
+}
+
+func (net NetplanNetManager) computeNetworkConfig(networks boshsettings.Networks, interfacesByMacAddress map[string]string) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, []string, error) {
+	nonVipNetworks := boshsettings.Networks{}
+	for networkName, networkSettings := range networks {
+		if networkSettings.IsVIP() {
+			continue
+		}
+		nonVipNetworks[networkName] = networkSettings
+	}
+
+	staticConfigs, dhcpConfigs, err := net.interfaceConfigurationCreator.CreateInterfaceConfigurations(nonVipNetworks, interfacesByMacAddress)
+	if err != nil {
+		return nil, nil, nil, bosherr.WrapError(err, "Creating interface configurations")
+	}
+
+	StaticInterfaceConfigurations(staticConfigs).AssignRoutingTables()
+
+	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
+	return staticConfigs, dhcpConfigs, dnsNetwork.DNS, nil
+}
+
+func (net NetplanNetManager) buildNetplanConfig(
+	staticConfigs StaticInterfaceConfigurations,
+	dhcpConfigs DHCPInterfaceConfigurations,
+	dnsServers []string,
+	interfacesByMacAddress map[string]string,
+) (netplanConfig, error) {
+	sort.Stable(staticConfigs)
+	sort.Stable(dhcpConfigs)
+
+	network := netplanNetwork{
+		Version:   2,
+		Renderer:  "networkd",
+		Ethernets: map[string]*netplanDevice{},
+		Bonds:     map[string]*netplanDevice{},
+		VLANs:     map[string]*netplanDevice{},
+		Bridges:   map[string]*netplanDevice{},
+	}
+
+	// Interfaces enslaved to bonds must not be configured on their own
+	bondMembers := map[string]bool{}
+	for _, config := range staticConfigs {
+		for _, member := range net.bondMembers(config.Bond, interfacesByMacAddress) {
+			bondMembers[member] = true
+		}
+	}
+	for _, config := range dhcpConfigs {
+		for _, member := range net.bondMembers(config.Bond, interfacesByMacAddress) {
+			bondMembers[member] = true
+		}
+	}
+
+	var nameservers *netplanNameservers
+	if len(dnsServers) > 0 {
+		nameservers = &netplanNameservers{Addresses: dnsServers}
+	}
+
+	for _, config := range dhcpConfigs {
+		if bondMembers[config.Name] {
+			continue
+		}
+
+		device, err := net.layerDevices(&network, config.Name, config.Bond, config.VLAN, config.Bridge, interfacesByMacAddress)
+		if err != nil {
+			return netplanConfig{}, err
+		}
+
+		if config.IsVersion6() {
+			device.DHCP6 = true
+			device.AcceptRA = true
+		} else {
+			device.DHCP4 = true
+		}
+
+		device.Nameservers = nameservers
+
+		routes, err := netplanRoutes(config.PostUpRoutes, config.IsVersion6())
+		if err != nil {
+			return netplanConfig{}, err
+		}
+		device.Routes = append(device.Routes, routes...)
+	}
+
+	for _, config := range staticConfigs {
+		// Virtual interfaces (e.g. eth0:1) become additional addresses of their device
+		name := strings.Split(config.Name, ":")[0]
+		if bondMembers[name] {
+			continue
+		}
+
+		device, err := net.layerDevices(&network, name, config.Bond, config.VLAN, config.Bridge, interfacesByMacAddress)
+		if err != nil {
+			return netplanConfig{}, err
+		}
+
+		cidr, err := config.CIDR()
+		if err != nil {
+			return netplanConfig{}, err
+		}
+
+		device.Addresses = append(device.Addresses, fmt.Sprintf("%s/%s", config.Address, cidr))
+		device.Nameservers = nameservers
+
+		if config.IsVersion6() {
+			device.AcceptRA = true
+		}
+
+		if config.IsDefaultForGateway {
+			device.Routes = append(device.Routes, netplanRoute{To: "default", Via: config.Gateway})
+		}
+
+		routes, err := netplanRoutes(config.PostUpRoutes, config.IsVersion6())
+		if err != nil {
+			return netplanConfig{}, err
+		}
+		device.Routes = append(device.Routes, routes...)
+
+		if config.RoutingTable != 0 {
+			err = net.addPolicyRouting(device, config, routes)
+			if err != nil {
+				return netplanConfig{}, err
+			}
+		}
+	}
+
+	return netplanConfig{Network: network}, nil
+}
+
+// addPolicyRouting duplicates interface routes into its own routing table
+// and adds a rule so that traffic originating from interface address uses that table
+func (net NetplanNetManager) addPolicyRouting(device *netplanDevice, config StaticInterfaceConfiguration, routes []netplanRoute) error {
+	subnet, err := config.SubnetCIDR()
+	if err != nil {
+		return err
+	}
+
+	device.Routes = append(device.Routes,
+		netplanRoute{To: subnet, Scope: "link", Table: config.RoutingTable},
+		netplanRoute{To: "default", Via: config.Gateway, Table: config.RoutingTable},
+	)
+
+	for _, route := range routes {
+		route.Table = config.RoutingTable
+		device.Routes = append(device.Routes, route)
+	}
+
+	device.Policy = append(device.Policy, netplanRoutingPolicy{From: config.HostCIDR(), Table: config.RoutingTable})
+
+	return nil
+}
+
+// layerDevices defines interface with bond, VLAN and bridge on top of it
+// and returns the topmost device which should carry the addresses
+func (net NetplanNetManager) layerDevices(
+	network *netplanNetwork,
+	ifaceName string,
+	bond *boshsettings.NetworkBond,
+	vlan *boshsettings.NetworkVLAN,
+	bridge *boshsettings.NetworkBridge,
+	interfacesByMacAddress map[string]string,
+) (*netplanDevice, error) {
+	carrierName := ifaceName
+	carrier := netplanDeviceFor(network.Ethernets, ifaceName)
+
+	if bond != nil {
+		if bond.Name == "" {
+			return nil, bosherr.Errorf("Bond on interface '%s' must have a name", ifaceName)
+		}
+
+		members := net.bondMembers(bond, interfacesByMacAddress)
+		for _, member := range members {
+			netplanDeviceFor(network.Ethernets, member)
+		}
+
+		carrierName = bond.Name
+		carrier = netplanDeviceFor(network.Bonds, bond.Name)
+		carrier.Interfaces = append([]string{ifaceName}, members...)
+
+		if bond.Mode != "" {
+			carrier.Parameters = &netplanBondParameters{Mode: bond.Mode}
+		}
+	}
+
+	if vlan != nil {
+		if vlan.ID < 1 || vlan.ID > 4094 {
+			return nil, bosherr.Errorf("VLAN ID '%d' on interface '%s' must be between 1 and 4094", vlan.ID, ifaceName)
+		}
+
+		vlanName := fmt.Sprintf("%s.%d", carrierName, vlan.ID)
+		vlanDevice := netplanDeviceFor(network.VLANs, vlanName)
+		vlanDevice.ID = vlan.ID
+		vlanDevice.Link = carrierName
+
+		carrierName = vlanName
+		carrier = vlanDevice
+	}
+
+	if bridge != nil {
+		if bridge.Name == "" {
+			return nil, bosherr.Errorf("Bridge on interface '%s' must have a name", ifaceName)
+		}
+
+		bridgeDevice := netplanDeviceFor(network.Bridges, bridge.Name)
+		bridgeDevice.Interfaces = append(bridgeDevice.Interfaces, carrierName)
+
+		carrier = bridgeDevice
+	}
+
+	return carrier, nil
+}
+
+func (net NetplanNetManager) bondMembers(bond *boshsettings.NetworkBond, interfacesByMacAddress map[string]string) []string {
+	if bond == nil {
+		return nil
+	}
+
+	members := []string{}
+	for _, mac := range bond.Interfaces {
+		if iface, found := interfacesByMacAddress[mac]; found {
+			members = append(members, iface)
+		} else {
+			net.logger.Warn(NetplanNetManagerLogTag, "No device found for bond '%s' member with MAC address '%s'", bond.Name, mac)
+		}
+	}
+
+	return members
+}
+
+func (net NetplanNetManager) readPreviousConfig() ([]byte, error) {
+	if !net.fs.FileExists(netplanConfigPath) {
+		return nil, nil
+	}
+
+	contents, err := net.fs.ReadFile(netplanConfigPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading %s", netplanConfigPath)
+	}
+
+	return contents, nil
+}
+
+func (net NetplanNetManager) applyConfig(staticAddresses []boship.InterfaceAddress, mbus string) error {
+	_, _, _, err := net.cmdRunner.RunCommand("netplan", "generate")
+	if err != nil {
+		return bosherr.WrapError(err, "Validating netplan configuration")
+	}
+
+	_, _, _, err = net.cmdRunner.RunCommand("netplan", "apply")
+	if err != nil {
+		return bosherr.WrapError(err, "Applying netplan configuration")
+	}
+
+	interfaceAddressesValidator := boship.NewInterfaceAddressesValidator(net.interfaceAddrsProvider, staticAddresses)
+	retryIPValidator := boshretry.NewAttemptRetryStrategy(10, net.retryDelay, interfaceAddressesValidator, net.logger)
+
+	err = retryIPValidator.Try()
+	if err != nil {
+		return bosherr.WrapError(err, "Validating static network configuration")
+	}
+
+	err = net.checkMbusConnectivity(mbus)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking connectivity to mbus")
+	}
+
+	return nil
+}
+
+func (net NetplanNetManager) rollback(previousContents []byte) error {
+	net.logger.Warn(NetplanNetManagerLogTag, "Rolling back network configuration")
+
+	var err error
+	if previousContents == nil {
+		err = net.fs.RemoveAll(netplanConfigPath)
+	} else {
+		err = net.fs.WriteFile(netplanConfigPath, previousContents)
+	}
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Restoring %s", netplanConfigPath)
+	}
+
+	_, _, _, err = net.cmdRunner.RunCommand("netplan", "apply")
+	if err != nil {
+		return bosherr.WrapError(err, "Applying previous netplan configuration")
+	}
+
+	return nil
+}
+
+func (net NetplanNetManager) checkMbusConnectivity(mbus string) error {
+	// Agent itself listens on https mbus (create-env) so there is nothing to connect to
+	mbusURL, err := gonetURL.Parse(mbus)
+	if err != nil || mbusURL.Scheme != "nats" || mbusURL.Port() == "" {
+		return nil
+	}
+
+	retryable := boshretry.NewRetryable(func() (bool, error) {
+		conn, err := net.dialer("tcp", mbusURL.Host, mbusConnectivityTimeout)
+		if err != nil {
+			return true, bosherr.WrapErrorf(err, "Connecting to %s", mbusURL.Host)
+		}
+
+		return false, conn.Close()
+	})
+
+	return boshretry.NewAttemptRetryStrategy(mbusConnectivityAttempts, net.retryDelay, retryable, net.logger).Try()
+}
+
+func (net NetplanNetManager) ifaceAddresses(config netplanConfig) ([]boship.InterfaceAddress, []boship.InterfaceAddress) {
+	staticAddresses := []boship.InterfaceAddress{}
+	dynamicAddresses := []boship.InterfaceAddress{}
+
+	for _, devices := range []map[string]*netplanDevice{config.Network.Ethernets, config.Network.Bonds, config.Network.VLANs, config.Network.Bridges} {
+		for name, device := range devices {
+			for _, address := range device.Addresses {
+				staticAddresses = append(staticAddresses, boship.NewSimpleInterfaceAddress(name, strings.Split(address, "/")[0]))
+			}
+
+			if device.DHCP4 || device.DHCP6 {
+				dynamicAddresses = append(dynamicAddresses, boship.NewResolvingInterfaceAddress(name, net.ipResolver))
+			}
+		}
+	}
+
+	return staticAddresses, dynamicAddresses
+}
+
+func netplanDeviceFor(devices map[string]*netplanDevice, name string) *netplanDevice {
+	device, found := devices[name]
+	if !found {
+		device = &netplanDevice{}
+		devices[name] = device
+	}
+	return device
+}
+
+func netplanRoutes(routes boshsettings.Routes, isVersion6 bool) ([]netplanRoute, error) {
+	netplanRoutes := []netplanRoute{}
+
+	for _, route := range routes {
+		cidr, err := boshsettings.NetmaskToCIDR(route.Netmask, isVersion6)
+		if err != nil {
+			return nil, err
+		}
+
+		netplanRoutes = append(netplanRoutes, netplanRoute{
+			To:  fmt.Sprintf("%s/%s", route.Destination, cidr),
+			Via: route.Gateway,
+		})
+	}
+
+	return netplanRoutes, nil
+}
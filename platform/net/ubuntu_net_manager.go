@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -212,6 +213,8 @@ func (net UbuntuNetManager) ComputeNetworkConfig(networks boshsettings.Networks)
 		return nil, nil, nil, err
 	}
 
+	StaticInterfaceConfigurations(staticConfigs).AssignRoutingTables()
+
 	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
 	dnsServers := dnsNetwork.DNS
 	return staticConfigs, dhcpConfigs, dnsServers, nil
@@ -549,6 +552,13 @@ func (net UbuntuNetManager) writeStaticInterfaceConfiguration(config StaticInter
 		file.AppendSection(routeSection)
 	}
 
+	if config.RoutingTable != 0 {
+		err = net.appendPolicyRoutingSections(file, config)
+		if err != nil {
+			return false, err
+		}
+	}
+
 	buffer := bytes.NewBuffer(nil)
 	_, err = file.WriteTo(buffer)
 	if err != nil {
@@ -558,6 +568,48 @@ func (net UbuntuNetManager) writeStaticInterfaceConfiguration(config StaticInter
 	return net.fs.ConvergeFileContents(configPath, buffer.Bytes(), opts)
 }
 
+// appendPolicyRoutingSections duplicates interface routes into its own routing table
+// and adds a rule so that traffic originating from interface address uses that table
+func (net UbuntuNetManager) appendPolicyRoutingSections(file *ini.File, config StaticInterfaceConfiguration) error {
+	table := strconv.Itoa(config.RoutingTable)
+
+	subnet, err := config.SubnetCIDR()
+	if err != nil {
+		return err
+	}
+
+	subnetSection := &ini.Section{Name: "Route"}
+	subnetSection.AddKey("Destination", subnet)
+	subnetSection.AddKey("Scope", "link")
+	subnetSection.AddKey("Table", table)
+	file.AppendSection(subnetSection)
+
+	gatewaySection := &ini.Section{Name: "Route"}
+	gatewaySection.AddKey("Gateway", config.Gateway)
+	gatewaySection.AddKey("Table", table)
+	file.AppendSection(gatewaySection)
+
+	for _, postUpRoute := range config.PostUpRoutes {
+		postUpRouteCidr, err := boshsettings.NetmaskToCIDR(postUpRoute.Netmask, config.IsVersion6())
+		if err != nil {
+			return err
+		}
+
+		routeSection := &ini.Section{Name: "Route"}
+		routeSection.AddKey("Destination", fmt.Sprintf("%s/%s", postUpRoute.Destination, postUpRouteCidr))
+		routeSection.AddKey("Gateway", postUpRoute.Gateway)
+		routeSection.AddKey("Table", table)
+		file.AppendSection(routeSection)
+	}
+
+	ruleSection := &ini.Section{Name: "RoutingPolicyRule"}
+	ruleSection.AddKey("From", config.HostCIDR())
+	ruleSection.AddKey("Table", table)
+	file.AppendSection(ruleSection)
+
+	return nil
+}
+
 func (net UbuntuNetManager) writeDynamicInterfaceConfiguration(config DHCPInterfaceConfiguration, dnsServers []string, opts boshsys.ConvergeFileContentsOpts) (bool, error) {
 	var err error
 	configPath := interfaceConfigurationFile(config.Name)
@@ -208,6 +208,19 @@ IPv6AcceptRA=true
 DNS=8.8.8.8
 DNS=9.9.9.9
 
+[Route]
+Destination=2601:646:100:e8e8::/64
+Scope=link
+Table=101
+
+[Route]
+Gateway=2601:646:100:e8e8::
+Table=101
+
+[RoutingPolicyRule]
+From=2601:646:100:e8e8::103/128
+Table=101
+
 `))
 			networkConfig = fs.GetFileTestStat("/etc/systemd/network/10_ethstatic2.network")
 			Expect(networkConfig).ToNot(BeNil())
@@ -237,6 +250,19 @@ IPv6AcceptRA=true
 DNS=8.8.8.8
 DNS=9.9.9.9
 
+[Route]
+Destination=2601:646:100:eeee::/80
+Scope=link
+Table=102
+
+[Route]
+Gateway=2601:646:100:eeee::
+Table=102
+
+[RoutingPolicyRule]
+From=2601:646:100:eeee::10/128
+Table=102
+
 `))
 		})
 
@@ -488,6 +488,19 @@ Address=1.2.3.4/24
 [Network]
 DNS=8.8.8.8
 
+[Route]
+Destination=1.2.3.0/24
+Scope=link
+Table=101
+
+[Route]
+Gateway=3.4.5.6
+Table=101
+
+[RoutingPolicyRule]
+From=1.2.3.4/32
+Table=101
+
 `))
 			networkConfig = fs.GetFileTestStat("/etc/systemd/network/10_eth1.network")
 			Expect(networkConfig).ToNot(BeNil())
@@ -503,6 +516,19 @@ Broadcast=5.6.7.255
 Gateway=6.7.8.9
 DNS=8.8.8.8
 
+[Route]
+Destination=5.6.7.0/24
+Scope=link
+Table=102
+
+[Route]
+Gateway=6.7.8.9
+Table=102
+
+[RoutingPolicyRule]
+From=5.6.7.8/32
+Table=102
+
 `))
 		})
 
@@ -673,6 +699,29 @@ Gateway=3.4.5.6
 Destination=10.0.1.0/8
 Gateway=3.4.5.6
 
+[Route]
+Destination=1.2.3.0/24
+Scope=link
+Table=101
+
+[Route]
+Gateway=3.4.5.6
+Table=101
+
+[Route]
+Destination=10.0.0.0/8
+Gateway=3.4.5.6
+Table=101
+
+[Route]
+Destination=10.0.1.0/8
+Gateway=3.4.5.6
+Table=101
+
+[RoutingPolicyRule]
+From=1.2.3.4/32
+Table=101
+
 `))
 			networkConfig = fs.GetFileTestStat("/etc/systemd/network/10_eth1.network")
 			Expect(networkConfig).ToNot(BeNil())
@@ -688,6 +737,19 @@ Broadcast=5.6.7.255
 Gateway=6.7.8.9
 DNS=8.8.8.8
 
+[Route]
+Destination=5.6.7.0/24
+Scope=link
+Table=102
+
+[Route]
+Gateway=6.7.8.9
+Table=102
+
+[RoutingPolicyRule]
+From=5.6.7.8/32
+Table=102
+
 `))
 		})
 
@@ -0,0 +1,123 @@
+package ntp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	chronyManagerLogTag = "ChronyManager"
+
+	// Ubuntu keeps configuration in a directory while CentOS uses a single file
+	chronyConfigDir          = "/etc/chrony"
+	chronyConfigPath         = "/etc/chrony/chrony.conf"
+	chronyFallbackConfigPath = "/etc/chrony.conf"
+
+	chronyNotSynchronised = "Not synchronised"
+)
+
+// Clock is stepped rather than slewed when it is off by more than
+// a second during first updates, e.g. after VM was resumed
+const chronyConfigTemplate = `# Generated by bosh-agent
+{{ range . }}server {{ . }} iburst
+{{ end }}
+driftfile /var/lib/chrony/chrony.drift
+makestep 1.0 3
+rtcsync
+`
+
+type chronyManager struct {
+	fs        boshsys.FileSystem
+	cmdRunner boshsys.CmdRunner
+	logger    boshlog.Logger
+}
+
+func NewChronyManager(fs boshsys.FileSystem, cmdRunner boshsys.CmdRunner, logger boshlog.Logger) Manager {
+	return chronyManager{
+		fs:        fs,
+		cmdRunner: cmdRunner,
+		logger:    logger,
+	}
+}
+
+func (m chronyManager) Configure(servers []string) error {
+	if len(servers) == 0 {
+		return nil
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	t := template.Must(template.New("chrony-config").Parse(chronyConfigTemplate))
+
+	err := t.Execute(buffer, servers)
+	if err != nil {
+		return bosherr.WrapError(err, "Generating chrony config")
+	}
+
+	configPath := chronyFallbackConfigPath
+	if m.fs.FileExists(chronyConfigDir) {
+		configPath = chronyConfigPath
+	}
+
+	changed, err := m.fs.ConvergeFileContents(configPath, buffer.Bytes())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", configPath)
+	}
+
+	if !changed {
+		return nil
+	}
+
+	m.logger.Info(chronyManagerLogTag, "Restarting chrony with servers %v", servers)
+
+	// Ubuntu aliases chrony service as chronyd which is its name on CentOS
+	_, _, _, err = m.cmdRunner.RunCommand("systemctl", "restart", "chronyd")
+	if err != nil {
+		return bosherr.WrapError(err, "Restarting chrony")
+	}
+
+	return nil
+}
+
+// Status parses CSV output of 'chronyc tracking', e.g.
+// A9FEA9FE,169.254.169.254,3,1697000000.123,-0.000012345,0.000023,0.000045,-12.345,0.001,0.050,0.0123,0.0012,64.5,Normal
+func (m chronyManager) Status() (Status, error) {
+	stdout, _, _, err := m.cmdRunner.RunCommand("chronyc", "-c", "tracking")
+	if err != nil {
+		return Status{}, bosherr.WrapError(err, "Querying chrony tracking")
+	}
+
+	fields, err := csv.NewReader(strings.NewReader(stdout)).Read()
+	if err != nil {
+		return Status{}, bosherr.WrapError(err, "Parsing chrony tracking")
+	}
+
+	if len(fields) < 14 {
+		return Status{}, bosherr.Errorf("Expected 14 fields in chrony tracking but found %d", len(fields))
+	}
+
+	stratum, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return Status{}, bosherr.WrapErrorf(err, "Parsing chrony stratum '%s'", fields[2])
+	}
+
+	offset, err := strconv.ParseFloat(fields[4], 64)
+	if err != nil {
+		return Status{}, bosherr.WrapErrorf(err, "Parsing chrony system time offset '%s'", fields[4])
+	}
+
+	return Status{
+		Synchronized: fields[13] != chronyNotSynchronised,
+		Server:       fields[1],
+		Stratum:      stratum,
+		Offset:       time.Duration(math.Round(offset * float64(time.Second))),
+	}, nil
+}
@@ -0,0 +1,124 @@
+package ntp_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("chronyManager", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		manager   Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		manager = NewChronyManager(fs, cmdRunner, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("Configure", func() {
+		expectedConfig := `# Generated by bosh-agent
+server 0.pool.ntp.org iburst
+server 169.254.169.254 iburst
+
+driftfile /var/lib/chrony/chrony.drift
+makestep 1.0 3
+rtcsync
+`
+
+		It("writes chrony config and restarts chrony", func() {
+			err := fs.MkdirAll("/etc/chrony", 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = manager.Configure([]string{"0.pool.ntp.org", "169.254.169.254"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.ReadFileString("/etc/chrony/chrony.conf")).To(Equal(expectedConfig))
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"systemctl", "restart", "chronyd"}}))
+		})
+
+		It("writes chrony config to /etc/chrony.conf when there is no chrony config directory", func() {
+			err := manager.Configure([]string{"0.pool.ntp.org", "169.254.169.254"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.ReadFileString("/etc/chrony.conf")).To(Equal(expectedConfig))
+		})
+
+		It("does not restart chrony when config did not change", func() {
+			err := fs.WriteFileString("/etc/chrony.conf", expectedConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = manager.Configure([]string{"0.pool.ntp.org", "169.254.169.254"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("keeps current config when no ntp server provided", func() {
+			err := manager.Configure(nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.FileExists("/etc/chrony.conf")).To(BeFalse())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns error when restarting chrony fails", func() {
+			cmdRunner.AddCmdResult("systemctl restart chronyd", fakesys.FakeCmdResult{Error: errors.New("fake-restart-err")})
+
+			err := manager.Configure([]string{"0.pool.ntp.org"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-restart-err"))
+		})
+	})
+
+	Describe("Status", func() {
+		It("parses chrony tracking", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{
+				Stdout: "A9FEA9FE,169.254.169.254,3,1697000000.123456789,-0.064230000,0.000023456,0.000045678,-12.345,0.001,0.050,0.012345678,0.001234567,64.5,Normal\n",
+			})
+
+			status, err := manager.Status()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(Status{
+				Synchronized: true,
+				Server:       "169.254.169.254",
+				Stratum:      3,
+				Offset:       -64230 * time.Microsecond,
+			}))
+		})
+
+		It("reports clock as not synchronized", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{
+				Stdout: "00000000,,0,0.000000000,0.000000000,0.000000000,0.000000000,0.000,0.000,0.000,0.000000000,0.000000000,0.0,Not synchronised\n",
+			})
+
+			status, err := manager.Status()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Synchronized).To(BeFalse())
+		})
+
+		It("returns error when chronyc fails", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{Error: errors.New("fake-chronyc-err")})
+
+			_, err := manager.Status()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-chronyc-err"))
+		})
+
+		It("returns error when tracking output is incomplete", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{Stdout: "A9FEA9FE,169.254.169.254,3\n"})
+
+			_, err := manager.Status()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected 14 fields in chrony tracking but found 3"))
+		})
+	})
+})
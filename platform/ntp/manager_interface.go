@@ -0,0 +1,29 @@
+package ntp
+
+import (
+	"errors"
+	"time"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Manager
+
+// ErrStatusUnavailable is returned by managers which cannot query clock synchronization state
+var ErrStatusUnavailable = errors.New("Time synchronization status is not available")
+
+// Status describes synchronization of the system clock with NTP servers
+type Status struct {
+	Synchronized bool
+	Server       string
+	Stratum      int
+
+	// Offset is positive when system clock is ahead of NTP time
+	Offset time.Duration
+}
+
+type Manager interface {
+	// Configure points time synchronization daemon at given servers;
+	// current configuration is kept when no servers are given
+	Configure(servers []string) error
+
+	Status() (Status, error)
+}
@@ -0,0 +1,121 @@
+package ntp
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Monitor
+
+const (
+	monitorLogTag = "NTPMonitor"
+
+	DefaultDriftThreshold  = 500 * time.Millisecond
+	DefaultMonitorInterval = time.Minute
+)
+
+// DriftHandler is called once clock offset exceeds the drift threshold;
+// it is not called again until offset gets back within the threshold
+type DriftHandler func(status Status, threshold time.Duration) error
+
+type Monitor interface {
+	// Status returns the most recently queried status, if any
+	Status() (Status, bool)
+
+	// MonitorDrift periodically queries status until handler fails;
+	// it returns right away if status is not available on this system
+	MonitorDrift(handler DriftHandler) error
+}
+
+type monitor struct {
+	manager   Manager
+	threshold time.Duration
+	interval  time.Duration
+	clock     clock.Clock
+	logger    boshlog.Logger
+
+	status    Status
+	hasStatus bool
+	lock      sync.RWMutex
+}
+
+func NewMonitor(manager Manager, threshold, interval time.Duration, clock clock.Clock, logger boshlog.Logger) Monitor {
+	if threshold <= 0 {
+		threshold = DefaultDriftThreshold
+	}
+
+	return &monitor{
+		manager:   manager,
+		threshold: threshold,
+		interval:  interval,
+		clock:     clock,
+		logger:    logger,
+	}
+}
+
+func (m *monitor) Status() (Status, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.status, m.hasStatus
+}
+
+func (m *monitor) MonitorDrift(handler DriftHandler) error {
+	drifting := false
+
+	for {
+		status, err := m.manager.Status()
+		if err == ErrStatusUnavailable {
+			m.logger.Debug(monitorLogTag, "Not monitoring clock drift: %s", err)
+			return nil
+		}
+
+		m.setStatus(status, err == nil)
+
+		if err != nil {
+			m.logger.Warn(monitorLogTag, "Failed to query time synchronization status: %s", err)
+		} else {
+			exceeded := status.Offset > m.threshold || status.Offset < -m.threshold
+
+			if exceeded && !drifting {
+				m.logger.Warn(monitorLogTag, "Clock offset %s exceeds %s", status.Offset, m.threshold)
+
+				err = handler(status, m.threshold)
+				if err != nil {
+					return bosherr.WrapError(err, "Handling clock drift")
+				}
+			}
+
+			drifting = exceeded
+		}
+
+		m.clock.Sleep(m.interval)
+	}
+}
+
+func (m *monitor) setStatus(status Status, hasStatus bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.status = status
+	m.hasStatus = hasStatus
+}
+
+type unsupportedMonitor struct{}
+
+// NewUnsupportedMonitor is used on platforms which do not manage time synchronization
+func NewUnsupportedMonitor() Monitor {
+	return unsupportedMonitor{}
+}
+
+func (m unsupportedMonitor) Status() (Status, bool) {
+	return Status{}, false
+}
+
+func (m unsupportedMonitor) MonitorDrift(handler DriftHandler) error {
+	return nil
+}
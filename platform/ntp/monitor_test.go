@@ -0,0 +1,113 @@
+package ntp_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	"github.com/cloudfoundry/bosh-agent/platform/ntp/ntpfakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("Monitor", func() {
+	var (
+		manager *ntpfakes.FakeManager
+		clock   *fakeclock.FakeClock
+		monitor Monitor
+	)
+
+	BeforeEach(func() {
+		manager = &ntpfakes.FakeManager{}
+		clock = fakeclock.NewFakeClock(time.Now())
+		monitor = NewMonitor(manager, time.Second, time.Minute, clock, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("MonitorDrift", func() {
+		It("calls handler once every time offset exceeds threshold", func() {
+			manager.StatusReturnsOnCall(0, Status{Offset: 1500 * time.Millisecond}, nil)
+			manager.StatusReturnsOnCall(1, Status{Offset: 2 * time.Second}, nil)
+			manager.StatusReturnsOnCall(2, Status{Offset: 100 * time.Millisecond}, nil)
+			manager.StatusReturnsOnCall(3, Status{Offset: -3 * time.Second}, nil)
+
+			handled := make(chan Status, 4)
+			errCh := make(chan error, 1)
+
+			go func() {
+				errCh <- monitor.MonitorDrift(func(status Status, threshold time.Duration) error {
+					Expect(threshold).To(Equal(time.Second))
+
+					handled <- status
+					if len(handled) == 2 {
+						return errors.New("fake-handler-err")
+					}
+					return nil
+				})
+			}()
+
+			for i := 0; i < 3; i++ {
+				clock.WaitForWatcherAndIncrement(time.Minute)
+			}
+
+			var err error
+			Eventually(errCh).Should(Receive(&err))
+			Expect(err.Error()).To(ContainSubstring("fake-handler-err"))
+
+			Expect(manager.StatusCallCount()).To(Equal(4))
+			Expect(<-handled).To(Equal(Status{Offset: 1500 * time.Millisecond}))
+			Expect(<-handled).To(Equal(Status{Offset: -3 * time.Second}))
+		})
+
+		It("remembers last queried status", func() {
+			_, found := monitor.Status()
+			Expect(found).To(BeFalse())
+
+			manager.StatusReturnsOnCall(0, Status{Synchronized: true, Stratum: 2}, nil)
+			manager.StatusReturnsOnCall(1, Status{}, errors.New("fake-status-err"))
+
+			go func() {
+				defer GinkgoRecover()
+				_ = monitor.MonitorDrift(func(Status, time.Duration) error { return nil })
+			}()
+
+			Eventually(func() bool {
+				_, found := monitor.Status()
+				return found
+			}).Should(BeTrue())
+
+			status, _ := monitor.Status()
+			Expect(status).To(Equal(Status{Synchronized: true, Stratum: 2}))
+
+			clock.WaitForWatcherAndIncrement(time.Minute)
+
+			Eventually(func() bool {
+				_, found := monitor.Status()
+				return found
+			}).Should(BeFalse())
+		})
+
+		It("stops right away when status is not available", func() {
+			manager.StatusReturns(Status{}, ErrStatusUnavailable)
+
+			err := monitor.MonitorDrift(func(Status, time.Duration) error {
+				Fail("handler should not be called")
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manager.StatusCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("NewUnsupportedMonitor", func() {
+		It("never has status", func() {
+			monitor = NewUnsupportedMonitor()
+
+			_, found := monitor.Status()
+			Expect(found).To(BeFalse())
+			Expect(monitor.MonitorDrift(nil)).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,186 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package ntpfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/platform/ntp"
+)
+
+type FakeManager struct {
+	ConfigureStub        func([]string) error
+	configureMutex       sync.RWMutex
+	configureArgsForCall []struct {
+		arg1 []string
+	}
+	configureReturns struct {
+		result1 error
+	}
+	configureReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StatusStub        func() (ntp.Status, error)
+	statusMutex       sync.RWMutex
+	statusArgsForCall []struct {
+	}
+	statusReturns struct {
+		result1 ntp.Status
+		result2 error
+	}
+	statusReturnsOnCall map[int]struct {
+		result1 ntp.Status
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeManager) Configure(arg1 []string) error {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.configureMutex.Lock()
+	ret, specificReturn := fake.configureReturnsOnCall[len(fake.configureArgsForCall)]
+	fake.configureArgsForCall = append(fake.configureArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	stub := fake.ConfigureStub
+	fakeReturns := fake.configureReturns
+	fake.recordInvocation("Configure", []interface{}{arg1Copy})
+	fake.configureMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) ConfigureCallCount() int {
+	fake.configureMutex.RLock()
+	defer fake.configureMutex.RUnlock()
+	return len(fake.configureArgsForCall)
+}
+
+func (fake *FakeManager) ConfigureCalls(stub func([]string) error) {
+	fake.configureMutex.Lock()
+	defer fake.configureMutex.Unlock()
+	fake.ConfigureStub = stub
+}
+
+func (fake *FakeManager) ConfigureArgsForCall(i int) []string {
+	fake.configureMutex.RLock()
+	defer fake.configureMutex.RUnlock()
+	argsForCall := fake.configureArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeManager) ConfigureReturns(result1 error) {
+	fake.configureMutex.Lock()
+	defer fake.configureMutex.Unlock()
+	fake.ConfigureStub = nil
+	fake.configureReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) ConfigureReturnsOnCall(i int, result1 error) {
+	fake.configureMutex.Lock()
+	defer fake.configureMutex.Unlock()
+	fake.ConfigureStub = nil
+	if fake.configureReturnsOnCall == nil {
+		fake.configureReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.configureReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) Status() (ntp.Status, error) {
+	fake.statusMutex.Lock()
+	ret, specificReturn := fake.statusReturnsOnCall[len(fake.statusArgsForCall)]
+	fake.statusArgsForCall = append(fake.statusArgsForCall, struct {
+	}{})
+	stub := fake.StatusStub
+	fakeReturns := fake.statusReturns
+	fake.recordInvocation("Status", []interface{}{})
+	fake.statusMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) StatusCallCount() int {
+	fake.statusMutex.RLock()
+	defer fake.statusMutex.RUnlock()
+	return len(fake.statusArgsForCall)
+}
+
+func (fake *FakeManager) StatusCalls(stub func() (ntp.Status, error)) {
+	fake.statusMutex.Lock()
+	defer fake.statusMutex.Unlock()
+	fake.StatusStub = stub
+}
+
+func (fake *FakeManager) StatusReturns(result1 ntp.Status, result2 error) {
+	fake.statusMutex.Lock()
+	defer fake.statusMutex.Unlock()
+	fake.StatusStub = nil
+	fake.statusReturns = struct {
+		result1 ntp.Status
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) StatusReturnsOnCall(i int, result1 ntp.Status, result2 error) {
+	fake.statusMutex.Lock()
+	defer fake.statusMutex.Unlock()
+	fake.StatusStub = nil
+	if fake.statusReturnsOnCall == nil {
+		fake.statusReturnsOnCall = make(map[int]struct {
+			result1 ntp.Status
+			result2 error
+		})
+	}
+	fake.statusReturnsOnCall[i] = struct {
+		result1 ntp.Status
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.configureMutex.RLock()
+	defer fake.configureMutex.RUnlock()
+	fake.statusMutex.RLock()
+	defer fake.statusMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeManager) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ ntp.Manager = new(FakeManager)
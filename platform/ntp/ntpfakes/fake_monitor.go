@@ -0,0 +1,181 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package ntpfakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/platform/ntp"
+)
+
+type FakeMonitor struct {
+	MonitorDriftStub        func(ntp.DriftHandler) error
+	monitorDriftMutex       sync.RWMutex
+	monitorDriftArgsForCall []struct {
+		arg1 ntp.DriftHandler
+	}
+	monitorDriftReturns struct {
+		result1 error
+	}
+	monitorDriftReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StatusStub        func() (ntp.Status, bool)
+	statusMutex       sync.RWMutex
+	statusArgsForCall []struct {
+	}
+	statusReturns struct {
+		result1 ntp.Status
+		result2 bool
+	}
+	statusReturnsOnCall map[int]struct {
+		result1 ntp.Status
+		result2 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMonitor) MonitorDrift(arg1 ntp.DriftHandler) error {
+	fake.monitorDriftMutex.Lock()
+	ret, specificReturn := fake.monitorDriftReturnsOnCall[len(fake.monitorDriftArgsForCall)]
+	fake.monitorDriftArgsForCall = append(fake.monitorDriftArgsForCall, struct {
+		arg1 ntp.DriftHandler
+	}{arg1})
+	stub := fake.MonitorDriftStub
+	fakeReturns := fake.monitorDriftReturns
+	fake.recordInvocation("MonitorDrift", []interface{}{arg1})
+	fake.monitorDriftMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMonitor) MonitorDriftCallCount() int {
+	fake.monitorDriftMutex.RLock()
+	defer fake.monitorDriftMutex.RUnlock()
+	return len(fake.monitorDriftArgsForCall)
+}
+
+func (fake *FakeMonitor) MonitorDriftCalls(stub func(ntp.DriftHandler) error) {
+	fake.monitorDriftMutex.Lock()
+	defer fake.monitorDriftMutex.Unlock()
+	fake.MonitorDriftStub = stub
+}
+
+func (fake *FakeMonitor) MonitorDriftArgsForCall(i int) ntp.DriftHandler {
+	fake.monitorDriftMutex.RLock()
+	defer fake.monitorDriftMutex.RUnlock()
+	argsForCall := fake.monitorDriftArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMonitor) MonitorDriftReturns(result1 error) {
+	fake.monitorDriftMutex.Lock()
+	defer fake.monitorDriftMutex.Unlock()
+	fake.MonitorDriftStub = nil
+	fake.monitorDriftReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMonitor) MonitorDriftReturnsOnCall(i int, result1 error) {
+	fake.monitorDriftMutex.Lock()
+	defer fake.monitorDriftMutex.Unlock()
+	fake.MonitorDriftStub = nil
+	if fake.monitorDriftReturnsOnCall == nil {
+		fake.monitorDriftReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.monitorDriftReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMonitor) Status() (ntp.Status, bool) {
+	fake.statusMutex.Lock()
+	ret, specificReturn := fake.statusReturnsOnCall[len(fake.statusArgsForCall)]
+	fake.statusArgsForCall = append(fake.statusArgsForCall, struct {
+	}{})
+	stub := fake.StatusStub
+	fakeReturns := fake.statusReturns
+	fake.recordInvocation("Status", []interface{}{})
+	fake.statusMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMonitor) StatusCallCount() int {
+	fake.statusMutex.RLock()
+	defer fake.statusMutex.RUnlock()
+	return len(fake.statusArgsForCall)
+}
+
+func (fake *FakeMonitor) StatusCalls(stub func() (ntp.Status, bool)) {
+	fake.statusMutex.Lock()
+	defer fake.statusMutex.Unlock()
+	fake.StatusStub = stub
+}
+
+func (fake *FakeMonitor) StatusReturns(result1 ntp.Status, result2 bool) {
+	fake.statusMutex.Lock()
+	defer fake.statusMutex.Unlock()
+	fake.StatusStub = nil
+	fake.statusReturns = struct {
+		result1 ntp.Status
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeMonitor) StatusReturnsOnCall(i int, result1 ntp.Status, result2 bool) {
+	fake.statusMutex.Lock()
+	defer fake.statusMutex.Unlock()
+	fake.StatusStub = nil
+	if fake.statusReturnsOnCall == nil {
+		fake.statusReturnsOnCall = make(map[int]struct {
+			result1 ntp.Status
+			result2 bool
+		})
+	}
+	fake.statusReturnsOnCall[i] = struct {
+		result1 ntp.Status
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeMonitor) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.monitorDriftMutex.RLock()
+	defer fake.monitorDriftMutex.RUnlock()
+	fake.statusMutex.RLock()
+	defer fake.statusMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMonitor) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ ntp.Monitor = new(FakeMonitor)
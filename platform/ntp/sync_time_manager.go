@@ -0,0 +1,48 @@
+package ntp
+
+import (
+	"path"
+	"strings"
+
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// syncTimeManager writes servers for the sync-time script of the stemcell
+// which steps the clock periodically instead of running a daemon
+type syncTimeManager struct {
+	fs          boshsys.FileSystem
+	cmdRunner   boshsys.CmdRunner
+	dirProvider boshdirs.Provider
+}
+
+func NewSyncTimeManager(fs boshsys.FileSystem, cmdRunner boshsys.CmdRunner, dirProvider boshdirs.Provider) Manager {
+	return syncTimeManager{
+		fs:          fs,
+		cmdRunner:   cmdRunner,
+		dirProvider: dirProvider,
+	}
+}
+
+func (m syncTimeManager) Configure(servers []string) error {
+	if len(servers) == 0 {
+		return nil
+	}
+
+	serversFilePath := path.Join(m.dirProvider.BaseDir(), "/bosh/etc/ntpserver")
+
+	err := m.fs.WriteFileString(serversFilePath, strings.Join(servers, " "))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing to %s", serversFilePath)
+	}
+
+	// Make a best effort to sync time now but don't error
+	_, _, _, _ = m.cmdRunner.RunCommand("sync-time")
+
+	return nil
+}
+
+func (m syncTimeManager) Status() (Status, error) {
+	return Status{}, ErrStatusUnavailable
+}
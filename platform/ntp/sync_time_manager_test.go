@@ -0,0 +1,53 @@
+package ntp_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("syncTimeManager", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		manager   Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		manager = NewSyncTimeManager(fs, cmdRunner, boshdirs.NewProvider("/fake-dir"))
+	})
+
+	Describe("Configure", func() {
+		It("writes ntp servers and syncs time", func() {
+			err := manager.Configure([]string{"0.north-america.pool.ntp.org", "1.north-america.pool.ntp.org"})
+			Expect(err).NotTo(HaveOccurred())
+
+			ntpConfig := fs.GetFileTestStat("/fake-dir/bosh/etc/ntpserver")
+			Expect(ntpConfig.StringContents()).To(Equal("0.north-america.pool.ntp.org 1.north-america.pool.ntp.org"))
+			Expect(ntpConfig.FileType).To(Equal(fakesys.FakeFileTypeFile))
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"sync-time"}}))
+		})
+
+		It("is noop when no ntp server provided", func() {
+			err := manager.Configure([]string{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+
+			ntpConfig := fs.GetFileTestStat("/fake-dir/bosh/etc/ntpserver")
+			Expect(ntpConfig).To(BeNil())
+		})
+	})
+
+	Describe("Status", func() {
+		It("reports that status is not available", func() {
+			_, err := manager.Status()
+			Expect(err).To(Equal(ErrStatusUnavailable))
+		})
+	})
+})
@@ -0,0 +1,119 @@
+package ntp
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	timesyncdManagerLogTag = "TimesyncdManager"
+
+	timesyncdConfigPath = "/etc/systemd/timesyncd.conf.d/bosh.conf"
+)
+
+type timesyncdManager struct {
+	fs        boshsys.FileSystem
+	cmdRunner boshsys.CmdRunner
+	logger    boshlog.Logger
+}
+
+func NewTimesyncdManager(fs boshsys.FileSystem, cmdRunner boshsys.CmdRunner, logger boshlog.Logger) Manager {
+	return timesyncdManager{
+		fs:        fs,
+		cmdRunner: cmdRunner,
+		logger:    logger,
+	}
+}
+
+func (m timesyncdManager) Configure(servers []string) error {
+	if len(servers) == 0 {
+		return nil
+	}
+
+	contents := fmt.Sprintf("# Generated by bosh-agent\n[Time]\nNTP=%s\n", strings.Join(servers, " "))
+
+	changed, err := m.fs.ConvergeFileContents(timesyncdConfigPath, []byte(contents))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", timesyncdConfigPath)
+	}
+
+	if !changed {
+		return nil
+	}
+
+	m.logger.Info(timesyncdManagerLogTag, "Restarting systemd-timesyncd with servers %v", servers)
+
+	_, _, _, err = m.cmdRunner.RunCommand("systemctl", "restart", "systemd-timesyncd")
+	if err != nil {
+		return bosherr.WrapError(err, "Restarting systemd-timesyncd")
+	}
+
+	return nil
+}
+
+// Status parses output of 'timedatectl timesync-status', e.g.
+//
+//	Server: 169.254.169.254 (metadata.google.internal)
+//	Stratum: 2
+//	Offset: -1.234ms
+//
+// Offset and stratum are only known once a server responded.
+func (m timesyncdManager) Status() (Status, error) {
+	stdout, _, _, err := m.cmdRunner.RunCommand("timedatectl", "show", "--property", "NTPSynchronized", "--value")
+	if err != nil {
+		return Status{}, bosherr.WrapError(err, "Querying NTP synchronization")
+	}
+
+	status := Status{Synchronized: strings.TrimSpace(stdout) == "yes"}
+
+	stdout, _, _, err = m.cmdRunner.RunCommand("timedatectl", "timesync-status")
+	if err != nil {
+		return Status{}, bosherr.WrapError(err, "Querying systemd-timesyncd status")
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+
+		switch strings.TrimSpace(parts[0]) {
+		case "Server":
+			if fields := strings.Fields(value); len(fields) > 0 {
+				status.Server = fields[0]
+			}
+
+		case "Stratum":
+			status.Stratum, err = strconv.Atoi(value)
+			if err != nil {
+				return Status{}, bosherr.WrapErrorf(err, "Parsing systemd-timesyncd stratum '%s'", value)
+			}
+
+		case "Offset":
+			status.Offset, err = parseTimespan(value)
+			if err != nil {
+				return Status{}, bosherr.WrapErrorf(err, "Parsing systemd-timesyncd offset '%s'", value)
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// parseTimespan parses systemd formatted durations such as '+1min 2.5s' or '-259us'
+func parseTimespan(value string) (time.Duration, error) {
+	value = strings.ReplaceAll(value, " ", "")
+	value = strings.ReplaceAll(value, "min", "m")
+
+	return time.ParseDuration(value)
+}
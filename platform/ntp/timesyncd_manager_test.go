@@ -0,0 +1,106 @@
+package ntp_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("timesyncdManager", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		manager   Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		manager = NewTimesyncdManager(fs, cmdRunner, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("Configure", func() {
+		It("writes timesyncd config and restarts timesyncd", func() {
+			err := manager.Configure([]string{"0.pool.ntp.org", "169.254.169.254"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.ReadFileString("/etc/systemd/timesyncd.conf.d/bosh.conf")).To(Equal(
+				"# Generated by bosh-agent\n[Time]\nNTP=0.pool.ntp.org 169.254.169.254\n",
+			))
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"systemctl", "restart", "systemd-timesyncd"}}))
+		})
+
+		It("does not restart timesyncd when config did not change", func() {
+			err := manager.Configure([]string{"0.pool.ntp.org"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = manager.Configure([]string{"0.pool.ntp.org"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(HaveLen(1))
+		})
+
+		It("keeps current config when no ntp server provided", func() {
+			err := manager.Configure([]string{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.FileExists("/etc/systemd/timesyncd.conf.d/bosh.conf")).To(BeFalse())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Describe("Status", func() {
+		BeforeEach(func() {
+			cmdRunner.AddCmdResult("timedatectl show --property NTPSynchronized --value", fakesys.FakeCmdResult{Stdout: "yes\n"})
+		})
+
+		It("parses timesync status", func() {
+			cmdRunner.AddCmdResult("timedatectl timesync-status", fakesys.FakeCmdResult{
+				Stdout: `       Server: 169.254.169.254 (metadata.google.internal)
+Poll interval: 32s (min: 32s; max 34min 8s)
+         Leap: normal
+      Version: 4
+      Stratum: 2
+    Reference: C035676C
+    Precision: 1us (-25)
+Root distance: 25.975ms (max: 5s)
+       Offset: -1min 2.5s
+        Delay: 38.227ms
+       Jitter: 0
+ Packet count: 1
+    Frequency: -14.113ppm
+`,
+			})
+
+			status, err := manager.Status()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(Status{
+				Synchronized: true,
+				Server:       "169.254.169.254",
+				Stratum:      2,
+				Offset:       -62500 * time.Millisecond,
+			}))
+		})
+
+		It("parses offsets in microseconds", func() {
+			cmdRunner.AddCmdResult("timedatectl timesync-status", fakesys.FakeCmdResult{Stdout: "Offset: +259us\n"})
+
+			status, err := manager.Status()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Offset).To(Equal(259 * time.Microsecond))
+		})
+
+		It("returns error when timedatectl fails", func() {
+			cmdRunner.AddCmdResult("timedatectl timesync-status", fakesys.FakeCmdResult{Error: errors.New("fake-timedatectl-err")})
+
+			_, err := manager.Status()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-timedatectl-err"))
+		})
+	})
+})
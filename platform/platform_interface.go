@@ -6,6 +6,9 @@ import (
 	"log"
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlogrotate "github.com/cloudfoundry/bosh-agent/platform/logrotate"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -37,6 +40,8 @@ type Platform interface {
 	GetVitalsService() boshvitals.Service
 	GetAuditLogger() AuditLogger
 	GetDevicePathResolver() (devicePathResolver boshdpresolv.DevicePathResolver)
+	GetPersistentDiskSnapshotter() boshdisk.Snapshotter
+	GetTimeSyncMonitor() boshntp.Monitor
 	GetAgentSettingsPath(tmpfs bool) string
 	GetPersistentDiskSettingsPath(tmpfs bool) string
 	GetUpdateSettingsPath(tmpfs bool) string
@@ -54,9 +59,9 @@ type Platform interface {
 	SetupIPv6(boshsettings.IPv6) error
 	SetupHostname(hostname string) (err error)
 	SetupNetworking(networks boshsettings.Networks, mbus string) (err error)
-	SetupLogrotate(groupName, basePath, size string) (err error)
+	SetupLogrotate(groupName, basePath, size string, jobPolicies map[string]boshlogrotate.Policy) (err error)
 	SetTimeWithNtpServers(servers []string) (err error)
-	SetupEphemeralDiskWithPath(devicePath string, desiredSwapSizeInBytes *uint64, labelPrefix string) (err error)
+	SetupEphemeralDiskWithPath(devicePath string, desiredSwapSizeInBytes *uint64, labelPrefix string, encryption boshsettings.DiskEncryption) (err error)
 	SetupRawEphemeralDisks(devices []boshsettings.DiskSettings) (err error)
 	SetupDataDir(boshsettings.JobDir, boshsettings.RunDir) (err error)
 	SetupSharedMemory() (err error)
@@ -76,7 +81,8 @@ type Platform interface {
 	AdjustPersistentDiskPartitioning(diskSettings boshsettings.DiskSettings, mountPoint string) error
 	MountPersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) error
 	UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error)
-	MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error)
+	ResizePersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) (DiskResize, error)
+	MigratePersistentDisk(fromMountPoint, toMountPoint string, progress boshdisk.MigrationProgressFunc) (err error)
 	GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) (string, error)
 	IsMountPoint(path string) (partitionPath string, result bool, err error)
 	IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (result bool, err error)
@@ -105,3 +111,11 @@ type Platform interface {
 
 	Shutdown() error
 }
+
+// DiskResize reports sizes in bytes before and after growing a persistent disk
+type DiskResize struct {
+	DeviceSizeBefore     uint64 `json:"device_size_before"`
+	DeviceSizeAfter      uint64 `json:"device_size_after"`
+	FilesystemSizeBefore uint64 `json:"filesystem_size_before"`
+	FilesystemSizeAfter  uint64 `json:"filesystem_size_after"`
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	"github.com/cloudfoundry/bosh-agent/platform"
 	"github.com/cloudfoundry/bosh-agent/platform/cert"
+	"github.com/cloudfoundry/bosh-agent/platform/disk"
+	"github.com/cloudfoundry/bosh-agent/platform/logrotate"
+	"github.com/cloudfoundry/bosh-agent/platform/ntp"
 	"github.com/cloudfoundry/bosh-agent/platform/vitals"
 	"github.com/cloudfoundry/bosh-agent/settings"
 	"github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -267,6 +270,16 @@ type FakePlatform struct {
 	getPersistentDiskSettingsPathReturnsOnCall map[int]struct {
 		result1 string
 	}
+	GetPersistentDiskSnapshotterStub        func() disk.Snapshotter
+	getPersistentDiskSnapshotterMutex       sync.RWMutex
+	getPersistentDiskSnapshotterArgsForCall []struct {
+	}
+	getPersistentDiskSnapshotterReturns struct {
+		result1 disk.Snapshotter
+	}
+	getPersistentDiskSnapshotterReturnsOnCall map[int]struct {
+		result1 disk.Snapshotter
+	}
 	GetRunnerStub        func() system.CmdRunner
 	getRunnerMutex       sync.RWMutex
 	getRunnerArgsForCall []struct {
@@ -277,6 +290,16 @@ type FakePlatform struct {
 	getRunnerReturnsOnCall map[int]struct {
 		result1 system.CmdRunner
 	}
+	GetTimeSyncMonitorStub        func() ntp.Monitor
+	getTimeSyncMonitorMutex       sync.RWMutex
+	getTimeSyncMonitorArgsForCall []struct {
+	}
+	getTimeSyncMonitorReturns struct {
+		result1 ntp.Monitor
+	}
+	getTimeSyncMonitorReturnsOnCall map[int]struct {
+		result1 ntp.Monitor
+	}
 	GetUpdateSettingsPathStub        func(bool) string
 	getUpdateSettingsPathMutex       sync.RWMutex
 	getUpdateSettingsPathArgsForCall []struct {
@@ -339,11 +362,12 @@ type FakePlatform struct {
 		result1 bool
 		result2 error
 	}
-	MigratePersistentDiskStub        func(string, string) error
+	MigratePersistentDiskStub        func(string, string, disk.MigrationProgressFunc) error
 	migratePersistentDiskMutex       sync.RWMutex
 	migratePersistentDiskArgsForCall []struct {
 		arg1 string
 		arg2 string
+		arg3 disk.MigrationProgressFunc
 	}
 	migratePersistentDiskReturns struct {
 		result1 error
@@ -395,6 +419,20 @@ type FakePlatform struct {
 	removeStaticLibrariesReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ResizePersistentDiskStub        func(settings.DiskSettings, string) (platform.DiskResize, error)
+	resizePersistentDiskMutex       sync.RWMutex
+	resizePersistentDiskArgsForCall []struct {
+		arg1 settings.DiskSettings
+		arg2 string
+	}
+	resizePersistentDiskReturns struct {
+		result1 platform.DiskResize
+		result2 error
+	}
+	resizePersistentDiskReturnsOnCall map[int]struct {
+		result1 platform.DiskResize
+		result2 error
+	}
 	SaveDNSRecordsStub        func(settings.DNSRecords, string) error
 	saveDNSRecordsMutex       sync.RWMutex
 	saveDNSRecordsArgsForCall []struct {
@@ -472,12 +510,13 @@ type FakePlatform struct {
 	setupDataDirReturnsOnCall map[int]struct {
 		result1 error
 	}
-	SetupEphemeralDiskWithPathStub        func(string, *uint64, string) error
+	SetupEphemeralDiskWithPathStub        func(string, *uint64, string, settings.DiskEncryption) error
 	setupEphemeralDiskWithPathMutex       sync.RWMutex
 	setupEphemeralDiskWithPathArgsForCall []struct {
 		arg1 string
 		arg2 *uint64
 		arg3 string
+		arg4 settings.DiskEncryption
 	}
 	setupEphemeralDiskWithPathReturns struct {
 		result1 error
@@ -537,12 +576,13 @@ type FakePlatform struct {
 	setupLoggingAndAuditingReturnsOnCall map[int]struct {
 		result1 error
 	}
-	SetupLogrotateStub        func(string, string, string) error
+	SetupLogrotateStub        func(string, string, string, map[string]logrotate.Policy) error
 	setupLogrotateMutex       sync.RWMutex
 	setupLogrotateArgsForCall []struct {
 		arg1 string
 		arg2 string
 		arg3 string
+		arg4 map[string]logrotate.Policy
 	}
 	setupLogrotateReturns struct {
 		result1 error
@@ -1987,6 +2027,59 @@ func (fake *FakePlatform) GetPersistentDiskSettingsPathReturnsOnCall(i int, resu
 	}{result1}
 }
 
+func (fake *FakePlatform) GetPersistentDiskSnapshotter() disk.Snapshotter {
+	fake.getPersistentDiskSnapshotterMutex.Lock()
+	ret, specificReturn := fake.getPersistentDiskSnapshotterReturnsOnCall[len(fake.getPersistentDiskSnapshotterArgsForCall)]
+	fake.getPersistentDiskSnapshotterArgsForCall = append(fake.getPersistentDiskSnapshotterArgsForCall, struct {
+	}{})
+	stub := fake.GetPersistentDiskSnapshotterStub
+	fakeReturns := fake.getPersistentDiskSnapshotterReturns
+	fake.recordInvocation("GetPersistentDiskSnapshotter", []interface{}{})
+	fake.getPersistentDiskSnapshotterMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePlatform) GetPersistentDiskSnapshotterCallCount() int {
+	fake.getPersistentDiskSnapshotterMutex.RLock()
+	defer fake.getPersistentDiskSnapshotterMutex.RUnlock()
+	return len(fake.getPersistentDiskSnapshotterArgsForCall)
+}
+
+func (fake *FakePlatform) GetPersistentDiskSnapshotterCalls(stub func() disk.Snapshotter) {
+	fake.getPersistentDiskSnapshotterMutex.Lock()
+	defer fake.getPersistentDiskSnapshotterMutex.Unlock()
+	fake.GetPersistentDiskSnapshotterStub = stub
+}
+
+func (fake *FakePlatform) GetPersistentDiskSnapshotterReturns(result1 disk.Snapshotter) {
+	fake.getPersistentDiskSnapshotterMutex.Lock()
+	defer fake.getPersistentDiskSnapshotterMutex.Unlock()
+	fake.GetPersistentDiskSnapshotterStub = nil
+	fake.getPersistentDiskSnapshotterReturns = struct {
+		result1 disk.Snapshotter
+	}{result1}
+}
+
+func (fake *FakePlatform) GetPersistentDiskSnapshotterReturnsOnCall(i int, result1 disk.Snapshotter) {
+	fake.getPersistentDiskSnapshotterMutex.Lock()
+	defer fake.getPersistentDiskSnapshotterMutex.Unlock()
+	fake.GetPersistentDiskSnapshotterStub = nil
+	if fake.getPersistentDiskSnapshotterReturnsOnCall == nil {
+		fake.getPersistentDiskSnapshotterReturnsOnCall = make(map[int]struct {
+			result1 disk.Snapshotter
+		})
+	}
+	fake.getPersistentDiskSnapshotterReturnsOnCall[i] = struct {
+		result1 disk.Snapshotter
+	}{result1}
+}
+
 func (fake *FakePlatform) GetRunner() system.CmdRunner {
 	fake.getRunnerMutex.Lock()
 	ret, specificReturn := fake.getRunnerReturnsOnCall[len(fake.getRunnerArgsForCall)]
@@ -2040,6 +2133,59 @@ func (fake *FakePlatform) GetRunnerReturnsOnCall(i int, result1 system.CmdRunner
 	}{result1}
 }
 
+func (fake *FakePlatform) GetTimeSyncMonitor() ntp.Monitor {
+	fake.getTimeSyncMonitorMutex.Lock()
+	ret, specificReturn := fake.getTimeSyncMonitorReturnsOnCall[len(fake.getTimeSyncMonitorArgsForCall)]
+	fake.getTimeSyncMonitorArgsForCall = append(fake.getTimeSyncMonitorArgsForCall, struct {
+	}{})
+	stub := fake.GetTimeSyncMonitorStub
+	fakeReturns := fake.getTimeSyncMonitorReturns
+	fake.recordInvocation("GetTimeSyncMonitor", []interface{}{})
+	fake.getTimeSyncMonitorMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePlatform) GetTimeSyncMonitorCallCount() int {
+	fake.getTimeSyncMonitorMutex.RLock()
+	defer fake.getTimeSyncMonitorMutex.RUnlock()
+	return len(fake.getTimeSyncMonitorArgsForCall)
+}
+
+func (fake *FakePlatform) GetTimeSyncMonitorCalls(stub func() ntp.Monitor) {
+	fake.getTimeSyncMonitorMutex.Lock()
+	defer fake.getTimeSyncMonitorMutex.Unlock()
+	fake.GetTimeSyncMonitorStub = stub
+}
+
+func (fake *FakePlatform) GetTimeSyncMonitorReturns(result1 ntp.Monitor) {
+	fake.getTimeSyncMonitorMutex.Lock()
+	defer fake.getTimeSyncMonitorMutex.Unlock()
+	fake.GetTimeSyncMonitorStub = nil
+	fake.getTimeSyncMonitorReturns = struct {
+		result1 ntp.Monitor
+	}{result1}
+}
+
+func (fake *FakePlatform) GetTimeSyncMonitorReturnsOnCall(i int, result1 ntp.Monitor) {
+	fake.getTimeSyncMonitorMutex.Lock()
+	defer fake.getTimeSyncMonitorMutex.Unlock()
+	fake.GetTimeSyncMonitorStub = nil
+	if fake.getTimeSyncMonitorReturnsOnCall == nil {
+		fake.getTimeSyncMonitorReturnsOnCall = make(map[int]struct {
+			result1 ntp.Monitor
+		})
+	}
+	fake.getTimeSyncMonitorReturnsOnCall[i] = struct {
+		result1 ntp.Monitor
+	}{result1}
+}
+
 func (fake *FakePlatform) GetUpdateSettingsPath(arg1 bool) string {
 	fake.getUpdateSettingsPathMutex.Lock()
 	ret, specificReturn := fake.getUpdateSettingsPathReturnsOnCall[len(fake.getUpdateSettingsPathArgsForCall)]
@@ -2349,19 +2495,20 @@ func (fake *FakePlatform) IsPersistentDiskMountedReturnsOnCall(i int, result1 bo
 	}{result1, result2}
 }
 
-func (fake *FakePlatform) MigratePersistentDisk(arg1 string, arg2 string) error {
+func (fake *FakePlatform) MigratePersistentDisk(arg1 string, arg2 string, arg3 disk.MigrationProgressFunc) error {
 	fake.migratePersistentDiskMutex.Lock()
 	ret, specificReturn := fake.migratePersistentDiskReturnsOnCall[len(fake.migratePersistentDiskArgsForCall)]
 	fake.migratePersistentDiskArgsForCall = append(fake.migratePersistentDiskArgsForCall, struct {
 		arg1 string
 		arg2 string
-	}{arg1, arg2})
+		arg3 disk.MigrationProgressFunc
+	}{arg1, arg2, arg3})
 	stub := fake.MigratePersistentDiskStub
 	fakeReturns := fake.migratePersistentDiskReturns
-	fake.recordInvocation("MigratePersistentDisk", []interface{}{arg1, arg2})
+	fake.recordInvocation("MigratePersistentDisk", []interface{}{arg1, arg2, arg3})
 	fake.migratePersistentDiskMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -2375,17 +2522,17 @@ func (fake *FakePlatform) MigratePersistentDiskCallCount() int {
 	return len(fake.migratePersistentDiskArgsForCall)
 }
 
-func (fake *FakePlatform) MigratePersistentDiskCalls(stub func(string, string) error) {
+func (fake *FakePlatform) MigratePersistentDiskCalls(stub func(string, string, disk.MigrationProgressFunc) error) {
 	fake.migratePersistentDiskMutex.Lock()
 	defer fake.migratePersistentDiskMutex.Unlock()
 	fake.MigratePersistentDiskStub = stub
 }
 
-func (fake *FakePlatform) MigratePersistentDiskArgsForCall(i int) (string, string) {
+func (fake *FakePlatform) MigratePersistentDiskArgsForCall(i int) (string, string, disk.MigrationProgressFunc) {
 	fake.migratePersistentDiskMutex.RLock()
 	defer fake.migratePersistentDiskMutex.RUnlock()
 	argsForCall := fake.migratePersistentDiskArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakePlatform) MigratePersistentDiskReturns(result1 error) {
@@ -2648,6 +2795,71 @@ func (fake *FakePlatform) RemoveStaticLibrariesReturnsOnCall(i int, result1 erro
 	}{result1}
 }
 
+func (fake *FakePlatform) ResizePersistentDisk(arg1 settings.DiskSettings, arg2 string) (platform.DiskResize, error) {
+	fake.resizePersistentDiskMutex.Lock()
+	ret, specificReturn := fake.resizePersistentDiskReturnsOnCall[len(fake.resizePersistentDiskArgsForCall)]
+	fake.resizePersistentDiskArgsForCall = append(fake.resizePersistentDiskArgsForCall, struct {
+		arg1 settings.DiskSettings
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ResizePersistentDiskStub
+	fakeReturns := fake.resizePersistentDiskReturns
+	fake.recordInvocation("ResizePersistentDisk", []interface{}{arg1, arg2})
+	fake.resizePersistentDiskMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePlatform) ResizePersistentDiskCallCount() int {
+	fake.resizePersistentDiskMutex.RLock()
+	defer fake.resizePersistentDiskMutex.RUnlock()
+	return len(fake.resizePersistentDiskArgsForCall)
+}
+
+func (fake *FakePlatform) ResizePersistentDiskCalls(stub func(settings.DiskSettings, string) (platform.DiskResize, error)) {
+	fake.resizePersistentDiskMutex.Lock()
+	defer fake.resizePersistentDiskMutex.Unlock()
+	fake.ResizePersistentDiskStub = stub
+}
+
+func (fake *FakePlatform) ResizePersistentDiskArgsForCall(i int) (settings.DiskSettings, string) {
+	fake.resizePersistentDiskMutex.RLock()
+	defer fake.resizePersistentDiskMutex.RUnlock()
+	argsForCall := fake.resizePersistentDiskArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakePlatform) ResizePersistentDiskReturns(result1 platform.DiskResize, result2 error) {
+	fake.resizePersistentDiskMutex.Lock()
+	defer fake.resizePersistentDiskMutex.Unlock()
+	fake.ResizePersistentDiskStub = nil
+	fake.resizePersistentDiskReturns = struct {
+		result1 platform.DiskResize
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePlatform) ResizePersistentDiskReturnsOnCall(i int, result1 platform.DiskResize, result2 error) {
+	fake.resizePersistentDiskMutex.Lock()
+	defer fake.resizePersistentDiskMutex.Unlock()
+	fake.ResizePersistentDiskStub = nil
+	if fake.resizePersistentDiskReturnsOnCall == nil {
+		fake.resizePersistentDiskReturnsOnCall = make(map[int]struct {
+			result1 platform.DiskResize
+			result2 error
+		})
+	}
+	fake.resizePersistentDiskReturnsOnCall[i] = struct {
+		result1 platform.DiskResize
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakePlatform) SaveDNSRecords(arg1 settings.DNSRecords, arg2 string) error {
 	fake.saveDNSRecordsMutex.Lock()
 	ret, specificReturn := fake.saveDNSRecordsReturnsOnCall[len(fake.saveDNSRecordsArgsForCall)]
@@ -3059,20 +3271,21 @@ func (fake *FakePlatform) SetupDataDirReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakePlatform) SetupEphemeralDiskWithPath(arg1 string, arg2 *uint64, arg3 string) error {
+func (fake *FakePlatform) SetupEphemeralDiskWithPath(arg1 string, arg2 *uint64, arg3 string, arg4 settings.DiskEncryption) error {
 	fake.setupEphemeralDiskWithPathMutex.Lock()
 	ret, specificReturn := fake.setupEphemeralDiskWithPathReturnsOnCall[len(fake.setupEphemeralDiskWithPathArgsForCall)]
 	fake.setupEphemeralDiskWithPathArgsForCall = append(fake.setupEphemeralDiskWithPathArgsForCall, struct {
 		arg1 string
 		arg2 *uint64
 		arg3 string
-	}{arg1, arg2, arg3})
+		arg4 settings.DiskEncryption
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.SetupEphemeralDiskWithPathStub
 	fakeReturns := fake.setupEphemeralDiskWithPathReturns
-	fake.recordInvocation("SetupEphemeralDiskWithPath", []interface{}{arg1, arg2, arg3})
+	fake.recordInvocation("SetupEphemeralDiskWithPath", []interface{}{arg1, arg2, arg3, arg4})
 	fake.setupEphemeralDiskWithPathMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
@@ -3086,17 +3299,17 @@ func (fake *FakePlatform) SetupEphemeralDiskWithPathCallCount() int {
 	return len(fake.setupEphemeralDiskWithPathArgsForCall)
 }
 
-func (fake *FakePlatform) SetupEphemeralDiskWithPathCalls(stub func(string, *uint64, string) error) {
+func (fake *FakePlatform) SetupEphemeralDiskWithPathCalls(stub func(string, *uint64, string, settings.DiskEncryption) error) {
 	fake.setupEphemeralDiskWithPathMutex.Lock()
 	defer fake.setupEphemeralDiskWithPathMutex.Unlock()
 	fake.SetupEphemeralDiskWithPathStub = stub
 }
 
-func (fake *FakePlatform) SetupEphemeralDiskWithPathArgsForCall(i int) (string, *uint64, string) {
+func (fake *FakePlatform) SetupEphemeralDiskWithPathArgsForCall(i int) (string, *uint64, string, settings.DiskEncryption) {
 	fake.setupEphemeralDiskWithPathMutex.RLock()
 	defer fake.setupEphemeralDiskWithPathMutex.RUnlock()
 	argsForCall := fake.setupEphemeralDiskWithPathArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakePlatform) SetupEphemeralDiskWithPathReturns(result1 error) {
@@ -3403,20 +3616,21 @@ func (fake *FakePlatform) SetupLoggingAndAuditingReturnsOnCall(i int, result1 er
 	}{result1}
 }
 
-func (fake *FakePlatform) SetupLogrotate(arg1 string, arg2 string, arg3 string) error {
+func (fake *FakePlatform) SetupLogrotate(arg1 string, arg2 string, arg3 string, arg4 map[string]logrotate.Policy) error {
 	fake.setupLogrotateMutex.Lock()
 	ret, specificReturn := fake.setupLogrotateReturnsOnCall[len(fake.setupLogrotateArgsForCall)]
 	fake.setupLogrotateArgsForCall = append(fake.setupLogrotateArgsForCall, struct {
 		arg1 string
 		arg2 string
 		arg3 string
-	}{arg1, arg2, arg3})
+		arg4 map[string]logrotate.Policy
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.SetupLogrotateStub
 	fakeReturns := fake.setupLogrotateReturns
-	fake.recordInvocation("SetupLogrotate", []interface{}{arg1, arg2, arg3})
+	fake.recordInvocation("SetupLogrotate", []interface{}{arg1, arg2, arg3, arg4})
 	fake.setupLogrotateMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
@@ -3430,17 +3644,17 @@ func (fake *FakePlatform) SetupLogrotateCallCount() int {
 	return len(fake.setupLogrotateArgsForCall)
 }
 
-func (fake *FakePlatform) SetupLogrotateCalls(stub func(string, string, string) error) {
+func (fake *FakePlatform) SetupLogrotateCalls(stub func(string, string, string, map[string]logrotate.Policy) error) {
 	fake.setupLogrotateMutex.Lock()
 	defer fake.setupLogrotateMutex.Unlock()
 	fake.SetupLogrotateStub = stub
 }
 
-func (fake *FakePlatform) SetupLogrotateArgsForCall(i int) (string, string, string) {
+func (fake *FakePlatform) SetupLogrotateArgsForCall(i int) (string, string, string, map[string]logrotate.Policy) {
 	fake.setupLogrotateMutex.RLock()
 	defer fake.setupLogrotateMutex.RUnlock()
 	argsForCall := fake.setupLogrotateArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakePlatform) SetupLogrotateReturns(result1 error) {
@@ -4265,8 +4479,12 @@ func (fake *FakePlatform) Invocations() map[string][][]interface{} {
 	defer fake.getMonitCredentialsMutex.RUnlock()
 	fake.getPersistentDiskSettingsPathMutex.RLock()
 	defer fake.getPersistentDiskSettingsPathMutex.RUnlock()
+	fake.getPersistentDiskSnapshotterMutex.RLock()
+	defer fake.getPersistentDiskSnapshotterMutex.RUnlock()
 	fake.getRunnerMutex.RLock()
 	defer fake.getRunnerMutex.RUnlock()
+	fake.getTimeSyncMonitorMutex.RLock()
+	defer fake.getTimeSyncMonitorMutex.RUnlock()
 	fake.getUpdateSettingsPathMutex.RLock()
 	defer fake.getUpdateSettingsPathMutex.RUnlock()
 	fake.getVitalsServiceMutex.RLock()
@@ -4287,6 +4505,8 @@ func (fake *FakePlatform) Invocations() map[string][][]interface{} {
 	defer fake.removeDevToolsMutex.RUnlock()
 	fake.removeStaticLibrariesMutex.RLock()
 	defer fake.removeStaticLibrariesMutex.RUnlock()
+	fake.resizePersistentDiskMutex.RLock()
+	defer fake.resizePersistentDiskMutex.RUnlock()
 	fake.saveDNSRecordsMutex.RLock()
 	defer fake.saveDNSRecordsMutex.RUnlock()
 	fake.setTimeWithNtpServersMutex.RLock()
@@ -10,9 +10,11 @@ import (
 	boshcdrom "github.com/cloudfoundry/bosh-agent/platform/cdrom"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshdnsresolver "github.com/cloudfoundry/bosh-agent/platform/dnsresolver"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
 	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshiscsi "github.com/cloudfoundry/bosh-agent/platform/openiscsi"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshudev "github.com/cloudfoundry/bosh-agent/platform/udevdevice"
@@ -21,6 +23,7 @@ import (
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherror "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -51,7 +54,8 @@ type Options struct {
 }
 
 func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsCollector boshstats.Collector, fs boshsys.FileSystem, options Options, bootstrapState *BootstrapState, clock clock.Clock, auditLogger AuditLogger) Provider {
-	runner := boshsys.NewExecCmdRunner(logger)
+	// Actions rescope runner to log commands with correlation fields of requests
+	runner := agentlogger.NewExecCmdRunner(logger)
 
 	diskManagerOpts := boshdisk.LinuxDiskManagerOpts{
 		BindMount:       options.Linux.BindMountPersistentDisk,
@@ -72,7 +76,20 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	// Kick of stats collection as soon as possible
 	statsCollector.StartCollecting(SigarStatsCollectionInterval, nil)
 
-	vitalsService := boshvitals.NewService(statsCollector, dirProvider, linuxDiskManager.GetMounter())
+	var timeSyncManager boshntp.Manager
+	switch options.Linux.TimeSyncManagerType {
+	case "chrony":
+		timeSyncManager = boshntp.NewChronyManager(fs, runner, logger)
+	case "timesyncd":
+		timeSyncManager = boshntp.NewTimesyncdManager(fs, runner, logger)
+	default:
+		timeSyncManager = boshntp.NewSyncTimeManager(fs, runner, dirProvider)
+	}
+
+	driftThreshold := time.Duration(options.Linux.ClockDriftThresholdMs) * time.Millisecond
+	timeSyncMonitor := boshntp.NewMonitor(timeSyncManager, driftThreshold, boshntp.DefaultMonitorInterval, clock, logger)
+
+	vitalsService := boshvitals.NewService(statsCollector, dirProvider, linuxDiskManager.GetMounter(), timeSyncMonitor)
 
 	ipResolver := boship.NewResolver(boship.NetworkInterfaceToAddrsFunc)
 
@@ -86,6 +103,11 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 
 	centosNetManager := boshnet.NewCentosNetManager(fs, runner, ipResolver, macAddressDetector, interfaceConfigurationCreator, interfaceAddressesProvider, dnsValidator, arping, logger)
 	ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, macAddressDetector, interfaceConfigurationCreator, interfaceAddressesProvider, dnsValidator, arping, kernelIPv6, logger)
+	if options.Linux.NetworkManagerType == "netplan" {
+		ubuntuNetManager = boshnet.NewNetplanNetManager(fs, runner, ipResolver, macAddressDetector, interfaceConfigurationCreator, interfaceAddressesProvider, arping, kernelIPv6, gonet.DialTimeout, time.Second, logger)
+	}
+
+	localDNSResolver := boshdnsresolver.NewLocalResolver(boshdnsresolver.DefaultAddress, clock, logger)
 
 	windowsNetManager := boshnet.NewWindowsNetManager(
 		runner,
@@ -145,6 +167,9 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			linuxCdutil,
 			linuxDiskManager,
 			centosNetManager,
+			localDNSResolver,
+			timeSyncManager,
+			timeSyncMonitor,
 			centosCertManager,
 			monitRetryStrategy,
 			devicePathResolver,
@@ -169,6 +194,9 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			linuxCdutil,
 			linuxDiskManager,
 			ubuntuNetManager,
+			localDNSResolver,
+			timeSyncManager,
+			timeSyncMonitor,
 			ubuntuCertManager,
 			monitRetryStrategy,
 			devicePathResolver,
@@ -38,6 +38,10 @@ func (p dummyStatsCollector) GetDiskStats(devicePath string) (stats DiskStats, e
 	return
 }
 
+func (p dummyStatsCollector) GetDiskHealthStats(mountedPath string) (stats DiskHealthStats, err error) {
+	return
+}
+
 func (p dummyStatsCollector) GetUptimeStats() (stats UptimeStats, err error) {
 	stats.Secs = 5
 	return
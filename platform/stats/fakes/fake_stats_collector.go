@@ -19,6 +19,8 @@ type FakeCollector struct {
 	SwapStats boshstats.Usage
 	DiskStats map[string]boshstats.DiskStats
 
+	DiskHealthStats map[string]boshstats.DiskHealthStats
+
 	UptimeStats boshstats.UptimeStats
 }
 
@@ -53,6 +55,14 @@ func (c *FakeCollector) GetDiskStats(devicePath string) (stats boshstats.DiskSta
 	return
 }
 
+func (c *FakeCollector) GetDiskHealthStats(mountedPath string) (stats boshstats.DiskHealthStats, err error) {
+	stats, found := c.DiskHealthStats[mountedPath]
+	if !found {
+		err = errors.New("Disk not found")
+	}
+	return
+}
+
 func (c *FakeCollector) GetUptimeStats() (stats boshstats.UptimeStats, err error) {
 	stats = c.UptimeStats
 	return
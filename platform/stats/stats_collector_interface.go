@@ -26,6 +26,35 @@ type DiskStats struct {
 	InodeUsage Usage
 }
 
+type DiskHealthStats struct {
+	ReadOnly         bool
+	FilesystemErrors uint64
+
+	// IO is nil when device backing the mount cannot be determined
+	IO *DiskIOStats
+
+	// SMART is empty when disks backing the mount do not support it
+	SMART []DiskSMARTStats
+}
+
+// DiskIOStats are averaged over the last collection interval
+type DiskIOStats struct {
+	ReadIOPS       float64
+	WriteIOPS      float64
+	ReadLatencyMs  float64
+	WriteLatencyMs float64
+	QueueDepth     float64
+}
+
+type DiskSMARTStats struct {
+	Device             string
+	Passed             bool
+	ReallocatedSectors uint64
+	PendingSectors     uint64
+	MediaErrors        uint64
+	TemperatureCelsius int64
+}
+
 type UptimeStats struct {
 	Secs uint64
 }
@@ -39,6 +68,7 @@ type Collector interface {
 	GetMemStats() (usage Usage, err error)
 	GetSwapStats() (usage Usage, err error)
 	GetDiskStats(mountedPath string) (stats DiskStats, err error)
+	GetDiskHealthStats(mountedPath string) (stats DiskHealthStats, err error)
 
 	GetUptimeStats() (stats UptimeStats, err error)
 }
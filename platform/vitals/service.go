@@ -6,6 +6,7 @@ import (
 	sigar "github.com/cloudfoundry/gosigar"
 
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -21,17 +22,20 @@ type concreteService struct {
 	statsCollector boshstats.Collector
 	dirProvider    boshdirs.Provider
 	diskMounter    boshdisk.Mounter
+	ntpMonitor     boshntp.Monitor
 }
 
 func NewService(
 	statsCollector boshstats.Collector,
 	dirProvider boshdirs.Provider,
 	diskMounter boshdisk.Mounter,
+	ntpMonitor boshntp.Monitor,
 ) Service {
 	return concreteService{
 		statsCollector: statsCollector,
 		dirProvider:    dirProvider,
 		diskMounter:    diskMounter,
+		ntpMonitor:     ntpMonitor,
 	}
 }
 
@@ -88,9 +92,30 @@ func (s concreteService) Get() (Vitals, error) {
 		Swap:   createMemVitals(swapStats),
 		Disk:   diskStats,
 		Uptime: UptimeVitals{Secs: uptimeStats.Secs},
+		NTP:    s.getNTPVitals(),
 	}, nil
 }
 
+// getNTPVitals returns last status queried by monitor so that
+// heartbeats do not wait for time synchronization daemon
+func (s concreteService) getNTPVitals() *NTPVitals {
+	if s.ntpMonitor == nil {
+		return nil
+	}
+
+	status, found := s.ntpMonitor.Status()
+	if !found {
+		return nil
+	}
+
+	return &NTPVitals{
+		Offset:  fmt.Sprintf("%.6f", status.Offset.Seconds()),
+		Stratum: fmt.Sprintf("%d", status.Stratum),
+		Synced:  fmt.Sprintf("%t", status.Synchronized),
+		Server:  status.Server,
+	}
+}
+
 func (s concreteService) getDiskStats() (DiskVitals, error) {
 	disks := map[string]string{
 		"/":                      "system",
@@ -129,13 +154,58 @@ func (s concreteService) addDiskStats(diskStats DiskVitals, path, name string) (
 		return diskStats, nil
 	}
 
-	diskStats[name] = SpecificDiskVitals{
+	diskVitals := SpecificDiskVitals{
 		Percent:      stat.DiskUsage.Percent().FormatFractionOf100(0),
 		InodePercent: stat.InodeUsage.Percent().FormatFractionOf100(0),
 	}
+
+	// Health stats are best effort since they are not available on all platforms
+	healthStat, healthErr := s.statsCollector.GetDiskHealthStats(path)
+	if healthErr == nil {
+		diskVitals.ReadOnly = fmt.Sprintf("%t", healthStat.ReadOnly)
+		diskVitals.Errors = fmt.Sprintf("%d", healthStat.FilesystemErrors)
+		diskVitals.IO = createDiskIOVitals(healthStat.IO)
+		diskVitals.SMART = createDiskSMARTVitals(healthStat.SMART)
+	}
+
+	diskStats[name] = diskVitals
 	return diskStats, nil
 }
 
+func createDiskIOVitals(ioStats *boshstats.DiskIOStats) *DiskIOVitals {
+	if ioStats == nil {
+		return nil
+	}
+
+	return &DiskIOVitals{
+		ReadIOPS:       fmt.Sprintf("%.1f", ioStats.ReadIOPS),
+		WriteIOPS:      fmt.Sprintf("%.1f", ioStats.WriteIOPS),
+		ReadLatencyMs:  fmt.Sprintf("%.2f", ioStats.ReadLatencyMs),
+		WriteLatencyMs: fmt.Sprintf("%.2f", ioStats.WriteLatencyMs),
+		QueueDepth:     fmt.Sprintf("%.2f", ioStats.QueueDepth),
+	}
+}
+
+func createDiskSMARTVitals(smartStats []boshstats.DiskSMARTStats) []DiskSMARTVitals {
+	if len(smartStats) == 0 {
+		return nil
+	}
+
+	smartVitals := []DiskSMARTVitals{}
+	for _, stats := range smartStats {
+		smartVitals = append(smartVitals, DiskSMARTVitals{
+			Device:             stats.Device,
+			Passed:             fmt.Sprintf("%t", stats.Passed),
+			ReallocatedSectors: fmt.Sprintf("%d", stats.ReallocatedSectors),
+			PendingSectors:     fmt.Sprintf("%d", stats.PendingSectors),
+			MediaErrors:        fmt.Sprintf("%d", stats.MediaErrors),
+			Temperature:        fmt.Sprintf("%d", stats.TemperatureCelsius),
+		})
+	}
+
+	return smartVitals
+}
+
 func createMemVitals(memUsage boshstats.Usage) MemoryVitals {
 	return MemoryVitals{
 		Percent: memUsage.Percent().FormatFractionOf100(0),
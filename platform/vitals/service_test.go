@@ -9,6 +9,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry/bosh-agent/platform/disk/diskfakes"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	"github.com/cloudfoundry/bosh-agent/platform/ntp/ntpfakes"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	fakestats "github.com/cloudfoundry/bosh-agent/platform/stats/fakes"
 	. "github.com/cloudfoundry/bosh-agent/platform/vitals"
@@ -23,6 +25,7 @@ var _ = Describe("Vitals service", func() {
 		dirProvider    boshdirs.Provider
 		statsCollector *fakestats.FakeCollector
 		mounter        *diskfakes.FakeMounter
+		ntpMonitor     *ntpfakes.FakeMonitor
 		service        Service
 	)
 
@@ -70,7 +73,9 @@ var _ = Describe("Vitals service", func() {
 		mounter = &diskfakes.FakeMounter{}
 		mounter.IsMountPointReturns("/dev/fake-partition-device", true, nil)
 
-		service = NewService(statsCollector, dirProvider, mounter)
+		ntpMonitor = &ntpfakes.FakeMonitor{}
+
+		service = NewService(statsCollector, dirProvider, mounter, ntpMonitor)
 		statsCollector.StartCollecting(1*time.Millisecond, nil)
 	})
 
@@ -122,6 +127,97 @@ var _ = Describe("Vitals service", func() {
 		boshassert.MatchesJSONMap(GinkgoT(), vitals, expectedVitals)
 	})
 
+	Context("when disk health stats are available", func() {
+		BeforeEach(func() {
+			statsCollector.DiskHealthStats = map[string]boshstats.DiskHealthStats{
+				"/": {},
+				dirProvider.StoreDir(): {
+					ReadOnly:         true,
+					FilesystemErrors: 2,
+					IO: &boshstats.DiskIOStats{
+						ReadIOPS:       10,
+						WriteIOPS:      20.55,
+						ReadLatencyMs:  1.5,
+						WriteLatencyMs: 3,
+						QueueDepth:     0.25,
+					},
+					SMART: []boshstats.DiskSMARTStats{{
+						Device:             "sdc",
+						Passed:             true,
+						ReallocatedSectors: 8,
+						TemperatureCelsius: 38,
+					}},
+				},
+			}
+		})
+
+		It("includes disk health in disk vitals", func() {
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.Disk["system"]).To(Equal(SpecificDiskVitals{
+				Percent:      "50",
+				InodePercent: "10",
+				ReadOnly:     "false",
+				Errors:       "0",
+			}))
+			Expect(vitals.Disk["ephemeral"]).To(Equal(SpecificDiskVitals{
+				Percent:      "75",
+				InodePercent: "20",
+			}))
+			Expect(vitals.Disk["persistent"]).To(Equal(SpecificDiskVitals{
+				Percent:      "100",
+				InodePercent: "75",
+				ReadOnly:     "true",
+				Errors:       "2",
+				IO: &DiskIOVitals{
+					ReadIOPS:       "10.0",
+					WriteIOPS:      "20.6",
+					ReadLatencyMs:  "1.50",
+					WriteLatencyMs: "3.00",
+					QueueDepth:     "0.25",
+				},
+				SMART: []DiskSMARTVitals{{
+					Device:             "sdc",
+					Passed:             "true",
+					ReallocatedSectors: "8",
+					PendingSectors:     "0",
+					MediaErrors:        "0",
+					Temperature:        "38",
+				}},
+			}))
+		})
+	})
+
+	Context("when time synchronization status is known", func() {
+		BeforeEach(func() {
+			ntpMonitor.StatusReturns(boshntp.Status{
+				Synchronized: true,
+				Server:       "fake-ntp-server",
+				Stratum:      3,
+				Offset:       -64230 * time.Microsecond,
+			}, true)
+		})
+
+		It("includes ntp vitals", func() {
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.NTP).To(Equal(&NTPVitals{
+				Offset:  "-0.064230",
+				Stratum: "3",
+				Synced:  "true",
+				Server:  "fake-ntp-server",
+			}))
+		})
+	})
+
+	It("omits ntp vitals when time synchronization status is not known yet", func() {
+		vitals, err := service.Get()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vitals.NTP).To(BeNil())
+	})
+
 	Context("when missing stats for ephemeral and persistent disk", func() {
 		BeforeEach(func() {
 			statsCollector.DiskStats = map[string]boshstats.DiskStats{
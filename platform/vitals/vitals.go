@@ -7,6 +7,7 @@ type Vitals struct {
 	Mem    MemoryVitals `json:"mem"`
 	Swap   MemoryVitals `json:"swap"`
 	Uptime UptimeVitals `json:"uptime"`
+	NTP    *NTPVitals   `json:"ntp,omitempty"`
 }
 
 type CPUVitals struct {
@@ -18,8 +19,30 @@ type CPUVitals struct {
 type DiskVitals map[string]SpecificDiskVitals
 
 type SpecificDiskVitals struct {
-	InodePercent string `json:"inode_percent,omitempty"`
-	Percent      string `json:"percent,omitempty"`
+	InodePercent string        `json:"inode_percent,omitempty"`
+	Percent      string        `json:"percent,omitempty"`
+	ReadOnly     string        `json:"read_only,omitempty"`
+	Errors       string        `json:"errors,omitempty"`
+	IO           *DiskIOVitals `json:"io,omitempty"`
+
+	SMART []DiskSMARTVitals `json:"smart,omitempty"`
+}
+
+type DiskIOVitals struct {
+	ReadIOPS       string `json:"read_iops"`
+	WriteIOPS      string `json:"write_iops"`
+	ReadLatencyMs  string `json:"read_latency_ms"`
+	WriteLatencyMs string `json:"write_latency_ms"`
+	QueueDepth     string `json:"queue_depth"`
+}
+
+type DiskSMARTVitals struct {
+	Device             string `json:"device"`
+	Passed             string `json:"passed"`
+	ReallocatedSectors string `json:"reallocated_sectors"`
+	PendingSectors     string `json:"pending_sectors"`
+	MediaErrors        string `json:"media_errors"`
+	Temperature        string `json:"temperature"`
 }
 
 type MemoryVitals struct {
@@ -27,6 +50,13 @@ type MemoryVitals struct {
 	Percent string `json:"percent,omitempty"`
 }
 
+type NTPVitals struct {
+	Offset  string `json:"offset"`
+	Stratum string `json:"stratum"`
+	Synced  string `json:"synced"`
+	Server  string `json:"server,omitempty"`
+}
+
 type UptimeVitals struct {
 	Secs uint64 `json:"secs,omitempty"`
 }
@@ -13,7 +13,10 @@ import (
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlogrotate "github.com/cloudfoundry/bosh-agent/platform/logrotate"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	"github.com/cloudfoundry/bosh-agent/platform/windows/disk"
@@ -87,7 +90,7 @@ func NewWindowsPlatform(
 		dirProvider:            dirProvider,
 		netManager:             netManager,
 		devicePathResolver:     devicePathResolver,
-		vitalsService:          boshvitals.NewService(collector, dirProvider, nil),
+		vitalsService:          boshvitals.NewService(collector, dirProvider, nil, nil),
 		certManager:            certManager,
 		options:                options,
 		defaultNetworkResolver: defaultNetworkResolver,
@@ -122,6 +125,14 @@ func (p WindowsPlatform) GetDirProvider() (dirProvider boshdirs.Provider) {
 	return p.dirProvider
 }
 
+func (p WindowsPlatform) GetPersistentDiskSnapshotter() boshdisk.Snapshotter {
+	return boshdisk.NewUnsupportedSnapshotter()
+}
+
+func (p WindowsPlatform) GetTimeSyncMonitor() boshntp.Monitor {
+	return boshntp.NewUnsupportedMonitor()
+}
+
 func (p WindowsPlatform) GetVitalsService() (service boshvitals.Service) {
 	return p.vitalsService
 }
@@ -398,7 +409,7 @@ func (p WindowsPlatform) GetCertManager() (certManager boshcert.Manager) {
 	return p.certManager
 }
 
-func (p WindowsPlatform) SetupLogrotate(groupName, basePath, size string) error {
+func (p WindowsPlatform) SetupLogrotate(groupName, basePath, size string, jobPolicies map[string]boshlogrotate.Policy) error {
 	return nil
 }
 
@@ -442,7 +453,7 @@ func (p WindowsPlatform) SetTimeWithNtpServers(servers []string) error {
 	return nil
 }
 
-func (p WindowsPlatform) SetupEphemeralDiskWithPath(devicePath string, desiredSwapSizeInBytes *uint64, labelPrefix string) error {
+func (p WindowsPlatform) SetupEphemeralDiskWithPath(devicePath string, desiredSwapSizeInBytes *uint64, labelPrefix string, encryption boshsettings.DiskEncryption) error {
 	const minimumDiskSizeToPartition = 1024 * 1024
 
 	if devicePath == "" || !p.options.Windows.EnableEphemeralDiskMounting {
@@ -613,6 +624,10 @@ func (p WindowsPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSet
 	return
 }
 
+func (p WindowsPlatform) ResizePersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) (DiskResize, error) {
+	return DiskResize{}, nil
+}
+
 func (p WindowsPlatform) GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) (diskPath string, err error) {
 	p.logger.Debug("WindowsPlatform", "Identifying ephemeral disk path, diskSettings.Path: `%s`", diskSettings.Path)
 
@@ -658,7 +673,7 @@ func (p WindowsPlatform) GetFilesContentsFromDisk(diskPath string, fileNames []s
 	return
 }
 
-func (p WindowsPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error) {
+func (p WindowsPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string, progress boshdisk.MigrationProgressFunc) (err error) {
 	return
 }
 
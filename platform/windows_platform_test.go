@@ -586,14 +586,14 @@ var _ = Describe("WindowsPlatform", func() {
 
 		It("does nothing when path is empty", func() {
 			diskNumber = ""
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).NotTo(HaveOccurred())
 			Expect(diskManager.Invocations()).To(BeEmpty())
 		})
 
 		It("partitions the root disk when disk is 0", func() {
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).NotTo(HaveOccurred())
 
@@ -627,7 +627,7 @@ var _ = Describe("WindowsPlatform", func() {
 			partitioner.GetCountOnDiskReturns("0", nil)
 			partitioner.PartitionDiskReturns(partitionNumber, nil)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).NotTo(HaveOccurred())
 
@@ -642,7 +642,7 @@ var _ = Describe("WindowsPlatform", func() {
 			linker.LinkTargetReturns(fmt.Sprintf(`%s:\`, driveLetter), nil)
 			partitioner.GetCountOnDiskReturns("1", nil)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).NotTo(HaveOccurred())
 			Expect(partitioner.PartitionDiskCallCount()).To(Equal(0))
@@ -654,7 +654,7 @@ var _ = Describe("WindowsPlatform", func() {
 			linker.LinkTargetReturns(fmt.Sprintf(`%s:\`, driveLetter), nil)
 			partitioner.GetCountOnDiskReturns("1", nil)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).NotTo(HaveOccurred())
 			Expect(partitioner.GetCountOnDiskCallCount()).To(Equal(1))
@@ -666,7 +666,7 @@ var _ = Describe("WindowsPlatform", func() {
 			partitioner.GetFreeSpaceOnDiskReturns(0, nil)
 			linker.LinkTargetReturns(fmt.Sprintf(`%s:\`, driveLetter), nil)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).NotTo(HaveOccurred())
 			Consistently(logBuffer).ShouldNot(gbytes.Say(
@@ -678,7 +678,7 @@ var _ = Describe("WindowsPlatform", func() {
 		It("logs a warning and doesn't create a partition if there is less than 1MB of free disk space", func() {
 			partitioner.GetFreeSpaceOnDiskReturns((1024*1024)-1, nil)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).NotTo(HaveOccurred())
 			Eventually(logBuffer).Should(gbytes.Say(
@@ -690,7 +690,7 @@ var _ = Describe("WindowsPlatform", func() {
 		It("returns an error when Protect-Path cmdlet is missing", func() {
 			protector.CommandExistsReturns(false)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 			Expect(err).To(MatchError(
 				fmt.Sprintf("cannot protect %s. %s cmd does not exist", dataDir, disk.ProtectCmdlet),
 			))
@@ -700,7 +700,7 @@ var _ = Describe("WindowsPlatform", func() {
 			expectedError := errors.New("it went wrong")
 			partitioner.GetFreeSpaceOnDiskReturns(0, expectedError)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).To(Equal(expectedError))
 		})
@@ -712,7 +712,7 @@ var _ = Describe("WindowsPlatform", func() {
 			partitionCountError := errors.New("something failed")
 			partitioner.GetCountOnDiskReturns("", partitionCountError)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).To(Equal(partitionCountError))
 		})
@@ -724,7 +724,7 @@ var _ = Describe("WindowsPlatform", func() {
 			initializeDiskError := errors.New("it went wrong")
 			partitioner.InitializeDiskReturns(initializeDiskError)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).To(Equal(initializeDiskError))
 		})
@@ -733,7 +733,7 @@ var _ = Describe("WindowsPlatform", func() {
 			linkTargetError := errors.New("failure")
 			linker.LinkTargetReturns("", linkTargetError)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).To(Equal(linkTargetError))
 		})
@@ -742,7 +742,7 @@ var _ = Describe("WindowsPlatform", func() {
 			partitionDiskError := errors.New("it went wrong")
 			partitioner.PartitionDiskReturns("", partitionDiskError)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).To(Equal(partitionDiskError))
 		})
@@ -751,7 +751,7 @@ var _ = Describe("WindowsPlatform", func() {
 			formatError := errors.New("A failure occurred")
 			formatter.FormatReturns(formatError)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).To(Equal(formatError))
 		})
@@ -760,7 +760,7 @@ var _ = Describe("WindowsPlatform", func() {
 			assignDriveLetterError := errors.New("failure")
 			partitioner.AssignDriveLetterReturns("", assignDriveLetterError)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).To(Equal(assignDriveLetterError))
 		})
@@ -769,7 +769,7 @@ var _ = Describe("WindowsPlatform", func() {
 			LinkError := errors.New("it went wrong")
 			linker.LinkReturns(LinkError)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).To(Equal(LinkError))
 		})
@@ -778,7 +778,7 @@ var _ = Describe("WindowsPlatform", func() {
 			protectPathError := errors.New("failure")
 			protector.ProtectPathReturns(protectPathError)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).To(Equal(protectPathError))
 		})
@@ -800,7 +800,7 @@ var _ = Describe("WindowsPlatform", func() {
 				diskManager,
 			)
 
-			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix)
+			err := platform.SetupEphemeralDiskWithPath(diskNumber, nil, labelPrefix, boshsettings.DiskEncryption{})
 
 			Expect(err).NotTo(HaveOccurred())
 			Consistently(logBuffer).ShouldNot(gbytes.Say(
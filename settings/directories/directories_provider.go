@@ -58,6 +58,14 @@ func (p Provider) MonitDir() string {
 	return filepath.Join(p.BaseDir(), "monit")
 }
 
+func (p Provider) SystemdJobsDir() string {
+	return filepath.Join(p.BaseDir(), "systemd", "job")
+}
+
+func (p Provider) SystemdDir() string {
+	return filepath.Join(p.BaseDir(), "systemd")
+}
+
 func (p Provider) JobsDir() string {
 	return filepath.Join(p.BaseDir(), "jobs")
 }
@@ -121,3 +129,15 @@ func (p Provider) BlobsDir() string {
 func (p Provider) SensitiveBlobsDir() string {
 	return filepath.Join(p.DataDir(), "sensitive_blobs")
 }
+
+func (p Provider) BlobCacheDir() string {
+	return filepath.Join(p.DataDir(), "blob_cache")
+}
+
+func (p Provider) LogForwarderDir() string {
+	return filepath.Join(p.DataDir(), "log_forwarder")
+}
+
+func (p Provider) AdminSocketPath() string {
+	return filepath.Join(p.BoshDir(), "admin.sock")
+}
@@ -28,6 +28,8 @@ var _ = Describe("Provider", func() {
 		Entry("CompileDir()", p.CompileDir(), "/some/dir/data/compile"),
 		Entry("MonitJobsDir()", p.MonitJobsDir(), "/some/dir/monit/job"),
 		Entry("MonitDir()", p.MonitDir(), "/some/dir/monit"),
+		Entry("SystemdJobsDir()", p.SystemdJobsDir(), "/some/dir/systemd/job"),
+		Entry("SystemdDir()", p.SystemdDir(), "/some/dir/systemd"),
 		Entry("JobsDir()", p.JobsDir(), "/some/dir/jobs"),
 		Entry("DataJobsDir()", p.DataJobsDir(), "/some/dir/data/jobs"),
 		Entry("JobBinDir(jobName)", p.JobBinDir("myJob"), "/some/dir/jobs/myJob/bin"),
@@ -84,6 +84,21 @@ type DiskSettings struct {
 	MountOptions   []string
 
 	Partitioner string
+
+	Encryption DiskEncryption
+}
+
+// DiskEncryption describes whether a disk is encrypted with LUKS
+// and where to find its key. Key takes precedence over KeyPath.
+type DiskEncryption struct {
+	Enabled bool
+	Key     string
+	KeyPath string
+}
+
+// String keeps encryption keys out of logs which print disk settings
+func (e DiskEncryption) String() string {
+	return fmt.Sprintf("{Enabled:%t KeyPath:%s}", e.Enabled, e.KeyPath)
 }
 
 type ISCSISettings struct {
@@ -142,6 +157,8 @@ func (s Settings) EphemeralDiskSettings() DiskSettings {
 		}
 	}
 
+	diskSettings.Encryption = s.Env.Bosh.DiskEncryption.ephemeralDiskEncryption()
+
 	return diskSettings
 }
 
@@ -177,6 +194,10 @@ func (s Settings) GetBlobstore() Blobstore {
 	return s.Blobstore
 }
 
+func (s Settings) GetLogForwarding() LogForwarding {
+	return s.Env.Bosh.LogForwarding
+}
+
 func (s Settings) GetNtpServers() []string {
 	if len(s.Env.Bosh.NTP) > 0 {
 		return s.Env.Bosh.NTP
@@ -230,6 +251,7 @@ func (s Settings) populatePersistentDiskSettings(diskID string, settingsInfo int
 	diskSettings.FileSystemType = s.Env.PersistentDiskFS
 	diskSettings.MountOptions = s.Env.PersistentDiskMountOptions
 	diskSettings.Partitioner = s.Env.PersistentDiskPartitioner
+	diskSettings.Encryption = s.Env.Bosh.DiskEncryption.persistentDiskEncryption()
 
 	return diskSettings
 }
@@ -293,6 +315,70 @@ type BoshEnv struct {
 	Blobstores            []Blobstore `json:"blobstores"`
 	NTP                   []string    `json:"ntp"`
 	Parallel              *int        `json:"parallel"`
+
+	ReproduciblePackages ReproduciblePackages `json:"reproducible_packages"`
+	CompilationSandbox   CompilationSandbox   `json:"compilation_sandbox"`
+	DiskEncryption       DiskEncryptionEnv    `json:"disk_encryption"`
+	LogForwarding        LogForwarding        `json:"log_forwarding"`
+}
+
+type ReproduciblePackages struct {
+	Enabled bool `json:"enabled"`
+
+	// Seconds since Unix epoch used as timestamp of packaged files
+	SourceDateEpoch int64 `json:"source_date_epoch"`
+}
+
+// CompilationSandbox requires cgroup v2 and is ignored on Windows
+type CompilationSandbox struct {
+	Enabled bool `json:"enabled"`
+
+	// Limits are not applied when zero
+	CPUs     float64 `json:"cpus"`
+	MemoryMB uint64  `json:"memory_mb"`
+	MaxPids  uint64  `json:"max_pids"`
+}
+
+// DiskEncryptionEnv is ignored on Windows. Encryption only applies to
+// disks which are not formatted yet; existing unencrypted persistent
+// disks keep being mounted as is so that their data can be migrated.
+type DiskEncryptionEnv struct {
+	Persistent bool `json:"persistent"`
+	Ephemeral  bool `json:"ephemeral"`
+
+	// Key is used as passphrase if set; otherwise KeyPath refers to a
+	// key file present on the VM, e.g. placed there by the IaaS
+	Key     string `json:"key"`
+	KeyPath string `json:"key_path"`
+}
+
+func (e DiskEncryptionEnv) persistentDiskEncryption() DiskEncryption {
+	return DiskEncryption{Enabled: e.Persistent, Key: e.Key, KeyPath: e.KeyPath}
+}
+
+func (e DiskEncryptionEnv) ephemeralDiskEncryption() DiskEncryption {
+	return DiskEncryption{Enabled: e.Ephemeral, Key: e.Key, KeyPath: e.KeyPath}
+}
+
+// LogForwarding ships job logs as RFC 5424 syslog messages;
+// forwarding is disabled when no address is given
+type LogForwarding struct {
+	Address string `json:"address"`
+
+	// Transport is either "tcp" (default) or "tls"
+	Transport string `json:"transport"`
+
+	// CA verifies certificate of the syslog endpoint when using tls;
+	// system root certificates are used when empty
+	CA string `json:"ca"`
+
+	// Logs are buffered on disk up to this size while the endpoint is
+	// unreachable, oldest logs are dropped first; defaults to 100 MB
+	BufferSizeMB int64 `json:"buffer_size_mb"`
+}
+
+func (f LogForwarding) Enabled() bool {
+	return f.Address != ""
 }
 
 type AgentEnv struct {
@@ -368,6 +454,28 @@ type Network struct {
 	Routes        Routes `json:"routes,omitempty"`
 
 	Alias string `json:"alias,omitempty"`
+
+	// Bond, VLAN and Bridge are layered on top of the network's interface in that order
+	// and are only supported by network managers which can configure them (e.g. netplan)
+	Bond   *NetworkBond   `json:"bond,omitempty"`
+	VLAN   *NetworkVLAN   `json:"vlan,omitempty"`
+	Bridge *NetworkBridge `json:"bridge,omitempty"`
+}
+
+type NetworkBond struct {
+	Name string `json:"name"`
+	Mode string `json:"mode,omitempty"`
+
+	// MAC addresses of interfaces bonded together with the network's interface
+	Interfaces []string `json:"interfaces,omitempty"`
+}
+
+type NetworkVLAN struct {
+	ID int `json:"id"`
+}
+
+type NetworkBridge struct {
+	Name string `json:"name"`
 }
 
 type Networks map[string]Network
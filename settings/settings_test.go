@@ -2,6 +2,7 @@ package settings_test
 
 import (
 	"encoding/json"
+	"fmt"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
@@ -94,6 +95,26 @@ var _ = Describe("Settings", func() {
 					}))
 				})
 
+				It("gets disk encryption from env", func() {
+					settingsJSON := `{
+						"env": {
+							"bosh": {
+								"disk_encryption": {"persistent": true, "key": "fake-secret", "key_path": "/fake-key-path"}
+							}
+						}
+					}`
+
+					err := json.Unmarshal([]byte(settingsJSON), &settings)
+					Expect(err).NotTo(HaveOccurred())
+
+					diskSettings, _ := settings.PersistentDiskSettings("fake-disk-id")
+					Expect(diskSettings.Encryption).To(Equal(DiskEncryption{Enabled: true, Key: "fake-secret", KeyPath: "/fake-key-path"}))
+					Expect(settings.EphemeralDiskSettings().Encryption.Enabled).To(BeFalse())
+
+					Expect(fmt.Sprintf("%+v", diskSettings)).ToNot(ContainSubstring("fake-secret"))
+					Expect(fmt.Sprintf("%+v", diskSettings)).To(ContainSubstring("KeyPath:/fake-key-path"))
+				})
+
 				It("does not crash if env does not have a filesystem type", func() {
 					settingsJSON := `{"env": {"bosh": {"password": "secret"}}}`
 
@@ -857,6 +878,16 @@ var _ = Describe("Settings", func() {
     ],
     "swap_size": 2048,
     "parallel": 10,
+    "reproducible_packages": {
+      "enabled": true,
+      "source_date_epoch": 1577836800
+    },
+    "compilation_sandbox": {
+      "enabled": true,
+      "cpus": 1.5,
+      "memory_mb": 2048,
+      "max_pids": 512
+    },
 	"blobstores": [
 		{
 			"options": {
@@ -886,6 +917,8 @@ var _ = Describe("Settings", func() {
 			Expect(env.GetAuthorizedKeys()).To(ConsistOf("fake-key"))
 			Expect(*env.GetSwapSizeInBytes()).To(Equal(uint64(2048 * 1024 * 1024)))
 			Expect(*env.GetParallel()).To(Equal(10))
+			Expect(env.Bosh.ReproduciblePackages).To(Equal(ReproduciblePackages{Enabled: true, SourceDateEpoch: 1577836800}))
+			Expect(env.Bosh.CompilationSandbox).To(Equal(CompilationSandbox{Enabled: true, CPUs: 1.5, MemoryMB: 2048, MaxPids: 512}))
 			Expect(env.Bosh.Blobstores).To(Equal(
 				[](Blobstore){
 					Blobstore{
@@ -1125,6 +1158,27 @@ var _ = Describe("Settings", func() {
 					nil),
 			)
 		})
+
+		Context("#GetLogForwarding", func() {
+			It("parses log forwarding from env.bosh", func() {
+				var settings Settings
+				err := json.Unmarshal([]byte(`{"env": {"bosh": {"log_forwarding": {"address": "syslog.example.com:6514", "transport": "tls", "ca": "fake-ca", "buffer_size_mb": 10}}}}`), &settings)
+				Expect(err).NotTo(HaveOccurred())
+
+				logForwarding := settings.GetLogForwarding()
+				Expect(logForwarding).To(Equal(LogForwarding{
+					Address:      "syslog.example.com:6514",
+					Transport:    "tls",
+					CA:           "fake-ca",
+					BufferSizeMB: 10,
+				}))
+				Expect(logForwarding.Enabled()).To(BeTrue())
+			})
+
+			It("is disabled without an address", func() {
+				Expect(Settings{}.GetLogForwarding().Enabled()).To(BeFalse())
+			})
+		})
 	})
 
 	Describe("UpdateSettings", func() {
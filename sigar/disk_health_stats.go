@@ -0,0 +1,209 @@
+package sigar
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	procMountsPath    = "/proc/mounts"
+	procDiskStatsPath = "/proc/diskstats"
+	sysFsExt4Dir      = "/sys/fs/ext4"
+)
+
+type diskIOCounters struct {
+	Reads            uint64
+	ReadTimeMs       uint64
+	Writes           uint64
+	WriteTimeMs      uint64
+	WeightedIOTimeMs uint64
+}
+
+type diskIOSample struct {
+	Time     time.Time
+	Counters map[string]diskIOCounters
+}
+
+func (s *sigarStatsCollector) GetDiskHealthStats(mountedPath string) (boshstats.DiskHealthStats, error) {
+	var stats boshstats.DiskHealthStats
+
+	device, options, fsType, err := s.findMount(mountedPath)
+	if err != nil {
+		return stats, err
+	}
+
+	for _, option := range strings.Split(options, ",") {
+		if option == "ro" {
+			stats.ReadOnly = true
+		}
+	}
+
+	deviceName := s.deviceName(device)
+
+	switch fsType {
+	case "ext4":
+		errorsCount, err := s.fs.ReadFileString(path.Join(sysFsExt4Dir, deviceName, "errors_count"))
+		if err == nil {
+			stats.FilesystemErrors, _ = strconv.ParseUint(strings.TrimSpace(errorsCount), 10, 64)
+		}
+	case "xfs":
+		// Kernels older than 5.2 do not report xfs health, errors then only surface as read-only state
+		unhealthyCount, err := s.xfsUnhealthyMetadataCount(mountedPath)
+		if err == nil {
+			stats.FilesystemErrors = unhealthyCount
+		}
+	}
+
+	s.latestDiskIOStatsLock.RLock()
+	ioStats, found := s.latestDiskIOStats[deviceName]
+	s.latestDiskIOStatsLock.RUnlock()
+
+	if found {
+		stats.IO = &ioStats
+	}
+
+	stats.SMART = s.getSMARTStats(deviceName)
+
+	return stats, nil
+}
+
+func (s *sigarStatsCollector) collectDiskIOStats(collectionInterval time.Duration) {
+	previous, err := s.readDiskIOSample()
+
+	go func() {
+		ticker := time.NewTicker(collectionInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			latest, latestErr := s.readDiskIOSample()
+			if latestErr != nil {
+				continue
+			}
+
+			if err == nil {
+				s.latestDiskIOStatsLock.Lock()
+				s.latestDiskIOStats = diskIOStatsBetween(previous, latest)
+				s.latestDiskIOStatsLock.Unlock()
+			}
+
+			previous, err = latest, nil
+		}
+	}()
+}
+
+func (s *sigarStatsCollector) readDiskIOSample() (diskIOSample, error) {
+	sample := diskIOSample{Time: time.Now(), Counters: map[string]diskIOCounters{}}
+
+	contents, err := s.fs.ReadFileString(procDiskStatsPath)
+	if err != nil {
+		return sample, bosherr.WrapErrorf(err, "Reading %s", procDiskStatsPath)
+	}
+
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 14 {
+			continue
+		}
+
+		values := make([]uint64, len(fields))
+		for i := 3; i < len(fields); i++ {
+			values[i], _ = strconv.ParseUint(fields[i], 10, 64)
+		}
+
+		// https://www.kernel.org/doc/Documentation/ABI/testing/procfs-diskstats
+		sample.Counters[fields[2]] = diskIOCounters{
+			Reads:            values[3],
+			ReadTimeMs:       values[6],
+			Writes:           values[7],
+			WriteTimeMs:      values[10],
+			WeightedIOTimeMs: values[13],
+		}
+	}
+
+	return sample, nil
+}
+
+func (s *sigarStatsCollector) findMount(mountedPath string) (device, options, fsType string, err error) {
+	contents, err := s.fs.ReadFileString(procMountsPath)
+	if err != nil {
+		return "", "", "", bosherr.WrapErrorf(err, "Reading %s", procMountsPath)
+	}
+
+	found := false
+
+	// Last matching entry wins since it is the one mounted on top
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[1] != mountedPath {
+			continue
+		}
+
+		device, fsType, options, found = fields[0], fields[2], fields[3], true
+	}
+
+	if !found {
+		return "", "", "", bosherr.Errorf("Finding mount for '%s'", mountedPath)
+	}
+
+	return device, options, fsType, nil
+}
+
+// deviceName resolves device mapper and by-id symlinks to kernel device name
+func (s *sigarStatsCollector) deviceName(device string) string {
+	resolvedDevice, err := s.fs.ReadAndFollowLink(device)
+	if err != nil {
+		return path.Base(device)
+	}
+
+	return path.Base(resolvedDevice)
+}
+
+func diskIOStatsBetween(previous, latest diskIOSample) map[string]boshstats.DiskIOStats {
+	ioStats := map[string]boshstats.DiskIOStats{}
+
+	elapsedMs := latest.Time.Sub(previous.Time).Seconds() * 1000
+	if elapsedMs <= 0 {
+		return ioStats
+	}
+
+	for name, latestCounters := range latest.Counters {
+		previousCounters, found := previous.Counters[name]
+		if !found {
+			continue
+		}
+
+		reads := counterDelta(previousCounters.Reads, latestCounters.Reads)
+		writes := counterDelta(previousCounters.Writes, latestCounters.Writes)
+
+		stats := boshstats.DiskIOStats{
+			ReadIOPS:   float64(reads) * 1000 / elapsedMs,
+			WriteIOPS:  float64(writes) * 1000 / elapsedMs,
+			QueueDepth: float64(counterDelta(previousCounters.WeightedIOTimeMs, latestCounters.WeightedIOTimeMs)) / elapsedMs,
+		}
+
+		if reads > 0 {
+			stats.ReadLatencyMs = float64(counterDelta(previousCounters.ReadTimeMs, latestCounters.ReadTimeMs)) / float64(reads)
+		}
+
+		if writes > 0 {
+			stats.WriteLatencyMs = float64(counterDelta(previousCounters.WriteTimeMs, latestCounters.WriteTimeMs)) / float64(writes)
+		}
+
+		ioStats[name] = stats
+	}
+
+	return ioStats
+}
+
+// counterDelta treats wrapped or reset counters as no activity
+func counterDelta(previous, latest uint64) uint64 {
+	if latest < previous {
+		return 0
+	}
+	return latest - previous
+}
@@ -0,0 +1,149 @@
+package sigar
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"time"
+
+	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
+)
+
+const (
+	sysBlockDir = "/sys/block"
+
+	// SMART attributes change slowly and querying them may wake up idle disks
+	smartStatsMaxAge = 10 * time.Minute
+
+	ataReallocatedSectorCountID    = 5
+	ataCurrentPendingSectorCountID = 197
+	ataOfflineUncorrectableID      = 198
+)
+
+type smartStatsResult struct {
+	Time  time.Time
+	Stats *boshstats.DiskSMARTStats
+}
+
+type smartctlOutput struct {
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+
+	Temperature struct {
+		Current int64 `json:"current"`
+	} `json:"temperature"`
+
+	ATASmartAttributes struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+
+	NVMeSmartHealthInformationLog struct {
+		MediaErrors uint64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// getSMARTStats returns SMART health of every disk backing the device,
+// skipping disks which do not support SMART such as most virtual disks
+func (s *sigarStatsCollector) getSMARTStats(deviceName string) []boshstats.DiskSMARTStats {
+	stats := []boshstats.DiskSMARTStats{}
+
+	for _, disk := range s.backingDisks(deviceName) {
+		diskStats := s.diskSMARTStats(disk)
+		if diskStats != nil {
+			stats = append(stats, *diskStats)
+		}
+	}
+
+	return stats
+}
+
+// backingDisks resolves device mapper devices and partitions to whole disks
+func (s *sigarStatsCollector) backingDisks(deviceName string) []string {
+	slaves, err := s.fs.Glob(path.Join(sysBlockDir, deviceName, "slaves", "*"))
+	if err == nil && len(slaves) > 0 {
+		disks := []string{}
+		for _, slave := range slaves {
+			disks = append(disks, s.backingDisks(path.Base(slave))...)
+		}
+		return uniqueSorted(disks)
+	}
+
+	if s.fs.FileExists(path.Join(sysBlockDir, deviceName)) {
+		return []string{deviceName}
+	}
+
+	// Partitions are listed inside directory of their disk
+	partitions, err := s.fs.Glob(path.Join(sysBlockDir, "*", deviceName))
+	if err == nil && len(partitions) > 0 {
+		return []string{path.Base(path.Dir(partitions[0]))}
+	}
+
+	return []string{}
+}
+
+func (s *sigarStatsCollector) diskSMARTStats(disk string) *boshstats.DiskSMARTStats {
+	s.latestSMARTStatsLock.Lock()
+	defer s.latestSMARTStatsLock.Unlock()
+
+	result, found := s.latestSMARTStats[disk]
+	if found && time.Since(result.Time) < smartStatsMaxAge {
+		return result.Stats
+	}
+
+	result = smartStatsResult{Time: time.Now(), Stats: s.querySMARTStats(disk)}
+	s.latestSMARTStats[disk] = result
+
+	return result.Stats
+}
+
+// querySMARTStats ignores exit status of smartctl since it is a bit mask
+// which is also non-zero when disk is failing or has logged errors
+func (s *sigarStatsCollector) querySMARTStats(disk string) *boshstats.DiskSMARTStats {
+	stdout, _, _, _ := s.cmdRunner.RunCommandQuietly("smartctl", "-H", "-A", "--json", path.Join("/dev", disk))
+
+	var output smartctlOutput
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil || output.SmartStatus == nil {
+		return nil
+	}
+
+	stats := &boshstats.DiskSMARTStats{
+		Device:             disk,
+		Passed:             output.SmartStatus.Passed,
+		MediaErrors:        output.NVMeSmartHealthInformationLog.MediaErrors,
+		TemperatureCelsius: output.Temperature.Current,
+	}
+
+	for _, attribute := range output.ATASmartAttributes.Table {
+		switch attribute.ID {
+		case ataReallocatedSectorCountID:
+			stats.ReallocatedSectors = attribute.Raw.Value
+		case ataCurrentPendingSectorCountID:
+			stats.PendingSectors = attribute.Raw.Value
+		case ataOfflineUncorrectableID:
+			stats.MediaErrors = attribute.Raw.Value
+		}
+	}
+
+	return stats
+}
+
+func uniqueSorted(values []string) []string {
+	unique := map[string]bool{}
+	for _, value := range values {
+		unique[value] = true
+	}
+
+	sorted := []string{}
+	for value := range unique {
+		sorted = append(sorted, value)
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
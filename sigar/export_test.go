@@ -0,0 +1,9 @@
+package sigar
+
+import (
+	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
+)
+
+func SetXFSUnhealthyMetadataCount(collector boshstats.Collector, xfsHealth func(string) (uint64, error)) {
+	collector.(*sigarStatsCollector).xfsUnhealthyMetadataCount = xfsHealth
+}
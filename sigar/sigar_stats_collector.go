@@ -8,17 +8,34 @@ import (
 
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
 type sigarStatsCollector struct {
 	statsSigar         sigar.Sigar
 	latestCPUStats     boshstats.CPUStats
 	latestCPUStatsLock sync.RWMutex
+
+	fs                    boshsys.FileSystem
+	latestDiskIOStats     map[string]boshstats.DiskIOStats
+	latestDiskIOStatsLock sync.RWMutex
+
+	cmdRunner            boshsys.CmdRunner
+	latestSMARTStats     map[string]smartStatsResult
+	latestSMARTStatsLock sync.Mutex
+
+	xfsUnhealthyMetadataCount func(mountedPath string) (uint64, error)
 }
 
-func NewSigarStatsCollector(sigar sigar.Sigar) boshstats.Collector {
+func NewSigarStatsCollector(sigar sigar.Sigar, fs boshsys.FileSystem, cmdRunner boshsys.CmdRunner) boshstats.Collector {
 	return &sigarStatsCollector{
-		statsSigar: sigar,
+		statsSigar:        sigar,
+		fs:                fs,
+		latestDiskIOStats: map[string]boshstats.DiskIOStats{},
+		cmdRunner:         cmdRunner,
+		latestSMARTStats:  map[string]smartStatsResult{},
+
+		xfsUnhealthyMetadataCount: xfsUnhealthyMetadataCount,
 	}
 }
 
@@ -40,6 +57,8 @@ func (s *sigarStatsCollector) StartCollecting(collectionInterval time.Duration,
 			}
 		}
 	}()
+
+	s.collectDiskIOStats(collectionInterval)
 }
 
 func (s *sigarStatsCollector) GetCPULoad() (load boshstats.CPULoad, err error) {
@@ -1,6 +1,7 @@
 package sigar_test
 
 import (
+	"errors"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -10,17 +11,23 @@ import (
 	boshsigar "github.com/cloudfoundry/bosh-agent/sigar"
 	sigar "github.com/cloudfoundry/gosigar"
 	fakesigar "github.com/cloudfoundry/gosigar/fakes"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
 var _ = Describe("sigarStatsCollector", func() {
 	var (
 		collector Collector
 		fakeSigar *fakesigar.FakeSigar
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
 	)
 
 	BeforeEach(func() {
 		fakeSigar = fakesigar.NewFakeSigar()
-		collector = boshsigar.NewSigarStatsCollector(fakeSigar)
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		collector = boshsigar.NewSigarStatsCollector(fakeSigar, fs, cmdRunner)
 	})
 
 	Describe("GetCPULoad", func() {
@@ -135,4 +142,139 @@ var _ = Describe("sigarStatsCollector", func() {
 			Expect(stats.InodeUsage.Used).To(Equal(uint64(400)))
 		})
 	})
+
+	Describe("GetDiskHealthStats", func() {
+		BeforeEach(func() {
+			Expect(fs.WriteFileString("/proc/mounts", `/dev/sda1 / ext4 rw,relatime 0 0
+/dev/mapper/bosh-persistent /var/vcap/store ext4 rw,relatime 0 0
+/dev/sdb2 /var/vcap/data xfs ro,relatime 0 0
+`)).To(Succeed())
+			Expect(fs.WriteFileString("/dev/dm-0", "")).To(Succeed())
+			Expect(fs.Symlink("/dev/dm-0", "/dev/mapper/bosh-persistent")).To(Succeed())
+			Expect(fs.WriteFileString("/sys/fs/ext4/dm-0/errors_count", "3\n")).To(Succeed())
+			Expect(fs.WriteFileString("/proc/diskstats", "   8       1 sda1 10 0 80 20 5 0 40 50 0 0 70 0 0 0 0\n 253       0 dm-0 100 0 800 200 50 0 400 500 0 0 700 0 0 0 0\n")).To(Succeed())
+		})
+
+		It("returns filesystem errors and read-only state of the mounted device", func() {
+			stats, err := collector.GetDiskHealthStats("/var/vcap/store")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.FilesystemErrors).To(Equal(uint64(3)))
+			Expect(stats.ReadOnly).To(BeFalse())
+			Expect(stats.IO).To(BeNil())
+
+			stats, err = collector.GetDiskHealthStats("/var/vcap/data")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.FilesystemErrors).To(Equal(uint64(0)))
+			Expect(stats.ReadOnly).To(BeTrue())
+		})
+
+		It("returns unhealthy metadata count of xfs filesystems as filesystem errors", func() {
+			boshsigar.SetXFSUnhealthyMetadataCount(collector, func(mountedPath string) (uint64, error) {
+				Expect(mountedPath).To(Equal("/var/vcap/data"))
+				return 2, nil
+			})
+
+			stats, err := collector.GetDiskHealthStats("/var/vcap/data")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.FilesystemErrors).To(Equal(uint64(2)))
+			Expect(stats.ReadOnly).To(BeTrue())
+		})
+
+		It("returns no filesystem errors when xfs health cannot be determined", func() {
+			boshsigar.SetXFSUnhealthyMetadataCount(collector, func(string) (uint64, error) {
+				return 0, errors.New("fake-ioctl-error")
+			})
+
+			stats, err := collector.GetDiskHealthStats("/var/vcap/data")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.FilesystemErrors).To(Equal(uint64(0)))
+		})
+
+		It("returns io stats averaged over collection interval", func() {
+			collector.StartCollecting(100*time.Millisecond, nil)
+
+			Expect(fs.WriteFileString("/proc/diskstats", " 253       0 dm-0 110 0 880 240 70 0 560 540 1 0 780 900 0 0 0\n")).To(Succeed())
+
+			var stats DiskHealthStats
+			Eventually(func() *DiskIOStats {
+				stats, _ = collector.GetDiskHealthStats("/var/vcap/store")
+				return stats.IO
+			}).ShouldNot(BeNil())
+
+			Expect(stats.IO.ReadIOPS).To(BeNumerically(">", 0))
+			Expect(stats.IO.WriteIOPS).To(BeNumerically(">", stats.IO.ReadIOPS))
+			Expect(stats.IO.ReadLatencyMs).To(Equal(float64(4)))
+			Expect(stats.IO.WriteLatencyMs).To(Equal(float64(2)))
+			Expect(stats.IO.QueueDepth).To(BeNumerically(">", 0))
+		})
+
+		It("returns SMART health of disks backing the mounted device", func() {
+			fs.SetGlob("/sys/block/dm-0/slaves/*", []string{"/sys/block/dm-0/slaves/sdc1"})
+			fs.SetGlob("/sys/block/*/sdc1", []string{"/sys/block/sdc/sdc1"})
+
+			// smartctl exit status has bit 3 set when disk is failing
+			cmdRunner.AddCmdResult("smartctl -H -A --json /dev/sdc", fakesys.FakeCmdResult{
+				Stdout: `{
+  "smart_status": {"passed": false},
+  "temperature": {"current": 41},
+  "ata_smart_attributes": {"table": [
+    {"id": 5, "name": "Reallocated_Sector_Ct", "raw": {"value": 12}},
+    {"id": 197, "name": "Current_Pending_Sector", "raw": {"value": 3}},
+    {"id": 198, "name": "Offline_Uncorrectable", "raw": {"value": 1}}
+  ]}
+}`,
+				ExitStatus: 8,
+				Error:      errors.New("fake-exit-status-error"),
+			})
+
+			stats, err := collector.GetDiskHealthStats("/var/vcap/store")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.SMART).To(Equal([]DiskSMARTStats{{
+				Device:             "sdc",
+				Passed:             false,
+				ReallocatedSectors: 12,
+				PendingSectors:     3,
+				MediaErrors:        1,
+				TemperatureCelsius: 41,
+			}}))
+		})
+
+		It("returns SMART health of nvme disks", func() {
+			fs.SetGlob("/sys/block/*/sda1", []string{"/sys/block/sda/sda1"})
+			cmdRunner.AddCmdResult("smartctl -H -A --json /dev/sda", fakesys.FakeCmdResult{
+				Stdout: `{"smart_status": {"passed": true}, "temperature": {"current": 35}, "nvme_smart_health_information_log": {"media_errors": 2}}`,
+			})
+
+			stats, err := collector.GetDiskHealthStats("/")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.SMART).To(Equal([]DiskSMARTStats{{
+				Device:             "sda",
+				Passed:             true,
+				MediaErrors:        2,
+				TemperatureCelsius: 35,
+			}}))
+		})
+
+		It("omits SMART health of disks which do not support it and does not query them again", func() {
+			Expect(fs.WriteFileString("/sys/block/sdb/size", "")).To(Succeed())
+			fs.SetGlob("/sys/block/*/sdb2", []string{"/sys/block/sdb/sdb2"})
+			cmdRunner.AddCmdResult("smartctl -H -A --json /dev/sdb", fakesys.FakeCmdResult{
+				Stdout: `{"smartctl": {"exit_status": 4}}`,
+				Error:  errors.New("fake-exit-status-error"),
+			})
+
+			stats, err := collector.GetDiskHealthStats("/var/vcap/data")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.SMART).To(BeEmpty())
+
+			_, err = collector.GetDiskHealthStats("/var/vcap/data")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommandsQuietly).To(Equal([][]string{{"smartctl", "-H", "-A", "--json", "/dev/sdb"}}))
+		})
+
+		It("returns an error when path is not mounted", func() {
+			_, err := collector.GetDiskHealthStats("/fake-mount-path")
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,45 @@
+//go:build linux
+// +build linux
+
+package sigar
+
+import (
+	"math/bits"
+	"os"
+	"syscall"
+	"unsafe"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	// XFS_IOC_FSGEOMETRY, i.e. _IOR('X', 126, struct xfs_fsop_geom)
+	xfsIocFSGeometry = 0x8100587e
+
+	xfsFSGeometrySize = 256
+
+	// Offset of 'sick' mask in struct xfs_fsop_geom (v5)
+	xfsFSGeometrySickOffset = 112
+)
+
+// xfsUnhealthyMetadataCount returns number of filesystem wide metadata structures
+// that xfs found corrupt at runtime or while scrubbing. xfs keeps no error counters
+// in /sys/fs/xfs/<dev>/stats, so its health mask is the closest equivalent to ext4 errors_count.
+func xfsUnhealthyMetadataCount(mountedPath string) (uint64, error) {
+	file, err := os.Open(mountedPath)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Opening '%s'", mountedPath)
+	}
+	defer file.Close()
+
+	var geometry [xfsFSGeometrySize]byte
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), xfsIocFSGeometry, uintptr(unsafe.Pointer(&geometry[0])))
+	if errno != 0 {
+		return 0, bosherr.WrapErrorf(errno, "Getting xfs geometry of '%s'", mountedPath)
+	}
+
+	sick := *(*uint32)(unsafe.Pointer(&geometry[xfsFSGeometrySickOffset]))
+
+	return uint64(bits.OnesCount32(sick)), nil
+}
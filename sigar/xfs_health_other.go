@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package sigar
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+func xfsUnhealthyMetadataCount(mountedPath string) (uint64, error) {
+	return 0, bosherr.Errorf("Getting xfs health of '%s' is not supported", mountedPath)
+}